@@ -17,7 +17,6 @@ package anansi
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"sync"
 
@@ -26,15 +25,13 @@ import (
 	pevents "github.com/asaidimu/go-anansi/v8/core/persistence/events"
 	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
 	"github.com/asaidimu/go-anansi/v8/core/query"
-	"github.com/asaidimu/go-anansi/v8/core/query/native"
 	"github.com/asaidimu/go-anansi/v8/core/data"
 	"github.com/asaidimu/go-anansi/v8/core/sanitize"
 	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
 	putils "github.com/asaidimu/go-anansi/v8/core/persistence/utils"
 	"github.com/asaidimu/go-anansi/v8/utils"
-	sqliteExecutor "github.com/asaidimu/go-anansi/v8/sqlite/executor"
-	sqliteQuery "github.com/asaidimu/go-anansi/v8/sqlite/query"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/asaidimu/go-anansi/v8/sqlite"
 	"go.uber.org/zap"
 )
 
@@ -61,7 +58,8 @@ var (
 // Anansi deployment.
 type SetupConfig struct {
 	// Interactor is the concrete database implementation (SQLite, PostgreSQL,
-	// MySQL, …).  It must satisfy query.DatabaseInteractor.
+	// MySQL, …).  It must satisfy query.DatabaseInteractor.  For SQLite use
+	// sqlite.NewInteractor, which sets production pragmas and pools readers.
 	Interactor query.DatabaseInteractor
 
 	// Logger is a *zap.Logger used throughout the framework.  Production
@@ -81,6 +79,11 @@ type SetupConfig struct {
 
 	// Schemas are created automatically on first start if they do not exist.
 	Schemas []*definition.Schema
+
+	// Telemetry receives spans and metrics for collection operations, query
+	// stages, SQL statements and migrations.  The zero value records nothing;
+	// see the telemetry package for the log and in-memory adapters.
+	Telemetry telemetry.Telemetry
 }
 
 // Setup builds the persistence layer.  It is safe to call multiple times –
@@ -109,6 +112,7 @@ func Setup(config SetupConfig) (base.Persistence, error) {
 			config.EventBus,
 			config.Logger,
 			config.Decorators,
+			persistence.WithTelemetry(config.Telemetry),
 		)
 		if err != nil {
 			setupError = err
@@ -152,9 +156,13 @@ type PlaygroundConfig struct {
 	// code should supply a configured logger (JSON, stackdriver, etc.).
 	Logger *zap.Logger
 
-	// DBPath is the SQLite DSN.
+	// DBPath is the SQLite database path, passed to sqlite.NewInteractor as-is.
 	//   * ":memory:"  -> in-memory (default)
 	//   * "file.db"   -> persistent file on disk
+	// Playground no longer wraps the path in a "file:...?cache=shared&_fk=1"
+	// DSN: an in-memory database is private to its one connection rather than
+	// shared, and foreign keys are enforced by sqlite.Options. A "file:" URI
+	// may carry parameters of its own.
 	DBPath string
 
 	// EnableLogging turns on zap.NewDevelopment() output.
@@ -236,31 +244,13 @@ func Playground(cfg PlaygroundConfig) (base.Persistence, func(), error) {
 	// -----------------------------------------------------------------
 	//  Database
 	// -----------------------------------------------------------------
-	dsn := cfg.DBPath
-	if cfg.DBPath != ":memory:" {
-		dsn = fmt.Sprintf("file:%s?cache=shared&_fk=1", cfg.DBPath)
-	}
-
-	db, err := sql.Open("sqlite3", dsn)
-	if err != nil {
-		return nil, func() {}, err
-	}
-
-	executor, err := sqliteExecutor.NewSQLiteExecutor(db, logger)
-	if err != nil {
-		db.Close()
-		return nil, func() {}, err
-	}
-
-	queryFactory := sqliteQuery.NewSQLiteFactory(logger)
-	interactor, err := native.NewNativeInteractor(executor, queryFactory, logger)
+	interactor, closeDB, err := sqlite.NewInteractor(sqlite.Options{Path: cfg.DBPath}, logger)
 	if err != nil {
-		db.Close()
 		return nil, func() {}, err
 	}
 
 	cleanup := func() {
-		_ = db.Close()
+		_ = closeDB()
 		if busCleanup != nil {
 			busCleanup()
 		}
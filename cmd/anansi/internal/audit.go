@@ -0,0 +1,57 @@
+package schemagen
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/asaidimu/go-anansi/v8/core/persistence/audit"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+)
+
+// RunAuditVerify verifies the audit chains stored in the database at dbPath.
+// With no collections given, every audited collection is verified.
+func RunAuditVerify(dbPath, logCollection string, collections []string) error {
+	p, closeDB, err := OpenDatabase(dbPath)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	return VerifyAudit(context.Background(), p, logCollection, collections)
+}
+
+// VerifyAudit prints a line per verified chain and returns an error when any
+// chain fails verification.
+func VerifyAudit(ctx context.Context, p base.BasePersistence, logCollection string, collections []string) error {
+	verifier := audit.NewVerifier(p, logCollection)
+	if len(collections) == 0 {
+		var err error
+		if collections, err = verifier.Collections(ctx); err != nil {
+			return fmt.Errorf("list audited collections: %w", err)
+		}
+		if len(collections) == 0 {
+			fmt.Println("no audit entries found")
+			return nil
+		}
+	}
+
+	failed := 0
+	for _, collection := range collections {
+		report, err := verifier.Verify(ctx, collection)
+		if err != nil {
+			return fmt.Errorf("verify %s: %w", collection, err)
+		}
+		if report.Valid() {
+			fmt.Printf("ok      %s: %d entries, head %s\n", collection, report.Entries, report.Head)
+			continue
+		}
+		failed++
+		fmt.Printf("FAILED  %s: %d entries, %d issues\n", collection, report.Entries, len(report.Issues))
+		for _, issue := range report.Issues {
+			fmt.Printf("  %s %s: %s\n", issue.Code, issue.Path, issue.Message)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d audit chains failed verification", failed, len(collections))
+	}
+	return nil
+}
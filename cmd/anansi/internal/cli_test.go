@@ -549,3 +549,54 @@ func TestRunNewSchema_ValidJSON(t *testing.T) {
 	_, err = definition.FromJSON(raw)
 	require.NoError(t, err, "schema file should contain valid JSON")
 }
+
+func TestRunSchemaExportImport(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "blog.schema.json")
+	require.NoError(t, os.WriteFile(src, []byte(`{"name":"Blog","version":"1.0.0","fields":{"title":{"name":"title","type":"string","required":true}}}`), 0644))
+
+	exported := filepath.Join(dir, "json", "blog.json")
+	require.NoError(t, RunSchemaExport(src, exported))
+	raw, err := os.ReadFile(exported)
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	require.Equal(t, "Blog", doc["title"])
+
+	out := filepath.Join(dir, "imported")
+	require.NoError(t, RunSchemaImport(exported, "Post", out, false))
+	raw, err = os.ReadFile(filepath.Join(out, "Post.schema.json"))
+	require.NoError(t, err)
+	s, err := definition.FromJSON(raw)
+	require.NoError(t, err)
+	require.Equal(t, "Post", s.Name)
+}
+
+func TestRunSchemaImport_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "doc.json")
+	require.NoError(t, os.WriteFile(src, []byte(`{"title":"Note","type":"object","properties":{"body":{"type":"string"}}}`), 0644))
+
+	out := filepath.Join(dir, "empty")
+	require.NoError(t, RunSchemaImport(src, "", out, true))
+	require.NoDirExists(t, out)
+}
+
+func TestRunOpenAPIGen(t *testing.T) {
+	dir := t.TempDir()
+	s := `{"name":"notes","version":"1.0.0","fields":{"019d7775-6563-7c55-a6f3-ac8f087d89d1":{"name":"title","type":"string"}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.schema.json"), []byte(s), 0644))
+
+	out := filepath.Join(dir, "openapi.json")
+	err := RunOpenAPIGen(&Config{
+		Schema:  SchemaConfig{Glob: filepath.Join(dir, "*.schema.json")},
+		OpenAPI: OpenAPIConfig{Out: out, BasePath: "/v1"},
+	}, false)
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	require.Contains(t, doc["paths"], "/v1/notes/{id}")
+}
@@ -13,8 +13,17 @@ import (
 type Config struct {
 	Schema   SchemaConfig   `json:"schema"`
 	TSGen    TSGenConfig    `json:"tsgen"`
+	OpenAPI  OpenAPIConfig  `json:"openapi"`
 	GoGen    GoGenConfig    `json:"gogen"`
 	Metadata MetadataConfig `json:"metadata"`
+	Database DatabaseConfig `json:"database"`
+}
+
+// DatabaseConfig locates the database used by commands that operate on live
+// data, such as `anansi audit verify`.
+type DatabaseConfig struct {
+	// Path is the SQLite database file.
+	Path string `json:"path"`
 }
 
 type SchemaConfig struct {
@@ -25,6 +34,22 @@ type SchemaConfig struct {
 
 type TSGenConfig struct {
 	Out string `json:"out"`
+	// Validators, QueryBuilder and Client enable the optional sections of
+	// the generated file; see typescript.GeneratorConfig.
+	Validators   bool `json:"validators"`
+	QueryBuilder bool `json:"query_builder"`
+	Client       bool `json:"client"`
+	// BasePath is the REST prefix the generated client prepends.
+	BasePath string `json:"base_path"`
+}
+
+// OpenAPIConfig configures `anansi codegen openapi`.
+type OpenAPIConfig struct {
+	Out string `json:"out"`
+	// BasePath prefixes every path; it must match the prefix the REST
+	// handler is mounted under.
+	BasePath string `json:"base_path"`
+	Title    string `json:"title"`
 }
 
 // MetadataConfig configures the declarative user-defined metadata source and
@@ -125,6 +150,9 @@ func DefaultConfig() *Config {
 		TSGen: TSGenConfig{
 			Out: "types.ts",
 		},
+		OpenAPI: OpenAPIConfig{
+			Out: "openapi.json",
+		},
 		Metadata: MetadataConfig{
 			SchemaPath: "metadata.schema.json",
 			OutDir:     "",
@@ -161,6 +189,21 @@ func LoadConfig(path string) (*Config, error) {
 	if fileCfg.TSGen.Out != "" {
 		cfg.TSGen.Out = fileCfg.TSGen.Out
 	}
+	cfg.TSGen.Validators = fileCfg.TSGen.Validators
+	cfg.TSGen.QueryBuilder = fileCfg.TSGen.QueryBuilder
+	cfg.TSGen.Client = fileCfg.TSGen.Client
+	if fileCfg.TSGen.BasePath != "" {
+		cfg.TSGen.BasePath = fileCfg.TSGen.BasePath
+	}
+	if fileCfg.OpenAPI.Out != "" {
+		cfg.OpenAPI.Out = fileCfg.OpenAPI.Out
+	}
+	if fileCfg.OpenAPI.BasePath != "" {
+		cfg.OpenAPI.BasePath = fileCfg.OpenAPI.BasePath
+	}
+	if fileCfg.OpenAPI.Title != "" {
+		cfg.OpenAPI.Title = fileCfg.OpenAPI.Title
+	}
 	if len(fileCfg.GoGen.NameRules) > 0 {
 		cfg.GoGen.NameRules = fileCfg.GoGen.NameRules
 	}
@@ -180,6 +223,9 @@ func LoadConfig(path string) (*Config, error) {
 	if fileCfg.Metadata.OutDir != "" {
 		cfg.Metadata.OutDir = fileCfg.Metadata.OutDir
 	}
+	if fileCfg.Database.Path != "" {
+		cfg.Database.Path = fileCfg.Database.Path
+	}
 
 	return cfg, nil
 }
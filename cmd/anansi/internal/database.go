@@ -0,0 +1,54 @@
+package schemagen
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/query/native"
+	sqliteExecutor "github.com/asaidimu/go-anansi/v8/sqlite/executor"
+	sqliteQuery "github.com/asaidimu/go-anansi/v8/sqlite/query"
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+// OpenDatabase opens an existing SQLite database as a persistence for the
+// commands that inspect or change a live database. The returned function
+// closes it.
+func OpenDatabase(path string) (base.Persistence, func(), error) {
+	if path == "" {
+		return nil, nil, fmt.Errorf("no database configured: pass --db or set database.path in anansi.json")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil, fmt.Errorf("open database %s: %w", path, err)
+	}
+
+	logger := zap.NewNop()
+	if err := data.ConfigureDocumentFactory(data.DocumentFactoryConfig{}, logger); err != nil {
+		return nil, nil, err
+	}
+
+	db, err := sql.Open(sqliteExecutor.DriverName, fmt.Sprintf("file:%s?_fk=1", path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("open database %s: %w", path, err)
+	}
+	executor, err := sqliteExecutor.NewSQLiteExecutor(db, logger)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	interactor, err := native.NewNativeInteractor(executor, sqliteQuery.NewSQLiteFactory(logger, sqliteQuery.WithRegexFunction()), logger)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	p, err := persistence.NewPersistence(interactor, nil, logger, nil)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return p, func() { _ = db.Close() }, nil
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
 )
 
+// RunFaker prints count fake documents per schema file. Schemas are generated
+// in reference order, so a field referencing another of the given schemas
+// picks values from the documents printed for it.
 func RunFaker(seed int64, count int, pretty bool, dir string, files []string) error {
 	if dir == "" && len(files) == 0 {
 		return fmt.Errorf("either --dir or schema file paths are required")
@@ -35,6 +38,8 @@ func RunFaker(seed int64, count int, pretty bool, dir string, files []string) er
 		return fmt.Errorf("no schema files found")
 	}
 
+	schemas := make([]*definition.Schema, 0, len(schemaFiles))
+	paths := make(map[*definition.Schema]string, len(schemaFiles))
 	for _, path := range schemaFiles {
 		data, err := os.ReadFile(path)
 		if err != nil {
@@ -45,12 +50,38 @@ func RunFaker(seed int64, count int, pretty bool, dir string, files []string) er
 		if err != nil {
 			return fmt.Errorf("parse %s: %w", path, err)
 		}
+		schemas = append(schemas, schema)
+		paths[schema] = path
+	}
+
+	ordered, err := faker.Order(schemas)
+	if err != nil {
+		return err
+	}
 
+	generated := make(map[string][]map[string]any, len(ordered))
+	for _, schema := range ordered {
+		path := paths[schema]
 		gen := faker.NewFakeGenerator(schema, seed)
+		for _, ref := range faker.References(schema) {
+			var values []any
+			for _, doc := range generated[ref.Collection] {
+				if v, ok := doc[ref.Field]; ok && v != nil {
+					values = append(values, v)
+				}
+			}
+			if len(values) > 0 {
+				gen.UseReferences(ref, values)
+			}
+		}
+
 		results := make([]map[string]any, count)
 		for i := 0; i < count; i++ {
-			results[i] = gen.Generate()
+			if results[i], err = gen.Generate(); err != nil {
+				return fmt.Errorf("generate %s: %w", path, err)
+			}
 		}
+		generated[schema.Name] = results
 
 		var out []byte
 		if pretty {
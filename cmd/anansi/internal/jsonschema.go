@@ -0,0 +1,80 @@
+package schemagen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/asaidimu/go-anansi/v8/core/schema"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/schema/jsonschema"
+)
+
+// RunSchemaExport writes the JSON Schema form of the schema file at path to
+// out, or to stdout when out is empty.
+func RunSchemaExport(path, out string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	s, err := definition.FromJSON(raw)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	doc, err := jsonschema.ExportJSON(s)
+	if err != nil {
+		return fmt.Errorf("export %s: %w", path, err)
+	}
+
+	if out == "" {
+		_, err := os.Stdout.Write(doc)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return fmt.Errorf("create dir %s: %w", filepath.Dir(out), err)
+	}
+	if err := os.WriteFile(out, doc, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", out, err)
+	}
+	fmt.Printf("exported: %s\n", out)
+	return nil
+}
+
+// RunSchemaImport converts the JSON Schema document at path into an Anansi
+// schema file in dir. The collection name defaults to the document title.
+func RunSchemaImport(path, name, dir string, dryRun bool) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolve dir: %w", err)
+	}
+
+	s, err := jsonschema.Import(raw, jsonschema.ImportOptions{Name: name})
+	if err != nil {
+		return fmt.Errorf("import %s: %w", path, err)
+	}
+	if issues, ok := schema.SchemaValidator().Validate(s.AsMap()); !ok {
+		return fmt.Errorf("imported schema %q is invalid: %v", s.Name, issues)
+	}
+
+	filename := filepath.Join(abs, SafeIdent(s.Name)+".schema.json")
+
+	if dryRun {
+		fmt.Printf("would create: %s\n", filename)
+		return nil
+	}
+
+	if err := os.MkdirAll(abs, 0755); err != nil {
+		return fmt.Errorf("create dir %s: %w", abs, err)
+	}
+	if err := os.WriteFile(filename, s.ToJSON(), 0644); err != nil {
+		return fmt.Errorf("write schema: %w", err)
+	}
+
+	fmt.Printf("created: %s\n", filename)
+	return nil
+}
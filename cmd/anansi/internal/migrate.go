@@ -0,0 +1,510 @@
+package schemagen
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// MigrationState describes where a collection of the database stands
+// relative to its lockfile history.
+type MigrationState string
+
+const (
+	// MigrationCurrent means the database is at the lockfile version.
+	MigrationCurrent MigrationState = "current"
+	// MigrationPending means the database is at an earlier version of the
+	// lockfile history.
+	MigrationPending MigrationState = "pending"
+	// MigrationMissing means the collection does not exist in the database.
+	MigrationMissing MigrationState = "missing"
+	// MigrationDiverged means the database is at a version the lockfile
+	// history does not contain.
+	MigrationDiverged MigrationState = "diverged"
+	// MigrationUntracked means the collection exists in the database but not
+	// in the lockfile.
+	MigrationUntracked MigrationState = "untracked"
+)
+
+// CollectionStatus is the migration state of one collection.
+type CollectionStatus struct {
+	Name string
+	// Locked is the version recorded in the lockfile.
+	Locked string
+	// Applied is the active version in the database; empty when the
+	// collection does not exist there.
+	Applied string
+	State   MigrationState
+	// Pending lists the lockfile versions migrate up would apply, in order.
+	Pending []string
+}
+
+// migrationStep is one version of a collection's lockfile history together
+// with the schema at that version and the file that migrates to it.
+type migrationStep struct {
+	Version string
+	Schema  *definition.Schema
+	File    string
+}
+
+// lockChain returns the versions of a lockfile entry from oldest to newest.
+// A squashed history keeps only the version it starts from, without a schema.
+func lockChain(ref *SchemaRef) []migrationStep {
+	chain := make([]migrationStep, 0, len(ref.History)+1)
+	for _, h := range ref.History {
+		chain = append(chain, migrationStep{Version: h.Version, Schema: h.Schema, File: h.MigrationFile})
+	}
+	return append(chain, migrationStep{Version: ref.Version, Schema: ref.Schema, File: ref.MigrationFile})
+}
+
+func stepIndex(chain []migrationStep, version string) int {
+	for i, step := range chain {
+		if step.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// pendingVersions lists the versions after index i that carry a schema.
+func pendingVersions(chain []migrationStep, i int) []string {
+	var out []string
+	for _, step := range chain[i+1:] {
+		if step.Schema != nil {
+			out = append(out, step.Version)
+		}
+	}
+	return out
+}
+
+func sortedNames(lock *Lockfile) []string {
+	names := make([]string, 0, len(lock.Schemas))
+	for name := range lock.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MigrationStatus compares every collection of the lockfile with the
+// database, followed by the database collections the lockfile does not know.
+func MigrationStatus(ctx context.Context, p base.Persistence, lock *Lockfile) ([]CollectionStatus, error) {
+	var out []CollectionStatus
+	for _, name := range sortedNames(lock) {
+		ref := lock.Schemas[name]
+		chain := lockChain(ref)
+		st := CollectionStatus{Name: name, Locked: ref.Version}
+
+		exists, err := p.HasCollection(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", name, err)
+		}
+		if !exists {
+			st.State = MigrationMissing
+			st.Pending = pendingVersions(chain, -1)
+			out = append(out, st)
+			continue
+		}
+
+		s, err := p.Schema(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("load schema %s: %w", name, err)
+		}
+		st.Applied = s.Version.String()
+		switch i := stepIndex(chain, st.Applied); {
+		case i < 0:
+			st.State = MigrationDiverged
+		case i == len(chain)-1:
+			st.State = MigrationCurrent
+		default:
+			st.State = MigrationPending
+			st.Pending = pendingVersions(chain, i)
+		}
+		out = append(out, st)
+	}
+
+	stored, err := p.ListCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list collections: %w", err)
+	}
+	sort.Strings(stored)
+	for _, name := range stored {
+		if _, ok := lock.Schemas[name]; ok {
+			continue
+		}
+		st := CollectionStatus{Name: name, State: MigrationUntracked}
+		if s, err := p.Schema(ctx, name); err == nil {
+			st.Applied = s.Version.String()
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// MigrateUp brings the named collections (every lockfile collection when
+// none are named) to their lockfile version. A missing collection is created
+// at the first version of its history and migrated from there, so the
+// database keeps every version to roll back to.
+//
+// Each step is applied from the schema recorded in the lockfile, as the
+// generated migration would apply it. Steps that need a data transformer
+// (see DetectPhase) cannot be applied this way and stop the collection at the
+// version before them, unless the collection was created in this run and
+// holds no documents yet. A version the database already has, because it was
+// rolled back, is activated again instead of migrated.
+func MigrateUp(ctx context.Context, p base.Persistence, lock *Lockfile, collections []string, dryRun bool) error {
+	names := collections
+	if len(names) == 0 {
+		names = sortedNames(lock)
+	}
+	for _, name := range names {
+		ref, ok := lock.Schemas[name]
+		if !ok {
+			return fmt.Errorf("collection %q not found in lockfile", name)
+		}
+		if err := migrateCollectionUp(ctx, p, name, ref, dryRun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateCollectionUp(ctx context.Context, p base.Persistence, name string, ref *SchemaRef, dryRun bool) error {
+	chain := lockChain(ref)
+	would := ""
+	if dryRun {
+		would = "would "
+	}
+
+	exists, err := p.HasCollection(ctx, name)
+	if err != nil {
+		return fmt.Errorf("check %s: %w", name, err)
+	}
+
+	var current *definition.Schema
+	var next int
+	created := false
+	if exists {
+		if current, err = p.Schema(ctx, name); err != nil {
+			return fmt.Errorf("load schema %s: %w", name, err)
+		}
+		i := stepIndex(chain, current.Version.String())
+		if i < 0 {
+			return fmt.Errorf("%s is at version %s, which the lockfile history does not contain; run anansi migrate verify", name, current.Version)
+		}
+		next = i + 1
+	} else {
+		first := -1
+		for i, step := range chain {
+			if step.Schema != nil {
+				first = i
+				break
+			}
+		}
+		if first < 0 {
+			return fmt.Errorf("%s has no schema in the lockfile", name)
+		}
+		current, err = versioned(chain[first])
+		if err != nil {
+			return err
+		}
+		if !dryRun {
+			if _, err := p.CreateCollection(ctx, current); err != nil {
+				return fmt.Errorf("create %s: %w", name, err)
+			}
+		}
+		fmt.Printf("  %screate: %s %s\n", would, name, chain[first].Version)
+		next, created = first+1, true
+	}
+
+	if next == len(chain) {
+		if !created {
+			fmt.Printf("  up to date: %s %s\n", name, current.Version)
+		}
+		return nil
+	}
+
+	for _, step := range chain[next:] {
+		from := current.Version.String()
+		if step.Schema == nil {
+			return fmt.Errorf("%s %s -> %s: the lockfile has no schema for %s", name, from, step.Version, step.Version)
+		}
+		target, err := versioned(step)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			if _, err := p.Schema(ctx, name, step.Version); err == nil {
+				if !dryRun {
+					if _, err := p.Rollback(ctx, name, &step.Version, nil); err != nil {
+						return fmt.Errorf("activate %s %s: %w", name, step.Version, err)
+					}
+				}
+				fmt.Printf("  %sreactivate: %s %s -> %s\n", would, name, from, step.Version)
+				current = target
+				continue
+			}
+		}
+
+		diff, err := definition.Diff(current, target)
+		if err != nil {
+			return fmt.Errorf("diff %s %s -> %s: %w", name, from, step.Version, err)
+		}
+		if DetectPhase(diff) == "full" && !created {
+			return fmt.Errorf("%s %s -> %s needs the data transformer in %s; apply it with the generated migrations.Apply", name, from, step.Version, step.File)
+		}
+		bump := definition.VersionImpact(diff)
+		if to := bump.Apply(*current.Version); to.String() != step.Version {
+			return fmt.Errorf("%s %s -> %s: the schema change is a %s bump to %s; run anansi migrate verify", name, from, step.Version, bump, to.String())
+		}
+
+		if !dryRun {
+			plan := &base.MigrationPlan{
+				Description: fmt.Sprintf("auto migration from %s to %s", from, step.Version),
+				Target:      target,
+				Diff:        diff,
+				VersionBump: bump,
+			}
+			if _, err := p.Migrate(ctx, name, plan, nil); err != nil {
+				return fmt.Errorf("migrate %s %s -> %s: %w", name, from, step.Version, err)
+			}
+		}
+		fmt.Printf("  %smigrate: %s %s -> %s\n", would, name, from, step.Version)
+		current = target
+	}
+	return nil
+}
+
+// versioned returns a copy of the step's schema carrying the step's version,
+// which the lockfile tracks apart from the version written in the schema file.
+func versioned(step migrationStep) (*definition.Schema, error) {
+	v, err := common.NewVersion(step.Version)
+	if err != nil {
+		return nil, fmt.Errorf("parse version %q: %w", step.Version, err)
+	}
+	s := step.Schema.DeepCopy()
+	s.Version = v
+	return s, nil
+}
+
+// MigrateDown rolls each named collection back to version to, or to its
+// previous version when to is empty.
+func MigrateDown(ctx context.Context, p base.Persistence, collections []string, to string, dryRun bool) error {
+	if len(collections) == 0 {
+		return fmt.Errorf("name the collections to roll back")
+	}
+	var version *string
+	if to != "" {
+		version = &to
+	}
+	for _, name := range collections {
+		s, err := p.Schema(ctx, name)
+		if err != nil {
+			return fmt.Errorf("load schema %s: %w", name, err)
+		}
+		from := s.Version.String()
+		if _, err := p.Rollback(ctx, name, version, &dryRun); err != nil {
+			return fmt.Errorf("roll back %s: %w", name, err)
+		}
+		if dryRun {
+			target := to
+			if target == "" {
+				target = "its previous version"
+			}
+			fmt.Printf("  would roll back: %s %s -> %s\n", name, from, target)
+			continue
+		}
+		s, err = p.Schema(ctx, name)
+		if err != nil {
+			return fmt.Errorf("load schema %s: %w", name, err)
+		}
+		fmt.Printf("  rolled back: %s %s -> %s\n", name, from, s.Version)
+	}
+	return nil
+}
+
+// VerifyMigrations reports the drift between the schema files, the lockfile,
+// the migration files in migrationsDir and the database: schema files edited
+// since the lockfile was written, missing migration files, database versions
+// outside the lockfile history, database schemas that differ from the
+// lockfile schema of their version, and collections the lockfile does not
+// know. Collections that are missing from the database are pending, not
+// drift.
+func VerifyMigrations(ctx context.Context, p base.Persistence, lock *Lockfile, migrationsDir string) ([]string, error) {
+	var drift []string
+	report := func(format string, a ...any) {
+		drift = append(drift, fmt.Sprintf(format, a...))
+	}
+
+	for _, name := range sortedNames(lock) {
+		ref := lock.Schemas[name]
+		if ref.Path != "" {
+			raw, err := os.ReadFile(ref.Path)
+			switch {
+			case err != nil:
+				report("%s: schema file %s cannot be read: %v", name, ref.Path, err)
+			case ContentHash(raw) != ref.Hash:
+				report("%s: %s changed since the lockfile was written; run anansi migrate generate", name, ref.Path)
+			}
+		}
+
+		chain := lockChain(ref)
+		files := append([]string{}, ref.SubMigrations...)
+		for _, step := range chain {
+			files = append(files, step.File)
+		}
+		seen := map[string]bool{}
+		for _, f := range files {
+			if f == "" || seen[f] {
+				continue
+			}
+			seen[f] = true
+			if _, err := os.Stat(filepath.Join(migrationsDir, f)); err != nil {
+				report("%s: migration file %s is missing", name, f)
+			}
+		}
+
+		exists, err := p.HasCollection(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", name, err)
+		}
+		if !exists {
+			continue
+		}
+		s, err := p.Schema(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("load schema %s: %w", name, err)
+		}
+		i := stepIndex(chain, s.Version.String())
+		if i < 0 {
+			report("%s: database is at %s, which the lockfile history does not contain", name, s.Version)
+			continue
+		}
+		if chain[i].Schema == nil {
+			continue
+		}
+		locked, err := versioned(chain[i])
+		if err != nil {
+			return nil, err
+		}
+		diff, err := definition.Diff(locked, s)
+		if err != nil {
+			return nil, fmt.Errorf("diff %s: %w", name, err)
+		}
+		if len(diff.Changes) > 0 {
+			report("%s: database schema at %s differs from the lockfile by %d changes", name, s.Version, len(diff.Changes))
+		}
+	}
+
+	stored, err := p.ListCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list collections: %w", err)
+	}
+	sort.Strings(stored)
+	for _, name := range stored {
+		if _, ok := lock.Schemas[name]; !ok {
+			report("%s: collection exists in the database but not in the lockfile", name)
+		}
+	}
+	return drift, nil
+}
+
+// openMigrations opens the database and reads the lockfile for the migrate
+// commands that work on a live database.
+func openMigrations(cfg *Config, dbPath string) (base.Persistence, *Lockfile, func(), error) {
+	lock, err := ReadLockfile(cfg.Schema.Lockfile)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("read lockfile: %w", err)
+	}
+	p, closeDB, err := OpenDatabase(dbPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return p, lock, closeDB, nil
+}
+
+// RunMigrateStatus prints the migration state of every collection.
+func RunMigrateStatus(cfg *Config, dbPath string) error {
+	p, lock, closeDB, err := openMigrations(cfg, dbPath)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	statuses, err := MigrationStatus(context.Background(), p, lock)
+	if err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		fmt.Println("no collections in the lockfile or the database")
+		return nil
+	}
+	for _, st := range statuses {
+		line := fmt.Sprintf("  %-10s %s", st.State, st.Name)
+		switch st.State {
+		case MigrationCurrent:
+			line += " " + st.Applied
+		case MigrationPending:
+			line += fmt.Sprintf(" %s -> %s", st.Applied, strings.Join(st.Pending, " -> "))
+		case MigrationMissing:
+			line += " -> " + strings.Join(st.Pending, " -> ")
+		case MigrationDiverged:
+			line += fmt.Sprintf(" %s (lockfile %s)", st.Applied, st.Locked)
+		case MigrationUntracked:
+			line += " " + st.Applied
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// RunMigrateUp applies the pending migrations of the database at dbPath.
+func RunMigrateUp(cfg *Config, dbPath string, collections []string, dryRun bool) error {
+	p, lock, closeDB, err := openMigrations(cfg, dbPath)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	return MigrateUp(context.Background(), p, lock, collections, dryRun)
+}
+
+// RunMigrateDown rolls back collections of the database at dbPath.
+func RunMigrateDown(dbPath string, collections []string, to string, dryRun bool) error {
+	p, closeDB, err := OpenDatabase(dbPath)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	return MigrateDown(context.Background(), p, collections, to, dryRun)
+}
+
+// RunMigrateVerify prints the drift between files and the database at dbPath
+// and fails when there is any.
+func RunMigrateVerify(cfg *Config, dbPath string) error {
+	p, lock, closeDB, err := openMigrations(cfg, dbPath)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	drift, err := VerifyMigrations(context.Background(), p, lock, cfg.Schema.MigrationsDir)
+	if err != nil {
+		return err
+	}
+	if len(drift) == 0 {
+		fmt.Println("no drift between files and database")
+		return nil
+	}
+	for _, d := range drift {
+		fmt.Fprintf(os.Stderr, "  drift: %s\n", d)
+	}
+	return fmt.Errorf("found %d drift issues", len(drift))
+}
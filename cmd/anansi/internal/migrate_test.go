@@ -0,0 +1,172 @@
+package schemagen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/schema/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const migrateNotes = `{
+  "version": "1.0.0",
+  "name": "notes",
+  "fields": {
+    "title": { "name": "title", "type": "string", "required": true },
+    "body":  { "name": "body", "type": "string" }
+  }
+}`
+
+// lockVersion records s as the next version of a lockfile entry, the way
+// migrate generate does.
+func lockVersion(t *testing.T, lock *Lockfile, dir string, s *definition.Schema) {
+	t.Helper()
+	prev := lock.Schemas[s.Name]
+	from, old := "0.0.0", &definition.Schema{}
+	if prev != nil {
+		from, old = prev.Version, prev.Schema
+	}
+	diff, err := definition.Diff(old, s)
+	require.NoError(t, err)
+	to, err := bumpVersion(from, definition.VersionImpact(diff))
+	require.NoError(t, err)
+
+	file := s.Name + "_" + to + ".go"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, file), nil, 0o644))
+	ref := &SchemaRef{Version: to, Schema: s, MigrationFile: file}
+	if prev != nil {
+		ref.History = append(prev.History, &HistoryEntry{Version: prev.Version, Schema: prev.Schema, MigrationFile: prev.MigrationFile})
+	}
+	lock.Schemas[s.Name] = ref
+}
+
+func migrateDatabase(t *testing.T) (base.Persistence, *Lockfile, string, func()) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "migrate.db")
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+	p, closeDB, err := OpenDatabase(path)
+	require.NoError(t, err)
+
+	v1, err := definition.FromJSON([]byte(migrateNotes))
+	require.NoError(t, err)
+	meta.NormalizeSchema(v1)
+	v2 := v1.DeepCopy()
+	v2.Fields["tags"] = definition.Field{Name: "tags", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}}
+	meta.NormalizeSchema(v2)
+
+	lock := &Lockfile{Version: "1", Schemas: map[string]*SchemaRef{}}
+	lockVersion(t, lock, dir, v1)
+	lockVersion(t, lock, dir, v2)
+	return p, lock, dir, closeDB
+}
+
+func statusOf(t *testing.T, p base.Persistence, lock *Lockfile, name string) CollectionStatus {
+	t.Helper()
+	statuses, err := MigrationStatus(context.Background(), p, lock)
+	require.NoError(t, err)
+	for _, st := range statuses {
+		if st.Name == name {
+			return st
+		}
+	}
+	t.Fatalf("no status for %s", name)
+	return CollectionStatus{}
+}
+
+func TestMigrate_UpDown(t *testing.T) {
+	ctx := context.Background()
+	p, lock, dir, closeDB := migrateDatabase(t)
+	defer closeDB()
+	ref := lock.Schemas["notes"]
+	first, last := ref.History[0].Version, ref.Version
+
+	st := statusOf(t, p, lock, "notes")
+	assert.Equal(t, MigrationMissing, st.State)
+	assert.Equal(t, []string{first, last}, st.Pending)
+
+	require.NoError(t, MigrateUp(ctx, p, lock, nil, true))
+	assert.Equal(t, MigrationMissing, statusOf(t, p, lock, "notes").State, "a dry run changes nothing")
+
+	require.NoError(t, MigrateUp(ctx, p, lock, nil, false))
+	st = statusOf(t, p, lock, "notes")
+	assert.Equal(t, MigrationCurrent, st.State)
+	assert.Equal(t, last, st.Applied)
+	s, err := p.Schema(ctx, "notes")
+	require.NoError(t, err)
+	names := map[definition.FieldName]bool{}
+	for _, f := range s.Fields {
+		names[f.Name] = true
+	}
+	assert.True(t, names["tags"], "the migrated schema has the new field")
+
+	drift, err := VerifyMigrations(ctx, p, lock, dir)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+
+	require.NoError(t, MigrateDown(ctx, p, []string{"notes"}, "", false))
+	st = statusOf(t, p, lock, "notes")
+	assert.Equal(t, MigrationPending, st.State)
+	assert.Equal(t, first, st.Applied)
+	assert.Equal(t, []string{last}, st.Pending)
+
+	// The rolled back version is activated again rather than migrated.
+	require.NoError(t, MigrateUp(ctx, p, lock, []string{"notes"}, false))
+	assert.Equal(t, MigrationCurrent, statusOf(t, p, lock, "notes").State)
+}
+
+func TestMigrate_NeedsTransformer(t *testing.T) {
+	ctx := context.Background()
+	p, lock, dir, closeDB := migrateDatabase(t)
+	defer closeDB()
+	require.NoError(t, MigrateUp(ctx, p, lock, nil, false))
+
+	v3 := lock.Schemas["notes"].Schema.DeepCopy()
+	for id, f := range v3.Fields {
+		if f.Name == "body" {
+			delete(v3.Fields, id)
+		}
+	}
+	lockVersion(t, lock, dir, v3)
+
+	err := MigrateUp(ctx, p, lock, nil, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "needs the data transformer")
+	assert.Equal(t, MigrationPending, statusOf(t, p, lock, "notes").State)
+}
+
+func TestMigrate_Verify(t *testing.T) {
+	ctx := context.Background()
+	p, lock, dir, closeDB := migrateDatabase(t)
+	defer closeDB()
+	require.NoError(t, MigrateUp(ctx, p, lock, nil, false))
+
+	ref := lock.Schemas["notes"]
+	require.NoError(t, os.Remove(filepath.Join(dir, ref.MigrationFile)))
+
+	schemaPath := filepath.Join(dir, "notes.schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(migrateNotes), 0o644))
+	ref.Path, ref.Hash = schemaPath, "sha256:stale"
+
+	// A lockfile whose newest version diverges from what the database holds.
+	ref.Schema = ref.Schema.DeepCopy()
+	ref.Schema.Fields["extra"] = definition.Field{Name: "extra", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}}
+	meta.NormalizeSchema(ref.Schema)
+
+	drift, err := VerifyMigrations(ctx, p, lock, dir)
+	require.NoError(t, err)
+	require.Len(t, drift, 3, "%v", drift)
+	assert.Contains(t, drift[0], "changed since the lockfile was written")
+	assert.Contains(t, drift[1], "is missing")
+	assert.Contains(t, drift[2], "differs from the lockfile")
+
+	delete(lock.Schemas, "notes")
+	drift, err = VerifyMigrations(ctx, p, lock, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes: collection exists in the database but not in the lockfile"}, drift)
+}
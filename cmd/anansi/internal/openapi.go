@@ -0,0 +1,55 @@
+package schemagen
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/asaidimu/go-anansi/v8/codegen/openapi"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// RunOpenAPIGen writes the OpenAPI document describing the rest package
+// routes for every schema matching the configured glob.
+func RunOpenAPIGen(cfg *Config, dryRun bool) error {
+	matches, err := doublestar.FilepathGlob(cfg.Schema.Glob)
+	if err != nil {
+		return fmt.Errorf("glob pattern %q: %w", cfg.Schema.Glob, err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no schema files matching %q", cfg.Schema.Glob)
+	}
+
+	var schemas []*definition.Schema
+	for _, m := range matches {
+		raw, err := os.ReadFile(m)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", m, err)
+		}
+		s, err := definition.FromJSON(raw)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", m, err)
+		}
+		schemas = append(schemas, s)
+	}
+
+	doc, err := openapi.GenerateJSON(schemas, openapi.Options{
+		BasePath: cfg.OpenAPI.BasePath,
+		Title:    cfg.OpenAPI.Title,
+	})
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("would generate %s (%d collections)\n", cfg.OpenAPI.Out, len(schemas))
+		return nil
+	}
+
+	if err := os.WriteFile(cfg.OpenAPI.Out, doc, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", cfg.OpenAPI.Out, err)
+	}
+
+	fmt.Printf("generated %s\n", cfg.OpenAPI.Out)
+	return nil
+}
@@ -0,0 +1,208 @@
+package schemagen
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/asaidimu/go-anansi/v8/codegen/faker"
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// SeedOptions configures Seed.
+type SeedOptions struct {
+	// Count is the number of documents inserted into each collection.
+	Count int
+	// Seed makes the generated values reproducible. Each collection derives
+	// its own seed from it and its name, so seeding a subset of collections
+	// yields the same documents for them.
+	Seed int64
+	// Collections limits seeding to the named collections; empty seeds every
+	// collection in the database.
+	Collections []string
+}
+
+// RunSeed seeds the database at dbPath.
+func RunSeed(dbPath string, opts SeedOptions) error {
+	p, closeDB, err := OpenDatabase(dbPath)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	return Seed(context.Background(), p, opts)
+}
+
+// Seed inserts opts.Count fake documents into each collection, in one
+// transaction. Collections are seeded after the collections they reference
+// (see faker.Reference), and reference fields take their values from the
+// documents just inserted; references to collections that are not being
+// seeded take them from the documents already stored there.
+func Seed(ctx context.Context, p base.Persistence, opts SeedOptions) error {
+	if opts.Count < 1 {
+		return fmt.Errorf("count must be at least 1")
+	}
+	names := opts.Collections
+	if len(names) == 0 {
+		var err error
+		if names, err = p.ListCollections(ctx); err != nil {
+			return fmt.Errorf("list collections: %w", err)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no collections to seed")
+	}
+
+	schemas := make([]*definition.Schema, 0, len(names))
+	seeding := make(map[string]bool, len(names))
+	for _, name := range names {
+		s, err := p.Schema(ctx, name)
+		if err != nil {
+			return fmt.Errorf("load schema %s: %w", name, err)
+		}
+		schemas = append(schemas, s)
+		seeding[s.Name] = true
+	}
+	ordered, err := faker.Order(schemas)
+	if err != nil {
+		return err
+	}
+
+	// wanted holds every reference some seeded collection makes, so the
+	// values of referenced fields are kept as their documents are inserted.
+	wanted := map[faker.Reference]bool{}
+	for _, s := range ordered {
+		for _, ref := range faker.References(s) {
+			wanted[ref] = true
+		}
+	}
+
+	_, err = p.Transact(ctx, func(ctx context.Context, tx base.BasePersistence) (any, error) {
+		values := map[faker.Reference][]any{}
+		for _, s := range ordered {
+			gen := faker.NewFakeGenerator(s, collectionSeed(opts.Seed, s.Name)).SkipSystemFields()
+			self := false
+			for _, ref := range faker.References(s) {
+				switch {
+				case ref.Collection == s.Name:
+					self = true
+				case !seeding[ref.Collection]:
+					if _, ok := values[ref]; !ok {
+						stored, err := storedValues(ctx, tx, ref)
+						if err != nil {
+							return nil, err
+						}
+						values[ref] = stored
+					}
+				}
+				gen.UseReferences(ref, values[ref])
+			}
+
+			coll, err := tx.Collection(ctx, s.Name)
+			if err != nil {
+				return nil, fmt.Errorf("open collection %s: %w", s.Name, err)
+			}
+			// A collection referencing itself is inserted one document at a
+			// time, so later documents can reference earlier ones.
+			batch := opts.Count
+			if self {
+				batch = 1
+			}
+			for done := 0; done < opts.Count; done += batch {
+				docs := make([]data.Documenter, 0, batch)
+				for i := 0; i < batch; i++ {
+					m, err := gen.Generate()
+					if err != nil {
+						return nil, fmt.Errorf("generate %s: %w", s.Name, err)
+					}
+					doc, err := data.NewDocument(m)
+					if err != nil {
+						return nil, fmt.Errorf("generate %s: %w", s.Name, err)
+					}
+					docs = append(docs, doc)
+				}
+				results, err := coll.CreateMany(ctx, docs)
+				if err != nil {
+					return nil, insertError(s.Name, err)
+				}
+				for _, r := range results {
+					if r.Status != base.StatusCreated {
+						return nil, fmt.Errorf("insert into %s: %s", s.Name, r.Status)
+					}
+					for ref := range wanted {
+						if ref.Collection != s.Name {
+							continue
+						}
+						if v, ok := referencedValue(r.Data, ref.Field); ok {
+							values[ref] = append(values[ref], v)
+						}
+					}
+				}
+				if self {
+					for _, ref := range faker.References(s) {
+						if ref.Collection == s.Name {
+							gen.UseReferences(ref, values[ref])
+						}
+					}
+				}
+			}
+			fmt.Printf("seeded %d documents into %s\n", opts.Count, s.Name)
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// collectionSeed derives the seed of one collection from the run's seed.
+func collectionSeed(seed int64, name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return seed ^ int64(h.Sum64())
+}
+
+// storedValues reads the values of a referenced field from the documents
+// already in its collection.
+func storedValues(ctx context.Context, tx base.BasePersistence, ref faker.Reference) ([]any, error) {
+	coll, err := tx.Collection(ctx, ref.Collection)
+	if err != nil {
+		return nil, fmt.Errorf("open referenced collection %s: %w", ref.Collection, err)
+	}
+	q := query.NewQueryBuilder().Build()
+	result, err := coll.Read(ctx, &q)
+	if err != nil {
+		return nil, fmt.Errorf("read referenced collection %s: %w", ref.Collection, err)
+	}
+	var out []any
+	for _, doc := range result.Data {
+		if v, ok := referencedValue(doc, ref.Field); ok {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func referencedValue(doc data.Documenter, field string) (any, bool) {
+	if field == "_id_" {
+		id := doc.ID()
+		return id, id != ""
+	}
+	v, err := doc.GetNested(field)
+	return v, err == nil && v != nil
+}
+
+// insertError reports the first issue behind a failed insert. Collections
+// report the documents they reject as nested issues of the error.
+func insertError(collection string, err error) error {
+	issues := common.SystemErrorFrom(err).Issues
+	for len(issues) > 0 {
+		issue := issues[0]
+		if issue.Cause == nil || len(*issue.Cause) == 0 {
+			return fmt.Errorf("insert into %s: %w: %s", collection, err, issue.Message)
+		}
+		issues = *issue.Cause
+	}
+	return fmt.Errorf("insert into %s: %w", collection, err)
+}
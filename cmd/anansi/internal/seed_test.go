@@ -0,0 +1,112 @@
+package schemagen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/schema/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const seedAuthors = `{
+  "version": "1.0.0",
+  "name": "authors",
+  "fields": {
+    "name":  { "name": "name", "type": "string", "required": true },
+    "email": { "name": "email", "type": "string", "required": true, "unique": true }
+  }
+}`
+
+const seedBooks = `{
+  "version": "1.0.0",
+  "name": "books",
+  "fields": {
+    "title":  { "name": "title", "type": "string", "required": true },
+    "author": { "name": "author", "type": "string", "required": true, "metadata": { "references": "authors" } },
+    "sequel": { "name": "sequel", "type": "string", "metadata": { "references": "books" } },
+    "pages":  { "name": "pages", "type": "integer", "required": true }
+  }
+}`
+
+func seedDatabase(t *testing.T) (base.Persistence, func()) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "seed.db")
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+	p, closeDB, err := OpenDatabase(path)
+	require.NoError(t, err)
+	for _, js := range []string{seedAuthors, seedBooks} {
+		s, err := definition.FromJSON([]byte(js))
+		require.NoError(t, err)
+		meta.NormalizeSchema(s)
+		_, err = p.CreateCollection(context.Background(), s)
+		require.NoError(t, err)
+	}
+	return p, closeDB
+}
+
+func readAll(t *testing.T, p base.Persistence, name string) []map[string]any {
+	t.Helper()
+	ctx := context.Background()
+	coll, err := p.Collection(ctx, name)
+	require.NoError(t, err)
+	q := query.NewQueryBuilder().Build()
+	result, err := coll.Read(ctx, &q)
+	require.NoError(t, err)
+	out := make([]map[string]any, len(result.Data))
+	for i, doc := range result.Data {
+		m := doc.ToMap()
+		m["_id_"] = doc.ID()
+		out[i] = m
+	}
+	return out
+}
+
+func TestSeed_ReferencesAndConstraints(t *testing.T) {
+	p, closeDB := seedDatabase(t)
+	defer closeDB()
+
+	// Books come first to show the order follows references, not arguments.
+	require.NoError(t, Seed(context.Background(), p, SeedOptions{Count: 6, Seed: 1, Collections: []string{"books", "authors"}}))
+
+	authors := readAll(t, p, "authors")
+	require.Len(t, authors, 6)
+	ids := map[any]bool{}
+	for _, a := range authors {
+		ids[a["_id_"]] = true
+	}
+
+	books := readAll(t, p, "books")
+	require.Len(t, books, 6)
+	bookIDs := map[any]bool{}
+	for _, b := range books {
+		assert.True(t, ids[b["author"]], "book author %v is not a seeded author", b["author"])
+		if sequel, ok := b["sequel"]; ok && sequel != nil {
+			assert.True(t, bookIDs[sequel], "sequel %v is not an earlier book", sequel)
+		}
+		bookIDs[b["_id_"]] = true
+	}
+
+	// Seeding only books draws authors from the stored documents.
+	require.NoError(t, Seed(context.Background(), p, SeedOptions{Count: 3, Seed: 2, Collections: []string{"books"}}))
+	books = readAll(t, p, "books")
+	require.Len(t, books, 9)
+	for _, b := range books {
+		assert.True(t, ids[b["author"]])
+	}
+}
+
+func TestSeed_RequiresReferencedDocuments(t *testing.T) {
+	p, closeDB := seedDatabase(t)
+	defer closeDB()
+
+	err := Seed(context.Background(), p, SeedOptions{Count: 2, Seed: 1, Collections: []string{"books"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "references authors._id_")
+	assert.Empty(t, readAll(t, p, "books"), "a failed seed inserts nothing")
+}
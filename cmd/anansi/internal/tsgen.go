@@ -31,7 +31,12 @@ func RunTSGen(cfg *Config, dryRun bool) error {
 		schemas = append(schemas, s)
 	}
 
-	ts := typescript.GenerateCombined(schemas)
+	ts := typescript.GenerateCombinedWithConfig(schemas, &typescript.GeneratorConfig{
+		Validators:   cfg.TSGen.Validators,
+		QueryBuilder: cfg.TSGen.QueryBuilder,
+		Client:       cfg.TSGen.Client,
+		BasePath:     cfg.TSGen.BasePath,
+	})
 
 	if dryRun {
 		fmt.Printf("would generate %s (%d types from %d schemas)\n", cfg.TSGen.Out, len(schemas), len(schemas))
@@ -3,6 +3,7 @@ package schemagen
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/asaidimu/go-anansi/v8/codegen/typescript"
@@ -123,3 +124,79 @@ func TestRunTSGen(t *testing.T) {
 	require.Contains(t, content, "export interface B")
 	require.Contains(t, content, "y?: number")
 }
+
+const paymentsSchema = `{
+  "name": "payments",
+  "version": "1.0.0",
+  "fields": {
+    "019d7775-6563-7c55-a6f3-ac8f087d89d1": { "name": "reference", "type": "string", "required": true },
+    "019d7775-6563-7605-8bfb-c27365b73581": { "name": "note", "type": "string", "nullable": true },
+    "019d7775-6563-7605-8bfb-c27365b73582": { "name": "status", "type": "enum", "schema": { "type": "string", "values": ["open", "settled"] } },
+    "019d7775-6563-7605-8bfb-c27365b73583": { "name": "method", "type": "union", "required": true, "schema": [ { "id": "019d7775-6565-7c1f-a6f7-aeb5c4eb35e1" }, { "id": "019d7775-6565-7c1f-a6f7-aeb5c4eb35e2" } ] },
+    "019d7775-6563-7605-8bfb-c27365b73584": { "name": "payer", "type": "object", "schema": { "id": "019d7775-6565-7c1f-a6f7-aeb5c4eb35e3" } }
+  },
+  "constraints": {
+    "019d7775-6566-7c1f-a6f7-aeb5c4eb35e1": { "name": "reference_min", "predicate": "min_length", "fields": ["reference"], "parameters": { "min": 3 } }
+  },
+  "schemas": {
+    "019d7775-6565-7c1f-a6f7-aeb5c4eb35e1": { "name": "Card", "fields": {
+      "019d7775-6565-7545-9e25-fc471f57bcf1": { "name": "kind", "type": "enum", "required": true, "schema": { "type": "string", "values": ["card"] } },
+      "019d7775-6565-7545-9e25-fc471f57bcf2": { "name": "last4", "type": "string" }
+    } },
+    "019d7775-6565-7c1f-a6f7-aeb5c4eb35e2": { "name": "Transfer", "fields": {
+      "019d7775-6565-7545-9e25-fc471f57bcf3": { "name": "kind", "type": "enum", "required": true, "schema": { "type": "string", "values": ["transfer"] } },
+      "019d7775-6565-7545-9e25-fc471f57bcf4": { "name": "iban", "type": "string", "required": true }
+    } },
+    "019d7775-6565-7c1f-a6f7-aeb5c4eb35e3": { "name": "Payer", "fields": {
+      "019d7775-6565-7545-9e25-fc471f57bcf5": { "name": "email", "type": "string", "required": true }
+    } }
+  }
+}`
+
+func TestGenerateCombinedWithConfig(t *testing.T) {
+	s, err := definition.FromJSON([]byte(paymentsSchema))
+	require.NoError(t, err)
+	schemas := []*definition.Schema{s}
+
+	plain := typescript.GenerateCombined(schemas)
+	require.NotContains(t, plain, "validatePayments")
+	require.NotContains(t, plain, "createClient")
+
+	ts := typescript.GenerateCombinedWithConfig(schemas, &typescript.GeneratorConfig{
+		Validators:   true,
+		QueryBuilder: true,
+		Client:       true,
+		BasePath:     "/api/",
+	})
+	require.True(t, strings.HasPrefix(ts, plain[:len(plain)-1]), "types come first, unchanged")
+
+	// Validators.
+	require.Contains(t, ts, "export function validatePayments(value: unknown")
+	require.Contains(t, ts, "export function isPayments(value: unknown): value is payments")
+	require.Contains(t, ts, `field(value, "reference", path, issues, true, false, withRules(checkString, [minLength("reference_min", 3)]));`)
+	require.Contains(t, ts, `field(value, "note", path, issues, false, true, checkString);`)
+	require.Contains(t, ts, `checkEnum(["open", "settled"])`)
+	require.Contains(t, ts, `checkTagged("kind", { "card": checkCard, "transfer": checkTransfer })`)
+	require.Contains(t, ts, `field(value, "payer", path, issues, false, false, checkPayer);`)
+
+	// Query builder: field paths descend into nested objects.
+	require.Contains(t, ts, "export type PaymentsField =")
+	require.Contains(t, ts, `| "payer.email"`)
+	require.Contains(t, ts, `| "_id_"`)
+	require.Contains(t, ts, "export function queryPayments(): QueryBuilder<PaymentsField>")
+
+	// Client.
+	require.Contains(t, ts, `export const basePath = "/api";`)
+	require.Contains(t, ts, `"payments": new CollectionClient<payments, PaymentsField>(transport, "/payments"),`)
+}
+
+func TestGenerateCombinedWithConfig_UndiscriminatedUnion(t *testing.T) {
+	raw := strings.Replace(paymentsSchema, `"values": ["transfer"]`, `"values": ["card"]`, 1)
+	s, err := definition.FromJSON([]byte(raw))
+	require.NoError(t, err)
+
+	ts := typescript.GenerateCombinedWithConfig([]*definition.Schema{s}, &typescript.GeneratorConfig{Validators: true})
+	require.Contains(t, ts, "checkUnion([checkCard, checkTransfer])")
+	require.NotContains(t, ts, "createClient")
+	require.NotContains(t, ts, "class QueryBuilder")
+}
@@ -41,6 +41,8 @@ func main() {
 	rootCmd.AddCommand(migrateCmd())
 	rootCmd.AddCommand(codegenCmd())
 	rootCmd.AddCommand(schemaCmd())
+	rootCmd.AddCommand(auditCmd())
+	rootCmd.AddCommand(dbCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -149,6 +151,10 @@ func migrateCmd() *cobra.Command {
 
 	cmd.AddCommand(migrateGenerateCmd())
 	cmd.AddCommand(migrateSquashCmd())
+	cmd.AddCommand(migrateStatusCmd())
+	cmd.AddCommand(migrateUpCmd())
+	cmd.AddCommand(migrateDownCmd())
+	cmd.AddCommand(migrateVerifyCmd())
 
 	return cmd
 }
@@ -209,6 +215,116 @@ func migrateSquashCmd() *cobra.Command {
 	return cmd
 }
 
+// migrateDatabaseCfg loads the config and applies the --db and --lockfile
+// overrides shared by the commands that work on a live database.
+func migrateDatabaseCfg(db, lockfile string) *schemagen.Config {
+	cfg := loadCfg()
+	if db != "" {
+		cfg.Database.Path = db
+	}
+	if lockfile != "" {
+		cfg.Schema.Lockfile = lockfile
+	}
+	return cfg
+}
+
+func migrateStatusCmd() *cobra.Command {
+	var db, lockfile string
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show which migrations a database has applied",
+		Long: `Compare the lockfile history of every collection with the active schema
+versions of a database. Each collection is reported as current, pending (with
+the versions migrate up would apply), missing from the database, diverged (at
+a version the lockfile history does not contain) or untracked (in the
+database but not in the lockfile).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := migrateDatabaseCfg(db, lockfile)
+			return schemagen.RunMigrateStatus(cfg, cfg.Database.Path)
+		},
+	}
+
+	cmd.Flags().StringVar(&db, "db", "", "SQLite database file (overrides config database.path)")
+	cmd.Flags().StringVar(&lockfile, "lockfile", "", "lockfile path (overrides config)")
+	return cmd
+}
+
+func migrateUpCmd() *cobra.Command {
+	var db, lockfile string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "up [collection...]",
+		Short: "Apply pending schema-only migrations to a database",
+		Long: `Bring the named collections (every lockfile collection when none are given)
+to their lockfile version, one history step at a time, using the schemas the
+lockfile records. Missing collections are created at the first version of their
+history. Steps that need a data transformer are not applied; run the generated
+migrations.Apply from your program for those. A version the database already
+has because it was rolled back is activated again.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := migrateDatabaseCfg(db, lockfile)
+			return schemagen.RunMigrateUp(cfg, cfg.Database.Path, args, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&db, "db", "", "SQLite database file (overrides config database.path)")
+	cmd.Flags().StringVar(&lockfile, "lockfile", "", "lockfile path (overrides config)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print what would be done without making changes")
+	return cmd
+}
+
+func migrateDownCmd() *cobra.Command {
+	var db, to string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "down <collection...>",
+		Short: "Roll collections back to an earlier schema version",
+		Long: `Roll the named collections back to their previous schema version, or to
+--to, by activating that version in the collection registry.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if db == "" {
+				db = loadCfg().Database.Path
+			}
+			return schemagen.RunMigrateDown(db, args, to, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&db, "db", "", "SQLite database file (overrides config database.path)")
+	cmd.Flags().StringVar(&to, "to", "", "version to roll back to (default: the previous version)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print what would be done without making changes")
+	return cmd
+}
+
+func migrateVerifyCmd() *cobra.Command {
+	var db, lockfile, out string
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Report drift between schema files, migrations and a database",
+		Long: `Check that the schema files match the lockfile, that every migration file the
+lockfile names exists, and that every collection of the database is at a
+lockfile version with the schema the lockfile records for it. Exits non-zero
+when any drift is found.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := migrateDatabaseCfg(db, lockfile)
+			if out != "" {
+				cfg.Schema.MigrationsDir = out
+			}
+			return schemagen.RunMigrateVerify(cfg, cfg.Database.Path)
+		},
+	}
+
+	cmd.Flags().StringVar(&db, "db", "", "SQLite database file (overrides config database.path)")
+	cmd.Flags().StringVar(&lockfile, "lockfile", "", "lockfile path (overrides config)")
+	cmd.Flags().StringVar(&out, "out", "", "migration directory (overrides config)")
+	return cmd
+}
+
 // --- agents ---
 
 func agentsCmd() *cobra.Command {
@@ -256,14 +372,48 @@ func codegenCmd() *cobra.Command {
 	cmd.AddCommand(codegenGolangCmd())
 	cmd.AddCommand(codegenTypescriptCmd())
 	cmd.AddCommand(codegenFakerCmd())
+	cmd.AddCommand(codegenOpenAPICmd())
 
 	return cmd
 }
 
-func codegenTypescriptCmd() *cobra.Command {
-	var glob, out string
+func codegenOpenAPICmd() *cobra.Command {
+	var glob, out, basePath, title string
 	var dryRun bool
 
+	cmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Generate an OpenAPI 3.1 document for the REST routes of all schemas",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadCfg()
+			if glob != "" {
+				cfg.Schema.Glob = glob
+			}
+			if out != "" {
+				cfg.OpenAPI.Out = out
+			}
+			if basePath != "" {
+				cfg.OpenAPI.BasePath = basePath
+			}
+			if title != "" {
+				cfg.OpenAPI.Title = title
+			}
+			return schemagen.RunOpenAPIGen(cfg, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&glob, "glob", "", "glob pattern for schema files (overrides config)")
+	cmd.Flags().StringVar(&out, "out", "", "output OpenAPI JSON file (overrides config)")
+	cmd.Flags().StringVar(&basePath, "base-path", "", "path prefix the REST handler is mounted under (overrides config)")
+	cmd.Flags().StringVar(&title, "title", "", "info.title of the document (overrides config)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print what would be done without making changes")
+	return cmd
+}
+
+func codegenTypescriptCmd() *cobra.Command {
+	var glob, out, basePath string
+	var validators, queryBuilder, client, dryRun bool
+
 	cmd := &cobra.Command{
 		Use:   "typescript",
 		Short: "Generate TypeScript types for all schemas",
@@ -275,12 +425,28 @@ func codegenTypescriptCmd() *cobra.Command {
 			if out != "" {
 				cfg.TSGen.Out = out
 			}
+			if cmd.Flags().Changed("validators") {
+				cfg.TSGen.Validators = validators
+			}
+			if cmd.Flags().Changed("query-builder") {
+				cfg.TSGen.QueryBuilder = queryBuilder
+			}
+			if cmd.Flags().Changed("client") {
+				cfg.TSGen.Client = client
+			}
+			if basePath != "" {
+				cfg.TSGen.BasePath = basePath
+			}
 			return schemagen.RunTSGen(cfg, dryRun)
 		},
 	}
 
 	cmd.Flags().StringVar(&glob, "glob", "", "glob pattern for schema files (overrides config)")
 	cmd.Flags().StringVar(&out, "out", "", "output TypeScript file (overrides config)")
+	cmd.Flags().BoolVar(&validators, "validators", false, "emit runtime validators (overrides config)")
+	cmd.Flags().BoolVar(&queryBuilder, "query-builder", false, "emit a typed query DSL builder per collection (overrides config)")
+	cmd.Flags().BoolVar(&client, "client", false, "emit a fetch-based client for the REST routes (overrides config)")
+	cmd.Flags().StringVar(&basePath, "base-path", "", "REST path prefix used by the client (overrides config)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print what would be done without making changes")
 	return cmd
 }
@@ -355,6 +521,8 @@ func schemaCmd() *cobra.Command {
 
 	cmd.AddCommand(schemaNewCmd())
 	cmd.AddCommand(schemaNormalizeCmd())
+	cmd.AddCommand(schemaExportCmd())
+	cmd.AddCommand(schemaImportCmd())
 
 	return cmd
 }
@@ -393,6 +561,124 @@ func schemaNormalizeCmd() *cobra.Command {
 	return cmd
 }
 
+func schemaExportCmd() *cobra.Command {
+	var out string
+
+	cmd := &cobra.Command{
+		Use:   "export <path>",
+		Short: "Export a schema file as JSON Schema 2020-12",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return schemagen.RunSchemaExport(args[0], out)
+		},
+	}
+
+	cmd.Flags().StringVar(&out, "out", "", "output file (default: stdout)")
+	return cmd
+}
+
+func schemaImportCmd() *cobra.Command {
+	var name, dir string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "import <path>",
+		Short: "Create a schema file from a JSON Schema document",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return schemagen.RunSchemaImport(args[0], name, dir, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "collection name (default: the document title)")
+	cmd.Flags().StringVar(&dir, "dir", ".", "output directory for the new schema file")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print what would be done without making changes")
+	return cmd
+}
+
+// --- audit ---
+
+func auditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the audit trail",
+	}
+
+	cmd.AddCommand(auditVerifyCmd())
+
+	return cmd
+}
+
+func auditVerifyCmd() *cobra.Command {
+	var db, logCollection string
+
+	cmd := &cobra.Command{
+		Use:   "verify [collection...]",
+		Short: "Verify the hash chains of the audit trail",
+		Long: `Verify the audit trail written by the audit decorator.
+
+Walks the hash chain of each named collection (every audited collection when
+none are given) and reports removed, re-linked or altered entries. Exits
+non-zero when any chain fails. The printed head hash can be compared with a
+previously anchored value to detect truncation.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if db == "" {
+				db = loadCfg().Database.Path
+			}
+			return schemagen.RunAuditVerify(db, logCollection, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&db, "db", "", "SQLite database file (overrides config database.path)")
+	cmd.Flags().StringVar(&logCollection, "log", "", "audit collection name (default \"audit_log\")")
+	return cmd
+}
+
+// --- db ---
+
+func dbCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Work with the data in a database",
+	}
+
+	cmd.AddCommand(dbSeedCmd())
+
+	return cmd
+}
+
+func dbSeedCmd() *cobra.Command {
+	var db string
+	var seed int64
+	var count int
+
+	cmd := &cobra.Command{
+		Use:   "seed [collection...]",
+		Short: "Insert fake documents into the collections of a database",
+		Long: `Seed the collections of a database with fake documents.
+
+Inserts --count documents into each named collection (every collection when
+none are given) in a single transaction. Values follow each schema's field
+types, unique flags and standard constraints. A field declaring
+"metadata": {"references": "<collection>[.<field>]"} takes its values from the
+referenced collection, which is seeded first, or from its stored documents when
+it is not being seeded. The same --seed produces the same documents.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if db == "" {
+				db = loadCfg().Database.Path
+			}
+			return schemagen.RunSeed(db, schemagen.SeedOptions{Count: count, Seed: seed, Collections: args})
+		},
+	}
+
+	cmd.Flags().StringVar(&db, "db", "", "SQLite database file (overrides config database.path)")
+	cmd.Flags().Int64Var(&seed, "seed", 42, "random seed for reproducibility")
+	cmd.Flags().IntVar(&count, "count", 10, "number of documents to insert per collection")
+	return cmd
+}
+
 func loadCfg() *schemagen.Config {
 	path := schemagen.FindConfig()
 	if path == "" {
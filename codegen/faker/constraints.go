@@ -0,0 +1,176 @@
+package faker
+
+import (
+	"math"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// limits gathers the standard constraint predicates declared on one value:
+// the ones JSON Schema export and the TypeScript validators also understand.
+// Other predicates are application-defined and left alone.
+type limits struct {
+	lower, upper         float64
+	hasLower, hasUpper   bool
+	lowerOpen, upperOpen bool
+
+	minLen, maxLen       int
+	hasMinLen, hasMaxLen bool
+
+	pattern *regexp.Regexp
+	email   bool
+}
+
+// collectLimits returns the limits of the single-field rules in constraints,
+// keyed by field name. Rules naming no field apply to the value the
+// constraints belong to and are keyed by "". Rule fields may name a field by
+// name or by id, as the validator allows.
+func collectLimits(fields map[definition.FieldId]definition.Field, constraints map[definition.ConstraintId]definition.Constraint) map[string]*limits {
+	if len(constraints) == 0 {
+		return nil
+	}
+	out := make(map[string]*limits)
+	for _, c := range constraints {
+		rule, err := definition.ConstraintAs[*definition.ConstraintRule](c.ConstraintUnion)
+		if err != nil || len(rule.Fields) > 1 {
+			continue
+		}
+		target := ""
+		if len(rule.Fields) == 1 {
+			target = string(rule.Fields[0])
+			if f, ok := fields[definition.FieldId(target)]; ok {
+				target = string(f.Name)
+			}
+		}
+		l := out[target]
+		if l == nil {
+			l = &limits{}
+			out[target] = l
+		}
+		l.add(rule)
+	}
+	return out
+}
+
+func (l *limits) add(rule *definition.ConstraintRule) {
+	params, _ := rule.Parameters.Value().(map[string]any)
+	num := func(key string) (float64, bool) {
+		switch v := params[key].(type) {
+		case int64:
+			return float64(v), true
+		case float64:
+			return v, true
+		case int:
+			return float64(v), true
+		}
+		return 0, false
+	}
+	switch rule.Predicate {
+	case "min_length":
+		if v, ok := num("min"); ok && (!l.hasMinLen || int(v) > l.minLen) {
+			l.minLen, l.hasMinLen = int(v), true
+		}
+	case "max_length":
+		if v, ok := num("max"); ok && (!l.hasMaxLen || int(v) < l.maxLen) {
+			l.maxLen, l.hasMaxLen = int(v), true
+		}
+	case "greater_than", "greater_than_or_equal":
+		if v, ok := num("min"); ok && (!l.hasLower || v > l.lower) {
+			l.lower, l.hasLower, l.lowerOpen = v, true, rule.Predicate == "greater_than"
+		}
+	case "less_than", "less_than_or_equal":
+		if v, ok := num("max"); ok && (!l.hasUpper || v < l.upper) {
+			l.upper, l.hasUpper, l.upperOpen = v, true, rule.Predicate == "less_than"
+		}
+	case "pattern":
+		if p, ok := params["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil {
+				l.pattern = re
+			}
+		}
+	case "email_format":
+		l.email = true
+	}
+}
+
+// span narrows the default range [lo, hi] to the declared bounds. A single
+// bound keeps the width of the default range.
+func (l *limits) span(lo, hi float64) (float64, float64) {
+	if l == nil {
+		return lo, hi
+	}
+	width := hi - lo
+	switch {
+	case l.hasLower && l.hasUpper:
+		return l.lower, l.upper
+	case l.hasLower:
+		return l.lower, l.lower + width
+	case l.hasUpper:
+		return l.upper - width, l.upper
+	}
+	return lo, hi
+}
+
+// intSpan is span for integers, with open bounds moved inward.
+func (l *limits) intSpan(lo, hi int) (int, int) {
+	flo, fhi := l.span(float64(lo), float64(hi))
+	ilo, ihi := int(math.Ceil(flo)), int(math.Floor(fhi))
+	if l != nil && l.lowerOpen && float64(ilo) == flo {
+		ilo++
+	}
+	if l != nil && l.upperOpen && float64(ihi) == fhi {
+		ihi--
+	}
+	if ihi < ilo {
+		ihi = ilo
+	}
+	return ilo, ihi
+}
+
+// admits reports whether v lies within the declared bounds.
+func (l *limits) admits(v float64) bool {
+	if l == nil {
+		return true
+	}
+	if l.hasLower && (v < l.lower || l.lowerOpen && v == l.lower) {
+		return false
+	}
+	if l.hasUpper && (v > l.upper || l.upperOpen && v == l.upper) {
+		return false
+	}
+	return true
+}
+
+// lengths narrows the default length range [lo, hi] to the declared ones.
+func (l *limits) lengths(lo, hi int) (int, int) {
+	if l == nil {
+		return lo, hi
+	}
+	if l.hasMinLen {
+		lo = l.minLen
+		if hi < lo {
+			hi = lo
+		}
+	}
+	if l.hasMaxLen {
+		hi = l.maxLen
+		if lo > hi {
+			lo = hi
+		}
+	}
+	return lo, hi
+}
+
+// fits reports whether s satisfies the declared length and pattern.
+func (l *limits) fits(s string) bool {
+	if l == nil {
+		return true
+	}
+	n := utf8.RuneCountInString(s)
+	if l.hasMinLen && n < l.minLen || l.hasMaxLen && n > l.maxLen {
+		return false
+	}
+	return l.pattern == nil || l.pattern.MatchString(s)
+}
@@ -2,69 +2,268 @@ package faker
 
 import (
 	"fmt"
-	"math/rand"
+	"math"
+	"sort"
+	"strings"
 
+	"github.com/asaidimu/go-anansi/v8/core/common"
 	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
 	"github.com/brianvoe/gofakeit/v7"
 )
 
+var (
+	ErrGenerateFailed = common.NewSystemError("ERR_FAKER_GENERATE_FAILED", "failed to generate fake data")
+	ErrReferenceCycle = common.NewSystemError("ERR_FAKER_REFERENCE_CYCLE", "collection references form a cycle")
+)
+
+const (
+	// maxAttempts bounds the draws spent on a value that must be unique or
+	// match a pattern, and on a document whose unique index is taken.
+	maxAttempts = 64
+	// omitOneIn and nullOneIn set how often an optional field is left out and
+	// a nullable one is null.
+	omitOneIn = 5
+	nullOneIn = 10
+)
+
+// FakeGenerator produces documents for a schema. Values follow the field
+// type and name, the standard constraint predicates, unique fields and
+// indexes, and the references wired with UseReferences. The same seed yields
+// the same documents.
 type FakeGenerator struct {
 	schema   *definition.Schema
-	rng      *rand.Rand
+	fake     *gofakeit.Faker
 	maxDepth int
-	seeded   bool
+	system   bool
+	limits   map[string]map[string]*limits
+	unique   map[string]map[string]struct{}
+	tuples   map[string]map[string]struct{}
+	pools    map[Reference][]any
+	err      error
 }
 
 func NewFakeGenerator(schema *definition.Schema, seed int64) *FakeGenerator {
 	return &FakeGenerator{
 		schema:   schema,
-		rng:      rand.New(rand.NewSource(seed)),
+		fake:     gofakeit.New(uint64(seed)),
 		maxDepth: 5,
+		system:   true,
+		limits:   map[string]map[string]*limits{},
+		unique:   map[string]map[string]struct{}{},
+		tuples:   map[string]map[string]struct{}{},
+		pools:    map[Reference][]any{},
+	}
+}
+
+// SkipSystemFields leaves the _id_ and _metadata_ system fields out of
+// generated documents, for documents that persistence will assign them to.
+func (g *FakeGenerator) SkipSystemFields() *FakeGenerator {
+	g.system = false
+	return g
+}
+
+// UseReferences supplies the values that fields referencing ref pick from.
+// A reference without values is generated like any other field of its type;
+// one wired with no values is left out when optional and fails the document
+// when required.
+func (g *FakeGenerator) UseReferences(ref Reference, values []any) {
+	g.pools[ref] = values
+}
+
+// Generate returns the next document.
+func (g *FakeGenerator) Generate() (map[string]any, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		g.err = nil
+		out := g.generateObject("", g.schema.BaseSchema, "", 0)
+		if g.err != nil {
+			return nil, g.err
+		}
+		if g.claimIndexes(out) {
+			return out, nil
+		}
 	}
+	return nil, ErrGenerateFailed.WithMessagef("no unused values left for the unique indexes of %s", g.schema.Name)
 }
 
-func (g *FakeGenerator) Generate() map[string]any {
-	if !g.seeded {
-		gofakeit.Seed(g.rng.Int63())
-		g.seeded = true
+func (g *FakeGenerator) fail(err error) {
+	if g.err == nil {
+		g.err = err
 	}
-	return g.generateObject(g.schema.Fields, 0)
 }
 
-func (g *FakeGenerator) generateObject(fields map[definition.FieldId]definition.Field, depth int) map[string]any {
+func (g *FakeGenerator) generateObject(key string, bs definition.BaseSchema, path string, depth int) map[string]any {
+	rules, ok := g.limits[key]
+	if !ok {
+		rules = collectLimits(bs.Fields, bs.Constraints)
+		g.limits[key] = rules
+	}
+	uniqueIndexed := uniqueIndexFields(bs.Indexes)
+
+	fields := make([]definition.Field, 0, len(bs.Fields))
+	for _, f := range bs.Fields {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
 	out := make(map[string]any, len(fields))
 	for _, f := range fields {
-		out[string(f.Name)] = g.generateField(f, depth)
+		name := string(f.Name)
+		if depth == 0 && !g.system && isSystemField(name) {
+			continue
+		}
+		if !f.Required && g.fake.IntN(omitOneIn) == 0 {
+			continue
+		}
+		if f.Nullable && g.fake.IntN(nullOneIn) == 0 {
+			out[name] = nil
+			continue
+		}
+		fieldPath := joinPath(path, name)
+		unique := f.Unique || uniqueIndexed[name]
+		if ref, ok := FieldReference(f); ok && depth == 0 {
+			if pool, wired := g.pools[ref]; wired {
+				if v, ok := g.pickReference(fieldPath, f, ref, pool, rules[name], unique); ok {
+					out[name] = v
+				}
+				continue
+			}
+		}
+		l := rules[name]
+		out[name] = g.uniqueValue(fieldPath, unique, func() any {
+			return g.generateField(f, l, fieldPath, depth)
+		})
 	}
 	return out
 }
 
-func (g *FakeGenerator) generateField(f definition.Field, depth int) any {
+// uniqueValue draws until next returns a value not seen at path. Strings that
+// keep colliding get a numeric suffix.
+func (g *FakeGenerator) uniqueValue(path string, unique bool, next func() any) any {
+	if !unique {
+		return next()
+	}
+	seen := g.unique[path]
+	if seen == nil {
+		seen = map[string]struct{}{}
+		g.unique[path] = seen
+	}
+	var v any
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		v = next()
+		if g.claim(seen, v) {
+			return v
+		}
+	}
+	if s, ok := v.(string); ok {
+		for n := len(seen); ; n++ {
+			if candidate := fmt.Sprintf("%s-%d", s, n); g.claim(seen, candidate) {
+				return candidate
+			}
+		}
+	}
+	g.fail(ErrGenerateFailed.WithMessagef("no unused value left for unique field %s", path).WithPath(path))
+	return v
+}
+
+func (g *FakeGenerator) claim(seen map[string]struct{}, v any) bool {
+	k := fmt.Sprintf("%T:%v", v, v)
+	if _, taken := seen[k]; taken {
+		return false
+	}
+	seen[k] = struct{}{}
+	return true
+}
+
+// pickReference picks values of a referenced field from its pool. It reports
+// false when the field should be left out.
+func (g *FakeGenerator) pickReference(path string, f definition.Field, ref Reference, pool []any, l *limits, unique bool) (any, bool) {
+	if len(pool) == 0 {
+		if f.Required {
+			g.fail(ErrGenerateFailed.WithMessagef("field %s references %s.%s, which has no values", path, ref.Collection, ref.Field).WithPath(path))
+		}
+		return nil, false
+	}
+	start := g.fake.IntN(len(pool))
+	if f.Type == definition.FieldTypeArray {
+		lo, hi := l.lengths(1, 3)
+		n := min(g.fake.Number(lo, hi), len(pool))
+		out := make([]any, n)
+		for i := range out {
+			out[i] = pool[(start+i)%len(pool)]
+		}
+		return out, true
+	}
+	if !unique {
+		return pool[start], true
+	}
+	seen := g.unique[path]
+	if seen == nil {
+		seen = map[string]struct{}{}
+		g.unique[path] = seen
+	}
+	for i := range pool {
+		if v := pool[(start+i)%len(pool)]; g.claim(seen, v) {
+			return v, true
+		}
+	}
+	g.fail(ErrGenerateFailed.WithMessagef("unique field %s has used all %d values of %s.%s", path, len(pool), ref.Collection, ref.Field).WithPath(path))
+	return nil, false
+}
+
+// claimIndexes records the values of out under each unique index spanning
+// several top-level fields. It reports false, claiming nothing, when one of
+// them is taken.
+func (g *FakeGenerator) claimIndexes(out map[string]any) bool {
+	keys := map[string]string{}
+	for id, idx := range g.schema.Indexes {
+		if !idx.Unique || len(idx.Fields) < 2 {
+			continue
+		}
+		parts := make([]string, len(idx.Fields))
+		for i, name := range idx.Fields {
+			parts[i] = fmt.Sprintf("%v", out[string(name)])
+		}
+		k := strings.Join(parts, "\x00")
+		if _, taken := g.tuples[string(id)][k]; taken {
+			return false
+		}
+		keys[string(id)] = k
+	}
+	for id, k := range keys {
+		if g.tuples[id] == nil {
+			g.tuples[id] = map[string]struct{}{}
+		}
+		g.tuples[id][k] = struct{}{}
+	}
+	return true
+}
+
+func (g *FakeGenerator) generateField(f definition.Field, l *limits, path string, depth int) any {
 	switch f.Type {
 	case definition.FieldTypeString:
-		return g.fakeString(f)
+		return g.fakeString(string(f.Name), l)
 	case definition.FieldTypeNumber:
-		return g.fakeNumber()
+		return g.fakeFloat(l, 0.01, 9999.99)
 	case definition.FieldTypeInteger:
-		return g.fakeInteger()
+		return g.fakeInteger(l)
 	case definition.FieldTypeDecimal:
-		return g.fakeDecimal()
+		return g.fakeFloat(l, 0.01, 999999.99)
 	case definition.FieldTypeBoolean:
-		return gofakeit.Bool()
+		return g.fake.Bool()
 	case definition.FieldTypeBytes:
-		return gofakeit.UUID()
+		return g.fake.UUID()
 	case definition.FieldTypeEnum:
 		return g.fakeEnum(f)
 	case definition.FieldTypeObject:
-		return g.fakeObject(f, depth)
+		return g.fakeObject(f, path, depth)
 	case definition.FieldTypeArray:
-		return g.fakeArray(f, depth)
+		return g.fakeArray(f, l, path, depth)
 	case definition.FieldTypeRecord:
-		return g.fakeRecord(f, depth)
+		return g.fakeRecord(f, path, depth)
 	case definition.FieldTypeUnion:
-		return g.fakeUnion(f, depth)
+		return g.fakeUnion(f, path, depth)
 	case definition.FieldTypeComposite:
-		return g.fakeComposite(f, depth)
+		return g.fakeComposite(f, path, depth)
 	case definition.FieldTypeGeometry:
 		return g.fakeGeometry()
 	default:
@@ -72,48 +271,75 @@ func (g *FakeGenerator) generateField(f definition.Field, depth int) any {
 	}
 }
 
-func (g *FakeGenerator) fakeString(f definition.Field) string {
-	return g.fieldBasedString(string(f.Name))
+// fakeString draws a string for the field name, or from the declared pattern
+// or email format, then fits it to the declared length.
+func (g *FakeGenerator) fakeString(name string, l *limits) string {
+	var s string
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		switch {
+		case l != nil && l.pattern != nil:
+			s = g.fake.Regex(l.pattern.String())
+		case l != nil && l.email:
+			s = g.fake.Email()
+		default:
+			s = g.fieldBasedString(name)
+		}
+		if l.fits(s) {
+			return s
+		}
+		if l == nil || l.pattern == nil {
+			break
+		}
+	}
+	lo, hi := l.lengths(0, math.MaxInt)
+	runes := []rune(s)
+	for len(runes) < lo {
+		runes = append(runes, []rune(g.fake.Letter())...)
+	}
+	if len(runes) > hi {
+		runes = runes[:hi]
+	}
+	return string(runes)
 }
 
 func (g *FakeGenerator) fieldBasedString(name string) string {
 	switch name {
-	case "id", "ID", "uuid", "UUID", "uid", "UID":
-		return gofakeit.UUID()
+	case "id", "ID", "uuid", "UUID", "uid", "UID", "_id_":
+		return g.fake.UUID()
 	case "email", "Email", "mail", "Mail":
-		return gofakeit.Email()
+		return g.fake.Email()
 	case "name", "Name", "full_name", "FullName":
-		return gofakeit.Name()
+		return g.fake.Name()
 	case "username", "Username", "user", "User", "handle", "Handle":
-		return gofakeit.Username()
+		return g.fake.Username()
 	case "first_name", "FirstName", "firstname", "first":
-		return gofakeit.FirstName()
+		return g.fake.FirstName()
 	case "last_name", "LastName", "lastname", "last", "surname", "Surname":
-		return gofakeit.LastName()
+		return g.fake.LastName()
 	case "url", "URL", "uri", "URI", "link", "Link", "website", "Website":
-		return gofakeit.URL()
+		return g.fake.URL()
 	case "phone", "Phone", "tel", "Tel", "telephone", "Telephone", "mobile", "Mobile":
-		return gofakeit.Phone()
+		return g.fake.Phone()
 	case "address", "Address", "street", "Street":
-		return gofakeit.Street()
+		return g.fake.Street()
 	case "city", "City":
-		return gofakeit.City()
+		return g.fake.City()
 	case "state", "State", "province", "Province", "region", "Region":
-		return gofakeit.State()
+		return g.fake.State()
 	case "country", "Country":
-		return gofakeit.Country()
+		return g.fake.Country()
 	case "zip", "Zip", "zipcode", "Zipcode", "postal_code", "PostalCode", "postcode":
-		return gofakeit.Zip()
+		return g.fake.Zip()
 	case "description", "Description", "desc", "Desc", "summary", "Summary", "bio", "Bio":
-		return gofakeit.Sentence(10)
+		return g.fake.Sentence(10)
 	case "password", "Password", "token", "Token", "secret", "Secret":
-		return gofakeit.Password(true, true, true, false, false, 24)
+		return g.fake.Password(true, true, true, false, false, 24)
 	case "color", "Color", "colour", "Colour", "hex", "Hex":
-		return gofakeit.HexColor()
+		return g.fake.HexColor()
 	case "ip", "IP", "ip_address", "IPAddress", "ipv4", "IPv4":
-		return gofakeit.IPv4Address()
+		return g.fake.IPv4Address()
 	case "ipv6", "IPv6":
-		return gofakeit.IPv6Address()
+		return g.fake.IPv6Address()
 	case "status", "Status":
 		return g.randomChoice("active", "inactive", "pending", "archived")
 	case "type", "Type", "kind", "Kind", "category", "Category":
@@ -121,65 +347,69 @@ func (g *FakeGenerator) fieldBasedString(name string) string {
 	case "role", "Role":
 		return g.randomChoice("admin", "user", "moderator", "viewer")
 	case "lang", "Lang", "language", "Language", "locale", "Locale":
-		return gofakeit.Language()
+		return g.fake.Language()
 	case "job", "Job", "occupation", "Occupation", "title", "Title":
-		return gofakeit.JobTitle()
+		return g.fake.JobTitle()
 	case "company", "Company", "org", "Org", "organization", "Organization":
-		return gofakeit.Company()
+		return g.fake.Company()
 	case "date", "Date", "created_at", "CreatedAt", "updated_at", "UpdatedAt":
-		return gofakeit.Date().Format("2006-01-02T15:04:05Z")
+		return g.fake.Date().Format("2006-01-02T15:04:05Z")
 	case "time", "Time", "timestamp", "Timestamp":
-		return gofakeit.Date().Format("2006-01-02T15:04:05Z")
+		return g.fake.Date().Format("2006-01-02T15:04:05Z")
 	case "avatar", "Avatar", "image", "Image", "img", "Img", "photo", "Photo":
-		return gofakeit.URL() + "/img/" + gofakeit.UUID()
+		return g.fake.URL() + "/img/" + g.fake.UUID()
 	case "slug", "Slug":
-		return gofakeit.Username()
+		return g.fake.Username()
 	default:
-		return gofakeit.Word()
+		return g.fake.Word()
 	}
 }
 
-func (g *FakeGenerator) fakeNumber() float64 {
-	return gofakeit.Price(0.01, 9999.99)
-}
-
-func (g *FakeGenerator) fakeInteger() int {
-	return gofakeit.Number(0, 99999)
+// fakeFloat draws a two-decimal value from the declared bounds, or from
+// [lo, hi] when none are declared.
+func (g *FakeGenerator) fakeFloat(l *limits, lo, hi float64) float64 {
+	lo, hi = l.span(lo, hi)
+	v := math.Round(g.fake.Float64Range(lo, hi)*100) / 100
+	if !l.admits(v) {
+		v = lo + (hi-lo)/2
+	}
+	return v
 }
 
-func (g *FakeGenerator) fakeDecimal() float64 {
-	return gofakeit.Price(0.01, 999999.99)
+func (g *FakeGenerator) fakeInteger(l *limits) int {
+	lo, hi := l.intSpan(0, 99999)
+	return g.fake.Number(lo, hi)
 }
 
 func (g *FakeGenerator) fakeEnum(f definition.Field) any {
 	if f.Schema.IsZero() {
-		return gofakeit.Word()
+		return g.fake.Word()
 	}
 	if !f.Schema.IsSingle() {
-		return gofakeit.Word()
+		return g.fake.Word()
 	}
 
 	ref, err := definition.FieldSchemaAs[definition.SchemaReference](f.Schema)
 	if err != nil {
-		return gofakeit.Word()
+		return g.fake.Word()
 	}
 
 	if ref.IsInline() {
 		if len(ref.Values) > 0 {
 			return g.pickLiteral(ref.Values)
 		}
-		return gofakeit.Word()
+		return g.fake.Word()
 	}
 
 	ns, ok := g.schema.Schemas[ref.ID]
 	if !ok || len(ns.Values) == 0 {
-		return gofakeit.Word()
+		return g.fake.Word()
 	}
 
 	return g.pickLiteral(ns.Values)
 }
 
-func (g *FakeGenerator) fakeObject(f definition.Field, depth int) any {
+func (g *FakeGenerator) fakeObject(f definition.Field, path string, depth int) any {
 	if depth >= g.maxDepth {
 		return nil
 	}
@@ -200,27 +430,21 @@ func (g *FakeGenerator) fakeObject(f definition.Field, depth int) any {
 		return nil
 	}
 
-	return g.generateObject(ns.Fields, depth+1)
+	return g.generateObject(string(ref.ID), ns.BaseSchema, path, depth+1)
 }
 
-func (g *FakeGenerator) fakeArray(f definition.Field, depth int) any {
+func (g *FakeGenerator) fakeArray(f definition.Field, l *limits, path string, depth int) any {
 	if depth >= g.maxDepth {
 		return nil
 	}
 
-	count := gofakeit.Number(1, 3)
+	lo, hi := l.lengths(1, 3)
+	count := g.fake.Number(lo, hi)
 
-	if f.Schema.IsZero() {
-		out := make([]any, count)
-		for i := range out {
-			out[i] = gofakeit.Word()
-		}
-		return out
-	}
-	if !f.Schema.IsSingle() {
+	if f.Schema.IsZero() || !f.Schema.IsSingle() {
 		out := make([]any, count)
 		for i := range out {
-			out[i] = gofakeit.Word()
+			out[i] = g.fake.Word()
 		}
 		return out
 	}
@@ -229,30 +453,29 @@ func (g *FakeGenerator) fakeArray(f definition.Field, depth int) any {
 	if err != nil {
 		out := make([]any, count)
 		for i := range out {
-			out[i] = gofakeit.Word()
+			out[i] = g.fake.Word()
 		}
 		return out
 	}
 
-	itemType := g.resolveItemType(ref)
 	out := make([]any, count)
 	for i := range out {
-		out[i] = g.generateFromType(itemType, depth+1)
+		out[i] = g.generateFromRef(ref, path, depth+1)
 	}
 	return out
 }
 
-func (g *FakeGenerator) fakeRecord(f definition.Field, depth int) any {
+func (g *FakeGenerator) fakeRecord(f definition.Field, path string, depth int) any {
 	if depth >= g.maxDepth {
 		return nil
 	}
 
-	count := gofakeit.Number(1, 3)
+	count := g.fake.Number(1, 3)
 	out := make(map[string]any, count)
 
 	if f.Schema.IsZero() || !f.Schema.IsSingle() {
 		for i := 0; i < count; i++ {
-			out[fmt.Sprintf("key_%d", i)] = gofakeit.Word()
+			out[fmt.Sprintf("key_%d", i)] = g.fake.Word()
 		}
 		return out
 	}
@@ -262,14 +485,16 @@ func (g *FakeGenerator) fakeRecord(f definition.Field, depth int) any {
 		return out
 	}
 
-	itemType := g.resolveItemType(ref)
 	for i := 0; i < count; i++ {
-		out[gofakeit.Word()] = g.generateFromType(itemType, depth+1)
+		out[g.fake.Word()] = g.generateFromRef(ref, path, depth+1)
 	}
 	return out
 }
 
-func (g *FakeGenerator) fakeUnion(f definition.Field, depth int) any {
+// fakeUnion generates one of the union's variants. Object variants carry
+// their own literal tag fields, so the result matches the variant it was
+// generated from.
+func (g *FakeGenerator) fakeUnion(f definition.Field, path string, depth int) any {
 	if depth >= g.maxDepth {
 		return nil
 	}
@@ -282,20 +507,10 @@ func (g *FakeGenerator) fakeUnion(f definition.Field, depth int) any {
 		return nil
 	}
 
-	ref := refs[g.rng.Intn(len(refs))]
-	if ref.IsInline() {
-		return g.generateFromType(ref.Type, depth+1)
-	}
-
-	ns, ok := g.schema.Schemas[ref.ID]
-	if !ok {
-		return nil
-	}
-
-	return g.generateFromNested(ns, depth+1)
+	return g.generateFromRef(refs[g.fake.IntN(len(refs))], path, depth+1)
 }
 
-func (g *FakeGenerator) fakeComposite(f definition.Field, depth int) any {
+func (g *FakeGenerator) fakeComposite(f definition.Field, path string, depth int) any {
 	if depth >= g.maxDepth {
 		return nil
 	}
@@ -314,10 +529,8 @@ func (g *FakeGenerator) fakeComposite(f definition.Field, depth int) any {
 		if !ok {
 			continue
 		}
-		if obj := g.generateObject(ns.Fields, depth+1); obj != nil {
-			for k, v := range obj {
-				merged[k] = v
-			}
+		for k, v := range g.generateObject(string(ref.ID), ns.BaseSchema, path, depth+1) {
+			merged[k] = v
 		}
 	}
 	if len(merged) == 0 {
@@ -329,50 +542,55 @@ func (g *FakeGenerator) fakeComposite(f definition.Field, depth int) any {
 func (g *FakeGenerator) fakeGeometry() any {
 	return map[string]any{
 		"type":        "Point",
-		"coordinates": []float64{gofakeit.Longitude(), gofakeit.Latitude()},
+		"coordinates": []float64{g.fake.Longitude(), g.fake.Latitude()},
 	}
 }
 
-func (g *FakeGenerator) resolveItemType(ref definition.SchemaReference) definition.FieldType {
-	if ref.IsInline() && ref.Type != 0 {
-		return ref.Type
-	}
-	if ns, ok := g.schema.Schemas[ref.ID]; ok {
-		if ns.Type != 0 {
-			return ns.Type
-		}
-		if len(ns.Fields) > 0 {
-			return definition.FieldTypeObject
+// generateFromRef generates a value of the schema ref points at: a literal
+// of an enum, an object of a schema with fields, or a value of a type.
+func (g *FakeGenerator) generateFromRef(ref definition.SchemaReference, path string, depth int) any {
+	if ref.IsInline() {
+		if len(ref.Values) > 0 {
+			return g.pickLiteral(ref.Values)
 		}
+		return g.generateFromType(ref.Type, collectLimits(nil, ref.Constraints)[""])
 	}
-	return definition.FieldTypeUnknown
+	ns, ok := g.schema.Schemas[ref.ID]
+	if !ok {
+		return g.fake.Word()
+	}
+	return g.generateFromNested(ref.ID, ns, path, depth)
 }
 
-func (g *FakeGenerator) generateFromType(t definition.FieldType, depth int) any {
+func (g *FakeGenerator) generateFromType(t definition.FieldType, l *limits) any {
 	switch t {
 	case definition.FieldTypeString:
-		return gofakeit.Word()
+		return g.fakeString("", l)
 	case definition.FieldTypeNumber:
-		return g.fakeNumber()
+		return g.fakeFloat(l, 0.01, 9999.99)
 	case definition.FieldTypeInteger:
-		return g.fakeInteger()
+		return g.fakeInteger(l)
 	case definition.FieldTypeDecimal:
-		return g.fakeDecimal()
+		return g.fakeFloat(l, 0.01, 999999.99)
 	case definition.FieldTypeBoolean:
-		return gofakeit.Bool()
+		return g.fake.Bool()
 	case definition.FieldTypeObject:
 		return nil
 	default:
-		return gofakeit.Word()
+		return g.fake.Word()
 	}
 }
 
-func (g *FakeGenerator) generateFromNested(ns definition.NestedSchema, depth int) any {
+func (g *FakeGenerator) generateFromNested(id definition.SchemaId, ns definition.NestedSchema, path string, depth int) any {
 	if len(ns.Values) > 0 {
 		return g.pickLiteral(ns.Values)
 	}
 	if len(ns.Fields) > 0 {
-		return g.generateObject(ns.Fields, depth)
+		return g.generateObject(string(id), ns.BaseSchema, path, depth)
+	}
+	if ns.Type != 0 {
+		f := definition.Field{Name: definition.FieldName(ns.Name), FieldProperties: ns.FieldProperties}
+		return g.generateField(f, collectLimits(nil, ns.Constraints)[""], path, depth)
 	}
 	return nil
 }
@@ -381,10 +599,33 @@ func (g *FakeGenerator) pickLiteral(values []definition.LiteralValue) any {
 	if len(values) == 0 {
 		return nil
 	}
-	lv := values[g.rng.Intn(len(values))]
+	lv := values[g.fake.IntN(len(values))]
 	return lv.Value()
 }
 
 func (g *FakeGenerator) randomChoice(options ...string) string {
-	return options[g.rng.Intn(len(options))]
+	return options[g.fake.IntN(len(options))]
+}
+
+// uniqueIndexFields returns the fields made unique by a single-field unique
+// index.
+func uniqueIndexFields(indexes map[definition.IndexID]definition.Index) map[string]bool {
+	out := map[string]bool{}
+	for _, idx := range indexes {
+		if idx.Unique && len(idx.Fields) == 1 {
+			out[string(idx.Fields[0])] = true
+		}
+	}
+	return out
+}
+
+func isSystemField(name string) bool {
+	return name == "_id_" || name == "_metadata_"
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
 }
@@ -0,0 +1,165 @@
+package faker
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+)
+
+const usersSchema = `{
+  "version": "1.0.0",
+  "name": "users",
+  "fields": {
+    "email":  { "name": "email", "type": "string", "required": true, "unique": true },
+    "handle": { "name": "handle", "type": "string", "required": true },
+    "age":    { "name": "age", "type": "integer", "required": true },
+    "score":  { "name": "score", "type": "number", "required": true },
+    "code":   { "name": "code", "type": "string", "required": true, "unique": true },
+    "tags":   { "name": "tags", "type": "array", "required": true, "schema": { "type": "string" } },
+    "plan":   { "name": "plan", "type": "enum", "required": true, "schema": { "type": "string", "values": ["free", "pro"] } },
+    "note":   { "name": "note", "type": "string" }
+  },
+  "constraints": {
+    "c1": { "name": "email", "fields": ["email"], "predicate": "email_format" },
+    "c2": { "name": "adult", "fields": ["age"], "predicate": "greater_than_or_equal", "parameters": { "min": 18 } },
+    "c3": { "name": "age_cap", "fields": ["age"], "predicate": "less_than", "parameters": { "max": 21 } },
+    "c4": { "name": "score", "fields": ["score"], "predicate": "greater_than", "parameters": { "min": 0 } },
+    "c5": { "name": "score_cap", "fields": ["score"], "predicate": "less_than_or_equal", "parameters": { "max": 1 } },
+    "c6": { "name": "code", "fields": ["code"], "predicate": "pattern", "parameters": { "pattern": "^[A-Z]{3}-[0-9]{4}$" } },
+    "c7": { "name": "handle_min", "fields": ["handle"], "predicate": "min_length", "parameters": { "min": 12 } },
+    "c8": { "name": "handle_max", "fields": ["handle"], "predicate": "max_length", "parameters": { "max": 14 } },
+    "c9": { "name": "tags", "fields": ["tags"], "predicate": "min_length", "parameters": { "min": 4 } }
+  }
+}`
+
+const postsSchema = `{
+  "version": "1.0.0",
+  "name": "posts",
+  "fields": {
+    "author":   { "name": "author", "type": "string", "required": true, "metadata": { "references": "users" } },
+    "reviewer": { "name": "reviewer", "type": "string", "required": true, "unique": true, "metadata": { "references": { "collection": "users", "field": "email" } } },
+    "body":     { "name": "body", "type": "union", "required": true, "schema": [{ "id": "text" }, { "id": "image" }] }
+  },
+  "schemas": {
+    "text":  { "name": "text", "fields": {
+      "kind": { "name": "kind", "type": "enum", "required": true, "schema": { "type": "string", "values": ["text"] } },
+      "words": { "name": "words", "type": "string", "required": true } } },
+    "image": { "name": "image", "fields": {
+      "kind": { "name": "kind", "type": "enum", "required": true, "schema": { "type": "string", "values": ["image"] } },
+      "url":  { "name": "url", "type": "string", "required": true } } }
+  }
+}`
+
+func parse(t *testing.T, js string) *definition.Schema {
+	t.Helper()
+	s, err := definition.FromJSON([]byte(js))
+	require.NoError(t, err)
+	return s
+}
+
+func generate(t *testing.T, g *FakeGenerator, n int) []map[string]any {
+	t.Helper()
+	out := make([]map[string]any, n)
+	for i := range out {
+		doc, err := g.Generate()
+		require.NoError(t, err)
+		out[i] = doc
+	}
+	return out
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	s := parse(t, usersSchema)
+	a := generate(t, NewFakeGenerator(s, 7), 20)
+	b := generate(t, NewFakeGenerator(s, 7), 20)
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, generate(t, NewFakeGenerator(s, 8), 20))
+}
+
+func TestGenerate_Constraints(t *testing.T) {
+	email := regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	code := regexp.MustCompile(`^[A-Z]{3}-[0-9]{4}$`)
+	omitted := 0
+	for _, doc := range generate(t, NewFakeGenerator(parse(t, usersSchema), 1), 200) {
+		assert.Regexp(t, email, doc["email"])
+		assert.Regexp(t, code, doc["code"])
+		age := doc["age"].(int)
+		assert.True(t, age >= 18 && age < 21, "age %d", age)
+		score := doc["score"].(float64)
+		assert.True(t, score > 0 && score <= 1, "score %v", score)
+		handle := []rune(doc["handle"].(string))
+		assert.True(t, len(handle) >= 12 && len(handle) <= 14, "handle %q", string(handle))
+		assert.GreaterOrEqual(t, len(doc["tags"].([]any)), 4)
+		assert.Contains(t, []any{"free", "pro"}, doc["plan"])
+		if _, ok := doc["note"]; !ok {
+			omitted++
+		}
+	}
+	assert.Positive(t, omitted, "optional fields are sometimes left out")
+}
+
+func TestGenerate_Unique(t *testing.T) {
+	emails, codes := map[any]bool{}, map[any]bool{}
+	for _, doc := range generate(t, NewFakeGenerator(parse(t, usersSchema), 3), 500) {
+		assert.False(t, emails[doc["email"]], "duplicate email %v", doc["email"])
+		assert.False(t, codes[doc["code"]], "duplicate code %v", doc["code"])
+		emails[doc["email"]], codes[doc["code"]] = true, true
+	}
+}
+
+func TestGenerate_References(t *testing.T) {
+	posts := parse(t, postsSchema)
+	refs := References(posts)
+	require.Equal(t, Reference{Collection: "users", Field: "_id_"}, refs["author"])
+	require.Equal(t, Reference{Collection: "users", Field: "email"}, refs["reviewer"])
+
+	g := NewFakeGenerator(posts, 5)
+	g.UseReferences(refs["author"], []any{"u1", "u2"})
+	g.UseReferences(refs["reviewer"], []any{"a@x.io", "b@x.io", "c@x.io"})
+	reviewers := map[any]bool{}
+	for _, doc := range generate(t, g, 3) {
+		assert.Contains(t, []any{"u1", "u2"}, doc["author"])
+		assert.False(t, reviewers[doc["reviewer"]], "unique reference reused")
+		reviewers[doc["reviewer"]] = true
+
+		body := doc["body"].(map[string]any)
+		switch body["kind"] {
+		case "text":
+			assert.Contains(t, body, "words")
+		case "image":
+			assert.Contains(t, body, "url")
+		default:
+			t.Fatalf("unexpected union variant %v", body)
+		}
+	}
+	_, err := g.Generate()
+	errcode.Require(t, err, ErrGenerateFailed.Code, "a unique reference cannot outnumber its values")
+
+	empty := NewFakeGenerator(posts, 5)
+	empty.UseReferences(refs["author"], nil)
+	_, err = empty.Generate()
+	errcode.Require(t, err, ErrGenerateFailed.Code, "a required reference needs values")
+}
+
+func TestOrder(t *testing.T) {
+	users, posts := parse(t, usersSchema), parse(t, postsSchema)
+	ordered, err := Order([]*definition.Schema{posts, users})
+	require.NoError(t, err)
+	assert.Equal(t, []*definition.Schema{users, posts}, ordered)
+
+	a := parse(t, `{"version":"1.0.0","name":"a","fields":{"b":{"name":"b","type":"string","metadata":{"references":"b"}}}}`)
+	b := parse(t, `{"version":"1.0.0","name":"b","fields":{"a":{"name":"a","type":"string","required":true,"metadata":{"references":"a"}}}}`)
+	ordered, err = Order([]*definition.Schema{b, a})
+	require.NoError(t, err, "an optional reference breaks the cycle")
+	assert.Equal(t, []*definition.Schema{a, b}, ordered)
+
+	c := parse(t, `{"version":"1.0.0","name":"c","fields":{"b":{"name":"b","type":"string","required":true,"metadata":{"references":"b"}}}}`)
+	b2 := parse(t, `{"version":"1.0.0","name":"b","fields":{"c":{"name":"c","type":"string","required":true,"metadata":{"references":"c"}}}}`)
+	_, err = Order([]*definition.Schema{b2, c})
+	errcode.Require(t, err, ErrReferenceCycle.Code)
+}
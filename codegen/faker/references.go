@@ -0,0 +1,125 @@
+package faker
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// idField is the system field a reference targets when it names no field.
+const idField = "_id_"
+
+// Reference is a foreign-key-like link from a field to a field of another
+// collection, declared in the field's metadata:
+//
+//	"metadata": { "references": { "collection": "users", "field": "_id_" } }
+//
+// The shorthand "references": "users" targets the referenced collection's
+// _id_, and "references": "users.email" its email field. An array field with a
+// reference holds several referenced values.
+type Reference struct {
+	Collection string
+	Field      string
+}
+
+// FieldReference returns the reference declared on f, if any.
+func FieldReference(f definition.Field) (Reference, bool) {
+	var ref Reference
+	switch v := f.Metadata["references"].(type) {
+	case string:
+		ref.Collection, ref.Field, _ = strings.Cut(v, ".")
+	case map[string]any:
+		ref.Collection, _ = v["collection"].(string)
+		ref.Field, _ = v["field"].(string)
+	}
+	if ref.Collection == "" {
+		return Reference{}, false
+	}
+	if ref.Field == "" {
+		ref.Field = idField
+	}
+	return ref, true
+}
+
+// References returns the references declared on the top-level fields of s,
+// keyed by field name.
+func References(s *definition.Schema) map[definition.FieldName]Reference {
+	out := make(map[definition.FieldName]Reference)
+	for _, f := range s.Fields {
+		if ref, ok := FieldReference(f); ok {
+			out[f.Name] = ref
+		}
+	}
+	return out
+}
+
+// Order sorts schemas so every collection follows the collections it
+// references, breaking ties by name. References to collections outside the
+// set and to the collection itself do not constrain the order. When the
+// references form a cycle, only required reference fields are kept; a cycle
+// among those is an error, since no collection in it can be generated first.
+func Order(schemas []*definition.Schema) ([]*definition.Schema, error) {
+	ordered, cycle := order(schemas, false)
+	if cycle == nil {
+		return ordered, nil
+	}
+	if ordered, cycle = order(schemas, true); cycle == nil {
+		return ordered, nil
+	}
+	return nil, ErrReferenceCycle.WithMessagef("required references form a cycle between %s", strings.Join(cycle, ", "))
+}
+
+// order is a Kahn topological sort. It returns the names left unsorted when
+// the dependencies form a cycle.
+func order(schemas []*definition.Schema, requiredOnly bool) ([]*definition.Schema, []string) {
+	byName := make(map[string]*definition.Schema, len(schemas))
+	for _, s := range schemas {
+		byName[s.Name] = s
+	}
+	pending := make(map[string]int, len(schemas))
+	dependents := make(map[string][]string)
+	for _, s := range schemas {
+		deps := map[string]bool{}
+		for _, f := range s.Fields {
+			ref, ok := FieldReference(f)
+			if !ok || ref.Collection == s.Name || byName[ref.Collection] == nil || requiredOnly && !f.Required {
+				continue
+			}
+			deps[ref.Collection] = true
+		}
+		pending[s.Name] = len(deps)
+		for dep := range deps {
+			dependents[dep] = append(dependents[dep], s.Name)
+		}
+	}
+
+	var ready []string
+	for name, n := range pending {
+		if n == 0 {
+			ready = append(ready, name)
+		}
+	}
+	out := make([]*definition.Schema, 0, len(schemas))
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		out = append(out, byName[name])
+		delete(pending, name)
+		for _, d := range dependents[name] {
+			if pending[d]--; pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+	if len(pending) == 0 {
+		return out, nil
+	}
+	cycle := make([]string, 0, len(pending))
+	for name := range pending {
+		cycle = append(cycle, name)
+	}
+	sort.Strings(cycle)
+	return nil, cycle
+}
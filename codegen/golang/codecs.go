@@ -0,0 +1,290 @@
+package golang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// codecKinds lists the leaf kinds the document codecs read and write through a
+// typed Encoder/Decoder method, keyed by Go type.
+var codecKinds = map[string]string{
+	"string":  "String",
+	"int64":   "Int",
+	"float64": "Float",
+	"bool":    "Bool",
+}
+
+// codecArrays lists the array element types with a typed Encoder/Decoder
+// method.
+var codecArrays = map[string]string{
+	"string":  "Strings",
+	"int64":   "Ints",
+	"float64": "Floats",
+	"bool":    "Bools",
+}
+
+// codecSet decides which generated structs get document codecs. A struct
+// qualifies when every field has a codec-supported kind and every struct it
+// nests qualifies as well; the rest keep the reflective binder.
+type codecSet struct {
+	structs  map[string][]StructField
+	enumDefs map[string]EnumDef
+	root     string
+	ok       map[string]bool
+}
+
+func newCodecSet(structs map[string][]StructField, enumDefs map[string]EnumDef, root string, rootFields []StructField) *codecSet {
+	s := &codecSet{structs: make(map[string][]StructField, len(structs)), enumDefs: enumDefs, root: root, ok: map[string]bool{}}
+	for name, fields := range structs {
+		s.structs[name] = fields
+	}
+	s.structs[root] = rootFields
+	for name := range s.structs {
+		s.ok[name] = true
+	}
+	// Drop unsupported structs until the set is stable, so a struct nesting a
+	// dropped one is dropped too.
+	for changed := true; changed; {
+		changed = false
+		for name := range s.ok {
+			if !s.supports(name) {
+				delete(s.ok, name)
+				changed = true
+			}
+		}
+	}
+	return s
+}
+
+func (s *codecSet) supports(name string) bool {
+	for _, f := range s.structs[name] {
+		if f.FieldName == "" || f.Name == "EncodeDocument" || f.Name == "DecodeDocument" || anansiTagName(f.Tags) != f.FieldName {
+			return false
+		}
+		if name == s.root && (f.FieldName == "_id_" || f.FieldName == "_metadata_") {
+			if f.FieldName == "_id_" && f.Type != "string" {
+				return false
+			}
+			if f.FieldName == "_metadata_" && f.Type != "map[string]any" && !s.nests(f) {
+				return false
+			}
+			continue
+		}
+		if !s.field(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// nests reports whether f is an object field whose struct has codecs. The
+// root model is never nested: its codecs write document identity.
+func (s *codecSet) nests(f StructField) bool {
+	to := strings.TrimPrefix(f.Type, "*")
+	return f.Kind == "object" && to != s.root && s.ok[to]
+}
+
+func (s *codecSet) field(f StructField) bool {
+	base := strings.TrimPrefix(f.Type, "*")
+	switch f.Kind {
+	case "string", "number", "integer", "boolean":
+		_, ok := codecKinds[base]
+		return ok
+	case "enum":
+		_, _, ok := s.leaf(base)
+		return ok
+	case "bytes":
+		return f.Type == "[]byte"
+	case "unknown":
+		return f.Type == "any"
+	case "record":
+		return f.Type == "map[string]any"
+	case "object":
+		return s.nests(f)
+	case "array":
+		elem := strings.TrimPrefix(f.Type, "[]")
+		if _, ok := codecArrays[elem]; ok {
+			return true
+		}
+		return elem != s.root && s.ok[elem]
+	}
+	return false
+}
+
+// leaf returns the Encoder/Decoder method and plain Go type for a primitive
+// or enum type.
+func (s *codecSet) leaf(goType string) (method, plain string, ok bool) {
+	if m, ok := codecKinds[goType]; ok {
+		return m, goType, true
+	}
+	if enum, ok := s.enumDefs[goType]; ok {
+		if m, ok := codecKinds[enum.Underlying]; ok {
+			return m, enum.Underlying, true
+		}
+	}
+	return "", "", false
+}
+
+// anansiTagName returns the field name of the anansi tag in a struct tag
+// string, or "" when there is none.
+func anansiTagName(tags string) string {
+	name, _, _ := strings.Cut(anansiTagValue(tags), ",")
+	return name
+}
+
+// anansiOmitEmpty reports whether the anansi tag carries omitempty, which the
+// reflective binder honors when building full documents.
+func anansiOmitEmpty(tags string) bool {
+	for _, opt := range strings.Split(anansiTagValue(tags), ",")[1:] {
+		if opt == "omitempty" {
+			return true
+		}
+	}
+	return false
+}
+
+func anansiTagValue(tags string) string {
+	_, rest, ok := strings.Cut(strings.Trim(tags, "`"), `anansi:"`)
+	if !ok {
+		return ""
+	}
+	v, _, _ := strings.Cut(rest, `"`)
+	return v
+}
+
+// emitCodecs writes EncodeDocument and DecodeDocument for the root model and
+// every struct that qualifies. document.DocumentPool uses them in place of the
+// reflective binder, so ModelCollection creates and reads models without
+// walking struct tags.
+func (g *GoGenerator) emitCodecs(sb *strings.Builder, rootTypeName string, rootFields []StructField, structs map[string][]StructField, enumDefs map[string]EnumDef) {
+	s := newCodecSet(structs, enumDefs, rootTypeName, rootFields)
+	names := make([]string, 0, len(s.ok))
+	for name := range s.ok {
+		if name != rootTypeName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if s.ok[rootTypeName] {
+		names = append([]string{rootTypeName}, names...)
+	}
+	for _, name := range names {
+		fields := append([]StructField(nil), s.structs[name]...)
+		sort.Slice(fields, func(i, j int) bool { return fields[i].FieldName < fields[j].FieldName })
+		s.emitEncoder(sb, name, fields)
+		s.emitDecoder(sb, name, fields)
+	}
+}
+
+func (s *codecSet) emitEncoder(sb *strings.Builder, name string, fields []StructField) {
+	root := name == s.root
+	fmt.Fprintf(sb, "// EncodeDocument writes the %s fields into a document without reflection.\n", name)
+	fmt.Fprintf(sb, "func (m *%s) EncodeDocument(e *document.Encoder) error {\n", name)
+	if root {
+		sb.WriteString("    if !e.Partial() {\n")
+		sb.WriteString("        e.ID(m.DocumentModel.ID)\n")
+		sb.WriteString("        e.MetadataMap(m.DocumentModel.Metadata)\n")
+		sb.WriteString("    }\n")
+	}
+	for _, f := range fields {
+		omit := anansiOmitEmpty(f.Tags)
+		base := strings.TrimPrefix(f.Type, "*")
+		opt := base != f.Type
+		switch {
+		case root && f.FieldName == "_id_":
+			fmt.Fprintf(sb, "    e.ID(m.%s)\n", f.Name)
+		case root && f.FieldName == "_metadata_" && f.Kind == "record":
+			fmt.Fprintf(sb, "    e.MetadataMap(m.%s)\n", f.Name)
+		case root && f.FieldName == "_metadata_":
+			fmt.Fprintf(sb, "    if m.%s != nil {\n        e.Metadata(m.%s)\n    }\n", f.Name, f.Name)
+		case f.Kind == "object" && opt:
+			fmt.Fprintf(sb, "    if m.%s != nil {\n        e.Object(%q, m.%s)\n    }\n", f.Name, f.FieldName, f.Name)
+		case f.Kind == "object":
+			fmt.Fprintf(sb, "    e.Object(%q, &m.%s)\n", f.FieldName, f.Name)
+		case f.Kind == "array":
+			elem := strings.TrimPrefix(f.Type, "[]")
+			if method, ok := codecArrays[elem]; ok {
+				fmt.Fprintf(sb, "    e.%s(%q, m.%s, %t)\n", method, f.FieldName, f.Name, omit)
+			} else {
+				fmt.Fprintf(sb, "    document.EncodeObjects(e, %q, m.%s, %t)\n", f.FieldName, f.Name, omit)
+			}
+		case f.Kind == "bytes":
+			fmt.Fprintf(sb, "    e.Bytes(%q, m.%s, %t)\n", f.FieldName, f.Name, omit)
+		case f.Kind == "record":
+			fmt.Fprintf(sb, "    e.Record(%q, m.%s, %t)\n", f.FieldName, f.Name, omit)
+		case f.Kind == "unknown":
+			fmt.Fprintf(sb, "    e.Value(%q, m.%s, %t)\n", f.FieldName, f.Name, omit)
+		default:
+			method, plain, _ := s.leaf(base)
+			value := "m." + f.Name
+			if plain != base {
+				if opt {
+					value = fmt.Sprintf("(*%s)(%s)", plain, value)
+				} else {
+					value = fmt.Sprintf("%s(%s)", plain, value)
+				}
+			}
+			if opt {
+				method = "Opt" + method
+			}
+			fmt.Fprintf(sb, "    e.%s(%q, %s, %t)\n", method, f.FieldName, value, omit)
+		}
+	}
+	sb.WriteString("    return e.Err()\n}\n\n")
+}
+
+func (s *codecSet) emitDecoder(sb *strings.Builder, name string, fields []StructField) {
+	root := name == s.root
+	fmt.Fprintf(sb, "// DecodeDocument reads the %s fields from a document without reflection.\n", name)
+	fmt.Fprintf(sb, "func (m *%s) DecodeDocument(d *document.Decoder) error {\n", name)
+	if root {
+		sb.WriteString("    d.Model(&m.DocumentModel, m)\n")
+	}
+	for _, f := range fields {
+		base := strings.TrimPrefix(f.Type, "*")
+		opt := base != f.Type
+		switch {
+		case root && f.FieldName == "_id_":
+			fmt.Fprintf(sb, "    m.%s = d.ID()\n", f.Name)
+		case root && f.FieldName == "_metadata_" && f.Kind == "record":
+			fmt.Fprintf(sb, "    m.%s = d.MetadataMap()\n", f.Name)
+		case f.Kind == "object" && opt:
+			fmt.Fprintf(sb, "    m.%s = nil\n", f.Name)
+			fmt.Fprintf(sb, "    if d.Has(%q) {\n", f.FieldName)
+			fmt.Fprintf(sb, "        m.%s = &%s{}\n", f.Name, base)
+			fmt.Fprintf(sb, "        d.Object(%q, m.%s)\n", f.FieldName, f.Name)
+			sb.WriteString("    }\n")
+		case f.Kind == "object":
+			fmt.Fprintf(sb, "    d.Object(%q, &m.%s)\n", f.FieldName, f.Name)
+		case f.Kind == "array":
+			elem := strings.TrimPrefix(f.Type, "[]")
+			if method, ok := codecArrays[elem]; ok {
+				fmt.Fprintf(sb, "    m.%s = d.%s(%q)\n", f.Name, method, f.FieldName)
+			} else {
+				fmt.Fprintf(sb, "    m.%s = document.DecodeObjects[%s](d, %q)\n", f.Name, elem, f.FieldName)
+			}
+		case f.Kind == "bytes":
+			fmt.Fprintf(sb, "    m.%s = d.Bytes(%q)\n", f.Name, f.FieldName)
+		case f.Kind == "record":
+			fmt.Fprintf(sb, "    m.%s = d.Record(%q)\n", f.Name, f.FieldName)
+		case f.Kind == "unknown":
+			fmt.Fprintf(sb, "    m.%s = d.Value(%q)\n", f.Name, f.FieldName)
+		default:
+			method, plain, _ := s.leaf(base)
+			if opt {
+				method = "Opt" + method
+			}
+			value := fmt.Sprintf("d.%s(%q)", method, f.FieldName)
+			if plain != base {
+				if opt {
+					value = fmt.Sprintf("(*%s)(%s)", base, value)
+				} else {
+					value = fmt.Sprintf("%s(%s)", base, value)
+				}
+			}
+			fmt.Fprintf(sb, "    m.%s = %s\n", f.Name, value)
+		}
+	}
+	sb.WriteString("    return d.Err()\n}\n\n")
+}
@@ -0,0 +1,185 @@
+package golang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// fieldsImport is the runtime package backing the generated field descriptors.
+const fieldsImport = "\"github.com/asaidimu/go-anansi/v8/core/query/fields\""
+
+// descriptorSetName returns the name of the descriptor struct for a type.
+func descriptorSetName(typeName string) string {
+	return typeName + "FieldSet"
+}
+
+// descriptorFieldsVar returns the name of the root descriptor variable. Scoped
+// packages hold a single model, so the type name is dropped, as with New.
+func descriptorFieldsVar(scoped bool, rootTypeName string) string {
+	if scoped {
+		return "Fields"
+	}
+	return rootTypeName + "Fields"
+}
+
+// emitFieldDescriptors writes the typed field descriptors for the root model:
+// a FieldSet struct for the root and for every schema reached through object
+// fields, and the variable holding the root's descriptors. Nested sets take
+// their path prefix at construction so a schema used under several fields
+// shares one type. An object field whose schema can reach back to the
+// enclosing schema is described as a plain fields.Object, since a set cannot
+// contain itself.
+func (g *GoGenerator) emitFieldDescriptors(sb *strings.Builder, rootTypeName string, rootFields []StructField, structs map[string][]StructField, taken func(string) bool) error {
+	sets := map[string][]StructField{rootTypeName: rootFields}
+	descends := func(from string, f StructField) (string, bool) {
+		if f.Kind != "object" {
+			return "", false
+		}
+		to := strings.TrimPrefix(f.Type, "*")
+		if _, ok := structs[to]; !ok || to == from || reaches(structs, to, from) {
+			return "", false
+		}
+		return to, true
+	}
+
+	var order []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		for _, f := range sets[name] {
+			to, ok := descends(name, f)
+			if !ok {
+				continue
+			}
+			if _, seen := sets[to]; seen {
+				continue
+			}
+			if taken(descriptorSetName(to)) {
+				return fmt.Errorf("field descriptor type %s collides with an existing type", descriptorSetName(to))
+			}
+			sets[to] = structs[to]
+			order = append(order, to)
+			if err := visit(to); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if taken(descriptorSetName(rootTypeName)) {
+		return fmt.Errorf("field descriptor type %s collides with an existing type", descriptorSetName(rootTypeName))
+	}
+	if err := visit(rootTypeName); err != nil {
+		return err
+	}
+	sort.Strings(order)
+
+	type entry struct {
+		name, typ, ctor string
+	}
+	entries := func(owner string, path func(string) string) []entry {
+		fields := make([]StructField, 0, len(sets[owner]))
+		for _, f := range sets[owner] {
+			if f.FieldName != "" {
+				fields = append(fields, f)
+			}
+		}
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+		out := make([]entry, 0, len(fields))
+		for _, f := range fields {
+			p := path(f.FieldName)
+			if to, ok := descends(owner, f); ok {
+				out = append(out, entry{f.Name, descriptorSetName(to), fmt.Sprintf("new%s(%s)", descriptorSetName(to), p)})
+				continue
+			}
+			typ, ctor := descriptorFor(f)
+			out = append(out, entry{f.Name, typ, fmt.Sprintf("%s(%s)", ctor, p)})
+		}
+		return out
+	}
+	writeSet := func(name string, embed bool, es []entry) {
+		fmt.Fprintf(sb, "type %s struct {\n", name)
+		if embed {
+			sb.WriteString("    fields.Object\n")
+		}
+		for _, e := range es {
+			fmt.Fprintf(sb, "    %s %s\n", e.name, e.typ)
+		}
+		sb.WriteString("}\n\n")
+	}
+
+	rootEntries := entries(rootTypeName, func(n string) string { return fmt.Sprintf("%q", n) })
+	rootSet := descriptorSetName(rootTypeName)
+	fieldsVar := descriptorFieldsVar(g.scoped, rootTypeName)
+	fmt.Fprintf(sb, "// %s holds a typed descriptor for each %s field.\n", rootSet, rootTypeName)
+	writeSet(rootSet, false, rootEntries)
+	fmt.Fprintf(sb, "// %s describes the %s fields for building typed filters, sorts,\n", fieldsVar, rootTypeName)
+	sb.WriteString("// projections and updates.\n")
+	fmt.Fprintf(sb, "var %s = %s{\n", fieldsVar, rootSet)
+	for _, e := range rootEntries {
+		fmt.Fprintf(sb, "    %s: %s,\n", e.name, e.ctor)
+	}
+	sb.WriteString("}\n\n")
+
+	for _, name := range order {
+		set := descriptorSetName(name)
+		es := entries(name, func(n string) string { return fmt.Sprintf("path + %q", "."+n) })
+		// A schema field named Object would clash with the embedded descriptor.
+		embed := true
+		for _, e := range es {
+			if e.name == "Object" {
+				embed = false
+			}
+		}
+		fmt.Fprintf(sb, "// %s holds a typed descriptor for each %s field.\n", set, name)
+		writeSet(set, embed, es)
+		fmt.Fprintf(sb, "func new%s(path string) %s {\n", set, set)
+		fmt.Fprintf(sb, "    return %s{\n", set)
+		if embed {
+			sb.WriteString("        Object: fields.NewObject(path),\n")
+		}
+		for _, e := range es {
+			fmt.Fprintf(sb, "        %s: %s,\n", e.name, e.ctor)
+		}
+		sb.WriteString("    }\n}\n\n")
+	}
+	return nil
+}
+
+// descriptorFor returns the descriptor type and constructor for a field that
+// is not described by a nested set.
+func descriptorFor(f StructField) (typ, ctor string) {
+	goType := strings.TrimPrefix(f.Type, "*")
+	switch f.Kind {
+	case "string":
+		return "fields.String", "fields.NewString"
+	case "number", "integer", "decimal":
+		return "fields.Ordered[" + goType + "]", "fields.NewOrdered[" + goType + "]"
+	case "boolean", "enum":
+		return "fields.Field[" + goType + "]", "fields.New[" + goType + "]"
+	default:
+		return "fields.Object", "fields.NewObject"
+	}
+}
+
+// reaches reports whether schema to can be reached from schema from through
+// object fields.
+func reaches(structs map[string][]StructField, from, to string) bool {
+	seen := map[string]bool{}
+	var walk func(string) bool
+	walk = func(name string) bool {
+		if name == to {
+			return true
+		}
+		if seen[name] {
+			return false
+		}
+		seen[name] = true
+		for _, f := range structs[name] {
+			if f.Kind == "object" && walk(strings.TrimPrefix(f.Type, "*")) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(from)
+}
@@ -470,7 +470,7 @@ func (g *GoGenerator) render(rootSchemaName, rootTypeName string, structs map[st
 
 	var imports []string
 	if emitRootModel {
-		imports = append(imports, "\"github.com/asaidimu/go-anansi/v8/core/document\"")
+		imports = append(imports, "\"github.com/asaidimu/go-anansi/v8/core/document\"", fieldsImport)
 	}
 	if needsDecimal {
 		imports = append(imports, "\"github.com/asaidimu/go-anansi/v8/core/types/decimal\"")
@@ -606,6 +606,32 @@ func (g *GoGenerator) render(rootSchemaName, rootTypeName string, structs map[st
 		for _, name := range sortedKeys(projections) {
 			emitStruct(&sb, name, projections[name], true, true)
 		}
+
+		// Typed field descriptors. A schema without _id_ gets one for the
+		// shadow ID so documents can be addressed by identifier.
+		descFields := structs[rootTypeName]
+		if rootIDFieldName == "" {
+			descFields = append(append([]StructField(nil), descFields...),
+				StructField{Name: idName, Type: "string", FieldName: "_id_", Kind: "string"})
+		}
+		taken := func(name string) bool {
+			_, isStruct := structs[name]
+			_, isAlias := typeAliases[name]
+			_, isEnum := enumDefs[name]
+			_, isProjection := projections[name]
+			return isStruct || isAlias || isEnum || isProjection || (emitCollection && name == rootTypeName+"s")
+		}
+		var desc strings.Builder
+		if err := g.emitFieldDescriptors(&desc, rootTypeName, descFields, structs, taken); err != nil {
+			return "", err
+		}
+
+		// Document codecs, so the pool binds models without reflection.
+		g.emitCodecs(&desc, rootTypeName, rootFields, structs, enumDefs)
+		if !strings.HasSuffix(sb.String(), "\n\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(strings.TrimSuffix(desc.String(), "\n"))
 	}
 
 	return sb.String(), nil
@@ -660,8 +686,8 @@ func appendSystemShadows(fields []StructField) ([]StructField, string) {
 	}
 
 	shadows := append(append([]StructField(nil), fields...),
-		StructField{Name: idName, Type: "string", Tags: `json:"_id_,omitempty" anansi:"_id_,required=true,omitempty"`},
-		StructField{Name: metaName, Type: "map[string]any", Tags: `json:"_metadata_,omitempty" anansi:"_metadata_,required=true,omitempty"`},
+		StructField{Name: idName, Type: "string", Tags: `json:"_id_,omitempty" anansi:"_id_,required=true,omitempty"`, FieldName: "_id_", Kind: "string"},
+		StructField{Name: metaName, Type: "map[string]any", Tags: `json:"_metadata_,omitempty" anansi:"_metadata_,required=true,omitempty"`, FieldName: "_metadata_", Kind: "record"},
 	)
 	return shadows, idName
 }
@@ -792,6 +818,11 @@ type StructField struct {
 	Name string
 	Type string
 	Tags string // e.g., `json:"name" schema:"..."`
+	// FieldName and Kind are the schema field name and type. They are set
+	// only for fields generated from schema fields, and drive the typed
+	// field descriptors.
+	FieldName string
+	Kind      string
 }
 
 type EnumDef struct {
@@ -1483,9 +1514,11 @@ func generateFields(fields map[string]FieldDef, typeNames map[string]string, sch
 		tags := buildTags(fd, fieldName, goFieldName, tagConfig)
 
 		result = append(result, StructField{
-			Name: goFieldName,
-			Type: goType,
-			Tags: tags,
+			Name:      goFieldName,
+			Type:      goType,
+			Tags:      tags,
+			FieldName: fieldName,
+			Kind:      fd.Type,
 		})
 	}
 
@@ -396,3 +396,128 @@ func TestGenerate_MetadataTypeNamePerSchema(t *testing.T) {
 		t.Errorf("generated output is not valid Go: %v\n%s", err, out)
 	}
 }
+
+func TestGenerate_FieldDescriptors(t *testing.T) {
+	out := generate(t, &GeneratorConfig{Mode: ModeModel, TagConfig: DefaultTagConfig()})
+
+	// Comparable values keep their Go types; nested objects get their own set,
+	// rooted at the parent field's path.
+	assert.Contains(t, out, "var OrderFields = OrderFieldSet{")
+	assert.Contains(t, out, "    Quantity: fields.NewOrdered[int64](\"quantity\"),")
+	assert.Contains(t, out, "    Status: fields.New[OrderStatusEnum](\"status\"),")
+	assert.Contains(t, out, "    Shipping: newAddressFieldSet(\"shipping\"),")
+	assert.Contains(t, out, "        Street: fields.NewString(path + \".street\"),")
+	// The _id_ shadow gets a descriptor under its shadow name.
+	assert.Contains(t, out, "    ModelID: fields.NewString(\"_id_\"),")
+
+	scoped := generate(t, &GeneratorConfig{Mode: ModeModel, ScopedPackages: true, TagConfig: DefaultTagConfig()})
+	assert.Contains(t, scoped, "var Fields = OrderFieldSet{")
+
+	structs := generate(t, &GeneratorConfig{Mode: ModeStructs, TagConfig: DefaultTagConfig()})
+	assert.NotContains(t, structs, "FieldSet")
+	assert.NotContains(t, structs, "core/query/fields")
+}
+
+func TestGenerate_FieldDescriptorsRecursive(t *testing.T) {
+	schema := `{
+  "name": "Category",
+  "fields": {
+    "f1": {"name": "name", "type": "string", "required": true},
+    "f2": {"name": "node", "type": "object", "schema": {"id": "node"}}
+  },
+  "schemas": {
+    "node": {"name": "Node", "fields": {
+      "n1": {"name": "label", "type": "string"},
+      "n2": {"name": "parent", "type": "object", "schema": {"id": "node"}},
+      "n3": {"name": "pair", "type": "object", "schema": {"id": "pair"}}
+    }},
+    "pair": {"name": "Pair", "fields": {
+      "p1": {"name": "node", "type": "object", "schema": {"id": "node"}}
+    }}
+  }
+}`
+	gen := NewGoGenerator(&GeneratorConfig{Mode: ModeModel, TagConfig: DefaultTagConfig()})
+	out, err := gen.Generate([]byte(schema))
+	require.NoError(t, err)
+
+	// Node refers to itself and through Pair back to itself; those edges are
+	// plain objects so the set types stay finite.
+	assert.Contains(t, out, "    Node: newNodeFieldSet(\"node\"),")
+	assert.Contains(t, out, "        Parent: fields.NewObject(path + \".parent\"),")
+	assert.Contains(t, out, "        Pair: fields.NewObject(path + \".pair\"),")
+	assert.NotContains(t, out, "PairFieldSet")
+
+	src := "package test\n\n" + out
+	if _, err := parser.ParseFile(token.NewFileSet(), "", src, parser.AllErrors); err != nil {
+		t.Errorf("generated output is not valid Go: %v\n%s", err, out)
+	}
+}
+
+func TestGenerate_DocumentCodecs(t *testing.T) {
+	schema := `{
+  "name": "Invoice",
+  "fields": {
+    "f1": {"name": "number", "type": "string", "required": true},
+    "f2": {"name": "status", "type": "enum", "required": true, "schema": {"type": "string", "values": ["draft", "sent"]}},
+    "f3": {"name": "priority", "type": "enum", "schema": {"type": "integer", "values": [1, 2]}},
+    "f4": {"name": "count", "type": "integer"},
+    "f5": {"name": "customer", "type": "object", "schema": {"id": "party"}},
+    "f6": {"name": "lines", "type": "array", "schema": {"id": "line"}},
+    "f7": {"name": "labels", "type": "array", "schema": {"type": "string"}},
+    "f8": {"name": "extra", "type": "record"}
+  },
+  "schemas": {
+    "party": {"name": "Party", "fields": {"p1": {"name": "name", "type": "string", "required": true}}},
+    "line": {"name": "Line", "fields": {"l1": {"name": "amount", "type": "decimal"}}}
+  }
+}`
+	gen := NewGoGenerator(&GeneratorConfig{Mode: ModeModel, TagConfig: DefaultTagConfig()})
+	out, err := gen.Generate([]byte(schema))
+	require.NoError(t, err)
+
+	// Party qualifies; Line holds a decimal, so neither it nor the Invoice
+	// nesting it gets codecs and both keep the reflective binder.
+	assert.Contains(t, out, "func (m *Party) EncodeDocument(e *document.Encoder) error {")
+	assert.Contains(t, out, "    e.String(\"name\", m.Name, false)")
+	assert.Contains(t, out, "    m.Name = d.String(\"name\")")
+	assert.NotContains(t, out, "func (m *Line) EncodeDocument")
+	assert.NotContains(t, out, "func (m *Invoice) EncodeDocument")
+
+	schema = strings.Replace(schema, `"type": "decimal"`, `"type": "number"`, 1)
+	out, err = gen.Generate([]byte(schema))
+	require.NoError(t, err)
+	for _, want := range []string{
+		"func (m *Invoice) EncodeDocument(e *document.Encoder) error {",
+		"        e.ID(m.DocumentModel.ID)",
+		"    e.ID(m.ID)",
+		"    e.MetadataMap(m.Metadata)",
+		"    e.String(\"status\", string(m.Status), false)",
+		"    e.OptInt(\"priority\", (*int64)(m.Priority), true)",
+		"    e.OptInt(\"count\", m.Count, true)",
+		"        e.Object(\"customer\", m.Customer)",
+		"    document.EncodeObjects(e, \"lines\", m.Lines, true)",
+		"    e.Strings(\"labels\", m.Labels, true)",
+		"    e.Record(\"extra\", m.Extra, true)",
+		"    d.Model(&m.DocumentModel, m)",
+		"    m.ID = d.ID()",
+		"    m.Status = InvoiceStatusEnum(d.String(\"status\"))",
+		"    m.Priority = (*InvoicePriorityEnum)(d.OptInt(\"priority\"))",
+		"        m.Customer = &Party{}",
+		"    m.Lines = document.DecodeObjects[Line](d, \"lines\")",
+		"func (m *Line) DecodeDocument(d *document.Decoder) error {",
+	} {
+		assert.Contains(t, out, want)
+	}
+
+	src := "package test\n\n" + out
+	if _, err := parser.ParseFile(token.NewFileSet(), "", src, parser.AllErrors); err != nil {
+		t.Errorf("generated output is not valid Go: %v\n%s", err, out)
+	}
+
+	// Without anansi tags the reflective binder would not see the fields, so
+	// no codecs are emitted.
+	bare := NewGoGenerator(&GeneratorConfig{Mode: ModeModel})
+	out, err = bare.Generate([]byte(schema))
+	require.NoError(t, err)
+	assert.NotContains(t, out, "EncodeDocument")
+}
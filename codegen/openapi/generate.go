@@ -0,0 +1,425 @@
+// Package openapi generates an OpenAPI 3.1 document describing the routes the
+// rest package mounts for a set of collections.
+//
+// Each schema becomes three components: <Name>, the document body accepted by
+// create (its JSON Schema export, see core/schema/jsonschema); <Name>Patch,
+// the same shape without required properties; and <Name>Document, the stored
+// document with _id_ and _metadata_. Nested schemas are lifted out of $defs
+// into <Name>_<def> components. A collection's route segment is its schema
+// name, so the rest.Handler must be keyed by schema name for the two to agree.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/schema/jsonschema"
+)
+
+// Version is the OpenAPI version written by Generate.
+const Version = "3.1.0"
+
+// problemContentType mirrors rest.ProblemContentType; importing rest here
+// would pull the persistence layer into code generation.
+const problemContentType = "application/problem+json"
+
+var ErrGenerateFailed = common.NewSystemError("ERR_OPENAPI_GENERATE_FAILED", "failed to generate OpenAPI document")
+
+// Options configures Generate.
+type Options struct {
+	// BasePath prefixes every path; it must match the rest.WithPrefix value.
+	BasePath string
+	// Title is info.title. The default is "Anansi API".
+	Title string
+	// Version is info.version. The default is "1.0.0".
+	Version string
+}
+
+var unsafeComponentChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// componentName makes s usable as a components.schemas key.
+func componentName(s string) string {
+	return unsafeComponentChars.ReplaceAllString(s, "_")
+}
+
+// operationName PascalCases a collection name for use in operation ids.
+func operationName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Generate builds the OpenAPI document for schemas.
+func Generate(schemas []*definition.Schema, opts Options) (map[string]any, error) {
+	if opts.Title == "" {
+		opts.Title = "Anansi API"
+	}
+	if opts.Version == "" {
+		opts.Version = "1.0.0"
+	}
+	base := strings.TrimRight(opts.BasePath, "/")
+
+	sorted := append([]*definition.Schema(nil), schemas...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	components := sharedComponents()
+	paths := make(map[string]any, 2*len(sorted))
+	seen := make(map[string]bool, len(sorted))
+	for _, s := range sorted {
+		if s == nil || s.Name == "" {
+			return nil, ErrGenerateFailed.WithMessage("every schema needs a name").WithOperation("Generate")
+		}
+		if seen[s.Name] {
+			return nil, ErrGenerateFailed.WithMessagef("duplicate collection %q", s.Name).WithOperation("Generate")
+		}
+		seen[s.Name] = true
+
+		name := componentName(s.Name)
+		if err := addComponents(components, name, s); err != nil {
+			return nil, err
+		}
+		path := base + "/" + s.Name
+		op := operationName(s.Name)
+		paths[path] = collectionPath(name, op)
+		paths[path+"/query"] = queryPath(name, op)
+		paths[path+"/bulk"] = bulkPath(name, op)
+		paths[path+"/{id}"] = documentPath(name, op)
+	}
+
+	return map[string]any{
+		"openapi": Version,
+		"info": map[string]any{
+			"title":   opts.Title,
+			"version": opts.Version,
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": components},
+	}, nil
+}
+
+// GenerateJSON is Generate followed by indented JSON encoding.
+func GenerateJSON(schemas []*definition.Schema, opts Options) ([]byte, error) {
+	doc, err := Generate(schemas, opts)
+	if err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, ErrGenerateFailed.WithCause(err).WithOperation("GenerateJSON")
+	}
+	return append(out, '\n'), nil
+}
+
+// addComponents adds the components of one collection.
+func addComponents(components map[string]any, name string, s *definition.Schema) error {
+	doc, err := jsonschema.Export(s)
+	if err != nil {
+		return ErrGenerateFailed.WithCause(err).WithPath(s.Name)
+	}
+	delete(doc, "$schema")
+
+	// $defs become sibling components; their refs are rewritten to match.
+	refs := make(map[string]string)
+	defs, _ := doc["$defs"].(map[string]any)
+	delete(doc, "$defs")
+	for key := range defs {
+		refs["#/$defs/"+pointerEscape(key)] = componentRef + name + "_" + componentName(key)
+	}
+	for key, def := range defs {
+		component := name + "_" + componentName(key)
+		if _, ok := components[component]; ok {
+			return ErrGenerateFailed.WithMessagef("component %q is defined twice", component).WithPath(s.Name)
+		}
+		components[component] = rewriteRefs(def, refs)
+	}
+	rewriteRefs(doc, refs)
+
+	patch := make(map[string]any, len(doc))
+	for k, v := range doc {
+		patch[k] = v
+	}
+	delete(patch, "required")
+	patch["title"] = s.Name + " patch"
+
+	for _, component := range []string{name, name + "Patch", name + "Document"} {
+		if _, ok := components[component]; ok {
+			return ErrGenerateFailed.WithMessagef("component %q is defined twice", component).WithPath(s.Name)
+		}
+	}
+	components[name] = doc
+	components[name+"Patch"] = patch
+	components[name+"Document"] = map[string]any{
+		"allOf": []any{
+			schemaRef(name),
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					data.DocumentIDField: map[string]any{"type": "string"},
+					data.MetadataField:   map[string]any{"type": "object"},
+				},
+				"required": []any{data.DocumentIDField},
+			},
+		},
+	}
+	return nil
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func pointerEscape(s string) string { return pointerEscaper.Replace(s) }
+
+// rewriteRefs replaces $ref values found in refs, in place.
+func rewriteRefs(node any, refs map[string]string) any {
+	switch n := node.(type) {
+	case map[string]any:
+		for k, v := range n {
+			if ref, ok := v.(string); ok && k == "$ref" {
+				if to, ok := refs[ref]; ok {
+					n[k] = to
+				}
+				continue
+			}
+			rewriteRefs(v, refs)
+		}
+	case []any:
+		for _, v := range n {
+			rewriteRefs(v, refs)
+		}
+	}
+	return node
+}
+
+const componentRef = "#/components/schemas/"
+
+func schemaRef(component string) map[string]any {
+	return map[string]any{"$ref": componentRef + component}
+}
+
+// sharedComponents returns the components every document needs.
+func sharedComponents() map[string]any {
+	return map[string]any{
+		"Problem": map[string]any{
+			"type":        "object",
+			"description": "RFC 9457 problem details; code is the Anansi error code.",
+			"properties": map[string]any{
+				"type":     map[string]any{"type": "string"},
+				"title":    map[string]any{"type": "string"},
+				"status":   map[string]any{"type": "integer"},
+				"detail":   map[string]any{"type": "string"},
+				"instance": map[string]any{"type": "string"},
+				"code":     map[string]any{"type": "string"},
+				"issues": map[string]any{
+					"type":  "array",
+					"items": map[string]any{"type": "object"},
+				},
+			},
+			"required": []any{"type", "title", "status"},
+		},
+		"Query": map[string]any{
+			"type":        "object",
+			"description": "Query DSL: filters, sort, pagination and projection.",
+		},
+		"Pagination": map[string]any{"type": "object"},
+	}
+}
+
+func collectionPath(name, op string) map[string]any {
+	return map[string]any{
+		"get": map[string]any{
+			"operationId": "list" + op,
+			"tags":        []any{name},
+			"parameters": []any{
+				queryParam("query", "Query DSL as JSON.", map[string]any{"type": "string"}),
+				queryParam("limit", "Page size; enables offset pagination.", map[string]any{"type": "integer", "minimum": 1}),
+				queryParam("offset", "Documents to skip; requires limit.", map[string]any{"type": "integer", "minimum": 0}),
+			},
+			"responses": responses(
+				http.StatusOK, jsonResponse("Matching documents.", listSchema(name)),
+				http.StatusBadRequest, problem("Invalid query."),
+			),
+		},
+		"post": map[string]any{
+			"operationId": "create" + op,
+			"tags":        []any{name},
+			"requestBody": jsonBody(schemaRef(name)),
+			"responses": responses(
+				http.StatusCreated, withHeaders(jsonResponse("Created document.", schemaRef(name+"Document")), "ETag", "Location"),
+				http.StatusBadRequest, problem("Malformed body."),
+				http.StatusConflict, problem("Unique constraint violated."),
+				http.StatusUnprocessableEntity, problem("Validation failed."),
+			),
+		},
+	}
+}
+
+func queryPath(name, op string) map[string]any {
+	return map[string]any{
+		"post": map[string]any{
+			"operationId": "query" + op,
+			"tags":        []any{name},
+			"requestBody": jsonBody(schemaRef("Query")),
+			"responses": responses(
+				http.StatusOK, jsonResponse("Matching documents.", listSchema(name)),
+				http.StatusBadRequest, problem("Invalid query."),
+			),
+		},
+	}
+}
+
+func bulkPath(name, op string) map[string]any {
+	docs := func(ref string) map[string]any {
+		return map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"documents": map[string]any{"type": "array", "items": schemaRef(ref)},
+			},
+			"required": []any{"documents"},
+		}
+	}
+	return map[string]any{
+		"post": map[string]any{
+			"operationId": "bulkCreate" + op,
+			"tags":        []any{name},
+			"description": "Creates every document or none; issues are indexed by position.",
+			"requestBody": jsonBody(docs(name)),
+			"responses": responses(
+				http.StatusCreated, jsonResponse("Created documents, in request order.", docs(name+"Document")),
+				http.StatusBadRequest, problem("Malformed body."),
+				http.StatusRequestEntityTooLarge, problem("Too many documents."),
+				http.StatusUnprocessableEntity, problem("Validation failed."),
+			),
+		},
+	}
+}
+
+func documentPath(name, op string) map[string]any {
+	document := schemaRef(name + "Document")
+	ifMatch := headerParam("If-Match", "Entity tag of the expected version, or *.")
+	return map[string]any{
+		"parameters": []any{map[string]any{
+			"name":     "id",
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		}},
+		"get": map[string]any{
+			"operationId": "get" + op,
+			"tags":        []any{name},
+			"parameters":  []any{headerParam("If-None-Match", "Entity tag of a cached version.")},
+			"responses": responses(
+				http.StatusOK, withHeaders(jsonResponse("The document.", document), "ETag"),
+				http.StatusNotModified, map[string]any{"description": "Not modified."},
+				http.StatusNotFound, problem("No such document."),
+			),
+		},
+		"patch": map[string]any{
+			"operationId": "update" + op,
+			"tags":        []any{name},
+			"parameters":  []any{ifMatch},
+			"requestBody": jsonBody(schemaRef(name + "Patch")),
+			"responses": responses(
+				http.StatusOK, withHeaders(jsonResponse("The updated document.", document), "ETag"),
+				http.StatusBadRequest, problem("Malformed body or If-Match."),
+				http.StatusNotFound, problem("No such document."),
+				http.StatusPreconditionFailed, problem("Version does not match If-Match."),
+				http.StatusUnprocessableEntity, problem("Validation failed."),
+				http.StatusPreconditionRequired, problem("If-Match is required."),
+			),
+		},
+		"delete": map[string]any{
+			"operationId": "delete" + op,
+			"tags":        []any{name},
+			"parameters":  []any{ifMatch},
+			"responses": responses(
+				http.StatusNoContent, map[string]any{"description": "Deleted."},
+				http.StatusBadRequest, problem("Malformed If-Match."),
+				http.StatusNotFound, problem("No such document."),
+				http.StatusPreconditionFailed, problem("Version does not match If-Match."),
+				http.StatusPreconditionRequired, problem("If-Match is required."),
+			),
+		},
+	}
+}
+
+func listSchema(name string) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"data":       map[string]any{"type": "array", "items": schemaRef(name + "Document")},
+			"count":      map[string]any{"type": "integer"},
+			"total":      map[string]any{"type": "integer"},
+			"pagination": schemaRef("Pagination"),
+		},
+		"required": []any{"data", "count"},
+	}
+}
+
+// responses builds a responses object from status, response pairs.
+func responses(pairs ...any) map[string]any {
+	out := make(map[string]any, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out[statusKey(pairs[i].(int))] = pairs[i+1]
+	}
+	return out
+}
+
+func statusKey(status int) string {
+	return strconv.Itoa(status)
+}
+
+func jsonBody(schema map[string]any) map[string]any {
+	return map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+func jsonResponse(description string, schema map[string]any) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+func problem(description string) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{problemContentType: map[string]any{"schema": schemaRef("Problem")}},
+	}
+}
+
+func withHeaders(response map[string]any, names ...string) map[string]any {
+	headers := make(map[string]any, len(names))
+	for _, n := range names {
+		headers[n] = map[string]any{"schema": map[string]any{"type": "string"}}
+	}
+	response["headers"] = headers
+	return response
+}
+
+func queryParam(name, description string, schema map[string]any) map[string]any {
+	return map[string]any{"name": name, "in": "query", "description": description, "schema": schema}
+}
+
+func headerParam(name, description string) map[string]any {
+	return map[string]any{"name": name, "in": "header", "description": description, "schema": map[string]any{"type": "string"}}
+}
@@ -0,0 +1,114 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/codegen/openapi"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ordersSchema = `{
+  "version": "1.0.0",
+  "name": "orders",
+  "fields": {
+    "number":   { "name": "number",   "type": "string", "required": true },
+    "shipping": { "name": "shipping", "type": "object", "schema": { "id": "address" } },
+    "lines":    { "name": "lines",    "type": "array",  "schema": { "id": "line" } }
+  },
+  "schemas": {
+    "address": { "name": "address", "fields": { "city": { "name": "city", "type": "string", "required": true } } },
+    "line":    { "name": "line",    "fields": { "sku":  { "name": "sku",  "type": "string", "required": true } } }
+  }
+}`
+
+const customerSchema = `{
+  "version": "1.0.0",
+  "name": "customer_accounts",
+  "fields": { "email": { "name": "email", "type": "string", "required": true } }
+}`
+
+func generate(t *testing.T, opts openapi.Options, raws ...string) map[string]any {
+	t.Helper()
+	var schemas []*definition.Schema
+	for _, raw := range raws {
+		s, err := definition.FromJSON([]byte(raw))
+		require.NoError(t, err)
+		schemas = append(schemas, s)
+	}
+	out, err := openapi.GenerateJSON(schemas, opts)
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(out, &doc))
+	return doc
+}
+
+func TestGenerate_Paths(t *testing.T) {
+	doc := generate(t, openapi.Options{BasePath: "/api/", Title: "Shop"}, ordersSchema, customerSchema)
+	assert.Equal(t, openapi.Version, doc["openapi"])
+	assert.Equal(t, "Shop", doc["info"].(map[string]any)["title"])
+
+	paths := doc["paths"].(map[string]any)
+	for _, p := range []string{"/api/orders", "/api/orders/query", "/api/orders/bulk", "/api/orders/{id}", "/api/customer_accounts/{id}"} {
+		assert.Contains(t, paths, p)
+	}
+	item := paths["/api/orders/{id}"].(map[string]any)
+	assert.Contains(t, item, "get")
+	assert.Contains(t, item, "patch")
+	assert.Contains(t, item, "delete")
+	patch := item["patch"].(map[string]any)
+	assert.Equal(t, "updateOrders", patch["operationId"])
+	assert.Contains(t, patch["responses"], "412")
+	assert.Equal(t, "getCustomerAccounts", paths["/api/customer_accounts/{id}"].(map[string]any)["get"].(map[string]any)["operationId"])
+
+	created := paths["/api/orders"].(map[string]any)["post"].(map[string]any)["responses"].(map[string]any)["201"].(map[string]any)
+	assert.Contains(t, created["headers"], "ETag")
+	problem := paths["/api/orders"].(map[string]any)["post"].(map[string]any)["responses"].(map[string]any)["422"].(map[string]any)
+	assert.Contains(t, problem["content"], "application/problem+json")
+}
+
+func TestGenerate_Components(t *testing.T) {
+	doc := generate(t, openapi.Options{}, ordersSchema)
+	assert.Contains(t, doc["paths"], "/orders")
+
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	for _, c := range []string{"orders", "ordersPatch", "ordersDocument", "orders_address", "orders_line", "Problem"} {
+		assert.Contains(t, schemas, c)
+	}
+	orders := schemas["orders"].(map[string]any)
+	assert.NotContains(t, orders, "$defs")
+	assert.Contains(t, orders, "required")
+	assert.NotContains(t, schemas["ordersPatch"], "required")
+
+	// Every reference resolves to a component.
+	raw, err := json.Marshal(doc)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "#/$defs/")
+	var walk func(v any)
+	walk = func(v any) {
+		switch n := v.(type) {
+		case map[string]any:
+			if ref, ok := n["$ref"].(string); ok {
+				assert.Contains(t, schemas, strings.TrimPrefix(ref, "#/components/schemas/"), ref)
+			}
+			for _, e := range n {
+				walk(e)
+			}
+		case []any:
+			for _, e := range n {
+				walk(e)
+			}
+		}
+	}
+	walk(doc)
+}
+
+func TestGenerate_DuplicateCollection(t *testing.T) {
+	s, err := definition.FromJSON([]byte(customerSchema))
+	require.NoError(t, err)
+	_, err = openapi.Generate([]*definition.Schema{s, s}, openapi.Options{})
+	require.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// clientRuntime talks to the routes mounted by the rest package. Errors come
+// back as problem+json and are thrown as ApiError.
+const clientRuntime = `export interface Problem {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string;
+  code?: string;
+  issues?: { code: string; message: string; path?: string; index?: number }[];
+}
+
+export class ApiError extends Error {
+  constructor(readonly problem: Problem) {
+    super(problem.detail ?? problem.title);
+    this.name = "ApiError";
+  }
+
+  get status(): number {
+    return this.problem.status;
+  }
+
+  get code(): string | undefined {
+    return this.problem.code;
+  }
+}
+
+export type Stored<T> = T & { _id_: string; _metadata_?: { version?: number } & Record<string, unknown> };
+
+export interface Page<T> {
+  data: Stored<T>[];
+  count: number;
+  total?: number;
+  pagination?: { number: number; size: number; count: number; total: number; pages: number };
+}
+
+export interface ClientOptions {
+  /** Origin and any prefix in front of the base path, e.g. "https://api.example.com". */
+  baseUrl?: string;
+  /** Defaults to the global fetch. */
+  fetch?: typeof fetch;
+  /** Sent with every request, e.g. authorization. */
+  headers?: Record<string, string>;
+}
+
+/** etag returns the entity tag of a stored document, for If-Match. */
+export function etag(doc: { _metadata_?: { version?: number } }): string | undefined {
+  const version = doc._metadata_?.version;
+  return version === undefined ? undefined : ` + "`\"${version}\"`" + `;
+}
+
+export class Transport {
+  private readonly fetch: typeof fetch;
+
+  constructor(private readonly options: ClientOptions, private readonly basePath: string) {
+    this.fetch = options.fetch ?? globalThis.fetch.bind(globalThis);
+  }
+
+  async send<R>(method: string, path: string, body?: unknown, headers: Record<string, string> = {}): Promise<R> {
+    const init: RequestInit = { method, headers: { ...this.options.headers, ...headers } };
+    if (body !== undefined) {
+      init.body = JSON.stringify(body);
+      (init.headers as Record<string, string>)["Content-Type"] = "application/json";
+    }
+    const res = await this.fetch((this.options.baseUrl ?? "") + this.basePath + path, init);
+    if (!res.ok) {
+      let problem: Problem;
+      try {
+        problem = (await res.json()) as Problem;
+      } catch {
+        problem = { type: "about:blank", title: res.statusText, status: res.status };
+      }
+      throw new ApiError(problem);
+    }
+    if (res.status === 204) return undefined as R;
+    return (await res.json()) as R;
+  }
+}
+
+export class CollectionClient<T, F extends string = string> {
+  constructor(private readonly transport: Transport, private readonly path: string) {}
+
+  list(query?: Query<F>): Promise<Page<T>> {
+    return query === undefined
+      ? this.transport.send<Page<T>>("GET", this.path)
+      : this.transport.send<Page<T>>("POST", this.path + "/query", query);
+  }
+
+  get(id: string): Promise<Stored<T>> {
+    return this.transport.send<Stored<T>>("GET", this.path + "/" + encodeURIComponent(id));
+  }
+
+  create(doc: T): Promise<Stored<T>> {
+    return this.transport.send<Stored<T>>("POST", this.path, doc);
+  }
+
+  /** Creates every document or none. */
+  async createMany(docs: T[]): Promise<Stored<T>[]> {
+    const res = await this.transport.send<{ documents: Stored<T>[] }>("POST", this.path + "/bulk", { documents: docs });
+    return res.documents;
+  }
+
+  /** Applies patch; with ifMatch (see etag) the update fails with 412 if the document changed. */
+  update(id: string, patch: Partial<T>, ifMatch?: string): Promise<Stored<T>> {
+    return this.transport.send<Stored<T>>("PATCH", this.path + "/" + encodeURIComponent(id), patch, ifMatch ? { "If-Match": ifMatch } : {});
+  }
+
+  remove(id: string, ifMatch?: string): Promise<void> {
+    return this.transport.send<void>("DELETE", this.path + "/" + encodeURIComponent(id), undefined, ifMatch ? { "If-Match": ifMatch } : {});
+  }
+}`
+
+// clientDecls emits createClient, with one CollectionClient per collection
+// under its schema name.
+func clientDecls(schemas []*definition.Schema, basePath string) string {
+	var buf strings.Builder
+	buf.WriteString(clientRuntime)
+	fmt.Fprintf(&buf, "\n\nexport const basePath = %s;\n\n", jsString(strings.TrimRight(basePath, "/")))
+	buf.WriteString("export function createClient(options: ClientOptions = {}) {\n")
+	buf.WriteString("  const transport = new Transport(options, basePath);\n")
+	buf.WriteString("  return {\n")
+	for _, s := range schemas {
+		if len(s.Fields) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "    %s: new CollectionClient<%s, %sField>(transport, %s),\n",
+			jsString(s.Name), s.Name, typeIdent(s.Name), jsString("/"+s.Name))
+	}
+	buf.WriteString("  };\n}")
+	return buf.String()
+}
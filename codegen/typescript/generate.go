@@ -32,10 +32,33 @@ func (g *TSGenerator) Generate() string {
 	return strings.Join(lines, "\n\n") + "\n"
 }
 
+// GeneratorConfig selects the optional sections GenerateCombinedWithConfig
+// emits after the types.
+type GeneratorConfig struct {
+	// Validators emits validate<Type> and is<Type> for every type, checking
+	// required, nullable, enum, union and nested rules and the standard
+	// constraint predicates.
+	Validators bool
+	// QueryBuilder emits the query DSL types, a <Type>Field union of each
+	// collection's field paths and a query<Type>() builder.
+	QueryBuilder bool
+	// Client emits createClient, a fetch-based client for the rest package
+	// routes.
+	Client bool
+	// BasePath is the prefix the REST handler is mounted under.
+	BasePath string
+}
+
 // GenerateCombined generates a single TypeScript file containing types for all
 // given schemas. Nested schemas are merged into one registry so cross-schema
 // references resolve correctly.
 func GenerateCombined(schemas []*definition.Schema) string {
+	return GenerateCombinedWithConfig(schemas, nil)
+}
+
+// GenerateCombinedWithConfig is GenerateCombined followed by the sections
+// config enables.
+func GenerateCombinedWithConfig(schemas []*definition.Schema, config *GeneratorConfig) string {
 	merged := &definition.Schema{
 		Schemas: make(map[definition.SchemaId]definition.NestedSchema),
 	}
@@ -70,6 +93,21 @@ func GenerateCombined(schemas []*definition.Schema) string {
 		}
 	}
 
+	if config != nil {
+		if config.Validators {
+			parts = append(parts, gen.validatorDecls(schemas))
+		}
+		if config.QueryBuilder || config.Client {
+			parts = append(parts, queryTypes, gen.fieldTypeDecls(schemas))
+		}
+		if config.QueryBuilder {
+			parts = append(parts, queryBuilderDecls(schemas))
+		}
+		if config.Client {
+			parts = append(parts, clientDecls(schemas, config.BasePath))
+		}
+	}
+
 	return strings.Join(parts, "\n\n") + "\n"
 }
 
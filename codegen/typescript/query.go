@@ -0,0 +1,191 @@
+package typescript
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// maxFieldPathDepth bounds how far field paths descend into nested objects.
+const maxFieldPathDepth = 4
+
+// queryTypes mirrors the JSON form of core/query.Query. Field names are a type
+// parameter, so each collection's queries only accept its own paths.
+const queryTypes = `export type ComparisonOperator =
+  | "eq" | "neq" | "lt" | "lte" | "gt" | "gte"
+  | "in" | "nin" | "contains" | "ncontains" | "exists" | "nexists";
+
+export type LogicalOperator = "and" | "or" | "not" | "nor" | "xor" | "nand" | "xnor";
+
+export interface FilterCondition<F extends string = string> {
+  field: F;
+  operator: ComparisonOperator;
+  value?: unknown;
+}
+
+export interface FilterGroup<F extends string = string> {
+  operator: LogicalOperator;
+  conditions: QueryFilter<F>[];
+}
+
+export type QueryFilter<F extends string = string> =
+  | { condition: FilterCondition<F> }
+  | { group: FilterGroup<F> };
+
+export interface SortConfiguration<F extends string = string> {
+  field: F;
+  direction: "asc" | "desc";
+}
+
+export interface OffsetPagination {
+  type: "offset";
+  limit: number;
+  offset?: number;
+  include_total?: boolean;
+}
+
+export interface Projection<F extends string = string> {
+  include?: { name: F }[];
+  exclude?: { name: F }[];
+}
+
+export interface Query<F extends string = string> {
+  filters?: QueryFilter<F>;
+  sort?: SortConfiguration<F>[];
+  pagination?: OffsetPagination;
+  projection?: Projection<F>;
+}`
+
+// queryBuilder is a chainable builder over Query; conditions added with where
+// are ANDed, as with the Go query builder.
+const queryBuilder = `export function condition<F extends string>(field: F, operator: ComparisonOperator, value?: unknown): QueryFilter<F> {
+  return { condition: { field, operator, value } };
+}
+
+export function group<F extends string>(operator: LogicalOperator, ...conditions: QueryFilter<F>[]): QueryFilter<F> {
+  return { group: { operator, conditions } };
+}
+
+export class QueryBuilder<F extends string> {
+  private readonly filters: QueryFilter<F>[] = [];
+  private readonly query: Query<F> = {};
+
+  where(field: F, operator: ComparisonOperator, value?: unknown): this {
+    this.filters.push(condition(field, operator, value));
+    return this;
+  }
+
+  eq(field: F, value: unknown): this {
+    return this.where(field, "eq", value);
+  }
+
+  in(field: F, values: unknown[]): this {
+    return this.where(field, "in", values);
+  }
+
+  filter(filter: QueryFilter<F>): this {
+    this.filters.push(filter);
+    return this;
+  }
+
+  or(...filters: QueryFilter<F>[]): this {
+    return this.filter(group("or", ...filters));
+  }
+
+  sort(field: F, direction: "asc" | "desc" = "asc"): this {
+    (this.query.sort ??= []).push({ field, direction });
+    return this;
+  }
+
+  page(limit: number, offset = 0, includeTotal = false): this {
+    this.query.pagination = { type: "offset", limit, offset, include_total: includeTotal || undefined };
+    return this;
+  }
+
+  include(...fields: F[]): this {
+    this.query.projection = { include: fields.map((name) => ({ name })) };
+    return this;
+  }
+
+  exclude(...fields: F[]): this {
+    this.query.projection = { exclude: fields.map((name) => ({ name })) };
+    return this;
+  }
+
+  build(): Query<F> {
+    const query: Query<F> = { ...this.query };
+    if (this.filters.length === 1) query.filters = this.filters[0];
+    else if (this.filters.length > 1) query.filters = group("and", ...this.filters);
+    return query;
+  }
+}`
+
+// fieldTypeDecls emits <Type>Field, the union of a collection's queryable
+// paths.
+func (g *TSGenerator) fieldTypeDecls(schemas []*definition.Schema) string {
+	var parts []string
+	for _, s := range schemas {
+		if len(s.Fields) == 0 {
+			continue
+		}
+		paths := g.fieldPaths(s.Fields, "", 0, map[definition.SchemaId]bool{})
+		seen := make(map[string]bool, len(paths))
+		for _, p := range paths {
+			seen[p] = true
+		}
+		if !seen[data.DocumentIDField] {
+			paths = append(paths, data.DocumentIDField)
+		}
+		sort.Strings(paths)
+		quoted := make([]string, len(paths))
+		for i, p := range paths {
+			quoted[i] = jsString(p)
+		}
+		parts = append(parts, fmt.Sprintf("export type %sField =\n  | %s;", typeIdent(s.Name), strings.Join(quoted, "\n  | ")))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// fieldPaths lists the dotted paths of fields, descending into object fields
+// backed by a nested schema.
+func (g *TSGenerator) fieldPaths(fields map[definition.FieldId]definition.Field, prefix string, depth int, visiting map[definition.SchemaId]bool) []string {
+	var paths []string
+	for _, f := range g.sortedFields(fields) {
+		path := prefix + string(f.Name)
+		paths = append(paths, path)
+		if f.Type != definition.FieldTypeObject || depth >= maxFieldPathDepth || !f.Schema.IsSingle() {
+			continue
+		}
+		ref, err := definition.FieldSchemaAs[definition.SchemaReference](f.Schema)
+		if err != nil || ref.IsInline() || visiting[ref.ID] {
+			continue
+		}
+		ns, ok := g.schema.Schemas[ref.ID]
+		if !ok || len(ns.Fields) == 0 {
+			continue
+		}
+		visiting[ref.ID] = true
+		paths = append(paths, g.fieldPaths(ns.Fields, path+".", depth+1, visiting)...)
+		delete(visiting, ref.ID)
+	}
+	return paths
+}
+
+// queryBuilderDecls emits the builder and a query<Type>() factory for each
+// collection.
+func queryBuilderDecls(schemas []*definition.Schema) string {
+	parts := []string{queryBuilder}
+	for _, s := range schemas {
+		if len(s.Fields) == 0 {
+			continue
+		}
+		ident := typeIdent(s.Name)
+		parts = append(parts, fmt.Sprintf(`export function query%[1]s(): QueryBuilder<%[1]sField> {
+  return new QueryBuilder<%[1]sField>();
+}`, ident))
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,524 @@
+package typescript
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// validatorRuntime is emitted once ahead of the per-type validators. Issue
+// codes match the ones the Go validator reports, so client and server errors
+// can be handled alike.
+const validatorRuntime = `export interface ValidationIssue {
+  code: string;
+  message: string;
+  path: string;
+}
+
+type Check = (value: unknown, path: string, issues: ValidationIssue[]) => void;
+
+function issue(issues: ValidationIssue[], code: string, message: string, path: string): void {
+  issues.push({ code, message, path });
+}
+
+function joinPath(path: string, key: string | number): string {
+  if (typeof key === "number") return ` + "`${path}[${key}]`" + `;
+  return path === "" ? key : ` + "`${path}.${key}`" + `;
+}
+
+function typeName(value: unknown): string {
+  if (value === null) return "null";
+  if (Array.isArray(value)) return "array";
+  return typeof value;
+}
+
+function isRecord(value: unknown): value is Record<string, unknown> {
+  return typeof value === "object" && value !== null && !Array.isArray(value);
+}
+
+function primitive(expected: string, test: (value: unknown) => boolean): Check {
+  return (value, path, issues) => {
+    if (!test(value)) issue(issues, "TYPE_MISMATCH", ` + "`Expected ${expected}, got ${typeName(value)}`" + `, path);
+  };
+}
+
+const checkAny: Check = () => {};
+const checkString = primitive("string", (v) => typeof v === "string");
+const checkNumber = primitive("number", (v) => typeof v === "number" && Number.isFinite(v));
+const checkInteger = primitive("integer", (v) => typeof v === "number" && Number.isInteger(v));
+const checkBoolean = primitive("boolean", (v) => typeof v === "boolean");
+const checkDecimal = primitive("decimal", (v) =>
+  (typeof v === "number" && Number.isFinite(v)) || (typeof v === "string" && /^-?[0-9]+(\.[0-9]+)?$/.test(v)));
+
+function checkEnum(values: readonly unknown[]): Check {
+  return (value, path, issues) => {
+    if (!values.includes(value)) issue(issues, "ENUM_MISMATCH", ` + "`Expected one of ${JSON.stringify(values)}`" + `, path);
+  };
+}
+
+function checkArray(item: Check): Check {
+  return (value, path, issues) => {
+    if (!Array.isArray(value)) {
+      issue(issues, "ARRAY_TYPE_MISMATCH", "Expected array", path);
+      return;
+    }
+    value.forEach((v, i) => item(v, joinPath(path, i), issues));
+  };
+}
+
+function checkRecord(item: Check): Check {
+  return (value, path, issues) => {
+    if (!isRecord(value)) {
+      issue(issues, "OBJECT_TYPE_MISMATCH", ` + "`Expected object for record, got ${typeName(value)}`" + `, path);
+      return;
+    }
+    for (const [k, v] of Object.entries(value)) item(v, joinPath(path, k), issues);
+  };
+}
+
+function checkUnion(variants: Check[]): Check {
+  return (value, path, issues) => {
+    for (const variant of variants) {
+      const sub: ValidationIssue[] = [];
+      variant(value, path, sub);
+      if (sub.length === 0) return;
+    }
+    issue(issues, "UNION_MISMATCH", ` + "`Value at '${path}' did not match any variant of the union`" + `, path);
+  };
+}
+
+// checkTagged validates a union whose variants are told apart by a literal
+// field, reporting the chosen variant's issues instead of a bare mismatch.
+function checkTagged(field: string, variants: Record<string, Check>): Check {
+  return (value, path, issues) => {
+    if (!isRecord(value)) {
+      issue(issues, "TYPE_MISMATCH", ` + "`Expected object, got ${typeName(value)}`" + `, path);
+      return;
+    }
+    const tag = value[field];
+    const key = typeof tag === "string" || typeof tag === "number" || typeof tag === "boolean" ? String(tag) : "";
+    const variant = Object.prototype.hasOwnProperty.call(variants, key) ? variants[key] : undefined;
+    if (variant === undefined) {
+      issue(issues, "UNION_MISMATCH", ` + "`Value at '${path}' did not match any variant of the union`" + `, joinPath(path, field));
+      return;
+    }
+    variant(value, path, issues);
+  };
+}
+
+function checkAll(parts: Check[]): Check {
+  return (value, path, issues) => {
+    for (const part of parts) part(value, path, issues);
+  };
+}
+
+function withRules(check: Check, rules: Check[]): Check {
+  return (value, path, issues) => {
+    const before = issues.length;
+    check(value, path, issues);
+    if (issues.length === before) for (const rule of rules) rule(value, path, issues);
+  };
+}
+
+function field(obj: Record<string, unknown>, key: string, path: string, issues: ValidationIssue[],
+  required: boolean, nullable: boolean, check: Check): void {
+  const p = joinPath(path, key);
+  const value = obj[key];
+  if (value === undefined) {
+    if (required) issue(issues, "REQUIRED_FIELD_MISSING", ` + "`Required field '${key}' is missing`" + `, p);
+    return;
+  }
+  if (value === null) {
+    if (!nullable) issue(issues, "TYPE_MISMATCH", ` + "`Field '${key}' is not nullable`" + `, p);
+    return;
+  }
+  check(value, p, issues);
+}
+
+function violated(issues: ValidationIssue[], name: string, message: string, path: string): void {
+  issue(issues, "CONSTRAINT_VIOLATION", ` + "`${name}: ${message}`" + `, path);
+}
+
+function lengthOf(value: unknown): number | undefined {
+  return typeof value === "string" || Array.isArray(value) ? value.length : undefined;
+}
+
+function minLength(name: string, min: number): Check {
+  return (value, path, issues) => {
+    const n = lengthOf(value);
+    if (n !== undefined && n < min) violated(issues, name, ` + "`length must be at least ${min}`" + `, path);
+  };
+}
+
+function maxLength(name: string, max: number): Check {
+  return (value, path, issues) => {
+    const n = lengthOf(value);
+    if (n !== undefined && n > max) violated(issues, name, ` + "`length must be at most ${max}`" + `, path);
+  };
+}
+
+function bound(name: string, op: ">" | ">=" | "<" | "<=", limit: number): Check {
+  return (value, path, issues) => {
+    if (typeof value !== "number") return;
+    const ok = op === ">" ? value > limit : op === ">=" ? value >= limit : op === "<" ? value < limit : value <= limit;
+    if (!ok) violated(issues, name, ` + "`must be ${op} ${limit}`" + `, path);
+  };
+}
+
+function matches(name: string, pattern: string): Check {
+  const re = new RegExp(pattern);
+  return (value, path, issues) => {
+    if (typeof value === "string" && !re.test(value)) violated(issues, name, ` + "`must match ${pattern}`" + `, path);
+  };
+}
+
+function email(name: string): Check {
+  return matches(name, "^[^@\\s]+@[^@\\s]+\\.[^@\\s]+$");
+}`
+
+// standardRules maps the standard constraint predicates (the ones JSON Schema
+// export also understands) to the runtime function checking them.
+var standardRules = map[definition.PredicateName]func(name string, params map[string]any) (string, bool){
+	"min_length":            numberRule("minLength(%s, %s)", "min"),
+	"max_length":            numberRule("maxLength(%s, %s)", "max"),
+	"greater_than":          numberRule(`bound(%s, ">", %s)`, "min"),
+	"greater_than_or_equal": numberRule(`bound(%s, ">=", %s)`, "min"),
+	"less_than":             numberRule(`bound(%s, "<", %s)`, "max"),
+	"less_than_or_equal":    numberRule(`bound(%s, "<=", %s)`, "max"),
+	"pattern": func(name string, params map[string]any) (string, bool) {
+		pattern, ok := params["pattern"].(string)
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("matches(%s, %s)", jsString(name), jsString(pattern)), true
+	},
+	"email_format": func(name string, _ map[string]any) (string, bool) {
+		return fmt.Sprintf("email(%s)", jsString(name)), true
+	},
+}
+
+func numberRule(format, param string) func(string, map[string]any) (string, bool) {
+	return func(name string, params map[string]any) (string, bool) {
+		switch v := params[param].(type) {
+		case int64, float64, int:
+			return fmt.Sprintf(format, jsString(name), fmt.Sprint(v)), true
+		}
+		return "", false
+	}
+}
+
+// jsString quotes s as a JavaScript string literal.
+func jsString(s string) string {
+	out, _ := json.Marshal(s)
+	return string(out)
+}
+
+// typeIdent PascalCases a schema name for use inside generated identifiers.
+func typeIdent(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		isWord := r == '_' || r == '$' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+		if !isWord || r == '_' {
+			upper = true
+			continue
+		}
+		if upper && 'a' <= r && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// validatorDecls emits validate<Type>, is<Type> and the internal check
+// function of every named type: nested schemas first, then the collections.
+func (g *TSGenerator) validatorDecls(schemas []*definition.Schema) string {
+	parts := []string{validatorRuntime}
+	for _, id := range g.sortedSchemaIDs() {
+		ns := g.schema.Schemas[id]
+		if ns.Name == "" {
+			continue
+		}
+		var body string
+		switch {
+		case len(ns.Values) > 0:
+			body = fmt.Sprintf("  checkEnum([%s])(value, path, issues);", g.literalList(ns.Values))
+		case len(ns.Fields) > 0:
+			body = g.objectBody(ns.BaseSchema)
+		case ns.Type != 0:
+			body = fmt.Sprintf("  %s(value, path, issues);", g.checkProps(ns.FieldProperties))
+		default:
+			continue
+		}
+		parts = append(parts, validatorDecl(ns.Name, body))
+	}
+	for _, s := range schemas {
+		if len(s.Fields) > 0 {
+			parts = append(parts, validatorDecl(s.Name, g.objectBody(s.BaseSchema)))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func validatorDecl(name, body string) string {
+	ident := typeIdent(name)
+	return fmt.Sprintf(`export function validate%[1]s(value: unknown, path = ""): ValidationIssue[] {
+  const issues: ValidationIssue[] = [];
+  check%[1]s(value, path, issues);
+  return issues;
+}
+
+export function is%[1]s(value: unknown): value is %[2]s {
+  return validate%[1]s(value).length === 0;
+}
+
+function check%[1]s(value: unknown, path: string, issues: ValidationIssue[]): void {
+%[3]s
+}`, ident, name, body)
+}
+
+// objectBody checks each field in name order, folding single-field
+// constraints of the schema into the field's check.
+func (g *TSGenerator) objectBody(bs definition.BaseSchema) string {
+	rules := make(map[string][]string)
+	ids := make([]string, 0, len(bs.Constraints))
+	for id := range bs.Constraints {
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		c := bs.Constraints[definition.ConstraintId(id)]
+		rule, err := definition.ConstraintAs[*definition.ConstraintRule](c.ConstraintUnion)
+		if err != nil || len(rule.Fields) != 1 {
+			continue
+		}
+		target := string(rule.Fields[0])
+		if f, ok := bs.Fields[definition.FieldId(target)]; ok {
+			target = string(f.Name)
+		}
+		if expr, ok := ruleExpr(c.Name, id, rule); ok {
+			rules[target] = append(rules[target], expr)
+		}
+	}
+
+	var buf strings.Builder
+	buf.WriteString(`  if (!isRecord(value)) {
+    issue(issues, "TYPE_MISMATCH", ` + "`Expected object, got ${typeName(value)}`" + `, path);
+    return;
+  }`)
+	for _, f := range g.sortedFields(bs.Fields) {
+		check := g.checkProps(f.FieldProperties)
+		if r := rules[string(f.Name)]; len(r) > 0 {
+			check = fmt.Sprintf("withRules(%s, [%s])", check, strings.Join(r, ", "))
+		}
+		fmt.Fprintf(&buf, "\n  field(value, %s, path, issues, %t, %t, %s);", jsString(string(f.Name)), f.Required, f.Nullable, check)
+	}
+	return buf.String()
+}
+
+// ruleExpr renders a standard constraint rule; other predicates are left to
+// the server.
+func ruleExpr(name, id string, rule *definition.ConstraintRule) (string, bool) {
+	build, ok := standardRules[rule.Predicate]
+	if !ok {
+		return "", false
+	}
+	if name == "" {
+		name = id
+	}
+	params, _ := rule.Parameters.Value().(map[string]any)
+	return build(name, params)
+}
+
+// checkProps returns the Check expression for a field or nested schema type.
+func (g *TSGenerator) checkProps(fp definition.FieldProperties) string {
+	switch fp.Type {
+	case definition.FieldTypeArray:
+		return fmt.Sprintf("checkArray(%s)", g.checkSingle(fp.Schema))
+	case definition.FieldTypeRecord:
+		return fmt.Sprintf("checkRecord(%s)", g.checkSingle(fp.Schema))
+	case definition.FieldTypeEnum, definition.FieldTypeObject:
+		if fp.Schema.IsZero() && fp.Type == definition.FieldTypeObject {
+			return "checkRecord(checkAny)"
+		}
+		return g.checkSingle(fp.Schema)
+	case definition.FieldTypeUnion:
+		return g.checkUnion(fp.Schema)
+	case definition.FieldTypeComposite:
+		return fmt.Sprintf("checkAll([%s])", strings.Join(g.checkMulti(fp.Schema), ", "))
+	}
+	return primitiveCheck(fp.Type)
+}
+
+func primitiveCheck(t definition.FieldType) string {
+	switch t {
+	case definition.FieldTypeString, definition.FieldTypeBytes:
+		return "checkString"
+	case definition.FieldTypeNumber:
+		return "checkNumber"
+	case definition.FieldTypeInteger:
+		return "checkInteger"
+	case definition.FieldTypeDecimal:
+		return "checkDecimal"
+	case definition.FieldTypeBoolean:
+		return "checkBoolean"
+	}
+	return "checkAny"
+}
+
+func (g *TSGenerator) checkSingle(fr definition.FieldSchemaReference) string {
+	if fr.IsZero() || !fr.IsSingle() {
+		return "checkAny"
+	}
+	ref, err := definition.FieldSchemaAs[definition.SchemaReference](fr)
+	if err != nil {
+		return "checkAny"
+	}
+	return g.checkRef(ref)
+}
+
+func (g *TSGenerator) checkMulti(fr definition.FieldSchemaReference) []string {
+	refs := g.multiRefs(fr)
+	out := make([]string, len(refs))
+	for i, ref := range refs {
+		out[i] = g.checkRef(ref)
+	}
+	return out
+}
+
+func (g *TSGenerator) multiRefs(fr definition.FieldSchemaReference) []definition.SchemaReference {
+	if fr.IsZero() || !fr.IsMultiple() {
+		return nil
+	}
+	refs, err := definition.FieldSchemaAs[[]definition.SchemaReference](fr)
+	if err != nil {
+		return nil
+	}
+	return refs
+}
+
+// checkRef checks a schema reference: a nested schema by its check function,
+// an inline type directly, with the reference's own rules applied after.
+func (g *TSGenerator) checkRef(ref definition.SchemaReference) string {
+	var check string
+	switch {
+	case !ref.IsInline():
+		ns, ok := g.schema.Schemas[ref.ID]
+		if !ok || ns.Name == "" {
+			return "checkAny"
+		}
+		check = "check" + typeIdent(ns.Name)
+	case len(ref.Values) > 0:
+		check = fmt.Sprintf("checkEnum([%s])", g.literalList(ref.Values))
+	default:
+		check = primitiveCheck(ref.Type)
+	}
+
+	var rules []string
+	ids := make([]string, 0, len(ref.Constraints))
+	for id := range ref.Constraints {
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		c := ref.Constraints[definition.ConstraintId(id)]
+		rule, err := definition.ConstraintAs[*definition.ConstraintRule](c.ConstraintUnion)
+		if err != nil || len(rule.Fields) > 0 {
+			continue
+		}
+		if expr, ok := ruleExpr(c.Name, id, rule); ok {
+			rules = append(rules, expr)
+		}
+	}
+	if len(rules) > 0 {
+		return fmt.Sprintf("withRules(%s, [%s])", check, strings.Join(rules, ", "))
+	}
+	return check
+}
+
+// checkUnion discriminates on a tag field when the variants have one and
+// otherwise accepts the first variant that validates.
+func (g *TSGenerator) checkUnion(fr definition.FieldSchemaReference) string {
+	refs := g.multiRefs(fr)
+	if field, tags, ok := g.discriminator(refs); ok {
+		entries := make([]string, len(refs))
+		for i, ref := range refs {
+			entries[i] = fmt.Sprintf("%s: %s", jsString(tags[i]), g.checkRef(ref))
+		}
+		return fmt.Sprintf("checkTagged(%s, { %s })", jsString(field), strings.Join(entries, ", "))
+	}
+	checks := make([]string, len(refs))
+	for i, ref := range refs {
+		checks[i] = g.checkRef(ref)
+	}
+	return fmt.Sprintf("checkUnion([%s])", strings.Join(checks, ", "))
+}
+
+// discriminator finds a required field that every variant declares with a
+// single, distinct literal value.
+func (g *TSGenerator) discriminator(refs []definition.SchemaReference) (string, []string, bool) {
+	if len(refs) < 2 {
+		return "", nil, false
+	}
+	variants := make([]definition.NestedSchema, len(refs))
+	for i, ref := range refs {
+		ns, ok := g.schema.Schemas[ref.ID]
+		if ref.IsInline() || !ok || len(ns.Fields) == 0 {
+			return "", nil, false
+		}
+		variants[i] = ns
+	}
+
+	for _, candidate := range g.sortedFields(variants[0].Fields) {
+		tags := make([]string, len(variants))
+		seen := make(map[string]bool, len(variants))
+		ok := true
+		for i, ns := range variants {
+			_, f := ns.FindField(string(candidate.Name))
+			tag, single := g.singleLiteral(f)
+			if !single || seen[tag] {
+				ok = false
+				break
+			}
+			seen[tag] = true
+			tags[i] = tag
+		}
+		if ok {
+			return string(candidate.Name), tags, true
+		}
+	}
+	return "", nil, false
+}
+
+// singleLiteral reports the value of a required enum field with exactly one
+// value, as the string form checkTagged compares against.
+func (g *TSGenerator) singleLiteral(f *definition.Field) (string, bool) {
+	if f == nil || !f.Required || f.Type != definition.FieldTypeEnum || !f.Schema.IsSingle() {
+		return "", false
+	}
+	ref, err := definition.FieldSchemaAs[definition.SchemaReference](f.Schema)
+	if err != nil {
+		return "", false
+	}
+	values := ref.Values
+	if !ref.IsInline() {
+		values = g.schema.Schemas[ref.ID].Values
+	}
+	if len(values) != 1 {
+		return "", false
+	}
+	return fmt.Sprint(values[0].Value()), true
+}
+
+func (g *TSGenerator) literalList(values []definition.LiteralValue) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = g.formatLiteral(v)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -127,6 +127,23 @@ type CacheStats struct {
 	// goroutine is currently running (i.e. size is at/above the high
 	// watermark and has not yet drained to the low watermark).
 	EvictorActive bool
+
+	// The coherence counters below are maintained by layers that keep a
+	// cache consistent with writes made elsewhere (e.g. another process
+	// sharing the same database). A bare managed cache reports zero.
+
+	// Invalidations counts entries dropped because the backing store
+	// reported a change to their key.
+	Invalidations uint64
+	// InvalidationFlushes counts whole-cache clears caused by changes whose
+	// affected keys were unknown.
+	InvalidationFlushes uint64
+	// Refreshes counts invalidated entries that were reloaded eagerly
+	// instead of being left for the next read-through.
+	Refreshes uint64
+	// LastInvalidation is when the most recent invalidation was applied, or
+	// the zero time if none has been.
+	LastInvalidation time.Time
 }
 
 // ---------------------------------------------------------------------------
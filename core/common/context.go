@@ -9,6 +9,27 @@ type ContextKey string
 
 const CollectionNameContextKey ContextKey = "anansi.collection.name"
 const SanitizationScopeContextKey ContextKey = "anansi.sanitization.scope"
+const TenantContextKey ContextKey = "anansi.tenant.id"
+const PrincipalContextKey ContextKey = "anansi.principal"
+
+// Principal identifies the caller on whose behalf an operation is performed.
+// Attributes carry arbitrary claims (department, clearance, ...) that access
+// policies can reference.
+type Principal struct {
+	ID         string         `json:"id"`
+	Roles      []string       `json:"roles,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// HasRole reports whether the principal holds any of the given roles.
+func (p Principal) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if slices.Contains(p.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
 
 // ============================================================================
 // Context Helpers
@@ -67,6 +88,33 @@ func CollectionNameFromContext(ctx context.Context) (string, bool) {
 	return name, ok
 }
 
+// ContextWithTenant adds a tenant identifier to the context. Tenancy-aware
+// persistence layers scope every operation performed with the returned context
+// to that tenant. An empty tenantID leaves the context unchanged.
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	if tenantID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, TenantContextKey, tenantID)
+}
+
+// TenantFromContext retrieves the tenant identifier from the context, if present.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(TenantContextKey).(string)
+	return tenant, ok && tenant != ""
+}
+
+// ContextWithPrincipal adds the calling principal to the context.
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, PrincipalContextKey, principal)
+}
+
+// PrincipalFromContext retrieves the calling principal from the context, if present.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(PrincipalContextKey).(Principal)
+	return principal, ok
+}
+
 // ExecuteWithContext executes a function and waits for it to complete or for the context to be canceled.
 func ExecuteWithContext[T any](ctx context.Context, f func() (T, error)) (T, error) {
 	done := make(chan struct{})
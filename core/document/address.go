@@ -91,18 +91,14 @@ func computeLeafKey(cs *definition.CompiledSchema, fd definition.FieldDescriptor
 	if addr == 0 {
 		return internalKey(fd), nil
 	}
-	dp, err := container.NewDataPoint(fd.DataType(), int32(addr))
-	if err != nil {
-		return 0, err
-	}
-	return container.NewDataContainerKey(dp, uint32(fd)), nil
+	return fd.AddressKey(addr)
 }
 
 // internalKey builds the structural key used for non-flattened fields
 // (records, array-of-object), whose DataPoint ID derives from the descriptor
 // rather than a flat address.
 func internalKey(fd definition.FieldDescriptor) container.DataContainerKey {
-	return container.NewDataContainerKey(container.DataPoint(fd.DataPoint()), uint32(fd))
+	return fd.InternalKey()
 }
 
 // ============================================================================
@@ -245,7 +241,7 @@ func setByType(c *container.DataContainer, dt container.DataType, key container.
 // source of truth for map-shaped reads (Get of non-terminal objects, ToMap,
 // Data). Schema defaults are not injected here — only the persistence layer
 // applies default values — so absent fields stay absent.
-func materializeSlot(cs *definition.CompiledSchema, c *container.DataContainer, slotIdx uint8, path definition.ResolvedPath) (map[string]any, error) {
+func materializeSlot(cs *definition.CompiledSchema, c *container.DataContainer, slotIdx uint16, path definition.ResolvedPath) (map[string]any, error) {
 	if cs == nil || int(slotIdx) >= len(cs.Schemas) {
 		return nil, fmt.Errorf("document: schema slot %d out of range", slotIdx)
 	}
@@ -262,7 +258,7 @@ func materializeSlot(cs *definition.CompiledSchema, c *container.DataContainer,
 		abs := int(slot.FieldStart) + int(j)
 		fd := cs.Descriptors[abs]
 		name := cs.FieldsMeta[abs].Name
-		fp := append(scratch, definition.NewResolvedStep(slotIdx, uint8(j)))
+		fp := append(scratch, definition.NewResolvedStep(slotIdx, uint16(j)))
 
 		if !fd.Terminal() && fd.ChildSchemaIdx() != definition.FdNoChild {
 			childIdx := fd.ChildSchemaIdx()
@@ -353,7 +349,7 @@ func present(cs *definition.CompiledSchema, c *container.DataContainer, fd defin
 
 // anyDescendantPresent reports whether any leaf under the given object slot is
 // set in the container.
-func anyDescendantPresent(cs *definition.CompiledSchema, c *container.DataContainer, slotIdx uint8, path definition.ResolvedPath) (bool, error) {
+func anyDescendantPresent(cs *definition.CompiledSchema, c *container.DataContainer, slotIdx uint16, path definition.ResolvedPath) (bool, error) {
 	if cs == nil || int(slotIdx) >= len(cs.Schemas) {
 		return false, nil
 	}
@@ -361,7 +357,7 @@ func anyDescendantPresent(cs *definition.CompiledSchema, c *container.DataContai
 	for j := uint16(0); j < slot.FieldCount; j++ {
 		abs := int(slot.FieldStart) + int(j)
 		fd := cs.Descriptors[abs]
-		fp := appendPath(path, definition.NewResolvedStep(slotIdx, uint8(j)))
+		fp := appendPath(path, definition.NewResolvedStep(slotIdx, uint16(j)))
 		ok, err := present(cs, c, fd, fp)
 		if err != nil {
 			return false, err
@@ -405,7 +401,7 @@ func unsetPath(cs *definition.CompiledSchema, c *container.DataContainer, fd def
 }
 
 // unsetSubtree removes every leaf under the object slot path.
-func unsetSubtree(cs *definition.CompiledSchema, c *container.DataContainer, slotIdx uint8, path definition.ResolvedPath) error {
+func unsetSubtree(cs *definition.CompiledSchema, c *container.DataContainer, slotIdx uint16, path definition.ResolvedPath) error {
 	if cs == nil || int(slotIdx) >= len(cs.Schemas) {
 		return nil
 	}
@@ -413,7 +409,7 @@ func unsetSubtree(cs *definition.CompiledSchema, c *container.DataContainer, slo
 	for j := uint16(0); j < slot.FieldCount; j++ {
 		abs := int(slot.FieldStart) + int(j)
 		fd := cs.Descriptors[abs]
-		fp := appendPath(path, definition.NewResolvedStep(slotIdx, uint8(j)))
+		fp := appendPath(path, definition.NewResolvedStep(slotIdx, uint16(j)))
 		if err := unsetPath(cs, c, fd, fp); err != nil {
 			return err
 		}
@@ -434,7 +430,7 @@ func unsetSubtree(cs *definition.CompiledSchema, c *container.DataContainer, slo
 // (slotIdx, fieldIdx), addressed under base. pool, when non-nil, supplies child
 // containers for array-of-object fields so struct->container binds reuse pooled
 // documents instead of allocating fresh ones.
-func setInto(cs *definition.CompiledSchema, c *container.DataContainer, pool *container.Pool, slotIdx uint8, fieldIdx uint16, base definition.ResolvedPath, value any) error {
+func setInto(cs *definition.CompiledSchema, c *container.DataContainer, pool *container.Pool, slotIdx uint16, fieldIdx uint16, base definition.ResolvedPath, value any) error {
 	if cs == nil || int(slotIdx) >= len(cs.Schemas) {
 		return fmt.Errorf("document: schema slot %d out of range", slotIdx)
 	}
@@ -442,7 +438,7 @@ func setInto(cs *definition.CompiledSchema, c *container.DataContainer, pool *co
 	abs := int(slot.FieldStart) + int(fieldIdx)
 	fd := cs.Descriptors[abs]
 	name := cs.FieldsMeta[abs].Name
-	fp := appendPath(base, definition.NewResolvedStep(slotIdx, uint8(fieldIdx)))
+	fp := appendPath(base, definition.NewResolvedStep(slotIdx, fieldIdx))
 
 	if !fd.Terminal() && fd.ChildSchemaIdx() != definition.FdNoChild {
 		child := fd.ChildSchemaIdx()
@@ -473,7 +469,7 @@ func setInto(cs *definition.CompiledSchema, c *container.DataContainer, pool *co
 
 // setMapInto populates every field of the object at slotIdx from m. Values
 // live in container c, addressed under base (the object's own resolved path).
-func setMapInto(cs *definition.CompiledSchema, c *container.DataContainer, pool *container.Pool, slotIdx uint8, base definition.ResolvedPath, m map[string]any) error {
+func setMapInto(cs *definition.CompiledSchema, c *container.DataContainer, pool *container.Pool, slotIdx uint16, base definition.ResolvedPath, m map[string]any) error {
 	if cs == nil || int(slotIdx) >= len(cs.Schemas) {
 		return fmt.Errorf("document: schema slot %d out of range", slotIdx)
 	}
@@ -494,7 +490,7 @@ func setMapInto(cs *definition.CompiledSchema, c *container.DataContainer, pool
 
 // setArrayObject populates an array-of-object field. Each item becomes a child
 // container whose leaves are addressed under the array field's own path.
-func setArrayObject(cs *definition.CompiledSchema, c *container.DataContainer, pool *container.Pool, fd definition.FieldDescriptor, childIdx uint8, base definition.ResolvedPath, value any) error {
+func setArrayObject(cs *definition.CompiledSchema, c *container.DataContainer, pool *container.Pool, fd definition.FieldDescriptor, childIdx uint16, base definition.ResolvedPath, value any) error {
 	items, err := toMapSlice(value)
 	if err != nil {
 		return err
@@ -0,0 +1,245 @@
+package document
+
+import (
+	"context"
+	"sync"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+	"github.com/asaidimu/go-anansi/v8/core/encoding/binary"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// ErrBinaryView is returned when a nested or record view is binary encoded.
+// Only root documents own a container the codec can address.
+var ErrBinaryView = common.NewSystemError("ERR_DOCUMENT_BINARY_VIEW", "only root documents can be binary encoded")
+
+// ============================================================================
+// Binary Codec
+// ============================================================================
+
+var (
+	binaryCodecs sync.Map // *definition.CompiledSchema -> *binary.Codec
+	binaryPools  sync.Map // *definition.Schema -> *DocumentPool
+)
+
+// codecFor returns the cached binary codec for cs. Its schema version is the
+// layout version of cs, so packets written under one schema layout are
+// rejected, rather than misread, after the schema changes.
+func codecFor(cs *definition.CompiledSchema) (*binary.Codec, error) {
+	if v, ok := binaryCodecs.Load(cs); ok {
+		return v.(*binary.Codec), nil
+	}
+	c, err := binary.NewCodec(cs, binary.Options{Version: binary.LayoutVersion(cs)})
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := binaryCodecs.LoadOrStore(cs, c)
+	return actual.(*binary.Codec), nil
+}
+
+// binaryPoolFor returns a cached DocumentPool for s, for backends that hold a
+// schema rather than a pool.
+func binaryPoolFor(s *definition.Schema) (*DocumentPool, error) {
+	if s == nil {
+		return nil, ErrNoSchema
+	}
+	if v, ok := binaryPools.Load(s); ok {
+		return v.(*DocumentPool), nil
+	}
+	p, err := NewDocumentPool(s)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := binaryPools.LoadOrStore(s, p)
+	return actual.(*DocumentPool), nil
+}
+
+// Codec returns the binary codec bound to this pool's compiled schema.
+func (c *DocumentPool) Codec() (*binary.Codec, error) {
+	if c == nil || c.cs == nil {
+		return nil, ErrNoSchema
+	}
+	return codecFor(c.cs)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, encoding the document —
+// identity and metadata included — as a single binary packet.
+func (d *Document) MarshalBinary() ([]byte, error) {
+	if d == nil {
+		return nil, ErrNilDocument
+	}
+	if d.isRecord() || len(d.prefix) > 0 || d.c == nil {
+		return nil, ErrBinaryView
+	}
+	codec, err := codecFor(d.cs)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Encode(d.c)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. Like UnmarshalJSON,
+// the receiver must already be bound to a schema; the packet is merged into
+// its container, then identity metadata defaults are completed and the
+// checksum computed.
+func (d *Document) UnmarshalBinary(data []byte) error {
+	if d == nil {
+		return ErrNilDocument
+	}
+	if d.cs == nil || d.c == nil {
+		return ErrNoSchema
+	}
+	codec, err := codecFor(d.cs)
+	if err != nil {
+		return err
+	}
+	if err := codec.DecodeInto(data, d.c, d.pool); err != nil {
+		return err
+	}
+	d.ensureMetadataDefaults()
+	return d.finalizeMetadata()
+}
+
+// SerializeFieldBinary encodes the root field name as a sparse binary packet,
+// reporting present=false when the field holds no value so callers can store
+// SQL NULL instead.
+func (d *Document) SerializeFieldBinary(name string) ([]byte, bool, error) {
+	if d == nil {
+		return nil, false, nil
+	}
+	if d.isRecord() || len(d.prefix) > 0 || d.c == nil {
+		return nil, false, ErrBinaryView
+	}
+	codec, err := codecFor(d.cs)
+	if err != nil {
+		return nil, false, err
+	}
+	return codec.EncodeField(d.c, name)
+}
+
+// FromBinary decodes a packet produced by MarshalBinary into a pooled,
+// schema-bound document. As with FromJSON, a valid decoded _id_ is honored
+// and otherwise generated, and metadata defaults and the checksum are
+// completed.
+func (c *DocumentPool) FromBinary(data []byte, opts ...Option) (*Document, error) {
+	codec, err := c.Codec()
+	if err != nil {
+		return nil, err
+	}
+	d, err := c.newDocument()
+	if err != nil {
+		return nil, err
+	}
+	if err := codec.DecodeInto(data, d.c, c.pool); err != nil {
+		c.Release(d)
+		return nil, err
+	}
+	if err := d.completeDecoded(opts); err != nil {
+		c.Release(d)
+		return nil, err
+	}
+	return d, nil
+}
+
+// EncodeBatch encodes root documents as one binary batch packet.
+func (c *DocumentPool) EncodeBatch(docs []*Document) ([]byte, error) {
+	codec, err := c.Codec()
+	if err != nil {
+		return nil, err
+	}
+	containers := make([]*container.DataContainer, len(docs))
+	for i, d := range docs {
+		if d == nil || d.isRecord() || len(d.prefix) > 0 || d.c == nil {
+			return nil, ErrBinaryView
+		}
+		containers[i] = d.c
+	}
+	return codec.EncodeBatch(containers)
+}
+
+// DecodeBatch decodes a batch packet produced by EncodeBatch into pooled
+// documents, completed as FromBinary completes a single document.
+func (c *DocumentPool) DecodeBatch(data []byte, opts ...Option) ([]*Document, error) {
+	codec, err := c.Codec()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := codec.DecodeBatch(data, c.pool)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]*Document, len(containers))
+	for i, dc := range containers {
+		docs[i] = &Document{cs: c.cs, c: dc, pool: c.pool, ctx: context.Background()}
+	}
+	for _, d := range docs {
+		if err := d.completeDecoded(opts); err != nil {
+			for _, d := range docs {
+				c.Release(d)
+			}
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// completeDecoded applies opts to a freshly decoded document and completes
+// its identity and metadata.
+func (d *Document) completeDecoded(opts []Option) error {
+	for _, o := range opts {
+		o(d)
+	}
+	if d.ID() == "" || !isValidID(d.ID()) {
+		d.setID(newUUID())
+	}
+	d.ensureMetadataDefaults()
+	return d.finalizeMetadata()
+}
+
+// EncodeBinaryField encodes value as the root field name of a document bound
+// to s, in the form SerializeFieldBinary produces. It serves backends that
+// write field values outside of a document, such as whole-field updates.
+func EncodeBinaryField(s *definition.Schema, name string, value any) ([]byte, bool, error) {
+	p, err := binaryPoolFor(s)
+	if err != nil {
+		return nil, false, err
+	}
+	d, err := p.newDocument()
+	if err != nil {
+		return nil, false, err
+	}
+	defer p.Release(d)
+	if value != nil {
+		if err := d.Set(name, value); err != nil {
+			return nil, false, err
+		}
+	}
+	return d.SerializeFieldBinary(name)
+}
+
+// DecodeBinaryField decodes a packet produced by SerializeFieldBinary or
+// EncodeBinaryField and returns the materialized value of the root field
+// name, or nil when the packet does not hold it.
+func DecodeBinaryField(s *definition.Schema, name string, data []byte) (any, error) {
+	p, err := binaryPoolFor(s)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := p.Codec()
+	if err != nil {
+		return nil, err
+	}
+	d, err := p.newDocument()
+	if err != nil {
+		return nil, err
+	}
+	defer p.Release(d)
+	if err := codec.DecodeInto(data, d.c, p.pool); err != nil {
+		return nil, err
+	}
+	if !d.HasKey(name) {
+		return nil, nil
+	}
+	return d.Get(name)
+}
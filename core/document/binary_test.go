@@ -0,0 +1,107 @@
+package document
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	cjson "github.com/asaidimu/go-anansi/v8/core/encoding/json"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+func requireSameContainer(t *testing.T, want, got *Document) {
+	t.Helper()
+	w, err := cjson.SerializeJSON(want.cs, want.c)
+	require.NoError(t, err)
+	g, err := cjson.SerializeJSON(got.cs, got.c)
+	require.NoError(t, err)
+	require.JSONEq(t, string(w), string(g))
+}
+
+func TestDocument_BinaryRoundTrip(t *testing.T) {
+	d := testDocument(t)
+	col := newTestCollection(t)
+
+	packet, err := d.MarshalBinary()
+	require.NoError(t, err)
+	got, err := col.FromBinary(packet)
+	require.NoError(t, err)
+	require.Equal(t, d.ID(), got.ID())
+	requireSameContainer(t, d, got)
+
+	into, err := col.Patch()
+	require.NoError(t, err)
+	require.NoError(t, into.UnmarshalBinary(packet))
+	requireSameContainer(t, d, into)
+
+	view, err := d.Get("address")
+	require.NoError(t, err)
+	_, err = NewRecordView(view.(map[string]any)).MarshalBinary()
+	require.ErrorIs(t, err, ErrBinaryView)
+}
+
+func TestDocument_BinaryBatch(t *testing.T) {
+	col := newTestCollection(t)
+	a := testDocument(t)
+	b, err := col.FromMap(map[string]any{"id": "user-2", "tags": []string{"x"}})
+	require.NoError(t, err)
+
+	packet, err := col.EncodeBatch([]*Document{a, b})
+	require.NoError(t, err)
+	docs, err := col.DecodeBatch(packet)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	requireSameContainer(t, a, docs[0])
+	requireSameContainer(t, b, docs[1])
+}
+
+func TestDocument_BinaryFieldColumns(t *testing.T) {
+	col := newTestCollection(t)
+	d := testDocument(t)
+
+	// Container fields travel as binary field packets, as a backend storing
+	// binary documents would hand them back.
+	columns, values := rowForDoc(t, col, d)
+	for i, name := range columns {
+		if _, ok := values[i].(string); !ok || name == data.MetadataField {
+			continue
+		}
+		abs := int(col.CompiledSchema().Schemas[0].FieldStart) + i
+		if !col.CompiledSchema().FieldTypes[abs].IsContainer() {
+			continue
+		}
+		packet, present, err := d.SerializeFieldBinary(name)
+		require.NoError(t, err)
+		require.True(t, present, name)
+		values[i] = packet
+	}
+	plan, err := col.PlanRow(columns, "__matches__")
+	require.NoError(t, err)
+	scanned, err := col.ScanRow(context.Background(), plan, values)
+	require.NoError(t, err)
+	requireSameContainer(t, d, scanned)
+
+	empty, err := col.Patch()
+	require.NoError(t, err)
+	_, present, err := empty.SerializeFieldBinary("address")
+	require.NoError(t, err)
+	require.False(t, present)
+}
+
+func TestDocument_BinaryFieldHelpers(t *testing.T) {
+	s, err := definition.FromJSON([]byte(testSchemaJSON))
+	require.NoError(t, err)
+
+	packet, present, err := EncodeBinaryField(s, "address", map[string]any{"street": "1 Main St", "zip": int64(10001)})
+	require.NoError(t, err)
+	require.True(t, present)
+	got, err := DecodeBinaryField(s, "address", packet)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"street": "1 Main St", "zip": int64(10001)}, got)
+
+	got, err = DecodeBinaryField(s, "profile", packet)
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
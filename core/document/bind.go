@@ -13,7 +13,9 @@ import (
 // Binding is lazy: values are read from the container's typed slots one field
 // at a time via data.BindSourced, so a bind never materializes the whole
 // document into a map. Only generated fast-path unmarshalers (which require a
-// map-backed data.Document) trigger the materializing fallback.
+// map-backed data.Document) trigger the materializing fallback. Targets that
+// implement DocumentDecoder bypass reflection and read their typed slots
+// directly.
 
 // BindTo binds the document data into a target struct.
 func (d *Document) BindTo(target any) error {
@@ -40,6 +42,9 @@ func (d *Document) bindTo(ctx context.Context, target any, tag string) error {
 	if d == nil {
 		return ErrNilDocument
 	}
+	if dec, ok := target.(DocumentDecoder); ok && tag == "" && !d.isRecord() && d.cs != nil {
+		return d.decodeStruct(dec)
+	}
 	return data.BindSourced(d, d.asDataDocument, target, ctx, tag)
 }
 
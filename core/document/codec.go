@@ -0,0 +1,975 @@
+package document
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// ============================================================================
+// GENERATED CODECS
+// ============================================================================
+//
+// Structs built by the reflective binder walk their anansi tags on every
+// FromStruct and BindTo. A struct that implements DocumentEncoder or
+// DocumentDecoder skips that walk: FromStruct, FromPartialStruct and BindTo
+// hand it an Encoder or Decoder positioned on the document's schema slot, and
+// the struct reads or writes each field by name straight into its typed
+// container slot. The Go code generator emits both methods for generated
+// models, so ModelCollection uses them without configuration; structs without
+// them keep the reflective path.
+//
+// Encoder and Decoder carry a sticky error: once a call fails, later calls are
+// no-ops and the error is reported by Err.
+
+// DocumentEncoder is implemented by structs that write themselves into a
+// document without reflection.
+type DocumentEncoder interface {
+	EncodeDocument(e *Encoder) error
+}
+
+// DocumentDecoder is implemented by structs that read themselves from a
+// document without reflection.
+type DocumentDecoder interface {
+	DecodeDocument(d *Decoder) error
+}
+
+// Encoder writes struct fields into the object of a document addressed by a
+// schema slot.
+type Encoder struct {
+	doc     *Document
+	c       *container.DataContainer
+	slot    uint16
+	base    definition.ResolvedPath
+	partial bool
+	// lenient drops fields the schema does not declare instead of failing,
+	// as populateMetadata does for undeclared metadata keys.
+	lenient bool
+	scratch definition.ResolvedPath
+	err     *error
+}
+
+// Decoder reads struct fields from the object of a document addressed by a
+// schema slot.
+type Decoder struct {
+	doc     *Document
+	c       *container.DataContainer
+	slot    uint16
+	base    definition.ResolvedPath
+	scratch definition.ResolvedPath
+	err     *error
+}
+
+// encodeStruct populates d from a generated encoder, the counterpart of the
+// reflective populateFromStruct.
+func (d *Document) encodeStruct(s DocumentEncoder, partial bool) error {
+	var err error
+	e := &Encoder{doc: d, c: d.c, slot: d.slotIdx, base: d.prefix, partial: partial, err: &err}
+	if encErr := s.EncodeDocument(e); encErr != nil {
+		return encErr
+	}
+	return err
+}
+
+// decodeStruct binds d into a generated decoder.
+func (d *Document) decodeStruct(s DocumentDecoder) error {
+	var err error
+	dec := &Decoder{doc: d, c: d.c, slot: d.slotIdx, base: d.prefix, err: &err}
+	if decErr := s.DecodeDocument(dec); decErr != nil {
+		return decErr
+	}
+	return err
+}
+
+// codecErr attributes err to the named field.
+func codecErr(op, name string, err error) error {
+	return common.SystemErrorFrom(err).WithOperation(op).WithPath(name)
+}
+
+// resolveField returns the descriptor and full path of the named field of the
+// current object. The path is only valid until the next call.
+func resolveField(doc *Document, slot uint16, base definition.ResolvedPath, scratch *definition.ResolvedPath, name string) (definition.FieldDescriptor, definition.ResolvedPath, error) {
+	step, fd, err := doc.cs.ResolveFieldStep(slot, name)
+	if err != nil {
+		return 0, nil, doc.keyErr(name)
+	}
+	p := append(append((*scratch)[:0], base...), step)
+	*scratch = p
+	return fd, p, nil
+}
+
+// storageKey returns the key holding a field's value: the structural key for
+// records and arrays with a child schema, the flat leaf key otherwise.
+func storageKey(cs *definition.CompiledSchema, fd definition.FieldDescriptor, p definition.ResolvedPath) (container.DataContainerKey, error) {
+	if !fd.Terminal() && fd.ChildSchemaIdx() != definition.FdNoChild {
+		return internalKey(fd), nil
+	}
+	return computeLeafKey(cs, fd, p)
+}
+
+// ----------------------------------------------------------------------------
+// Encoder
+// ----------------------------------------------------------------------------
+
+// resolve resolves the named field. A field the schema does not declare fails
+// the encoder, as FromStruct fails on unknown paths, unless it is lenient.
+func (e *Encoder) resolve(name string) (definition.FieldDescriptor, definition.ResolvedPath, bool) {
+	fd, p, err := resolveField(e.doc, e.slot, e.base, &e.scratch, name)
+	if err != nil {
+		if !e.lenient {
+			e.fail(name, err)
+		}
+		return 0, nil, false
+	}
+	return fd, p, true
+}
+
+// Err returns the first error encountered by the encoder.
+func (e *Encoder) Err() error {
+	return *e.err
+}
+
+// Partial reports whether the encoder builds a patch, in which system fields
+// and zero values are skipped.
+func (e *Encoder) Partial() bool {
+	return e.partial
+}
+
+func (e *Encoder) fail(name string, err error) {
+	if *e.err == nil {
+		*e.err = codecErr("document.Encoder", name, err)
+	}
+}
+
+// skip reports whether a zero value is left out: always in a patch, and in a
+// full document when the field is tagged omitempty.
+func (e *Encoder) skip(zero, omitEmpty bool) bool {
+	return zero && (e.partial || omitEmpty)
+}
+
+// leaf resolves the named terminal field to its storage key and slot type.
+func (e *Encoder) leaf(name string) (container.DataContainerKey, container.DataType, bool) {
+	if *e.err != nil {
+		return 0, 0, false
+	}
+	fd, p, ok := e.resolve(name)
+	if !ok {
+		return 0, 0, false
+	}
+	if !fd.Terminal() || fd.ChildSchemaIdx() != definition.FdNoChild {
+		e.fail(name, e.doc.typeErr(name, "a terminal field", dtName(fd.DataType())))
+		return 0, 0, false
+	}
+	k, err := computeLeafKey(e.doc.cs, fd, p)
+	if err != nil {
+		e.fail(name, err)
+		return 0, 0, false
+	}
+	return k, fd.DataType(), true
+}
+
+// check records err against the named field.
+func (e *Encoder) check(name string, err error) {
+	if err != nil {
+		e.fail(name, err)
+	}
+}
+
+// coerce writes v through the boxing path. It is taken when a slot's type
+// differs from the generated one, i.e. the struct was generated from another
+// version of the schema.
+func (e *Encoder) coerce(name string, dt container.DataType, k container.DataContainerKey, v any) {
+	e.check(name, setByType(e.c, dt, k, v))
+}
+
+// Null writes an explicit null to the named field.
+func (e *Encoder) Null(name string) {
+	if *e.err != nil {
+		return
+	}
+	fd, p, ok := e.resolve(name)
+	if !ok {
+		return
+	}
+	k, err := storageKey(e.doc.cs, fd, p)
+	if err != nil {
+		e.fail(name, err)
+		return
+	}
+	e.c.SetNull(k)
+}
+
+// String writes a string field.
+func (e *Encoder) String(name, v string, omitEmpty bool) {
+	if !e.skip(v == "", omitEmpty) {
+		e.putString(name, v)
+	}
+}
+
+func (e *Encoder) putString(name, v string) {
+	k, dt, ok := e.leaf(name)
+	if !ok {
+		return
+	}
+	if dt != container.TypeString {
+		e.coerce(name, dt, k, v)
+		return
+	}
+	e.check(name, e.c.SetString(k, v))
+}
+
+// Int writes an integer field.
+func (e *Encoder) Int(name string, v int64, omitEmpty bool) {
+	if !e.skip(v == 0, omitEmpty) {
+		e.putInt(name, v)
+	}
+}
+
+func (e *Encoder) putInt(name string, v int64) {
+	k, dt, ok := e.leaf(name)
+	if !ok {
+		return
+	}
+	if dt != container.TypeInt {
+		e.coerce(name, dt, k, v)
+		return
+	}
+	e.check(name, e.c.SetInt(k, v))
+}
+
+// Float writes a number field.
+func (e *Encoder) Float(name string, v float64, omitEmpty bool) {
+	if !e.skip(v == 0, omitEmpty) {
+		e.putFloat(name, v)
+	}
+}
+
+func (e *Encoder) putFloat(name string, v float64) {
+	k, dt, ok := e.leaf(name)
+	if !ok {
+		return
+	}
+	if dt != container.TypeFloat {
+		e.coerce(name, dt, k, v)
+		return
+	}
+	e.check(name, e.c.SetFloat(k, v))
+}
+
+// Bool writes a boolean field.
+func (e *Encoder) Bool(name string, v bool, omitEmpty bool) {
+	if !e.skip(!v, omitEmpty) {
+		e.putBool(name, v)
+	}
+}
+
+func (e *Encoder) putBool(name string, v bool) {
+	k, dt, ok := e.leaf(name)
+	if !ok {
+		return
+	}
+	if dt != container.TypeBool {
+		e.coerce(name, dt, k, v)
+		return
+	}
+	e.check(name, e.c.SetBool(k, v))
+}
+
+// Bytes writes a bytes field. A nil slice is written as null.
+func (e *Encoder) Bytes(name string, v []byte, omitEmpty bool) {
+	if e.skip(v == nil, omitEmpty) {
+		return
+	}
+	if v == nil {
+		e.Null(name)
+		return
+	}
+	k, dt, ok := e.leaf(name)
+	if !ok {
+		return
+	}
+	if dt != container.TypeBytes {
+		e.coerce(name, dt, k, v)
+		return
+	}
+	e.check(name, e.c.SetBytes(k, slices.Clone(v)))
+}
+
+// OptString writes an optional string field; nil is written as null.
+func (e *Encoder) OptString(name string, v *string, omitEmpty bool) {
+	if v == nil {
+		e.optNull(name, omitEmpty)
+		return
+	}
+	e.putString(name, *v)
+}
+
+// OptInt writes an optional integer field; nil is written as null.
+func (e *Encoder) OptInt(name string, v *int64, omitEmpty bool) {
+	if v == nil {
+		e.optNull(name, omitEmpty)
+		return
+	}
+	e.putInt(name, *v)
+}
+
+// OptFloat writes an optional number field; nil is written as null.
+func (e *Encoder) OptFloat(name string, v *float64, omitEmpty bool) {
+	if v == nil {
+		e.optNull(name, omitEmpty)
+		return
+	}
+	e.putFloat(name, *v)
+}
+
+// OptBool writes an optional boolean field; nil is written as null.
+func (e *Encoder) OptBool(name string, v *bool, omitEmpty bool) {
+	if v == nil {
+		e.optNull(name, omitEmpty)
+		return
+	}
+	e.putBool(name, *v)
+}
+
+func (e *Encoder) optNull(name string, omitEmpty bool) {
+	if !e.skip(true, omitEmpty) {
+		e.Null(name)
+	}
+}
+
+// Strings writes a string array field. Array values are copied, so the
+// document does not share memory with the struct.
+func (e *Encoder) Strings(name string, v []string, omitEmpty bool) {
+	if e.skip(v == nil, omitEmpty) {
+		return
+	}
+	k, dt, ok := e.leaf(name)
+	if !ok {
+		return
+	}
+	if dt != container.TypeArrayString {
+		e.coerce(name, dt, k, v)
+		return
+	}
+	e.check(name, e.c.SetArrayString(k, orEmpty(slices.Clone(v))))
+}
+
+// Ints writes an integer array field.
+func (e *Encoder) Ints(name string, v []int64, omitEmpty bool) {
+	if e.skip(v == nil, omitEmpty) {
+		return
+	}
+	k, dt, ok := e.leaf(name)
+	if !ok {
+		return
+	}
+	if dt != container.TypeArrayInt {
+		e.coerce(name, dt, k, v)
+		return
+	}
+	e.check(name, e.c.SetArrayInt(k, orEmpty(slices.Clone(v))))
+}
+
+// Floats writes a number array field.
+func (e *Encoder) Floats(name string, v []float64, omitEmpty bool) {
+	if e.skip(v == nil, omitEmpty) {
+		return
+	}
+	k, dt, ok := e.leaf(name)
+	if !ok {
+		return
+	}
+	if dt != container.TypeArrayFloat {
+		e.coerce(name, dt, k, v)
+		return
+	}
+	e.check(name, e.c.SetArrayFloat(k, orEmpty(slices.Clone(v))))
+}
+
+// Bools writes a boolean array field.
+func (e *Encoder) Bools(name string, v []bool, omitEmpty bool) {
+	if e.skip(v == nil, omitEmpty) {
+		return
+	}
+	k, dt, ok := e.leaf(name)
+	if !ok {
+		return
+	}
+	if dt != container.TypeArrayBool {
+		e.coerce(name, dt, k, v)
+		return
+	}
+	e.check(name, e.c.SetArrayBool(k, orEmpty(slices.Clone(v))))
+}
+
+// orEmpty maps a nil slice to an empty one: a present array field is never
+// stored as nil.
+func orEmpty[T any](v []T) []T {
+	if v == nil {
+		return []T{}
+	}
+	return v
+}
+
+// Record writes a record field. A nil map is written as null.
+func (e *Encoder) Record(name string, v map[string]any, omitEmpty bool) {
+	if e.skip(v == nil, omitEmpty) {
+		return
+	}
+	if *e.err != nil {
+		return
+	}
+	fd, p, ok := e.resolve(name)
+	if !ok {
+		return
+	}
+	if fd.DataType() != container.TypeRecord {
+		e.fail(name, e.doc.typeErr(name, dtName(container.TypeRecord), dtName(fd.DataType())))
+		return
+	}
+	k, err := storageKey(e.doc.cs, fd, p)
+	if err != nil {
+		e.fail(name, err)
+		return
+	}
+	if v == nil {
+		e.c.SetNull(k)
+		return
+	}
+	e.check(name, e.c.SetRecord(k, maps.Clone(v)))
+}
+
+// Value writes any value through the coercing path used by Set. It covers
+// field kinds without a typed method.
+func (e *Encoder) Value(name string, v any, omitEmpty bool) {
+	if e.skip(v == nil, omitEmpty) || *e.err != nil {
+		return
+	}
+	step, _, err := e.doc.cs.ResolveFieldStep(e.slot, name)
+	if err != nil {
+		if !e.lenient {
+			e.fail(name, e.doc.keyErr(name))
+		}
+		return
+	}
+	if err := setInto(e.doc.cs, e.c, e.doc.pool, e.slot, uint16(step.FieldIdx()), e.base, v); err != nil {
+		e.fail(name, err)
+	}
+}
+
+// Object writes a nested object field from v.
+func (e *Encoder) Object(name string, v DocumentEncoder) {
+	e.object(name, v, e.lenient)
+}
+
+func (e *Encoder) object(name string, v DocumentEncoder, lenient bool) {
+	if *e.err != nil {
+		return
+	}
+	fd, p, ok := e.resolve(name)
+	if !ok {
+		return
+	}
+	if fd.Terminal() || fd.ChildSchemaIdx() == definition.FdNoChild || fd.DataType() == container.TypeArrayObject || fd.DataType() == container.TypeRecord {
+		e.fail(name, e.doc.typeErr(name, "object", dtName(fd.DataType())))
+		return
+	}
+	child := &Encoder{doc: e.doc, c: e.c, slot: fd.ChildSchemaIdx(), base: slices.Clone(p), partial: e.partial, lenient: lenient, err: e.err}
+	if err := v.EncodeDocument(child); err != nil {
+		e.fail(name, err)
+	}
+}
+
+// EncodeObjects writes an array-of-objects field, one pooled child container
+// per item. A nil slice is written as an empty array.
+func EncodeObjects[T any, P interface {
+	*T
+	DocumentEncoder
+}](e *Encoder, name string, items []T, omitEmpty bool) {
+	if e.skip(items == nil, omitEmpty) || *e.err != nil {
+		return
+	}
+	fd, p, ok := e.resolve(name)
+	if !ok {
+		return
+	}
+	if fd.DataType() != container.TypeArrayObject {
+		e.fail(name, e.doc.typeErr(name, dtName(container.TypeArrayObject), dtName(fd.DataType())))
+		return
+	}
+	base := slices.Clone(p)
+	children := make([]*container.DataContainer, len(items))
+	for i := range items {
+		var c *container.DataContainer
+		if e.doc.pool != nil {
+			c = e.doc.pool.Get()
+		} else {
+			c = container.NewDataContainer()
+		}
+		children[i] = c
+		child := &Encoder{doc: e.doc, c: c, slot: fd.ChildSchemaIdx(), base: base, partial: e.partial, lenient: e.lenient, err: e.err}
+		if err := P(&items[i]).EncodeDocument(child); err != nil {
+			e.fail(name, err)
+		}
+		if *e.err != nil {
+			return
+		}
+	}
+	if err := e.c.SetArrayObject(internalKey(fd), children); err != nil {
+		e.fail(name, err)
+	}
+}
+
+// ID sets the document identity. Empty and malformed identifiers are ignored,
+// as in FromStruct.
+func (e *Encoder) ID(id string) {
+	if *e.err == nil && isValidID(id) {
+		e.doc.setID(id)
+	}
+}
+
+// MetadataMap replaces the document metadata from m. A nil map leaves the
+// metadata untouched.
+func (e *Encoder) MetadataMap(m map[string]any) {
+	if *e.err != nil || m == nil {
+		return
+	}
+	if err := e.doc.populateMetadata(m); err != nil {
+		e.fail(data.MetadataField, err)
+	}
+}
+
+// Metadata replaces the document metadata from a generated metadata struct.
+func (e *Encoder) Metadata(v DocumentEncoder) {
+	if *e.err != nil {
+		return
+	}
+	if err := e.doc.clearMetadata(); err != nil {
+		e.fail(data.MetadataField, err)
+		return
+	}
+	e.object(data.MetadataField, v, true)
+}
+
+// ----------------------------------------------------------------------------
+// Decoder
+// ----------------------------------------------------------------------------
+
+// resolve resolves the named field. A field the schema does not declare reads
+// as absent, as the reflective binder leaves it unset.
+func (d *Decoder) resolve(name string) (definition.FieldDescriptor, definition.ResolvedPath, bool) {
+	fd, p, err := resolveField(d.doc, d.slot, d.base, &d.scratch, name)
+	if err != nil {
+		return 0, nil, false
+	}
+	return fd, p, true
+}
+
+// Err returns the first error encountered by the decoder.
+func (d *Decoder) Err() error {
+	return *d.err
+}
+
+func (d *Decoder) fail(name string, err error) {
+	if *d.err == nil {
+		*d.err = codecErr("document.Decoder", name, err)
+	}
+}
+
+// get reads the named leaf. It returns the typed value when the slot holds
+// want, or the stored value boxed in v for coercion otherwise. ok is false when
+// the field is absent or null.
+func (d *Decoder) get(name string, want container.DataType) (k container.DataContainerKey, v any, match, ok bool) {
+	if *d.err != nil {
+		return 0, nil, false, false
+	}
+	fd, p, ok := d.resolve(name)
+	if !ok {
+		return 0, nil, false, false
+	}
+	if !fd.Terminal() || fd.ChildSchemaIdx() != definition.FdNoChild {
+		d.fail(name, d.doc.typeErr(name, dtName(want), dtName(fd.DataType())))
+		return 0, nil, false, false
+	}
+	k, err := computeLeafKey(d.doc.cs, fd, p)
+	if err != nil {
+		d.fail(name, err)
+		return 0, nil, false, false
+	}
+	if !d.c.HasValue(k) {
+		return k, nil, false, false
+	}
+	if fd.DataType() == want {
+		return k, nil, true, true
+	}
+	v, ok, err = getByType(d.c, fd.DataType(), k)
+	if err != nil {
+		d.fail(name, err)
+		return k, nil, false, false
+	}
+	return k, v, false, ok
+}
+
+// Has reports whether the named field holds a value, an explicit null
+// included. Generated code uses it to allocate optional nested objects.
+func (d *Decoder) Has(name string) bool {
+	if *d.err != nil {
+		return false
+	}
+	fd, p, ok := d.resolve(name)
+	if !ok {
+		return false
+	}
+	ok, err := present(d.doc.cs, d.c, fd, p)
+	if err != nil {
+		d.fail(name, err)
+		return false
+	}
+	return ok
+}
+
+// String reads a string field; absent fields read as "".
+func (d *Decoder) String(name string) string {
+	if v := d.OptString(name); v != nil {
+		return *v
+	}
+	return ""
+}
+
+// OptString reads an optional string field; absent and null fields read as
+// nil.
+func (d *Decoder) OptString(name string) *string {
+	k, raw, match, ok := d.get(name, container.TypeString)
+	if !ok {
+		return nil
+	}
+	if !match {
+		s := asString(raw)
+		return &s
+	}
+	s, _, err := d.c.GetString(k)
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	return &s
+}
+
+// Int reads an integer field.
+func (d *Decoder) Int(name string) int64 {
+	if v := d.OptInt(name); v != nil {
+		return *v
+	}
+	return 0
+}
+
+// OptInt reads an optional integer field.
+func (d *Decoder) OptInt(name string) *int64 {
+	k, raw, match, ok := d.get(name, container.TypeInt)
+	if !ok {
+		return nil
+	}
+	n, err := int64(0), error(nil)
+	if match {
+		n, _, err = d.c.GetInt(k)
+	} else {
+		n, err = asInt64(raw)
+	}
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	return &n
+}
+
+// Float reads a number field.
+func (d *Decoder) Float(name string) float64 {
+	if v := d.OptFloat(name); v != nil {
+		return *v
+	}
+	return 0
+}
+
+// OptFloat reads an optional number field.
+func (d *Decoder) OptFloat(name string) *float64 {
+	k, raw, match, ok := d.get(name, container.TypeFloat)
+	if !ok {
+		return nil
+	}
+	f, err := float64(0), error(nil)
+	if match {
+		f, _, err = d.c.GetFloat(k)
+	} else {
+		f, err = asFloat64(raw)
+	}
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	return &f
+}
+
+// Bool reads a boolean field.
+func (d *Decoder) Bool(name string) bool {
+	if v := d.OptBool(name); v != nil {
+		return *v
+	}
+	return false
+}
+
+// OptBool reads an optional boolean field.
+func (d *Decoder) OptBool(name string) *bool {
+	k, raw, match, ok := d.get(name, container.TypeBool)
+	if !ok {
+		return nil
+	}
+	if !match {
+		b, isBool := raw.(bool)
+		if !isBool {
+			d.fail(name, d.doc.typeErr(name, dtName(container.TypeBool), raw))
+			return nil
+		}
+		return &b
+	}
+	b, _, err := d.c.GetBool(k)
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	return &b
+}
+
+// Bytes reads a bytes field as a copy of the stored value.
+func (d *Decoder) Bytes(name string) []byte {
+	k, raw, match, ok := d.get(name, container.TypeBytes)
+	if !ok {
+		return nil
+	}
+	if !match {
+		switch b := raw.(type) {
+		case []byte:
+			return slices.Clone(b)
+		case string:
+			return []byte(b)
+		}
+		d.fail(name, d.doc.typeErr(name, dtName(container.TypeBytes), raw))
+		return nil
+	}
+	b, _, err := d.c.GetBytes(k)
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	return slices.Clone(b)
+}
+
+// Strings reads a string array field as a copy of the stored value.
+func (d *Decoder) Strings(name string) []string {
+	k, raw, match, ok := d.get(name, container.TypeArrayString)
+	if !ok {
+		return nil
+	}
+	if !match {
+		return asStringSlice(raw)
+	}
+	v, _, err := d.c.GetArrayString(k)
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	return orEmpty(slices.Clone(v))
+}
+
+// Ints reads an integer array field as a copy of the stored value.
+func (d *Decoder) Ints(name string) []int64 {
+	k, raw, match, ok := d.get(name, container.TypeArrayInt)
+	if !ok {
+		return nil
+	}
+	var v []int64
+	var err error
+	if match {
+		v, _, err = d.c.GetArrayInt(k)
+		v = orEmpty(slices.Clone(v))
+	} else {
+		v, err = asInt64Slice(raw)
+	}
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	return v
+}
+
+// Floats reads a number array field as a copy of the stored value.
+func (d *Decoder) Floats(name string) []float64 {
+	k, raw, match, ok := d.get(name, container.TypeArrayFloat)
+	if !ok {
+		return nil
+	}
+	var v []float64
+	var err error
+	if match {
+		v, _, err = d.c.GetArrayFloat(k)
+		v = orEmpty(slices.Clone(v))
+	} else {
+		v, err = asFloat64Slice(raw)
+	}
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	return v
+}
+
+// Bools reads a boolean array field as a copy of the stored value.
+func (d *Decoder) Bools(name string) []bool {
+	k, raw, match, ok := d.get(name, container.TypeArrayBool)
+	if !ok {
+		return nil
+	}
+	var v []bool
+	var err error
+	if match {
+		v, _, err = d.c.GetArrayBool(k)
+		v = orEmpty(slices.Clone(v))
+	} else {
+		v, err = asBoolSlice(raw)
+	}
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	return v
+}
+
+// Record reads a record field as a shallow copy of the stored map.
+func (d *Decoder) Record(name string) map[string]any {
+	if *d.err != nil {
+		return nil
+	}
+	fd, p, ok := d.resolve(name)
+	if !ok {
+		return nil
+	}
+	if fd.DataType() != container.TypeRecord {
+		d.fail(name, d.doc.typeErr(name, dtName(container.TypeRecord), dtName(fd.DataType())))
+		return nil
+	}
+	k, err := storageKey(d.doc.cs, fd, p)
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	m, _, err := d.c.GetRecord(k)
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	return maps.Clone(m)
+}
+
+// Value reads any field through the materializing path used by Get; absent
+// fields read as nil.
+func (d *Decoder) Value(name string) any {
+	if *d.err != nil || !d.Has(name) {
+		return nil
+	}
+	v, err := d.view().Get(name)
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	return v
+}
+
+// view returns a document view over the decoder's object.
+func (d *Decoder) view() *Document {
+	if d.c == d.doc.c {
+		return d.doc.newNestedView(d.slot, d.base)
+	}
+	return d.doc.newNestedViewForChild(d.c, d.slot, d.base)
+}
+
+// Object reads a nested object field into v. Absent objects leave v
+// untouched.
+func (d *Decoder) Object(name string, v DocumentDecoder) {
+	if *d.err != nil {
+		return
+	}
+	fd, p, ok := d.resolve(name)
+	if !ok {
+		return
+	}
+	if fd.Terminal() || fd.ChildSchemaIdx() == definition.FdNoChild || fd.DataType() == container.TypeArrayObject || fd.DataType() == container.TypeRecord {
+		d.fail(name, d.doc.typeErr(name, "object", dtName(fd.DataType())))
+		return
+	}
+	child := &Decoder{doc: d.doc, c: d.c, slot: fd.ChildSchemaIdx(), base: slices.Clone(p), err: d.err}
+	if err := v.DecodeDocument(child); err != nil {
+		d.fail(name, err)
+	}
+}
+
+// DecodeObjects reads an array-of-objects field. Absent arrays read as nil.
+func DecodeObjects[T any, P interface {
+	*T
+	DocumentDecoder
+}](d *Decoder, name string) []T {
+	if *d.err != nil {
+		return nil
+	}
+	fd, p, ok := d.resolve(name)
+	if !ok {
+		return nil
+	}
+	if fd.DataType() != container.TypeArrayObject {
+		d.fail(name, d.doc.typeErr(name, dtName(container.TypeArrayObject), dtName(fd.DataType())))
+		return nil
+	}
+	children, ok, err := d.c.GetArrayObject(internalKey(fd))
+	if err != nil {
+		d.fail(name, err)
+		return nil
+	}
+	if !ok || children == nil {
+		return nil
+	}
+	base := slices.Clone(p)
+	out := make([]T, len(children))
+	for i, c := range children {
+		if c == nil {
+			continue
+		}
+		child := &Decoder{doc: d.doc, c: c, slot: fd.ChildSchemaIdx(), base: base, err: d.err}
+		if err := P(&out[i]).DecodeDocument(child); err != nil {
+			d.fail(name, err)
+		}
+		if *d.err != nil {
+			return nil
+		}
+	}
+	return out
+}
+
+// ID returns the document identity.
+func (d *Decoder) ID() string {
+	return d.doc.ID()
+}
+
+// MetadataMap returns the document metadata.
+func (d *Decoder) MetadataMap() map[string]any {
+	return d.doc.Metadata()
+}
+
+// Model fills the system fields of an embedded DocumentModel and links it to
+// parent, as the reflective binder does.
+func (d *Decoder) Model(dm *DocumentModel, parent any) {
+	dm.ID = d.doc.ID()
+	dm.Metadata = d.doc.Metadata()
+	dm.parent = parent
+}
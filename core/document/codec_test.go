@@ -0,0 +1,224 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+const codecSchemaJSON = `{
+  "version": "1.0.0",
+  "name": "codecorder",
+  "fields": {
+    "name":    { "name": "name", "type": "string", "required": true },
+    "qty":     { "name": "qty", "type": "integer" },
+    "price":   { "name": "price", "type": "number" },
+    "active":  { "name": "active", "type": "boolean" },
+    "tags":    { "name": "tags", "type": "array", "schema": { "type": "string" } },
+    "attrs":   { "name": "attrs", "type": "record" },
+    "address": { "name": "address", "type": "object", "schema": { "id": "codecaddress" } },
+    "items":   { "name": "items", "type": "array", "schema": { "id": "codecitem" } }
+  },
+  "schemas": {
+    "codecaddress": { "name": "codecaddress", "fields": { "city": { "name": "city", "type": "string" } } },
+    "codecitem": { "name": "codecitem", "fields": { "label": { "name": "label", "type": "string" } } }
+  }
+}`
+
+type codecStatus string
+
+type codecAddress struct {
+	City *string `anansi:"city,omitempty"`
+}
+
+func (m *codecAddress) EncodeDocument(e *Encoder) error {
+	e.OptString("city", m.City, true)
+	return e.Err()
+}
+
+func (m *codecAddress) DecodeDocument(d *Decoder) error {
+	m.City = d.OptString("city")
+	return d.Err()
+}
+
+type codecItem struct {
+	Label string `anansi:"label"`
+}
+
+func (m *codecItem) EncodeDocument(e *Encoder) error {
+	e.String("label", m.Label, false)
+	return e.Err()
+}
+
+func (m *codecItem) DecodeDocument(d *Decoder) error {
+	m.Label = d.String("label")
+	return d.Err()
+}
+
+// codecOrder is written the way the Go generator emits codecs.
+type codecOrder struct {
+	DocumentModel
+	Name    codecStatus    `anansi:"name"`
+	Qty     *int64         `anansi:"qty,omitempty"`
+	Price   *float64       `anansi:"price,omitempty"`
+	Active  *bool          `anansi:"active,omitempty"`
+	Tags    []string       `anansi:"tags,omitempty"`
+	Attrs   map[string]any `anansi:"attrs,omitempty"`
+	Address *codecAddress  `anansi:"address,omitempty"`
+	Items   []codecItem    `anansi:"items,omitempty"`
+}
+
+func (m *codecOrder) EncodeDocument(e *Encoder) error {
+	if !e.Partial() {
+		e.ID(m.DocumentModel.ID)
+		e.MetadataMap(m.DocumentModel.Metadata)
+	}
+	e.String("name", string(m.Name), false)
+	e.OptInt("qty", m.Qty, true)
+	e.OptFloat("price", m.Price, true)
+	e.OptBool("active", m.Active, true)
+	e.Strings("tags", m.Tags, true)
+	e.Record("attrs", m.Attrs, true)
+	if m.Address != nil {
+		e.Object("address", m.Address)
+	}
+	EncodeObjects(e, "items", m.Items, true)
+	return e.Err()
+}
+
+func (m *codecOrder) DecodeDocument(d *Decoder) error {
+	d.Model(&m.DocumentModel, m)
+	m.Name = codecStatus(d.String("name"))
+	m.Qty = d.OptInt("qty")
+	m.Price = d.OptFloat("price")
+	m.Active = d.OptBool("active")
+	m.Tags = d.Strings("tags")
+	m.Attrs = d.Record("attrs")
+	m.Address = nil
+	if d.Has("address") {
+		m.Address = &codecAddress{}
+		d.Object("address", m.Address)
+	}
+	m.Items = DecodeObjects[codecItem](d, "items")
+	return d.Err()
+}
+
+// reflectCodecOrder has codecOrder's fields but no codec methods.
+type reflectCodecOrder codecOrder
+
+func newCodecPool(t *testing.T) *DocumentPool {
+	t.Helper()
+	s, err := definition.FromJSON([]byte(codecSchemaJSON))
+	require.NoError(t, err)
+	col, err := NewDocumentPool(s)
+	require.NoError(t, err)
+	return col
+}
+
+func sampleCodecOrder() codecOrder {
+	qty, price, active, city := int64(3), 9.5, true, "Lyon"
+	return codecOrder{
+		DocumentModel: DocumentModel{ID: newUUID()},
+		Name:          "lamp",
+		Qty:           &qty,
+		Price:         &price,
+		Active:        &active,
+		Tags:          []string{"a", "b"},
+		Attrs:         map[string]any{"k": "v"},
+		Address:       &codecAddress{City: &city},
+		Items:         []codecItem{{Label: "x"}, {Label: "y"}},
+	}
+}
+
+func TestCodec_MatchesReflection(t *testing.T) {
+	col := newCodecPool(t)
+	order := sampleCodecOrder()
+	reflected := reflectCodecOrder(order)
+
+	fast, err := col.FromStruct(&order)
+	require.NoError(t, err)
+	defer fast.Release()
+	slow, err := col.FromStruct(&reflected)
+	require.NoError(t, err)
+	defer slow.Release()
+	assert.Equal(t, slow.Data(), fast.Data())
+	assert.Equal(t, order.DocumentModel.ID, fast.ID())
+
+	var decoded codecOrder
+	require.NoError(t, slow.BindTo(&decoded))
+	var reflectDecoded reflectCodecOrder
+	require.NoError(t, slow.BindTo(&reflectDecoded))
+	assert.Equal(t, reflectDecoded.Name, decoded.Name)
+	assert.Equal(t, reflectDecoded.Qty, decoded.Qty)
+	assert.Equal(t, reflectDecoded.Price, decoded.Price)
+	assert.Equal(t, reflectDecoded.Active, decoded.Active)
+	assert.Equal(t, reflectDecoded.Tags, decoded.Tags)
+	assert.Equal(t, reflectDecoded.Attrs, decoded.Attrs)
+	assert.Equal(t, reflectDecoded.Address, decoded.Address)
+	assert.Equal(t, reflectDecoded.Items, decoded.Items)
+	assert.Equal(t, slow.ID(), decoded.DocumentModel.ID)
+	assert.Same(t, &decoded, decoded.DocumentModel.parent)
+}
+
+func TestCodec_PartialSkipsZeroValues(t *testing.T) {
+	col := newCodecPool(t)
+	price := 0.0
+	patch, err := col.FromPartialStruct(&codecOrder{Name: "lamp", Price: &price})
+	require.NoError(t, err)
+	defer patch.Release()
+	assert.Equal(t, map[string]any{"name": "lamp", "price": 0.0}, patch.Data())
+	assert.Empty(t, patch.ID())
+}
+
+func TestCodec_DecodedValuesDoNotAliasContainer(t *testing.T) {
+	col := newCodecPool(t)
+	order := sampleCodecOrder()
+	doc, err := col.FromStruct(&order)
+	require.NoError(t, err)
+	order.Tags[0] = "changed"
+
+	var decoded codecOrder
+	require.NoError(t, doc.BindTo(&decoded))
+	doc.Release()
+	assert.Equal(t, []string{"a", "b"}, decoded.Tags)
+}
+
+type undeclaredField struct{}
+
+func (undeclaredField) EncodeDocument(e *Encoder) error {
+	e.String("missing", "x", false)
+	return e.Err()
+}
+
+func (*undeclaredField) DecodeDocument(d *Decoder) error {
+	if d.String("missing") != "" {
+		return assert.AnError
+	}
+	return d.Err()
+}
+
+func TestCodec_UndeclaredFields(t *testing.T) {
+	col := newCodecPool(t)
+	_, err := col.FromStruct(undeclaredField{})
+	assert.Error(t, err, "encoding a field the schema lacks fails, as FromStruct does")
+
+	doc, err := col.FromStruct(&reflectCodecOrder{Name: "lamp"})
+	require.NoError(t, err)
+	defer doc.Release()
+	assert.NoError(t, doc.BindTo(&undeclaredField{}), "decoding a field the schema lacks reads it as absent")
+}
+
+func TestCodec_MarshalStruct(t *testing.T) {
+	col := newCodecPool(t)
+	order := sampleCodecOrder()
+	reflected := reflectCodecOrder(order)
+	fast, err := col.MarshalStruct(&order)
+	require.NoError(t, err)
+	slow, err := col.MarshalStruct(&reflected)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(slow), string(fast))
+	assert.Contains(t, string(fast), `"city":"Lyon"`)
+}
@@ -55,7 +55,7 @@ type Document struct {
 	// object (empty for a root document). slotIdx is the schema slot against
 	// which relative keys are resolved (0 for root).
 	prefix  definition.ResolvedPath
-	slotIdx uint8
+	slotIdx uint16
 
 	// record is non-nil for schema-free record views (nested record fields).
 	record map[string]any
@@ -106,7 +106,11 @@ func WithMetadata(m map[string]any) Option {
 
 // populateFromStruct writes the anansi-tagged fields of s into the container
 // (or record view), honoring the reserved "_id_" and "_metadata_" fields.
+// Structs implementing DocumentEncoder write themselves without reflection.
 func (d *Document) populateFromStruct(s any, partial bool) error {
+	if enc, ok := s.(DocumentEncoder); ok && !d.isRecord() {
+		return d.encodeStruct(enc, partial)
+	}
 	fields, err := data.StructFieldValues(s, partial)
 	if err != nil {
 		return err
@@ -301,7 +301,7 @@ func (d *Document) GetDocumentArray(keyOrPath string) ([]data.Documenter, error)
 }
 
 // newNestedView builds a container-backed view sharing the root container.
-func (d *Document) newNestedView(child uint8, rp definition.ResolvedPath) *Document {
+func (d *Document) newNestedView(child uint16, rp definition.ResolvedPath) *Document {
 	return &Document{
 		cs:      d.cs,
 		c:       d.c,
@@ -313,7 +313,7 @@ func (d *Document) newNestedView(child uint8, rp definition.ResolvedPath) *Docum
 
 // newNestedViewForChild builds a container-backed view over an array element's
 // own container.
-func (d *Document) newNestedViewForChild(child *container.DataContainer, childSlot uint8, rp definition.ResolvedPath) *Document {
+func (d *Document) newNestedViewForChild(child *container.DataContainer, childSlot uint16, rp definition.ResolvedPath) *Document {
 	return &Document{
 		cs:      d.cs,
 		c:       child,
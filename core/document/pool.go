@@ -345,6 +345,23 @@ func (c *DocumentPool) MustFromPartialStruct(s any, opts ...Option) *Document {
 	return d
 }
 
+// MarshalStruct serializes a struct through the schema codec: its fields are
+// written into a pooled container, as FromStruct does, and rendered as JSON.
+// No identity, metadata defaults or checksum are added; "_id_" and
+// "_metadata_" appear only when the struct carries them. Structs implementing
+// DocumentEncoder are written without reflection.
+func (c *DocumentPool) MarshalStruct(s any) ([]byte, error) {
+	d, err := c.newDocument()
+	if err != nil {
+		return nil, err
+	}
+	defer d.Release()
+	if err := d.populateFromStruct(s, false); err != nil {
+		return nil, err
+	}
+	return d.MarshalJSON()
+}
+
 // Release returns a document's pooled containers to the DocumentPool's pool.
 // Views and already-released documents are no-ops.
 func (c *DocumentPool) Release(d *Document) {
@@ -88,7 +88,7 @@ func sanitizedContainerClone(d *Document, ds *sanitize.DocumentSanitizer) (*Docu
 // scheme as materializeSlot so each leaf knows its schema field name for
 // policy lookup. inMetadata marks the _metadata_ subtree, whose reserved
 // system fields are always preserved.
-func sanitizeContainerLeaves(cs *definition.CompiledSchema, c *container.DataContainer, slotIdx uint8, path definition.ResolvedPath, ds *sanitize.DocumentSanitizer, inMetadata bool) error {
+func sanitizeContainerLeaves(cs *definition.CompiledSchema, c *container.DataContainer, slotIdx uint16, path definition.ResolvedPath, ds *sanitize.DocumentSanitizer, inMetadata bool) error {
 	if cs == nil || int(slotIdx) >= len(cs.Schemas) {
 		return nil
 	}
@@ -99,7 +99,7 @@ func sanitizeContainerLeaves(cs *definition.CompiledSchema, c *container.DataCon
 		abs := int(slot.FieldStart) + int(j)
 		fd := cs.Descriptors[abs]
 		name := cs.FieldsMeta[abs].Name
-		fp := append(scratch, definition.NewResolvedStep(slotIdx, uint8(j)))
+		fp := append(scratch, definition.NewResolvedStep(slotIdx, uint16(j)))
 
 		childMeta := inMetadata || (len(path) == 0 && name == data.MetadataField)
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/asaidimu/go-anansi/v8/core/data"
 	"github.com/asaidimu/go-anansi/v8/core/data/container"
+	"github.com/asaidimu/go-anansi/v8/core/encoding/binary"
 	cjson "github.com/asaidimu/go-anansi/v8/core/encoding/json"
 	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
 )
@@ -73,7 +74,7 @@ func (c *DocumentPool) PlanRow(columns []string, matchCol string) (*RowScan, err
 		}
 		abs := int(slot.FieldStart) + j
 		fd := c.cs.Descriptors[abs]
-		key, err := rootFieldKey(c.cs, fd, uint8(j))
+		key, err := rootFieldKey(c.cs, fd, uint16(j))
 		if err != nil {
 			return nil, err
 		}
@@ -91,9 +92,10 @@ func (c *DocumentPool) PlanRow(columns []string, matchCol string) (*RowScan, err
 // ScanRow consumes one result row into a fresh pooled, schema-bound document.
 // Values are parallel to the columns the plan was built with. NULL is absence
 // (the slot is left untouched); scalar values land in their typed slot,
-// JSON-fragment columns are decoded leniently at the field's coordinate. No ID,
-// metadata defaults, or checksum are generated — read-back never invents
-// values.
+// JSON-fragment columns are decoded leniently at the field's coordinate, and
+// BLOB columns holding binary field packets are merged via the binary codec.
+// No ID, metadata defaults, or checksum are generated — read-back never
+// invents values.
 func (c *DocumentPool) ScanRow(ctx context.Context, s *RowScan, values []any) (*Document, error) {
 	d, err := c.newDocument()
 	if err != nil {
@@ -115,6 +117,13 @@ func (c *DocumentPool) ScanRow(ctx context.Context, s *RowScan, values []any) (*
 			continue
 		}
 		if f.json {
+			if b, ok := v.([]byte); ok && binary.IsFieldPacket(b) {
+				if err := c.decodeBinaryColumn(d, b); err != nil {
+					c.Release(d)
+					return nil, err
+				}
+				continue
+			}
 			data, ok := fragmentBytes(v)
 			if !ok {
 				continue
@@ -136,20 +145,26 @@ func (c *DocumentPool) ScanRow(ctx context.Context, s *RowScan, values []any) (*
 	return d, nil
 }
 
+// decodeBinaryColumn merges a binary field packet, as written by
+// SerializeFieldBinary, into d.
+func (c *DocumentPool) decodeBinaryColumn(d *Document, data []byte) error {
+	codec, err := c.Codec()
+	if err != nil {
+		return err
+	}
+	return codec.DecodeInto(data, d.c, c.pool)
+}
+
 // rootFieldKey resolves a root-level field's single-step path to its
 // DataContainerKey via the compiled schema's own address space — the schema is
 // the sole source of address truth, mirroring the codec's computeLeafKey.
-func rootFieldKey(cs *definition.CompiledSchema, fd definition.FieldDescriptor, fieldIdx uint8) (container.DataContainerKey, error) {
+func rootFieldKey(cs *definition.CompiledSchema, fd definition.FieldDescriptor, fieldIdx uint16) (container.DataContainerKey, error) {
 	path := definition.ResolvedPath{definition.NewResolvedStep(0, fieldIdx)}
 	addr := cs.Address(path)
 	if addr == 0 {
-		return container.NewDataContainerKey(container.DataPoint(fd.DataPoint()), uint32(fd)), nil
-	}
-	dp, err := container.NewDataPoint(fd.DataType(), int32(addr))
-	if err != nil {
-		return 0, err
+		return fd.InternalKey(), nil
 	}
-	return container.NewDataContainerKey(dp, uint32(fd)), nil
+	return fd.AddressKey(addr)
 }
 
 // fragmentBytes extracts the serialized JSON fragment for a complex column.
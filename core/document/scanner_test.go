@@ -188,7 +188,7 @@ func TestDocument_ScanRowRootFieldKey(t *testing.T) {
 		t.Fatal("field age not found")
 	}
 
-	key, err := rootFieldKey(cs, cs.Descriptors[abs], uint8(abs))
+	key, err := rootFieldKey(cs, cs.Descriptors[abs], uint16(abs))
 	require.NoError(t, err)
 	require.Equal(t, cs.Descriptors[abs].DataType(), key.Type())
 
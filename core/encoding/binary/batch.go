@@ -0,0 +1,269 @@
+package binary
+
+import (
+	stdbinary "encoding/binary"
+	"unsafe"
+
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+)
+
+// EncodeBatch encodes docs as one batch packet. Rows are dense when every
+// document is dense-eligible under the codec's density policy, and laid out
+// per field when Options.Columnar is set; otherwise each row is sparse.
+func (c *Codec) EncodeBatch(docs []*container.DataContainer) ([]byte, error) {
+	b := stdbinary.AppendUvarint(nil, uint64(len(docs)))
+	flagsAt := len(b)
+	b = append(b, 0)
+	b, flags, err := c.appendRows(b, docs)
+	if err != nil {
+		return nil, err
+	}
+	b[flagsAt] = flags
+	return c.seal(Header{Type: PacketBatch, Columnar: flags&batchColumnar != 0}, b)
+}
+
+// DecodeBatch decodes a batch packet, sourcing documents from pool when
+// non-nil. On error, documents already taken from pool are returned to it.
+func (c *Codec) DecodeBatch(data []byte, pool *container.Pool) ([]*container.DataContainer, error) {
+	h, r, err := c.open(data)
+	if err != nil {
+		return nil, err
+	}
+	if h.Type != PacketBatch {
+		return nil, ErrUnknownPacketType.WithMessagef("expected a batch packet, got %s", h.Type)
+	}
+	n, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	flags, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	if h.Columnar != (flags&batchColumnar != 0) {
+		return nil, ErrInvalidPacket.WithMessage("header and batch flags disagree on the layout")
+	}
+	docs, err := c.readRows(r, flags, n, pool)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.finish(); err != nil {
+		release(pool, docs)
+		return nil, err
+	}
+	return docs, nil
+}
+
+// appendRows appends the rows of docs and returns the batch flags describing
+// their layout.
+func (c *Codec) appendRows(b []byte, docs []*container.DataContainer) ([]byte, byte, error) {
+	dense, err := c.batchDense(docs)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch {
+	case dense && c.opts.Columnar:
+		b, err = c.appendColumns(b, docs)
+		return b, batchColumnar, err
+	case dense:
+		for _, doc := range docs {
+			if b, err = c.appendDense(b, c.root, doc, 0); err != nil {
+				return nil, 0, err
+			}
+		}
+		return b, 0, nil
+	default:
+		for _, doc := range docs {
+			if b, _, err = c.appendSparse(b, c.root, doc, nil, 0); err != nil {
+				return nil, 0, err
+			}
+		}
+		return b, batchSparse, nil
+	}
+}
+
+// batchDense applies the density policy to a set of documents.
+func (c *Codec) batchDense(docs []*container.DataContainer) (bool, error) {
+	if c.opts.Density == DensitySparse || len(docs) == 0 {
+		return false, nil
+	}
+	set := 0
+	for _, doc := range docs {
+		if !eligibleDense(c.root, doc) {
+			if c.opts.Density == DensityDense {
+				return false, ErrDenseIneligible
+			}
+			return false, nil
+		}
+		set += doc.Length()
+	}
+	if c.opts.Density == DensityDense {
+		return true, nil
+	}
+	n := len(c.root.fields)
+	return n <= denseSmallSchema || set*4 > n*len(docs), nil
+}
+
+// readRows reads n rows laid out as flags describe.
+func (c *Codec) readRows(r *reader, flags byte, n uint64, pool *container.Pool) ([]*container.DataContainer, error) {
+	if flags&^(batchColumnar|batchSparse) != 0 || flags == batchColumnar|batchSparse {
+		return nil, ErrInvalidPacket.WithMessagef("invalid batch flags 0x%02x", flags)
+	}
+	sparse := flags&batchSparse != 0
+	if !sparse && len(c.root.fields) == 0 {
+		return nil, ErrInvalidPacket.WithMessage("dense rows for a schema without fields")
+	}
+	// Bound the row count by the smallest encoding of a row: one count byte
+	// for sparse rows, the state map for dense ones.
+	minBits := 8
+	switch {
+	case flags&batchColumnar != 0:
+		minBits = 2 * len(c.root.fields)
+	case !sparse:
+		minBits = 8 * c.root.stateBytes
+	}
+	if err := r.checkCount(n, minBits); err != nil {
+		return nil, err
+	}
+
+	docs := make([]*container.DataContainer, 0, n)
+	if flags&batchColumnar != 0 {
+		for range n {
+			docs = append(docs, acquire(pool))
+		}
+		if err := c.readColumns(r, docs, pool); err != nil {
+			release(pool, docs)
+			return nil, err
+		}
+		return docs, nil
+	}
+	for range n {
+		doc := acquire(pool)
+		docs = append(docs, doc)
+		var err error
+		if sparse {
+			err = c.readSparse(r, c.root, doc, pool)
+		} else {
+			err = c.readDense(r, c.root, doc, pool)
+		}
+		if err != nil {
+			release(pool, docs)
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// --- Columnar ---
+
+// appendColumns lays docs out per DataType: a state column covering every
+// field of the type across all records (field-major), then each field's
+// values for the records where it has one. Bool columns are packed.
+func (c *Codec) appendColumns(b []byte, docs []*container.DataContainer) ([]byte, error) {
+	p := c.root
+	var err error
+	for t := container.TypeUnknown; t <= container.TypeArrayGeometry; t++ {
+		fields := p.fields[p.typeStart(t):p.typeEnd[t]]
+		if len(fields) == 0 {
+			continue
+		}
+		start := len(b)
+		b = append(b, make([]byte, (2*len(fields)*len(docs)+7)/8)...)
+		for fi, f := range fields {
+			for ri, doc := range docs {
+				i := fi*len(docs) + ri
+				switch {
+				case doc.IsNull(f.key):
+					b[start+i/4] |= stateNull << (2 * (i % 4))
+				case doc.HasValue(f.key):
+					b[start+i/4] |= stateValue << (2 * (i % 4))
+				}
+			}
+		}
+		for _, f := range fields {
+			if t == container.TypeBool {
+				var bools []bool
+				for _, doc := range docs {
+					if v, ok, _ := doc.GetBool(f.key); ok && doc.HasValue(f.key) {
+						bools = append(bools, v)
+					}
+				}
+				b = appendPackedBools(b, bools)
+				continue
+			}
+			for _, doc := range docs {
+				if !doc.HasValue(f.key) {
+					continue
+				}
+				if b, err = c.appendDocValue(b, doc, f); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return b, nil
+}
+
+// appendDocValue appends the value of one planned field of doc.
+func (c *Codec) appendDocValue(b []byte, doc *container.DataContainer, f planField) ([]byte, error) {
+	_, err := doc.Walk(func(positions map[int64]int32, slot func(container.DataType, ...int) unsafe.Pointer) (any, error) {
+		var err error
+		b, err = c.appendValue(b, f.key, positions[int64(f.key)], slot, f.child, 0)
+		return nil, err
+	})
+	return b, err
+}
+
+// readColumns reads a columnar layout into docs.
+func (c *Codec) readColumns(r *reader, docs []*container.DataContainer, pool *container.Pool) error {
+	p := c.root
+	for t := container.TypeUnknown; t <= container.TypeArrayGeometry; t++ {
+		fields := p.fields[p.typeStart(t):p.typeEnd[t]]
+		if len(fields) == 0 {
+			continue
+		}
+		cells := len(fields) * len(docs)
+		states, err := r.next((2*cells + 7) / 8)
+		if err != nil {
+			return err
+		}
+		for i := range cells {
+			if stateAt(states, i) == stateReserved {
+				return ErrInvalidPacket.WithMessagef("reserved state in the %d column", t)
+			}
+		}
+		if pad := 2 * cells % 8; pad != 0 && states[len(states)-1]>>pad != 0 {
+			return ErrInvalidPacket.WithMessage("state column padding is not zero")
+		}
+
+		for fi, f := range fields {
+			var rows []int
+			for ri, doc := range docs {
+				switch stateAt(states, fi*len(docs)+ri) {
+				case stateNull:
+					doc.SetNull(f.key)
+				case stateValue:
+					rows = append(rows, ri)
+				}
+			}
+			if t == container.TypeBool {
+				values, err := readPackedBools(r, len(rows))
+				if err != nil {
+					return err
+				}
+				for i, ri := range rows {
+					if err := setValue(docs[ri].SetBool(f.key, values[i])); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			for _, ri := range rows {
+				if err := c.readValue(r, f.key, f.child, docs[ri], pool); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,422 @@
+// Package binary implements the Anansi binary wire format for container-backed
+// documents (see todo/anansi_encoding.md).
+//
+// A Codec is bound to one compiled schema and one schema version. It encodes a
+// DataContainer as a dense packet (a 2-bit state per schema field followed by
+// per-type value blocks) or a sparse packet (only the set fields, each
+// prefixed with its key), picks between them by field density, and encodes
+// sets of documents as batch packets (row or columnar) or as a stream of
+// chunks. Decoding validates every length and count against the bytes left,
+// so malformed input is rejected with an error rather than a panic or an
+// oversized allocation.
+//
+//	codec, err := binary.NewCodec(cs, binary.Options{Version: 3})
+//	packet, err := codec.Encode(doc)
+//	decoded, err := codec.Decode(packet)
+//
+// The format follows the specification with these adjustments to the
+// container model:
+//
+//   - Keys are full 64-bit DataContainerKeys rather than 32-bit DataPoints:
+//     the descriptor half distinguishes fields whose DataPoints coincide. A
+//     key's null bit is bit 0 of the DataPoint half, as specified, and
+//     canonical order is by DataType, then key, so value blocks group by type.
+//   - Varints are LEB128 and take up to 10 bytes for 64-bit values.
+//   - Geometry values are [ring_count]{[coordinate_count][float64...]}, since
+//     a container ring is a flat coordinate list.
+//   - Records are map[string]any in the container model and encode as
+//     [count]{[key][tagged value]} with keys sorted, not as nested packets.
+//   - Compression is DEFLATE (compress/flate) and the payload is prefixed with
+//     its uncompressed size. Encryption uses any cipher.AEAD, with the header
+//     as additional data. Integrity hashes (BLAKE3) are not supported: the
+//     flag is never set and packets carrying it are rejected.
+package binary
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/cipher"
+	"crypto/rand"
+	stdbinary "encoding/binary"
+	"hash/fnv"
+	"io"
+	"sync"
+	"unsafe"
+
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// DefaultMaxMessageSize bounds the size of a packet, and of its payload once
+// decompressed or decrypted, when Options.MaxMessageSize is not set.
+const DefaultMaxMessageSize = 64 << 20
+
+// Density selects between dense and sparse packets.
+type Density uint8
+
+const (
+	// DensityAuto encodes densely when every field of the document belongs
+	// to the schema and the schema is small or at least a quarter of its
+	// fields are set; otherwise sparsely.
+	DensityAuto Density = iota
+	// DensityDense always encodes densely; documents holding keys outside
+	// the schema fail with ErrDenseIneligible.
+	DensityDense
+	// DensitySparse always encodes sparsely.
+	DensitySparse
+)
+
+// denseSmallSchema is the field count up to which DensityAuto always
+// prefers dense packets: their state map is at most 16 bytes.
+const denseSmallSchema = 64
+
+// Options configures a Codec.
+type Options struct {
+	// Version is the schema version written to, and required of, packets.
+	// It must not exceed MaxVersion.
+	Version uint16
+	// Density selects dense or sparse encoding. Nested packets (array of
+	// object elements) always use DensityAuto.
+	Density Density
+	// Columnar lays dense batches out per field rather than per record.
+	Columnar bool
+	// Compress DEFLATE-compresses packet payloads.
+	Compress bool
+	// Cipher, when set, encrypts packet payloads and is required to decode
+	// them; unencrypted packets are then rejected.
+	Cipher cipher.AEAD
+	// ZeroCopy lets decoded strings and byte slices alias the input buffer.
+	// The caller must then keep the buffer unchanged for as long as the
+	// decoded documents are in use. Payloads the codec decompresses or
+	// decrypts are always aliased: the codec owns those buffers.
+	ZeroCopy bool
+	// MaxMessageSize bounds packet and payload sizes. Defaults to
+	// DefaultMaxMessageSize.
+	MaxMessageSize int
+}
+
+// Codec encodes and decodes documents of one compiled schema. It is safe for
+// concurrent use.
+type Codec struct {
+	cs   *definition.CompiledSchema
+	opts Options
+	root *plan
+}
+
+// NewCodec returns a codec for documents of cs.
+func NewCodec(cs *definition.CompiledSchema, opts Options) (*Codec, error) {
+	if cs == nil || len(cs.Schemas) == 0 {
+		return nil, ErrInvalidOptions.WithMessage("a compiled schema is required")
+	}
+	if opts.Version > MaxVersion {
+		return nil, ErrInvalidOptions.WithMessagef("version %d exceeds %d", opts.Version, MaxVersion)
+	}
+	if opts.Density > DensitySparse {
+		return nil, ErrInvalidOptions.WithMessagef("unknown density %d", opts.Density)
+	}
+	if opts.MaxMessageSize <= 0 {
+		opts.MaxMessageSize = DefaultMaxMessageSize
+	}
+	root, err := buildPlan(cs, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{cs: cs, opts: opts, root: root}, nil
+}
+
+// Version returns the schema version the codec reads and writes.
+func (c *Codec) Version() uint16 {
+	return c.opts.Version
+}
+
+// CompiledSchema returns the schema the codec is bound to.
+func (c *Codec) CompiledSchema() *definition.CompiledSchema {
+	return c.cs
+}
+
+// Encode encodes doc as a dense or sparse packet.
+func (c *Codec) Encode(doc *container.DataContainer) ([]byte, error) {
+	dense, err := c.chooseDense(c.root, c.opts.Density, doc)
+	if err != nil {
+		return nil, err
+	}
+	h := Header{Type: PacketSparse}
+	var body []byte
+	if dense {
+		h.Type = PacketDense
+		body, err = c.appendDense(nil, c.root, doc, 0)
+	} else {
+		body, _, err = c.appendSparse(nil, c.root, doc, nil, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.seal(h, body)
+}
+
+// EncodeField encodes the keys of doc that belong to the root-level field
+// name as a sparse packet. It reports false when none of them is set.
+// Decoding the packet with DecodeInto restores just that field.
+func (c *Codec) EncodeField(doc *container.DataContainer, name string) ([]byte, bool, error) {
+	root, ok := rootFieldIndex(c.cs, name)
+	if !ok {
+		return nil, false, ErrFieldNotFound.WithPath(name)
+	}
+	body, n, err := c.appendSparse(nil, c.root, doc, func(key container.DataContainerKey) bool {
+		f, ok := c.root.field(key)
+		return ok && f.root == root
+	}, 0)
+	if err != nil || n == 0 {
+		return nil, false, err
+	}
+	packet, err := c.seal(Header{Type: PacketSparse}, body)
+	return packet, err == nil, err
+}
+
+// Decode decodes a dense or sparse packet into a new container.
+func (c *Codec) Decode(data []byte) (*container.DataContainer, error) {
+	doc := container.NewDataContainer()
+	if err := c.DecodeInto(data, doc, nil); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// DecodeInto decodes a dense or sparse packet into doc, sourcing the elements
+// of arrays of objects from pool when non-nil. Keys the packet sets are
+// overwritten; other keys of doc are left untouched.
+func (c *Codec) DecodeInto(data []byte, doc *container.DataContainer, pool *container.Pool) error {
+	h, r, err := c.open(data)
+	if err != nil {
+		return err
+	}
+	switch h.Type {
+	case PacketDense:
+		err = c.readDense(r, c.root, doc, pool)
+	case PacketSparse:
+		err = c.readSparse(r, c.root, doc, pool)
+	default:
+		return ErrUnknownPacketType.WithMessagef("expected a dense or sparse packet, got %s", h.Type)
+	}
+	if err != nil {
+		return err
+	}
+	return r.finish()
+}
+
+// chooseDense applies the density policy to doc against p.
+func (c *Codec) chooseDense(p *plan, density Density, doc *container.DataContainer) (bool, error) {
+	if density == DensitySparse {
+		return false, nil
+	}
+	eligible := eligibleDense(p, doc)
+	if density == DensityDense {
+		if !eligible {
+			return false, ErrDenseIneligible
+		}
+		return true, nil
+	}
+	return eligible && (len(p.fields) <= denseSmallSchema || doc.Length()*4 > len(p.fields)), nil
+}
+
+// eligibleDense reports whether every key of doc has a slot in p's state map.
+func eligibleDense(p *plan, doc *container.DataContainer) bool {
+	if p == nil || len(p.fields) == 0 {
+		return false
+	}
+	if doc.Length() > len(p.fields) {
+		return false
+	}
+	ok, _ := doc.Walk(func(positions map[int64]int32, _ func(container.DataType, ...int) unsafe.Pointer) (any, error) {
+		for k := range positions {
+			if _, planned := p.index[k]; !planned {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	return ok.(bool)
+}
+
+// --- Framing ---
+
+var flateWriters = sync.Pool{
+	New: func() any {
+		w, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return w
+	},
+}
+
+// seal frames body behind h, compressing and encrypting it as configured.
+func (c *Codec) seal(h Header, body []byte) ([]byte, error) {
+	h.Version = c.opts.Version
+	if c.opts.Compress {
+		h.Compressed = true
+		body = compress(body)
+	}
+	if c.opts.Cipher != nil {
+		h.Encrypted = true
+	}
+	header := appendHeader(make([]byte, 0, headerSize), h)
+	if c.opts.Cipher == nil {
+		if len(body) > c.opts.MaxMessageSize {
+			return nil, ErrLimitExceeded.WithMessagef("packet of %d bytes exceeds %d", len(body), c.opts.MaxMessageSize)
+		}
+		return append(header, body...), nil
+	}
+	nonceSize := c.opts.Cipher.NonceSize()
+	out := make([]byte, headerSize+nonceSize, headerSize+nonceSize+len(body)+c.opts.Cipher.Overhead())
+	copy(out, header)
+	nonce := out[headerSize:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, ErrInvalidOptions.WithMessage("failed to generate a nonce").WithCause(err)
+	}
+	return c.opts.Cipher.Seal(out, nonce, body, header), nil
+}
+
+func compress(body []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(stdbinary.AppendUvarint(nil, uint64(len(body))))
+	w := flateWriters.Get().(*flate.Writer)
+	w.Reset(&buf)
+	_, _ = w.Write(body)
+	_ = w.Close()
+	flateWriters.Put(w)
+	return buf.Bytes()
+}
+
+// open checks data's header against the codec and returns a reader over its
+// plaintext payload.
+func (c *Codec) open(data []byte) (Header, *reader, error) {
+	if len(data) > c.opts.MaxMessageSize {
+		return Header{}, nil, ErrLimitExceeded.WithMessagef("packet of %d bytes exceeds %d", len(data), c.opts.MaxMessageSize)
+	}
+	h, err := ReadHeader(data)
+	if err != nil {
+		return Header{}, nil, err
+	}
+	if err := c.checkHeader(h); err != nil {
+		return Header{}, nil, err
+	}
+	payload, owned, err := c.unwrap(h, data[:headerSize], data[headerSize:])
+	if err != nil {
+		return Header{}, nil, err
+	}
+	return h, &reader{buf: payload, alias: c.opts.ZeroCopy || owned}, nil
+}
+
+func (c *Codec) checkHeader(h Header) error {
+	if h.Version != c.opts.Version {
+		return ErrSchemaVersion.WithMessagef("packet has schema version %d, codec expects %d", h.Version, c.opts.Version)
+	}
+	if h.Hashed {
+		return ErrUnsupportedFeature.WithMessage("integrity hashes are not supported")
+	}
+	return nil
+}
+
+// unwrap decrypts and decompresses a payload as its header says. owned
+// reports whether the result is a buffer the codec allocated.
+func (c *Codec) unwrap(h Header, header, payload []byte) ([]byte, bool, error) {
+	owned := false
+	if h.Encrypted {
+		if c.opts.Cipher == nil {
+			return nil, false, ErrDecryptFailed.WithMessage("packet is encrypted but the codec has no cipher")
+		}
+		nonceSize := c.opts.Cipher.NonceSize()
+		if len(payload) < nonceSize {
+			return nil, false, ErrDecryptFailed.WithMessage("packet is shorter than its nonce")
+		}
+		plain, err := c.opts.Cipher.Open(nil, payload[:nonceSize], payload[nonceSize:], header)
+		if err != nil {
+			return nil, false, ErrDecryptFailed.WithCause(err)
+		}
+		payload, owned = plain, true
+	} else if c.opts.Cipher != nil {
+		return nil, false, ErrDecryptFailed.WithMessage("packet is not encrypted")
+	}
+	if h.Compressed {
+		plain, err := c.decompress(payload)
+		if err != nil {
+			return nil, false, err
+		}
+		payload, owned = plain, true
+	}
+	return payload, owned, nil
+}
+
+func (c *Codec) decompress(payload []byte) ([]byte, error) {
+	size, n := stdbinary.Uvarint(payload)
+	if n <= 0 {
+		return nil, ErrDecompressFailed.WithMessage("missing uncompressed size")
+	}
+	if size > uint64(c.opts.MaxMessageSize) {
+		return nil, ErrLimitExceeded.WithMessagef("uncompressed payload of %d bytes exceeds %d", size, c.opts.MaxMessageSize)
+	}
+	fr := flate.NewReader(bytes.NewReader(payload[n:]))
+	defer fr.Close()
+	out := make([]byte, size)
+	if _, err := io.ReadFull(fr, out); err != nil {
+		return nil, ErrDecompressFailed.WithCause(err)
+	}
+	var extra [1]byte
+	if m, err := fr.Read(extra[:]); m != 0 || err != io.EOF {
+		return nil, ErrDecompressFailed.WithMessage("payload is longer than its declared size")
+	}
+	return out, nil
+}
+
+// finish rejects trailing bytes after a fully decoded payload.
+func (r *reader) finish() error {
+	if !r.done() {
+		return ErrInvalidPacket.WithMessagef("%d trailing bytes", r.remaining())
+	}
+	return nil
+}
+
+// --- Schema layout ---
+
+// rootFieldIndex resolves a root-level field name to its index in the root
+// schema slot.
+func rootFieldIndex(cs *definition.CompiledSchema, name string) (int, bool) {
+	slot := cs.Schemas[0]
+	for j := uint16(0); j < slot.FieldCount; j++ {
+		if cs.FieldsMeta[slot.FieldStart+j].Name == name {
+			return int(j), true
+		}
+	}
+	return 0, false
+}
+
+// LayoutVersion derives a schema version from the layout of cs: its field
+// descriptors, IDs, names, slots and address offsets. Packets written with it
+// can only be read back by a codec for an identically laid out schema, which
+// guards stored packets against schema changes that would move their keys.
+// Distinct layouts share a version with probability 1/1024.
+func LayoutVersion(cs *definition.CompiledSchema) uint16 {
+	h := fnv.New32a()
+	var buf [4]byte
+	put := func(v uint32) {
+		stdbinary.LittleEndian.PutUint32(buf[:], v)
+		_, _ = h.Write(buf[:])
+	}
+	for i, fd := range cs.Descriptors {
+		put(uint32(fd))
+		if fd.Extended() {
+			put(uint32(fd >> 32))
+		}
+		if i < len(cs.FieldsMeta) {
+			_, _ = io.WriteString(h, cs.FieldsMeta[i].ID)
+			_, _ = io.WriteString(h, cs.FieldsMeta[i].Name)
+		}
+		if i < len(cs.LocalOffsets) {
+			put(cs.LocalOffsets[i])
+		}
+	}
+	for _, s := range cs.Schemas {
+		put(uint32(s.FieldStart)<<16 | uint32(s.FieldCount))
+		put(s.Footprint)
+	}
+	sum := h.Sum32()
+	return uint16((sum ^ sum>>10 ^ sum>>20 ^ sum>>30) & MaxVersion)
+}
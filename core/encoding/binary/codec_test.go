@@ -0,0 +1,631 @@
+package binary
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+	cjson "github.com/asaidimu/go-anansi/v8/core/encoding/json"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const demoSchemaJSON = `{
+  "version": "1.0.0",
+  "name": "demo",
+  "fields": {
+    "id":       { "name": "id",       "type": "string" },
+    "active":   { "name": "active",   "type": "boolean" },
+    "age":      { "name": "age",      "type": "integer" },
+    "score":    { "name": "score",    "type": "number" },
+    "tags":     { "name": "tags",     "type": "array",  "schema": { "type": "string" } },
+    "address":  { "name": "address",  "type": "object", "schema": { "id": "addr" } },
+    "profile":  { "name": "profile",  "type": "record", "schema": { "id": "addr" } },
+    "items":    { "name": "items",    "type": "array",  "schema": { "id": "addr" } },
+    "location": { "name": "location", "type": "composite", "schema": [ { "id": "coord" }, { "id": "zone" } ] },
+    "meta":     { "name": "meta",     "type": "unknown" }
+  },
+  "schemas": {
+    "addr": { "name": "addr", "fields": {
+      "street": { "name": "street", "type": "string" },
+      "zip":    { "name": "zip",    "type": "integer" }
+    } },
+    "coord": { "name": "coord", "fields": {
+      "lat": { "name": "lat", "type": "number" },
+      "lng": { "name": "lng", "type": "number" }
+    } },
+    "zone": { "name": "zone", "fields": {
+      "label": { "name": "label", "type": "string" }
+    } }
+  }
+}`
+
+const demoDocumentJSON = `{
+  "id": "user-1",
+  "active": true,
+  "age": 31,
+  "score": 9.5,
+  "tags": ["go", "schema"],
+  "address": { "street": "1 Main St", "zip": 10001 },
+  "location": { "lat": 40.7128, "lng": -74.006, "label": "downtown" },
+  "profile": { "street": "2 Side Rd", "zip": 20002 },
+  "items": [
+    { "street": "3 Leaf Ct", "zip": 30003 },
+    { "street": "4 Oak Ave", "zip": 40004 }
+  ],
+  "meta": { "source": "demo", "rank": 2, "weights": [0.5, 1.5], "nested": { "ok": true, "none": null } }
+}`
+
+// allTypesSchemaJSON plans one field of each of the 16 DataTypes.
+const allTypesSchemaJSON = `{
+  "version": "1.0.0",
+  "name": "all_types",
+  "fields": {
+    "u":   { "name": "u",   "type": "unknown" },
+    "i":   { "name": "i",   "type": "integer" },
+    "f":   { "name": "f",   "type": "number" },
+    "s":   { "name": "s",   "type": "string" },
+    "b":   { "name": "b",   "type": "boolean" },
+    "by":  { "name": "by",  "type": "bytes" },
+    "g":   { "name": "g",   "type": "geometry" },
+    "r":   { "name": "r",   "type": "record" },
+    "au":  { "name": "au",  "type": "array" },
+    "ai":  { "name": "ai",  "type": "array", "schema": { "type": "integer" } },
+    "af":  { "name": "af",  "type": "array", "schema": { "type": "number" } },
+    "as":  { "name": "as",  "type": "array", "schema": { "type": "string" } },
+    "ab":  { "name": "ab",  "type": "array", "schema": { "type": "boolean" } },
+    "aby": { "name": "aby", "type": "array", "schema": { "type": "bytes" } },
+    "ao":  { "name": "ao",  "type": "array", "schema": { "id": "item" } },
+    "ag":  { "name": "ag",  "type": "array", "schema": { "type": "geometry" } }
+  },
+  "schemas": {
+    "item": { "name": "item", "fields": {
+      "label": { "name": "label", "type": "string" },
+      "on":    { "name": "on",    "type": "boolean" }
+    } }
+  }
+}`
+
+func compileSchema(t testing.TB, data string) *definition.CompiledSchema {
+	t.Helper()
+	s, err := definition.FromJSON([]byte(data))
+	require.NoError(t, err)
+	rs, err := definition.Compile(s)
+	require.NoError(t, err)
+	cs, err := definition.Link(rs)
+	require.NoError(t, err)
+	return cs
+}
+
+func newCodec(t testing.TB, cs *definition.CompiledSchema, opts Options) *Codec {
+	t.Helper()
+	c, err := NewCodec(cs, opts)
+	require.NoError(t, err)
+	return c
+}
+
+func demoDocument(t testing.TB, cs *definition.CompiledSchema) *container.DataContainer {
+	t.Helper()
+	doc, err := cjson.DecodeJSON(cs, []byte(demoDocumentJSON))
+	require.NoError(t, err)
+	return doc
+}
+
+// allTypesDocument sets every planned field of c to a non-trivial value.
+func allTypesDocument(t testing.TB, c *Codec) *container.DataContainer {
+	t.Helper()
+	doc := container.NewDataContainer()
+	for _, f := range c.root.fields {
+		var err error
+		switch f.key.Type() {
+		case container.TypeUnknown:
+			err = doc.SetUnknown(f.key, map[string]any{"k": []any{int64(-7), 1.25, "x", nil, []byte{1}}})
+		case container.TypeInt:
+			err = doc.SetInt(f.key, -1<<40)
+		case container.TypeFloat:
+			err = doc.SetFloat(f.key, -3.5)
+		case container.TypeString:
+			err = doc.SetString(f.key, "héllo")
+		case container.TypeBool:
+			err = doc.SetBool(f.key, true)
+		case container.TypeBytes:
+			err = doc.SetBytes(f.key, []byte{0, 1, 2, 255})
+		case container.TypeGeometry:
+			err = doc.SetGeometry(f.key, [][]float64{{1, 2, 3, 4}, {5, 6}})
+		case container.TypeRecord:
+			err = doc.SetRecord(f.key, map[string]any{"a": int64(1), "b": []int64{2, 3}})
+		case container.TypeArrayUnknown:
+			err = doc.SetArrayUnknown(f.key, []any{"a", int64(1), false, map[string]any{"z": 1.5}})
+		case container.TypeArrayInt:
+			err = doc.SetArrayInt(f.key, []int64{0, -1, 1 << 62})
+		case container.TypeArrayFloat:
+			err = doc.SetArrayFloat(f.key, []float64{0.5, -2})
+		case container.TypeArrayString:
+			err = doc.SetArrayString(f.key, []string{"", "b"})
+		case container.TypeArrayBool:
+			err = doc.SetArrayBool(f.key, []bool{true, false, true, true, false, false, false, false, true})
+		case container.TypeArrayBytes:
+			err = doc.SetArrayBytes(f.key, [][]byte{{9}, {}})
+		case container.TypeArrayGeometry:
+			err = doc.SetArrayGeometry(f.key, [][][]float64{{{1, 1}}, {{2, 2}, {3}}})
+		case container.TypeArrayObject:
+			var elements []*container.DataContainer
+			for i, label := range []string{"first", "second"} {
+				elem := container.NewDataContainer()
+				for _, cf := range f.child.fields {
+					switch cf.key.Type() {
+					case container.TypeString:
+						require.NoError(t, elem.SetString(cf.key, label))
+					case container.TypeBool:
+						if i == 0 {
+							require.NoError(t, elem.SetBool(cf.key, true))
+						} else {
+							elem.SetNull(cf.key)
+						}
+					}
+				}
+				elements = append(elements, elem)
+			}
+			err = doc.SetArrayObject(f.key, elements)
+		}
+		require.NoError(t, err)
+	}
+	return doc
+}
+
+func requireSameDocument(t *testing.T, cs *definition.CompiledSchema, want, got *container.DataContainer) {
+	t.Helper()
+	wantDump, err := cjson.Dump(cs, want)
+	require.NoError(t, err)
+	gotDump, err := cjson.Dump(cs, got)
+	require.NoError(t, err)
+	assert.Equal(t, wantDump, gotDump)
+}
+
+func TestCodec_RoundTripAllTypes(t *testing.T) {
+	cs := compileSchema(t, allTypesSchemaJSON)
+	for _, density := range []Density{DensityAuto, DensityDense, DensitySparse} {
+		c := newCodec(t, cs, Options{Version: 7, Density: density})
+		doc := allTypesDocument(t, c)
+
+		packet, err := c.Encode(doc)
+		require.NoError(t, err)
+		h, err := ReadHeader(packet)
+		require.NoError(t, err)
+		if density == DensitySparse {
+			assert.Equal(t, PacketSparse, h.Type)
+		} else {
+			assert.Equal(t, PacketDense, h.Type)
+		}
+		assert.Equal(t, uint16(7), h.Version)
+
+		got, err := c.Decode(packet)
+		require.NoError(t, err)
+		requireSameDocument(t, cs, doc, got)
+
+		again, err := c.Encode(got)
+		require.NoError(t, err)
+		assert.Equal(t, packet, again, "encoding is deterministic")
+	}
+}
+
+func TestCodec_RoundTripDemoDocument(t *testing.T) {
+	cs := compileSchema(t, demoSchemaJSON)
+	doc := demoDocument(t, cs)
+	for _, density := range []Density{DensityDense, DensitySparse} {
+		c := newCodec(t, cs, Options{Density: density})
+		packet, err := c.Encode(doc)
+		require.NoError(t, err)
+
+		pool := container.NewPool()
+		got := pool.Get()
+		require.NoError(t, c.DecodeInto(packet, got, pool))
+		requireSameDocument(t, cs, doc, got)
+
+		want, err := cjson.SerializeJSON(cs, doc)
+		require.NoError(t, err)
+		gotJSON, err := cjson.SerializeJSON(cs, got)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(want), string(gotJSON))
+		pool.Put(got)
+	}
+}
+
+func TestCodec_FieldStates(t *testing.T) {
+	cs := compileSchema(t, demoSchemaJSON)
+	key := func(path string) container.DataContainerKey {
+		rp, err := cs.ResolvePath(path)
+		require.NoError(t, err)
+		last := rp[len(rp)-1]
+		fd := cs.Descriptors[int(cs.Schemas[last.SchemaIdx()].FieldStart)+int(last.FieldIdx())]
+		k, err := leafKey(cs, fd, rp)
+		require.NoError(t, err)
+		return k
+	}
+	age, zip := key("age"), key("address.zip")
+
+	for _, density := range []Density{DensityDense, DensitySparse} {
+		c := newCodec(t, cs, Options{Density: density})
+		doc := container.NewDataContainer()
+		require.NoError(t, doc.SetInt(age, 40))
+		require.NoError(t, doc.SetInt(zip, 12345))
+
+		decode := func() *container.DataContainer {
+			packet, err := c.Encode(doc)
+			require.NoError(t, err)
+			got, err := c.Decode(packet)
+			require.NoError(t, err)
+			return got
+		}
+
+		got := decode()
+		v, ok, err := got.GetInt(age)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, int64(40), v)
+
+		doc.SetNull(age)
+		got = decode()
+		assert.True(t, got.IsNull(age))
+		assert.True(t, got.HasValue(zip))
+
+		doc.Unset(age)
+		got = decode()
+		assert.False(t, got.IsSet(age))
+		assert.Equal(t, 1, got.Length())
+	}
+}
+
+func TestCodec_DecodeIntoMerges(t *testing.T) {
+	cs := compileSchema(t, demoSchemaJSON)
+	c := newCodec(t, cs, Options{})
+	id, _, ok := rootKey(cs, "id")
+	require.True(t, ok)
+	score, _, ok := rootKey(cs, "score")
+	require.True(t, ok)
+
+	src := container.NewDataContainer()
+	require.NoError(t, src.SetString(id, "new"))
+	packet, err := c.Encode(src)
+	require.NoError(t, err)
+
+	dst := container.NewDataContainer()
+	require.NoError(t, dst.SetString(id, "old"))
+	require.NoError(t, dst.SetFloat(score, 1.5))
+	require.NoError(t, c.DecodeInto(packet, dst, nil))
+
+	v, _, _ := dst.GetString(id)
+	assert.Equal(t, "new", v)
+	f, ok, _ := dst.GetFloat(score)
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, f)
+}
+
+// rootKey returns the planned key of a root-level leaf field.
+func rootKey(cs *definition.CompiledSchema, name string) (container.DataContainerKey, definition.FieldDescriptor, bool) {
+	j, ok := rootFieldIndex(cs, name)
+	if !ok {
+		return 0, 0, false
+	}
+	fd := cs.Descriptors[int(cs.Schemas[0].FieldStart)+j]
+	key, err := leafKey(cs, fd, definition.ResolvedPath{definition.NewResolvedStep(0, uint16(j))})
+	return key, fd, err == nil
+}
+
+func TestCodec_UnplannedKeysFallBackToSparse(t *testing.T) {
+	cs := compileSchema(t, demoSchemaJSON)
+	doc := demoDocument(t, cs)
+	dp, err := container.NewDataPoint(container.TypeString, 99999)
+	require.NoError(t, err)
+	foreign := container.NewDataContainerKey(dp, 0xFFFF)
+	require.NoError(t, doc.SetString(foreign, "extra"))
+
+	packet, err := newCodec(t, cs, Options{}).Encode(doc)
+	require.NoError(t, err)
+	assert.Equal(t, byte(PacketSparse), packet[0]&flagTypeMask)
+	got, err := newCodec(t, cs, Options{}).Decode(packet)
+	require.NoError(t, err)
+	v, _, _ := got.GetString(foreign)
+	assert.Equal(t, "extra", v)
+
+	_, err = newCodec(t, cs, Options{Density: DensityDense}).Encode(doc)
+	errcode.Require(t, err, "ERR_BINARY_DENSE_INELIGIBLE")
+}
+
+func TestCodec_ZeroCopy(t *testing.T) {
+	cs := compileSchema(t, demoSchemaJSON)
+	id, _, _ := rootKey(cs, "id")
+	doc := container.NewDataContainer()
+	require.NoError(t, doc.SetString(id, "abc"))
+
+	for _, zeroCopy := range []bool{false, true} {
+		c := newCodec(t, cs, Options{ZeroCopy: zeroCopy})
+		packet, err := c.Encode(doc)
+		require.NoError(t, err)
+		got, err := c.Decode(packet)
+		require.NoError(t, err)
+
+		i := bytes.Index(packet, []byte("abc"))
+		require.GreaterOrEqual(t, i, 0)
+		packet[i] = 'x'
+		v, _, _ := got.GetString(id)
+		if zeroCopy {
+			assert.Equal(t, "xbc", v, "string aliases the packet")
+		} else {
+			assert.Equal(t, "abc", v, "string is copied")
+		}
+	}
+}
+
+func TestCodec_EncodeField(t *testing.T) {
+	cs := compileSchema(t, demoSchemaJSON)
+	c := newCodec(t, cs, Options{})
+	doc := demoDocument(t, cs)
+
+	for _, name := range []string{"address", "items", "profile", "location", "meta"} {
+		packet, ok, err := c.EncodeField(doc, name)
+		require.NoError(t, err)
+		require.True(t, ok, name)
+		got := container.NewDataContainer()
+		require.NoError(t, c.DecodeInto(packet, got, nil))
+
+		full, err := cjson.Dump(cs, doc)
+		require.NoError(t, err)
+		part, err := cjson.Dump(cs, got)
+		require.NoError(t, err)
+		require.NotEmpty(t, part)
+		for k, v := range part {
+			assert.Equal(t, full[k], v, "%s: %s", name, k)
+		}
+	}
+
+	_, ok, err := c.EncodeField(container.NewDataContainer(), "address")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, _, err = c.EncodeField(doc, "missing")
+	errcode.Require(t, err, "ERR_BINARY_FIELD_NOT_FOUND")
+}
+
+func TestCodec_Batch(t *testing.T) {
+	cs := compileSchema(t, allTypesSchemaJSON)
+	for _, tc := range []struct {
+		name  string
+		opts  Options
+		flags byte
+	}{
+		{"row dense", Options{}, 0},
+		{"row sparse", Options{Density: DensitySparse}, batchSparse},
+		{"columnar", Options{Columnar: true}, batchColumnar},
+		{"columnar compressed", Options{Columnar: true, Compress: true}, batchColumnar},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newCodec(t, cs, tc.opts)
+			full := allTypesDocument(t, c)
+			partial := container.NewDataContainer()
+			for i, f := range c.root.fields {
+				if i%3 == 0 {
+					partial.SetNull(f.key)
+				}
+			}
+			docs := []*container.DataContainer{full, partial, allTypesDocument(t, c)}
+
+			packet, err := c.EncodeBatch(docs)
+			require.NoError(t, err)
+			h, err := ReadHeader(packet)
+			require.NoError(t, err)
+			assert.Equal(t, PacketBatch, h.Type)
+			assert.Equal(t, tc.opts.Columnar, h.Columnar)
+			if !tc.opts.Compress {
+				assert.Equal(t, []byte{3, tc.flags}, packet[2:4])
+			}
+
+			pool := container.NewPool()
+			got, err := c.DecodeBatch(packet, pool)
+			require.NoError(t, err)
+			require.Len(t, got, len(docs))
+			for i := range docs {
+				requireSameDocument(t, cs, docs[i], got[i])
+			}
+		})
+	}
+}
+
+func TestCodec_Stream(t *testing.T) {
+	cs := compileSchema(t, allTypesSchemaJSON)
+	for _, compress := range []bool{false, true} {
+		c := newCodec(t, cs, Options{Compress: compress})
+		columnar := newCodec(t, cs, Options{Compress: compress, Columnar: true})
+		sparse := newCodec(t, cs, Options{Compress: compress, Density: DensitySparse})
+
+		var buf bytes.Buffer
+		w, err := c.NewStreamWriter(&buf)
+		require.NoError(t, err)
+		chunks := [][]*container.DataContainer{
+			{allTypesDocument(t, c), allTypesDocument(t, c)},
+			{allTypesDocument(t, c)},
+		}
+		require.NoError(t, w.WriteChunk(chunks[0]))
+		require.NoError(t, w.WriteChunk(nil))
+		// Chunks may switch layout mid-stream.
+		w.c = columnar
+		require.NoError(t, w.WriteChunk(chunks[1]))
+		w.c = sparse
+		require.NoError(t, w.WriteChunk(chunks[0]))
+		w.c = c
+		require.NoError(t, w.Close())
+		chunks = append(chunks, chunks[0])
+
+		stream := buf.Bytes()
+		if compress {
+			assert.Equal(t, []byte{0x07, 0x00, 0x01, 0x05}, stream[:4])
+		} else {
+			assert.Equal(t, []byte{0x03, 0x00, 0x01, 0x01}, stream[:4])
+			assert.Equal(t, []byte{0x00, 0x00}, stream[len(stream)-2:])
+		}
+
+		var got [][]*container.DataContainer
+		require.NoError(t, c.DecodeStream(stream, nil, func(docs []*container.DataContainer) error {
+			got = append(got, docs)
+			return nil
+		}))
+		require.Len(t, got, len(chunks))
+		for i := range chunks {
+			require.Len(t, got[i], len(chunks[i]))
+			for j := range chunks[i] {
+				requireSameDocument(t, cs, chunks[i][j], got[i][j])
+			}
+		}
+
+		// Without its end marker, the stream still yields its complete chunks.
+		var truncated bytes.Buffer
+		w, err = c.NewStreamWriter(&truncated)
+		require.NoError(t, err)
+		require.NoError(t, w.WriteChunk(chunks[0]))
+		require.NoError(t, w.WriteChunk(chunks[1]))
+		partial := truncated.Bytes()
+		if !compress {
+			partial = partial[:len(partial)-3]
+		}
+		delivered := 0
+		err = c.ReadStream(bytes.NewReader(partial), nil, func(docs []*container.DataContainer) error {
+			delivered++
+			return nil
+		})
+		errcode.Require(t, err, "ERR_BINARY_STREAM_TRUNCATED")
+		if compress {
+			assert.Equal(t, 2, delivered)
+		} else {
+			assert.Equal(t, 1, delivered)
+		}
+	}
+
+	_, err := newCodec(t, cs, Options{Cipher: newAEAD(t)}).NewStreamWriter(&bytes.Buffer{})
+	errcode.Require(t, err, "ERR_BINARY_UNSUPPORTED_FEATURE")
+}
+
+func newAEAD(t *testing.T) cipher.AEAD {
+	t.Helper()
+	block, err := aes.NewCipher(bytes.Repeat([]byte{7}, 32))
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	return aead
+}
+
+func TestCodec_CompressionAndEncryption(t *testing.T) {
+	cs := compileSchema(t, demoSchemaJSON)
+	doc := demoDocument(t, cs)
+	aead := newAEAD(t)
+
+	for _, opts := range []Options{
+		{Compress: true},
+		{Cipher: aead},
+		{Compress: true, Cipher: aead},
+	} {
+		c := newCodec(t, cs, opts)
+		packet, err := c.Encode(doc)
+		require.NoError(t, err)
+		h, err := ReadHeader(packet)
+		require.NoError(t, err)
+		assert.Equal(t, opts.Compress, h.Compressed)
+		assert.Equal(t, opts.Cipher != nil, h.Encrypted)
+
+		got, err := c.Decode(packet)
+		require.NoError(t, err)
+		requireSameDocument(t, cs, doc, got)
+
+		if opts.Cipher != nil {
+			tampered := bytes.Clone(packet)
+			tampered[len(tampered)-1] ^= 1
+			_, err = c.Decode(tampered)
+			errcode.Require(t, err, "ERR_BINARY_DECRYPT_FAILED")
+
+			// The header is authenticated too.
+			tampered = bytes.Clone(packet)
+			tampered[0] ^= flagCompressed
+			_, err = c.Decode(tampered)
+			errcode.Require(t, err, "ERR_BINARY_DECRYPT_FAILED")
+
+			_, err = newCodec(t, cs, Options{Compress: opts.Compress}).Decode(packet)
+			errcode.Require(t, err, "ERR_BINARY_DECRYPT_FAILED")
+		}
+	}
+
+	plain, err := newCodec(t, cs, Options{}).Encode(doc)
+	require.NoError(t, err)
+	_, err = newCodec(t, cs, Options{Cipher: aead}).Decode(plain)
+	errcode.Require(t, err, "ERR_BINARY_DECRYPT_FAILED")
+}
+
+func TestCodec_Limits(t *testing.T) {
+	cs := compileSchema(t, demoSchemaJSON)
+	doc := demoDocument(t, cs)
+	packet, err := newCodec(t, cs, Options{Compress: true}).Encode(doc)
+	require.NoError(t, err)
+
+	_, err = newCodec(t, cs, Options{Compress: true, MaxMessageSize: 16}).Decode(packet)
+	errcode.Require(t, err, "ERR_BINARY_LIMIT_EXCEEDED")
+
+	deep := any("leaf")
+	for range maxValueDepth + 1 {
+		deep = []any{deep}
+	}
+	meta, _, _ := rootKey(cs, "meta")
+	d := container.NewDataContainer()
+	require.NoError(t, d.SetUnknown(meta, deep))
+	_, err = newCodec(t, cs, Options{}).Encode(d)
+	errcode.Require(t, err, "ERR_BINARY_LIMIT_EXCEEDED")
+
+	require.NoError(t, d.SetUnknown(meta, make(chan int)))
+	_, err = newCodec(t, cs, Options{}).Encode(d)
+	errcode.Require(t, err, "ERR_BINARY_UNSUPPORTED_VALUE")
+}
+
+func TestRegistry(t *testing.T) {
+	v1 := compileSchema(t, demoSchemaJSON)
+	v2 := compileSchema(t, allTypesSchemaJSON)
+	c1 := newCodec(t, v1, Options{Version: 1})
+	c2 := newCodec(t, v2, Options{Version: 300})
+
+	p1, err := c1.Encode(demoDocument(t, v1))
+	require.NoError(t, err)
+	p2, err := c2.Encode(allTypesDocument(t, c2))
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x10), p2[0]&flagEpochMask, "version 300 is in epoch 1")
+
+	_, err = c1.Decode(p2)
+	errcode.Require(t, err, "ERR_BINARY_SCHEMA_VERSION")
+
+	reg := NewRegistry(c1, c2)
+	got, err := reg.Decode(p1)
+	require.NoError(t, err)
+	requireSameDocument(t, v1, demoDocument(t, v1), got)
+	codec, err := reg.CodecFor(p2)
+	require.NoError(t, err)
+	assert.Same(t, c2, codec)
+
+	_, err = NewRegistry(c1).Decode(p2)
+	errcode.Require(t, err, "ERR_BINARY_SCHEMA_VERSION")
+}
+
+func TestLayoutVersion(t *testing.T) {
+	a := compileSchema(t, demoSchemaJSON)
+	b := compileSchema(t, demoSchemaJSON)
+	assert.Equal(t, LayoutVersion(a), LayoutVersion(b))
+	assert.LessOrEqual(t, LayoutVersion(a), uint16(MaxVersion))
+	assert.NotEqual(t, LayoutVersion(a), LayoutVersion(compileSchema(t, allTypesSchemaJSON)))
+}
+
+func TestNewCodec_RejectsInvalidOptions(t *testing.T) {
+	cs := compileSchema(t, demoSchemaJSON)
+	_, err := NewCodec(cs, Options{Version: MaxVersion + 1})
+	errcode.Require(t, err, "ERR_BINARY_INVALID_OPTIONS")
+	_, err = NewCodec(cs, Options{Density: DensitySparse + 1})
+	errcode.Require(t, err, "ERR_BINARY_INVALID_OPTIONS")
+	_, err = NewCodec(nil, Options{})
+	errcode.Require(t, err, "ERR_BINARY_INVALID_OPTIONS")
+}
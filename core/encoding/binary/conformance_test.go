@@ -0,0 +1,187 @@
+package binary
+
+import (
+	stdbinary "encoding/binary"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The vectors below are taken from the examples of the wire format
+// specification, or derived by hand from its layout rules.
+
+func TestConformance_Varints(t *testing.T) {
+	for _, tc := range []struct {
+		value uint64
+		want  []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7F}},
+		{128, []byte{0x80, 0x01}},
+		{16383, []byte{0xFF, 0x7F}},
+		{16384, []byte{0x80, 0x80, 0x01}},
+	} {
+		assert.Equal(t, tc.want, stdbinary.AppendUvarint(nil, tc.value), "%d", tc.value)
+		r := &reader{buf: tc.want}
+		got, err := r.uvarint()
+		require.NoError(t, err)
+		assert.Equal(t, tc.value, got)
+	}
+	for _, tc := range []struct {
+		value int64
+		want  []byte
+	}{
+		{0, []byte{0x00}},
+		{-1, []byte{0x01}},
+		{1, []byte{0x02}},
+		{-2, []byte{0x03}},
+	} {
+		assert.Equal(t, tc.want, stdbinary.AppendVarint(nil, tc.value), "%d", tc.value)
+	}
+
+	r := &reader{buf: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}}
+	_, err := r.uvarint()
+	errcode.Require(t, err, "ERR_BINARY_VARINT_OVERFLOW")
+	r = &reader{buf: []byte{0x80}}
+	_, err = r.uvarint()
+	errcode.Require(t, err, "ERR_BINARY_BUFFER_UNDERFLOW")
+}
+
+func TestConformance_HeaderFlags(t *testing.T) {
+	for _, tc := range []struct {
+		flags byte
+		want  Header
+	}{
+		{0x00, Header{Type: PacketDense}},
+		{0x01, Header{Type: PacketSparse}},
+		{0x02, Header{Type: PacketBatch}},
+		{0x0A, Header{Type: PacketBatch, Columnar: true}},
+		{0x03, Header{Type: PacketStream}},
+		{0x04, Header{Type: PacketDense, Compressed: true}},
+		{0x06, Header{Type: PacketBatch, Compressed: true}},
+		{0x07, Header{Type: PacketStream, Compressed: true}},
+		{0x10, Header{Type: PacketDense, Version: 256}},
+		{0x20, Header{Type: PacketDense, Version: 512}},
+		{0x30, Header{Type: PacketDense, Version: 768}},
+		{0x40, Header{Type: PacketDense, Encrypted: true}},
+		{0x44, Header{Type: PacketDense, Compressed: true, Encrypted: true}},
+		{0x80, Header{Type: PacketDense, Hashed: true}},
+		{0xC0, Header{Type: PacketDense, Encrypted: true, Hashed: true}},
+		{0xE4, Header{Type: PacketDense, Version: 512, Compressed: true, Encrypted: true, Hashed: true}},
+	} {
+		got, err := ReadHeader([]byte{tc.flags, 0x00})
+		require.NoError(t, err, "0x%02x", tc.flags)
+		assert.Equal(t, tc.want, got, "0x%02x", tc.flags)
+		assert.Equal(t, []byte{tc.flags, 0x00}, appendHeader(nil, tc.want), "0x%02x", tc.flags)
+	}
+
+	for _, tc := range []struct {
+		header  []byte
+		version uint16
+	}{
+		{[]byte{0x00, 0x01}, 1},
+		{[]byte{0x10, 0x00}, 256},
+		{[]byte{0x20, 0xFF}, 767},
+		{[]byte{0x30, 0xFF}, 1023},
+	} {
+		h, err := ReadHeader(tc.header)
+		require.NoError(t, err)
+		assert.Equal(t, tc.version, h.Version)
+	}
+
+	_, err := ReadHeader([]byte{0x08, 0x00})
+	errcode.Require(t, err, "ERR_BINARY_INVALID_PACKET")
+	_, err = ReadHeader([]byte{0x00})
+	errcode.Require(t, err, "ERR_BINARY_BUFFER_UNDERFLOW")
+}
+
+const pairSchemaJSON = `{
+  "version": "1.0.0",
+  "name": "pair",
+  "fields": {
+    "n": { "name": "n", "type": "integer" },
+    "s": { "name": "s", "type": "string" }
+  }
+}`
+
+func TestConformance_Packets(t *testing.T) {
+	cs := compileSchema(t, pairSchemaJSON)
+	n, _, _ := rootKey(cs, "n")
+	s, _, _ := rootKey(cs, "s")
+	doc := container.NewDataContainer()
+	require.NoError(t, doc.SetInt(n, -1))
+	require.NoError(t, doc.SetString(s, "hi"))
+
+	// Dense: state map 0b1010 (n then s, both "has value", LSB first), the
+	// TypeInt block, then the TypeString block.
+	dense, err := newCodec(t, cs, Options{Version: 1, Density: DensityDense}).Encode(doc)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x01, 0x0A, 0x01, 0x02, 'h', 'i'}, dense)
+
+	// Sparse: field count, then each key followed by its value.
+	sparse, err := newCodec(t, cs, Options{Version: 1, Density: DensitySparse}).Encode(doc)
+	require.NoError(t, err)
+	want := []byte{0x01, 0x01, 0x02}
+	want = append(stdbinary.AppendUvarint(want, uint64(n)), 0x01)
+	want = append(stdbinary.AppendUvarint(want, uint64(s)), 0x02, 'h', 'i')
+	assert.Equal(t, want, sparse)
+
+	// Null: state 01 in dense packets, the key's null bit in sparse ones.
+	doc.SetNull(n)
+	dense, err = newCodec(t, cs, Options{Version: 1, Density: DensityDense}).Encode(doc)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x01, 0x09, 0x02, 'h', 'i'}, dense)
+	sparse, err = newCodec(t, cs, Options{Version: 1, Density: DensitySparse}).Encode(doc)
+	require.NoError(t, err)
+	want = stdbinary.AppendUvarint([]byte{0x01, 0x01, 0x02}, uint64(n)|1)
+	want = append(stdbinary.AppendUvarint(want, uint64(s)), 0x02, 'h', 'i')
+	assert.Equal(t, want, sparse)
+
+	// The reserved state is rejected.
+	_, err = newCodec(t, cs, Options{Version: 1}).Decode([]byte{0x00, 0x01, 0x03, 0x00})
+	errcode.Require(t, err, "ERR_BINARY_INVALID_PACKET")
+	// So are trailing bytes.
+	_, err = newCodec(t, cs, Options{Version: 1}).Decode(append(dense, 0x00))
+	errcode.Require(t, err, "ERR_BINARY_INVALID_PACKET")
+}
+
+func TestConformance_ValueEncodings(t *testing.T) {
+	assert.Equal(t, []byte{0x09, 0x0D, 0x01},
+		appendBools(nil, []bool{true, false, true, true, false, false, false, false, true}),
+		"bools pack LSB first")
+	assert.Equal(t, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F}, appendFloat(nil, 1),
+		"floats are little-endian IEEE 754")
+	assert.Equal(t, []byte{0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40},
+		appendGeometry(nil, [][]float64{{1, 2}}))
+
+	b, err := appendAny(nil, map[string]any{"b": nil, "a": true}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{byte(container.TypeRecord), 0x02, 0x01, 'a', byte(container.TypeBool), 0x01, 0x01, 'b', byte(container.TypeUnknown)}, b,
+		"records are tagged, with keys sorted")
+
+	r := &reader{buf: []byte{byte(container.TypeBool), 0x02}}
+	_, err = readAny(r)
+	errcode.Require(t, err, "ERR_BINARY_TYPE_MISMATCH")
+	r = &reader{buf: []byte{byte(container.TypeArrayObject), 0x00}}
+	_, err = readAny(r)
+	errcode.Require(t, err, "ERR_BINARY_TYPE_MISMATCH")
+	r = &reader{buf: []byte{byte(container.TypeArrayString), 0xFF, 0xFF, 0x03}}
+	_, err = readAny(r)
+	errcode.Require(t, err, "ERR_BINARY_BUFFER_UNDERFLOW")
+}
+
+func TestConformance_Unsupported(t *testing.T) {
+	cs := compileSchema(t, pairSchemaJSON)
+	c := newCodec(t, cs, Options{})
+	_, err := c.Decode([]byte{0x80, 0x00})
+	errcode.Require(t, err, "ERR_BINARY_UNSUPPORTED_FEATURE")
+	_, err = c.Decode([]byte{0x02, 0x00, 0x00, 0x00})
+	errcode.Require(t, err, "ERR_BINARY_UNKNOWN_PACKET_TYPE")
+	_, err = c.DecodeBatch([]byte{0x00, 0x00, 0x00}, nil)
+	errcode.Require(t, err, "ERR_BINARY_UNKNOWN_PACKET_TYPE")
+	_, err = c.DecodeBatch([]byte{0x0A, 0x00, 0x00, 0x00}, nil)
+	errcode.Require(t, err, "ERR_BINARY_INVALID_PACKET")
+}
@@ -0,0 +1,33 @@
+package binary
+
+import (
+	"errors"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+)
+
+// Errors returned by the binary codec. They follow the error table of the
+// wire format specification: every malformed packet is rejected.
+var (
+	ErrInvalidOptions     = common.NewSystemError("ERR_BINARY_INVALID_OPTIONS", "invalid binary codec options")
+	ErrInvalidPacket      = common.NewSystemError("ERR_BINARY_INVALID_PACKET", "malformed binary packet")
+	ErrUnknownPacketType  = common.NewSystemError("ERR_BINARY_UNKNOWN_PACKET_TYPE", "unexpected binary packet type")
+	ErrSchemaVersion      = common.NewSystemError("ERR_BINARY_SCHEMA_VERSION", "binary packet was encoded with an unknown schema version")
+	ErrBufferUnderflow    = common.NewSystemError("ERR_BINARY_BUFFER_UNDERFLOW", "binary packet ended unexpectedly")
+	ErrVarintOverflow     = common.NewSystemError("ERR_BINARY_VARINT_OVERFLOW", "varint exceeds 64 bits")
+	ErrTypeMismatch       = common.NewSystemError("ERR_BINARY_TYPE_MISMATCH", "value encoding does not match its data type")
+	ErrLimitExceeded      = common.NewSystemError("ERR_BINARY_LIMIT_EXCEEDED", "binary packet exceeds a decoding limit")
+	ErrDecompressFailed   = common.NewSystemError("ERR_BINARY_DECOMPRESS_FAILED", "failed to decompress binary packet")
+	ErrDecryptFailed      = common.NewSystemError("ERR_BINARY_DECRYPT_FAILED", "failed to authenticate or decrypt binary packet")
+	ErrUnsupportedFeature = common.NewSystemError("ERR_BINARY_UNSUPPORTED_FEATURE", "binary packet uses an unsupported feature")
+	ErrUnsupportedValue   = common.NewSystemError("ERR_BINARY_UNSUPPORTED_VALUE", "value cannot be encoded")
+	ErrDenseIneligible    = common.NewSystemError("ERR_BINARY_DENSE_INELIGIBLE", "document holds fields outside the schema and cannot be densely encoded")
+	ErrStreamTruncated    = common.NewSystemError("ERR_BINARY_STREAM_TRUNCATED", "stream ended without an end marker")
+	ErrFieldNotFound      = common.NewSystemError("ERR_BINARY_FIELD_NOT_FOUND", "field not found in the root schema")
+)
+
+// hasCode reports whether err is, or wraps, a SystemError with target's code.
+func hasCode(err error, target *common.SystemError) bool {
+	var se *common.SystemError
+	return errors.As(err, &se) && se.Code == target.Code
+}
@@ -0,0 +1,56 @@
+package binary
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+)
+
+// FuzzDecode feeds arbitrary input to every decoder. Malformed input must be
+// rejected with an error, never a panic; input that decodes must re-encode.
+func FuzzDecode(f *testing.F) {
+	cs := compileSchema(f, allTypesSchemaJSON)
+	c := newCodec(f, cs, Options{})
+	doc := allTypesDocument(f, c)
+	for _, opts := range []Options{{}, {Density: DensitySparse}, {Compress: true}, {Columnar: true}} {
+		oc := newCodec(f, cs, opts)
+		packet, err := oc.Encode(doc)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(packet)
+		batch, err := oc.EncodeBatch([]*container.DataContainer{doc, container.NewDataContainer()})
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(batch)
+		var stream bytes.Buffer
+		w, err := oc.NewStreamWriter(&stream)
+		if err != nil {
+			f.Fatal(err)
+		}
+		_ = w.WriteChunk([]*container.DataContainer{doc})
+		_ = w.Close()
+		f.Add(stream.Bytes())
+	}
+	f.Add([]byte{0x01, 0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0x0F})
+
+	compressed := newCodec(f, cs, Options{Compress: true, MaxMessageSize: 1 << 20})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pool := container.NewPool()
+		for _, codec := range []*Codec{c, compressed} {
+			if got, err := codec.Decode(data); err == nil {
+				if _, err := codec.Encode(got); err != nil {
+					t.Fatalf("decoded packet does not re-encode: %v", err)
+				}
+			}
+			if docs, err := codec.DecodeBatch(data, pool); err == nil {
+				if _, err := codec.EncodeBatch(docs); err != nil {
+					t.Fatalf("decoded batch does not re-encode: %v", err)
+				}
+			}
+			_ = codec.DecodeStream(data, pool, func([]*container.DataContainer) error { return nil })
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package binary
+
+// PacketType is the packet type carried in bits 0-1 of the flags byte.
+type PacketType uint8
+
+const (
+	PacketDense  PacketType = 0
+	PacketSparse PacketType = 1
+	PacketBatch  PacketType = 2
+	PacketStream PacketType = 3
+)
+
+func (t PacketType) String() string {
+	switch t {
+	case PacketDense:
+		return "dense"
+	case PacketSparse:
+		return "sparse"
+	case PacketBatch:
+		return "batch"
+	default:
+		return "stream"
+	}
+}
+
+// Flags byte layout.
+const (
+	flagTypeMask   byte = 0x03
+	flagCompressed byte = 0x04
+	flagColumnar   byte = 0x08
+	flagEpochMask  byte = 0x30
+	flagEncrypted  byte = 0x40
+	flagHashed     byte = 0x80
+
+	epochShift = 4
+)
+
+// Batch flags byte, following the record count of batch packets and used as
+// the chunk descriptor of streams.
+const (
+	batchColumnar byte = 0x01
+	batchSparse   byte = 0x02
+)
+
+// Stream header bytes following the common header.
+const (
+	streamExtendedType  byte = 0x01
+	streamChunkMask     byte = 0x03
+	streamChunkColumnar byte = 0x01
+	streamCompressed    byte = 0x04
+)
+
+const (
+	headerSize       = 2
+	streamHeaderSize = 4
+)
+
+// MaxVersion is the largest schema version a header can carry: an 8-bit
+// version byte extended by the 2-bit epoch.
+const MaxVersion = 1023
+
+// Header is the decoded 2-byte header every packet starts with.
+type Header struct {
+	Type       PacketType
+	Version    uint16
+	Compressed bool
+	// Columnar is only meaningful for batch packets.
+	Columnar  bool
+	Encrypted bool
+	Hashed    bool
+}
+
+// ReadHeader decodes the header at the start of data.
+func ReadHeader(data []byte) (Header, error) {
+	if len(data) < headerSize {
+		return Header{}, ErrBufferUnderflow.WithMessage("packet is shorter than its header")
+	}
+	flags := data[0]
+	h := Header{
+		Type:       PacketType(flags & flagTypeMask),
+		Version:    uint16(flags&flagEpochMask)<<(8-epochShift) | uint16(data[1]),
+		Compressed: flags&flagCompressed != 0,
+		Columnar:   flags&flagColumnar != 0,
+		Encrypted:  flags&flagEncrypted != 0,
+		Hashed:     flags&flagHashed != 0,
+	}
+	if h.Columnar && h.Type != PacketBatch {
+		return Header{}, ErrInvalidPacket.WithMessagef("columnar flag set on a %s packet", h.Type)
+	}
+	return h, nil
+}
+
+// appendHeader appends the 2-byte header for h.
+func appendHeader(b []byte, h Header) []byte {
+	flags := byte(h.Type) | byte(h.Version>>8)<<epochShift
+	if h.Compressed {
+		flags |= flagCompressed
+	}
+	if h.Columnar {
+		flags |= flagColumnar
+	}
+	if h.Encrypted {
+		flags |= flagEncrypted
+	}
+	if h.Hashed {
+		flags |= flagHashed
+	}
+	return append(b, flags, byte(h.Version))
+}
+
+// IsFieldPacket reports whether data starts with a header a single-document
+// packet (dense or sparse) could carry. It is a structural check only: some
+// JSON texts also pass it (a leading digit, "t" or a space), so storage
+// backends must tell binary values apart by how they were stored — e.g. as a
+// BLOB rather than TEXT — and use IsFieldPacket as a guard, not a detector.
+func IsFieldPacket(data []byte) bool {
+	if len(data) < headerSize {
+		return false
+	}
+	flags := data[0]
+	t := PacketType(flags & flagTypeMask)
+	return (t == PacketDense || t == PacketSparse) && flags&(flagColumnar|flagHashed) == 0
+}
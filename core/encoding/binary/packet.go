@@ -0,0 +1,458 @@
+package binary
+
+import (
+	stdbinary "encoding/binary"
+	"slices"
+	"unsafe"
+
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+)
+
+// Dense state map entries, two bits per planned field, LSB first.
+const (
+	stateUnset    byte = 0b00
+	stateNull     byte = 0b01
+	stateValue    byte = 0b10
+	stateReserved byte = 0b11
+)
+
+func stateAt(states []byte, i int) byte {
+	return states[i/4] >> (2 * (i % 4)) & 0b11
+}
+
+// --- Dense ---
+
+// appendDense appends doc's state map and value blocks laid out against p.
+// The caller has checked that every key of doc is planned.
+func (c *Codec) appendDense(b []byte, p *plan, doc *container.DataContainer, depth int) ([]byte, error) {
+	_, err := doc.Walk(func(positions map[int64]int32, slot func(container.DataType, ...int) unsafe.Pointer) (any, error) {
+		start := len(b)
+		b = append(b, make([]byte, p.stateBytes)...)
+		for i, f := range p.fields {
+			idx, ok := positions[int64(f.key)]
+			switch {
+			case !ok:
+			case idx < 0:
+				b[start+i/4] |= stateNull << (2 * (i % 4))
+			default:
+				b[start+i/4] |= stateValue << (2 * (i % 4))
+			}
+		}
+
+		var err error
+		for t := container.TypeUnknown; t <= container.TypeArrayGeometry; t++ {
+			fields := p.fields[p.typeStart(t):p.typeEnd[t]]
+			if t == container.TypeBool {
+				var bools []bool
+				for _, f := range fields {
+					if hasValue(positions, f.key) {
+						bools = append(bools, (*(*[]bool)(slot(container.TypeBool)))[positions[int64(f.key)]])
+					}
+				}
+				b = appendPackedBools(b, bools)
+				continue
+			}
+			for _, f := range fields {
+				if !hasValue(positions, f.key) {
+					continue
+				}
+				if b, err = c.appendValue(b, f.key, positions[int64(f.key)], slot, f.child, depth); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func hasValue(positions map[int64]int32, key container.DataContainerKey) bool {
+	idx, ok := positions[int64(key)]
+	return ok && idx >= 0
+}
+
+// readDense reads a state map and value blocks laid out against p into doc.
+func (c *Codec) readDense(r *reader, p *plan, doc *container.DataContainer, pool *container.Pool) error {
+	states, err := r.next(p.stateBytes)
+	if err != nil {
+		return err
+	}
+	for i := range p.fields {
+		if stateAt(states, i) == stateReserved {
+			return ErrInvalidPacket.WithMessagef("reserved state for field %d", i)
+		}
+	}
+	if pad := 2 * len(p.fields) % 8; pad != 0 && states[len(states)-1]>>pad != 0 {
+		return ErrInvalidPacket.WithMessage("state map padding is not zero")
+	}
+
+	for t := container.TypeUnknown; t <= container.TypeArrayGeometry; t++ {
+		first := p.typeStart(t)
+		fields := p.fields[first:p.typeEnd[t]]
+		if t == container.TypeBool {
+			var keys []container.DataContainerKey
+			for i, f := range fields {
+				switch stateAt(states, first+i) {
+				case stateNull:
+					doc.SetNull(f.key)
+				case stateValue:
+					keys = append(keys, f.key)
+				}
+			}
+			values, err := readPackedBools(r, len(keys))
+			if err != nil {
+				return err
+			}
+			for i, key := range keys {
+				if err := doc.SetBool(key, values[i]); err != nil {
+					return ErrTypeMismatch.WithCause(err)
+				}
+			}
+			continue
+		}
+		for i, f := range fields {
+			switch stateAt(states, first+i) {
+			case stateNull:
+				doc.SetNull(f.key)
+			case stateValue:
+				if err := c.readValue(r, f.key, f.child, doc, pool); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// --- Sparse ---
+
+// appendSparse appends the count and the keyed entries of the keys of doc
+// that keep accepts (all of them when keep is nil), in canonical order. It
+// returns the number of entries written.
+func (c *Codec) appendSparse(b []byte, p *plan, doc *container.DataContainer, keep func(container.DataContainerKey) bool, depth int) ([]byte, int, error) {
+	count := 0
+	_, err := doc.Walk(func(positions map[int64]int32, slot func(container.DataType, ...int) unsafe.Pointer) (any, error) {
+		keys := make([]container.DataContainerKey, 0, len(positions))
+		for k := range positions {
+			key := container.DataContainerKey(k)
+			if keep != nil && !keep(key) {
+				continue
+			}
+			if key.IsNull() {
+				return nil, ErrUnsupportedValue.WithMessagef("key %#x has its null bit set", uint64(key))
+			}
+			keys = append(keys, key)
+		}
+		slices.SortFunc(keys, compareKeys)
+
+		b = stdbinary.AppendUvarint(b, uint64(len(keys)))
+		var err error
+		for _, key := range keys {
+			idx := positions[int64(key)]
+			if idx < 0 {
+				b = stdbinary.AppendUvarint(b, uint64(key)|1)
+				continue
+			}
+			b = stdbinary.AppendUvarint(b, uint64(key))
+			if b, err = c.appendValue(b, key, idx, slot, p.child(key), depth); err != nil {
+				return nil, err
+			}
+		}
+		count = len(keys)
+		return nil, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return b, count, nil
+}
+
+// readSparse reads keyed entries into doc. Keys p does not plan are accepted
+// so that packets written against a newer layout still decode; the elements
+// of an unplanned array of objects must then be sparse packets.
+func (c *Codec) readSparse(r *reader, p *plan, doc *container.DataContainer, pool *container.Pool) error {
+	n, err := r.count(8)
+	if err != nil {
+		return err
+	}
+	for range n {
+		wire, err := r.uvarint()
+		if err != nil {
+			return err
+		}
+		key := container.DataContainerKey(int64(wire &^ 1))
+		if wire&1 != 0 {
+			doc.SetNull(key)
+			continue
+		}
+		if err := c.readValue(r, key, p.child(key), doc, pool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- Values ---
+
+// appendValue appends the value stored at idx for key. child is the element
+// plan when key is an array of objects.
+func (c *Codec) appendValue(b []byte, key container.DataContainerKey, idx int32, slot func(container.DataType, ...int) unsafe.Pointer, child *plan, depth int) ([]byte, error) {
+	t := key.Type()
+	ptr := slot(t)
+	switch t {
+	case container.TypeUnknown:
+		return appendAny(b, (*(*[]any)(ptr))[idx], 0)
+	case container.TypeInt:
+		return stdbinary.AppendVarint(b, (*(*[]int64)(ptr))[idx]), nil
+	case container.TypeFloat:
+		return appendFloat(b, (*(*[]float64)(ptr))[idx]), nil
+	case container.TypeString:
+		return appendString(b, (*(*[]string)(ptr))[idx]), nil
+	case container.TypeBool:
+		return appendBool(b, (*(*[]bool)(ptr))[idx]), nil
+	case container.TypeBytes:
+		return appendBytes(b, (*(*[][]byte)(ptr))[idx]), nil
+	case container.TypeGeometry:
+		return appendGeometry(b, (*(*[][][]float64)(ptr))[idx]), nil
+	case container.TypeRecord:
+		return appendRecord(b, (*(*[]map[string]any)(ptr))[idx], 1)
+	case container.TypeArrayUnknown:
+		values := (*(*[][]any)(ptr))[idx]
+		b = stdbinary.AppendUvarint(b, uint64(len(values)))
+		var err error
+		for _, v := range values {
+			if b, err = appendAny(b, v, 1); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case container.TypeArrayInt:
+		return appendInts(b, (*(*[][]int64)(ptr))[idx]), nil
+	case container.TypeArrayFloat:
+		return appendFloats(b, (*(*[][]float64)(ptr))[idx]), nil
+	case container.TypeArrayString:
+		return appendStrings(b, (*(*[][]string)(ptr))[idx]), nil
+	case container.TypeArrayBool:
+		return appendBools(b, (*(*[][]bool)(ptr))[idx]), nil
+	case container.TypeArrayBytes:
+		return appendBytesArray(b, (*(*[][][]byte)(ptr))[idx]), nil
+	case container.TypeArrayGeometry:
+		return appendGeometries(b, (*(*[][][][]float64)(ptr))[idx]), nil
+	default: // container.TypeArrayObject
+		return c.appendElements(b, (*(*[][]*container.DataContainer)(ptr))[idx], child, depth+1)
+	}
+}
+
+// appendElements appends the elements of an array of objects, each as a
+// length-prefixed nested packet: a header carrying the codec's version and
+// no compression or encryption, then a dense or sparse body.
+func (c *Codec) appendElements(b []byte, elements []*container.DataContainer, child *plan, depth int) ([]byte, error) {
+	if depth > maxValueDepth {
+		return nil, ErrLimitExceeded.WithMessagef("objects nest deeper than %d levels", maxValueDepth)
+	}
+	b = stdbinary.AppendUvarint(b, uint64(len(elements)))
+	var packet []byte
+	for _, elem := range elements {
+		if elem == nil {
+			elem = container.NewDataContainer()
+		}
+		dense, err := c.chooseDense(child, DensityAuto, elem)
+		if err != nil {
+			return nil, err
+		}
+		h := Header{Type: PacketSparse, Version: c.opts.Version}
+		if dense {
+			h.Type = PacketDense
+		}
+		packet = appendHeader(packet[:0], h)
+		if dense {
+			packet, err = c.appendDense(packet, child, elem, depth)
+		} else {
+			packet, _, err = c.appendSparse(packet, child, elem, nil, depth)
+		}
+		if err != nil {
+			return nil, err
+		}
+		b = appendBytes(b, packet)
+	}
+	return b, nil
+}
+
+// readValue reads one value of key's type and stores it in doc.
+func (c *Codec) readValue(r *reader, key container.DataContainerKey, child *plan, doc *container.DataContainer, pool *container.Pool) error {
+	var err error
+	switch key.Type() {
+	case container.TypeUnknown:
+		var v any
+		if v, err = readAny(r); err == nil {
+			err = setValue(doc.SetUnknown(key, v))
+		}
+	case container.TypeInt:
+		var v int64
+		if v, err = r.varint(); err == nil {
+			err = setValue(doc.SetInt(key, v))
+		}
+	case container.TypeFloat:
+		var v float64
+		if v, err = r.float(); err == nil {
+			err = setValue(doc.SetFloat(key, v))
+		}
+	case container.TypeString:
+		var v string
+		if v, err = r.string(); err == nil {
+			err = setValue(doc.SetString(key, v))
+		}
+	case container.TypeBool:
+		var v bool
+		if v, err = r.bool(); err == nil {
+			err = setValue(doc.SetBool(key, v))
+		}
+	case container.TypeBytes:
+		var v []byte
+		if v, err = r.bytes(); err == nil {
+			err = setValue(doc.SetBytes(key, v))
+		}
+	case container.TypeGeometry:
+		var v [][]float64
+		if v, err = readGeometry(r); err == nil {
+			err = setValue(doc.SetGeometry(key, v))
+		}
+	case container.TypeRecord:
+		var v map[string]any
+		if v, err = readRecord(r); err == nil {
+			err = setValue(doc.SetRecord(key, v))
+		}
+	case container.TypeArrayUnknown:
+		var v []any
+		if v, err = readAnyArray(r); err == nil {
+			err = setValue(doc.SetArrayUnknown(key, v))
+		}
+	case container.TypeArrayInt:
+		var v []int64
+		if v, err = readInts(r); err == nil {
+			err = setValue(doc.SetArrayInt(key, v))
+		}
+	case container.TypeArrayFloat:
+		var v []float64
+		if v, err = readFloats(r); err == nil {
+			err = setValue(doc.SetArrayFloat(key, v))
+		}
+	case container.TypeArrayString:
+		var v []string
+		if v, err = readStrings(r); err == nil {
+			err = setValue(doc.SetArrayString(key, v))
+		}
+	case container.TypeArrayBool:
+		var v []bool
+		if v, err = readBools(r); err == nil {
+			err = setValue(doc.SetArrayBool(key, v))
+		}
+	case container.TypeArrayBytes:
+		var v [][]byte
+		if v, err = readBytesArray(r); err == nil {
+			err = setValue(doc.SetArrayBytes(key, v))
+		}
+	case container.TypeArrayGeometry:
+		var v [][][]float64
+		if v, err = readGeometries(r); err == nil {
+			err = setValue(doc.SetArrayGeometry(key, v))
+		}
+	case container.TypeArrayObject:
+		var v []*container.DataContainer
+		if v, err = c.readElements(r, child, pool); err == nil {
+			if err = setValue(doc.SetArrayObject(key, v)); err != nil {
+				release(pool, v)
+			}
+		}
+	}
+	return err
+}
+
+// setValue maps a container write failure to a codec error.
+func setValue(err error) error {
+	if err != nil {
+		return ErrTypeMismatch.WithCause(err)
+	}
+	return nil
+}
+
+// readElements reads the nested packets of an array of objects.
+func (c *Codec) readElements(r *reader, child *plan, pool *container.Pool) ([]*container.DataContainer, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	n, err := r.count(8)
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]*container.DataContainer, 0, n)
+	for range n {
+		elem, err := c.readElement(r, child, pool)
+		if err != nil {
+			release(pool, elements)
+			return nil, err
+		}
+		elements = append(elements, elem)
+	}
+	return elements, nil
+}
+
+func (c *Codec) readElement(r *reader, child *plan, pool *container.Pool) (*container.DataContainer, error) {
+	n, err := r.count(8)
+	if err != nil {
+		return nil, err
+	}
+	packet, _ := r.next(n)
+	h, err := ReadHeader(packet)
+	if err != nil {
+		return nil, err
+	}
+	if h.Version != c.opts.Version {
+		return nil, ErrSchemaVersion.WithMessagef("nested packet has schema version %d, codec expects %d", h.Version, c.opts.Version)
+	}
+	if h.Compressed || h.Encrypted || h.Hashed {
+		return nil, ErrInvalidPacket.WithMessage("nested packets cannot be compressed, encrypted or hashed")
+	}
+	nested := &reader{buf: packet[headerSize:], alias: r.alias, depth: r.depth}
+	elem := acquire(pool)
+	switch h.Type {
+	case PacketDense:
+		if child == nil {
+			err = ErrInvalidPacket.WithMessage("dense nested packet for a field outside the schema")
+		} else {
+			err = c.readDense(nested, child, elem, pool)
+		}
+	case PacketSparse:
+		err = c.readSparse(nested, child, elem, pool)
+	default:
+		err = ErrUnknownPacketType.WithMessagef("nested %s packet", h.Type)
+	}
+	if err == nil {
+		err = nested.finish()
+	}
+	if err != nil {
+		release(pool, []*container.DataContainer{elem})
+		return nil, err
+	}
+	return elem, nil
+}
+
+func acquire(pool *container.Pool) *container.DataContainer {
+	if pool != nil {
+		return pool.Get()
+	}
+	return container.NewDataContainer()
+}
+
+func release(pool *container.Pool, docs []*container.DataContainer) {
+	if pool == nil {
+		return
+	}
+	for _, doc := range docs {
+		pool.Put(doc)
+	}
+}
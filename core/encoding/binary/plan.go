@@ -0,0 +1,158 @@
+package binary
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// planField is one key a document of the planned schema can hold.
+type planField struct {
+	key container.DataContainerKey
+	// root is the index of the root-level field the key belongs to.
+	root int
+	// child is the element plan of a TypeArrayObject field.
+	child *plan
+}
+
+// plan is the fixed field list of one schema slot as seen from one path: the
+// keys a document decoded by the JSON decoder can hold, in canonical order
+// (DataType, then key). Dense packets are laid out against it.
+type plan struct {
+	fields []planField
+	index  map[int64]int
+	// typeEnd[t] is one past the last field of DataType t.
+	typeEnd    [16]int
+	stateBytes int
+}
+
+// buildPlan enumerates the keys of schema slot schemaIdx reached through
+// path, deriving each key exactly as the JSON decoder does: nested objects
+// flatten into the parent at their addresses, records and arrays of objects
+// are stored under their descriptor's internal key, and the elements of an
+// array of objects are planned separately.
+func buildPlan(cs *definition.CompiledSchema, schemaIdx uint16, path definition.ResolvedPath) (*plan, error) {
+	p := &plan{index: make(map[int64]int)}
+	if err := p.collect(cs, schemaIdx, path, -1, 0); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(p.fields, func(a, b planField) int {
+		return compareKeys(a.key, b.key)
+	})
+	for i, f := range p.fields {
+		p.index[int64(f.key)] = i
+		p.typeEnd[f.key.Type()] = i + 1
+	}
+	for t := 1; t < len(p.typeEnd); t++ {
+		p.typeEnd[t] = max(p.typeEnd[t], p.typeEnd[t-1])
+	}
+	p.stateBytes = (2*len(p.fields) + 7) / 8
+	return p, nil
+}
+
+func (p *plan) collect(cs *definition.CompiledSchema, schemaIdx uint16, path definition.ResolvedPath, root int, depth int) error {
+	if depth > maxValueDepth {
+		return ErrLimitExceeded.WithMessagef("schema nests deeper than %d levels", maxValueDepth)
+	}
+	if int(schemaIdx) >= len(cs.Schemas) {
+		return ErrInvalidOptions.WithMessagef("schema slot %d out of range", schemaIdx)
+	}
+	slot := cs.Schemas[schemaIdx]
+	for j := uint16(0); j < slot.FieldCount; j++ {
+		fd := cs.Descriptors[int(slot.FieldStart)+int(j)]
+		fieldRoot := root
+		if fieldRoot < 0 {
+			fieldRoot = int(j)
+		}
+		fieldPath := append(path[:len(path):len(path)], definition.NewResolvedStep(schemaIdx, j))
+
+		if !fd.Terminal() && fd.ChildSchemaIdx() != definition.FdNoChild {
+			switch fd.DataType() {
+			case container.TypeArrayObject:
+				child, err := buildPlan(cs, fd.ChildSchemaIdx(), fieldPath)
+				if err != nil {
+					return err
+				}
+				p.add(planField{key: internalKey(fd), root: fieldRoot, child: child})
+			case container.TypeRecord:
+				p.add(planField{key: internalKey(fd), root: fieldRoot})
+			default:
+				if err := p.collect(cs, fd.ChildSchemaIdx(), fieldPath, fieldRoot, depth+1); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		key, err := leafKey(cs, fd, fieldPath)
+		if err != nil {
+			return err
+		}
+		p.add(planField{key: key, root: fieldRoot})
+	}
+	return nil
+}
+
+// add appends f unless its key is already planned; a named schema used by two
+// fields of one parent maps its internal keys onto the same entries.
+func (p *plan) add(f planField) {
+	if _, ok := p.index[int64(f.key)]; ok {
+		return
+	}
+	p.index[int64(f.key)] = len(p.fields)
+	p.fields = append(p.fields, f)
+}
+
+// field returns the planned field for key, if any.
+func (p *plan) field(key container.DataContainerKey) (planField, bool) {
+	if p == nil {
+		return planField{}, false
+	}
+	i, ok := p.index[int64(key)]
+	if !ok {
+		return planField{}, false
+	}
+	return p.fields[i], true
+}
+
+// child returns the element plan for key, or nil when the key is not planned.
+func (p *plan) child(key container.DataContainerKey) *plan {
+	f, _ := p.field(key)
+	return f.child
+}
+
+// typeStart returns the index of the first field of DataType t.
+func (p *plan) typeStart(t container.DataType) int {
+	if t == 0 {
+		return 0
+	}
+	return p.typeEnd[t-1]
+}
+
+// compareKeys orders keys canonically: by DataType, so that value blocks
+// group by type, then by the unsigned key.
+func compareKeys(a, b container.DataContainerKey) int {
+	if c := cmp.Compare(a.Type(), b.Type()); c != 0 {
+		return c
+	}
+	return cmp.Compare(uint64(a), uint64(b))
+}
+
+// leafKey mirrors the JSON decoder's key derivation for a leaf value.
+func leafKey(cs *definition.CompiledSchema, fd definition.FieldDescriptor, path definition.ResolvedPath) (container.DataContainerKey, error) {
+	addr := cs.Address(path)
+	if addr == 0 {
+		return internalKey(fd), nil
+	}
+	key, err := fd.AddressKey(addr)
+	if err != nil {
+		return 0, ErrInvalidOptions.WithCause(err)
+	}
+	return key, nil
+}
+
+func internalKey(fd definition.FieldDescriptor) container.DataContainerKey {
+	return fd.InternalKey()
+}
@@ -0,0 +1,71 @@
+package binary
+
+import (
+	"sync"
+
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+)
+
+// Registry routes packets to the codec of their schema version, so that
+// packets written under earlier schema versions stay readable. It is safe for
+// concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	codecs map[uint16]*Codec
+}
+
+// NewRegistry returns a registry holding codecs. A later codec replaces an
+// earlier one with the same version.
+func NewRegistry(codecs ...*Codec) *Registry {
+	r := &Registry{codecs: make(map[uint16]*Codec, len(codecs))}
+	for _, c := range codecs {
+		r.Register(c)
+	}
+	return r
+}
+
+// Register adds c under its version, replacing any codec registered for it.
+func (r *Registry) Register(c *Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codecs[c.Version()] = c
+}
+
+// Codec returns the codec registered for version.
+func (r *Registry) Codec(version uint16) (*Codec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.codecs[version]
+	return c, ok
+}
+
+// CodecFor returns the codec for the schema version in data's header.
+func (r *Registry) CodecFor(data []byte) (*Codec, error) {
+	h, err := ReadHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := r.Codec(h.Version)
+	if !ok {
+		return nil, ErrSchemaVersion.WithMessagef("no codec registered for schema version %d", h.Version)
+	}
+	return c, nil
+}
+
+// Decode decodes a dense or sparse packet with the codec of its version.
+func (r *Registry) Decode(data []byte) (*container.DataContainer, error) {
+	c, err := r.CodecFor(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(data)
+}
+
+// DecodeBatch decodes a batch packet with the codec of its version.
+func (r *Registry) DecodeBatch(data []byte, pool *container.Pool) ([]*container.DataContainer, error) {
+	c, err := r.CodecFor(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.DecodeBatch(data, pool)
+}
@@ -0,0 +1,198 @@
+package binary
+
+import (
+	"bytes"
+	"compress/flate"
+	stdbinary "encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+)
+
+// StreamWriter writes a stream packet: a 4-byte stream header, then one chunk
+// per WriteChunk call, then the end marker written by Close. Each chunk
+// carries its own layout descriptor, so a stream can move between dense,
+// sparse and columnar rows as its documents change. With Options.Compress
+// all chunks share one DEFLATE stream, which is flushed after every chunk so
+// readers can decode what has arrived.
+type StreamWriter struct {
+	c      *Codec
+	w      io.Writer
+	fw     *flate.Writer
+	closed bool
+	err    error
+}
+
+// NewStreamWriter writes the stream header to w and returns a writer for its
+// chunks. Encrypted streams are not supported.
+func (c *Codec) NewStreamWriter(w io.Writer) (*StreamWriter, error) {
+	if c.opts.Cipher != nil {
+		return nil, ErrUnsupportedFeature.WithMessage("streams cannot be encrypted")
+	}
+	h := Header{Type: PacketStream, Version: c.opts.Version, Compressed: c.opts.Compress}
+	encoding := streamChunkColumnar
+	if c.opts.Compress {
+		encoding |= streamCompressed
+	}
+	header := append(appendHeader(make([]byte, 0, streamHeaderSize), h), streamExtendedType, encoding)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	s := &StreamWriter{c: c, w: w}
+	if c.opts.Compress {
+		s.fw = flateWriters.Get().(*flate.Writer)
+		s.fw.Reset(w)
+		s.w = s.fw
+	}
+	return s, nil
+}
+
+// WriteChunk writes docs as one chunk. An empty docs is a no-op: a chunk of
+// zero rows would read as the end marker.
+func (s *StreamWriter) WriteChunk(docs []*container.DataContainer) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.closed {
+		return ErrInvalidOptions.WithMessage("stream is closed")
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	chunk := []byte{0}
+	chunk = stdbinary.AppendUvarint(chunk, uint64(len(docs)))
+	chunk, flags, err := s.c.appendRows(chunk, docs)
+	if err != nil {
+		return err
+	}
+	chunk[0] = flags
+	if err := s.write(chunk); err != nil {
+		s.err = err
+	}
+	return s.err
+}
+
+// Close writes the end marker and flushes compressed output. It does not
+// close the underlying writer. Idempotent.
+func (s *StreamWriter) Close() error {
+	if s.closed {
+		return s.err
+	}
+	s.closed = true
+	if s.err != nil {
+		return s.err
+	}
+	s.err = s.write([]byte{0, 0})
+	if s.fw != nil {
+		if err := s.fw.Close(); err != nil && s.err == nil {
+			s.err = err
+		}
+		flateWriters.Put(s.fw)
+		s.fw = nil
+	}
+	return s.err
+}
+
+func (s *StreamWriter) write(b []byte) error {
+	if _, err := s.w.Write(b); err != nil {
+		return err
+	}
+	if s.fw != nil {
+		return s.fw.Flush()
+	}
+	return nil
+}
+
+// DecodeStream decodes a stream packet held in data, calling fn with the
+// documents of each chunk in order. See ReadStream.
+func (c *Codec) DecodeStream(data []byte, pool *container.Pool, fn func([]*container.DataContainer) error) error {
+	return c.ReadStream(bytes.NewReader(data), pool, fn)
+}
+
+// ReadStream reads a stream packet from r, calling fn with the documents of
+// each chunk in order; fn owns them. Documents are sourced from pool when
+// non-nil. The stream is read in full, up to Options.MaxMessageSize, before
+// decoding, since chunks carry no length. A stream that ends before its end
+// marker delivers every complete chunk and then fails with
+// ErrStreamTruncated. An error returned by fn stops decoding and is returned.
+func (c *Codec) ReadStream(r io.Reader, pool *container.Pool, fn func([]*container.DataContainer) error) error {
+	var header [streamHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return ErrStreamTruncated.WithMessage("stream is shorter than its header").WithCause(err)
+	}
+	h, err := ReadHeader(header[:])
+	if err != nil {
+		return err
+	}
+	if h.Type != PacketStream {
+		return ErrUnknownPacketType.WithMessagef("expected a stream packet, got %s", h.Type)
+	}
+	if err := c.checkHeader(h); err != nil {
+		return err
+	}
+	if h.Encrypted {
+		return ErrUnsupportedFeature.WithMessage("streams cannot be encrypted")
+	}
+	if header[2] != streamExtendedType {
+		return ErrUnknownPacketType.WithMessagef("unknown extended packet type 0x%02x", header[2])
+	}
+	encoding := header[3]
+	if encoding&^(streamChunkColumnar|streamCompressed) != 0 || encoding&streamChunkMask != streamChunkColumnar {
+		return ErrInvalidPacket.WithMessagef("invalid stream encoding 0x%02x", encoding)
+	}
+	if h.Compressed != (encoding&streamCompressed != 0) {
+		return ErrInvalidPacket.WithMessage("header and stream encoding disagree on compression")
+	}
+
+	src := r
+	if h.Compressed {
+		fr := flate.NewReader(r)
+		defer fr.Close()
+		src = fr
+	}
+	payload, err := io.ReadAll(io.LimitReader(src, int64(c.opts.MaxMessageSize)+1))
+	truncated := false
+	if err != nil {
+		// A compressed stream cut short still yields the chunks flushed
+		// before the cut.
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrDecompressFailed.WithCause(err)
+		}
+		truncated = true
+	}
+	if len(payload) > c.opts.MaxMessageSize {
+		return ErrLimitExceeded.WithMessagef("stream exceeds %d bytes", c.opts.MaxMessageSize)
+	}
+
+	pr := &reader{buf: payload, alias: true}
+	for {
+		if pr.done() {
+			if truncated {
+				return ErrStreamTruncated.WithCause(err)
+			}
+			return ErrStreamTruncated
+		}
+		flags, _ := pr.byte()
+		rows, err := pr.uvarint()
+		if err != nil {
+			return ErrStreamTruncated.WithCause(err)
+		}
+		if rows == 0 {
+			if flags != 0 {
+				return ErrInvalidPacket.WithMessagef("end marker with descriptor 0x%02x", flags)
+			}
+			return pr.finish()
+		}
+		docs, err := c.readRows(pr, flags, rows, pool)
+		if err != nil {
+			if truncated || hasCode(err, ErrBufferUnderflow) {
+				return ErrStreamTruncated.WithCause(err)
+			}
+			return err
+		}
+		if err := fn(docs); err != nil {
+			return err
+		}
+	}
+}
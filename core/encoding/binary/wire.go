@@ -0,0 +1,590 @@
+package binary
+
+import (
+	"encoding"
+	stdbinary "encoding/binary"
+	"encoding/json"
+	"math"
+	"reflect"
+	"slices"
+	"unsafe"
+
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
+)
+
+// maxValueDepth bounds the nesting of dynamic (TypeUnknown, TypeRecord and
+// TypeArrayUnknown) values and of nested packets, on both sides of the wire.
+const maxValueDepth = 64
+
+// reader consumes a packet payload. When alias is set, decoded strings and
+// byte slices point into buf instead of being copied.
+type reader struct {
+	buf   []byte
+	pos   int
+	alias bool
+	depth int
+}
+
+func (r *reader) remaining() int {
+	return len(r.buf) - r.pos
+}
+
+func (r *reader) done() bool {
+	return r.pos >= len(r.buf)
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, ErrBufferUnderflow
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) next(n int) ([]byte, error) {
+	if n < 0 || n > r.remaining() {
+		return nil, ErrBufferUnderflow
+	}
+	b := r.buf[r.pos : r.pos+n : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) uvarint() (uint64, error) {
+	v, n := stdbinary.Uvarint(r.buf[r.pos:])
+	if n == 0 {
+		return 0, ErrBufferUnderflow
+	}
+	if n < 0 {
+		return 0, ErrVarintOverflow
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *reader) varint() (int64, error) {
+	v, n := stdbinary.Varint(r.buf[r.pos:])
+	if n == 0 {
+		return 0, ErrBufferUnderflow
+	}
+	if n < 0 {
+		return 0, ErrVarintOverflow
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *reader) float() (float64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(stdbinary.LittleEndian.Uint64(b)), nil
+}
+
+// count reads an element count and checks it against the bytes left, given
+// that every element occupies at least minBits bits. It keeps a corrupt count
+// from driving a large allocation.
+func (r *reader) count(minBits int) (int, error) {
+	n, err := r.uvarint()
+	if err != nil {
+		return 0, err
+	}
+	if err := r.checkCount(n, minBits); err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// checkCount checks that n elements of at least minBits bits each fit in the
+// bytes left.
+func (r *reader) checkCount(n uint64, minBits int) error {
+	if minBits > 0 && n > uint64(r.remaining())*8/uint64(minBits) {
+		return ErrBufferUnderflow.WithMessagef("count %d exceeds the remaining %d bytes", n, r.remaining())
+	}
+	return nil
+}
+
+func (r *reader) bytes() ([]byte, error) {
+	n, err := r.count(8)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := r.next(n)
+	if r.alias {
+		return b, nil
+	}
+	return slices.Clone(b), nil
+}
+
+func (r *reader) string() (string, error) {
+	n, err := r.count(8)
+	if err != nil {
+		return "", err
+	}
+	b, _ := r.next(n)
+	if n == 0 {
+		return "", nil
+	}
+	if r.alias {
+		return unsafe.String(unsafe.SliceData(b), n), nil
+	}
+	return string(b), nil
+}
+
+func (r *reader) bool() (bool, error) {
+	b, err := r.byte()
+	if err != nil {
+		return false, err
+	}
+	switch b {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	}
+	return false, ErrTypeMismatch.WithMessagef("invalid boolean byte 0x%02x", b)
+}
+
+func (r *reader) enter() error {
+	r.depth++
+	if r.depth > maxValueDepth {
+		return ErrLimitExceeded.WithMessagef("values nest deeper than %d levels", maxValueDepth)
+	}
+	return nil
+}
+
+func (r *reader) leave() {
+	r.depth--
+}
+
+// --- Append helpers ---
+
+func appendFloat(b []byte, f float64) []byte {
+	return stdbinary.LittleEndian.AppendUint64(b, math.Float64bits(f))
+}
+
+func appendString(b []byte, s string) []byte {
+	b = stdbinary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendBytes(b []byte, v []byte) []byte {
+	b = stdbinary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, 1)
+	}
+	return append(b, 0)
+}
+
+// appendPackedBools appends v as ceil(len(v)/8) bytes, LSB first.
+func appendPackedBools(b []byte, v []bool) []byte {
+	start := len(b)
+	b = append(b, make([]byte, (len(v)+7)/8)...)
+	for i, set := range v {
+		if set {
+			b[start+i/8] |= 1 << (i % 8)
+		}
+	}
+	return b
+}
+
+func readPackedBools(r *reader, n int) ([]bool, error) {
+	packed, err := r.next((n + 7) / 8)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]bool, n)
+	for i := range out {
+		out[i] = packed[i/8]&(1<<(i%8)) != 0
+	}
+	return out, nil
+}
+
+// --- Geometry ---
+
+// Geometry values are a list of rings, each a flat list of coordinates:
+// [ring_count]{[coordinate_count][float64...]}.
+func appendGeometry(b []byte, g [][]float64) []byte {
+	b = stdbinary.AppendUvarint(b, uint64(len(g)))
+	for _, ring := range g {
+		b = appendFloats(b, ring)
+	}
+	return b
+}
+
+func readGeometry(r *reader) ([][]float64, error) {
+	n, err := r.count(8)
+	if err != nil {
+		return nil, err
+	}
+	g := make([][]float64, n)
+	for i := range g {
+		if g[i], err = readFloats(r); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func appendFloats(b []byte, v []float64) []byte {
+	b = stdbinary.AppendUvarint(b, uint64(len(v)))
+	for _, f := range v {
+		b = appendFloat(b, f)
+	}
+	return b
+}
+
+func readFloats(r *reader) ([]float64, error) {
+	n, err := r.count(64)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float64, n)
+	for i := range out {
+		out[i], _ = r.float()
+	}
+	return out, nil
+}
+
+// --- Typed arrays ---
+
+func appendInts(b []byte, v []int64) []byte {
+	b = stdbinary.AppendUvarint(b, uint64(len(v)))
+	for _, n := range v {
+		b = stdbinary.AppendVarint(b, n)
+	}
+	return b
+}
+
+func readInts(r *reader) ([]int64, error) {
+	n, err := r.count(8)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]int64, n)
+	for i := range out {
+		if out[i], err = r.varint(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func appendStrings(b []byte, v []string) []byte {
+	b = stdbinary.AppendUvarint(b, uint64(len(v)))
+	for _, s := range v {
+		b = appendString(b, s)
+	}
+	return b
+}
+
+func readStrings(r *reader) ([]string, error) {
+	n, err := r.count(8)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, n)
+	for i := range out {
+		if out[i], err = r.string(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func appendBools(b []byte, v []bool) []byte {
+	b = stdbinary.AppendUvarint(b, uint64(len(v)))
+	return appendPackedBools(b, v)
+}
+
+func readBools(r *reader) ([]bool, error) {
+	n, err := r.count(1)
+	if err != nil {
+		return nil, err
+	}
+	return readPackedBools(r, n)
+}
+
+func appendBytesArray(b []byte, v [][]byte) []byte {
+	b = stdbinary.AppendUvarint(b, uint64(len(v)))
+	for _, e := range v {
+		b = appendBytes(b, e)
+	}
+	return b
+}
+
+func readBytesArray(r *reader) ([][]byte, error) {
+	n, err := r.count(8)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]byte, n)
+	for i := range out {
+		if out[i], err = r.bytes(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func appendGeometries(b []byte, v [][][]float64) []byte {
+	b = stdbinary.AppendUvarint(b, uint64(len(v)))
+	for _, g := range v {
+		b = appendGeometry(b, g)
+	}
+	return b
+}
+
+func readGeometries(r *reader) ([][][]float64, error) {
+	n, err := r.count(8)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][][]float64, n)
+	for i := range out {
+		if out[i], err = readGeometry(r); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// --- Dynamic values ---
+
+// Dynamic values (TypeUnknown fields, record entries and TypeArrayUnknown
+// elements) are written as [tag][value], where tag is the DataType whose
+// encoding follows. Tag TypeUnknown stands for nil, and TypeArrayObject is
+// never used: objects without a schema travel as records.
+
+// appendAny appends v with its type tag.
+func appendAny(b []byte, v any, depth int) ([]byte, error) {
+	if depth > maxValueDepth {
+		return nil, ErrLimitExceeded.WithMessagef("values nest deeper than %d levels", maxValueDepth)
+	}
+	switch x := v.(type) {
+	case nil:
+		return append(b, byte(container.TypeUnknown)), nil
+	case bool:
+		return appendBool(append(b, byte(container.TypeBool)), x), nil
+	case int64:
+		return stdbinary.AppendVarint(append(b, byte(container.TypeInt)), x), nil
+	case int:
+		return stdbinary.AppendVarint(append(b, byte(container.TypeInt)), int64(x)), nil
+	case int32:
+		return stdbinary.AppendVarint(append(b, byte(container.TypeInt)), int64(x)), nil
+	case float64:
+		return appendFloat(append(b, byte(container.TypeFloat)), x), nil
+	case float32:
+		return appendFloat(append(b, byte(container.TypeFloat)), float64(x)), nil
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			return stdbinary.AppendVarint(append(b, byte(container.TypeInt)), n), nil
+		}
+		f, err := x.Float64()
+		if err != nil {
+			return nil, ErrUnsupportedValue.WithMessagef("invalid number %q", string(x))
+		}
+		return appendFloat(append(b, byte(container.TypeFloat)), f), nil
+	case string:
+		return appendString(append(b, byte(container.TypeString)), x), nil
+	case []byte:
+		return appendBytes(append(b, byte(container.TypeBytes)), x), nil
+	case [][]float64:
+		return appendGeometry(append(b, byte(container.TypeGeometry)), x), nil
+	case map[string]any:
+		return appendRecord(append(b, byte(container.TypeRecord)), x, depth+1)
+	case []any:
+		b = stdbinary.AppendUvarint(append(b, byte(container.TypeArrayUnknown)), uint64(len(x)))
+		var err error
+		for _, e := range x {
+			if b, err = appendAny(b, e, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case []map[string]any:
+		b = stdbinary.AppendUvarint(append(b, byte(container.TypeArrayUnknown)), uint64(len(x)))
+		var err error
+		for _, e := range x {
+			if b, err = appendAny(b, e, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case []int64:
+		return appendInts(append(b, byte(container.TypeArrayInt)), x), nil
+	case []float64:
+		return appendFloats(append(b, byte(container.TypeArrayFloat)), x), nil
+	case []string:
+		return appendStrings(append(b, byte(container.TypeArrayString)), x), nil
+	case []bool:
+		return appendBools(append(b, byte(container.TypeArrayBool)), x), nil
+	case [][]byte:
+		return appendBytesArray(append(b, byte(container.TypeArrayBytes)), x), nil
+	case [][][]float64:
+		return appendGeometries(append(b, byte(container.TypeArrayGeometry)), x), nil
+	case encoding.TextMarshaler:
+		text, err := x.MarshalText()
+		if err != nil {
+			return nil, ErrUnsupportedValue.WithCause(err)
+		}
+		return appendBytes(append(b, byte(container.TypeString)), text), nil
+	}
+	return appendReflected(b, v, depth)
+}
+
+// appendAny's fallback for the remaining integer kinds, string-keyed maps,
+// slices and pointers.
+func appendReflected(b []byte, v any, depth int) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return stdbinary.AppendVarint(append(b, byte(container.TypeInt)), rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if u > math.MaxInt64 {
+			return nil, ErrUnsupportedValue.WithMessagef("unsigned value %d overflows int64", u)
+		}
+		return stdbinary.AppendVarint(append(b, byte(container.TypeInt)), int64(u)), nil
+	case reflect.Float32, reflect.Float64:
+		return appendFloat(append(b, byte(container.TypeFloat)), rv.Float()), nil
+	case reflect.String:
+		return appendString(append(b, byte(container.TypeString)), rv.String()), nil
+	case reflect.Bool:
+		return appendBool(append(b, byte(container.TypeBool)), rv.Bool()), nil
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return append(b, byte(container.TypeUnknown)), nil
+		}
+		return appendAny(b, rv.Elem().Interface(), depth+1)
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		m := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return appendRecord(append(b, byte(container.TypeRecord)), m, depth+1)
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return append(b, byte(container.TypeUnknown)), nil
+		}
+		b = stdbinary.AppendUvarint(append(b, byte(container.TypeArrayUnknown)), uint64(rv.Len()))
+		var err error
+		for i := 0; i < rv.Len(); i++ {
+			if b, err = appendAny(b, rv.Index(i).Interface(), depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, ErrUnsupportedValue.WithMessagef("cannot encode a value of type %T", v)
+}
+
+// appendRecord appends a map as [count]{[key][tagged value]}, keys sorted so
+// that equal maps encode identically.
+func appendRecord(b []byte, m map[string]any, depth int) ([]byte, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	b = stdbinary.AppendUvarint(b, uint64(len(keys)))
+	var err error
+	for _, k := range keys {
+		b = appendString(b, k)
+		if b, err = appendAny(b, m[k], depth); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func readRecord(r *reader) (map[string]any, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	n, err := r.count(16)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]any, n)
+	for range n {
+		k, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+		if m[k], err = readAny(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func readAnyArray(r *reader) ([]any, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	n, err := r.count(8)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, n)
+	for i := range out {
+		if out[i], err = readAny(r); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// readAny reads a tagged dynamic value.
+func readAny(r *reader) (any, error) {
+	tag, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	switch container.DataType(tag) {
+	case container.TypeUnknown:
+		return nil, nil
+	case container.TypeInt:
+		return r.varint()
+	case container.TypeFloat:
+		return r.float()
+	case container.TypeString:
+		return r.string()
+	case container.TypeBool:
+		return r.bool()
+	case container.TypeBytes:
+		return r.bytes()
+	case container.TypeGeometry:
+		return readGeometry(r)
+	case container.TypeRecord:
+		return readRecord(r)
+	case container.TypeArrayUnknown:
+		return readAnyArray(r)
+	case container.TypeArrayInt:
+		return readInts(r)
+	case container.TypeArrayFloat:
+		return readFloats(r)
+	case container.TypeArrayString:
+		return readStrings(r)
+	case container.TypeArrayBool:
+		return readBools(r)
+	case container.TypeArrayBytes:
+		return readBytesArray(r)
+	case container.TypeArrayGeometry:
+		return readGeometries(r)
+	}
+	return nil, ErrTypeMismatch.WithMessagef("invalid value tag %d", tag)
+}
@@ -115,7 +115,7 @@ func DecodeJSONField(cs *definition.CompiledSchema, data []byte, doc *container.
 	}
 	fd := cs.Descriptors[abs]
 	p := newJSONParser(data)
-	path := definition.ResolvedPath{definition.NewResolvedStep(0, uint8(fieldIdx))}
+	path := definition.ResolvedPath{definition.NewResolvedStep(0, uint16(fieldIdx))}
 	if err := parseValueInto(p, cs, doc, fd, path, pool, false); err != nil {
 		return fmt.Errorf("json: decode field %q: %w", fieldName, err)
 	}
@@ -143,19 +143,19 @@ func findRootField(cs *definition.CompiledSchema, name string) (abs int, fieldId
 
 // decodeObject parses one JSON object against one schema slot. Absent
 // required fields are validated (full-document decode).
-func decodeObject(cs *definition.CompiledSchema, doc *container.DataContainer, schemaIdx uint8, path definition.ResolvedPath, p *jsonParser, pool *container.Pool) error {
+func decodeObject(cs *definition.CompiledSchema, doc *container.DataContainer, schemaIdx uint16, path definition.ResolvedPath, p *jsonParser, pool *container.Pool) error {
 	return decodeObjectInto(cs, doc, schemaIdx, path, p, pool, true)
 }
 
 // decodeObjectLenient is decodeObject without required-field validation. It is
 // used when materializing stored fragments (row read-back) that may be partial
 // or structurally absent.
-func decodeObjectLenient(cs *definition.CompiledSchema, doc *container.DataContainer, schemaIdx uint8, path definition.ResolvedPath, p *jsonParser, pool *container.Pool) error {
+func decodeObjectLenient(cs *definition.CompiledSchema, doc *container.DataContainer, schemaIdx uint16, path definition.ResolvedPath, p *jsonParser, pool *container.Pool) error {
 	return decodeObjectInto(cs, doc, schemaIdx, path, p, pool, false)
 }
 
 // decodeObjectInto parses one JSON object against one schema slot.
-func decodeObjectInto(cs *definition.CompiledSchema, doc *container.DataContainer, schemaIdx uint8, path definition.ResolvedPath, p *jsonParser, pool *container.Pool, validate bool) error {
+func decodeObjectInto(cs *definition.CompiledSchema, doc *container.DataContainer, schemaIdx uint16, path definition.ResolvedPath, p *jsonParser, pool *container.Pool, validate bool) error {
 	if int(schemaIdx) >= len(cs.Schemas) {
 		return fmt.Errorf("json: schema slot %d out of range", schemaIdx)
 	}
@@ -193,7 +193,7 @@ func decodeObjectInto(cs *definition.CompiledSchema, doc *container.DataContaine
 
 				abs := int(slot.FieldStart) + j
 				fd := cs.Descriptors[abs]
-				step := definition.NewResolvedStep(schemaIdx, uint8(j))
+				step := definition.NewResolvedStep(schemaIdx, uint16(j))
 				fieldPath := appendStep(path, step)
 				if err := parseValueInto(p, cs, doc, fd, fieldPath, pool, validate); err != nil {
 					return err
@@ -342,11 +342,11 @@ func parseValueInto(p *jsonParser, cs *definition.CompiledSchema, doc *container
 	return parseLeaf(p, cs, doc, fd, path)
 }
 
-func parseArrayObject(p *jsonParser, cs *definition.CompiledSchema, doc *container.DataContainer, fd definition.FieldDescriptor, path definition.ResolvedPath, childIdx uint8, pool *container.Pool) error {
+func parseArrayObject(p *jsonParser, cs *definition.CompiledSchema, doc *container.DataContainer, fd definition.FieldDescriptor, path definition.ResolvedPath, childIdx uint16, pool *container.Pool) error {
 	return parseArrayObjectInto(p, cs, doc, fd, path, childIdx, pool, true)
 }
 
-func parseArrayObjectInto(p *jsonParser, cs *definition.CompiledSchema, doc *container.DataContainer, fd definition.FieldDescriptor, path definition.ResolvedPath, childIdx uint8, pool *container.Pool, validate bool) error {
+func parseArrayObjectInto(p *jsonParser, cs *definition.CompiledSchema, doc *container.DataContainer, fd definition.FieldDescriptor, path definition.ResolvedPath, childIdx uint16, pool *container.Pool, validate bool) error {
 	// Pre-allocate slice capacity to prevent growslice allocations
 	children := make([]*container.DataContainer, 0, 8)
 	err := p.parseArray(func() error {
@@ -486,15 +486,15 @@ func computeLeafKey(cs *definition.CompiledSchema, fd definition.FieldDescriptor
 	if addr == 0 {
 		return internalKey(fd), nil
 	}
-	dp, err := container.NewDataPoint(fd.DataType(), int32(addr))
+	key, err := fd.AddressKey(addr)
 	if err != nil {
 		return 0, fmt.Errorf("json: build data point: %w", err)
 	}
-	return container.NewDataContainerKey(dp, uint32(fd)), nil
+	return key, nil
 }
 
 func internalKey(fd definition.FieldDescriptor) container.DataContainerKey {
-	return container.NewDataContainerKey(container.DataPoint(fd.DataPoint()), uint32(fd))
+	return fd.InternalKey()
 }
 
 func setByType(doc *container.DataContainer, dt container.DataType, key container.DataContainerKey, value any) error {
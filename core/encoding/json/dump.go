@@ -82,8 +82,8 @@ func dump(cs *definition.CompiledSchema, doc *container.DataContainer) (map[stri
 // (records, array-of-object fields, unions) carry no flat address, so they fall
 // back to their descriptor's local name.
 func nameFor(cs *definition.CompiledSchema, key container.DataContainerKey) string {
-	if name, ok := cs.PathNameForAddress(uint32(key.DataPoint().ID())); ok {
+	if name, ok := cs.PathNameForAddress(cs.KeyAddress(key)); ok {
 		return name
 	}
-	return cs.FieldPath(definition.FieldDescriptor(key.Descriptor()))
+	return cs.FieldPath(cs.KeyDescriptor(key))
 }
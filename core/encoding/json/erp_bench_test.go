@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/asaidimu/go-anansi/v8/core/data/container"
@@ -170,6 +171,49 @@ func BenchmarkERP_Decode_Container(b *testing.B) {
 	}
 }
 
+// erpExtendedSchemaJSON is erpSchemaJSON with the customer schema widened past
+// the compact descriptor layout's 128 fields, so it links with extended
+// descriptors. The extra fields stay absent from the payloads, which keeps the
+// decoded documents identical to the compact benchmark's.
+func erpExtendedSchemaJSON() string {
+	extra := make([]string, 0, 130)
+	for i := 0; i < 130; i++ {
+		extra = append(extra, fmt.Sprintf(`"attr_%d": { "name": "attr_%d", "type": "string" }`, i, i))
+	}
+	const tier = `"tier":  { "name": "tier",  "type": "string" }`
+	return strings.Replace(erpSchemaJSON, tier, tier+",\n"+strings.Join(extra, ",\n"), 1)
+}
+
+// BenchmarkERP_Decode_Extended is BenchmarkERP_Decode_Container against the
+// extended-layout schema: the difference is the extended layout's cost.
+func BenchmarkERP_Decode_Extended(b *testing.B) {
+	cs, err := compileSchema(b, []byte(erpExtendedSchemaJSON()))
+	if err != nil {
+		b.Fatalf("compile ERP schema: %v", err)
+	}
+	if !cs.Extended() {
+		b.Fatal("widened ERP schema did not link with the extended layout")
+	}
+	payloads := erpPayloads(b)
+	for _, name := range []string{"small", "medium", "large"} {
+		payload := payloads[name]
+		b.Run(name, func(b *testing.B) {
+			pool := container.NewPool()
+			primePoolWith(b, cs, pool, payload)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				doc := pool.Get()
+				if err := DecodeJSONInto(cs, payload, doc, pool); err != nil {
+					pool.Put(doc)
+					b.Fatal(err)
+				}
+				pool.Put(doc)
+			}
+		})
+	}
+}
+
 // BenchmarkERP_Dump_Map isolates materialization (container -> map[string]any)
 // per order size: the decoded document is reused, the response map is rebuilt
 // each iteration.
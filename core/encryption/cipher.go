@@ -0,0 +1,105 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/query"
+)
+
+// ciphertextPrefix starts every encrypted value. The full form is
+// "enc:v1:<mode>:<key id>:<base64url(nonce || sealed)>", where mode is "d"
+// (deterministic) or "r" (randomized).
+const ciphertextPrefix = "enc:v1:"
+
+var encoding = base64.RawURLEncoding
+
+// IsCiphertext reports whether s is a value produced by this package.
+func IsCiphertext(s string) bool {
+	return strings.HasPrefix(s, ciphertextPrefix)
+}
+
+// subkey derives an independent key from a data encryption key so the same
+// material is never used for both AES-GCM and the deterministic nonce MAC.
+func subkey(key *Key, purpose string) []byte {
+	mac := hmac.New(sha256.New, key.Material)
+	mac.Write([]byte("anansi/encryption/" + purpose))
+	return mac.Sum(nil)
+}
+
+func newAEAD(key *Key) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(subkey(key, "aead"))
+	if err != nil {
+		return nil, ErrInvalidKey.WithCause(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, ErrInvalidKey.WithCause(err)
+	}
+	return aead, nil
+}
+
+// encryptValue seals plaintext for field under key. The field name is bound
+// as associated data, so a ciphertext copied into another column does not
+// decrypt. Deterministic mode derives the nonce from the plaintext (SIV
+// style): equal inputs under the same key yield equal outputs.
+func encryptValue(key *Key, mode, field, plaintext string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	tag := "r"
+	if mode == query.EncryptionDeterministic {
+		tag = "d"
+		mac := hmac.New(sha256.New, subkey(key, "siv"))
+		mac.Write([]byte(field))
+		mac.Write([]byte{0})
+		mac.Write([]byte(plaintext))
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", ErrInvalidKey.WithMessage("failed to generate nonce").WithCause(err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(field))
+	return ciphertextPrefix + tag + ":" + key.ID + ":" + encoding.EncodeToString(sealed), nil
+}
+
+// decryptValue opens a value produced by encryptValue, looking its key up by
+// the ID embedded in it.
+func decryptValue(ctx context.Context, keys KeyProvider, field, value string) (string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(value, ciphertextPrefix), ":", 3)
+	if len(parts) != 3 || (parts[0] != "d" && parts[0] != "r") {
+		return "", ErrMalformedCiphertext.WithPath(field)
+	}
+	sealed, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrMalformedCiphertext.WithPath(field).WithCause(err)
+	}
+
+	key, err := keys.Key(ctx, parts[1])
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", ErrMalformedCiphertext.WithPath(field)
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(field))
+	if err != nil {
+		return "", ErrDecryptionFailed.WithPath(field).WithCause(err)
+	}
+	return string(plaintext), nil
+}
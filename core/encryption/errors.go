@@ -0,0 +1,18 @@
+package encryption
+
+import (
+	"github.com/asaidimu/go-anansi/v8/core/common"
+)
+
+// Errors returned by the encryption layer.
+var (
+	ErrInvalidKey             = common.NewSystemError("ERR_ENCRYPTION_INVALID_KEY", "encryption key is invalid")
+	ErrKeyNotFound            = common.NewSystemError("ERR_ENCRYPTION_KEY_NOT_FOUND", "encryption key not found")
+	ErrNoActiveKey            = common.NewSystemError("ERR_ENCRYPTION_NO_ACTIVE_KEY", "no active encryption key")
+	ErrMalformedCiphertext    = common.NewSystemError("ERR_ENCRYPTION_MALFORMED_CIPHERTEXT", "malformed ciphertext")
+	ErrDecryptionFailed       = common.NewSystemError("ERR_ENCRYPTION_DECRYPTION_FAILED", "failed to decrypt value")
+	ErrUnsupportedField       = common.NewSystemError("ERR_ENCRYPTION_UNSUPPORTED_FIELD", "only string fields can be encrypted")
+	ErrUnsupportedValue       = common.NewSystemError("ERR_ENCRYPTION_UNSUPPORTED_VALUE", "encrypted fields only accept string values")
+	ErrUnsupportedPredicate   = common.NewSystemError("ERR_ENCRYPTION_UNSUPPORTED_PREDICATE", "predicate cannot be evaluated on an encrypted field by the database")
+	ErrComputedEncryptedField = common.NewSystemError("ERR_ENCRYPTION_COMPUTED_FIELD", "encrypted fields cannot be computed by the database")
+)
@@ -0,0 +1,459 @@
+// Package encryption provides field-level encryption at rest.
+//
+// Fields opt in through their schema Metadata under query.EncryptionMetadataKey:
+//
+//	"ssn":   {Type: "string", Metadata: {"encryption": "randomized"}}
+//	"email": {Type: "string", Metadata: {"encryption": "deterministic"}}
+//
+// Wrapping a DatabaseInteractor with NewInteractor encrypts those fields before
+// they reach the database and decrypts them in every result. Deterministic
+// fields keep equality filters working, and unique indexes too until the key
+// is rotated (see below); every other predicate, sort or aggregate on an
+// encrypted field is evaluated in memory by the query engine after decryption.
+//
+// Keys come from a KeyProvider: a StaticKeyring for keys held in
+// configuration, or an EnvelopeKeyProvider whose data keys are wrapped by a
+// KMS and rotated with Rotate. Every ciphertext names its key, so values
+// written before a rotation remain readable and equality filters match them
+// under every key. A unique index, however, only compares ciphertexts: once
+// the active key changes, a value written before the rotation and the same
+// value written after it no longer collide, so uniqueness on a deterministic
+// field holds only among values encrypted under the same key. Re-encrypt
+// existing rows under the new key (or enforce uniqueness in the application)
+// before relying on such an index after a rotation.
+package encryption
+
+import (
+	"context"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/document"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
+)
+
+// Interactor wraps a query.DatabaseInteractor, encrypting the fields a schema
+// marks as encrypted on the way in and decrypting them on the way out. Every
+// other call is forwarded unchanged.
+type Interactor struct {
+	query.DatabaseInteractor
+	keys KeyProvider
+}
+
+var (
+	_ query.DatabaseInteractor    = (*Interactor)(nil)
+	_ query.DocumentPoolRegistrar = (*Interactor)(nil)
+	_ query.TelemetrySource       = (*Interactor)(nil)
+)
+
+// NewInteractor wraps inner so that encrypted fields are stored as
+// ciphertext under keys from the given provider.
+func NewInteractor(inner query.DatabaseInteractor, keys KeyProvider) *Interactor {
+	return &Interactor{DatabaseInteractor: inner, keys: keys}
+}
+
+// encryptedFields returns the encryption mode of every encrypted field in sc,
+// keyed by field name. Only string fields may be encrypted.
+func encryptedFields(sc *definition.Schema) (map[string]string, error) {
+	if sc == nil {
+		return nil, nil
+	}
+	var fields map[string]string
+	for _, field := range sc.Fields {
+		mode := query.FieldEncryptionMode(field)
+		if mode == "" {
+			continue
+		}
+		if field.Type != definition.FieldTypeString {
+			return nil, ErrUnsupportedField.WithPath(string(field.Name)).
+				WithMessagef("field %q has type %s; only string fields can be encrypted", field.Name, field.Type)
+		}
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[string(field.Name)] = mode
+	}
+	return fields, nil
+}
+
+// encrypt seals value for field. Nil passes through so nullable fields stay
+// null; anything other than a string is rejected.
+func (i *Interactor) encrypt(key *Key, mode, field string, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	plaintext, ok := value.(string)
+	if !ok {
+		return nil, ErrUnsupportedValue.WithPath(field).WithMessagef("field %q is encrypted and only accepts strings, got %T", field, value)
+	}
+	return encryptValue(key, mode, field, plaintext)
+}
+
+// decrypt opens value when it is ciphertext. Plaintext values written before
+// the field was marked encrypted are returned as stored.
+func (i *Interactor) decrypt(ctx context.Context, field string, value any) (any, bool, error) {
+	s, ok := value.(string)
+	if !ok || !IsCiphertext(s) {
+		return value, false, nil
+	}
+	plaintext, err := decryptValue(ctx, i.keys, field, s)
+	if err != nil {
+		return nil, false, err
+	}
+	return plaintext, true, nil
+}
+
+// sealRecord returns a copy of record with its encrypted fields sealed.
+func (i *Interactor) sealRecord(ctx context.Context, key *Key, fields map[string]string, record data.Documenter) (data.Documenter, error) {
+	sealed := record.Clone()
+	for field, mode := range fields {
+		if !sealed.HasKey(field) {
+			continue
+		}
+		value, err := sealed.Get(field)
+		if err != nil {
+			return nil, err
+		}
+		encrypted, err := i.encrypt(key, mode, field, value)
+		if err != nil {
+			return nil, err
+		}
+		if err := sealed.Set(field, encrypted); err != nil {
+			return nil, err
+		}
+	}
+	return sealed, nil
+}
+
+// openDocuments decrypts the encrypted fields of docs in place.
+func (i *Interactor) openDocuments(ctx context.Context, fields map[string]string, docs []*document.Document) error {
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for field := range fields {
+			value, err := doc.Get(field)
+			if err != nil {
+				continue
+			}
+			plaintext, changed, err := i.decrypt(ctx, field, value)
+			if err != nil {
+				return err
+			}
+			if changed {
+				if err := doc.Set(field, plaintext); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sealFilter rewrites the conditions on encrypted fields so the database can
+// evaluate them against ciphertext. Equality-family operators on
+// deterministic fields become membership tests over the operand encrypted
+// under every known key; any other predicate on an encrypted field is an
+// error, since the query engine keeps those in memory.
+func (i *Interactor) sealFilter(ctx context.Context, fields map[string]string, qf *query.QueryFilter) (*query.QueryFilter, error) {
+	if qf == nil || len(fields) == 0 {
+		return qf, nil
+	}
+	var keys []*Key
+	return i.sealFilterWith(ctx, fields, qf, &keys)
+}
+
+func (i *Interactor) sealFilterWith(ctx context.Context, fields map[string]string, qf *query.QueryFilter, keys *[]*Key) (*query.QueryFilter, error) {
+	if qf.Group != nil {
+		group := *qf.Group
+		group.Conditions = make([]query.QueryFilter, len(qf.Group.Conditions))
+		for n := range qf.Group.Conditions {
+			sealed, err := i.sealFilterWith(ctx, fields, &qf.Group.Conditions[n], keys)
+			if err != nil {
+				return nil, err
+			}
+			group.Conditions[n] = *sealed
+		}
+		return &query.QueryFilter{Group: &group}, nil
+	}
+
+	cond := qf.Condition
+	if cond == nil {
+		return qf, nil
+	}
+	if cond.Value.FieldRefVal != nil {
+		if _, ok := fields[cond.Value.FieldRefVal.Field]; ok {
+			return nil, ErrUnsupportedPredicate.WithPath(cond.Value.FieldRefVal.Field)
+		}
+	}
+	mode, ok := fields[cond.Field]
+	if !ok || cond.Operator == query.ComparisonOperatorExists || cond.Operator == query.ComparisonOperatorNotExists {
+		return qf, nil
+	}
+
+	var operator query.ComparisonOperator
+	switch cond.Operator {
+	case query.ComparisonOperatorEq, query.ComparisonOperatorIn:
+		operator = query.ComparisonOperatorIn
+	case query.ComparisonOperatorNeq, query.ComparisonOperatorNin:
+		operator = query.ComparisonOperatorNin
+	}
+	if mode != query.EncryptionDeterministic || operator == "" || cond.Value.FieldRefVal != nil {
+		return nil, ErrUnsupportedPredicate.WithPath(cond.Field).
+			WithMessagef("operator %q cannot be evaluated on encrypted field %q by the database", cond.Operator, cond.Field)
+	}
+
+	if *keys == nil {
+		all, err := i.keys.Keys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		*keys = all
+	}
+
+	operands := []query.FilterValue{cond.Value}
+	if cond.Value.ArrayVal != nil {
+		operands = cond.Value.ArrayVal
+	}
+	var candidates []query.FilterValue
+	for _, operand := range operands {
+		if operand.StringVal == nil {
+			return nil, ErrUnsupportedValue.WithPath(cond.Field)
+		}
+		for _, key := range *keys {
+			sealed, err := encryptValue(key, mode, cond.Field, *operand.StringVal)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, query.NewFilterValue(sealed))
+		}
+	}
+
+	return &query.QueryFilter{Condition: &query.FilterCondition{
+		Field:    cond.Field,
+		Operator: operator,
+		Value:    query.FilterValue{ArrayVal: candidates},
+	}}, nil
+}
+
+func (i *Interactor) SelectDocuments(ctx context.Context, sc *definition.Schema, dsl *query.Query) ([]*document.Document, int64, error) {
+	fields, err := encryptedFields(sc)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(fields) == 0 {
+		return i.DatabaseInteractor.SelectDocuments(ctx, sc, dsl)
+	}
+
+	sealed := dsl
+	if dsl != nil && dsl.Filters != nil {
+		copied := *dsl
+		if copied.Filters, err = i.sealFilter(ctx, fields, dsl.Filters); err != nil {
+			return nil, 0, err
+		}
+		sealed = &copied
+	}
+
+	docs, total, err := i.DatabaseInteractor.SelectDocuments(ctx, sc, sealed)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := i.openDocuments(ctx, fields, docs); err != nil {
+		return nil, 0, err
+	}
+	return docs, total, nil
+}
+
+func (i *Interactor) SelectStream(ctx context.Context, sc *definition.Schema, dsl *query.Query) (<-chan map[string]any, <-chan error, error) {
+	fields, err := encryptedFields(sc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		return i.DatabaseInteractor.SelectStream(ctx, sc, dsl)
+	}
+
+	sealed := dsl
+	if dsl != nil && dsl.Filters != nil {
+		copied := *dsl
+		if copied.Filters, err = i.sealFilter(ctx, fields, dsl.Filters); err != nil {
+			return nil, nil, err
+		}
+		sealed = &copied
+	}
+
+	rows, errs, err := i.DatabaseInteractor.SelectStream(ctx, sc, sealed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan map[string]any)
+	outErrs := make(chan error, 1)
+	go func() {
+		defer close(out)
+		defer close(outErrs)
+		for row := range rows {
+			for field := range fields {
+				value, ok := row[field]
+				if !ok {
+					continue
+				}
+				plaintext, _, err := i.decrypt(ctx, field, value)
+				if err != nil {
+					outErrs <- err
+					for range rows {
+					}
+					return
+				}
+				row[field] = plaintext
+			}
+			select {
+			case out <- row:
+			case <-ctx.Done():
+				outErrs <- ctx.Err()
+				return
+			}
+		}
+		if err, ok := <-errs; ok && err != nil {
+			outErrs <- err
+		}
+	}()
+	return out, outErrs, nil
+}
+
+func (i *Interactor) InsertDocuments(ctx context.Context, sc *definition.Schema, records []data.Documenter) ([]*document.Document, error) {
+	fields, err := encryptedFields(sc)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return i.DatabaseInteractor.InsertDocuments(ctx, sc, records)
+	}
+
+	key, err := i.keys.ActiveKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sealed := make([]data.Documenter, len(records))
+	for n, record := range records {
+		if record == nil {
+			continue
+		}
+		if sealed[n], err = i.sealRecord(ctx, key, fields, record); err != nil {
+			return nil, err
+		}
+	}
+
+	docs, err := i.DatabaseInteractor.InsertDocuments(ctx, sc, sealed)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.openDocuments(ctx, fields, docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (i *Interactor) UpdateDocuments(ctx context.Context, sc *definition.Schema, updates data.Documenter, computedUpdates map[string]query.Query, operators []query.UpdateOperator, filters *query.QueryFilter, returning bool) ([]*document.Document, int64, error) {
+	fields, err := encryptedFields(sc)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(fields) == 0 {
+		return i.DatabaseInteractor.UpdateDocuments(ctx, sc, updates, computedUpdates, operators, filters, returning)
+	}
+
+	for field := range computedUpdates {
+		if _, ok := fields[field]; ok {
+			return nil, 0, ErrComputedEncryptedField.WithPath(field)
+		}
+	}
+	// Clearing a field is the only operator that does not read its value.
+	for _, op := range operators {
+		if _, ok := fields[strings.SplitN(op.Field, ".", 2)[0]]; ok && op.Type != query.UpdateOperatorUnset {
+			return nil, 0, ErrComputedEncryptedField.WithPath(op.Field)
+		}
+	}
+	if updates != nil {
+		key, err := i.keys.ActiveKey(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		if updates, err = i.sealRecord(ctx, key, fields, updates); err != nil {
+			return nil, 0, err
+		}
+	}
+	if filters, err = i.sealFilter(ctx, fields, filters); err != nil {
+		return nil, 0, err
+	}
+
+	docs, count, err := i.DatabaseInteractor.UpdateDocuments(ctx, sc, updates, computedUpdates, operators, filters, returning)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := i.openDocuments(ctx, fields, docs); err != nil {
+		return nil, 0, err
+	}
+	return docs, count, nil
+}
+
+func (i *Interactor) DeleteDocuments(ctx context.Context, sc *definition.Schema, filters *query.QueryFilter, unsafeDelete bool) (int64, error) {
+	fields, err := encryptedFields(sc)
+	if err != nil {
+		return 0, err
+	}
+	if filters, err = i.sealFilter(ctx, fields, filters); err != nil {
+		return 0, err
+	}
+	return i.DatabaseInteractor.DeleteDocuments(ctx, sc, filters, unsafeDelete)
+}
+
+// SchemaManager returns the interactor itself so collection DDL passes
+// through CreateCollection's validation.
+func (i *Interactor) SchemaManager() query.SchemaManager {
+	return i
+}
+
+// CreateCollection rejects schemas that mark non-string fields as encrypted
+// before any table is created.
+func (i *Interactor) CreateCollection(ctx context.Context, sc definition.Schema) error {
+	if _, err := encryptedFields(&sc); err != nil {
+		return err
+	}
+	return i.DatabaseInteractor.CreateCollection(ctx, sc)
+}
+
+// StartTransaction wraps the transactional interactor so writes inside the
+// transaction are encrypted too.
+func (i *Interactor) StartTransaction(ctx context.Context) (query.DatabaseInteractor, error) {
+	tx, err := i.DatabaseInteractor.StartTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewInteractor(tx, i.keys), nil
+}
+
+// RegisterDocumentPool forwards to the wrapped interactor when it keeps
+// schema-bound document pools.
+func (i *Interactor) RegisterDocumentPool(sc *definition.Schema, pool *document.DocumentPool) {
+	if registrar, ok := i.DatabaseInteractor.(query.DocumentPoolRegistrar); ok {
+		registrar.RegisterDocumentPool(sc, pool)
+	}
+}
+
+// SetTelemetrySink forwards to the wrapped interactor when it publishes
+// telemetry.
+func (i *Interactor) SetTelemetrySink(sink query.TelemetrySink) {
+	if source, ok := i.DatabaseInteractor.(query.TelemetrySource); ok {
+		source.SetTelemetrySink(sink)
+	}
+}
+
+// SetTelemetry forwards to the wrapped interactor when it is instrumented.
+func (i *Interactor) SetTelemetry(t telemetry.Telemetry) {
+	if instrumented, ok := i.DatabaseInteractor.(query.Instrumented); ok {
+		instrumented.SetTelemetry(t)
+	}
+}
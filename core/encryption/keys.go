@@ -0,0 +1,292 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KeySize is the size in bytes of every data encryption key (AES-256).
+const KeySize = 32
+
+// Key is a data encryption key. The ID is stored alongside every ciphertext so
+// values survive key rotation.
+type Key struct {
+	ID       string
+	Material []byte
+}
+
+func validateKey(id string, material []byte) error {
+	if id == "" || strings.Contains(id, ":") {
+		return ErrInvalidKey.WithMessagef("key id %q must be non-empty and must not contain ':'", id)
+	}
+	if len(material) != KeySize {
+		return ErrInvalidKey.WithMessagef("key %q must be %d bytes, got %d", id, KeySize, len(material))
+	}
+	return nil
+}
+
+// KeyProvider supplies the keys used to encrypt and decrypt field values.
+// Implementations must be safe for concurrent use.
+type KeyProvider interface {
+	// ActiveKey returns the key new values are encrypted with.
+	ActiveKey(ctx context.Context) (*Key, error)
+
+	// Key returns the key with the given ID, for decryption.
+	Key(ctx context.Context, id string) (*Key, error)
+
+	// Keys returns every key that may still protect stored values. Equality
+	// filters on deterministic fields match the value under each of them, so
+	// rows written before a rotation are still found.
+	Keys(ctx context.Context) ([]*Key, error)
+}
+
+// ---------------------------------------------------------------------
+//  Static keyring
+// ---------------------------------------------------------------------
+
+// StaticKeyring is a KeyProvider over a fixed set of keys, typically loaded
+// from configuration or a secret store at startup.
+type StaticKeyring struct {
+	active string
+	keys   map[string]*Key
+	order  []string
+}
+
+var _ KeyProvider = (*StaticKeyring)(nil)
+
+// NewStaticKeyring creates a keyring that encrypts with the key named active
+// and can decrypt with any key in keys.
+func NewStaticKeyring(active string, keys map[string][]byte) (*StaticKeyring, error) {
+	ring := &StaticKeyring{active: active, keys: make(map[string]*Key, len(keys))}
+	for id, material := range keys {
+		if err := validateKey(id, material); err != nil {
+			return nil, err
+		}
+		ring.keys[id] = &Key{ID: id, Material: append([]byte(nil), material...)}
+		ring.order = append(ring.order, id)
+	}
+	if _, ok := ring.keys[active]; !ok {
+		return nil, ErrNoActiveKey.WithMessagef("active key %q is not part of the keyring", active)
+	}
+	sort.Strings(ring.order)
+	return ring, nil
+}
+
+func (r *StaticKeyring) ActiveKey(context.Context) (*Key, error) {
+	return r.keys[r.active], nil
+}
+
+func (r *StaticKeyring) Key(_ context.Context, id string) (*Key, error) {
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, ErrKeyNotFound.WithMessagef("key %q is not part of the keyring", id)
+	}
+	return key, nil
+}
+
+func (r *StaticKeyring) Keys(context.Context) ([]*Key, error) {
+	keys := make([]*Key, len(r.order))
+	for i, id := range r.order {
+		keys[i] = r.keys[id]
+	}
+	return keys, nil
+}
+
+// ---------------------------------------------------------------------
+//  Envelope keys
+// ---------------------------------------------------------------------
+
+// KeyWrapper protects data encryption keys with a key encryption key that
+// never leaves it — a KMS, an HSM, or AESKeyWrapper for local deployments.
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// WrappedKey is a data encryption key in its wrapped, storable form.
+type WrappedKey struct {
+	ID      string    `json:"id"`
+	Wrapped []byte    `json:"wrapped"`
+	Created time.Time `json:"created"`
+}
+
+// EnvelopeKeyProvider is a KeyProvider whose data encryption keys are stored
+// wrapped by a KeyWrapper and unwrapped on first use. The most recently
+// created key is active; Rotate adds a new one. Callers persist
+// WrappedKeys() after rotating and pass them back on the next start.
+type EnvelopeKeyProvider struct {
+	mu       sync.RWMutex
+	wrapper  KeyWrapper
+	wrapped  []WrappedKey
+	unwraped map[string]*Key
+}
+
+var _ KeyProvider = (*EnvelopeKeyProvider)(nil)
+
+// NewEnvelopeKeyProvider creates an envelope provider over previously stored
+// wrapped keys. When keys is empty a first key is generated.
+func NewEnvelopeKeyProvider(ctx context.Context, wrapper KeyWrapper, keys []WrappedKey) (*EnvelopeKeyProvider, error) {
+	if wrapper == nil {
+		return nil, ErrInvalidKey.WithMessage("envelope key provider requires a key wrapper")
+	}
+	p := &EnvelopeKeyProvider{
+		wrapper:  wrapper,
+		wrapped:  append([]WrappedKey(nil), keys...),
+		unwraped: make(map[string]*Key),
+	}
+	sort.SliceStable(p.wrapped, func(i, j int) bool { return p.wrapped[i].Created.Before(p.wrapped[j].Created) })
+	if len(p.wrapped) == 0 {
+		if _, err := p.Rotate(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Rotate generates a new data encryption key and makes it active. Existing
+// keys remain available for decryption. Deterministic values written after the
+// rotation encrypt differently from those written before it, so a unique index
+// on a deterministic field no longer rejects duplicates across the two until
+// the older rows are re-encrypted.
+func (p *EnvelopeKeyProvider) Rotate(ctx context.Context) (WrappedKey, error) {
+	material := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, material); err != nil {
+		return WrappedKey{}, ErrInvalidKey.WithMessage("failed to generate key").WithCause(err)
+	}
+	wrapped, err := p.wrapper.WrapKey(ctx, material)
+	if err != nil {
+		return WrappedKey{}, ErrInvalidKey.WithMessage("failed to wrap key").WithCause(err)
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return WrappedKey{}, ErrInvalidKey.WithMessage("failed to generate key id").WithCause(err)
+	}
+
+	key := WrappedKey{ID: id.String(), Wrapped: wrapped, Created: time.Now().UTC()}
+	p.mu.Lock()
+	p.wrapped = append(p.wrapped, key)
+	p.unwraped[key.ID] = &Key{ID: key.ID, Material: material}
+	p.mu.Unlock()
+	return key, nil
+}
+
+// WrappedKeys returns every key in its wrapped form, oldest first.
+func (p *EnvelopeKeyProvider) WrappedKeys() []WrappedKey {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]WrappedKey(nil), p.wrapped...)
+}
+
+func (p *EnvelopeKeyProvider) ActiveKey(ctx context.Context) (*Key, error) {
+	p.mu.RLock()
+	if len(p.wrapped) == 0 {
+		p.mu.RUnlock()
+		return nil, ErrNoActiveKey
+	}
+	id := p.wrapped[len(p.wrapped)-1].ID
+	p.mu.RUnlock()
+	return p.Key(ctx, id)
+}
+
+func (p *EnvelopeKeyProvider) Key(ctx context.Context, id string) (*Key, error) {
+	p.mu.RLock()
+	key, ok := p.unwraped[id]
+	var wrapped *WrappedKey
+	if !ok {
+		for i := range p.wrapped {
+			if p.wrapped[i].ID == id {
+				wrapped = &p.wrapped[i]
+				break
+			}
+		}
+	}
+	p.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if wrapped == nil {
+		return nil, ErrKeyNotFound.WithMessagef("key %q is not known to the envelope provider", id)
+	}
+
+	material, err := p.wrapper.UnwrapKey(ctx, wrapped.Wrapped)
+	if err != nil {
+		return nil, ErrInvalidKey.WithMessagef("failed to unwrap key %q", id).WithCause(err)
+	}
+	if err := validateKey(id, material); err != nil {
+		return nil, err
+	}
+	key = &Key{ID: id, Material: material}
+	p.mu.Lock()
+	p.unwraped[id] = key
+	p.mu.Unlock()
+	return key, nil
+}
+
+func (p *EnvelopeKeyProvider) Keys(ctx context.Context) ([]*Key, error) {
+	p.mu.RLock()
+	ids := make([]string, len(p.wrapped))
+	for i, wrapped := range p.wrapped {
+		ids[i] = wrapped.ID
+	}
+	p.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(ids))
+	for _, id := range ids {
+		key, err := p.Key(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// AESKeyWrapper wraps data encryption keys locally with AES-256-GCM under a
+// master key. Prefer a KMS-backed KeyWrapper in production.
+type AESKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+var _ KeyWrapper = (*AESKeyWrapper)(nil)
+
+// NewAESKeyWrapper creates a wrapper from a 32-byte master key.
+func NewAESKeyWrapper(masterKey []byte) (*AESKeyWrapper, error) {
+	if len(masterKey) != KeySize {
+		return nil, ErrInvalidKey.WithMessagef("master key must be %d bytes, got %d", KeySize, len(masterKey))
+	}
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, ErrInvalidKey.WithCause(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, ErrInvalidKey.WithCause(err)
+	}
+	return &AESKeyWrapper{aead: aead}, nil
+}
+
+func (w *AESKeyWrapper) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	nonce := make([]byte, w.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return w.aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (w *AESKeyWrapper) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < w.aead.NonceSize() {
+		return nil, fmt.Errorf("wrapped key too short")
+	}
+	nonce, sealed := wrapped[:w.aead.NonceSize()], wrapped[w.aead.NonceSize():]
+	return w.aead.Open(nil, nonce, sealed, nil)
+}
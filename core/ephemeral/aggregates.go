@@ -1,7 +1,10 @@
 package ephemeral
 
 import (
+	"fmt"
+	"math"
 	"reflect"
+	"sort"
 	"strconv"
 
 	"github.com/asaidimu/go-anansi/v8/core/common"
@@ -161,4 +164,134 @@ func maxAggregate(records []map[string]any, field string) (any, error) {
 	return maxValue, nil
 }
 
+// countDistinctAggregate counts the distinct non-nil values of a field.
+// Numbers that are equal count once regardless of their Go type.
+func countDistinctAggregate(records []map[string]any, field string) (any, error) {
+	seen := make(map[string]struct{})
+	for _, record := range records {
+		value, _ := utils.GetValueByPath(record, field)
+		if value == nil {
+			continue
+		}
+		key := fmt.Sprintf("%T:%v", value, value)
+		if f, ok := numericValue(value); ok {
+			key = fmt.Sprintf("number:%v", f)
+		}
+		seen[key] = struct{}{}
+	}
+	return len(seen), nil
+}
+
+// arrayAggAggregate collects the non-nil values of a field in record order.
+func arrayAggAggregate(records []map[string]any, field string) (any, error) {
+	var values []any
+	for _, record := range records {
+		if value, _ := utils.GetValueByPath(record, field); value != nil {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values, nil
+}
+
+// varianceAggregate computes the sample variance of a numeric field.
+func varianceAggregate(records []map[string]any, field string) (any, error) {
+	values := numericValues(records, field)
+	if len(values) < 2 {
+		return nil, nil // The sample variance needs at least two values
+	}
+
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return squares / float64(len(values)-1), nil
+}
+
+// stddevAggregate computes the sample standard deviation of a numeric field.
+func stddevAggregate(records []map[string]any, field string) (any, error) {
+	variance, err := varianceAggregate(records, field)
+	if variance == nil || err != nil {
+		return variance, err
+	}
+	return math.Sqrt(variance.(float64)), nil
+}
+
+// medianAggregate computes the median of a numeric field, averaging the two
+// middle values when their count is even.
+func medianAggregate(records []map[string]any, field string) (any, error) {
+	values := numericValues(records, field)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2, nil
+	}
+	return values[mid], nil
+}
 
+// firstAggregate returns the first non-nil value of a field. The query helper
+// orders the records by the aggregation's OrderBy beforehand.
+func firstAggregate(records []map[string]any, field string) (any, error) {
+	for _, record := range records {
+		if value, _ := utils.GetValueByPath(record, field); value != nil {
+			return value, nil
+		}
+	}
+	return nil, nil
+}
+
+// lastAggregate returns the last non-nil value of a field. The query helper
+// orders the records by the aggregation's OrderBy beforehand.
+func lastAggregate(records []map[string]any, field string) (any, error) {
+	for i := len(records) - 1; i >= 0; i-- {
+		if value, _ := utils.GetValueByPath(records[i], field); value != nil {
+			return value, nil
+		}
+	}
+	return nil, nil
+}
+
+// numericValues returns the numeric values of a field, parsing numeric strings
+// the way sumAggregate does.
+func numericValues(records []map[string]any, field string) []float64 {
+	var values []float64
+	for _, record := range records {
+		value, _ := utils.GetValueByPath(record, field)
+		if f, ok := numericValue(value); ok {
+			values = append(values, f)
+		}
+	}
+	return values
+}
+
+// numericValue converts numbers and numeric strings to float64.
+func numericValue(value any) (float64, bool) {
+	if value == nil {
+		return 0, false
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -49,6 +49,16 @@ func (i *EphemeralDatabaseInteractor) SelectDocuments(ctx context.Context, schem
 		query.AggregationTypeAvg:   query.AggregateFunction(avgAggregate),
 		query.AggregationTypeMin:   query.AggregateFunction(minAggregate),
 		query.AggregationTypeMax:   query.AggregateFunction(maxAggregate),
+
+		query.AggregationTypeCountDistinct: query.AggregateFunction(countDistinctAggregate),
+		query.AggregationTypeArrayAgg:      query.AggregateFunction(arrayAggAggregate),
+		query.AggregationTypeStddev:        query.AggregateFunction(stddevAggregate),
+		query.AggregationTypeVariance:      query.AggregateFunction(varianceAggregate),
+		query.AggregationTypeMedian:        query.AggregateFunction(medianAggregate),
+		query.AggregationTypeFirst:         query.AggregateFunction(firstAggregate),
+		query.AggregationTypeLast:          query.AggregateFunction(lastAggregate),
+		// group_concat and percentile depend on the aggregation's separator
+		// and fraction, so they fall back to the query helper's built-ins.
 	}
 
 	queryHelper, err := query.NewQueryHelper(dsl, nil, aggregateFuncs, nil)
@@ -113,17 +123,21 @@ func (i *EphemeralDatabaseInteractor) SelectDocuments(ctx context.Context, schem
 		filteredDocs = currentDocs
 	}
 
-	// Handle Aggregations
+	// Aggregations replace the documents with one row per group, which are
+	// then sorted and paged like documents. Window expressions see the whole
+	// filtered result, before it is paged.
+	var windowedDocs []map[string]any
 	if len(dsl.Aggregations) > 0 {
-		aggregationResults, err := queryHelper.ApplyAggregations(filteredDocs)
-		if err != nil {
-			return nil, 0, err
-		}
-		return []*document.Document{document.NewRecordView(aggregationResults)}, 0, nil
+		windowedDocs, err = queryHelper.ApplyAggregations(filteredDocs)
+	} else {
+		windowedDocs, err = queryHelper.ApplyWindows(filteredDocs)
+	}
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Apply Sorting
-	sortedDocs, err := queryHelper.Sort(filteredDocs)
+	sortedDocs, err := queryHelper.Sort(windowedDocs)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -198,7 +212,7 @@ func (i *EphemeralDatabaseInteractor) SelectStream(ctx context.Context, sc *defi
 }
 
 // UpdateDocuments updates documents in the in-memory store.
-func (i *EphemeralDatabaseInteractor) UpdateDocuments(ctx context.Context, schemaDef *definition.Schema, updates data.Documenter, computedUpdates map[string]query.Query, filters *query.QueryFilter, returnDocs bool) ([]*document.Document, int64, error) {
+func (i *EphemeralDatabaseInteractor) UpdateDocuments(ctx context.Context, schemaDef *definition.Schema, updates data.Documenter, computedUpdates map[string]query.Query, operators []query.UpdateOperator, filters *query.QueryFilter, returnDocs bool) ([]*document.Document, int64, error) {
 	c, err := i.store.getCollection(schemaDef.Name)
 	if err != nil {
 		return nil, 0, err
@@ -249,10 +263,19 @@ func (i *EphemeralDatabaseInteractor) UpdateDocuments(ctx context.Context, schem
 		}
 		updatedDocData := make(map[string]any)
 		maps.Copy(updatedDocData, doc.Data)
-		maps.Copy(updatedDocData, updates.ToMap())
+		if updates != nil {
+			maps.Copy(updatedDocData, updates.ToMap())
+		}
+		// The update document carries an ID of its own; the stored document
+		// keeps its identity.
+		updatedDocData[data.DocumentIDField] = doc.Data[data.DocumentIDField]
 
 		// TODO: Apply computedUpdates logic here
 
+		if err := query.ApplyUpdateOperators(updatedDocData, operators); err != nil {
+			return nil, updatedCount, err
+		}
+
 		if err := c.data.Update(id, updatedDocData); err != nil {
 			return nil, 0, err
 		}
@@ -467,6 +490,12 @@ func (i *EphemeralDatabaseInteractor) Capabilities() query.Capabilities {
 			query.ComparisonOperatorNotContains: {},
 			query.ComparisonOperatorExists:      {},
 			query.ComparisonOperatorNotExists:   {},
+			query.ComparisonOperatorRegex:       {},
+			query.ComparisonOperatorIEq:         {},
+			query.ComparisonOperatorBetween:     {},
+			query.ComparisonOperatorSize:        {},
+			query.ComparisonOperatorElemMatch:   {},
+			query.ComparisonOperatorAll:         {},
 		},
 		SupportedAggregationFunctions: map[query.AggregationType]struct{}{
 			query.AggregationTypeCount: {},
@@ -474,6 +503,16 @@ func (i *EphemeralDatabaseInteractor) Capabilities() query.Capabilities {
 			query.AggregationTypeAvg:   {},
 			query.AggregationTypeMin:   {},
 			query.AggregationTypeMax:   {},
+
+			query.AggregationTypeCountDistinct: {},
+			query.AggregationTypeGroupConcat:   {},
+			query.AggregationTypeArrayAgg:      {},
+			query.AggregationTypeStddev:        {},
+			query.AggregationTypeVariance:      {},
+			query.AggregationTypePercentile:    {},
+			query.AggregationTypeMedian:        {},
+			query.AggregationTypeFirst:         {},
+			query.AggregationTypeLast:          {},
 		},
 		SupportedJoinTypes: map[query.JoinType]struct{}{
 			query.JoinTypeInner: {},
@@ -492,11 +531,13 @@ func (i *EphemeralDatabaseInteractor) Capabilities() query.Capabilities {
 			AddConstraint:    true,
 			DropConstraint:   true,
 		},
-		SupportsGroupBy:      true,
-		SupportsDistinct:     true,
-		SupportsNestedFields: true,
-		MaxWhereConditions:   0,
-		MaxJoinClauses:       0,
+		SupportsGroupBy:         true,
+		SupportsDistinct:        true,
+		SupportsWindowFunctions: true,
+		SupportsTimeBuckets:     true,
+		SupportsNestedFields:    true,
+		MaxWhereConditions:      0,
+		MaxJoinClauses:          0,
 	}
 }
 
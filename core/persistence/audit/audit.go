@@ -0,0 +1,160 @@
+// Package audit records every create, update and delete performed through a
+// persistence in an append-only audit collection, chaining the entries by
+// hash so that later tampering can be detected.
+//
+// Decorator is a persistence decorator. Each audited write runs in a
+// transaction together with the entries describing it, so either both the
+// change and its audit entries are stored or neither is. An entry records the
+// collection, the document ID, the actor taken from the request context (see
+// common.ContextWithPrincipal), an RFC 6902 JSON patch from the previous to
+// the new document contents, and the document checksum after the write.
+//
+// Entries form one chain per audited collection. Each entry stores the hash
+// of its predecessor, and its own hash covers every other field of the entry:
+//
+//	hash = sha256(canonical JSON of the entry with hash = "")
+//
+// Verifier walks a chain and reports sequence gaps (removed entries), broken
+// links and entries whose contents no longer match their hash. The head hash
+// of a verified chain can be anchored outside the database — a signed log, a
+// ticket, a regulator filing — to also detect truncation of the newest
+// entries.
+//
+//	decorators := utils.Decorators{
+//		PersistenceDecorators: []utils.DecoratorFunc[base.Persistence]{
+//			(utils.DecoratorFunc[base.Persistence])(audit.Decorator(audit.Config{})),
+//		},
+//	}
+//
+// The audit collection itself is append-only through the decorated
+// persistence: Update and Delete on it are rejected with ErrAppendOnly.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// DefaultCollection is the name of the audit collection when Config does not
+// set one.
+const DefaultCollection = "audit_log"
+
+// Operation is the kind of write an entry records.
+type Operation string
+
+const (
+	OperationCreate Operation = "create"
+	OperationUpdate Operation = "update"
+	OperationDelete Operation = "delete"
+)
+
+// Entry is a single link in an audit chain.
+type Entry struct {
+	Collection   string    `json:"collection"`
+	Sequence     int64     `json:"sequence"`
+	Operation    Operation `json:"operation"`
+	DocumentID   string    `json:"document_id"`
+	Actor        string    `json:"actor"`
+	Patch        string    `json:"patch"`
+	Checksum     string    `json:"checksum"`
+	Timestamp    string    `json:"timestamp"`
+	PreviousHash string    `json:"previous_hash"`
+	Hash         string    `json:"hash"`
+}
+
+// ComputeHash returns the hash the entry should carry: the SHA-256 of its
+// canonical JSON encoding with Hash cleared.
+func (e Entry) ComputeHash() string {
+	e.Hash = ""
+	encoded, _ := json.Marshal(e)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+func (e Entry) document() (data.Documenter, error) {
+	return data.NewDocument(map[string]any{
+		"collection":    e.Collection,
+		"sequence":      e.Sequence,
+		"operation":     string(e.Operation),
+		"document_id":   e.DocumentID,
+		"actor":         e.Actor,
+		"patch":         e.Patch,
+		"checksum":      e.Checksum,
+		"timestamp":     e.Timestamp,
+		"previous_hash": e.PreviousHash,
+		"hash":          e.Hash,
+	})
+}
+
+func entryFromDocument(doc data.Documenter) (Entry, error) {
+	var e Entry
+	encoded, err := json.Marshal(doc.Data())
+	if err != nil {
+		return e, err
+	}
+	err = json.Unmarshal(encoded, &e)
+	return e, err
+}
+
+// schemaFields maps the audit fields to the fixed IDs every audit collection
+// is created with. Field IDs must be UUIDv7 outside development mode, and
+// fixed IDs keep the schema identical across processes.
+var schemaFields = []struct {
+	id        definition.FieldId
+	name      string
+	fieldType definition.FieldType
+}{
+	{"01a14efc-98a9-74b1-9265-07e474bf7b9e", "collection", definition.FieldTypeString},
+	{"01a14efc-98a9-751b-bb2e-de39a8c519ca", "sequence", definition.FieldTypeInteger},
+	{"01a14efc-98a9-7524-b6a8-2198baf4ff59", "operation", definition.FieldTypeString},
+	{"01a14efc-98a9-7527-99f0-0ef64a45aae0", "document_id", definition.FieldTypeString},
+	{"01a14efc-98a9-752b-a183-810e0aedb6d3", "actor", definition.FieldTypeString},
+	{"01a14efc-98a9-752e-a82e-14334b336180", "patch", definition.FieldTypeString},
+	{"01a14efc-98a9-7533-94a1-ddf1e48fa8e4", "checksum", definition.FieldTypeString},
+	{"01a14efc-98a9-7537-b829-3f8deb8bbd02", "timestamp", definition.FieldTypeString},
+	{"01a14efc-98a9-753a-a06c-e55fd6fa9d09", "previous_hash", definition.FieldTypeString},
+	{"01a14efc-98a9-753e-a0f2-111ded47ee4f", "hash", definition.FieldTypeString},
+}
+
+// Schema returns the schema of an audit collection with the given name.
+func Schema(name string) *definition.Schema {
+	fields := make(map[definition.FieldId]definition.Field, len(schemaFields))
+	for _, f := range schemaFields {
+		fields[f.id] = definition.Field{
+			Name:            definition.FieldName(f.name),
+			Required:        f.name != "checksum",
+			FieldProperties: definition.FieldProperties{Type: f.fieldType},
+		}
+	}
+
+	return &definition.Schema{
+		Version: common.MustNewVersion("1.0.0"),
+		BaseSchema: definition.BaseSchema{
+			Name:        name,
+			Description: "Append-only, hash-chained audit trail",
+			Fields:      fields,
+			Indexes: map[definition.IndexID]definition.Index{
+				"01a14efc-98a9-7542-86cd-7033a1c2a0d9": {
+					Name:   "audit_chain",
+					Fields: []definition.FieldName{"collection", "sequence"},
+					Type:   definition.IndexTypeUnique,
+					Unique: true,
+				},
+			},
+		},
+	}
+}
+
+// actorFrom returns the ID of the principal in ctx, or "anonymous".
+func actorFrom(ctx context.Context) string {
+	if principal, ok := common.PrincipalFromContext(ctx); ok && principal.ID != "" {
+		return principal.ID
+	}
+	return "anonymous"
+}
@@ -0,0 +1,445 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	anansijson "github.com/asaidimu/go-anansi/v8/core/json"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/utils"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"go.uber.org/zap"
+)
+
+// Config configures the audit decorator.
+type Config struct {
+	// Collection is the audit collection. It is created on first use.
+	// Defaults to DefaultCollection.
+	Collection string
+
+	// Collections lists the logical collections to audit. Empty audits every
+	// collection except the audit collection itself.
+	Collections []string
+
+	// Actor identifies who performed a write. Defaults to the ID of the
+	// principal in context, or "anonymous".
+	Actor func(ctx context.Context) string
+
+	Logger *zap.Logger
+}
+
+// Decorator returns a persistence decorator recording every write to the
+// audited collections. See the package documentation.
+func Decorator(cfg Config) utils.PersistenceDecorator {
+	if cfg.Collection == "" {
+		cfg.Collection = DefaultCollection
+	}
+	if cfg.Actor == nil {
+		cfg.Actor = actorFrom
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	return func(next base.Persistence) base.Persistence {
+		t := &trail{cfg: cfg, root: next, locks: make(map[string]*sync.Mutex), patcher: anansijson.NewPatcher()}
+		return &auditPersistence{Persistence: next, trail: t}
+	}
+}
+
+// trail owns the audit collection and appends to its chains.
+type trail struct {
+	cfg     Config
+	root    base.Persistence
+	patcher *anansijson.Patcher
+
+	ensureMu sync.Mutex
+	ensured  bool
+
+	locksMu sync.Mutex
+	locks   map[string]*sync.Mutex
+}
+
+func (t *trail) audited(name string) bool {
+	if name == t.cfg.Collection {
+		return false
+	}
+	return len(t.cfg.Collections) == 0 || slices.Contains(t.cfg.Collections, name)
+}
+
+// ensure creates the audit collection when it does not exist yet.
+func (t *trail) ensure(ctx context.Context) error {
+	t.ensureMu.Lock()
+	defer t.ensureMu.Unlock()
+	if t.ensured {
+		return nil
+	}
+	exists, err := t.root.HasCollection(ctx, t.cfg.Collection)
+	if err != nil {
+		return ErrRecordFailed.WithOperation("ensure").WithCause(err)
+	}
+	if !exists {
+		if _, err := t.root.CreateCollection(ctx, Schema(t.cfg.Collection)); err != nil {
+			return ErrRecordFailed.WithOperation("ensure").WithCause(err)
+		}
+		t.cfg.Logger.Info("created audit collection", zap.String("collection", t.cfg.Collection))
+	}
+	t.ensured = true
+	return nil
+}
+
+// lock serialises appends to one chain within this process. Appends from
+// other processes are kept from forking the chain by the unique index on
+// (collection, sequence).
+func (t *trail) lock(collection string) *sync.Mutex {
+	t.locksMu.Lock()
+	defer t.locksMu.Unlock()
+	mu, ok := t.locks[collection]
+	if !ok {
+		mu = &sync.Mutex{}
+		t.locks[collection] = mu
+	}
+	return mu
+}
+
+// wrap decorates collection name when it is audited, and makes the audit
+// collection append-only.
+func (t *trail) wrap(ctx context.Context, name string, collection base.Collection) (base.Collection, error) {
+	if name == t.cfg.Collection {
+		return &appendOnlyCollection{Collection: collection}, nil
+	}
+	if !t.audited(name) {
+		return collection, nil
+	}
+	if err := t.ensure(ctx); err != nil {
+		return nil, err
+	}
+	return &auditCollection{Collection: collection, trail: t, name: name}, nil
+}
+
+// change is one document write to be recorded.
+type change struct {
+	operation Operation
+	id        string
+	before    map[string]any
+	after     data.Documenter
+}
+
+// append records changes at the end of the chain of collection. ctx carries
+// the transaction of the write being recorded.
+func (t *trail) append(ctx context.Context, collection string, changes []change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	log, err := t.root.Collection(ctx, t.cfg.Collection)
+	if err != nil {
+		return ErrRecordFailed.WithCause(err)
+	}
+
+	last := query.NewQueryBuilder().Where("collection").Eq(collection).OrderByDesc("sequence").Limit(1).Build()
+	result, err := log.Read(ctx, &last)
+	if err != nil {
+		return ErrRecordFailed.WithCause(err)
+	}
+	var previous Entry
+	if len(result.Data) > 0 {
+		if previous, err = entryFromDocument(result.Data[0]); err != nil {
+			return ErrRecordFailed.WithCause(err)
+		}
+	}
+
+	actor := t.cfg.Actor(ctx)
+	timestamp := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, c := range changes {
+		var after map[string]any
+		var checksum string
+		if c.after != nil {
+			after = c.after.Data()
+			checksum, _ = c.after.Checksum()
+		}
+		patch, err := t.patch(c.before, after)
+		if err != nil {
+			return ErrRecordFailed.WithPath(c.id).WithCause(err)
+		}
+		if c.operation == OperationUpdate && patch == "[]" {
+			// The filter matched but the document did not change.
+			continue
+		}
+
+		entry := Entry{
+			Collection:   collection,
+			Sequence:     previous.Sequence + 1,
+			Operation:    c.operation,
+			DocumentID:   c.id,
+			Actor:        actor,
+			Patch:        patch,
+			Checksum:     checksum,
+			Timestamp:    timestamp,
+			PreviousHash: previous.Hash,
+		}
+		entry.Hash = entry.ComputeHash()
+
+		doc, err := entry.document()
+		if err != nil {
+			return ErrRecordFailed.WithCause(err)
+		}
+		created, err := log.CreateOne(ctx, doc)
+		if err != nil {
+			return ErrRecordFailed.WithPath(c.id).WithCause(err)
+		}
+		if created.Status != base.StatusCreated {
+			return ErrRecordFailed.WithPath(c.id).WithIssues(created.Issues)
+		}
+		previous = entry
+	}
+	return nil
+}
+
+// patch returns the JSON patch turning before into after. A missing side is
+// treated as an empty document.
+func (t *trail) patch(before, after map[string]any) (string, error) {
+	if before == nil {
+		before = map[string]any{}
+	}
+	if after == nil {
+		after = map[string]any{}
+	}
+	// Round-trip through JSON so both sides use the same value types and the
+	// patch does not report int/float differences as changes.
+	normalize := func(m map[string]any) (any, error) {
+		encoded, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		var out any
+		err = json.Unmarshal(encoded, &out)
+		return out, err
+	}
+	from, err := normalize(before)
+	if err != nil {
+		return "", err
+	}
+	to, err := normalize(after)
+	if err != nil {
+		return "", err
+	}
+	ops, err := t.patcher.CreatePatch(from, to)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(ops)
+	return string(encoded), err
+}
+
+// ---------------------------------------------------------------------
+//  Persistence
+// ---------------------------------------------------------------------
+
+type auditPersistence struct {
+	base.Persistence
+	trail *trail
+}
+
+var _ base.Persistence = (*auditPersistence)(nil)
+
+func (p *auditPersistence) Collection(ctx context.Context, name string) (base.Collection, error) {
+	collection, err := p.Persistence.Collection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.trail.wrap(ctx, name, collection)
+}
+
+func (p *auditPersistence) CreateCollection(ctx context.Context, sc *definition.Schema) (base.Collection, error) {
+	collection, err := p.Persistence.CreateCollection(ctx, sc)
+	if err != nil {
+		return nil, err
+	}
+	return p.trail.wrap(ctx, sc.Name, collection)
+}
+
+func (p *auditPersistence) Rollback(ctx context.Context, name string, version *string, dryRun *bool) (base.Collection, error) {
+	collection, err := p.Persistence.Rollback(ctx, name, version, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	return p.trail.wrap(ctx, name, collection)
+}
+
+func (p *auditPersistence) Migrate(ctx context.Context, name string, migration any, dryRun *bool) (base.Collection, error) {
+	collection, err := p.Persistence.Migrate(ctx, name, migration, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	return p.trail.wrap(ctx, name, collection)
+}
+
+func (p *auditPersistence) Transact(ctx context.Context, callback func(ctx context.Context, tx base.BasePersistence) (any, error)) (any, error) {
+	return p.Persistence.Transact(ctx, func(tctx context.Context, tx base.BasePersistence) (any, error) {
+		return callback(tctx, &auditScope{BasePersistence: tx, trail: p.trail})
+	})
+}
+
+// auditScope decorates the persistence handed to a Transact callback.
+type auditScope struct {
+	base.BasePersistence
+	trail *trail
+}
+
+func (s *auditScope) Collection(ctx context.Context, name string) (base.Collection, error) {
+	collection, err := s.BasePersistence.Collection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.trail.wrap(ctx, name, collection)
+}
+
+// ---------------------------------------------------------------------
+//  Collections
+// ---------------------------------------------------------------------
+
+type auditCollection struct {
+	base.Collection
+	trail *trail
+	name  string
+}
+
+var _ base.Collection = (*auditCollection)(nil)
+
+// record runs write in a transaction and appends the changes it reports to
+// the collection's chain within the same transaction.
+func (c *auditCollection) record(ctx context.Context, write func(ctx context.Context) ([]change, error)) error {
+	mu := c.trail.lock(c.name)
+	mu.Lock()
+	defer mu.Unlock()
+
+	_, err := c.Collection.Transact(ctx, func(tctx context.Context) (any, error) {
+		changes, err := write(tctx)
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.trail.append(tctx, c.name, changes)
+	})
+	return err
+}
+
+// snapshot reads the documents matching qf, keyed by ID.
+func (c *auditCollection) snapshot(ctx context.Context, qf *query.QueryFilter) (map[string]data.Documenter, []string, error) {
+	result, err := c.Collection.Read(ctx, &query.Query{Filters: qf})
+	if err != nil {
+		return nil, nil, err
+	}
+	docs := make(map[string]data.Documenter, len(result.Data))
+	ids := make([]string, 0, len(result.Data))
+	for _, doc := range result.Data {
+		docs[doc.ID()] = doc
+		ids = append(ids, doc.ID())
+	}
+	return docs, ids, nil
+}
+
+func (c *auditCollection) CreateOne(ctx context.Context, doc data.Documenter) (base.CreateResult, error) {
+	var result base.CreateResult
+	err := c.record(ctx, func(tctx context.Context) ([]change, error) {
+		var err error
+		if result, err = c.Collection.CreateOne(tctx, doc); err != nil || result.Status != base.StatusCreated {
+			return nil, err
+		}
+		return []change{{operation: OperationCreate, id: result.Data.ID(), after: result.Data}}, nil
+	})
+	return result, err
+}
+
+func (c *auditCollection) CreateMany(ctx context.Context, docs []data.Documenter) ([]base.CreateResult, error) {
+	var results []base.CreateResult
+	err := c.record(ctx, func(tctx context.Context) ([]change, error) {
+		var err error
+		if results, err = c.Collection.CreateMany(tctx, docs); err != nil {
+			return nil, err
+		}
+		var changes []change
+		for _, result := range results {
+			if result.Status == base.StatusCreated {
+				changes = append(changes, change{operation: OperationCreate, id: result.Data.ID(), after: result.Data})
+			}
+		}
+		return changes, nil
+	})
+	return results, err
+}
+
+func (c *auditCollection) Update(ctx context.Context, params *base.CollectionUpdate) (*base.ReadResult, error) {
+	var result *base.ReadResult
+	err := c.record(ctx, func(tctx context.Context) ([]change, error) {
+		var before map[string]data.Documenter
+		var ids []string
+		if params != nil && params.Filter != nil {
+			var err error
+			if before, ids, err = c.snapshot(tctx, params.Filter); err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		if result, err = c.Collection.Update(tctx, params); err != nil || len(ids) == 0 {
+			return nil, err
+		}
+
+		values := make([]any, len(ids))
+		for i, id := range ids {
+			values[i] = id
+		}
+		after, _, err := c.snapshot(tctx, query.NewQueryBuilder().Where(data.DocumentIDField).In(values...).Build().Filters)
+		if err != nil {
+			return nil, err
+		}
+
+		var changes []change
+		for _, id := range ids {
+			updated, ok := after[id]
+			if !ok {
+				continue
+			}
+			changes = append(changes, change{operation: OperationUpdate, id: id, before: before[id].Data(), after: updated})
+		}
+		return changes, nil
+	})
+	return result, err
+}
+
+func (c *auditCollection) Delete(ctx context.Context, qf *query.QueryFilter, unsafe bool) (int, error) {
+	var deleted int
+	err := c.record(ctx, func(tctx context.Context) ([]change, error) {
+		before, ids, err := c.snapshot(tctx, qf)
+		if err != nil {
+			return nil, err
+		}
+		if deleted, err = c.Collection.Delete(tctx, qf, unsafe); err != nil {
+			return nil, err
+		}
+		changes := make([]change, 0, len(ids))
+		for _, id := range ids {
+			changes = append(changes, change{operation: OperationDelete, id: id, before: before[id].Data()})
+		}
+		return changes, nil
+	})
+	return deleted, err
+}
+
+// appendOnlyCollection is the audit collection as seen through the decorated
+// persistence.
+type appendOnlyCollection struct {
+	base.Collection
+}
+
+func (c *appendOnlyCollection) Update(context.Context, *base.CollectionUpdate) (*base.ReadResult, error) {
+	return nil, ErrAppendOnly.WithOperation("Update")
+}
+
+func (c *appendOnlyCollection) Delete(context.Context, *query.QueryFilter, bool) (int, error) {
+	return 0, ErrAppendOnly.WithOperation("Delete")
+}
@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+)
+
+// Errors returned by the audit layer.
+var (
+	ErrAppendOnly   = common.NewSystemError("ERR_AUDIT_APPEND_ONLY", "audit entries cannot be modified or deleted")
+	ErrRecordFailed = common.NewSystemError("ERR_AUDIT_RECORD_FAILED", "failed to record audit entry")
+	ErrVerifyFailed = common.NewSystemError("ERR_AUDIT_VERIFY_FAILED", "failed to read audit chain")
+)
+
+// Issue codes reported by Verifier.
+const (
+	IssueSequenceGap  = "AUDIT_SEQUENCE_GAP"
+	IssueChainBroken  = "AUDIT_CHAIN_BROKEN"
+	IssueEntryAltered = "AUDIT_ENTRY_ALTERED"
+)
+
+func issue(code string, sequence int64, format string, args ...any) common.Issue {
+	return common.Issue{
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Path:     fmt.Sprintf("sequence[%d]", sequence),
+		Severity: common.SeverityError,
+	}
+}
@@ -0,0 +1,117 @@
+package audit
+
+import (
+	"context"
+	"sort"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+)
+
+// Report is the outcome of verifying one audit chain.
+type Report struct {
+	Collection string `json:"collection"`
+	// Entries is the number of entries read.
+	Entries int `json:"entries"`
+	// Head is the hash of the newest entry. Compare it with an externally
+	// anchored value to detect truncation.
+	Head   string        `json:"head"`
+	Issues common.Issues `json:"issues,omitempty"`
+}
+
+// Valid reports whether the chain verified without issues.
+func (r *Report) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// Verifier checks the audit chains stored in an audit collection.
+type Verifier struct {
+	p          base.BasePersistence
+	collection string
+}
+
+// NewVerifier returns a verifier for the audit collection named collection,
+// or DefaultCollection when it is empty. p need not be decorated.
+func NewVerifier(p base.BasePersistence, collection string) *Verifier {
+	if collection == "" {
+		collection = DefaultCollection
+	}
+	return &Verifier{p: p, collection: collection}
+}
+
+// Collections returns the names of the collections with an audit chain.
+func (v *Verifier) Collections(ctx context.Context) ([]string, error) {
+	log, err := v.p.Collection(ctx, v.collection)
+	if err != nil {
+		return nil, ErrVerifyFailed.WithCause(err)
+	}
+	q := query.NewQueryBuilder().Select().Include("collection").End().Build()
+	result, err := log.Read(ctx, &q)
+	if err != nil {
+		return nil, ErrVerifyFailed.WithCause(err)
+	}
+	seen := make(map[string]struct{})
+	var names []string
+	for _, doc := range result.Data {
+		name, err := doc.GetString("collection")
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Verify walks the chain of collection in sequence order. Sequence gaps,
+// links that do not match the previous entry's hash, and entries whose
+// contents do not match their own hash are reported as issues; an error is
+// returned only when the chain cannot be read.
+func (v *Verifier) Verify(ctx context.Context, collection string) (*Report, error) {
+	log, err := v.p.Collection(ctx, v.collection)
+	if err != nil {
+		return nil, ErrVerifyFailed.WithCause(err)
+	}
+	q := query.NewQueryBuilder().Where("collection").Eq(collection).OrderByAsc("sequence").Build()
+	result, err := log.Read(ctx, &q)
+	if err != nil {
+		return nil, ErrVerifyFailed.WithCause(err)
+	}
+
+	report := &Report{Collection: collection}
+	var previous *Entry
+	for _, doc := range result.Data {
+		entry, err := entryFromDocument(doc)
+		if err != nil {
+			return nil, ErrVerifyFailed.WithPath(doc.ID()).WithCause(err)
+		}
+		report.Entries++
+
+		expected := int64(1)
+		previousHash := ""
+		if previous != nil {
+			expected = previous.Sequence + 1
+			previousHash = previous.Hash
+		}
+		if entry.Sequence != expected {
+			report.Issues = append(report.Issues, issue(IssueSequenceGap, entry.Sequence,
+				"expected sequence %d, found %d", expected, entry.Sequence))
+		}
+		if entry.PreviousHash != previousHash {
+			report.Issues = append(report.Issues, issue(IssueChainBroken, entry.Sequence,
+				"entry does not link to the hash of its predecessor"))
+		}
+		if entry.Hash != entry.ComputeHash() {
+			report.Issues = append(report.Issues, issue(IssueEntryAltered, entry.Sequence,
+				"entry contents do not match its hash"))
+		}
+
+		previous = &entry
+		report.Head = entry.Hash
+	}
+	return report, nil
+}
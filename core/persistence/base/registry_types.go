@@ -122,6 +122,19 @@ type CollectionRegistry interface {
 	// If no version is provided, it returns the currently active schema version.
 	ResolvePhysicalName(ctx context.Context, name string, version ...string) (string, error)
 
+	// CompiledSchema returns the compiled form of a collection's schema, the
+	// active version unless one is given. The result is a read-only view
+	// shared by every caller.
+	CompiledSchema(ctx context.Context, name string, version ...string) (*definition.CompiledSchema, error)
+
+	// ExportCompiledSchemas snapshots the compiled form of every stored schema
+	// version, for ImportCompiledSchemas to restore on a later cold start.
+	ExportCompiledSchemas() []byte
+
+	// ImportCompiledSchemas replaces the stored compiled schemas with a
+	// snapshot taken by ExportCompiledSchemas.
+	ImportCompiledSchemas(data []byte) error
+
 	// Close stops background goroutines (e.g. cache janitor/evictor) and
 	// releases resources held by the registry.
 	Close(ctx context.Context) error
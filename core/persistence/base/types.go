@@ -260,6 +260,7 @@ type CollectionMetadata struct {
 	Migrations      []MigrationMetadata      `json:"migrations,omitempty"`      // Migrations is a list of all schema migrations that have been applied to this collection.
 	Transformations []TransformationMetadata `json:"transformations,omitempty"` // Transformations is a list of all data transformations that have been applied to this collection.
 	Subscriptions   []SubscriptionInfo       `json:"subscriptions"`             // Subscriptions is a list of all active event subscriptions for this collection.
+	Tenant          string                   `json:"tenant,omitempty"`          // Tenant is the tenant the metadata was scoped to, when tenancy is enabled.
 }
 
 // Metadata represents the overall metadata for the entire persistence layer.
@@ -447,8 +448,11 @@ type CollectionUpdate struct {
 	Set data.Documenter `json:"set,omitempty"`
 	// For advanced updates where a field's value is computed by an expression or subquery.
 	Compute map[string]query.Query `json:"compute,omitempty"`
-	Filter  *query.QueryFilter     `json:"filter"`
-	Version *int                   `json:"version,omitempty"`
+	// Operators change fields in place, such as incrementing a counter or
+	// appending to an array, without reading the document first.
+	Operators []query.UpdateOperator `json:"operators,omitempty"`
+	Filter    *query.QueryFilter     `json:"filter"`
+	Version   *int                   `json:"version,omitempty"`
 	// ReturnDocument indicates if the updated document(s) should be returned by the update
 	// operation. A nil value means "not specified": consumers fall back to their own default.
 	ReturnDocument *bool `json:"returnDocument,omitempty"`
@@ -483,6 +487,73 @@ func (cu *CollectionUpdate) WithComputedField(field string, q query.Query) *Coll
 	return cu
 }
 
+// WithOperator appends an update operator.
+func (cu *CollectionUpdate) WithOperator(op query.UpdateOperator) *CollectionUpdate {
+	cu.Operators = append(cu.Operators, op)
+	return cu
+}
+
+// Increment adds by to a numeric field.
+func (cu *CollectionUpdate) Increment(field string, by any) *CollectionUpdate {
+	return cu.WithOperator(query.UpdateOperator{Type: query.UpdateOperatorIncrement, Field: field, Value: by})
+}
+
+// Decrement subtracts by from a numeric field.
+func (cu *CollectionUpdate) Decrement(field string, by any) *CollectionUpdate {
+	return cu.WithOperator(query.UpdateOperator{Type: query.UpdateOperatorDecrement, Field: field, Value: by})
+}
+
+// Multiply multiplies a numeric field by factor.
+func (cu *CollectionUpdate) Multiply(field string, factor any) *CollectionUpdate {
+	return cu.WithOperator(query.UpdateOperator{Type: query.UpdateOperatorMultiply, Field: field, Value: factor})
+}
+
+// Min lowers a field to value when value is smaller.
+func (cu *CollectionUpdate) Min(field string, value any) *CollectionUpdate {
+	return cu.WithOperator(query.UpdateOperator{Type: query.UpdateOperatorMin, Field: field, Value: value})
+}
+
+// Max raises a field to value when value is larger.
+func (cu *CollectionUpdate) Max(field string, value any) *CollectionUpdate {
+	return cu.WithOperator(query.UpdateOperator{Type: query.UpdateOperatorMax, Field: field, Value: value})
+}
+
+// Unset clears a field or removes a nested path.
+func (cu *CollectionUpdate) Unset(field string) *CollectionUpdate {
+	return cu.WithOperator(query.UpdateOperator{Type: query.UpdateOperatorUnset, Field: field})
+}
+
+// SetPath sets a nested path inside an object field, leaving its other
+// members in place.
+func (cu *CollectionUpdate) SetPath(path string, value any) *CollectionUpdate {
+	return cu.WithOperator(query.UpdateOperator{Type: query.UpdateOperatorSet, Field: path, Value: value})
+}
+
+// Push appends value to an array field.
+func (cu *CollectionUpdate) Push(field string, value any) *CollectionUpdate {
+	return cu.WithOperator(query.UpdateOperator{Type: query.UpdateOperatorPush, Field: field, Value: value})
+}
+
+// AddToSet appends value to an array field unless it is already there.
+func (cu *CollectionUpdate) AddToSet(field string, value any) *CollectionUpdate {
+	return cu.WithOperator(query.UpdateOperator{Type: query.UpdateOperatorAddToSet, Field: field, Value: value})
+}
+
+// Pull removes every element equal to value from an array field.
+func (cu *CollectionUpdate) Pull(field string, value any) *CollectionUpdate {
+	return cu.WithOperator(query.UpdateOperator{Type: query.UpdateOperatorPull, Field: field, Value: value})
+}
+
+// Pop removes the last element of an array field, or the first when first
+// is true.
+func (cu *CollectionUpdate) Pop(field string, first bool) *CollectionUpdate {
+	end := 1
+	if first {
+		end = -1
+	}
+	return cu.WithOperator(query.UpdateOperator{Type: query.UpdateOperatorPop, Field: field, Value: end})
+}
+
 // WithFilter sets the query filter, overriding any id-derived filter.
 func (cu *CollectionUpdate) WithFilter(f *query.QueryFilter) *CollectionUpdate {
 	cu.Filter = f
@@ -552,6 +623,12 @@ type Collection interface {
 	// Subscriptions returns a list of all active subscriptions for this collection.
 	Subscriptions(ctx context.Context) ([]SubscriptionInfo, error)
 
+	// SubscribeQuery evaluates a query and keeps its result live: callback
+	// receives the initial result, then an update after every committed write
+	// that changes it. It returns a subscription ID to pass to Unsubscribe.
+	// Live queries are driven by persistence events and require an event bus.
+	SubscribeQuery(ctx context.Context, query *query.Query, callback QueryCallback) (string, error)
+
 	// Capabilities returns the features and limitations of the underlying database backend.
 	Capabilities(ctx context.Context) *query.Capabilities
 
@@ -576,6 +653,53 @@ type ReadResult struct {
 	PaginationInfo *query.PaginationInfo `json:"pagination_info,omitempty"`
 }
 
+// QueryChangeType is the kind of change a QueryChange describes.
+type QueryChangeType string
+
+const (
+	// QueryDocumentAdded reports a document that entered the result.
+	QueryDocumentAdded QueryChangeType = "added"
+	// QueryDocumentRemoved reports a document that left the result.
+	QueryDocumentRemoved QueryChangeType = "removed"
+	// QueryDocumentChanged reports a document that stayed in the result but
+	// was modified or moved.
+	QueryDocumentChanged QueryChangeType = "changed"
+)
+
+// QueryChange describes how one document of a live query's result changed.
+type QueryChange struct {
+	Type     QueryChangeType `json:"type"`
+	Document data.Documenter `json:"document"`
+	// Index is the position of the document in the new result, or -1 when
+	// it was removed.
+	Index int `json:"index"`
+	// PreviousIndex is the position of the document in the previous result,
+	// or -1 when it was added.
+	PreviousIndex int `json:"previous_index"`
+}
+
+// QueryUpdate is delivered to the callback of a live query.
+type QueryUpdate struct {
+	// Result is the query's current result.
+	Result *ReadResult `json:"result,omitempty"`
+	// Changes turn the previous result into Result. Removals are listed
+	// first, then additions and changes in the order of the new result.
+	Changes []QueryChange `json:"changes,omitempty"`
+	// Refresh reports that Result replaces the previous result outright and
+	// Changes is empty. The first update of every live query is a refresh,
+	// as are updates of queries whose rows have no document ID, such as
+	// aggregations.
+	Refresh bool `json:"refresh,omitempty"`
+	// Error reports that the query could not be re-evaluated. The live
+	// query stays registered and tries again on the next write.
+	Error error `json:"-"`
+}
+
+// QueryCallback receives the updates of a live query. Calls for one live
+// query are never concurrent, so with an event bus delivering synchronously
+// a callback must not write to the collection it watches.
+type QueryCallback func(ctx context.Context, update QueryUpdate)
+
 // Transaction defines the interface for a database transaction.
 // Implementations of this interface should manage the state and coordination
 // of a single database transaction, including its lifecycle, operations,
@@ -243,7 +243,7 @@ func (c *baseCollection) Update(ctx context.Context, params *base.CollectionUpda
 		if err != nil {
 			return nil, err
 		}
-		docs, count, err := interactor.UpdateDocuments(ctx, sc, params.Set, params.Compute, params.Filter, params.ReturnsDocument())
+		docs, count, err := interactor.UpdateDocuments(ctx, sc, params.Set, params.Compute, params.Operators, params.Filter, params.ReturnsDocument())
 		if err != nil {
 			return nil, err
 		}
@@ -347,7 +347,7 @@ func (c *baseCollection) Subscribe(ctx context.Context, options base.Subscriptio
 		Cursor:    options.ReplayCursor,
 		Filters: []func(_ context.Context, payload base.PersistenceEvent) bool{
 			func(_ context.Context, payload base.PersistenceEvent) bool {
-				return *payload.Collection == c.name
+				return payload.Collection != nil && *payload.Collection == c.name
 			},
 		},
 	})
@@ -390,6 +390,11 @@ func (c *baseCollection) Subscriptions(ctx context.Context) ([]base.Subscription
 	return subs, nil
 }
 
+// SubscribeQuery registers a live query over this collection.
+func (c *baseCollection) SubscribeQuery(ctx context.Context, q *query.Query, callback base.QueryCallback) (string, error) {
+	return subscribeQuery(ctx, c, q, callback)
+}
+
 // Capabilities returns the features and limitations of the underlying database backend.
 func (c *baseCollection) Capabilities(ctx context.Context) *query.Capabilities {
 	capabilities := c.getCurrentInteractor(ctx).Capabilities()
@@ -52,5 +52,10 @@ func NewCollection(
 
 	// Decorate the managed collection with event emission.
 	eventEmitting := newEventEmittingCollection(name, managed, eventEmitter, logger)
+
+	// Report reads and writes when the engine was given telemetry.
+	if t := engine.Telemetry(); t.Enabled() {
+		return newTelemetryCollection(name, eventEmitting, t), nil
+	}
 	return eventEmitting, nil
 }
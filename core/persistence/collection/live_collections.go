@@ -3,6 +3,8 @@ package collection
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/asaidimu/go-anansi/v8/core/cache"
@@ -57,9 +59,13 @@ type LiveCollection[T any] interface {
 	Keys() []string
 	Clone() (LiveCollection[T], error)
 
+	// Stats returns the underlying cache's statistics together with the
+	// repository's coherence counters (invalidations, flushes, refreshes).
+	Stats() cache.CacheStats
+
 	// Close stops any background maintenance goroutines owned by this
-	// repository's cache (janitor, watermark evictor) and releases
-	// associated resources. Idempotent and safe to call from multiple
+	// repository's cache (janitor, watermark evictor), stops watching the
+	// invalidation source, and releases associated resources. Idempotent and safe to call from multiple
 	// goroutines. Callers MUST call Close when a repository created with a
 	// managed cache is no longer needed, or those goroutines will leak for
 	// the lifetime of the process.
@@ -136,6 +142,26 @@ type LiveRepositoryOptions[T any] struct {
 	// logged and excess entries are silently evicted per the eviction
 	// policy.
 	AutoLoad bool
+
+	// Invalidation optionally reports writes made outside this repository's
+	// interceptors — by another process sharing the database, or by code
+	// holding the collection directly. Affected keys are evicted (or
+	// refreshed, see RefreshOnInvalidate) as reports arrive. Without a
+	// source the cache only observes its own writes, and entries written
+	// elsewhere stay stale until their TTL expires.
+	Invalidation InvalidationSource
+
+	// RefreshOnInvalidate reloads invalidated keys from the database right
+	// away instead of leaving them for the next Get's read-through. Only
+	// keys that were cached at the time are reloaded.
+	RefreshOnInvalidate bool
+
+	// MaxStaleness bounds how long any entry the repository caches from the
+	// database may be served, by capping the positive and negative TTLs it
+	// uses. It is the guarantee that remains when an invalidation is missed
+	// or no source is configured. Zero leaves the cache's TTLs unchanged.
+	// Entries stored explicitly with Set/SetWithTTL are not capped.
+	MaxStaleness time.Duration
 }
 
 // liveRepository implements LiveCollection by wrapping a base.Collection
@@ -147,6 +173,28 @@ type liveRepository[T any] struct {
 	queryFunc func(key string) query.Query
 	cache     cache.RepositoryCache[T]
 	ctx       context.Context
+
+	source      InvalidationSource
+	refresh     bool
+	positiveTTL time.Duration
+	negativeTTL time.Duration
+	stopWatch   func()
+	coherence   *coherenceStats
+
+	// generation is bumped by every applied invalidation. A read-through
+	// that started before an invalidation does not cache what it read, as it
+	// may predate the change.
+	generation atomic.Uint64
+}
+
+// coherenceStats holds the counters reported through Stats.
+type coherenceStats struct {
+	invalidations atomic.Uint64
+	flushes       atomic.Uint64
+	refreshes     atomic.Uint64
+	last          atomic.Int64
+	// mu serializes applying invalidations.
+	mu sync.Mutex
 }
 
 // NewLiveRepository creates a new live repository from the provided options.
@@ -160,9 +208,12 @@ func NewLiveRepository[T any](ctx context.Context, opts LiveRepositoryOptions[T]
 		return nil, fmt.Errorf("queryKey is required")
 	}
 
+	// The TTLs of a caller-supplied cache are unknown; MaxStaleness then
+	// applies as-is.
+	var cfg cache.CacheConfig
 	cacheImpl := opts.Cache
 	if cacheImpl == nil {
-		cfg := cache.DefaultCacheConfig()
+		cfg = cache.DefaultCacheConfig()
 		if opts.CacheConfig != nil {
 			cfg = *opts.CacheConfig
 		}
@@ -183,11 +234,24 @@ func NewLiveRepository[T any](ctx context.Context, opts LiveRepositoryOptions[T]
 		queryFunc:  opts.QueryFunc,
 		cache:      cacheImpl,
 		ctx:        ctx,
+
+		source:      opts.Invalidation,
+		refresh:     opts.RefreshOnInvalidate,
+		positiveTTL: boundedTTL(cfg.PositiveTTL, opts.MaxStaleness),
+		negativeTTL: boundedTTL(cfg.NegativeTTL, opts.MaxStaleness),
+		coherence:   &coherenceStats{},
+	}
+
+	// Watch before priming so that writes landing while the cache loads are
+	// not missed.
+	if err := repo.watch(ctx); err != nil {
+		_ = cacheImpl.Close()
+		return nil, fmt.Errorf("failed to watch live repository invalidations: %w", err)
 	}
 
 	if opts.AutoLoad {
 		if err := repo.prime(ctx); err != nil {
-			_ = cacheImpl.Close()
+			_ = repo.Close()
 			return nil, fmt.Errorf("failed to prime live repository: %w", err)
 		}
 	}
@@ -195,6 +259,15 @@ func NewLiveRepository[T any](ctx context.Context, opts LiveRepositoryOptions[T]
 	return repo, nil
 }
 
+// boundedTTL returns the TTL to cache database reads with, given the cache's
+// configured default and the MaxStaleness bound.
+func boundedTTL(configured, bound time.Duration) time.Duration {
+	if bound <= 0 || (configured > 0 && configured <= bound) {
+		return cache.DefaultTTL
+	}
+	return bound
+}
+
 // prime fetches documents matching the optional query filter from the collection
 // and populates the cache.
 func (r *liveRepository[T]) prime(ctx context.Context) error {
@@ -219,7 +292,7 @@ func (r *liveRepository[T]) prime(ctx context.Context) error {
 		if err != nil {
 			continue
 		}
-		r.cache.Set(key, compiled)
+		r.cache.SetWithTTL(key, compiled, r.positiveTTL)
 		attempted++
 	}
 	return nil
@@ -276,6 +349,28 @@ func (r *liveRepository[T]) Get(key string) (T, bool) {
 	}
 
 	// CacheMiss: read-through to the database.
+	return r.load(key)
+}
+
+// load reads key from the database and caches the result, positive or
+// negative. Nothing is cached if an invalidation was applied while the read
+// was in flight.
+func (r *liveRepository[T]) load(key string) (T, bool) {
+	generation := r.generation.Load()
+	compiled, found := r.fetch(key)
+
+	// Invalidations bump the generation under coherence.mu, so holding it
+	// keeps one from slipping between the check and the store.
+	r.coherence.mu.Lock()
+	defer r.coherence.mu.Unlock()
+	if r.generation.Load() == generation {
+		r.store(key, compiled, found)
+	}
+	return compiled, found
+}
+
+// fetch reads key from the database and compiles it.
+func (r *liveRepository[T]) fetch(key string) (T, bool) {
 	var q query.Query
 	if r.queryFunc != nil {
 		q = r.queryFunc(key)
@@ -284,22 +379,27 @@ func (r *liveRepository[T]) Get(key string) (T, bool) {
 	}
 	docs, err := r.Read(context.Background(), &q)
 	if err != nil || docs == nil || docs.Count == 0 {
-		r.cache.Nullify(key)
 		var zero T
 		return zero, false
 	}
 
 	compiled, err := r.processor.Create(r.ctx, docs.Data[0])
 	if err != nil {
-		r.cache.Nullify(key)
 		var zero T
 		return zero, false
 	}
-
-	r.cache.Set(key, compiled)
 	return compiled, true
 }
 
+// store caches the result of fetch, positive or negative.
+func (r *liveRepository[T]) store(key string, compiled T, found bool) {
+	if found {
+		r.cache.SetWithTTL(key, compiled, r.positiveTTL)
+	} else {
+		r.cache.NullifyWithTTL(key, r.negativeTTL)
+	}
+}
+
 // Set stores the value in the cache only – no database operation.
 func (r *liveRepository[T]) Set(key string, value T) {
 	r.cache.Set(key, value)
@@ -334,27 +434,144 @@ func (r *liveRepository[T]) Keys() []string {
 	return r.cache.Keys()
 }
 
+// Clone returns a repository over an independent copy of the cache. The
+// clone watches the invalidation source on its own and starts with fresh
+// coherence counters.
 func (r *liveRepository[T]) Clone() (LiveCollection[T], error) {
 	clonedCache, err := r.cache.Clone()
 	if err != nil {
 		return nil, err
 	}
-	return &liveRepository[T]{
-		Collection: r.Collection,
-		processor:  r.processor,
-		queryKey:   r.queryKey,
-		queryFunc:  r.queryFunc,
-		cache:      clonedCache,
-		ctx:        r.ctx,
-	}, nil
+	clone := &liveRepository[T]{
+		Collection:  r.Collection,
+		processor:   r.processor,
+		queryKey:    r.queryKey,
+		queryFunc:   r.queryFunc,
+		cache:       clonedCache,
+		ctx:         r.ctx,
+		source:      r.source,
+		refresh:     r.refresh,
+		positiveTTL: r.positiveTTL,
+		negativeTTL: r.negativeTTL,
+		coherence:   &coherenceStats{},
+	}
+	if err := clone.watch(r.ctx); err != nil {
+		_ = clonedCache.Close()
+		return nil, err
+	}
+	return clone, nil
+}
+
+// Stats returns the cache's statistics with the coherence counters filled in.
+func (r *liveRepository[T]) Stats() cache.CacheStats {
+	stats := r.cache.Stats()
+	stats.Invalidations = r.coherence.invalidations.Load()
+	stats.InvalidationFlushes = r.coherence.flushes.Load()
+	stats.Refreshes = r.coherence.refreshes.Load()
+	if last := r.coherence.last.Load(); last != 0 {
+		stats.LastInvalidation = time.Unix(0, last)
+	}
+	return stats
 }
 
-// Close stops the underlying cache's background goroutines and releases its
-// resources. Does not affect the embedded base.Collection lifecycle.
+// Close stops watching for invalidations, then stops the underlying cache's
+// background goroutines and releases its resources. Does not affect the
+// embedded base.Collection lifecycle.
 func (r *liveRepository[T]) Close() error {
+	if r.stopWatch != nil {
+		r.stopWatch()
+	}
 	return r.cache.Close()
 }
 
+// --- Invalidation ---
+
+// watch subscribes the repository to its invalidation source, if any.
+func (r *liveRepository[T]) watch(ctx context.Context) error {
+	if r.source == nil {
+		return nil
+	}
+	stop, err := r.source.Watch(ctx, r.target(ctx), r.invalidate)
+	if err != nil {
+		return err
+	}
+	r.stopWatch = stop
+	return nil
+}
+
+// target names the watched collection both logically and physically.
+func (r *liveRepository[T]) target(ctx context.Context) InvalidationTarget {
+	var target InvalidationTarget
+	if md := r.Metadata(ctx, nil, false); md != nil {
+		target.Name = md.Name
+		target.Table = md.Collection
+	}
+	if target.Table == "" {
+		if sc, err := r.Schema(ctx); err == nil && sc != nil {
+			target.Table = sc.Name
+		}
+	}
+	if target.Table == "" {
+		target.Table = target.Name
+	}
+	return target
+}
+
+// invalidate applies one report from the invalidation source.
+func (r *liveRepository[T]) invalidate(inv Invalidation) {
+	keys := inv.Keys
+	for _, doc := range inv.Documents {
+		if doc == nil {
+			continue
+		}
+		if key, err := r.extractKey(doc); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	if !inv.All && len(keys) == 0 {
+		return
+	}
+
+	r.coherence.mu.Lock()
+	defer r.coherence.mu.Unlock()
+	r.generation.Add(1)
+	r.coherence.last.Store(time.Now().UnixNano())
+
+	if inv.All {
+		var reload []string
+		if r.refresh {
+			reload = r.cache.Keys()
+		}
+		r.cache.Clear()
+		r.coherence.flushes.Add(1)
+		r.reload(reload)
+		return
+	}
+
+	var reload []string
+	for _, key := range keys {
+		if _, cached := r.cache.TTL(key); !cached {
+			continue
+		}
+		r.cache.Evict(key)
+		r.coherence.invalidations.Add(1)
+		if r.refresh {
+			reload = append(reload, key)
+		}
+	}
+	r.reload(reload)
+}
+
+// reload reads keys back into the cache after an invalidation. The caller
+// holds coherence.mu.
+func (r *liveRepository[T]) reload(keys []string) {
+	for _, key := range keys {
+		compiled, found := r.fetch(key)
+		r.store(key, compiled, found)
+		r.coherence.refreshes.Add(1)
+	}
+}
+
 // --- Intercepted database write operations ---
 // Each override keeps the cache consistent with the underlying store.
 
@@ -375,7 +592,7 @@ func (r *liveRepository[T]) maybeCache(ctx context.Context, doc data.Documenter)
 		}
 	}
 	if compiled, compErr := r.processor.Create(ctx, doc); compErr == nil {
-		r.cache.Set(key, compiled)
+		r.cache.SetWithTTL(key, compiled, r.positiveTTL)
 	} else {
 		r.cache.Evict(key)
 	}
@@ -451,7 +668,7 @@ func (r *liveRepository[T]) Delete(ctx context.Context, filter *query.QueryFilte
 	if err == nil && count > 0 {
 		if len(keysToNullify) > 0 {
 			for _, key := range keysToNullify {
-				r.cache.Nullify(key)
+				r.cache.NullifyWithTTL(key, r.negativeTTL)
 			}
 		} else {
 			r.cache.Clear()
@@ -190,6 +190,10 @@ func (s *docStore) Subscriptions(_ context.Context) ([]base.SubscriptionInfo, er
 	return nil, nil
 }
 
+func (s *docStore) SubscribeQuery(_ context.Context, _ *query.Query, _ base.QueryCallback) (string, error) {
+	return "", nil
+}
+
 func (s *docStore) Capabilities(_ context.Context) *query.Capabilities {
 	return &query.Capabilities{}
 }
@@ -765,3 +769,244 @@ var errTest = &testError{}
 type testError struct{}
 
 func (e *testError) Error() string { return "test error" }
+
+// --- Invalidation ---
+
+// manualSource is an InvalidationSource driven directly by the test.
+type manualSource struct {
+	mu      sync.Mutex
+	target  collection.InvalidationTarget
+	handler func(collection.Invalidation)
+	stopped bool
+}
+
+func (s *manualSource) Watch(_ context.Context, target collection.InvalidationTarget, handler func(collection.Invalidation)) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.target, s.handler = target, handler
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.stopped = true
+	}, nil
+}
+
+func (s *manualSource) send(inv collection.Invalidation) {
+	s.mu.Lock()
+	handler := s.handler
+	s.mu.Unlock()
+	handler(inv)
+}
+
+func (p *recordingProcessor) createdCount(key string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, k := range p.created {
+		if k == key {
+			n++
+		}
+	}
+	return n
+}
+
+func TestLiveRepository_Invalidation_EvictsKeys(t *testing.T) {
+	source := &manualSource{}
+	h := newHarness(t, func(o *collection.LiveRepositoryOptions[string]) {
+		o.Invalidation = source
+	})
+	defer h.cleanup()
+
+	if source.target.Name != "test" || source.target.Table != "test" {
+		t.Fatalf("unexpected watch target %+v", source.target)
+	}
+
+	h.insertDoc("bob")
+	h.insertDoc("carol")
+	h.repo.Get("bob")
+	h.repo.Get("carol")
+
+	source.send(collection.Invalidation{Documents: []data.Documenter{
+		data.MustNewDocument(map[string]any{"name": "bob"}),
+	}})
+
+	if _, ok := h.repo.TTL("bob"); ok {
+		t.Fatal("expected bob to be evicted")
+	}
+	if _, ok := h.repo.TTL("carol"); !ok {
+		t.Fatal("expected carol to stay cached")
+	}
+	h.repo.Get("bob")
+	if n := h.proc.createdCount("bob"); n != 2 {
+		t.Fatalf("expected bob to be read through again, created %d times", n)
+	}
+
+	stats := h.repo.Stats()
+	if stats.Invalidations != 1 || stats.InvalidationFlushes != 0 || stats.LastInvalidation.IsZero() {
+		t.Fatalf("unexpected coherence stats %+v", stats)
+	}
+
+	h.cleanup()
+	if !source.stopped {
+		t.Fatal("expected Close to stop the watch")
+	}
+}
+
+func TestLiveRepository_Invalidation_All(t *testing.T) {
+	source := &manualSource{}
+	h := newHarness(t, func(o *collection.LiveRepositoryOptions[string]) {
+		o.Invalidation = source
+	})
+	defer h.cleanup()
+
+	h.insertDoc("bob")
+	h.repo.Get("bob")
+	h.repo.Get("ghost")
+
+	source.send(collection.Invalidation{All: true})
+
+	if keys := h.repo.Keys(); len(keys) != 0 {
+		t.Fatalf("expected empty cache, got %v", keys)
+	}
+	if _, ok := h.repo.TTL("ghost"); ok {
+		t.Fatal("expected negative entry to be flushed")
+	}
+	if stats := h.repo.Stats(); stats.InvalidationFlushes != 1 {
+		t.Fatalf("expected one flush, got %+v", stats)
+	}
+}
+
+func TestLiveRepository_Invalidation_Refresh(t *testing.T) {
+	source := &manualSource{}
+	h := newHarness(t, func(o *collection.LiveRepositoryOptions[string]) {
+		o.Invalidation = source
+		o.RefreshOnInvalidate = true
+	})
+	defer h.cleanup()
+
+	h.insertDoc("bob")
+	h.repo.Get("bob")
+
+	source.send(collection.Invalidation{All: true})
+	if _, ok := h.repo.TTL("bob"); !ok {
+		t.Fatal("expected bob to be reloaded")
+	}
+	source.send(collection.Invalidation{Keys: []string{"bob", "never-cached"}})
+	if _, ok := h.repo.TTL("never-cached"); ok {
+		t.Fatal("keys that were not cached must not be loaded")
+	}
+
+	if n := h.proc.createdCount("bob"); n != 3 {
+		t.Fatalf("expected two reloads of bob, created %d times", n)
+	}
+	if stats := h.repo.Stats(); stats.Refreshes != 2 || stats.Invalidations != 1 {
+		t.Fatalf("unexpected coherence stats %+v", stats)
+	}
+}
+
+func TestLiveRepository_MaxStaleness(t *testing.T) {
+	h := newHarness(t, func(o *collection.LiveRepositoryOptions[string]) {
+		o.MaxStaleness = time.Second
+		o.CacheConfig.PositiveTTL = time.Hour
+		o.CacheConfig.NegativeTTL = time.Millisecond * 500
+	})
+	defer h.cleanup()
+
+	h.insertDoc("bob")
+	h.repo.Get("bob")
+	h.repo.Get("ghost")
+
+	if ttl, ok := h.repo.TTL("bob"); !ok || ttl <= 0 || ttl > time.Second {
+		t.Fatalf("expected positive TTL capped at 1s, got %v (%v)", ttl, ok)
+	}
+	if ttl, ok := h.repo.TTL("ghost"); !ok || ttl > 500*time.Millisecond {
+		t.Fatalf("expected the shorter negative TTL to be kept, got %v (%v)", ttl, ok)
+	}
+
+	h.repo.Set("pinned", "pinned")
+	if ttl, ok := h.repo.TTL("pinned"); !ok || ttl <= time.Second {
+		t.Fatalf("explicit Set must not be capped, got %v", ttl)
+	}
+}
+
+// subscriberStore is a docStore that records subscriptions so tests can
+// publish events to them.
+type subscriberStore struct {
+	*docStore
+	mu        sync.Mutex
+	callbacks map[string]base.EventCallbackFunction
+}
+
+func (s *subscriberStore) Subscribe(_ context.Context, options base.SubscriptionOptions) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := fmt.Sprintf("%s-%d", options.Event, len(s.callbacks))
+	s.callbacks[id] = options.Callback
+	return id
+}
+
+func (s *subscriberStore) Unsubscribe(_ context.Context, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.callbacks, id)
+}
+
+func (s *subscriberStore) publish(event base.PersistenceEvent) {
+	s.mu.Lock()
+	var callbacks []base.EventCallbackFunction
+	for id, cb := range s.callbacks {
+		if len(id) > len(event.Type) && id[:len(event.Type)] == string(event.Type) {
+			callbacks = append(callbacks, cb)
+		}
+	}
+	s.mu.Unlock()
+	for _, cb := range callbacks {
+		_ = cb(context.Background(), event)
+	}
+}
+
+func TestLiveRepository_EventInvalidationSource(t *testing.T) {
+	bus := &subscriberStore{docStore: newDocStore(), callbacks: make(map[string]base.EventCallbackFunction)}
+	h := newHarness(t, func(o *collection.LiveRepositoryOptions[string]) {
+		o.Invalidation = collection.NewEventInvalidationSource(bus)
+	})
+	defer h.cleanup()
+
+	h.insertDoc("bob")
+	h.insertDoc("carol")
+	h.repo.Get("bob")
+	h.repo.Get("carol")
+
+	other, test := "other", "test"
+	bob := data.MustNewDocument(map[string]any{"name": "bob"})
+	bus.publish(base.PersistenceEvent{
+		Type:       base.DocumentUpdateSuccess,
+		Collection: &other,
+		Output:     &base.ReadResult{Data: data.DocumentSet{bob}, Count: 1},
+	})
+	if _, ok := h.repo.TTL("bob"); !ok {
+		t.Fatal("events for other collections must be ignored")
+	}
+
+	bus.publish(base.PersistenceEvent{
+		Type:       base.DocumentUpdateSuccess,
+		Collection: &test,
+		Output:     &base.ReadResult{Data: data.DocumentSet{bob}, Count: 1},
+	})
+	if _, ok := h.repo.TTL("bob"); ok {
+		t.Fatal("expected bob to be evicted by the update event")
+	}
+	if _, ok := h.repo.TTL("carol"); !ok {
+		t.Fatal("expected carol to stay cached")
+	}
+
+	bus.publish(base.PersistenceEvent{Type: base.DocumentDeleteSuccess, Collection: &test, Output: 1})
+	if keys := h.repo.Keys(); len(keys) != 0 {
+		t.Fatalf("expected delete event to flush the cache, got %v", keys)
+	}
+
+	h.cleanup()
+	if len(bus.callbacks) != 0 {
+		t.Fatalf("expected Close to unsubscribe, %d subscriptions left", len(bus.callbacks))
+	}
+}
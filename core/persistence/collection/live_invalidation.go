@@ -0,0 +1,147 @@
+package collection
+
+import (
+	"context"
+	"sync"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+)
+
+// Invalidation reports writes to a watched collection that a LiveCollection's
+// own interceptors did not see, typically because another process sharing the
+// same database made them.
+type Invalidation struct {
+	// Keys are cache keys known to be affected.
+	Keys []string
+	// Documents are changed documents. The QueryKey value of each is
+	// invalidated in addition to Keys.
+	Documents []data.Documenter
+	// All reports that the affected keys are unknown, so every entry must be
+	// invalidated.
+	All bool
+}
+
+// InvalidationTarget identifies the collection an InvalidationSource watches.
+type InvalidationTarget struct {
+	// Name is the logical collection name, as passed to
+	// Persistence.Collection and carried by persistence events.
+	Name string
+	// Table is the physical name the collection is stored under. It differs
+	// from Name when a decorator (e.g. tenancy) remaps collections.
+	Table string
+}
+
+// InvalidationSource notifies a LiveCollection of changes to its collection.
+//
+// Watch delivers invalidations for target to handler until the returned stop
+// function is called. handler may run on any goroutine. A source that loses
+// track of the store (a failed poll, a dropped subscription) should report
+// Invalidation{All: true} rather than stay silent.
+type InvalidationSource interface {
+	Watch(ctx context.Context, target InvalidationTarget, handler func(Invalidation)) (func(), error)
+}
+
+// EventSubscriber is the subscription half of base.Persistence and
+// base.Collection.
+type EventSubscriber interface {
+	Subscribe(ctx context.Context, options base.SubscriptionOptions) string
+	Unsubscribe(ctx context.Context, id string)
+}
+
+// eventInvalidationSource turns document write events into invalidations.
+type eventInvalidationSource struct {
+	subscriber EventSubscriber
+}
+
+// NewEventInvalidationSource returns an InvalidationSource fed by the
+// document create, update and delete success events of subscriber. When
+// those events travel over a bus shared between processes, every instance
+// learns of every other instance's writes.
+//
+// Creates and updates that return documents invalidate just their keys;
+// deletes, and events whose output cannot be interpreted (such as payloads
+// decoded from another process's JSON), invalidate the whole cache. Events
+// raised by this process's own writes are delivered too; they are redundant
+// with the interceptors but harmless.
+func NewEventInvalidationSource(subscriber EventSubscriber) InvalidationSource {
+	return &eventInvalidationSource{subscriber: subscriber}
+}
+
+var invalidatingEvents = []base.PersistenceEventType{
+	base.DocumentCreateSuccess,
+	base.DocumentUpdateSuccess,
+	base.DocumentDeleteSuccess,
+}
+
+func (s *eventInvalidationSource) Watch(ctx context.Context, target InvalidationTarget, handler func(Invalidation)) (func(), error) {
+	callback := func(_ context.Context, event base.PersistenceEvent) error {
+		if event.Collection == nil || *event.Collection != target.Name {
+			return nil
+		}
+		handler(invalidationFromEvent(event))
+		return nil
+	}
+
+	ids := make([]string, 0, len(invalidatingEvents))
+	for _, eventType := range invalidatingEvents {
+		ids = append(ids, s.subscriber.Subscribe(ctx, base.SubscriptionOptions{
+			Event:    eventType,
+			Callback: callback,
+		}))
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			for _, id := range ids {
+				s.subscriber.Unsubscribe(context.Background(), id)
+			}
+		})
+	}, nil
+}
+
+// invalidationFromEvent extracts the written documents from an event's
+// output, falling back to a full invalidation when it cannot.
+func invalidationFromEvent(event base.PersistenceEvent) Invalidation {
+	if event.Type == base.DocumentDeleteSuccess {
+		return Invalidation{All: true}
+	}
+	docs, known := WrittenDocuments(event)
+	if !known {
+		return Invalidation{All: true}
+	}
+	return Invalidation{Documents: docs}
+}
+
+// WrittenDocuments extracts the documents a create or update event wrote
+// from its output. known is false when the output cannot be interpreted; an
+// update that matched nothing wrote no documents. The documents are shared
+// with the writer, so callers keeping them must clone them.
+func WrittenDocuments(event base.PersistenceEvent) (docs []data.Documenter, known bool) {
+	switch out := event.Output.(type) {
+	case base.CreateResult:
+		docs = append(docs, out.Data)
+	case []base.CreateResult:
+		for _, res := range out {
+			docs = append(docs, res.Data)
+		}
+	case *base.ReadResult:
+		if out != nil && out.Count == 0 {
+			return nil, true
+		}
+		if out == nil || len(out.Data) == 0 {
+			return nil, false
+		}
+		docs = append(docs, out.Data...)
+	default:
+		return nil, false
+	}
+
+	for _, doc := range docs {
+		if doc == nil {
+			return nil, false
+		}
+	}
+	return docs, true
+}
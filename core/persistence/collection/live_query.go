@@ -0,0 +1,301 @@
+package collection
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/transaction"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+)
+
+// liveQueryLabel labels the event subscription backing a live query.
+var liveQueryLabel = "live query"
+
+// liveQuery keeps the result of a query current as persistence events report
+// committed writes to its collection.
+//
+// A write whose event carries the written documents is applied to the
+// previous result when the query is a plain filter and sort over the
+// collection: QueryHelper.Match decides whether each document is in the
+// result and QueryHelper.Compare where. Every other write, and every write
+// to a query with joins, aggregations, projections, pagination or the like,
+// re-executes the query. Either way subscribers receive the difference
+// between the previous and the new result.
+type liveQuery struct {
+	reader   base.Collection
+	query    *query.Query
+	callback base.QueryCallback
+	ctx      context.Context
+
+	// helper evaluates written documents against the query. It is nil when
+	// the query cannot be maintained incrementally.
+	helper *query.QueryHelper
+
+	mu     sync.Mutex
+	result *base.ReadResult
+}
+
+// subscribeQuery registers a live query reading through reader, which also
+// delivers the events it is kept current by.
+func subscribeQuery(ctx context.Context, reader base.Collection, q *query.Query, callback base.QueryCallback) (string, error) {
+	if callback == nil {
+		return "", ErrInvalidLiveQuery.WithMessage("live queries require a callback").WithOperation("SubscribeQuery")
+	}
+	if q == nil {
+		q = &query.Query{}
+	}
+	helper, err := incrementalHelper(q)
+	if err != nil {
+		return "", ErrInvalidLiveQuery.WithCause(err).WithOperation("SubscribeQuery")
+	}
+
+	lq := &liveQuery{
+		reader:   reader,
+		query:    q,
+		callback: callback,
+		ctx:      transaction.Detach(ctx),
+		helper:   helper,
+	}
+
+	// Events raised between subscribing and the initial read wait for it,
+	// so no write is missed.
+	lq.mu.Lock()
+	defer lq.mu.Unlock()
+	id := reader.Subscribe(ctx, base.SubscriptionOptions{
+		Event:    "document:*",
+		Label:    &liveQueryLabel,
+		Callback: lq.handle,
+	})
+	result, err := lq.read()
+	if err != nil {
+		reader.Unsubscribe(ctx, id)
+		return "", err
+	}
+	lq.result = result
+	lq.callback(lq.ctx, base.QueryUpdate{Result: result, Refresh: true})
+	return id, nil
+}
+
+// incrementalHelper returns the helper used to apply writes to the result of
+// q, or nil when q must be re-executed instead.
+func incrementalHelper(q *query.Query) (*query.QueryHelper, error) {
+	if q.Raw != nil || q.Union != nil || len(q.Joins) > 0 || len(q.Aggregations) > 0 ||
+		q.Distinct != nil || q.Projection != nil || q.Pagination != nil || q.Limit != nil {
+		return nil, nil
+	}
+	bound := &query.Query{Filters: q.Filters, Sort: q.Sort}
+	if q.Bindings != nil {
+		var err error
+		if bound, err = q.Bindings.Apply(bound); err != nil {
+			return nil, err
+		}
+	}
+	helper, err := query.NewQueryHelper(bound, nil, nil, nil)
+	if err != nil {
+		// Filters the helper cannot evaluate are left to the database.
+		return nil, nil
+	}
+	return helper, nil
+}
+
+// read executes the query. The reader may modify the query it is given, so
+// each read gets its own copy.
+func (lq *liveQuery) read() (*base.ReadResult, error) {
+	q, err := lq.query.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return lq.reader.Read(lq.ctx, q)
+}
+
+func (lq *liveQuery) handle(_ context.Context, event base.PersistenceEvent) error {
+	switch event.Type {
+	case base.DocumentCreateSuccess, base.DocumentUpdateSuccess, base.DocumentDeleteSuccess:
+	default:
+		return nil
+	}
+
+	lq.mu.Lock()
+	defer lq.mu.Unlock()
+
+	update, ok := lq.apply(event)
+	if !ok {
+		result, err := lq.read()
+		if err != nil {
+			lq.callback(lq.ctx, base.QueryUpdate{Result: lq.result, Error: err})
+			return nil
+		}
+		update = diffResults(lq.result, result, nil)
+	}
+	lq.result = update.Result
+	if update.Refresh || len(update.Changes) > 0 {
+		lq.callback(lq.ctx, update)
+	}
+	return nil
+}
+
+// apply applies the documents written by event to the current result,
+// reporting false when the query must be re-executed instead.
+func (lq *liveQuery) apply(event base.PersistenceEvent) (base.QueryUpdate, bool) {
+	if lq.helper == nil || event.Type == base.DocumentDeleteSuccess {
+		return base.QueryUpdate{}, false
+	}
+	written, known := WrittenDocuments(event)
+	if !known {
+		return base.QueryUpdate{}, false
+	}
+
+	rows := slices.Clone(lq.result.Data)
+	touched := make(map[string]bool, len(written))
+	for _, doc := range written {
+		id := doc.ID()
+		if id == "" {
+			return base.QueryUpdate{}, false
+		}
+		record := doc.ToMap()
+		matched, err := lq.helper.Match(record)
+		if err != nil {
+			return base.QueryUpdate{}, false
+		}
+
+		at := slices.IndexFunc(rows, func(row data.Documenter) bool { return row.ID() == id })
+		if at >= 0 {
+			rows = slices.Delete(rows, at, at+1)
+		}
+		touched[id] = true
+		if matched {
+			// Written documents are shared with the writer, so the result
+			// holds its own copy.
+			rows = slices.Insert(rows, lq.position(rows, record, at), doc.Clone())
+		}
+	}
+
+	return diffResults(lq.result, resultOf(rows, lq.result), touched), true
+}
+
+// position returns where record belongs in rows. Without a sort order a
+// document keeps its previous position and new documents are appended.
+func (lq *liveQuery) position(rows data.DocumentSet, record map[string]any, previous int) int {
+	if len(lq.query.Sort) == 0 {
+		if previous >= 0 {
+			return previous
+		}
+		return len(rows)
+	}
+	if i := slices.IndexFunc(rows, func(row data.Documenter) bool {
+		return lq.helper.Compare(record, row.ToMap()) < 0
+	}); i >= 0 {
+		return i
+	}
+	return len(rows)
+}
+
+// resultOf returns a result holding rows, with the counts of previous
+// updated to match.
+func resultOf(rows data.DocumentSet, previous *base.ReadResult) *base.ReadResult {
+	result := &base.ReadResult{Data: rows, Count: len(rows)}
+	if previous.Total != nil {
+		total := len(rows)
+		result.Total = &total
+	}
+	if previous.PaginationInfo != nil {
+		result.PaginationInfo = &query.PaginationInfo{Number: 1, Size: len(rows), Count: len(rows), Total: len(rows), Pages: 1}
+	}
+	return result
+}
+
+// diffResults returns the update turning previous into current. Documents
+// in both are reported as changed when they moved, or when they were written:
+// touched lists the written documents when known, otherwise their contents
+// are compared. Results whose rows lack document IDs are refreshed whole.
+func diffResults(previous, current *base.ReadResult, touched map[string]bool) base.QueryUpdate {
+	update := base.QueryUpdate{Result: current}
+	before, ok := rowIndex(previous.Data)
+	if !ok {
+		update.Refresh = true
+		return update
+	}
+	after, ok := rowIndex(current.Data)
+	if !ok {
+		update.Refresh = true
+		return update
+	}
+
+	for i, doc := range previous.Data {
+		if _, kept := after[doc.ID()]; !kept {
+			update.Changes = append(update.Changes, base.QueryChange{Type: base.QueryDocumentRemoved, Document: doc, Index: -1, PreviousIndex: i})
+		}
+	}
+
+	// The documents kept in the order of their previous positions stay put;
+	// the others moved.
+	var kept []int
+	for _, doc := range current.Data {
+		if i, ok := before[doc.ID()]; ok {
+			kept = append(kept, i)
+		}
+	}
+	stayed := longestIncreasing(kept)
+
+	for i, doc := range current.Data {
+		old, ok := before[doc.ID()]
+		switch {
+		case !ok:
+			update.Changes = append(update.Changes, base.QueryChange{Type: base.QueryDocumentAdded, Document: doc, Index: i, PreviousIndex: -1})
+		case !stayed[old], touched != nil && touched[doc.ID()], touched == nil && !doc.Equals(previous.Data[old]):
+			update.Changes = append(update.Changes, base.QueryChange{Type: base.QueryDocumentChanged, Document: doc, Index: i, PreviousIndex: old})
+		}
+	}
+	return update
+}
+
+// rowIndex maps the document IDs of rows to their positions, reporting false
+// when a row has no ID or shares one with another row.
+func rowIndex(rows data.DocumentSet) (map[string]int, bool) {
+	index := make(map[string]int, len(rows))
+	for i, doc := range rows {
+		if doc == nil || doc.ID() == "" {
+			return nil, false
+		}
+		if _, ok := index[doc.ID()]; ok {
+			return nil, false
+		}
+		index[doc.ID()] = i
+	}
+	return index, true
+}
+
+// longestIncreasing returns the values of a longest strictly increasing
+// subsequence of values.
+func longestIncreasing(values []int) map[int]bool {
+	// tails[k] is the index in values of the smallest tail of an increasing
+	// subsequence of length k+1; parent links each element to its
+	// predecessor in the subsequence it ends.
+	tails := make([]int, 0, len(values))
+	parent := make([]int, len(values))
+	for i, v := range values {
+		k := sort.Search(len(tails), func(k int) bool { return values[tails[k]] >= v })
+		parent[i] = -1
+		if k > 0 {
+			parent[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	stayed := make(map[int]bool, len(tails))
+	if len(tails) == 0 {
+		return stayed
+	}
+	for i := tails[len(tails)-1]; i >= 0; i = parent[i] {
+		stayed[values[i]] = true
+	}
+	return stayed
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -315,6 +316,7 @@ func (c *managedCollection) prepareFilterValue(ctx context.Context, value *query
 		NumberVal: value.NumberVal,
 		BoolVal:   value.BoolVal,
 		ObjectVal: value.ObjectVal,
+		ParamVal:  value.ParamVal,
 	}
 
 	if value.ArrayVal != nil {
@@ -363,6 +365,20 @@ func (c *managedCollection) prepareFilterValue(ctx context.Context, value *query
 		maps.Copy(translations, trans)
 	}
 
+	if value.ElementFilterVal != nil {
+		preparedFilter, trans, err := c.prepareFilter(ctx, &value.ElementFilterVal.Filter)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		prepared.ElementFilterVal = &query.ElementFilter{
+			Type:   value.ElementFilterVal.Type,
+			Filter: *preparedFilter,
+		}
+
+		maps.Copy(translations, trans)
+	}
+
 	return prepared, translations, nil
 }
 
@@ -400,11 +416,14 @@ func (c *managedCollection) Update(ctx context.Context, params *base.CollectionU
 
 	// Reject updates that carry no user payload. This must happen before the
 	// system metadata (version bump, updated timestamp) is injected below.
-	if params.Set == nil || (params.Set.Len() == 0 && len(params.Compute) == 0) {
+	if (params.Set == nil || params.Set.Len() == 0) && len(params.Compute) == 0 && len(params.Operators) == 0 {
 		return nil, base.ErrEmptyUpdate.
 			WithOperation("ManagedCollection.Update").
 			WithMessagef("update for '%s' must set or compute at least one field", c.logicalName)
 	}
+	if params.Set == nil {
+		params.Set, _ = data.NewDocument(nil)
+	}
 
 	validate := func() error {
 		result, ok := c.Validate(ctx, params.Set, true)
@@ -417,6 +436,9 @@ func (c *managedCollection) Update(ctx context.Context, params *base.CollectionU
 	if err := validate(); err != nil {
 		return nil, err
 	}
+	if err := c.validateOperators(ctx, params); err != nil {
+		return nil, err
+	}
 
 	// Prepare compute queries to resolve subqueries
 	var allTranslations map[string]string
@@ -443,16 +465,14 @@ func (c *managedCollection) Update(ctx context.Context, params *base.CollectionU
 		params.Compute = preparedCompute
 	}
 
-	if params.Compute == nil {
-		params.Compute = map[string]query.Query{}
-	}
-
+	// The metadata block changes through operators so that its other
+	// members survive, whichever backend applies the update.
 	versionField := data.MetadataFieldPath(data.MetadataVersion)
-	params.Compute[versionField] = query.NewQueryBuilder().
-		Select().
-		AddComputed(versionField, "ADD", &query.FieldReference{Field: versionField}, 1).
-		End().
-		Build()
+	updatedField := data.MetadataFieldPath(data.MetadataUpdated)
+	params.Operators = append(slices.Clone(params.Operators),
+		query.UpdateOperator{Type: query.UpdateOperatorIncrement, Field: versionField, Value: 1},
+		query.UpdateOperator{Type: query.UpdateOperatorSet, Field: updatedField, Value: strconv.FormatInt(time.Now().UnixNano(), 10)},
+	)
 
 	// Prepare the filter to resolve subqueries
 	if params.Filter != nil {
@@ -492,10 +512,6 @@ func (c *managedCollection) Update(ctx context.Context, params *base.CollectionU
 		params.Filter = qb.Build().Filters
 	}
 
-	now := strconv.FormatInt(time.Now().UnixNano(), 10)
-	updatedField := data.MetadataFieldPath(data.MetadataUpdated)
-	params.Set.Set(updatedField, now)
-
 	count, err := c.wrapped.Update(ctx, params)
 	if err != nil {
 		return count, c.sanitizeError(ctx, err, allTranslations)
@@ -503,6 +519,36 @@ func (c *managedCollection) Update(ctx context.Context, params *base.CollectionU
 	return count, nil
 }
 
+// validateOperators checks the update operators against the active schema
+// and rejects operators whose field is also replaced by Set or Compute,
+// since only one of the two changes could take effect.
+func (c *managedCollection) validateOperators(ctx context.Context, params *base.CollectionUpdate) error {
+	if len(params.Operators) == 0 {
+		return nil
+	}
+	sc, err := c.currentSchema(ctx)
+	if err != nil {
+		return err
+	}
+	if err := query.ValidateUpdateOperators(sc, params.Operators); err != nil {
+		return err
+	}
+
+	for _, op := range params.Operators {
+		conflict := slices.Contains(params.Set.Keys(), strings.SplitN(op.Field, ".", 2)[0])
+		for path := range params.Compute {
+			conflict = conflict || op.Field == path || strings.HasPrefix(path, op.Field+".") || strings.HasPrefix(op.Field, path+".")
+		}
+		if conflict {
+			return query.ErrUpdateOperatorConflict.
+				WithOperation("ManagedCollection.Update").
+				WithPath(op.Field).
+				WithMessagef("'%s' is also assigned by Set or Compute", op.Field)
+		}
+	}
+	return nil
+}
+
 // --- Passthrough Methods ---
 func (c *managedCollection) Delete(ctx context.Context, q *query.QueryFilter, unsafe bool) (int, error) {
 	if q == nil && !unsafe {
@@ -559,6 +605,12 @@ func (c *managedCollection) Subscriptions(ctx context.Context) ([]base.Subscript
 	return c.wrapped.Subscriptions(ctx)
 }
 
+// SubscribeQuery registers a live query whose reads are prepared by this
+// collection, like those passed to Read.
+func (c *managedCollection) SubscribeQuery(ctx context.Context, q *query.Query, callback base.QueryCallback) (string, error) {
+	return subscribeQuery(ctx, c, q, callback)
+}
+
 func (c *managedCollection) Capabilities(ctx context.Context) *query.Capabilities {
 	return c.wrapped.Capabilities(ctx)
 }
@@ -353,7 +353,8 @@ func (mc *ModelCollection[P]) Read(ctx context.Context, q *query.Query) ([]P, er
 // mergeUpdateOptions overlays caller-provided update options onto the
 // method-generated CollectionUpdate. Set from the options is ignored (the
 // shape-derived document always wins); a caller-supplied Filter overrides the
-// id filter; Compute maps are merged; and Version is passed through.
+// id filter; Compute maps are merged; Operators are appended; and Version is
+// passed through.
 // ReturnDocument is merged only when explicitly set (non-nil), so the
 // method-generated default (true for the binding operations) is preserved
 // unless the caller opts out.
@@ -370,6 +371,7 @@ func mergeUpdateOptions(cu base.CollectionUpdate, opts ...base.CollectionUpdate)
 				cu.Compute[k] = v
 			}
 		}
+		cu.Operators = append(cu.Operators, opt.Operators...)
 		if opt.Version != nil {
 			cu.Version = opt.Version
 		}
@@ -203,6 +203,7 @@ func (p *polyfillCollection) Update(ctx context.Context, params *base.Collection
 			Filter:         params.Filter,
 			Set:            params.Set,
 			Compute:        params.Compute,
+			Operators:      params.Operators,
 			Version:        params.Version,
 			ReturnDocument: utils.BoolPtr(false), // Polyfill is handling the return.
 		}
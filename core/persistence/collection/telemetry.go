@@ -0,0 +1,88 @@
+package collection
+
+import (
+	"context"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/transaction"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
+)
+
+// telemetryCollection reports the reads and writes of a collection as
+// operations of the telemetry.FamilyCollection family. It wraps the
+// event-emitting collection, so that a span covers the events, hooks and
+// database statements of the operation.
+type telemetryCollection struct {
+	base.Collection
+	name      string
+	telemetry telemetry.Telemetry
+}
+
+var _ base.Collection = (*telemetryCollection)(nil)
+
+func newTelemetryCollection(name string, collection base.Collection, t telemetry.Telemetry) *telemetryCollection {
+	return &telemetryCollection{Collection: collection, name: name, telemetry: t}
+}
+
+// start begins an operation, naming the collection and, inside a
+// transaction, the transaction ID.
+func (c *telemetryCollection) start(ctx context.Context, operation string) (context.Context, *telemetry.Operation) {
+	attrs := []telemetry.Attribute{telemetry.String(telemetry.KeyCollection, c.name)}
+	if tx, ok := transaction.GetCurrentTransaction(ctx); ok {
+		attrs = append(attrs, telemetry.String(telemetry.KeyTransaction, tx.ID()))
+	}
+	return c.telemetry.Start(ctx, telemetry.FamilyCollection, operation, attrs...)
+}
+
+func (c *telemetryCollection) CreateOne(ctx context.Context, doc data.Documenter) (result base.CreateResult, err error) {
+	ctx, op := c.start(ctx, "create")
+	defer func() {
+		if err == nil {
+			op.SetRows(1)
+		}
+		op.End(ctx, err)
+	}()
+	return c.Collection.CreateOne(ctx, doc)
+}
+
+func (c *telemetryCollection) CreateMany(ctx context.Context, docs []data.Documenter) (results []base.CreateResult, err error) {
+	ctx, op := c.start(ctx, "create")
+	defer func() {
+		op.SetRows(int64(len(results)))
+		op.End(ctx, err)
+	}()
+	return c.Collection.CreateMany(ctx, docs)
+}
+
+func (c *telemetryCollection) Read(ctx context.Context, q *query.Query) (result *base.ReadResult, err error) {
+	ctx, op := c.start(ctx, "read")
+	defer func() {
+		if result != nil {
+			op.SetRows(int64(result.Count))
+		}
+		op.End(ctx, err)
+	}()
+	return c.Collection.Read(ctx, q)
+}
+
+func (c *telemetryCollection) Update(ctx context.Context, params *base.CollectionUpdate) (result *base.ReadResult, err error) {
+	ctx, op := c.start(ctx, "update")
+	defer func() {
+		if result != nil {
+			op.SetRows(int64(result.Count))
+		}
+		op.End(ctx, err)
+	}()
+	return c.Collection.Update(ctx, params)
+}
+
+func (c *telemetryCollection) Delete(ctx context.Context, filter *query.QueryFilter, unsafe bool) (deleted int, err error) {
+	ctx, op := c.start(ctx, "delete")
+	defer func() {
+		op.SetRows(int64(deleted))
+		op.End(ctx, err)
+	}()
+	return c.Collection.Delete(ctx, filter, unsafe)
+}
@@ -0,0 +1,404 @@
+package hooks
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/transaction"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/utils"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"go.uber.org/zap"
+)
+
+// Config configures the hooks decorator.
+type Config struct {
+	// Registry holds the hooks to run. Collections without hooks pass
+	// through untouched.
+	Registry *Registry
+	Logger   *zap.Logger
+}
+
+// Decorator returns a collection decorator running the hooks registered in
+// cfg.Registry. See the package documentation for when each stage runs.
+func Decorator(cfg Config) utils.CollectionDecorator {
+	registry := cfg.Registry
+	if registry == nil {
+		registry = NewRegistry()
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return func(next base.Collection) base.Collection {
+		return &hookCollection{Collection: next, registry: registry, logger: logger}
+	}
+}
+
+type hookCollection struct {
+	base.Collection
+	registry *Registry
+	logger   *zap.Logger
+
+	once sync.Once
+	name string
+}
+
+var _ base.Collection = (*hookCollection)(nil)
+
+// collectionName resolves the logical name hooks are registered under. The
+// collection schema carries the physical name, so it is read once from the
+// collection metadata.
+func (c *hookCollection) collectionName(ctx context.Context) string {
+	c.once.Do(func() {
+		if metadata := c.Collection.Metadata(ctx, nil, false); metadata != nil {
+			c.name = metadata.Name
+		}
+	})
+	return c.name
+}
+
+// stages returns the hooks of the collection for a before stage and for
+// AfterCommit.
+func (c *hookCollection) stages(ctx context.Context, stage Stage) (string, []Hook, []Hook) {
+	name := c.collectionName(ctx)
+	return name, c.registry.stage(name, stage), c.registry.stage(name, AfterCommit)
+}
+
+func (c *hookCollection) CreateOne(ctx context.Context, doc data.Documenter) (base.CreateResult, error) {
+	results, err := c.create(ctx, []data.Documenter{doc}, func(ctx context.Context, docs []data.Documenter) ([]base.CreateResult, error) {
+		result, err := c.Collection.CreateOne(ctx, docs[0])
+		return []base.CreateResult{result}, err
+	})
+	if len(results) == 0 {
+		return base.CreateResult{}, err
+	}
+	return results[0], err
+}
+
+func (c *hookCollection) CreateMany(ctx context.Context, docs []data.Documenter) ([]base.CreateResult, error) {
+	return c.create(ctx, docs, c.Collection.CreateMany)
+}
+
+func (c *hookCollection) create(
+	ctx context.Context,
+	docs []data.Documenter,
+	write func(ctx context.Context, docs []data.Documenter) ([]base.CreateResult, error),
+) ([]base.CreateResult, error) {
+	name, before, after := c.stages(ctx, BeforeCreate)
+	if len(before) == 0 && len(after) == 0 {
+		return write(ctx, docs)
+	}
+
+	var results []base.CreateResult
+	_, err := c.Collection.Transact(ctx, func(tctx context.Context) (any, error) {
+		docs := slices.Clone(docs)
+		results = make([]base.CreateResult, len(docs))
+		rejected := 0
+		for i, doc := range docs {
+			change := &Change{Collection: name, Operation: OperationCreate, Document: doc, Index: i}
+			issues, err := run(tctx, before, change)
+			if err != nil {
+				return nil, err
+			}
+			docs[i] = change.Document
+			results[i] = base.CreateResult{Status: base.StatusCreated, Data: change.Document}
+			if len(issues) > 0 {
+				results[i] = base.CreateResult{Status: base.StatusFailedValidation, Data: change.Document, Issues: issues}
+				rejected++
+			}
+		}
+		if rejected > 0 {
+			return nil, base.ErrValidationFailed.
+				WithIssues(base.CreateResultSet(results).Issues()).
+				WithMessagef("hooks rejected %d documents", rejected)
+		}
+
+		var err error
+		results, err = write(tctx, docs)
+		if err != nil {
+			return nil, err
+		}
+		changes := make([]*Change, len(results))
+		for i, result := range results {
+			changes[i] = &Change{Collection: name, Operation: OperationCreate, Document: result.Data, Index: i}
+		}
+		c.afterCommit(ctx, tctx, after, changes)
+		return nil, nil
+	})
+	return results, err
+}
+
+func (c *hookCollection) Update(ctx context.Context, params *base.CollectionUpdate) (*base.ReadResult, error) {
+	name, before, after := c.stages(ctx, BeforeUpdate)
+	if params == nil || params.Filter == nil || (len(before) == 0 && len(after) == 0) {
+		return c.Collection.Update(ctx, params)
+	}
+
+	var result *base.ReadResult
+	_, err := c.Collection.Transact(ctx, func(tctx context.Context) (any, error) {
+		matched, err := c.matching(tctx, params.Filter)
+		if err != nil {
+			return nil, err
+		}
+
+		changes := make([]*Change, len(matched))
+		changed := make([]map[string]any, len(matched))
+		split := false
+		var issues []common.Issue
+		rejected := 0
+		for i, previous := range matched {
+			proposed, err := propose(previous, params)
+			if err != nil {
+				return nil, err
+			}
+			expected := proposed.Data()
+
+			change := &Change{Collection: name, Operation: OperationUpdate, Document: proposed, Previous: previous, Index: i}
+			found, err := run(tctx, before, change)
+			if err != nil {
+				return nil, err
+			}
+			if len(found) > 0 {
+				issues = append(issues, found...)
+				rejected++
+				continue
+			}
+			changes[i] = change
+			changed[i] = changedFields(expected, change.Document.Data())
+			split = split || len(changed[i]) > 0
+		}
+		if rejected > 0 {
+			return nil, base.ErrValidationFailed.WithIssues(issues).
+				WithMessagef("hooks rejected the update of %d documents", rejected)
+		}
+
+		if split {
+			result, err = c.updateEach(tctx, params, matched, changed)
+		} else {
+			result, err = c.Collection.Update(tctx, params)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(after) > 0 && len(matched) > 0 {
+			stored, err := c.byID(tctx, matched)
+			if err != nil {
+				return nil, err
+			}
+			for _, change := range changes {
+				if doc, ok := stored[change.Previous.ID()]; ok {
+					change.Document = doc
+				}
+			}
+			c.afterCommit(ctx, tctx, after, changes)
+		}
+		return nil, nil
+	})
+	return result, err
+}
+
+// propose returns previous with the Set fields and operators of params
+// applied, as the database would apply them.
+func propose(previous data.Documenter, params *base.CollectionUpdate) (data.Documenter, error) {
+	proposed := previous.Clone()
+	proposed.Merge(params.Set)
+	if len(params.Operators) == 0 {
+		return proposed, nil
+	}
+	fields := proposed.Data()
+	if err := query.ApplyUpdateOperators(fields, params.Operators); err != nil {
+		return nil, err
+	}
+	for _, key := range proposed.Keys() {
+		if _, ok := fields[key]; !ok {
+			proposed.Unset(key)
+		}
+	}
+	applied, err := data.NewDocument(fields)
+	if err != nil {
+		return nil, err
+	}
+	proposed.Merge(applied)
+	return proposed, nil
+}
+
+// updateEach applies params to each matched document separately, adding the
+// fields hooks changed on that document to the update's Set fields. The
+// changed values already account for the update, so operators and computed
+// fields writing inside a changed field are dropped for that document.
+func (c *hookCollection) updateEach(ctx context.Context, params *base.CollectionUpdate, matched []data.Documenter, changed []map[string]any) (*base.ReadResult, error) {
+	combined := &base.ReadResult{Data: data.DocumentSet{}}
+	total := 0
+	for i, previous := range matched {
+		fields := make(map[string]any)
+		if params.Set != nil {
+			maps.Copy(fields, params.Set.Data())
+		}
+		maps.Copy(fields, changed[i])
+		set, err := data.NewDocument(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		id := query.NewQueryBuilder().Where(data.DocumentIDField).Eq(previous.ID()).Build().Filters
+		update := *params
+		update.Set = set
+		update.Operators = nil
+		for _, op := range params.Operators {
+			if !within(changed[i], op.Field) {
+				update.Operators = append(update.Operators, op)
+			}
+		}
+		update.Compute = nil
+		for path, compute := range params.Compute {
+			if !within(changed[i], path) {
+				if update.Compute == nil {
+					update.Compute = make(map[string]query.Query)
+				}
+				update.Compute[path] = compute
+			}
+		}
+		update.Filter = query.NewQueryBuilder().AndFilter(*params.Filter).AndFilter(*id).Build().Filters
+
+		result, err := c.Collection.Update(ctx, &update)
+		if err != nil {
+			return nil, err
+		}
+		combined.Data = append(combined.Data, result.Data...)
+		if result.Total != nil {
+			total += *result.Total
+		}
+	}
+	combined.Count = len(combined.Data)
+	combined.Total = &total
+	return combined, nil
+}
+
+func (c *hookCollection) Delete(ctx context.Context, qf *query.QueryFilter, unsafe bool) (int, error) {
+	name, before, after := c.stages(ctx, BeforeDelete)
+	if (qf == nil && !unsafe) || (len(before) == 0 && len(after) == 0) {
+		return c.Collection.Delete(ctx, qf, unsafe)
+	}
+
+	var count int
+	_, err := c.Collection.Transact(ctx, func(tctx context.Context) (any, error) {
+		matched, err := c.matching(tctx, qf)
+		if err != nil {
+			return nil, err
+		}
+
+		changes := make([]*Change, len(matched))
+		var issues []common.Issue
+		rejected := 0
+		for i, previous := range matched {
+			changes[i] = &Change{Collection: name, Operation: OperationDelete, Previous: previous, Index: i}
+			found, err := run(tctx, before, changes[i])
+			if err != nil {
+				return nil, err
+			}
+			if len(found) > 0 {
+				issues = append(issues, found...)
+				rejected++
+			}
+		}
+		if rejected > 0 {
+			return nil, base.ErrValidationFailed.WithIssues(issues).
+				WithMessagef("hooks rejected the deletion of %d documents", rejected)
+		}
+
+		count, err = c.Collection.Delete(tctx, qf, unsafe)
+		if err != nil {
+			return nil, err
+		}
+		c.afterCommit(ctx, tctx, after, changes)
+		return nil, nil
+	})
+	return count, err
+}
+
+// matching reads the documents filter selects.
+func (c *hookCollection) matching(ctx context.Context, filter *query.QueryFilter) (data.DocumentSet, error) {
+	result, err := c.Collection.Read(ctx, &query.Query{Filters: filter})
+	if err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// byID reads the current version of docs, keyed by document ID.
+func (c *hookCollection) byID(ctx context.Context, docs data.DocumentSet) (map[string]data.Documenter, error) {
+	ids := make([]any, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID()
+	}
+	current, err := c.matching(ctx, query.NewQueryBuilder().Where(data.DocumentIDField).In(ids...).Build().Filters)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]data.Documenter, len(current))
+	for _, doc := range current {
+		stored[doc.ID()] = doc
+	}
+	return stored, nil
+}
+
+// afterCommit runs hooks for changes once the transaction in tctx commits.
+// Every hook runs for every change; failures are logged.
+func (c *hookCollection) afterCommit(ctx, tctx context.Context, hooks []Hook, changes []*Change) {
+	if len(hooks) == 0 || len(changes) == 0 {
+		return
+	}
+	tx, ok := transaction.GetCurrentTransaction(tctx)
+	if !ok {
+		return
+	}
+	tx.OnCommit(func() {
+		cctx := transaction.Detach(ctx)
+		for _, change := range changes {
+			for _, hook := range hooks {
+				issues, err := run(cctx, []Hook{hook}, change)
+				if err != nil || len(issues) > 0 {
+					c.logger.Warn("after-commit hook failed",
+						zap.String("collection", change.Collection),
+						zap.String("hook", hook.Name),
+						zap.String("operation", string(change.Operation)),
+						zap.Any("issues", issues),
+						zap.Error(err),
+					)
+				}
+			}
+		}
+	})
+}
+
+// changedFields returns the fields of actual that differ from expected, with
+// fields removed from expected set to nil.
+func changedFields(expected, actual map[string]any) map[string]any {
+	changed := make(map[string]any)
+	for field, value := range actual {
+		if previous, ok := expected[field]; !ok || !reflect.DeepEqual(previous, value) {
+			changed[field] = value
+		}
+	}
+	for field := range expected {
+		if _, ok := actual[field]; !ok {
+			changed[field] = nil
+		}
+	}
+	return changed
+}
+
+// within reports whether path is one of fields or lies inside one.
+func within(fields map[string]any, path string) bool {
+	top, _, _ := strings.Cut(path, ".")
+	_, ok := fields[top]
+	return ok
+}
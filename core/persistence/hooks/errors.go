@@ -0,0 +1,15 @@
+package hooks
+
+import (
+	"github.com/asaidimu/go-anansi/v8/core/common"
+)
+
+// Errors returned by the hooks layer.
+var (
+	ErrInvalidHook   = common.NewSystemError("ERR_HOOK_INVALID", "invalid lifecycle hook")
+	ErrDuplicateHook = common.NewSystemError("ERR_HOOK_DUPLICATE", "a lifecycle hook with this name is already registered")
+	ErrHookFailed    = common.NewSystemError("ERR_HOOK_FAILED", "lifecycle hook failed")
+)
+
+// IssueRejected is the code given to issues returned by a hook without one.
+const IssueRejected = "HOOK_REJECTED"
@@ -0,0 +1,217 @@
+// Package hooks runs registered functions synchronously around the writes of
+// a collection. Persistence events report a write to subscribers; hooks take
+// part in it. A before hook runs inside the write's transaction and may change
+// the document being written or reject the write, and an after-commit hook
+// runs once the transaction holding the write has committed.
+//
+// # Stages
+//
+//   - BeforeCreate runs once per document passed to CreateOne or CreateMany,
+//     before schema validation. Change.Document is the document to insert.
+//   - BeforeUpdate runs once per document matched by the update filter.
+//     Change.Previous is the stored document and Change.Document the stored
+//     document with the update's Set fields and operators applied. Computed
+//     fields are evaluated by the database, so they hold their stored value.
+//   - BeforeDelete runs once per document matched by the delete filter, with
+//     the stored document in Change.Previous.
+//   - AfterCommit runs once per document written, after the outermost
+//     transaction commits. Change.Document is the document as stored, and
+//     Change.Previous the document before an update or delete.
+//
+// Before hooks see the transaction in their context, so collection
+// operations they perform are part of the write. A hook changes the document
+// by modifying Change.Document or replacing it. Changes made to an updated
+// document are written in addition to the update's Set fields, replacing the
+// operators and computed fields writing inside the changed fields; when any
+// hook makes one, the update is applied one document at a time.
+//
+// # Rejecting writes
+//
+// A before hook rejects a document by returning issues. The write is rolled
+// back and fails with base.ErrValidationFailed carrying the issues; rejected
+// creates are reported with base.StatusFailedValidation. Later hooks do not
+// run for a rejected document. A hook returning an error aborts the write
+// with ErrHookFailed. After-commit hooks cannot undo the write, so their
+// issues and errors are logged.
+//
+// # Registration
+//
+// Hooks are registered on a Registry per logical collection name and run in
+// registration order within their stage. Hooks lists them in that order. The
+// registry may change at any time; a write runs the hooks registered when it
+// starts.
+//
+//	registry := hooks.NewRegistry()
+//	err := registry.Register("orders", hooks.Hook{
+//		Name:  "reject-empty",
+//		Stage: hooks.BeforeCreate,
+//		Func: func(ctx context.Context, change *hooks.Change) ([]common.Issue, error) {
+//			if total, _ := change.Document.GetFloat64("total"); total <= 0 {
+//				return []common.Issue{{Path: "total", Message: "order total must be positive"}}, nil
+//			}
+//			return nil, nil
+//		},
+//	})
+//
+//	decorators := &utils.Decorators{
+//		CollectionDecorators: []utils.DecoratorFunc[base.Collection]{
+//			(utils.DecoratorFunc[base.Collection])(hooks.Decorator(hooks.Config{Registry: registry})),
+//		},
+//	}
+package hooks
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+)
+
+// Stage is the point in a write at which a hook runs.
+type Stage string
+
+const (
+	BeforeCreate Stage = "before_create"
+	BeforeUpdate Stage = "before_update"
+	BeforeDelete Stage = "before_delete"
+	AfterCommit  Stage = "after_commit"
+)
+
+// Operation is the kind of write a Change describes.
+type Operation string
+
+const (
+	OperationCreate Operation = "create"
+	OperationUpdate Operation = "update"
+	OperationDelete Operation = "delete"
+)
+
+// Change describes the document a hook runs for.
+type Change struct {
+	Collection string
+	Operation  Operation
+	// Document is the document being written. Before hooks may modify or
+	// replace it. It is nil for deletes.
+	Document data.Documenter
+	// Previous is the stored document for updates and deletes.
+	Previous data.Documenter
+	// Index is the position of the document in the CreateMany batch, or
+	// among the documents matched by an update or delete.
+	Index int
+}
+
+// Func is the function a hook runs. Returning issues rejects the document;
+// returning an error aborts the write.
+type Func func(ctx context.Context, change *Change) ([]common.Issue, error)
+
+// Hook is a named function run at a stage of the writes to a collection.
+type Hook struct {
+	Name        string
+	Stage       Stage
+	Description string
+	Func        Func
+}
+
+// Registry holds the hooks of each collection.
+type Registry struct {
+	mu    sync.RWMutex
+	hooks map[string][]Hook
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{hooks: make(map[string][]Hook)}
+}
+
+// Register appends hooks to those of collection. Hook names must be unique
+// within a collection.
+func (r *Registry) Register(collection string, hooks ...Hook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	registered := slices.Clone(r.hooks[collection])
+	for _, hook := range hooks {
+		if hook.Name == "" || hook.Func == nil {
+			return ErrInvalidHook.WithOperation("Registry.Register").WithMessage("hooks require a name and a function")
+		}
+		switch hook.Stage {
+		case BeforeCreate, BeforeUpdate, BeforeDelete, AfterCommit:
+		default:
+			return ErrInvalidHook.WithOperation("Registry.Register").WithMessagef("hook %q has unknown stage %q", hook.Name, hook.Stage)
+		}
+		if slices.ContainsFunc(registered, func(h Hook) bool { return h.Name == hook.Name }) {
+			return ErrDuplicateHook.WithOperation("Registry.Register").WithMessagef("collection %q already has a hook named %q", collection, hook.Name)
+		}
+		registered = append(registered, hook)
+	}
+	r.hooks[collection] = registered
+	return nil
+}
+
+// Unregister removes the hook name from collection, reporting whether it
+// was registered.
+func (r *Registry) Unregister(collection, name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	registered := r.hooks[collection]
+	i := slices.IndexFunc(registered, func(h Hook) bool { return h.Name == name })
+	if i < 0 {
+		return false
+	}
+	r.hooks[collection] = slices.Delete(slices.Clone(registered), i, i+1)
+	return true
+}
+
+// Hooks returns the hooks of collection in registration order.
+func (r *Registry) Hooks(collection string) []Hook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.hooks[collection])
+}
+
+// stage returns the hooks of collection that run at stage, in order.
+func (r *Registry) stage(collection string, stage Stage) []Hook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var hooks []Hook
+	for _, hook := range r.hooks[collection] {
+		if hook.Stage == stage {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+// run runs hooks for change, stopping at the first hook that rejects it.
+// Issues are given the change's index and, when they have none, the
+// IssueRejected code.
+func run(ctx context.Context, hooks []Hook, change *Change) ([]common.Issue, error) {
+	for _, hook := range hooks {
+		issues, err := hook.Func(ctx, change)
+		if err != nil {
+			return nil, ErrHookFailed.WithOperation(hook.Name).WithCause(err).
+				WithMessagef("hook %q failed for %s on %q", hook.Name, change.Operation, change.Collection)
+		}
+		if len(issues) > 0 {
+			for i := range issues {
+				if issues[i].Code == "" {
+					issues[i].Code = IssueRejected
+				}
+				if issues[i].Index == nil {
+					index := change.Index
+					issues[i].Index = &index
+				}
+			}
+			return issues, nil
+		}
+		if change.Operation != OperationDelete && change.Document == nil {
+			return nil, ErrHookFailed.WithOperation(hook.Name).
+				WithMessagef("hook %q removed the document to %s", hook.Name, change.Operation)
+		}
+	}
+	return nil, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/asaidimu/go-anansi/v8/core/data"
 	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
 	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
 	"github.com/google/uuid"
 )
 
@@ -29,6 +30,7 @@ type DataMigrator interface {
 type DefaultDataMigrator struct {
 	interactor query.DatabaseInteractor
 	registry   base.CollectionRegistry
+	telemetry  telemetry.Telemetry
 }
 
 func NewDefaultDataMigrator(interactor query.DatabaseInteractor, registry base.CollectionRegistry) *DefaultDataMigrator {
@@ -38,13 +40,25 @@ func NewDefaultDataMigrator(interactor query.DatabaseInteractor, registry base.C
 	}
 }
 
+// SetTelemetry reports every migration, and the documents it copies, through t.
+func (m *DefaultDataMigrator) SetTelemetry(t telemetry.Telemetry) {
+	m.telemetry = t
+}
+
 // Migrate copies every document from the source version, transforms it, and
 // inserts it into the destination version. It returns a job ID on success.
 func (m *DefaultDataMigrator) Migrate(
 	ctx context.Context,
 	collectionName, sourceVersion, destVersion string,
 	transformer Transformer,
-) (string, error) {
+) (_ string, err error) {
+	ctx, op := m.telemetry.Start(ctx, telemetry.FamilyMigration, "migrate", telemetry.String(telemetry.KeyCollection, collectionName))
+	var migrated int
+	defer func() {
+		op.SetRows(int64(migrated))
+		op.End(ctx, err)
+	}()
+
 	if transformer == nil {
 		return "", fmt.Errorf("transformer is required for data migration")
 	}
@@ -108,6 +122,7 @@ func (m *DefaultDataMigrator) Migrate(
 	if _, err := m.interactor.InsertDocuments(ctx, dstSchema, insertBatch); err != nil {
 		return "", fmt.Errorf("write to destination: %w", err)
 	}
+	migrated = len(insertBatch)
 
 	return jobID, nil
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/asaidimu/go-anansi/v8/core/query"
 	"github.com/asaidimu/go-anansi/v8/core/schema"
 	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -46,10 +47,12 @@ func newBasePersistence(
 	eventEmitter *cevents.EventEmitter[base.PersistenceEvent],
 	logger *zap.Logger,
 	decorators []utils.DecoratorFunc[base.Collection],
+	t telemetry.Telemetry,
 ) (base.Persistence, error) {
 
 	registrySchema := registry.RegistrySchema()
 	engine := query.NewQueryEngine(interactor.Capabilities(), logger)
+	engine.SetTelemetry(t)
 	registryProvider := collection.NewStaticSchemaProvider(registrySchema)
 	registryCollection, err := collection.NewCollection(eventEmitter,
 		registry.REGISTRY_COLLECTION_NAME,
@@ -301,7 +304,7 @@ func (p *basePersistence) Subscriptions(ctx context.Context) ([]base.Subscriptio
 func (p *basePersistence) Transact(ctx context.Context, callback func(ctx context.Context, tx base.BasePersistence) (any, error)) (any, error) {
 	execute := func() (any, error) {
 		return transaction.Execute(ctx, p.interactor, p.logger, func(tctx context.Context, txInteractor query.DatabaseInteractor) (any, error) {
-			txBasePersistence, err := newBasePersistence(txInteractor, p.eventEmitter, p.logger, p.decorators)
+			txBasePersistence, err := newBasePersistence(txInteractor, p.eventEmitter, p.logger, p.decorators, p.engine.Telemetry())
 			if err != nil {
 				return nil, common.SystemErrorFrom(err, "ERR_TRANSACTION_PERSISTENCE_CREATION_FAILED", "failed to create transaction persistence instance").WithOperation("basePersistence.Transact")
 			}
@@ -692,6 +695,7 @@ func (p *basePersistence) migrateWithCopy(
 		)
 
 		migrator := migration.NewDefaultDataMigrator(p.interactor, p.registry)
+		migrator.SetTelemetry(p.engine.Telemetry())
 
 		wrappedTransformer := func(tctx context.Context, doc data.Document) (data.Document, error) {
 			return plan.Transformer(tctx, doc)
@@ -1,25 +1,51 @@
 package persistence
 
 import (
+	"context"
+	"time"
+
 	cevents "github.com/asaidimu/go-anansi/v8/core/events"
 	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
 	"github.com/asaidimu/go-anansi/v8/core/persistence/events"
 	"github.com/asaidimu/go-anansi/v8/core/persistence/utils"
 	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
 	"go.uber.org/zap"
 )
 
+// Option configures NewPersistence.
+type Option func(*options)
+
+type options struct {
+	telemetry telemetry.Telemetry
+}
+
+// WithTelemetry reports collection operations, query engine stages, data
+// migrations and, when the interactor is instrumented, database statements
+// through t.
+func WithTelemetry(t telemetry.Telemetry) Option {
+	return func(o *options) {
+		o.telemetry = t
+	}
+}
+
 func NewPersistence(
 	interactor query.DatabaseInteractor,
 	bus cevents.EventBus[base.PersistenceEvent],
 	logger *zap.Logger,
 	decorators *utils.Decorators,
+	opts ...Option,
 ) (base.Persistence, error) {
 
 	if logger == nil {
 		logger = zap.NewNop()
 	}
 
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var collectionDecorators []utils.DecoratorFunc[base.Collection]
 
 	if decorators != nil && decorators.CollectionDecorators != nil {
@@ -34,7 +60,20 @@ func NewPersistence(
 
 	eventEmitter := cevents.NewEventEmitter(bus, factory.CreateEvent, logger)
 
-	base, err := newBasePersistence(interactor, eventEmitter, logger, collectionDecorators)
+	// Backends that publish telemetry, such as the SQLite statement cache,
+	// report through the bus as telemetry events.
+	if source, ok := interactor.(query.TelemetrySource); ok && bus != nil {
+		source.SetTelemetrySink(func(name string, data map[string]any) {
+			ctx := context.Background()
+			eventEmitter.EmitEvent(ctx, string(base.Telemetry), factory.CreateEvent(ctx, string(base.Telemetry), name, nil, data, nil, time.Now(), nil, nil))
+		})
+	}
+
+	if instrumented, ok := interactor.(query.Instrumented); ok && o.telemetry.Enabled() {
+		instrumented.SetTelemetry(o.telemetry)
+	}
+
+	base, err := newBasePersistence(interactor, eventEmitter, logger, collectionDecorators, o.telemetry)
 	if err != nil {
 		return nil, err
 	}
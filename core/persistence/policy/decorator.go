@@ -0,0 +1,452 @@
+package policy
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/utils"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"go.uber.org/zap"
+)
+
+// Config configures the policy decorator.
+type Config struct {
+	// Policies to enforce, at most one per collection. Collections without a
+	// policy pass through untouched.
+	Policies []Policy
+	Logger   *zap.Logger
+}
+
+// Decorator returns a collection decorator enforcing cfg.Policies. See the
+// package documentation for the enforcement rules.
+func Decorator(cfg Config) utils.CollectionDecorator {
+	policies := make(map[string]Policy, len(cfg.Policies))
+	for _, p := range cfg.Policies {
+		policies[p.Collection] = p
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return func(next base.Collection) base.Collection {
+		return &policyCollection{Collection: next, policies: policies, logger: logger}
+	}
+}
+
+type policyCollection struct {
+	base.Collection
+	policies map[string]Policy
+	logger   *zap.Logger
+
+	once   sync.Once
+	policy *Policy
+}
+
+var _ base.Collection = (*policyCollection)(nil)
+
+// resolvePolicy finds the policy for the wrapped collection. The collection
+// schema carries the physical name, so the logical name is read once from
+// the collection metadata.
+func (c *policyCollection) resolvePolicy(ctx context.Context) *Policy {
+	c.once.Do(func() {
+		metadata := c.Collection.Metadata(ctx, nil, false)
+		if metadata == nil {
+			return
+		}
+		if p, ok := c.policies[metadata.Name]; ok {
+			c.policy = &p
+		}
+	})
+	return c.policy
+}
+
+// authorize resolves the grant for action. A nil grant with a nil error means
+// the collection has no policy.
+func (c *policyCollection) authorize(ctx context.Context, action Action, operation string) (*grant, error) {
+	p := c.resolvePolicy(ctx)
+	if p == nil {
+		return nil, nil
+	}
+	principal, ok := common.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrPrincipalRequired.WithOperation(operation)
+	}
+	g, issues := p.resolve(action, principal)
+	if len(issues) > 0 {
+		c.logger.Debug("policy denied operation",
+			zap.String("collection", p.Collection),
+			zap.String("action", string(action)),
+			zap.String("principal", principal.ID),
+		)
+		return nil, denied(operation, issues)
+	}
+	return g, nil
+}
+
+// scope AND-s the grant's row filter into qf without modifying qf.
+func (g *grant) scope(qf *query.QueryFilter) *query.QueryFilter {
+	if g.filter == nil {
+		return qf
+	}
+	if qf == nil {
+		return g.filter
+	}
+	return &query.QueryFilter{
+		Group: &query.FilterGroup{
+			Operator:   common.LogicalAnd,
+			Conditions: []query.QueryFilter{*qf, *g.filter},
+		},
+	}
+}
+
+// checkWrite rejects writes to hidden or read-only fields.
+func (g *grant) checkWrite(fields []string) []common.Issue {
+	var issues []common.Issue
+	for _, field := range fields {
+		if slices.Contains(g.readOnly, field) {
+			issues = append(issues, deny(IssueReadOnlyField, field, "field %q is read-only", field))
+		} else if g.hides(field) {
+			issues = append(issues, deny(IssueHiddenField, field, "field %q cannot be written", field))
+		}
+	}
+	return issues
+}
+
+// redact removes hidden fields from documents returned to the caller.
+func (g *grant) redact(result *base.ReadResult) {
+	if result == nil || len(g.hidden) == 0 {
+		return
+	}
+	for _, doc := range result.Data {
+		if doc == nil {
+			continue
+		}
+		for _, field := range g.hidden {
+			_ = doc.Delete(field)
+		}
+	}
+}
+
+// redactUpdate returns a copy of a live query update with hidden fields
+// removed. The live query keeps its documents between updates, so they are
+// copied rather than redacted in place.
+func (g *grant) redactUpdate(update base.QueryUpdate) base.QueryUpdate {
+	copies := make(map[data.Documenter]data.Documenter)
+	redacted := func(doc data.Documenter) data.Documenter {
+		if doc == nil {
+			return nil
+		}
+		if c, ok := copies[doc]; ok {
+			return c
+		}
+		c := doc.Clone()
+		for _, field := range g.hidden {
+			_ = c.Delete(field)
+		}
+		copies[doc] = c
+		return c
+	}
+
+	if update.Result != nil {
+		result := *update.Result
+		result.Data = make(data.DocumentSet, len(update.Result.Data))
+		for i, doc := range update.Result.Data {
+			result.Data[i] = redacted(doc)
+		}
+		update.Result = &result
+	}
+	if len(update.Changes) > 0 {
+		changes := make([]base.QueryChange, len(update.Changes))
+		for i, change := range update.Changes {
+			change.Document = redacted(change.Document)
+			changes[i] = change
+		}
+		update.Changes = changes
+	}
+	return update
+}
+
+// visible returns a redacted copy of doc when the grant covers its row.
+func (g *grant) visible(helper *query.QueryHelper, doc data.Documenter) (data.Documenter, bool) {
+	if doc == nil {
+		return nil, false
+	}
+	if g.filter != nil {
+		if helper == nil {
+			return nil, false
+		}
+		if matched, err := helper.Match(doc.ToMap(), g.filter); err != nil || !matched {
+			return nil, false
+		}
+	}
+	c := doc.Clone()
+	for _, field := range g.hidden {
+		_ = c.Delete(field)
+	}
+	return c, true
+}
+
+// visibleEvent narrows event to what the grant lets its subscriber read. The
+// writer's request and the number of deleted rows may describe rows outside
+// the grant, so they are withheld; written documents are kept only when the
+// grant covers them, and redacted. An event whose documents are all outside
+// the grant is not delivered.
+func (g *grant) visibleEvent(event base.PersistenceEvent) (base.PersistenceEvent, bool) {
+	event.Input, event.Query = nil, nil
+	var helper *query.QueryHelper
+	if g.filter != nil {
+		helper, _ = query.NewQueryHelper(&query.Query{}, nil, nil, nil)
+	}
+
+	switch output := event.Output.(type) {
+	case base.CreateResult:
+		doc, ok := g.visible(helper, output.Data)
+		if !ok {
+			return event, false
+		}
+		output.Data = doc
+		event.Output = output
+	case []base.CreateResult:
+		var results []base.CreateResult
+		for _, result := range output {
+			if doc, ok := g.visible(helper, result.Data); ok {
+				result.Data = doc
+				results = append(results, result)
+			}
+		}
+		if len(results) == 0 {
+			return event, false
+		}
+		event.Output = results
+	case *base.ReadResult:
+		if output == nil || len(output.Data) == 0 {
+			break
+		}
+		result := base.ReadResult{}
+		for _, doc := range output.Data {
+			if doc, ok := g.visible(helper, doc); ok {
+				result.Data = append(result.Data, doc)
+			}
+		}
+		if len(result.Data) == 0 {
+			return event, false
+		}
+		result.Count = len(result.Data)
+		event.Output = &result
+	default:
+		event.Output = nil
+	}
+	return event, true
+}
+
+// checkPayload verifies a document to be created falls within the rows the
+// grant covers.
+func (g *grant) checkPayload(doc data.Documenter, index int) []common.Issue {
+	issues := g.checkWrite(doc.Keys())
+	if g.filter == nil {
+		return issues
+	}
+	helper, err := query.NewQueryHelper(&query.Query{}, nil, nil, nil)
+	if err == nil {
+		var matched bool
+		if matched, err = helper.Match(doc.ToMap(), g.filter); err == nil {
+			if !matched {
+				issue := deny(IssueRowNotPermitted, "", "document is outside the rows the principal may create")
+				issue.Index = &index
+				issues = append(issues, issue)
+			}
+			return issues
+		}
+	}
+	issue := deny(IssuePayloadNotEvaluable, "", "document could not be checked against the policy: %v", err)
+	issue.Index = &index
+	return append(issues, issue)
+}
+
+func (c *policyCollection) CreateOne(ctx context.Context, doc data.Documenter) (base.CreateResult, error) {
+	g, err := c.authorize(ctx, ActionCreate, "CreateOne")
+	if err != nil {
+		return base.CreateResult{}, err
+	}
+	if g != nil && doc != nil {
+		if issues := g.checkPayload(doc, 0); len(issues) > 0 {
+			return base.CreateResult{}, denied("CreateOne", issues)
+		}
+	}
+	return c.Collection.CreateOne(ctx, doc)
+}
+
+func (c *policyCollection) CreateMany(ctx context.Context, docs []data.Documenter) ([]base.CreateResult, error) {
+	g, err := c.authorize(ctx, ActionCreate, "CreateMany")
+	if err != nil {
+		return nil, err
+	}
+	if g != nil {
+		var issues []common.Issue
+		for i, doc := range docs {
+			if doc != nil {
+				issues = append(issues, g.checkPayload(doc, i)...)
+			}
+		}
+		if len(issues) > 0 {
+			return nil, denied("CreateMany", issues)
+		}
+	}
+	return c.Collection.CreateMany(ctx, docs)
+}
+
+func (c *policyCollection) Read(ctx context.Context, q *query.Query) (*base.ReadResult, error) {
+	g, err := c.authorize(ctx, ActionRead, "Read")
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return c.Collection.Read(ctx, q)
+	}
+
+	scoped, err := g.scopeQuery(q, "Read")
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.Collection.Read(ctx, scoped)
+	if err != nil {
+		return nil, err
+	}
+	g.redact(result)
+	return result, nil
+}
+
+// SubscribeQuery authorizes a live query when it is registered and scopes it
+// like Read. Its updates are redacted before they reach callback.
+func (c *policyCollection) SubscribeQuery(ctx context.Context, q *query.Query, callback base.QueryCallback) (string, error) {
+	g, err := c.authorize(ctx, ActionRead, "SubscribeQuery")
+	if err != nil {
+		return "", err
+	}
+	if g == nil {
+		return c.Collection.SubscribeQuery(ctx, q, callback)
+	}
+
+	scoped, err := g.scopeQuery(q, "SubscribeQuery")
+	if err != nil {
+		return "", err
+	}
+	if callback == nil || len(g.hidden) == 0 {
+		return c.Collection.SubscribeQuery(ctx, scoped, callback)
+	}
+	return c.Collection.SubscribeQuery(ctx, scoped, func(ctx context.Context, update base.QueryUpdate) {
+		callback(ctx, g.redactUpdate(update))
+	})
+}
+
+// Subscribe authorizes the subscriber to read the collection and delivers
+// events narrowed to the rows and fields it may read (see visibleEvent). A
+// denied subscription is logged and returns an empty ID.
+func (c *policyCollection) Subscribe(ctx context.Context, options base.SubscriptionOptions) string {
+	g, err := c.authorize(ctx, ActionRead, "Subscribe")
+	if err != nil {
+		c.logger.Warn("rejected subscription", zap.Error(err))
+		return ""
+	}
+	if g == nil || options.Callback == nil {
+		return c.Collection.Subscribe(ctx, options)
+	}
+	callback := options.Callback
+	options.Callback = func(ctx context.Context, event base.PersistenceEvent) error {
+		if event, ok := g.visibleEvent(event); ok {
+			return callback(ctx, event)
+		}
+		return nil
+	}
+	return c.Collection.Subscribe(ctx, options)
+}
+
+// scopeQuery checks q against the grant and returns a copy restricted to the
+// rows and fields it covers.
+func (g *grant) scopeQuery(q *query.Query, operation string) (*query.Query, error) {
+	var scoped query.Query
+	if q != nil {
+		scoped = *q
+	}
+	if scoped.Raw != nil || scoped.Union != nil {
+		return nil, denied(operation, []common.Issue{deny(IssueUnsupportedQuery, "", "raw and union queries cannot be checked against a collection policy")})
+	}
+
+	if issues := g.checkReferences(queryReferences(&scoped)); len(issues) > 0 {
+		return nil, denied(operation, issues)
+	}
+
+	scoped.Filters = g.scope(scoped.Filters)
+	return &scoped, nil
+}
+
+func (c *policyCollection) Update(ctx context.Context, params *base.CollectionUpdate) (*base.ReadResult, error) {
+	g, err := c.authorize(ctx, ActionUpdate, "Update")
+	if err != nil {
+		return nil, err
+	}
+	if g == nil || params == nil {
+		return c.Collection.Update(ctx, params)
+	}
+	// Scoping would turn a missing filter into "every permitted row".
+	if params.Filter == nil {
+		return nil, base.ErrInvalidUpdateParams
+	}
+
+	var fields []string
+	if params.Set != nil {
+		fields = append(fields, params.Set.Keys()...)
+	}
+	for field := range params.Compute {
+		fields = append(fields, field)
+	}
+	for _, op := range params.Operators {
+		fields = append(fields, strings.SplitN(op.Field, ".", 2)[0])
+	}
+	issues := append(g.checkWrite(fields), g.checkFilter(params.Filter)...)
+	// A computed value may read other fields of the document, so it must not
+	// read a hidden one into a visible field.
+	for _, field := range slices.Sorted(maps.Keys(params.Compute)) {
+		compute := params.Compute[field]
+		if compute.Raw != nil || compute.Union != nil {
+			issues = append(issues, deny(IssueUnsupportedQuery, field, "raw and union computed values cannot be checked against a collection policy"))
+			continue
+		}
+		issues = append(issues, g.checkReferences(queryReferences(&compute))...)
+	}
+	if len(issues) > 0 {
+		return nil, denied("Update", issues)
+	}
+
+	scoped := *params
+	scoped.Filter = g.scope(params.Filter)
+	result, err := c.Collection.Update(ctx, &scoped)
+	if err != nil {
+		return nil, err
+	}
+	g.redact(result)
+	return result, nil
+}
+
+func (c *policyCollection) Delete(ctx context.Context, qf *query.QueryFilter, unsafe bool) (int, error) {
+	g, err := c.authorize(ctx, ActionDelete, "Delete")
+	if err != nil {
+		return 0, err
+	}
+	if g == nil {
+		return c.Collection.Delete(ctx, qf, unsafe)
+	}
+	if qf == nil && !unsafe {
+		return 0, base.ErrDeleteRequiresFilter
+	}
+	if issues := g.checkFilter(qf); len(issues) > 0 {
+		return 0, denied("Delete", issues)
+	}
+	return c.Collection.Delete(ctx, g.scope(qf), unsafe)
+}
@@ -0,0 +1,37 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+)
+
+// Errors returned by the policy layer.
+var (
+	ErrAccessDenied      = common.NewSystemError("ERR_POLICY_ACCESS_DENIED", "access denied by collection policy")
+	ErrPrincipalRequired = common.NewSystemError("ERR_POLICY_PRINCIPAL_REQUIRED", "operation requires a principal in context")
+)
+
+// Issue codes attached to ErrAccessDenied.
+const (
+	IssueNoMatchingRule      = "POLICY_NO_MATCHING_RULE"
+	IssueUnresolvedTemplate  = "POLICY_UNRESOLVED_TEMPLATE"
+	IssueRowNotPermitted     = "POLICY_ROW_NOT_PERMITTED"
+	IssueHiddenField         = "POLICY_HIDDEN_FIELD"
+	IssueReadOnlyField       = "POLICY_READ_ONLY_FIELD"
+	IssueUnsupportedQuery    = "POLICY_UNSUPPORTED_QUERY"
+	IssuePayloadNotEvaluable = "POLICY_PAYLOAD_NOT_EVALUABLE"
+)
+
+func deny(code, path, format string, args ...any) common.Issue {
+	return common.Issue{
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Path:     path,
+		Severity: common.SeverityError,
+	}
+}
+
+func denied(operation string, issues []common.Issue) error {
+	return ErrAccessDenied.WithOperation(operation).WithIssues(issues)
+}
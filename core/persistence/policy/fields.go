@@ -0,0 +1,192 @@
+package policy
+
+import (
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+)
+
+// reference is a document field a query reads, and how it reads it.
+type reference struct {
+	field string
+	use   string
+}
+
+// hides reports whether field reads a hidden field: the field itself, a path
+// inside it, or a value containing it.
+func (g *grant) hides(field string) bool {
+	for _, hidden := range g.hidden {
+		if field == hidden || strings.HasPrefix(field, hidden+".") || strings.HasPrefix(hidden, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReferences rejects references to hidden fields, which would otherwise
+// let a caller probe or derive their values.
+func (g *grant) checkReferences(refs []reference) []common.Issue {
+	if len(g.hidden) == 0 {
+		return nil
+	}
+	var issues []common.Issue
+	for _, ref := range refs {
+		if ref.field == "" && ref.use == "searched" {
+			issues = append(issues, deny(IssueHiddenField, "", "text search must name its fields when the collection has hidden fields"))
+		} else if g.hides(ref.field) {
+			issues = append(issues, deny(IssueHiddenField, ref.field, "field %q cannot be %s", ref.field, ref.use))
+		}
+	}
+	return issues
+}
+
+// checkFilter rejects filters that reference hidden fields.
+func (g *grant) checkFilter(qf *query.QueryFilter) []common.Issue {
+	return g.checkReferences(filterReferences(nil, qf))
+}
+
+// queryReferences returns every field of the queried collection q reads.
+// Joined collections and subqueries carry their own policies and are not
+// followed.
+func queryReferences(q *query.Query) []reference {
+	refs := filterReferences(nil, q.Filters)
+	for _, sort := range q.Sort {
+		refs = append(refs, reference{sort.Field, "sorted on"})
+	}
+	if q.Pagination != nil {
+		if q.Pagination.Cursor != nil && q.Pagination.Cursor.Field != nil {
+			refs = append(refs, reference{*q.Pagination.Cursor.Field, "paginated on"})
+		}
+		for _, sort := range q.Pagination.Order {
+			refs = append(refs, reference{sort.Field, "sorted on"})
+		}
+	}
+	if q.Distinct != nil {
+		for _, field := range q.Distinct.Fields {
+			refs = append(refs, reference{field, "selected distinct"})
+		}
+	}
+	for _, join := range q.Joins {
+		refs = filterReferences(refs, join.On)
+	}
+	if q.Projection != nil {
+		refs = projectionReferences(refs, "", q.Projection.Include)
+		for _, item := range q.Projection.Computed {
+			refs = computedReferences(refs, item)
+		}
+	}
+	for _, agg := range q.Aggregations {
+		if agg.Field != "" && agg.Field != "*" {
+			refs = append(refs, reference{agg.Field, "aggregated"})
+		}
+		for _, group := range agg.Groups {
+			refs = append(refs, reference{group, "grouped on"})
+		}
+		for _, bucket := range agg.TimeGroups {
+			refs = append(refs, reference{bucket.Field, "grouped on"})
+		}
+		for _, sort := range agg.OrderBy {
+			refs = append(refs, reference{sort.Field, "sorted on"})
+		}
+		refs = filterReferences(refs, agg.Filter)
+	}
+	return refs
+}
+
+func projectionReferences(refs []reference, prefix string, fields []query.ProjectionField) []reference {
+	for _, field := range fields {
+		refs = append(refs, reference{prefix + field.Name, "projected"})
+		if field.Nested != nil {
+			refs = projectionReferences(refs, prefix+field.Name+".", field.Nested.Include)
+		}
+	}
+	return refs
+}
+
+func computedReferences(refs []reference, item query.ProjectionComputedItem) []reference {
+	if expr := item.ComputedFieldExpression; expr != nil && expr.Expression != nil {
+		for _, arg := range expr.Expression.Arguments {
+			refs = valueReferences(refs, arg)
+		}
+	}
+	if expr := item.CaseExpression; expr != nil {
+		for i := range expr.Conditions {
+			refs = filterReferences(refs, &expr.Conditions[i].When)
+			refs = valueReferences(refs, expr.Conditions[i].Then)
+		}
+		refs = valueReferences(refs, expr.Else)
+	}
+	if expr := item.WindowExpression; expr != nil {
+		if expr.Field != "" {
+			refs = append(refs, reference{expr.Field, "computed from"})
+		}
+		if expr.Default != nil {
+			refs = valueReferences(refs, *expr.Default)
+		}
+		for _, field := range expr.PartitionBy {
+			refs = append(refs, reference{field, "partitioned on"})
+		}
+		for _, sort := range expr.OrderBy {
+			refs = append(refs, reference{sort.Field, "sorted on"})
+		}
+	}
+	return refs
+}
+
+func filterReferences(refs []reference, qf *query.QueryFilter) []reference {
+	if qf == nil {
+		return refs
+	}
+	if cond := qf.Condition; cond != nil {
+		refs = append(refs, reference{cond.Field, "filtered on"})
+		refs = valueReferences(refs, cond.Value)
+	}
+	if qf.Group != nil {
+		for i := range qf.Group.Conditions {
+			refs = filterReferences(refs, &qf.Group.Conditions[i])
+		}
+	}
+	if search := qf.TextSearchQuery; search != nil {
+		if len(search.Fields) == 0 {
+			refs = append(refs, reference{"", "searched"})
+		}
+		for _, field := range search.Fields {
+			refs = append(refs, reference{field, "searched"})
+		}
+	}
+	return refs
+}
+
+// valueReferences collects the fields a filter value reads. Conditions inside
+// an element filter are relative to an array element, but their field
+// references still resolve against the document.
+func valueReferences(refs []reference, value query.FilterValue) []reference {
+	if value.FieldRefVal != nil {
+		refs = append(refs, reference{value.FieldRefVal.Field, "compared against"})
+	}
+	for _, item := range value.ArrayVal {
+		refs = valueReferences(refs, item)
+	}
+	if value.FunctionCallVal != nil {
+		for _, arg := range value.FunctionCallVal.Arguments {
+			refs = valueReferences(refs, arg)
+		}
+	}
+	if value.ElementFilterVal != nil {
+		refs = elementReferences(refs, &value.ElementFilterVal.Filter)
+	}
+	return refs
+}
+
+func elementReferences(refs []reference, qf *query.QueryFilter) []reference {
+	if qf.Condition != nil {
+		refs = valueReferences(refs, qf.Condition.Value)
+	}
+	if qf.Group != nil {
+		for i := range qf.Group.Conditions {
+			refs = elementReferences(refs, &qf.Group.Conditions[i])
+		}
+	}
+	return refs
+}
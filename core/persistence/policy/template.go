@@ -0,0 +1,122 @@
+package policy
+
+import (
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+)
+
+const (
+	templatePrefix = "{{principal."
+	templateSuffix = "}}"
+)
+
+// resolveTemplate returns a copy of filter with every principal template
+// replaced by the principal's value. The rule's filter is never modified, so
+// policies can be shared between goroutines.
+func resolveTemplate(filter query.QueryFilter, principal common.Principal) (query.QueryFilter, []common.Issue) {
+	var issues []common.Issue
+	resolved := filter
+
+	if filter.Condition != nil {
+		condition := *filter.Condition
+		value, conditionIssues := resolveValue(condition.Value, condition.Field, principal)
+		condition.Value = value
+		resolved.Condition = &condition
+		issues = append(issues, conditionIssues...)
+	}
+
+	if filter.Group != nil {
+		group := *filter.Group
+		group.Conditions = make([]query.QueryFilter, len(filter.Group.Conditions))
+		for i, child := range filter.Group.Conditions {
+			var childIssues []common.Issue
+			group.Conditions[i], childIssues = resolveTemplate(child, principal)
+			issues = append(issues, childIssues...)
+		}
+		resolved.Group = &group
+	}
+
+	return resolved, issues
+}
+
+func resolveValue(value query.FilterValue, field string, principal common.Principal) (query.FilterValue, []common.Issue) {
+	if value.StringVal != nil {
+		path, ok := templatePath(*value.StringVal)
+		if !ok {
+			return value, nil
+		}
+		resolved, found := principalValue(principal, path)
+		if !found {
+			return value, []common.Issue{deny(IssueUnresolvedTemplate, field, "principal %q has no value for %q", principal.ID, path)}
+		}
+		return query.NewFilterValue(resolved), nil
+	}
+
+	if len(value.ArrayVal) > 0 {
+		var issues []common.Issue
+		items := make([]query.FilterValue, len(value.ArrayVal))
+		for i, item := range value.ArrayVal {
+			var itemIssues []common.Issue
+			items[i], itemIssues = resolveValue(item, field, principal)
+			issues = append(issues, itemIssues...)
+		}
+		value.ArrayVal = items
+		return value, issues
+	}
+
+	if value.ElementFilterVal != nil {
+		elementFilter := *value.ElementFilterVal
+		var issues []common.Issue
+		elementFilter.Filter, issues = resolveTemplate(elementFilter.Filter, principal)
+		value.ElementFilterVal = &elementFilter
+		return value, issues
+	}
+
+	return value, nil
+}
+
+// templatePath extracts the principal path from a "{{principal.<path>}}"
+// template string.
+func templatePath(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, templatePrefix) || !strings.HasSuffix(s, templateSuffix) {
+		return "", false
+	}
+	path := strings.TrimSpace(s[len(templatePrefix) : len(s)-len(templateSuffix)])
+	return path, path != ""
+}
+
+// principalValue looks a template path up on the principal.
+func principalValue(principal common.Principal, path string) (any, bool) {
+	switch path {
+	case "id":
+		return principal.ID, principal.ID != ""
+	case "roles":
+		roles := make([]any, len(principal.Roles))
+		for i, role := range principal.Roles {
+			roles[i] = role
+		}
+		return roles, true
+	}
+
+	var current any = principal.Attributes
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	if values, ok := current.([]string); ok {
+		items := make([]any, len(values))
+		for i, v := range values {
+			items[i] = v
+		}
+		return items, true
+	}
+	return current, current != nil
+}
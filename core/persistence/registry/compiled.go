@@ -0,0 +1,265 @@
+package registry
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/asaidimu/go-anansi/v8/core/bits"
+	"github.com/asaidimu/go-anansi/v8/core/cache"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// compiledSchemaExportMagic opens an ExportCompiledSchemas snapshot.
+const compiledSchemaExportMagic = "ACS1"
+
+// maxSchemaOrdinal bounds the versions one collection can hold: a SchemaKey's
+// 8-bit version extended by its 2-bit epoch.
+const maxSchemaOrdinal = 1 << 10
+
+// compiledSchemas keeps the compiled form of every registered schema version
+// as v1.2 payloads in a bits.CachedRegistry, served as zero-copy views.
+// Collections are assigned a SchemaKey ID, and their versions an ordinal, in
+// registration order; ordinal o is stored as Version o%256, Epoch o/256.
+type compiledSchemas struct {
+	mu    sync.RWMutex
+	ids   map[string]uint8                           // collection -> schema id
+	keys  map[string]map[string]definition.SchemaKey // collection -> version -> key
+	store *bits.CachedRegistry[definition.SchemaKey, *definition.CompiledSchema]
+}
+
+func newCompiledSchemas() *compiledSchemas {
+	cfg := cache.DefaultCacheConfig()
+	cfg.PositiveTTL = 0
+	return &compiledSchemas{
+		ids:  map[string]uint8{},
+		keys: map[string]map[string]definition.SchemaKey{},
+		store: bits.NewCachedRegistry(
+			definition.NewCompiledSchemaRegistry(),
+			cache.NewManagedCache[*definition.CompiledSchema](cfg, nil),
+			definition.SchemaKey.String,
+			cache.NoExpiration,
+			30*time.Second,
+		),
+	}
+}
+
+// get returns the stored view of name@version.
+func (c *compiledSchemas) get(name, version string) (*definition.CompiledSchema, bool) {
+	c.mu.RLock()
+	key, ok := c.keys[name][version]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	cs, err := c.store.Get(key)
+	return cs, err == nil && cs != nil
+}
+
+// put compiles sc and stores it as name@version, unless that version is
+// already stored.
+func (c *compiledSchemas) put(name, version string, sc *definition.Schema) (*definition.CompiledSchema, error) {
+	if cs, ok := c.get(name, version); ok {
+		return cs, nil
+	}
+	rs, err := definition.Compile(sc)
+	if err != nil {
+		return nil, err
+	}
+	linked, err := definition.Link(rs)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, err := c.assignKeyLocked(name, version)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := definition.EncodeCompiledSchema(linked, key)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := c.store.Set(key, payload)
+	if err != nil {
+		return nil, err
+	}
+	if cs == nil {
+		return nil, definition.ErrInvalidCompiledSchema
+	}
+	c.keys[name][version] = key
+	return cs, nil
+}
+
+// assignKeyLocked returns the key for name@version, allocating the
+// collection's schema id and the version's ordinal on first use.
+func (c *compiledSchemas) assignKeyLocked(name, version string) (definition.SchemaKey, error) {
+	if key, ok := c.keys[name][version]; ok {
+		return key, nil
+	}
+	id, ok := c.ids[name]
+	if !ok {
+		used := make(map[uint8]bool, len(c.ids))
+		for _, v := range c.ids {
+			used[v] = true
+		}
+		next := -1
+		for i := 0; i <= 0xFF; i++ {
+			if !used[uint8(i)] {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return definition.SchemaKey{}, ErrCompiledSchemaSpaceExhausted.WithMessagef("no schema id left for collection '%s'", name)
+		}
+		id = uint8(next)
+		c.ids[name] = id
+		c.keys[name] = map[string]definition.SchemaKey{}
+	}
+	used := make(map[uint64]bool, len(c.keys[name]))
+	for _, k := range c.keys[name] {
+		used[k.Hash64()] = true
+	}
+	for o := 0; o < maxSchemaOrdinal; o++ {
+		key := definition.SchemaKey{ID: id, Version: uint8(o), Epoch: uint8(o >> 8)}
+		if !used[key.Hash64()] {
+			return key, nil
+		}
+	}
+	return definition.SchemaKey{}, ErrCompiledSchemaSpaceExhausted.WithMessagef("no version ordinal left for collection '%s'", name)
+}
+
+// drop removes name@version, or every version of name when version is empty.
+func (c *compiledSchemas) drop(name, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for v, key := range c.keys[name] {
+		if version == "" || v == version {
+			c.store.Delete(key)
+			delete(c.keys[name], v)
+		}
+	}
+	if len(c.keys[name]) == 0 {
+		delete(c.keys, name)
+		delete(c.ids, name)
+	}
+}
+
+// clear removes every stored version.
+func (c *compiledSchemas) clear() {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.keys))
+	for name := range c.keys {
+		names = append(names, name)
+	}
+	c.mu.RUnlock()
+	for _, name := range names {
+		c.drop(name, "")
+	}
+}
+
+// export snapshots the key directory followed by the registry's own export.
+//
+// Layout (little-endian): magic, u32 entry count, then per entry a u16
+// length-prefixed collection name and version and the key's id, version and
+// epoch bytes; the bits.Registry export fills the rest.
+func (c *compiledSchemas) export() []byte {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	buf := []byte(compiledSchemaExportMagic)
+	var n uint32
+	for _, versions := range c.keys {
+		n += uint32(len(versions))
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, n)
+	for name, versions := range c.keys {
+		for version, key := range versions {
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(len(name)))
+			buf = append(buf, name...)
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(len(version)))
+			buf = append(buf, version...)
+			buf = append(buf, key.ID, key.Version, key.Epoch)
+		}
+	}
+	return append(buf, c.store.Export()...)
+}
+
+// restore replaces the stored versions with an export snapshot. Every
+// restored payload is decoded before the directory is swapped in.
+func (c *compiledSchemas) restore(data []byte) error {
+	invalid := func(format string, args ...any) error {
+		return ErrInvalidCompiledSchemaExport.WithMessagef(format, args...)
+	}
+	if len(data) < len(compiledSchemaExportMagic)+4 || string(data[:len(compiledSchemaExportMagic)]) != compiledSchemaExportMagic {
+		return invalid("missing %q header", compiledSchemaExportMagic)
+	}
+	pos := len(compiledSchemaExportMagic)
+	n := binary.LittleEndian.Uint32(data[pos:])
+	pos += 4
+	str := func() (string, bool) {
+		if pos+2 > len(data) {
+			return "", false
+		}
+		l := int(binary.LittleEndian.Uint16(data[pos:]))
+		pos += 2
+		if pos+l > len(data) {
+			return "", false
+		}
+		s := string(data[pos : pos+l])
+		pos += l
+		return s, true
+	}
+
+	ids := map[string]uint8{}
+	keys := map[string]map[string]definition.SchemaKey{}
+	for i := uint32(0); i < n; i++ {
+		name, ok := str()
+		if !ok {
+			return invalid("truncated directory")
+		}
+		version, ok := str()
+		if !ok || pos+3 > len(data) {
+			return invalid("truncated directory")
+		}
+		key := definition.SchemaKey{ID: data[pos], Version: data[pos+1], Epoch: data[pos+2]}
+		pos += 3
+		if id, ok := ids[name]; ok && id != key.ID {
+			return invalid("collection '%s' has more than one schema id", name)
+		}
+		ids[name] = key.ID
+		if keys[name] == nil {
+			keys[name] = map[string]definition.SchemaKey{}
+		}
+		keys[name][version] = key
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// From here on the previous directory may no longer match the store, so
+	// it is emptied first; should the import or its check fail, lookups
+	// start over and recompile.
+	c.ids, c.keys = map[string]uint8{}, map[string]map[string]definition.SchemaKey{}
+	if err := c.store.Import(data[pos:]); err != nil {
+		return invalid("%v", err)
+	}
+	for name, versions := range keys {
+		for version, key := range versions {
+			if cs, err := c.store.Get(key); err != nil || cs == nil {
+				return invalid("'%s@%s' did not restore", name, version)
+			}
+		}
+	}
+	c.ids, c.keys = ids, keys
+	return nil
+}
+
+func (c *compiledSchemas) close() error {
+	return c.store.Close()
+}
+
+// stats reports the size of the compiled schema store.
+func (c *compiledSchemas) stats() bits.CachedStats {
+	return c.store.Stats()
+}
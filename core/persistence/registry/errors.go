@@ -40,3 +40,8 @@ var (
 	ErrFailedToGeneratePhysicalName = common.NewSystemError("ERR_REGISTRY_FAILED_TO_GENERATE_PHYSICAL_NAME", "failed to generate physical name")
 )
 
+// Errors related to the compiled schema store
+var (
+	ErrCompiledSchemaSpaceExhausted = common.NewSystemError("ERR_REGISTRY_COMPILED_SCHEMA_SPACE_EXHAUSTED", "no compiled schema key is left to assign")
+	ErrInvalidCompiledSchemaExport  = common.NewSystemError("ERR_REGISTRY_INVALID_COMPILED_SCHEMA_EXPORT", "invalid compiled schema export")
+)
@@ -34,6 +34,7 @@ type collectionRegistry struct {
 	logger    *zap.Logger
 	cache     cache.RepositoryCache[*RegistryEntry]
 	validator *definition.DocumentValidator
+	compiled  *compiledSchemas
 }
 
 var _ base.CollectionRegistry = (*collectionRegistry)(nil)
@@ -81,6 +82,7 @@ func NewCollectionRegistry(executor RegistryExecutor, logger *zap.Logger, cacheC
 		logger:    logger,
 		cache:     c,
 		validator: validator,
+		compiled:  newCompiledSchemas(),
 	}
 
 	return registry, nil
@@ -88,7 +90,7 @@ func NewCollectionRegistry(executor RegistryExecutor, logger *zap.Logger, cacheC
 
 // Close shuts down the background cache goroutines and releases resources.
 func (r *collectionRegistry) Close(ctx context.Context) error {
-	return r.cache.Close()
+	return errors.Join(r.cache.Close(), r.compiled.close())
 }
 
 func (r *collectionRegistry) CreateCollection(ctx context.Context, sc *definition.Schema) (*RegistryEntry, error) {
@@ -181,6 +183,9 @@ func (r *collectionRegistry) CreateCollections(ctx context.Context, schemas []*d
 	// Update cache after successful completion
 	for _, entry := range results {
 		r.cache.Set(entry.Name, entry)
+		for version, record := range entry.Versions {
+			r.storeCompiled(entry.Name, version, &record.Schema)
+		}
 	}
 
 	return results, nil
@@ -361,6 +366,7 @@ func (r *collectionRegistry) AddSchemaVersion(ctx context.Context, name, version
 	}
 
 	r.cache.Set(name, updatedEntry)
+	r.storeCompiled(name, version, &updatedEntry.Versions[version].Schema)
 	return updatedEntry, nil
 }
 
@@ -428,6 +434,7 @@ func (r *collectionRegistry) DropCollection(ctx context.Context, name string, op
 	}
 
 	r.cache.Evict(name)
+	r.compiled.drop(name, "")
 	return nil
 }
 
@@ -466,6 +473,7 @@ func (r *collectionRegistry) PruneVersion(ctx context.Context, name, version str
 	}
 
 	r.cache.Set(name, updatedEntry)
+	r.compiled.drop(name, version)
 	return updatedEntry, nil
 }
 
@@ -492,8 +500,10 @@ func (r *collectionRegistry) List(ctx context.Context) ([]*RegistryEntry, error)
 func (r *collectionRegistry) InvalidateCache(name string) {
 	if name == "" {
 		r.cache.Clear()
+		r.compiled.clear()
 	} else {
 		r.cache.Evict(name)
+		r.compiled.drop(name, "")
 	}
 }
 
@@ -510,13 +520,80 @@ func (r *collectionRegistry) CacheStats() map[string]any {
 		"evictions":        stats.Evictions,
 		"expirations":      stats.Expirations,
 		"evictor_active":   stats.EvictorActive,
+		"compiled_schemas": r.compiled.stats().Registry.Entries,
 	}
 }
 
+// CompiledSchema returns the compiled form of a collection's schema, the
+// active version unless one is given, as a view into the registry's compiled
+// schema store. Versions registered through this registry are compiled when
+// they are added; others are compiled on first request.
+func (r *collectionRegistry) CompiledSchema(ctx context.Context, name string, version ...string) (*definition.CompiledSchema, error) {
+	if len(version) > 0 {
+		if cs, ok := r.compiled.get(name, version[0]); ok {
+			return cs, nil
+		}
+	}
+
+	entry, status := r.cache.GetStatus(name)
+	switch status {
+	case cache.CacheHitNegative:
+		return nil, base.ErrCollectionNotFound
+	case cache.CacheMiss:
+		var err error
+		entry, err = r.loadFromDatabase(ctx, name)
+		if err != nil {
+			if errors.Is(err, base.ErrCollectionNotFound) {
+				r.cache.Nullify(name)
+			}
+			return nil, err
+		}
+		r.cache.Set(name, entry)
+	}
+
+	resolvedVersion := entry.ActiveVersion.String()
+	if len(version) > 0 {
+		resolvedVersion = version[0]
+	}
+	versionRecord, ok := entry.Versions[resolvedVersion]
+	if !ok {
+		return nil, common.NewSystemError("ERR_REGISTRY_VERSION_NOT_FOUND_FOR_COLLECTION", fmt.Sprintf("version '%s' not found for collection '%s'", resolvedVersion, name))
+	}
+
+	cs, err := r.compiled.put(name, resolvedVersion, &versionRecord.Schema)
+	if err != nil {
+		return nil, common.SystemErrorFrom(err, "ERR_REGISTRY_FAILED_TO_COMPILE_SCHEMA", fmt.Sprintf("for '%s' v%s", name, resolvedVersion))
+	}
+	return cs, nil
+}
+
+// ExportCompiledSchemas snapshots the compiled schema store so a later
+// process can restore it with ImportCompiledSchemas instead of recompiling
+// every schema version on cold start.
+func (r *collectionRegistry) ExportCompiledSchemas() []byte {
+	return r.compiled.export()
+}
+
+// ImportCompiledSchemas replaces the compiled schema store with a snapshot
+// taken by ExportCompiledSchemas.
+func (r *collectionRegistry) ImportCompiledSchemas(data []byte) error {
+	return r.compiled.restore(data)
+}
+
 // ---------------------------------------------------------------------------
 // Internal helpers
 // ---------------------------------------------------------------------------
 
+// storeCompiled compiles a newly registered schema version into the compiled
+// schema store. Failure is not fatal to the registry operation: the version
+// is compiled again on its first CompiledSchema request.
+func (r *collectionRegistry) storeCompiled(name, version string, sc *definition.Schema) {
+	if _, err := r.compiled.put(name, version, sc); err != nil && r.logger != nil {
+		r.logger.Warn("failed to store compiled schema",
+			zap.String("collection", name), zap.String("version", version), zap.Error(err))
+	}
+}
+
 func execute[T any](
 	ctx context.Context,
 	executor RegistryExecutor,
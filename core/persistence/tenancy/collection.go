@@ -0,0 +1,116 @@
+package tenancy
+
+import (
+	"context"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// tenantCollection is a collection handle bound to the tenant that obtained it
+// through a Router. Handles can be cached and shared, so every data access
+// re-checks the tenant carried by the calling context.
+type tenantCollection struct {
+	base.Collection
+	scope *tenantScope
+	name  string
+}
+
+var _ base.Collection = (*tenantCollection)(nil)
+
+func (c *tenantCollection) CreateOne(ctx context.Context, doc data.Documenter) (base.CreateResult, error) {
+	if err := c.scope.check(ctx, "CreateOne"); err != nil {
+		return base.CreateResult{}, err
+	}
+	return c.Collection.CreateOne(ctx, doc)
+}
+
+func (c *tenantCollection) CreateMany(ctx context.Context, docs []data.Documenter) ([]base.CreateResult, error) {
+	if err := c.scope.check(ctx, "CreateMany"); err != nil {
+		return nil, err
+	}
+	return c.Collection.CreateMany(ctx, docs)
+}
+
+func (c *tenantCollection) Read(ctx context.Context, q *query.Query) (*base.ReadResult, error) {
+	if err := c.scope.check(ctx, "Read"); err != nil {
+		return nil, err
+	}
+	return c.Collection.Read(ctx, q)
+}
+
+func (c *tenantCollection) Update(ctx context.Context, params *base.CollectionUpdate) (*base.ReadResult, error) {
+	if err := c.scope.check(ctx, "Update"); err != nil {
+		return nil, err
+	}
+	return c.Collection.Update(ctx, params)
+}
+
+func (c *tenantCollection) Delete(ctx context.Context, qf *query.QueryFilter, unsafe bool) (int, error) {
+	if err := c.scope.check(ctx, "Delete"); err != nil {
+		return 0, err
+	}
+	return c.Collection.Delete(ctx, qf, unsafe)
+}
+
+func (c *tenantCollection) SubscribeQuery(ctx context.Context, q *query.Query, callback base.QueryCallback) (string, error) {
+	if err := c.scope.check(ctx, "SubscribeQuery"); err != nil {
+		return "", err
+	}
+	return c.Collection.SubscribeQuery(ctx, q, callback)
+}
+
+// Subscribe registers a subscription on the collection. Events carry the
+// logical collection name.
+func (c *tenantCollection) Subscribe(ctx context.Context, options base.SubscriptionOptions) string {
+	if err := c.scope.check(ctx, "Subscribe"); err != nil {
+		return ""
+	}
+	options.Callback = c.scope.filterEvents(options.Callback, &c.name)
+	return c.Collection.Subscribe(ctx, options)
+}
+
+func (c *tenantCollection) Validate(ctx context.Context, doc data.Documenter, partial bool) ([]common.Issue, bool) {
+	if err := c.scope.check(ctx, "Validate"); err != nil {
+		return []common.Issue{common.SystemErrorFrom(err).ToIssue()}, false
+	}
+	return c.Collection.Validate(ctx, doc, partial)
+}
+
+func (c *tenantCollection) Schema(ctx context.Context) (*definition.Schema, error) {
+	if err := c.scope.check(ctx, "Schema"); err != nil {
+		return nil, err
+	}
+	sc, err := c.Collection.Schema(ctx)
+	if err != nil || sc == nil {
+		return sc, err
+	}
+	// The collection reports its physical name; callers only ever see the
+	// logical one.
+	logical := *sc
+	logical.Name = c.name
+	return &logical, nil
+}
+
+func (c *tenantCollection) Metadata(ctx context.Context, filter *base.MetadataFilter, forceRefresh bool) *base.CollectionMetadata {
+	if err := c.scope.check(ctx, "Metadata"); err != nil {
+		return nil
+	}
+	metadata := c.Collection.Metadata(ctx, filter, forceRefresh)
+	if metadata == nil {
+		return nil
+	}
+	logical := c.scope.logicalMetadata(metadata)
+	logical.Name = c.name
+	return logical
+}
+
+func (c *tenantCollection) Transact(ctx context.Context, fn func(ctx context.Context) (any, error)) (any, error) {
+	if err := c.scope.check(ctx, "Transact"); err != nil {
+		return nil, err
+	}
+	return c.Collection.Transact(ctx, fn)
+}
@@ -0,0 +1,15 @@
+package tenancy
+
+import (
+	"github.com/asaidimu/go-anansi/v8/core/common"
+)
+
+// Errors returned by the tenancy layer.
+var (
+	ErrTenantRequired     = common.NewSystemError("ERR_TENANCY_TENANT_REQUIRED", "operation requires a tenant in context")
+	ErrInvalidTenant      = common.NewSystemError("ERR_TENANCY_INVALID_TENANT", "tenant identifier is invalid")
+	ErrCrossTenantAccess  = common.NewSystemError("ERR_TENANCY_CROSS_TENANT_ACCESS", "cross-tenant access is not permitted")
+	ErrUnscopedQuery      = common.NewSystemError("ERR_TENANCY_UNSCOPED_QUERY", "query cannot be scoped to a tenant")
+	ErrTenantFieldMissing = common.NewSystemError("ERR_TENANCY_FIELD_MISSING", "collection schema does not declare the tenant field")
+	ErrInvalidConfig      = common.NewSystemError("ERR_TENANCY_INVALID_CONFIG", "invalid tenancy configuration")
+)
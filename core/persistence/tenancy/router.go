@@ -0,0 +1,493 @@
+package tenancy
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/orchestrator"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"go.uber.org/zap"
+)
+
+// BackendResolver returns the backend that stores a tenant's collections.
+type BackendResolver func(ctx context.Context, tenant string) (base.Persistence, error)
+
+// OrchestratorBackends resolves each tenant to the orchestrator backend
+// registered under the tenant ID as its label.
+func OrchestratorBackends(o *orchestrator.Orchestrator) BackendResolver {
+	return func(_ context.Context, tenant string) (base.Persistence, error) {
+		return o.Backend(tenant)
+	}
+}
+
+// RouterConfig configures the schema-level tenancy router. Exactly one of
+// Shared and Backend must be set.
+type RouterConfig struct {
+	// Shared stores every tenant's collections on one backend.
+	Shared base.Persistence
+
+	// Backend resolves a dedicated backend per tenant. Backends obtained this
+	// way are owned by the resolver and are not closed by Router.Close.
+	Backend BackendResolver
+
+	// Options.CollectionPrefix is prepended to every qualified collection
+	// name. Other options are ignored.
+	Options query.InteractorOptions
+
+	Logger *zap.Logger
+}
+
+// Router is a base.Persistence facade that maps each tenant to its own set of
+// physical collections. Every operation requires a tenant in context.
+type Router struct {
+	cfg RouterConfig
+
+	// owners maps the ID of every subscription made through the router to
+	// the tenant that made it.
+	mu     sync.Mutex
+	owners map[string]string
+}
+
+var _ base.Persistence = (*Router)(nil)
+
+// NewRouter creates a schema-level tenancy router.
+func NewRouter(cfg RouterConfig) (*Router, error) {
+	if (cfg.Shared == nil) == (cfg.Backend == nil) {
+		return nil, ErrInvalidConfig.WithMessage("exactly one of Shared and Backend must be set")
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	return &Router{cfg: cfg, owners: make(map[string]string)}, nil
+}
+
+// resolve returns the validated tenant and its backend.
+func (r *Router) resolve(ctx context.Context, operation string) (*tenantScope, base.Persistence, error) {
+	tenant, err := tenantFrom(ctx, operation)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ValidateTenantID(tenant); err != nil {
+		return nil, nil, err
+	}
+
+	backend := r.cfg.Shared
+	if r.cfg.Backend != nil {
+		if backend, err = r.cfg.Backend(ctx, tenant); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	scope := &tenantScope{
+		BasePersistence: backend,
+		tenant:          tenant,
+		prefix:          r.cfg.Options.CollectionPrefix + tenant + separator,
+	}
+	return scope, backend, nil
+}
+
+func (r *Router) Collection(ctx context.Context, name string) (base.Collection, error) {
+	scope, _, err := r.resolve(ctx, "Collection")
+	if err != nil {
+		return nil, err
+	}
+	return scope.Collection(ctx, name)
+}
+
+func (r *Router) ListCollections(ctx context.Context) ([]string, error) {
+	scope, _, err := r.resolve(ctx, "ListCollections")
+	if err != nil {
+		return nil, err
+	}
+	return scope.ListCollections(ctx)
+}
+
+func (r *Router) Delete(ctx context.Context, name string) (bool, error) {
+	scope, _, err := r.resolve(ctx, "Delete")
+	if err != nil {
+		return false, err
+	}
+	return scope.Delete(ctx, name)
+}
+
+func (r *Router) Schema(ctx context.Context, name string, version ...string) (*definition.Schema, error) {
+	scope, _, err := r.resolve(ctx, "Schema")
+	if err != nil {
+		return nil, err
+	}
+	return scope.Schema(ctx, name, version...)
+}
+
+func (r *Router) Metadata(ctx context.Context, filter *base.MetadataFilter) (base.Metadata, error) {
+	scope, _, err := r.resolve(ctx, "Metadata")
+	if err != nil {
+		return base.Metadata{}, err
+	}
+	return scope.Metadata(ctx, filter)
+}
+
+func (r *Router) Async(ctx context.Context, f func(ctx context.Context) (any, error)) base.Future {
+	scope, _, err := r.resolve(ctx, "Async")
+	if err != nil {
+		return failedFuture{err: err}
+	}
+	return scope.Async(ctx, f)
+}
+
+func (r *Router) Query(ctx context.Context, rawQuery *query.RawQuery) (*query.RawQueryResult, error) {
+	scope, _, err := r.resolve(ctx, "Query")
+	if err != nil {
+		return nil, err
+	}
+	return scope.Query(ctx, rawQuery)
+}
+
+// CreateCollection creates the tenant's physical copy of sc. The caller's
+// schema is not modified.
+func (r *Router) CreateCollection(ctx context.Context, sc *definition.Schema) (base.Collection, error) {
+	scope, backend, err := r.resolve(ctx, "CreateCollection")
+	if err != nil {
+		return nil, err
+	}
+	if sc == nil {
+		return nil, ErrInvalidConfig.WithMessage("schema must not be nil")
+	}
+	collection, err := backend.CreateCollection(ctx, scope.qualifySchema(sc))
+	if err != nil {
+		return nil, err
+	}
+	return scope.bind(collection, sc.Name), nil
+}
+
+func (r *Router) CreateCollections(ctx context.Context, schemas []*definition.Schema) error {
+	scope, backend, err := r.resolve(ctx, "CreateCollections")
+	if err != nil {
+		return err
+	}
+	qualified := make([]*definition.Schema, 0, len(schemas))
+	for _, sc := range schemas {
+		if sc == nil {
+			return ErrInvalidConfig.WithMessage("schema must not be nil")
+		}
+		qualified = append(qualified, scope.qualifySchema(sc))
+	}
+	return backend.CreateCollections(ctx, qualified)
+}
+
+func (r *Router) HasCollection(ctx context.Context, name string) (bool, error) {
+	scope, backend, err := r.resolve(ctx, "HasCollection")
+	if err != nil {
+		return false, err
+	}
+	return backend.HasCollection(ctx, scope.qualify(name))
+}
+
+// Transact runs callback in a transaction on the tenant's backend. The
+// transactional persistence handed to callback is scoped to the same tenant.
+func (r *Router) Transact(ctx context.Context, callback func(ctx context.Context, p base.BasePersistence) (any, error)) (any, error) {
+	scope, backend, err := r.resolve(ctx, "Transact")
+	if err != nil {
+		return nil, err
+	}
+	return backend.Transact(ctx, func(ctx context.Context, tx base.BasePersistence) (any, error) {
+		return callback(ctx, &tenantScope{BasePersistence: tx, tenant: scope.tenant, prefix: scope.prefix})
+	})
+}
+
+// Subscribe registers a subscription on the tenant's backend. Only events of
+// the tenant's own collections are delivered, under their logical names;
+// events that name no collection cannot be attributed to a tenant and are
+// dropped.
+func (r *Router) Subscribe(ctx context.Context, options base.SubscriptionOptions) string {
+	scope, backend, err := r.resolve(ctx, "Subscribe")
+	if err != nil {
+		r.cfg.Logger.Warn("rejected tenant subscription", zap.Error(err))
+		return ""
+	}
+	options.Callback = scope.filterEvents(options.Callback, nil)
+	id := backend.Subscribe(ctx, options)
+	if id != "" {
+		r.mu.Lock()
+		r.owners[id] = scope.tenant
+		r.mu.Unlock()
+	}
+	return id
+}
+
+// Unsubscribe removes a subscription the tenant made through the router.
+// Subscriptions of other tenants, or made directly on the backend, are left
+// in place.
+func (r *Router) Unsubscribe(ctx context.Context, id string) {
+	scope, backend, err := r.resolve(ctx, "Unsubscribe")
+	if err != nil {
+		r.cfg.Logger.Warn("rejected tenant unsubscription", zap.Error(err))
+		return
+	}
+	r.mu.Lock()
+	owner, ok := r.owners[id]
+	if ok && owner == scope.tenant {
+		delete(r.owners, id)
+	}
+	r.mu.Unlock()
+	if !ok || owner != scope.tenant {
+		r.cfg.Logger.Warn("rejected tenant unsubscription", zap.String("subscription", id), zap.String("tenant", scope.tenant))
+		return
+	}
+	backend.Unsubscribe(ctx, id)
+}
+
+// Subscriptions lists the subscriptions the tenant made through the router.
+func (r *Router) Subscriptions(ctx context.Context) ([]base.SubscriptionInfo, error) {
+	scope, backend, err := r.resolve(ctx, "Subscriptions")
+	if err != nil {
+		return nil, err
+	}
+	all, err := backend.Subscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var owned []base.SubscriptionInfo
+	for _, info := range all {
+		if info.Id != nil && r.owners[*info.Id] == scope.tenant {
+			owned = append(owned, info)
+		}
+	}
+	return owned, nil
+}
+
+func (r *Router) Rollback(ctx context.Context, name string, version *string, dryRun *bool) (base.Collection, error) {
+	scope, backend, err := r.resolve(ctx, "Rollback")
+	if err != nil {
+		return nil, err
+	}
+	collection, err := backend.Rollback(ctx, scope.qualify(name), version, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	return scope.bind(collection, name), nil
+}
+
+func (r *Router) Migrate(ctx context.Context, name string, migration any, dryRun *bool) (base.Collection, error) {
+	scope, backend, err := r.resolve(ctx, "Migrate")
+	if err != nil {
+		return nil, err
+	}
+	collection, err := backend.Migrate(ctx, scope.qualify(name), migration, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	return scope.bind(collection, name), nil
+}
+
+// Close closes the shared backend. Backends supplied by a BackendResolver are
+// left to their owner.
+func (r *Router) Close(ctx context.Context) {
+	if r.cfg.Shared != nil {
+		r.cfg.Shared.Close(ctx)
+	}
+}
+
+// tenantScope presents one tenant's view of a (possibly transactional)
+// backend, translating between logical and qualified collection names.
+type tenantScope struct {
+	base.BasePersistence
+	tenant string
+	prefix string
+}
+
+func (s *tenantScope) qualify(name string) string {
+	return s.prefix + name
+}
+
+// unqualify returns the logical name for a qualified name, and false when the
+// name belongs to another tenant or to no tenant at all.
+func (s *tenantScope) unqualify(name string) (string, bool) {
+	if !strings.HasPrefix(name, s.prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(name, s.prefix), true
+}
+
+func (s *tenantScope) qualifySchema(sc *definition.Schema) *definition.Schema {
+	qualified := *sc
+	qualified.Name = s.qualify(sc.Name)
+	return &qualified
+}
+
+// logicalSchema returns a copy of sc carrying its logical name.
+func (s *tenantScope) logicalSchema(sc *definition.Schema) *definition.Schema {
+	if sc == nil {
+		return nil
+	}
+	name, ok := s.unqualify(sc.Name)
+	if !ok {
+		return sc
+	}
+	logical := *sc
+	logical.Name = name
+	return &logical
+}
+
+// filterEvents wraps callback so that it only sees events of the tenant's
+// collections, renamed to their logical names. When name is set, only events
+// of that logical collection are passed on.
+func (s *tenantScope) filterEvents(callback base.EventCallbackFunction, name *string) base.EventCallbackFunction {
+	if callback == nil {
+		return nil
+	}
+	return func(ctx context.Context, event base.PersistenceEvent) error {
+		if event.Collection == nil {
+			return nil
+		}
+		logical, ok := s.unqualify(*event.Collection)
+		if !ok || (name != nil && logical != *name) {
+			return nil
+		}
+		event.Collection = &logical
+		return callback(ctx, event)
+	}
+}
+
+func (s *tenantScope) bind(collection base.Collection, name string) base.Collection {
+	return &tenantCollection{Collection: collection, scope: s, name: name}
+}
+
+func (s *tenantScope) Collection(ctx context.Context, name string) (base.Collection, error) {
+	if err := s.check(ctx, "Collection"); err != nil {
+		return nil, err
+	}
+	collection, err := s.BasePersistence.Collection(ctx, s.qualify(name))
+	if err != nil {
+		return nil, err
+	}
+	return s.bind(collection, name), nil
+}
+
+func (s *tenantScope) ListCollections(ctx context.Context) ([]string, error) {
+	if err := s.check(ctx, "ListCollections"); err != nil {
+		return nil, err
+	}
+	names, err := s.BasePersistence.ListCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var owned []string
+	for _, name := range names {
+		if logical, ok := s.unqualify(name); ok {
+			owned = append(owned, logical)
+		}
+	}
+	return owned, nil
+}
+
+func (s *tenantScope) Delete(ctx context.Context, name string) (bool, error) {
+	if err := s.check(ctx, "Delete"); err != nil {
+		return false, err
+	}
+	return s.BasePersistence.Delete(ctx, s.qualify(name))
+}
+
+func (s *tenantScope) Schema(ctx context.Context, name string, version ...string) (*definition.Schema, error) {
+	if err := s.check(ctx, "Schema"); err != nil {
+		return nil, err
+	}
+	sc, err := s.BasePersistence.Schema(ctx, s.qualify(name), version...)
+	if err != nil {
+		return nil, err
+	}
+	return s.logicalSchema(sc), nil
+}
+
+// Metadata reports only the tenant's own collections and schemas, under their
+// logical names. Storage usage cannot be attributed to a single tenant on a
+// shared backend and is omitted.
+func (s *tenantScope) Metadata(ctx context.Context, filter *base.MetadataFilter) (base.Metadata, error) {
+	if err := s.check(ctx, "Metadata"); err != nil {
+		return base.Metadata{}, err
+	}
+	metadata, err := s.BasePersistence.Metadata(ctx, filter)
+	if err != nil {
+		return base.Metadata{}, err
+	}
+
+	scoped := base.Metadata{
+		ConnectionStatus: metadata.ConnectionStatus,
+		ConnectionError:  metadata.ConnectionError,
+		Subscriptions:    metadata.Subscriptions,
+	}
+	for _, sc := range metadata.Schemas {
+		if sc == nil {
+			continue
+		}
+		if _, ok := s.unqualify(sc.Name); ok {
+			scoped.Schemas = append(scoped.Schemas, s.logicalSchema(sc))
+		}
+	}
+	for _, collection := range metadata.Collections {
+		if collection == nil {
+			continue
+		}
+		if _, ok := s.unqualify(collection.Name); ok {
+			scoped.Collections = append(scoped.Collections, s.logicalMetadata(collection))
+		}
+	}
+	count := int64(len(scoped.Collections))
+	scoped.CollectionCount = &count
+	return scoped, nil
+}
+
+func (s *tenantScope) logicalMetadata(metadata *base.CollectionMetadata) *base.CollectionMetadata {
+	logical := *metadata
+	if name, ok := s.unqualify(metadata.Name); ok {
+		logical.Name = name
+	}
+	logical.Schema = s.logicalSchema(metadata.Schema)
+	logical.Tenant = s.tenant
+	return &logical
+}
+
+// Query qualifies every collection referenced by the raw query. The caller's
+// query is not modified.
+func (s *tenantScope) Query(ctx context.Context, rawQuery *query.RawQuery) (*query.RawQueryResult, error) {
+	if err := s.check(ctx, "Query"); err != nil {
+		return nil, err
+	}
+	if rawQuery == nil || len(rawQuery.Collections) == 0 {
+		return nil, ErrUnscopedQuery.WithMessage("raw queries must reference their collections through placeholders").WithOperation("Query")
+	}
+	scoped := *rawQuery
+	scoped.Collections = make(map[string]query.RawQueryTarget, len(rawQuery.Collections))
+	for placeholder, target := range rawQuery.Collections {
+		target.Collection = s.qualify(target.Collection)
+		scoped.Collections[placeholder] = target
+	}
+	return s.BasePersistence.Query(ctx, &scoped)
+}
+
+// check rejects use of the scope from a context carrying a different tenant.
+// It matters for transactional scopes, which outlive the call that created
+// them.
+func (s *tenantScope) check(ctx context.Context, operation string) error {
+	tenant, err := tenantFrom(ctx, operation)
+	if err != nil {
+		return err
+	}
+	if tenant != s.tenant {
+		return ErrCrossTenantAccess.WithMessagef("handle bound to tenant %q used by tenant %q", s.tenant, tenant).WithOperation(operation)
+	}
+	return nil
+}
+
+// failedFuture is returned by Async when the call could not be scoped.
+type failedFuture struct {
+	err error
+}
+
+func (f failedFuture) Await() (any, error) {
+	return nil, f.err
+}
@@ -0,0 +1,449 @@
+package tenancy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/utils"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"go.uber.org/zap"
+)
+
+// DefaultTenantField is the field RowLevel stamps and filters on when
+// RowLevelConfig.Field is empty.
+const DefaultTenantField = "tenant_id"
+
+// RowLevelConfig configures the row-level tenancy decorator.
+type RowLevelConfig struct {
+	// Field is the document field holding the tenant ID. Defaults to
+	// DefaultTenantField.
+	Field string
+
+	// Collections restricts enforcement to the named collections. Every
+	// listed collection must declare Field, otherwise its operations fail
+	// with ErrTenantFieldMissing. When empty, every collection whose schema
+	// declares Field is scoped and all others pass through untouched.
+	Collections []string
+
+	Logger *zap.Logger
+}
+
+// RowLevel returns a collection decorator that scopes every operation to the
+// tenant carried in context. See the package documentation for details.
+func RowLevel(cfg RowLevelConfig) utils.CollectionDecorator {
+	if cfg.Field == "" {
+		cfg.Field = DefaultTenantField
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	owners := &subscriptionOwners{byID: make(map[string]string)}
+	return func(next base.Collection) base.Collection {
+		return &rowLevelCollection{Collection: next, cfg: cfg, owners: owners}
+	}
+}
+
+// subscriptionOwners maps the ID of every subscription made on a scoped
+// collection to the tenant that made it. It is shared by every collection of
+// one decorator, since handles to the same collection may differ.
+type subscriptionOwners struct {
+	mu   sync.Mutex
+	byID map[string]string
+}
+
+func (o *subscriptionOwners) add(id, tenant string) {
+	if id == "" {
+		return
+	}
+	o.mu.Lock()
+	o.byID[id] = tenant
+	o.mu.Unlock()
+}
+
+// remove forgets id when tenant owns it and reports whether it did.
+func (o *subscriptionOwners) remove(id, tenant string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.byID[id] != tenant {
+		return false
+	}
+	delete(o.byID, id)
+	return true
+}
+
+func (o *subscriptionOwners) owns(id, tenant string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.byID[id] == tenant
+}
+
+type rowLevelCollection struct {
+	base.Collection
+	cfg    RowLevelConfig
+	owners *subscriptionOwners
+
+	nameOnce sync.Once
+	name     string
+}
+
+var _ base.Collection = (*rowLevelCollection)(nil)
+
+// scope resolves the tenant for an operation. It returns an empty tenant when
+// the collection is not tenant-scoped, in which case the caller must pass the
+// operation through unchanged. The schema is consulted on every call so a
+// migration that adds or removes the tenant field takes effect immediately.
+func (c *rowLevelCollection) scope(ctx context.Context, operation string) (string, error) {
+	sc, err := c.Collection.Schema(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	listed := len(c.cfg.Collections) > 0 && slices.Contains(c.cfg.Collections, c.logicalName(ctx))
+	if len(c.cfg.Collections) > 0 && !listed {
+		return "", nil
+	}
+
+	if _, field := sc.FindField(c.cfg.Field); field == nil {
+		if listed {
+			return "", ErrTenantFieldMissing.WithMessagef("collection %q does not declare tenant field %q", c.logicalName(ctx), c.cfg.Field).WithOperation(operation)
+		}
+		return "", nil
+	}
+
+	return tenantFrom(ctx, operation)
+}
+
+// logicalName returns the collection's logical name. The schema carries the
+// physical name, so it is read once from the collection metadata instead.
+func (c *rowLevelCollection) logicalName(ctx context.Context) string {
+	c.nameOnce.Do(func() {
+		if metadata := c.Collection.Metadata(ctx, nil, false); metadata != nil {
+			c.name = metadata.Name
+		}
+	})
+	return c.name
+}
+
+func (c *rowLevelCollection) filter(tenant string) query.QueryFilter {
+	return query.QueryFilter{
+		Condition: &query.FilterCondition{
+			Field:    c.cfg.Field,
+			Operator: query.ComparisonOperatorEq,
+			Value:    query.FilterValue{StringVal: &tenant},
+		},
+	}
+}
+
+// and combines an optional caller filter with the tenant filter. The caller's
+// filter is never modified.
+func (c *rowLevelCollection) and(qf *query.QueryFilter, tenant string) *query.QueryFilter {
+	scoped := c.filter(tenant)
+	if qf == nil {
+		return &scoped
+	}
+	return &query.QueryFilter{
+		Group: &query.FilterGroup{
+			Operator:   common.LogicalAnd,
+			Conditions: []query.QueryFilter{*qf, scoped},
+		},
+	}
+}
+
+// stamp sets the tenant field on doc, rejecting documents that already name a
+// different tenant.
+func (c *rowLevelCollection) stamp(doc data.Documenter, tenant string) error {
+	if doc == nil {
+		return nil
+	}
+	if doc.HasKey(c.cfg.Field) {
+		existing, _ := doc.Get(c.cfg.Field)
+		if existing != nil && existing != "" && fmt.Sprint(existing) != tenant {
+			return ErrCrossTenantAccess.WithMessagef("document belongs to tenant %q, not %q", fmt.Sprint(existing), tenant).WithPath(c.cfg.Field)
+		}
+	}
+	return doc.Set(c.cfg.Field, tenant)
+}
+
+func (c *rowLevelCollection) CreateOne(ctx context.Context, doc data.Documenter) (base.CreateResult, error) {
+	tenant, err := c.scope(ctx, "CreateOne")
+	if err != nil {
+		return base.CreateResult{}, err
+	}
+	if tenant != "" {
+		if err := c.stamp(doc, tenant); err != nil {
+			return base.CreateResult{}, err
+		}
+	}
+	return c.Collection.CreateOne(ctx, doc)
+}
+
+func (c *rowLevelCollection) CreateMany(ctx context.Context, docs []data.Documenter) ([]base.CreateResult, error) {
+	tenant, err := c.scope(ctx, "CreateMany")
+	if err != nil {
+		return nil, err
+	}
+	if tenant != "" {
+		for i, doc := range docs {
+			if err := c.stamp(doc, tenant); err != nil {
+				return nil, common.SystemErrorFrom(err).WithPath(fmt.Sprintf("[%d].%s", i, c.cfg.Field))
+			}
+		}
+	}
+	return c.Collection.CreateMany(ctx, docs)
+}
+
+func (c *rowLevelCollection) Read(ctx context.Context, q *query.Query) (*base.ReadResult, error) {
+	tenant, err := c.scope(ctx, "Read")
+	if err != nil {
+		return nil, err
+	}
+	if tenant == "" {
+		return c.Collection.Read(ctx, q)
+	}
+
+	var scoped query.Query
+	if q != nil {
+		scoped = *q
+	}
+	if scoped.Raw != nil || scoped.Union != nil {
+		return nil, ErrUnscopedQuery.WithMessage("raw and union queries cannot be scoped to a tenant").WithOperation("Read")
+	}
+	scoped.Filters = c.and(scoped.Filters, tenant)
+	return c.Collection.Read(ctx, &scoped)
+}
+
+func (c *rowLevelCollection) SubscribeQuery(ctx context.Context, q *query.Query, callback base.QueryCallback) (string, error) {
+	tenant, err := c.scope(ctx, "SubscribeQuery")
+	if err != nil {
+		return "", err
+	}
+	if tenant == "" {
+		return c.Collection.SubscribeQuery(ctx, q, callback)
+	}
+
+	var scoped query.Query
+	if q != nil {
+		scoped = *q
+	}
+	if scoped.Raw != nil || scoped.Union != nil {
+		return "", ErrUnscopedQuery.WithMessage("raw and union queries cannot be scoped to a tenant").WithOperation("SubscribeQuery")
+	}
+	scoped.Filters = c.and(scoped.Filters, tenant)
+	id, err := c.Collection.SubscribeQuery(ctx, &scoped, callback)
+	if err != nil {
+		return "", err
+	}
+	c.owners.add(id, tenant)
+	return id, nil
+}
+
+// Subscribe delivers only the events of operations made by the subscribing
+// tenant. Events are attributed by the documents they carry and by the
+// tenant filter this decorator added to their query; events that cannot be
+// attributed are dropped. A context without a tenant is rejected with an
+// empty ID.
+func (c *rowLevelCollection) Subscribe(ctx context.Context, options base.SubscriptionOptions) string {
+	tenant, err := c.scope(ctx, "Subscribe")
+	if err != nil {
+		c.cfg.Logger.Warn("rejected tenant subscription", zap.Error(err))
+		return ""
+	}
+	if tenant == "" {
+		return c.Collection.Subscribe(ctx, options)
+	}
+
+	callback := options.Callback
+	if callback != nil {
+		options.Callback = func(ctx context.Context, event base.PersistenceEvent) error {
+			if owner, ok := c.eventTenant(event); !ok || owner != tenant {
+				return nil
+			}
+			return callback(ctx, event)
+		}
+	}
+	id := c.Collection.Subscribe(ctx, options)
+	c.owners.add(id, tenant)
+	return id
+}
+
+// Unsubscribe removes a subscription the tenant made. Subscriptions of other
+// tenants are left in place.
+func (c *rowLevelCollection) Unsubscribe(ctx context.Context, id string) {
+	tenant, err := c.scope(ctx, "Unsubscribe")
+	if err != nil {
+		c.cfg.Logger.Warn("rejected tenant unsubscription", zap.Error(err))
+		return
+	}
+	if tenant != "" && !c.owners.remove(id, tenant) {
+		c.cfg.Logger.Warn("rejected tenant unsubscription", zap.String("subscription", id), zap.String("tenant", tenant))
+		return
+	}
+	c.Collection.Unsubscribe(ctx, id)
+}
+
+// Subscriptions lists the subscriptions the tenant made.
+func (c *rowLevelCollection) Subscriptions(ctx context.Context) ([]base.SubscriptionInfo, error) {
+	tenant, err := c.scope(ctx, "Subscriptions")
+	if err != nil {
+		return nil, err
+	}
+	all, err := c.Collection.Subscriptions(ctx)
+	if err != nil || tenant == "" {
+		return all, err
+	}
+	var owned []base.SubscriptionInfo
+	for _, info := range all {
+		if info.Id != nil && c.owners.owns(*info.Id, tenant) {
+			owned = append(owned, info)
+		}
+	}
+	return owned, nil
+}
+
+// eventTenant returns the tenant of the operation that raised event. Every
+// document the event carries must belong to that tenant.
+func (c *rowLevelCollection) eventTenant(event base.PersistenceEvent) (string, bool) {
+	var tenant string
+	var docs []data.Documenter
+	switch input := event.Input.(type) {
+	case data.Documenter:
+		docs = append(docs, input)
+	case []data.Documenter:
+		docs = append(docs, input...)
+	case *query.Query:
+		if input != nil {
+			tenant = c.filterTenant(input.Filters)
+		}
+	case *base.CollectionUpdate:
+		if input != nil {
+			tenant = c.filterTenant(input.Filter)
+		}
+	case *query.QueryFilter:
+		tenant = c.filterTenant(input)
+	}
+	switch output := event.Output.(type) {
+	case base.CreateResult:
+		docs = append(docs, output.Data)
+	case []base.CreateResult:
+		for _, result := range output {
+			docs = append(docs, result.Data)
+		}
+	case *base.ReadResult:
+		if output != nil {
+			docs = append(docs, output.Data...)
+		}
+	}
+
+	for _, doc := range docs {
+		if doc == nil || !doc.HasKey(c.cfg.Field) {
+			continue
+		}
+		value, _ := doc.Get(c.cfg.Field)
+		owner := fmt.Sprint(value)
+		if tenant != "" && owner != tenant {
+			return "", false
+		}
+		tenant = owner
+	}
+	return tenant, tenant != ""
+}
+
+// filterTenant returns the tenant of a filter scoped by and, or an empty
+// string when qf was not scoped. Only the condition and appends is trusted,
+// not one the caller's own filter might contain.
+func (c *rowLevelCollection) filterTenant(qf *query.QueryFilter) string {
+	if qf == nil {
+		return ""
+	}
+	if qf.Group != nil {
+		if qf.Group.Operator != common.LogicalAnd || len(qf.Group.Conditions) != 2 {
+			return ""
+		}
+		qf = &qf.Group.Conditions[1]
+	}
+	cond := qf.Condition
+	if cond == nil || cond.Field != c.cfg.Field || cond.Operator != query.ComparisonOperatorEq || cond.Value.StringVal == nil {
+		return ""
+	}
+	return *cond.Value.StringVal
+}
+
+func (c *rowLevelCollection) Update(ctx context.Context, params *base.CollectionUpdate) (*base.ReadResult, error) {
+	tenant, err := c.scope(ctx, "Update")
+	if err != nil {
+		return nil, err
+	}
+	if tenant == "" || params == nil {
+		return c.Collection.Update(ctx, params)
+	}
+	// Scoping would turn a missing filter into "every document of the tenant".
+	if params.Filter == nil {
+		return nil, base.ErrInvalidUpdateParams
+	}
+
+	if _, computed := params.Compute[c.cfg.Field]; computed {
+		return nil, ErrCrossTenantAccess.WithMessage("the tenant field cannot be computed").WithPath(c.cfg.Field).WithOperation("Update")
+	}
+	for _, op := range params.Operators {
+		if op.Field == c.cfg.Field || strings.HasPrefix(op.Field, c.cfg.Field+".") {
+			return nil, ErrCrossTenantAccess.WithMessage("the tenant field cannot be changed by an update operator").WithPath(c.cfg.Field).WithOperation("Update")
+		}
+	}
+	if params.Set != nil && params.Set.HasKey(c.cfg.Field) {
+		value, _ := params.Set.Get(c.cfg.Field)
+		if fmt.Sprint(value) != tenant {
+			return nil, ErrCrossTenantAccess.WithMessagef("documents cannot be moved to tenant %q", fmt.Sprint(value)).WithPath(c.cfg.Field).WithOperation("Update")
+		}
+	}
+
+	scoped := *params
+	scoped.Filter = c.and(params.Filter, tenant)
+	return c.Collection.Update(ctx, &scoped)
+}
+
+// Delete scopes the filter to the tenant. An unsafe delete without a filter
+// therefore removes every document of the current tenant, never the whole
+// collection.
+func (c *rowLevelCollection) Delete(ctx context.Context, qf *query.QueryFilter, unsafe bool) (int, error) {
+	tenant, err := c.scope(ctx, "Delete")
+	if err != nil {
+		return 0, err
+	}
+	if tenant == "" {
+		return c.Collection.Delete(ctx, qf, unsafe)
+	}
+	if qf == nil && !unsafe {
+		return 0, base.ErrDeleteRequiresFilter
+	}
+	return c.Collection.Delete(ctx, c.and(qf, tenant), unsafe)
+}
+
+// Metadata tags the collection metadata with the tenant. Record and size
+// counters describe the shared physical collection, so they are withheld from
+// tenant-scoped callers.
+func (c *rowLevelCollection) Metadata(ctx context.Context, filter *base.MetadataFilter, forceRefresh bool) *base.CollectionMetadata {
+	metadata := c.Collection.Metadata(ctx, filter, forceRefresh)
+	if metadata == nil {
+		return nil
+	}
+	tenant, err := c.scope(ctx, "Metadata")
+	if err != nil {
+		c.cfg.Logger.Debug("withholding collection metadata", zap.String("collection", metadata.Name), zap.Error(err))
+		return nil
+	}
+	if tenant == "" {
+		return metadata
+	}
+
+	scoped := *metadata
+	scoped.Tenant = tenant
+	scoped.Records = 0
+	scoped.Size = 0
+	return &scoped
+}
@@ -0,0 +1,90 @@
+// Package tenancy isolates the data of different tenants that share one
+// anansi deployment. The tenant is always taken from the request context
+// (see common.ContextWithTenant); nothing in this package trusts a tenant ID
+// supplied in a document, filter or collection name.
+//
+// Two isolation modes are provided, and they can be mixed in one process:
+//
+// # Row-level tenancy
+//
+// RowLevel is a collection decorator. Every collection whose schema declares
+// the tenant field (default "tenant_id") has that field stamped on Create and
+// enforced as an additional AND filter on Read, Update and Delete. Documents
+// that carry a different tenant, and updates that try to move a document to a
+// different tenant, are rejected with ErrCrossTenantAccess. Raw and union
+// queries cannot be scoped safely and are rejected with ErrUnscopedQuery.
+// Subscriptions require a tenant too, and only receive the events of that
+// tenant's own operations.
+//
+//	decorators := utils.Decorators{
+//		CollectionDecorators: []utils.DecoratorFunc[base.Collection]{
+//			(utils.DecoratorFunc[base.Collection])(tenancy.RowLevel(tenancy.RowLevelConfig{})),
+//		},
+//	}
+//
+// Joined collections are resolved by the underlying query engine and are not
+// re-scoped; join conditions must include the tenant field when the joined
+// collection is shared.
+//
+// # Schema-level tenancy
+//
+// Router is a base.Persistence facade that gives every tenant its own
+// physical collections. A logical collection "orders" requested by tenant
+// "acme" is stored as "<CollectionPrefix>acme__orders", either on a single
+// shared backend or on a backend resolved per tenant — for example a
+// backend registered on an orchestrator.Orchestrator under the tenant's
+// label (see OrchestratorBackends). Collection handles returned by the Router
+// are bound to the tenant that obtained them and reject use from a context
+// carrying any other tenant. Subscriptions made through the Router or its
+// handles only receive events of the tenant's own collections.
+//
+//	router, err := tenancy.NewRouter(tenancy.RouterConfig{Shared: p})
+//	ctx := common.ContextWithTenant(ctx, "acme")
+//	orders, err := router.Collection(ctx, "orders")
+//
+// Physical collection names are truncated by the registry, so tenant IDs for
+// the schema-level mode are restricted to short, lowercase identifiers (see
+// ValidateTenantID). Two qualified names that truncate to the same physical
+// name fail at creation time rather than sharing a table.
+package tenancy
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+)
+
+// separator joins a tenant ID and a logical collection name in the
+// schema-level mode. Tenant IDs cannot contain it, so a qualified name can
+// always be split back unambiguously.
+const separator = "__"
+
+// maxTenantIDLength bounds tenant IDs used for physical naming, leaving room
+// for a collection name within the registry's physical name limit.
+const maxTenantIDLength = 8
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// ValidateTenantID reports whether tenantID can be used to qualify physical
+// collection names: at most eight lowercase alphanumerics. Underscores are
+// excluded because the registry collapses runs of them when deriving physical
+// names, which would make "a_b" + "c" and "a" + "b_c" indistinguishable.
+func ValidateTenantID(tenantID string) error {
+	if tenantID == "" {
+		return ErrTenantRequired
+	}
+	if len(tenantID) > maxTenantIDLength || !tenantIDPattern.MatchString(tenantID) {
+		return ErrInvalidTenant.WithMessagef("tenant identifier %q must be at most %d lowercase alphanumerics", tenantID, maxTenantIDLength)
+	}
+	return nil
+}
+
+// tenantFrom returns the tenant carried by ctx, or ErrTenantRequired.
+func tenantFrom(ctx context.Context, operation string) (string, error) {
+	tenant, ok := common.TenantFromContext(ctx)
+	if !ok {
+		return "", ErrTenantRequired.WithOperation(operation)
+	}
+	return tenant, nil
+}
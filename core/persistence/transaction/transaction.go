@@ -299,3 +299,22 @@ func GetCurrentTransaction(ctx context.Context) (base.Transaction, bool) {
 	tx, ok := ctx.Value(TxKey).(base.Transaction)
 	return tx, ok
 }
+
+// Detach returns a context carrying the values of ctx except its transaction
+// and transactional interactor, and which is never cancelled. Work scheduled
+// by a write to run after it completes, such as from a commit hook, uses it
+// so its collection operations run on their own.
+func Detach(ctx context.Context) context.Context {
+	return detached{context.WithoutCancel(ctx)}
+}
+
+type detached struct {
+	context.Context
+}
+
+func (c detached) Value(key any) any {
+	if key == TxKey || key == query.InteractorContextKey {
+		return nil
+	}
+	return c.Context.Value(key)
+}
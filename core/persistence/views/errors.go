@@ -0,0 +1,14 @@
+package views
+
+import (
+	"github.com/asaidimu/go-anansi/v8/core/common"
+)
+
+// Errors returned by the views layer.
+var (
+	ErrInvalidView     = common.NewSystemError("ERR_VIEW_INVALID", "invalid view definition")
+	ErrUnsupportedView = common.NewSystemError("ERR_VIEW_UNSUPPORTED", "the view query cannot be materialized")
+	ErrViewExists      = common.NewSystemError("ERR_VIEW_EXISTS", "a view or collection with this name already exists")
+	ErrViewNotFound    = common.NewSystemError("ERR_VIEW_NOT_FOUND", "view not found")
+	ErrRefreshFailed   = common.NewSystemError("ERR_VIEW_REFRESH_FAILED", "failed to refresh view")
+)
@@ -0,0 +1,569 @@
+package views
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/collection"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// kind is how a view is kept current.
+type kind int
+
+const (
+	// kindRows replaces the view documents of the written source documents.
+	kindRows kind = iota
+	// kindGroups recomputes the groups of the written source documents.
+	kindGroups
+	// kindRefresh recomputes the whole view.
+	kindRefresh
+)
+
+// classify decides how the view computed by q is maintained. Row views get
+// the helper evaluating their filter and projection.
+func classify(q *query.Query) (kind, *query.QueryHelper, error) {
+	if q.Raw != nil || q.Union != nil {
+		return 0, nil, ErrUnsupportedView.WithMessage("views cannot be defined by raw or union queries").WithOperation("Create")
+	}
+
+	if len(q.Aggregations) > 0 {
+		if len(q.Joins) > 0 || q.Projection != nil || q.Distinct != nil || q.Limit != nil || q.Pagination != nil {
+			return 0, nil, ErrUnsupportedView.WithMessage("aggregate views cannot also join, project, limit or paginate").WithOperation("Create")
+		}
+		groups := q.Aggregations[0].Groups
+		for _, agg := range q.Aggregations {
+			if agg.Alias == nil || *agg.Alias == "" {
+				return 0, nil, ErrInvalidView.WithMessagef("the %s aggregation of field %q requires an alias", agg.Type, agg.Field).WithOperation("Create")
+			}
+			if len(agg.TimeGroups) > 0 {
+				return 0, nil, ErrUnsupportedView.WithMessage("aggregate views cannot group by time buckets").WithOperation("Create")
+			}
+			if !slices.Equal(agg.Groups, groups) {
+				return 0, nil, ErrUnsupportedView.WithMessage("every aggregation of a view must group by the same fields").WithOperation("Create")
+			}
+		}
+		return kindGroups, nil, nil
+	}
+
+	if len(q.Joins) > 0 || q.Distinct != nil || q.Limit != nil || q.Pagination != nil ||
+		(q.Projection != nil && len(q.Projection.Computed) > 0) {
+		return kindRefresh, nil, nil
+	}
+	helper, err := query.NewQueryHelper(&query.Query{Filters: q.Filters, Projection: q.Projection}, nil, nil, nil)
+	if err != nil {
+		// Filters the helper cannot evaluate are left to the database.
+		return kindRefresh, nil, nil
+	}
+	return kindRows, helper, nil
+}
+
+// view is a materialized view being maintained.
+type view struct {
+	definition View
+	query      *query.Query
+	kind       kind
+	helper     *query.QueryHelper
+	collection base.Collection
+	// source is the target of the query; sources holds every collection the
+	// query reads, by name.
+	source  base.Collection
+	sources map[string]base.Collection
+	ctx     context.Context
+	logger  *zap.Logger
+
+	mu            sync.Mutex
+	subscriptions map[string]string
+	// stale is set when maintenance failed, so that the next write
+	// recomputes the whole view.
+	stale bool
+}
+
+var viewLabel = "materialized view"
+
+// start subscribes to the sources of the view and fills it. Writes raised
+// before it is filled wait, so none is missed.
+func (v *view) start(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.subscriptions = make(map[string]string, len(v.sources))
+	for name, source := range v.sources {
+		v.subscriptions[name] = source.Subscribe(ctx, base.SubscriptionOptions{
+			Event:    "document:*",
+			Label:    &viewLabel,
+			Callback: v.handle,
+		})
+	}
+	if err := v.refresh(ctx); err != nil {
+		v.unsubscribe(ctx)
+		return err
+	}
+	return nil
+}
+
+// stop ends the maintenance of the view.
+func (v *view) stop(ctx context.Context) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.unsubscribe(ctx)
+}
+
+func (v *view) unsubscribe(ctx context.Context) {
+	for name, id := range v.subscriptions {
+		v.sources[name].Unsubscribe(ctx, id)
+	}
+	v.subscriptions = nil
+}
+
+func (v *view) handle(_ context.Context, event base.PersistenceEvent) error {
+	switch event.Type {
+	case base.DocumentCreateSuccess, base.DocumentUpdateSuccess, base.DocumentDeleteSuccess:
+	default:
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.subscriptions == nil {
+		return nil
+	}
+
+	var err error
+	switch {
+	case v.stale:
+		err = v.refresh(v.ctx)
+	case v.kind == kindRows:
+		err = v.applyRows(event)
+	case v.kind == kindGroups:
+		err = v.applyGroups(event)
+	default:
+		err = v.refresh(v.ctx)
+	}
+	if err != nil {
+		v.stale = true
+		v.logger.Error("failed to maintain view", zap.String("event", string(event.Type)), zap.Error(err))
+	}
+	return nil
+}
+
+// refresh recomputes the whole view.
+func (v *view) refresh(ctx context.Context) error {
+	var docs []data.Documenter
+	var err error
+	switch v.kind {
+	case kindRows:
+		docs, err = v.readRows(ctx)
+	case kindGroups:
+		docs, err = v.readAllGroups(ctx)
+	default:
+		docs, err = v.readQuery(ctx)
+	}
+	if err == nil {
+		_, err = v.collection.Transact(ctx, func(ctx context.Context) (any, error) {
+			if _, err := v.collection.Delete(ctx, nil, true); err != nil {
+				return nil, err
+			}
+			if len(docs) == 0 {
+				return nil, nil
+			}
+			return v.collection.CreateMany(ctx, docs)
+		})
+	}
+	if err != nil {
+		return ErrRefreshFailed.WithMessagef("failed to refresh view %q", v.definition.Name).WithCause(err)
+	}
+	v.stale = false
+	return nil
+}
+
+// readQuery executes the view query, for views recomputed in full. Its
+// results get IDs of their own, since joined rows may share one.
+func (v *view) readQuery(ctx context.Context) ([]data.Documenter, error) {
+	q, err := v.query.Clone()
+	if err != nil {
+		return nil, err
+	}
+	result, err := v.source.Read(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]data.Documenter, 0, len(result.Data))
+	for _, row := range result.Data {
+		doc, err := data.NewDocument(row.Data())
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// readRows returns the view documents of a row view.
+func (v *view) readRows(ctx context.Context) ([]data.Documenter, error) {
+	result, err := v.source.Read(ctx, &query.Query{Filters: v.query.Filters})
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]data.Documenter, 0, len(result.Data))
+	for _, source := range result.Data {
+		doc, err := v.rowOf(source)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// rowOf returns the view document of a source document of a row view.
+func (v *view) rowOf(source data.Documenter) (data.Documenter, error) {
+	projected, err := v.helper.ProjectSingle(source.Data())
+	if err != nil {
+		return nil, err
+	}
+	projected[data.DocumentIDField] = source.ID()
+	return data.NewDocument(projected)
+}
+
+// applyRows replaces the view documents of the source documents written by
+// event. Deletes remove the view documents whose sources are gone.
+func (v *view) applyRows(event base.PersistenceEvent) error {
+	if event.Type == base.DocumentDeleteSuccess {
+		return v.prune()
+	}
+	written, known := collection.WrittenDocuments(event)
+	if !known {
+		return v.refresh(v.ctx)
+	}
+	if len(written) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(written))
+	var rows []data.Documenter
+	for _, doc := range written {
+		if doc.ID() == "" {
+			return v.refresh(v.ctx)
+		}
+		matched, err := v.helper.Match(doc.ToMap())
+		if err != nil {
+			return err
+		}
+		ids = append(ids, doc.ID())
+		if matched {
+			row, err := v.rowOf(doc)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, row)
+		}
+	}
+	return v.replace(inFilter(data.DocumentIDField, ids), rows)
+}
+
+// prune deletes the view documents of a row view whose source documents no
+// longer exist.
+func (v *view) prune() error {
+	current, err := v.collection.Read(v.ctx, &query.Query{})
+	if err != nil {
+		return err
+	}
+	if len(current.Data) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(current.Data))
+	for _, doc := range current.Data {
+		ids = append(ids, doc.ID())
+	}
+	existing, err := v.source.Read(v.ctx, &query.Query{Filters: inFilter(data.DocumentIDField, ids)})
+	if err != nil {
+		return err
+	}
+	kept := make(map[string]bool, len(existing.Data))
+	for _, doc := range existing.Data {
+		kept[doc.ID()] = true
+	}
+	var gone []string
+	for _, id := range ids {
+		if !kept[id] {
+			gone = append(gone, id)
+		}
+	}
+	if len(gone) == 0 {
+		return nil
+	}
+	return v.replace(inFilter(data.DocumentIDField, gone), nil)
+}
+
+// replace deletes the view documents matching filter and creates docs, in one
+// transaction.
+func (v *view) replace(filter *query.QueryFilter, docs []data.Documenter) error {
+	_, err := v.collection.Transact(v.ctx, func(ctx context.Context) (any, error) {
+		if _, err := v.collection.Delete(ctx, filter, false); err != nil {
+			return nil, err
+		}
+		if len(docs) == 0 {
+			return nil, nil
+		}
+		return v.collection.CreateMany(ctx, docs)
+	})
+	return err
+}
+
+// groups returns the fields an aggregate view groups by.
+func (v *view) groups() []string {
+	return v.query.Aggregations[0].Groups
+}
+
+// applyGroups recomputes the groups of the source documents written by
+// event. Deletes and updates that may move documents between groups
+// recompute every group.
+func (v *view) applyGroups(event base.PersistenceEvent) error {
+	if event.Type == base.DocumentDeleteSuccess {
+		return v.refresh(v.ctx)
+	}
+	if event.Type == base.DocumentUpdateSuccess {
+		update, ok := event.Input.(*base.CollectionUpdate)
+		if !ok || v.touchesGroups(update) {
+			return v.refresh(v.ctx)
+		}
+	}
+	written, known := collection.WrittenDocuments(event)
+	if !known {
+		return v.refresh(v.ctx)
+	}
+
+	var keys [][]any
+	seen := make(map[string]bool)
+	for _, doc := range written {
+		key := v.keyOf(doc.ToMap())
+		id, err := groupID(key)
+		if err != nil {
+			return err
+		}
+		if !seen[id] {
+			seen[id] = true
+			keys = append(keys, key)
+		}
+	}
+
+	ids := make([]string, 0, len(keys))
+	var rows []data.Documenter
+	for _, key := range keys {
+		id, row, err := v.readGroup(v.ctx, key)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		if row != nil {
+			rows = append(rows, row)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return v.replace(inFilter(data.DocumentIDField, ids), rows)
+}
+
+// touchesGroups reports whether update may change a group field.
+func (v *view) touchesGroups(update *base.CollectionUpdate) bool {
+	var fields []string
+	if update.Set != nil {
+		for field := range update.Set.Data() {
+			fields = append(fields, field)
+		}
+	}
+	for field := range update.Compute {
+		fields = append(fields, field)
+	}
+	for _, operator := range update.Operators {
+		fields = append(fields, operator.Field)
+	}
+	for _, field := range fields {
+		for _, group := range v.groups() {
+			if field == group || strings.HasPrefix(group, field+".") || strings.HasPrefix(field, group+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keyOf returns the group values of a source document.
+func (v *view) keyOf(record map[string]any) []any {
+	key := make([]any, len(v.groups()))
+	for i, group := range v.groups() {
+		key[i] = valueAt(record, group)
+	}
+	return key
+}
+
+// readAllGroups computes every group of an aggregate view.
+func (v *view) readAllGroups(ctx context.Context) ([]data.Documenter, error) {
+	var keys [][]any
+	if len(v.groups()) == 0 {
+		keys = [][]any{{}}
+	} else {
+		result, err := v.source.Read(ctx, &query.Query{Filters: v.query.Filters})
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, doc := range result.Data {
+			key := v.keyOf(doc.ToMap())
+			id, err := groupID(key)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[id] {
+				seen[id] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	docs := make([]data.Documenter, 0, len(keys))
+	for _, key := range keys {
+		_, row, err := v.readGroup(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if row != nil {
+			docs = append(docs, row)
+		}
+	}
+	return docs, nil
+}
+
+// readGroup computes the view document of a group, returning a nil document
+// when the group no longer has source documents. Ungrouped views always have
+// their one document.
+func (v *view) readGroup(ctx context.Context, key []any) (string, data.Documenter, error) {
+	id, err := groupID(key)
+	if err != nil {
+		return "", nil, err
+	}
+	filter := v.query.Filters
+	for i, group := range v.groups() {
+		filter = and(filter, groupFilter(group, key[i]))
+	}
+
+	if len(key) > 0 {
+		limit := 1
+		members, err := v.source.Read(ctx, &query.Query{Filters: filter, Limit: &limit})
+		if err != nil {
+			return "", nil, err
+		}
+		if len(members.Data) == 0 {
+			return id, nil, nil
+		}
+	}
+
+	aggregations := slices.Clone(v.query.Aggregations)
+	for i := range aggregations {
+		aggregations[i].Groups = nil
+	}
+	result, err := v.source.Read(ctx, &query.Query{Filters: filter, Aggregations: aggregations})
+	if err != nil {
+		return "", nil, err
+	}
+	values := make(map[string]any, len(aggregations)+len(key)+1)
+	if len(result.Data) > 0 {
+		row := result.Data[0].Data()
+		for _, agg := range aggregations {
+			values[*agg.Alias] = row[*agg.Alias]
+		}
+	}
+	for i, group := range v.groups() {
+		if key[i] != nil {
+			values[group] = key[i]
+		}
+	}
+	values[data.DocumentIDField] = id
+	doc, err := data.NewDocument(values)
+	if err != nil {
+		return "", nil, err
+	}
+	return id, doc, nil
+}
+
+// groupID derives the document ID of a group from its values. Document IDs
+// must be UUIDv7s, so the hash of the values is given the version and variant
+// bits of one.
+func groupID(key []any) (string, error) {
+	encoded, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	var id uuid.UUID
+	copy(id[:], sum[:16])
+	id[6] = id[6]&0x0f | 0x70
+	id[8] = id[8]&0x3f | 0x80
+	return strings.ReplaceAll(id.String(), "-", ""), nil
+}
+
+// valueAt returns the value at a dotted path of record, or nil.
+func valueAt(record map[string]any, path string) any {
+	var value any = record
+	for part := range strings.SplitSeq(path, ".") {
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = fields[part]
+	}
+	return value
+}
+
+// groupFilter matches the source documents whose group field holds value.
+func groupFilter(field string, value any) query.QueryFilter {
+	if value == nil {
+		return query.QueryFilter{Condition: &query.FilterCondition{Field: field, Operator: query.ComparisonOperatorNotExists, Value: query.NewFilterValue(true)}}
+	}
+	return query.QueryFilter{Condition: &query.FilterCondition{Field: field, Operator: query.ComparisonOperatorEq, Value: query.NewFilterValue(normalize(value))}}
+}
+
+// normalize converts the numbers drivers return into the float64 filters
+// compare against.
+func normalize(value any) any {
+	switch n := value.(type) {
+	case int64:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case float32:
+		return float64(n)
+	}
+	return value
+}
+
+func inFilter(field string, ids []string) *query.QueryFilter {
+	values := make([]any, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	return &query.QueryFilter{Condition: &query.FilterCondition{Field: field, Operator: query.ComparisonOperatorIn, Value: query.NewFilterValue(values)}}
+}
+
+// and combines an optional filter with another. Neither is modified.
+func and(filter *query.QueryFilter, other query.QueryFilter) *query.QueryFilter {
+	if filter == nil {
+		return &other
+	}
+	return &query.QueryFilter{
+		Group: &query.FilterGroup{
+			Operator:   common.LogicalAnd,
+			Conditions: []query.QueryFilter{*filter, other},
+		},
+	}
+}
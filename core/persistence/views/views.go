@@ -0,0 +1,395 @@
+// Package views maintains materialized views: collections holding the result
+// of a query over other collections, kept current as those collections
+// change.
+//
+// A View names the collection and gives the query defining it. Manager.Create
+// derives the view schema from the query with query.SchemaFromQuery, creates
+// the collection, fills it and subscribes to the persistence events of every
+// collection the query reads. The view is then read through the normal
+// Collection API, like any other collection.
+//
+//	manager := views.New(p, views.Config{Logger: logger})
+//	totals, err := manager.Create(ctx, views.View{
+//		Name: "order_totals",
+//		Query: query.NewQueryBuilder().From("orders").
+//			GroupBy("status").Type(query.AggregationTypeSum).Field("amount").Alias("total").End().
+//			Build(),
+//	})
+//
+// How a view is kept current depends on its query:
+//
+//   - Row views filter, and optionally project, a single collection. Each
+//     view document carries the ID of the source document it was computed
+//     from, and a write replaces just the view documents of the source
+//     documents it touched.
+//   - Aggregate views compute aliased aggregations over a single collection,
+//     either ungrouped or with every aggregation grouped by the same fields.
+//     The view holds a document per group, and a write recomputes just the
+//     groups of the documents it touched.
+//   - Other views, such as those with joins, distinct, limits or pagination,
+//     are recomputed in full after every write to any of their sources.
+//
+// Incremental maintenance reads the written documents from event outputs, so
+// it needs an event bus delivering outputs as Go values. Writes whose
+// documents are unknown, such as updates that do not return their documents
+// or events decoded from JSON, recompute the view in full, as do deletes from
+// aggregate views and updates changing a group field. A view whose
+// maintenance fails is recomputed in full on the next write, or by calling
+// Manager.Refresh.
+//
+// Views are only as current as their events: without an event bus they are
+// filled on creation and change only when refreshed. Writes made directly to
+// a view collection are overwritten by its maintenance.
+package views
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/transaction"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MetadataKey is the schema Metadata key marking a collection as a view. Its
+// value fingerprints the view query, so that creating a view again over an
+// existing collection reuses it only when the definition is unchanged.
+const MetadataKey = "materialized_view"
+
+// View defines a materialized view.
+type View struct {
+	// Name is the name of the view collection.
+	Name        string
+	Description string
+	// Query computes the view. Its target, and the targets of its joins,
+	// are the source collections of the view. Sorting is ignored: views are
+	// read with their own queries.
+	Query query.Query
+}
+
+// Config configures a Manager.
+type Config struct {
+	Logger *zap.Logger
+}
+
+// Manager creates and maintains the materialized views of a persistence.
+type Manager struct {
+	persistence base.Persistence
+	logger      *zap.Logger
+
+	mu    sync.Mutex
+	views map[string]*view
+}
+
+// New returns a Manager creating views in p.
+func New(p base.Persistence, cfg Config) *Manager {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Manager{persistence: p, logger: logger, views: make(map[string]*view)}
+}
+
+// Create creates the collection of a view, fills it and keeps it current
+// until the view is dropped or the manager closed.
+//
+// Views are not persisted: a process maintaining a view creates it again on
+// every start. A view collection left by an earlier run is reused and
+// refreshed when its definition is unchanged; any other existing collection
+// of the same name is reported as ErrViewExists.
+func (m *Manager) Create(ctx context.Context, def View) (base.Collection, error) {
+	if def.Name == "" {
+		return nil, ErrInvalidView.WithMessage("views require a name").WithOperation("Create")
+	}
+	if def.Query.Target == nil || def.Query.Target.Name == "" {
+		return nil, ErrInvalidView.WithMessagef("view %q requires a query target", def.Name).WithOperation("Create")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.views[def.Name]; ok {
+		return nil, ErrViewExists.WithMessagef("view %q already exists", def.Name).WithOperation("Create")
+	}
+
+	q, err := bind(&def.Query)
+	if err != nil {
+		return nil, ErrInvalidView.WithCause(err).WithOperation("Create")
+	}
+	kind, helper, err := classify(q)
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range sourceNames(q) {
+		if source == def.Name {
+			return nil, ErrInvalidView.WithMessagef("view %q cannot read itself", def.Name).WithOperation("Create")
+		}
+	}
+	fingerprint, err := fingerprintOf(q)
+	if err != nil {
+		return nil, ErrInvalidView.WithCause(err).WithOperation("Create")
+	}
+
+	sources := make(map[string]base.Collection)
+	for _, name := range sourceNames(q) {
+		source, err := m.persistence.Collection(ctx, name)
+		if err != nil {
+			return nil, ErrInvalidView.WithMessagef("view %q reads collection %q, which cannot be opened", def.Name, name).WithCause(err).WithOperation("Create")
+		}
+		sources[name] = source
+	}
+
+	collection, created, err := m.open(ctx, def, q, sources, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &view{
+		definition: def,
+		query:      q,
+		kind:       kind,
+		helper:     helper,
+		collection: collection,
+		source:     sources[q.Target.Name],
+		sources:    sources,
+		ctx:        transaction.Detach(ctx),
+		logger:     m.logger.With(zap.String("view", def.Name)),
+	}
+	if err := v.start(ctx); err != nil {
+		if created {
+			if _, dropErr := m.persistence.Delete(ctx, def.Name); dropErr != nil {
+				m.logger.Warn("failed to remove the collection of a view that could not be created", zap.String("view", def.Name), zap.Error(dropErr))
+			}
+		}
+		return nil, err
+	}
+	m.views[def.Name] = v
+	return collection, nil
+}
+
+// open returns the collection of a view, creating it unless an earlier run
+// left it behind. created reports whether it was created.
+func (m *Manager) open(ctx context.Context, def View, q *query.Query, sources map[string]base.Collection, fingerprint string) (base.Collection, bool, error) {
+	exists, err := m.persistence.HasCollection(ctx, def.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	if exists {
+		collection, err := m.persistence.Collection(ctx, def.Name)
+		if err != nil {
+			return nil, false, err
+		}
+		sc, err := collection.Schema(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		if marker, _ := sc.Metadata[MetadataKey].(string); marker != fingerprint {
+			return nil, false, ErrViewExists.WithMessagef("collection %q exists and does not hold this view; drop it first", def.Name).WithOperation("Create")
+		}
+		return collection, false, nil
+	}
+
+	sc, err := viewSchema(ctx, def, q, sources)
+	if err != nil {
+		return nil, false, err
+	}
+	sc.Metadata[MetadataKey] = fingerprint
+	collection, err := m.persistence.CreateCollection(ctx, sc)
+	if err != nil {
+		return nil, false, err
+	}
+	m.logger.Info("created view collection", zap.String("view", def.Name))
+	return collection, true, nil
+}
+
+// Refresh recomputes a view in full.
+func (m *Manager) Refresh(ctx context.Context, name string) error {
+	v, err := m.view(name, "Refresh")
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.refresh(ctx)
+}
+
+// Drop stops maintaining a view and deletes its collection.
+func (m *Manager) Drop(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.views[name]
+	if !ok {
+		return ErrViewNotFound.WithMessagef("view %q not found", name).WithOperation("Drop")
+	}
+	v.stop(ctx)
+	delete(m.views, name)
+	if _, err := m.persistence.Delete(ctx, name); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Views returns the definitions of the views being maintained, ordered by
+// name.
+func (m *Manager) Views() []View {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defs := make([]View, 0, len(m.views))
+	for _, v := range m.views {
+		defs = append(defs, v.definition)
+	}
+	slices.SortFunc(defs, func(a, b View) int {
+		switch {
+		case a.Name < b.Name:
+			return -1
+		case a.Name > b.Name:
+			return 1
+		}
+		return 0
+	})
+	return defs
+}
+
+// Close stops maintaining every view. View collections are kept.
+func (m *Manager) Close(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, v := range m.views {
+		v.stop(ctx)
+		delete(m.views, name)
+	}
+}
+
+func (m *Manager) view(name, operation string) (*view, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.views[name]
+	if !ok {
+		return nil, ErrViewNotFound.WithMessagef("view %q not found", name).WithOperation(operation)
+	}
+	return v, nil
+}
+
+// bind returns a copy of q with its template bindings applied, so that
+// maintenance can evaluate its filters.
+func bind(q *query.Query) (*query.Query, error) {
+	if q.Bindings != nil {
+		return q.Bindings.Apply(q)
+	}
+	return q.Clone()
+}
+
+// sourceNames returns the collections q reads: its target, then the targets
+// of its joins.
+func sourceNames(q *query.Query) []string {
+	names := []string{q.Target.Name}
+	for _, join := range q.Joins {
+		if join.Target.Name != "" && !slices.Contains(names, join.Target.Name) {
+			names = append(names, join.Target.Name)
+		}
+	}
+	return names
+}
+
+// fingerprintOf hashes the JSON encoding of a view query.
+func fingerprintOf(q *query.Query) (string, error) {
+	encoded, err := json.Marshal(q)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// viewSchema derives the schema of a view collection from its query and the
+// schemas of its sources.
+//
+// Source indexes and constraints are not carried over, since the fields they
+// name may not be in the view.
+func viewSchema(ctx context.Context, def View, q *query.Query, sources map[string]base.Collection) (*definition.Schema, error) {
+	typed, err := q.Clone()
+	if err != nil {
+		return nil, err
+	}
+	resolve := func(target *query.QueryTarget) error {
+		sc, err := sources[target.Name].Schema(ctx)
+		if err != nil {
+			return err
+		}
+		target.Schema = sc
+		return nil
+	}
+	if err := resolve(typed.Target); err != nil {
+		return nil, err
+	}
+	for i := range typed.Joins {
+		if err := resolve(&typed.Joins[i].Target); err != nil {
+			return nil, err
+		}
+	}
+
+	var sc *definition.Schema
+	if len(typed.Aggregations) > 0 {
+		sc, err = aggregateSchema(typed)
+	} else {
+		sc, err = query.SchemaFromQuery(typed, nil)
+	}
+	if err != nil {
+		return nil, ErrUnsupportedView.WithCause(err).WithOperation("Create")
+	}
+	// Plain queries share the target schema rather than copy it.
+	sc = sc.DeepCopy()
+	sc.Name = def.Name
+	sc.Description = def.Description
+	sc.Version = common.MustNewVersion("1.0.0")
+	sc.Indexes = nil
+	sc.Constraints = nil
+	sc.Metadata = map[string]any{}
+	rekeyFields(sc)
+	return sc, nil
+}
+
+// aggregateSchema returns the schema of an aggregate view: a field per
+// aggregation, typed by query.SchemaFromQuery, and the group fields of the
+// source.
+func aggregateSchema(q *query.Query) (*definition.Schema, error) {
+	ungrouped := *q
+	ungrouped.Aggregations = slices.Clone(q.Aggregations)
+	for i := range ungrouped.Aggregations {
+		ungrouped.Aggregations[i].Groups = nil
+	}
+	sc, err := query.SchemaFromQuery(&ungrouped, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range q.Aggregations[0].Groups {
+		id, field := q.Target.Schema.FindField(group)
+		if field == nil {
+			return nil, ErrInvalidView.WithMessagef("group field %q is not in the schema of %q", group, q.Target.Name)
+		}
+		grouped := *field
+		grouped.Required = false
+		sc.Fields[id] = grouped
+	}
+	return sc, nil
+}
+
+// rekeyFields gives UUIDv7 IDs, which schemas require outside development,
+// to fields keyed by their name, such as aggregations.
+func rekeyFields(sc *definition.Schema) {
+	fields := make(map[definition.FieldId]definition.Field, len(sc.Fields))
+	for id, field := range sc.Fields {
+		if parsed, err := uuid.Parse(string(id)); err != nil || parsed.Version() != 7 {
+			id = definition.FieldId(uuid.Must(uuid.NewV7()).String())
+		}
+		fields[id] = field
+	}
+	sc.Fields = fields
+}
@@ -0,0 +1,339 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asaidimu/go-anansi/v8/core/utils"
+)
+
+// timeBucketLayout formats the start of a time bucket. It matches the text
+// SQL backends produce, so buckets compare equal wherever they are computed.
+const timeBucketLayout = "2006-01-02T15:04:05Z"
+
+// timeBucketUnits lists the supported time bucket units.
+var timeBucketUnits = map[TimeBucketUnit]struct{}{
+	TimeBucketMinute: {},
+	TimeBucketHour:   {},
+	TimeBucketDay:    {},
+	TimeBucketWeek:   {},
+	TimeBucketMonth:  {},
+}
+
+// AliasOrDefault returns the alias if set, otherwise "<field>_<unit>".
+func (b *TimeBucket) AliasOrDefault() string {
+	if b.Alias != "" {
+		return b.Alias
+	}
+	return b.Field + "_" + string(b.Unit)
+}
+
+// timeBucketValue truncates a timestamp to the start of its bucket in UTC.
+// Numbers are read as Unix seconds; values that are not timestamps fall into
+// the nil bucket.
+func timeBucketValue(value any, unit TimeBucketUnit) any {
+	var t time.Time
+	if f, ok := getFloat(value); ok {
+		t = time.Unix(int64(f), 0)
+	} else if parsed, ok := utils.CoerceTime(value); ok {
+		t = parsed
+	} else {
+		return nil
+	}
+	t = t.UTC()
+
+	switch unit {
+	case TimeBucketMinute:
+		t = t.Truncate(time.Minute)
+	case TimeBucketHour:
+		t = t.Truncate(time.Hour)
+	case TimeBucketDay:
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case TimeBucketWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		t = time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+	case TimeBucketMonth:
+		t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return nil
+	}
+	return t.Format(timeBucketLayout)
+}
+
+// validateAggregationOptions checks the settings specific to an aggregation
+// type and its time groups.
+func validateAggregationOptions(agg AggregationConfiguration) error {
+	if agg.Type == AggregationTypePercentile {
+		if agg.Percentile == nil || *agg.Percentile < 0 || *agg.Percentile > 1 || math.IsNaN(*agg.Percentile) {
+			return ErrInvalidPercentile.WithOperation("validateAggregationConfiguration").WithPath(agg.Field)
+		}
+	}
+	for _, bucket := range agg.TimeGroups {
+		if _, ok := timeBucketUnits[bucket.Unit]; !ok || bucket.Field == "" {
+			return ErrInvalidTimeBucket.WithOperation("validateAggregationConfiguration").WithPath(bucket.Field)
+		}
+	}
+	for _, order := range agg.OrderBy {
+		if order.Field == "" {
+			return ErrSortFieldEmpty.WithOperation("validateAggregationConfiguration")
+		}
+	}
+	return nil
+}
+
+// aggregateFunction resolves the function for an aggregation: a registered
+// function takes precedence over the built-in one.
+func (h *QueryHelper) aggregateFunction(agg AggregationConfiguration) (AggregateFunction, bool) {
+	if h.aggregates != nil {
+		if fn, ok := (*h.aggregates)[agg.Type]; ok {
+			return fn, true
+		}
+	}
+	return h.builtinAggregate(agg)
+}
+
+// builtinAggregate returns the in-memory implementation of a standard
+// aggregation type, bound to the options of its configuration. Nulls are
+// ignored, and aggregations over no values yield nil, except counts.
+func (h *QueryHelper) builtinAggregate(agg AggregationConfiguration) (AggregateFunction, bool) {
+	switch agg.Type {
+	case AggregationTypeCount:
+		return func(records []map[string]any, field string) (any, error) {
+			if field == "" || field == "*" {
+				return int64(len(records)), nil
+			}
+			return int64(len(aggregateValues(records, field))), nil
+		}, true
+
+	case AggregationTypeCountDistinct:
+		return func(records []map[string]any, field string) (any, error) {
+			seen := make(map[string]struct{})
+			for _, value := range aggregateValues(records, field) {
+				key, err := distinctValueKey(value)
+				if err != nil {
+					return nil, err
+				}
+				seen[key] = struct{}{}
+			}
+			return int64(len(seen)), nil
+		}, true
+
+	case AggregationTypeSum:
+		return func(records []map[string]any, field string) (any, error) {
+			var (
+				sum      float64
+				intSum   int64
+				numeric  int
+				integers = true
+			)
+			for _, value := range aggregateValues(records, field) {
+				f, ok := getFloat(value)
+				if !ok {
+					continue
+				}
+				numeric++
+				sum += f
+				switch reflect.ValueOf(value).Kind() {
+				case reflect.Float32, reflect.Float64:
+					integers = false
+				default:
+					intSum += int64(f)
+				}
+			}
+			switch {
+			case numeric == 0:
+				return nil, nil
+			case integers:
+				return intSum, nil
+			default:
+				return sum, nil
+			}
+		}, true
+
+	case AggregationTypeAvg:
+		return func(records []map[string]any, field string) (any, error) {
+			values := numericValues(records, field)
+			if len(values) == 0 {
+				return nil, nil
+			}
+			var sum float64
+			for _, v := range values {
+				sum += v
+			}
+			return sum / float64(len(values)), nil
+		}, true
+
+	case AggregationTypeMin, AggregationTypeMax:
+		sign := 1
+		if agg.Type == AggregationTypeMin {
+			sign = -1
+		}
+		return func(records []map[string]any, field string) (any, error) {
+			var extreme any
+			for _, value := range aggregateValues(records, field) {
+				if extreme == nil || sign*h.compareValues(value, extreme) > 0 {
+					extreme = value
+				}
+			}
+			return extreme, nil
+		}, true
+
+	case AggregationTypeGroupConcat:
+		separator := ","
+		if agg.Separator != nil {
+			separator = *agg.Separator
+		}
+		return func(records []map[string]any, field string) (any, error) {
+			values := aggregateValues(records, field)
+			if len(values) == 0 {
+				return nil, nil
+			}
+			parts := make([]string, len(values))
+			for i, value := range values {
+				parts[i] = fmt.Sprintf("%v", value)
+			}
+			return strings.Join(parts, separator), nil
+		}, true
+
+	case AggregationTypeArrayAgg:
+		return func(records []map[string]any, field string) (any, error) {
+			values := aggregateValues(records, field)
+			if len(values) == 0 {
+				return nil, nil
+			}
+			return values, nil
+		}, true
+
+	case AggregationTypeVariance, AggregationTypeStddev:
+		stddev := agg.Type == AggregationTypeStddev
+		return func(records []map[string]any, field string) (any, error) {
+			variance, ok := sampleVariance(numericValues(records, field))
+			if !ok {
+				return nil, nil
+			}
+			if stddev {
+				return math.Sqrt(variance), nil
+			}
+			return variance, nil
+		}, true
+
+	case AggregationTypePercentile, AggregationTypeMedian:
+		fraction := 0.5
+		if agg.Type == AggregationTypePercentile && agg.Percentile != nil {
+			fraction = *agg.Percentile
+		}
+		return func(records []map[string]any, field string) (any, error) {
+			values := numericValues(records, field)
+			if len(values) == 0 {
+				return nil, nil
+			}
+			return percentileOf(values, fraction), nil
+		}, true
+
+	case AggregationTypeFirst, AggregationTypeLast:
+		last := agg.Type == AggregationTypeLast
+		return func(records []map[string]any, field string) (any, error) {
+			values := aggregateValues(records, field)
+			if len(values) == 0 {
+				return nil, nil
+			}
+			if last {
+				return values[len(values)-1], nil
+			}
+			return values[0], nil
+		}, true
+	}
+	return nil, false
+}
+
+// orderAggregationRecords returns the records in the order an aggregation's
+// OrderBy asks for. Records that compare equal keep their input order.
+func (h *QueryHelper) orderAggregationRecords(records []map[string]any, orderBy []SortConfiguration) []map[string]any {
+	if len(orderBy) == 0 {
+		return records
+	}
+	ordered := slices.Clone(records)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		for _, order := range orderBy {
+			valueI, _ := utils.GetValueByPath(ordered[i], order.Field)
+			valueJ, _ := utils.GetValueByPath(ordered[j], order.Field)
+			c := h.compareWindowValues(valueI, valueJ)
+			if c == 0 {
+				continue
+			}
+			if order.Direction == SortDirectionDesc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+	return ordered
+}
+
+// aggregateValues returns the non-null values of a field, in record order.
+func aggregateValues(records []map[string]any, field string) []any {
+	var values []any
+	for _, record := range records {
+		if value, _ := utils.GetValueByPath(record, field); value != nil {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// numericValues returns the numeric values of a field, in record order.
+func numericValues(records []map[string]any, field string) []float64 {
+	var values []float64
+	for _, value := range aggregateValues(records, field) {
+		if f, ok := getFloat(value); ok {
+			values = append(values, f)
+		}
+	}
+	return values
+}
+
+// distinctValueKey returns a key under which equal values collide, so that 1
+// and 1.0 count once, as they do in SQL.
+func distinctValueKey(value any) (string, error) {
+	if f, ok := getFloat(value); ok {
+		return fmt.Sprintf("n:%v", f), nil
+	}
+	key, err := json.Marshal(value)
+	if err != nil {
+		return "", ErrFailedToMarshalDistinctKey.WithOperation("distinctValueKey").WithCause(err)
+	}
+	return string(key), nil
+}
+
+// sampleVariance returns the sample variance of values, which needs at least
+// two of them.
+func sampleVariance(values []float64) (float64, bool) {
+	if len(values) < 2 {
+		return 0, false
+	}
+	var mean, m2 float64
+	for i, v := range values {
+		delta := v - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (v - mean)
+	}
+	return m2 / float64(len(values)-1), true
+}
+
+// percentileOf returns the continuous percentile of values, interpolating
+// linearly between the two closest ranks.
+func percentileOf(values []float64, fraction float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	position := fraction * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
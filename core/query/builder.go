@@ -161,6 +161,9 @@ func (qb *QueryBuilder) validateQueryFilter(filter QueryFilter) error {
                 fmt.Sprintf("unknown comparison operator: %s", filter.Condition.Operator),
             ).WithOperation("validateQueryFilter").WithCause(ErrUnknownComparisonOperator)
         }
+        if err := validateOperatorValue(filter.Condition); err != nil {
+            return err
+        }
     }
 
     if filter.Group != nil {
@@ -375,6 +378,58 @@ func (qb *QueryBuilder) Max(field string, alias string) *QueryBuilder {
 	return qb.Aggregate(AggregationTypeMax, field, alias)
 }
 
+func (qb *QueryBuilder) CountDistinct(field string, alias string) *QueryBuilder {
+	return qb.Aggregate(AggregationTypeCountDistinct, field, alias)
+}
+
+// GroupConcat joins the values of field with separator; an empty separator
+// keeps the default ",".
+func (qb *QueryBuilder) GroupConcat(field string, separator string, alias string) *QueryBuilder {
+	qb.Aggregate(AggregationTypeGroupConcat, field, alias)
+	if separator != "" {
+		qb.query.Aggregations[len(qb.query.Aggregations)-1].Separator = &separator
+	}
+	return qb
+}
+
+func (qb *QueryBuilder) ArrayAgg(field string, alias string) *QueryBuilder {
+	return qb.Aggregate(AggregationTypeArrayAgg, field, alias)
+}
+
+func (qb *QueryBuilder) Stddev(field string, alias string) *QueryBuilder {
+	return qb.Aggregate(AggregationTypeStddev, field, alias)
+}
+
+func (qb *QueryBuilder) Variance(field string, alias string) *QueryBuilder {
+	return qb.Aggregate(AggregationTypeVariance, field, alias)
+}
+
+// Percentile computes the continuous percentile of field, with fraction
+// between 0 and 1.
+func (qb *QueryBuilder) Percentile(field string, fraction float64, alias string) *QueryBuilder {
+	qb.Aggregate(AggregationTypePercentile, field, alias)
+	qb.query.Aggregations[len(qb.query.Aggregations)-1].Percentile = &fraction
+	return qb
+}
+
+func (qb *QueryBuilder) Median(field string, alias string) *QueryBuilder {
+	return qb.Aggregate(AggregationTypeMedian, field, alias)
+}
+
+// First takes the first non-null value of field, with records ordered by orderBy.
+func (qb *QueryBuilder) First(field string, alias string, orderBy ...SortConfiguration) *QueryBuilder {
+	qb.Aggregate(AggregationTypeFirst, field, alias)
+	qb.query.Aggregations[len(qb.query.Aggregations)-1].OrderBy = orderBy
+	return qb
+}
+
+// Last takes the last non-null value of field, with records ordered by orderBy.
+func (qb *QueryBuilder) Last(field string, alias string, orderBy ...SortConfiguration) *QueryBuilder {
+	qb.Aggregate(AggregationTypeLast, field, alias)
+	qb.query.Aggregations[len(qb.query.Aggregations)-1].OrderBy = orderBy
+	return qb
+}
+
 func (qb *QueryBuilder) GroupBy(groupField string) AggregationBuilderInterface {
 	return &AggregationBuilder{
 		queryBuilder: qb,
@@ -384,6 +439,17 @@ func (qb *QueryBuilder) GroupBy(groupField string) AggregationBuilderInterface {
 	}
 }
 
+// GroupByTime starts an aggregation grouped by field truncated to unit. An
+// empty alias names the bucket "<field>_<unit>".
+func (qb *QueryBuilder) GroupByTime(field string, unit TimeBucketUnit, alias string) AggregationBuilderInterface {
+	return &AggregationBuilder{
+		queryBuilder: qb,
+		aggregation: &AggregationConfiguration{
+			TimeGroups: []TimeBucket{{Field: field, Unit: unit, Alias: alias}},
+		},
+	}
+}
+
 // Distinct
 func (qb *QueryBuilder) Distinct() *QueryBuilder {
 	isDistinct := true
@@ -559,6 +625,38 @@ func (fcb *FilterConditionBuilder) NotExists() *QueryBuilder {
 	return fcb.buildCondition(ComparisonOperatorNotExists, true)
 }
 
+// Matches filters on the field matching a regular expression in Go RE2
+// syntax; prefix the pattern with (?i) for a case-insensitive match.
+func (fcb *FilterConditionBuilder) Matches(pattern string) *QueryBuilder {
+	return fcb.buildCondition(ComparisonOperatorRegex, pattern)
+}
+
+// EqualFold filters on case-insensitive equality with value.
+func (fcb *FilterConditionBuilder) EqualFold(value string) *QueryBuilder {
+	return fcb.buildCondition(ComparisonOperatorIEq, value)
+}
+
+// Between filters on the field lying between low and high, inclusive.
+func (fcb *FilterConditionBuilder) Between(low, high any) *QueryBuilder {
+	return fcb.buildCondition(ComparisonOperatorBetween, []any{low, high})
+}
+
+// Size filters on the array field holding exactly n elements.
+func (fcb *FilterConditionBuilder) Size(n int) *QueryBuilder {
+	return fcb.buildCondition(ComparisonOperatorSize, n)
+}
+
+// ElemMatch filters on at least one element of the array field satisfying
+// filter, whose fields are relative to the element.
+func (fcb *FilterConditionBuilder) ElemMatch(filter QueryFilter) *QueryBuilder {
+	return fcb.buildCondition(ComparisonOperatorElemMatch, filter)
+}
+
+// All filters on the array field holding every one of values.
+func (fcb *FilterConditionBuilder) All(values ...any) *QueryBuilder {
+	return fcb.buildCondition(ComparisonOperatorAll, values)
+}
+
 func (fcb *FilterConditionBuilder) Custom(operator ComparisonOperator, value any) *QueryBuilder {
 	return fcb.buildCondition(operator, value)
 }
@@ -675,6 +773,30 @@ func (fcbig *FilterConditionBuilderInGroup) NotExists() *FilterGroupBuilder {
 	return fcbig.buildCondition(ComparisonOperatorNotExists, true)
 }
 
+func (fcbig *FilterConditionBuilderInGroup) Matches(pattern string) *FilterGroupBuilder {
+	return fcbig.buildCondition(ComparisonOperatorRegex, pattern)
+}
+
+func (fcbig *FilterConditionBuilderInGroup) EqualFold(value string) *FilterGroupBuilder {
+	return fcbig.buildCondition(ComparisonOperatorIEq, value)
+}
+
+func (fcbig *FilterConditionBuilderInGroup) Between(low, high any) *FilterGroupBuilder {
+	return fcbig.buildCondition(ComparisonOperatorBetween, []any{low, high})
+}
+
+func (fcbig *FilterConditionBuilderInGroup) Size(n int) *FilterGroupBuilder {
+	return fcbig.buildCondition(ComparisonOperatorSize, n)
+}
+
+func (fcbig *FilterConditionBuilderInGroup) ElemMatch(filter QueryFilter) *FilterGroupBuilder {
+	return fcbig.buildCondition(ComparisonOperatorElemMatch, filter)
+}
+
+func (fcbig *FilterConditionBuilderInGroup) All(values ...any) *FilterGroupBuilder {
+	return fcbig.buildCondition(ComparisonOperatorAll, values)
+}
+
 func (fcbig *FilterConditionBuilderInGroup) Custom(operator ComparisonOperator, value any) *FilterGroupBuilder {
 	return fcbig.buildCondition(operator, value)
 }
@@ -798,6 +920,20 @@ func (pb *ProjectionBuilder) AddCase(alias string) CaseExpressionBuilderInterfac
 	}
 }
 
+// AddWindow starts a window expression computing fn over field, stored under
+// alias. Ranking functions and a plain count take an empty field.
+func (pb *ProjectionBuilder) AddWindow(alias string, fn WindowFunction, field string) WindowExpressionBuilderInterface {
+	return &WindowExpressionBuilder{
+		projectionBuilder: pb,
+		window: WindowExpression{
+			Type:     "window",
+			Function: fn,
+			Field:    field,
+			Alias:    alias,
+		},
+	}
+}
+
 func (pb *ProjectionBuilder) End() *QueryBuilder {
 	pb.queryBuilder.query.Projection = pb.projection
 	return pb.queryBuilder
@@ -845,6 +981,85 @@ func (ceb *CaseExpressionBuilder) End() *ProjectionBuilder {
 	return pb
 }
 
+// WindowExpressionBuilder implementation
+type WindowExpressionBuilder struct {
+	projectionBuilder *ProjectionBuilder
+	window            WindowExpression
+}
+
+func (web *WindowExpressionBuilder) PartitionBy(fields ...string) *WindowExpressionBuilder {
+	web.window.PartitionBy = append(web.window.PartitionBy, fields...)
+	return web
+}
+
+func (web *WindowExpressionBuilder) OrderBy(field string, direction SortDirection) *WindowExpressionBuilder {
+	web.window.OrderBy = append(web.window.OrderBy, SortConfiguration{
+		Field:     field,
+		Direction: direction,
+	})
+	return web
+}
+
+// Offset sets how many rows lag and lead look back or ahead.
+func (web *WindowExpressionBuilder) Offset(offset int) *WindowExpressionBuilder {
+	web.window.Offset = &offset
+	return web
+}
+
+// Default sets the value lag and lead return when the offset row does not
+// exist.
+func (web *WindowExpressionBuilder) Default(value any) *WindowExpressionBuilder {
+	defaultVal := convertToFilterValue(value)
+	web.window.Default = &defaultVal
+	return web
+}
+
+// Rows frames the window by physical row offsets from the current row.
+func (web *WindowExpressionBuilder) Rows(start, end WindowFrameBound) *WindowExpressionBuilder {
+	web.window.Frame = &WindowFrame{Unit: WindowFrameRows, Start: start, End: end}
+	return web
+}
+
+// Range frames the window by peer groups of the current row.
+func (web *WindowExpressionBuilder) Range(start, end WindowFrameBound) *WindowExpressionBuilder {
+	web.window.Frame = &WindowFrame{Unit: WindowFrameRange, Start: start, End: end}
+	return web
+}
+
+func (web *WindowExpressionBuilder) End() *ProjectionBuilder {
+	window := web.window
+	pb := web.projectionBuilder
+	pb.projection.Computed = append(pb.projection.Computed, ProjectionComputedItem{
+		WindowExpression: &window,
+	})
+	return pb
+}
+
+// UnboundedPreceding is the frame bound at the first row of the partition.
+func UnboundedPreceding() WindowFrameBound {
+	return WindowFrameBound{Type: WindowBoundUnboundedPreceding}
+}
+
+// Preceding is the frame bound offset rows before the current row.
+func Preceding(offset int) WindowFrameBound {
+	return WindowFrameBound{Type: WindowBoundPreceding, Offset: offset}
+}
+
+// CurrentRow is the frame bound at the current row.
+func CurrentRow() WindowFrameBound {
+	return WindowFrameBound{Type: WindowBoundCurrentRow}
+}
+
+// Following is the frame bound offset rows after the current row.
+func Following(offset int) WindowFrameBound {
+	return WindowFrameBound{Type: WindowBoundFollowing, Offset: offset}
+}
+
+// UnboundedFollowing is the frame bound at the last row of the partition.
+func UnboundedFollowing() WindowFrameBound {
+	return WindowFrameBound{Type: WindowBoundUnboundedFollowing}
+}
+
 // JoinBuilder implementation
 type JoinBuilder struct {
 	queryBuilder *QueryBuilder
@@ -904,6 +1119,26 @@ func (ab *AggregationBuilder) AddGroup(groupField string) *AggregationBuilder {
 	return ab
 }
 
+func (ab *AggregationBuilder) AddTimeGroup(field string, unit TimeBucketUnit, alias string) *AggregationBuilder {
+	ab.aggregation.TimeGroups = append(ab.aggregation.TimeGroups, TimeBucket{Field: field, Unit: unit, Alias: alias})
+	return ab
+}
+
+func (ab *AggregationBuilder) Separator(separator string) *AggregationBuilder {
+	ab.aggregation.Separator = &separator
+	return ab
+}
+
+func (ab *AggregationBuilder) Percentile(fraction float64) *AggregationBuilder {
+	ab.aggregation.Percentile = &fraction
+	return ab
+}
+
+func (ab *AggregationBuilder) OrderBy(field string, direction SortDirection) *AggregationBuilder {
+	ab.aggregation.OrderBy = append(ab.aggregation.OrderBy, SortConfiguration{Field: field, Direction: direction})
+	return ab
+}
+
 func (ab *AggregationBuilder) WithFilter(filter QueryFilter) *AggregationBuilder {
 	ab.aggregation.Filter = &filter
 	return ab
@@ -914,6 +1149,14 @@ func (ab *AggregationBuilder) End() *QueryBuilder {
 	return ab.queryBuilder
 }
 
+// NewFilterValue converts a Go value (string, number, bool, []any,
+// map[string]any, field reference, subquery, function call or the
+// QueryFilter of an elem_match) into the FilterValue union used by filter
+// conditions.
+func NewFilterValue(value any) FilterValue {
+	return convertToFilterValue(value)
+}
+
 func convertToFilterValue(value any) FilterValue {
 	switch v := value.(type) {
 	case string:
@@ -945,6 +1188,14 @@ func convertToFilterValue(value any) FilterValue {
 		return FilterValue{FunctionCallVal: v}
 	case FunctionCall:
 		return FilterValue{FunctionCallVal: &v}
+	case QueryFilter:
+		return FilterValue{ElementFilterVal: &ElementFilter{Type: "filter", Filter: v}}
+	case *QueryFilter:
+		return FilterValue{ElementFilterVal: &ElementFilter{Type: "filter", Filter: *v}}
+	case Parameter:
+		return FilterValue{ParamVal: &v}
+	case *Parameter:
+		return FilterValue{ParamVal: v}
 	default:
 		// Try to convert to string as fallback
 		str := fmt.Sprintf("%v", v)
@@ -33,6 +33,12 @@ const (
 	ComparisonOperatorNotContains ComparisonOperator = "ncontains"
 	ComparisonOperatorExists      ComparisonOperator = "exists"
 	ComparisonOperatorNotExists   ComparisonOperator = "nexists"
+	ComparisonOperatorRegex       ComparisonOperator = "regex"      // Field matches a regular expression (Go RE2 syntax)
+	ComparisonOperatorIEq         ComparisonOperator = "ieq"        // Case-insensitive string equality
+	ComparisonOperatorBetween     ComparisonOperator = "between"    // Inclusive range; the value is a two-element array
+	ComparisonOperatorSize        ComparisonOperator = "size"       // Array field has exactly this many elements
+	ComparisonOperatorElemMatch   ComparisonOperator = "elem_match" // Some array element satisfies an ElementFilter
+	ComparisonOperatorAll         ComparisonOperator = "all"        // Array field holds every value in the set
 )
 
 // MatchCountName defines the alias used for the window function that calculates
@@ -64,11 +70,28 @@ type FieldReference struct {
 	Type  string `json:"type"`  // Should be "field"
 }
 
+// Parameter is a named placeholder for a filter value, bound to a concrete
+// value through a Template. See Param.
+type Parameter struct {
+	Type string `json:"type"` // Should be "param"
+	Name string `json:"name"`
+}
+
 type SubqueryValue struct {
 	Type  string `json:"type"` // Should be "subquery"
 	Query Query  `json:"query"`
 }
 
+// ElementFilter is the operand of the elem_match operator: a filter evaluated
+// against each element of an array field. Condition fields inside it are
+// paths relative to the element, and an empty field refers to the element
+// itself, so scalar arrays are matched with Field "". Field references in
+// values still resolve against the enclosing document.
+type ElementFilter struct {
+	Type   string      `json:"type"` // Should be "filter"
+	Filter QueryFilter `json:"filter"`
+}
+
 // FunctionCall represents a call to a function, which can be either a standard SQL
 // function or a custom Go function registered with the query processor.
 type FunctionCall struct {
@@ -82,19 +105,22 @@ type FunctionMap map[string]FunctionExecutor
 
 // FilterValue represents a union type for values used in filter conditions.
 // It can hold a primitive value (string, number, boolean, object),
-// an array of FilterValues, a FieldReference, a SubqueryValue, or a FunctionCall.
+// an array of FilterValues, a FieldReference, a SubqueryValue, a FunctionCall,
+// or the ElementFilter of an elem_match condition.
 type FilterValue struct {
 	// Pointers to hold one of the possible values.
 	// Using pointers allows us to distinguish between a zero value and a missing value,
 	// and enables omitempty behavior when marshalling.
-	StringVal       *string         `json:"string_value,omitempty"`
-	NumberVal       *float64        `json:"number_value,omitempty"`
-	BoolVal         *bool           `json:"bool_value,omitempty"`
-	ObjectVal       map[string]any  `json:"object_value,omitempty"`
-	ArrayVal        []FilterValue   `json:"array_value,omitempty"`
-	FieldRefVal     *FieldReference `json:"field_reference_value,omitempty"`
-	SubqueryVal     *SubqueryValue  `json:"subquery_value,omitempty"`
-	FunctionCallVal *FunctionCall   `json:"function_call_value,omitempty"`
+	StringVal        *string         `json:"string_value,omitempty"`
+	NumberVal        *float64        `json:"number_value,omitempty"`
+	BoolVal          *bool           `json:"bool_value,omitempty"`
+	ObjectVal        map[string]any  `json:"object_value,omitempty"`
+	ArrayVal         []FilterValue   `json:"array_value,omitempty"`
+	FieldRefVal      *FieldReference `json:"field_reference_value,omitempty"`
+	SubqueryVal      *SubqueryValue  `json:"subquery_value,omitempty"`
+	FunctionCallVal  *FunctionCall   `json:"function_call_value,omitempty"`
+	ElementFilterVal *ElementFilter  `json:"element_filter_value,omitempty"`
+	ParamVal         *Parameter      `json:"param_value,omitempty"`
 }
 
 // FilterCondition defines a single condition for filtering the results of a query.
@@ -192,10 +218,86 @@ type CaseExpression struct {
 	Alias      string          `json:"alias"`
 }
 
-// ProjectionComputedItem is a union type that can be either a computed field or a case expression.
+// WindowFunction names a function evaluated over a window of rows.
+type WindowFunction string
+
+// Supported window functions. Ranking functions ignore Field; the value and
+// aggregate functions read it from every row of the window frame.
+const (
+	WindowFunctionRowNumber  WindowFunction = "row_number"
+	WindowFunctionRank       WindowFunction = "rank"
+	WindowFunctionDenseRank  WindowFunction = "dense_rank"
+	WindowFunctionLag        WindowFunction = "lag"
+	WindowFunctionLead       WindowFunction = "lead"
+	WindowFunctionFirstValue WindowFunction = "first_value"
+	WindowFunctionLastValue  WindowFunction = "last_value"
+	WindowFunctionSum        WindowFunction = "sum"
+	WindowFunctionAvg        WindowFunction = "avg"
+	WindowFunctionCount      WindowFunction = "count"
+	WindowFunctionMin        WindowFunction = "min"
+	WindowFunctionMax        WindowFunction = "max"
+)
+
+// WindowFrameUnit selects how frame offsets are counted.
+type WindowFrameUnit string
+
+// Supported frame units.
+const (
+	WindowFrameRows  WindowFrameUnit = "rows"
+	WindowFrameRange WindowFrameUnit = "range"
+)
+
+// WindowFrameBoundType identifies one end of a window frame.
+type WindowFrameBoundType string
+
+// Supported frame bounds.
+const (
+	WindowBoundUnboundedPreceding WindowFrameBoundType = "unbounded_preceding"
+	WindowBoundPreceding          WindowFrameBoundType = "preceding"
+	WindowBoundCurrentRow         WindowFrameBoundType = "current_row"
+	WindowBoundFollowing          WindowFrameBoundType = "following"
+	WindowBoundUnboundedFollowing WindowFrameBoundType = "unbounded_following"
+)
+
+// WindowFrameBound is one end of a window frame. Offset is only used by the
+// preceding and following bounds.
+type WindowFrameBound struct {
+	Type   WindowFrameBoundType `json:"type"`
+	Offset int                  `json:"offset,omitempty"`
+}
+
+// WindowFrame restricts the rows of a partition an aggregate or value window
+// function sees, relative to the current row.
+type WindowFrame struct {
+	Unit  WindowFrameUnit  `json:"unit"`
+	Start WindowFrameBound `json:"start"`
+	End   WindowFrameBound `json:"end"`
+}
+
+// WindowExpression defines a value computed over the rows sharing a partition
+// with the current row, similar to a SQL window function.
+//
+// Without a Frame, a window with OrderBy covers the partition up to the
+// current row and its peers, and a window without OrderBy covers the whole
+// partition.
+type WindowExpression struct {
+	Type        string              `json:"type"`
+	Function    WindowFunction      `json:"function"`
+	Field       string              `json:"field,omitempty"`
+	Offset      *int                `json:"offset,omitempty"`  // Rows to look back or ahead for lag and lead, 1 when nil.
+	Default     *FilterValue        `json:"default,omitempty"` // Value for lag and lead when the offset row does not exist.
+	PartitionBy []string            `json:"partition_by,omitempty"`
+	OrderBy     []SortConfiguration `json:"order_by,omitempty"`
+	Frame       *WindowFrame        `json:"frame,omitempty"`
+	Alias       string              `json:"alias"`
+}
+
+// ProjectionComputedItem is a union type that can be either a computed field,
+// a case expression or a window expression.
 type ProjectionComputedItem struct {
 	ComputedFieldExpression *ComputedFieldExpression `json:"computed_field_expression,omitempty"`
 	CaseExpression          *CaseExpression          `json:"case_expression,omitempty"`
+	WindowExpression        *WindowExpression        `json:"window_expression,omitempty"`
 }
 
 // ProjectionConfiguration defines which fields should be returned in the query result.
@@ -242,15 +344,49 @@ const (
 	AggregationTypeAvg   AggregationType = "avg"
 	AggregationTypeMin   AggregationType = "min"
 	AggregationTypeMax   AggregationType = "max"
+
+	AggregationTypeCountDistinct AggregationType = "count_distinct"
+	AggregationTypeGroupConcat   AggregationType = "group_concat" // Joins the values into a string, see Separator.
+	AggregationTypeArrayAgg      AggregationType = "array_agg"    // Collects the values into an array.
+	AggregationTypeStddev        AggregationType = "stddev"       // Sample standard deviation.
+	AggregationTypeVariance      AggregationType = "variance"     // Sample variance.
+	AggregationTypePercentile    AggregationType = "percentile"   // Continuous percentile, see Percentile.
+	AggregationTypeMedian        AggregationType = "median"
+	AggregationTypeFirst         AggregationType = "first" // First non-null value in OrderBy order.
+	AggregationTypeLast          AggregationType = "last"  // Last non-null value in OrderBy order.
+)
+
+// TimeBucketUnit is the granularity a time bucket truncates timestamps to.
+type TimeBucketUnit string
+
+// Supported time bucket units. Weeks start on Monday.
+const (
+	TimeBucketMinute TimeBucketUnit = "minute"
+	TimeBucketHour   TimeBucketUnit = "hour"
+	TimeBucketDay    TimeBucketUnit = "day"
+	TimeBucketWeek   TimeBucketUnit = "week"
+	TimeBucketMonth  TimeBucketUnit = "month"
 )
 
+// TimeBucket groups records by a timestamp field truncated to a unit. The
+// bucket value is the UTC start of the bucket, formatted as RFC 3339.
+type TimeBucket struct {
+	Field string         `json:"field"`
+	Unit  TimeBucketUnit `json:"unit"`
+	Alias string         `json:"alias,omitempty"` // Defaults to "<field>_<unit>".
+}
+
 // AggregationConfiguration defines an aggregation operation to be performed on a field.
 type AggregationConfiguration struct {
-	Type   AggregationType `json:"type"`
-	Field  string          `json:"field"`
-	Alias  *string         `json:"alias,omitempty"`  // Change to pointer and omitempty
-	Groups []string        `json:"groups,omitempty"` // Add this field
-	Filter *QueryFilter    `json:"filter,omitempty"` // Add this field
+	Type       AggregationType     `json:"type"`
+	Field      string              `json:"field"`
+	Alias      *string             `json:"alias,omitempty"`       // Change to pointer and omitempty
+	Groups     []string            `json:"groups,omitempty"`      // Add this field
+	TimeGroups []TimeBucket        `json:"time_groups,omitempty"` // Groups by truncated timestamps, after Groups.
+	Filter     *QueryFilter        `json:"filter,omitempty"`      // Add this field
+	Separator  *string             `json:"separator,omitempty"`   // Separator for group_concat, "," when nil.
+	Percentile *float64            `json:"percentile,omitempty"`  // Fraction between 0 and 1 for percentile.
+	OrderBy    []SortConfiguration `json:"order_by,omitempty"`    // Record order for first and last.
 }
 
 // QueryHint provides a way to pass optimization hints to the database.
@@ -376,6 +512,11 @@ type Query struct {
 	// native query so the executor can scan into it. Excluded from JSON so
 	// query-cache keys stay stable.
 	DocumentPool *document.DocumentPool `json:"-"`
+
+	// Bindings holds the parameter values of a query bound from a Template.
+	// Excluded from JSON so every binding of a template shares its
+	// query-cache key.
+	Bindings *Bindings `json:"-"`
 }
 
 // IsEmpty checks if the query is empty (has no operations defined).
@@ -429,6 +570,12 @@ var standardComparisonOperators = map[ComparisonOperator]struct{}{
 	ComparisonOperatorNotContains: {},
 	ComparisonOperatorExists:      {},
 	ComparisonOperatorNotExists:   {},
+	ComparisonOperatorRegex:       {},
+	ComparisonOperatorIEq:         {},
+	ComparisonOperatorBetween:     {},
+	ComparisonOperatorSize:        {},
+	ComparisonOperatorElemMatch:   {},
+	ComparisonOperatorAll:         {},
 }
 
 // standardTextSearchTypes is a set of all the standard, built-in text search types.
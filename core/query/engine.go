@@ -9,6 +9,7 @@ import (
 	"github.com/asaidimu/go-anansi/v8/core/common"
 	"github.com/asaidimu/go-anansi/v8/core/document"
 	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
 	"go.uber.org/zap"
 )
 
@@ -20,6 +21,7 @@ type QueryEngine struct {
 	filterFunctions  map[ComparisonOperator]PredicateFunction
 	logger           *zap.Logger
 	cache            QueryCache
+	telemetry        telemetry.Telemetry
 }
 
 // NewQueryEngine creates a new query executor.
@@ -51,16 +53,40 @@ func (e *QueryEngine) RegisterFilterFunction(operator ComparisonOperator, fn Pre
 	e.filterFunctions[operator] = fn
 }
 
+// SetTelemetry reports the partition, execute and residual stages of every
+// query through t, and makes t available to the components built on the
+// engine through Telemetry.
+func (e *QueryEngine) SetTelemetry(t telemetry.Telemetry) {
+	e.telemetry = t
+}
+
+// Telemetry returns the telemetry set with SetTelemetry.
+func (e *QueryEngine) Telemetry() telemetry.Telemetry {
+	return e.telemetry
+}
+
 // Query orchestrates the entire query execution process, from partitioning to final result.
 func (e *QueryEngine) Query(ctx context.Context, schemaDef *definition.Schema, dsl *Query) (*QueryResult, error) {
 	interactor, ok := GetInteractor(ctx)
 	if !ok {
 		return nil, common.NewSystemError("ERR_QUERY_INTERACTOR_NOT_FOUND", "could not get interactor").WithOperation("Query")
 	}
+	var attrs []telemetry.Attribute
+	if schemaDef != nil {
+		attrs = append(attrs, telemetry.String(telemetry.KeyCollection, schemaDef.Name))
+	}
 	var dbQuery, postProcessingQuery *Query
 	var err error
 
-	if e.cache != nil {
+	// Encrypted fields change what the database can evaluate, so their
+	// partitioning is specific to the schema and bypasses the shared cache.
+	partitioner := e.partitioner
+	opaque := OpaqueFieldsOf(schemaDef)
+	if len(opaque) > 0 {
+		partitioner = partitioner.WithOpaqueFields(opaque)
+	}
+
+	if e.cache != nil && len(opaque) == 0 {
 		key, err := e.generateCacheKey(dsl)
 		if err == nil {
 			if cached, found := e.cache.Get(key); found {
@@ -71,17 +97,27 @@ func (e *QueryEngine) Query(ctx context.Context, schemaDef *definition.Schema, d
 	}
 
 	if dbQuery == nil { // Cache miss or no cache
-		dbQuery, postProcessingQuery, err = e.partitioner.Partition(dsl)
+		_, op := e.telemetry.Start(ctx, telemetry.FamilyQuery, "partition", attrs...)
+		dbQuery, postProcessingQuery, err = partitioner.Partition(dsl)
+		op.End(ctx, err)
 		if err != nil {
 			return nil, common.NewSystemError("ERR_QUERY_PARTITIONING_FAILED", "error partitioning query").WithOperation("Query").WithCause(err)
 		}
 
-		if e.cache != nil {
+		if e.cache != nil && len(opaque) == 0 {
 			key, _ := e.generateCacheKey(dsl) // Error already handled above
 			e.cache.Set(key, &PartitionedQuery{DbQuery: dbQuery, PostProcessingQuery: postProcessingQuery})
 		}
 	}
 
+	// The partitioned queries may be shared through the cache, so the
+	// per-call fields below are set on copies.
+	passThrough := dsl.Bindings != nil && len(opaque) == 0 && interactor.Capabilities().QueryParameters
+	dbQuery, postProcessingQuery, err = bindPartitions(dsl.Bindings, dbQuery, postProcessingQuery, passThrough)
+	if err != nil {
+		return nil, err
+	}
+
 	// 2. Attach provenance for direct container scanning: record the
 	// result-row shape and the schema-bound document pool so the executor can
 	// scan rows directly into pooled containers for single-table queries.
@@ -91,11 +127,16 @@ func (e *QueryEngine) Query(ctx context.Context, schemaDef *definition.Schema, d
 	dbQuery.DocumentPool = dsl.DocumentPool
 
 	// 2. Execute the database part of the query.
-	result, err := common.ExecuteWithContext(ctx, func() (*QueryResult, error) {
-		data, count, err := interactor.SelectDocuments(ctx, schemaDef, dbQuery)
+	execCtx, op := e.telemetry.Start(ctx, telemetry.FamilyQuery, "execute", attrs...)
+	result, err := common.ExecuteWithContext(execCtx, func() (*QueryResult, error) {
+		data, count, err := interactor.SelectDocuments(execCtx, schemaDef, dbQuery)
 		total := int(count)
 		return &QueryResult{Data: data, Count: len(data), Total: &total, PaginationInfo: computePaginationInfo(dsl.Pagination, len(data), &total)}, err
 	})
+	if result != nil {
+		op.SetRows(int64(result.Count))
+	}
+	op.End(execCtx, err)
 
 	if err != nil {
 		return nil, common.NewSystemError("ERR_QUERY_DB_EXECUTION_FAILED", "database query execution failed").WithOperation("Query").WithCause(err)
@@ -107,6 +148,28 @@ func (e *QueryEngine) Query(ctx context.Context, schemaDef *definition.Schema, d
 	}
 
 	// 4. Execute the in-memory part of the query.
+	final, err := e.runResidual(ctx, attrs, dsl, postProcessingQuery, result.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueryResult{
+		Data:           final,
+		Count:          len(final),
+		Total:          result.Total,
+		PaginationInfo: computePaginationInfo(dsl.Pagination, len(final), result.Total),
+	}, nil
+}
+
+// runResidual evaluates postProcessingQuery, the part of dsl the database
+// could not, over docs and applies the projection of dsl.
+func (e *QueryEngine) runResidual(ctx context.Context, attrs []telemetry.Attribute, dsl, postProcessingQuery *Query, docs []*document.Document) (final []*document.Document, err error) {
+	ctx, op := e.telemetry.Start(ctx, telemetry.FamilyQuery, "residual", attrs...)
+	defer func() {
+		op.SetRows(int64(len(final)))
+		op.End(ctx, err)
+	}()
+
 	queryHelper, err := NewQueryHelper(postProcessingQuery, nil, nil, nil)
 	if err != nil {
 		return nil, common.NewSystemError("ERR_QUERY_HELPER_CREATION_FAILED", "failed to create query helper for post-processing").WithOperation("Query").WithCause(err)
@@ -119,8 +182,8 @@ func (e *QueryEngine) Query(ctx context.Context, schemaDef *definition.Schema, d
 	// 5. Apply post-processing steps. The in-memory query engine operates on
 	// maps, so container-backed documents are materialized for the duration of
 	// post-processing only, then rebuilt before returning.
-	rawDocs := make([]map[string]any, 0, len(result.Data))
-	for _, d := range result.Data {
+	rawDocs := make([]map[string]any, 0, len(docs))
+	for _, d := range docs {
 		rawDocs = append(rawDocs, d.ToMap())
 	}
 	processedDocs, err := e.runPostProcessing(queryHelper, rawDocs)
@@ -135,17 +198,37 @@ func (e *QueryEngine) Query(ctx context.Context, schemaDef *definition.Schema, d
 		return nil, common.NewSystemError("ERR_QUERY_FINAL_PROJECTION_FAILED", "final projection failed").WithOperation("Query").WithCause(err)
 	}
 
-	final := make([]*document.Document, 0, len(finalDocs))
+	final = make([]*document.Document, 0, len(finalDocs))
 	for _, m := range finalDocs {
 		final = append(final, document.NewRecordView(m))
 	}
+	return final, nil
+}
 
-	return &QueryResult{
-		Data:           final,
-		Count:          len(final),
-		Total:          result.Total,
-		PaginationInfo: computePaginationInfo(dsl.Pagination, len(final), result.Total),
-	}, nil
+// bindPartitions returns copies of the partitioned queries with the template
+// values in bindings applied. The post-processing query is always evaluated
+// with values; the database query keeps its parameters, for the interactor to
+// pass as statement arguments, when passThrough is set. Encrypted fields
+// disable pass-through, since the interactor must seal the values themselves.
+func bindPartitions(bindings *Bindings, dbQuery, postQuery *Query, passThrough bool) (*Query, *Query, error) {
+	if bindings == nil {
+		db := *dbQuery
+		return &db, postQuery, nil
+	}
+	boundPost, err := bindings.Apply(postQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	if passThrough {
+		db := *dbQuery
+		db.Bindings = bindings
+		return &db, boundPost, nil
+	}
+	boundDb, err := bindings.Apply(dbQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	return boundDb, boundPost, nil
 }
 
 func (e *QueryEngine) generateCacheKey(dsl *Query) (uint64, error) {
@@ -214,13 +297,18 @@ func (e *QueryEngine) runPostProcessing(helper *QueryHelper, docs []map[string]a
 
 	// In-memory joins would be handled here if any were deferred.
 
+	processedDocs, err = helper.ApplyWindows(processedDocs)
+	if err != nil {
+		return nil, common.NewSystemError("ERR_QUERY_POST_PROCESSING_WINDOW_FAILED", "post-processing window evaluation failed").WithOperation("runPostProcessing").WithCause(err)
+	}
+
 	if len(helper.query.Aggregations) > 0 {
-		// Aggregation returns a single result document, so we return it immediately.
-		aggResult, err := helper.ApplyAggregations(processedDocs)
+		// Aggregation returns one row per group, which the sort and page
+		// below apply to, as they would to a database aggregate.
+		processedDocs, err = helper.ApplyAggregations(processedDocs)
 		if err != nil {
 			return nil, common.NewSystemError("ERR_QUERY_POST_PROCESSING_AGGREGATION_FAILED", "post-processing aggregation failed").WithOperation("runPostProcessing").WithCause(err)
 		}
-		return []map[string]any{aggResult}, nil
 	}
 
 	processedDocs, err = helper.Sort(processedDocs)
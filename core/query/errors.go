@@ -44,7 +44,14 @@ var (
 	ErrCaseExpressionTypeInvalid            = common.NewSystemError("ERR_QUERY_CASE_EXPRESSION_TYPE_INVALID", "case expression type must be 'case'")
 	ErrCaseExpressionNoConditions           = common.NewSystemError("ERR_QUERY_CASE_EXPRESSION_NO_CONDITIONS", "case expression must have at least one condition")
 	ErrCaseExpressionAliasEmpty             = common.NewSystemError("ERR_QUERY_CASE_EXPRESSION_ALIAS_EMPTY", "case expression alias cannot be empty")
-	ErrProjectionComputedItemConflict       = common.NewSystemError("ERR_QUERY_PROJECTION_COMPUTED_ITEM_CONFLICT", "ProjectionComputedItem must have exactly one of ComputedFieldExpression or CaseExpression set")
+	ErrProjectionComputedItemConflict       = common.NewSystemError("ERR_QUERY_PROJECTION_COMPUTED_ITEM_CONFLICT", "ProjectionComputedItem must have exactly one of ComputedFieldExpression, CaseExpression or WindowExpression set")
+	ErrWindowExpressionTypeInvalid          = common.NewSystemError("ERR_QUERY_WINDOW_EXPRESSION_TYPE_INVALID", "window expression type must be 'window'")
+	ErrWindowExpressionAliasEmpty           = common.NewSystemError("ERR_QUERY_WINDOW_EXPRESSION_ALIAS_EMPTY", "window expression alias cannot be empty")
+	ErrUnsupportedWindowFunction            = common.NewSystemError("ERR_QUERY_UNSUPPORTED_WINDOW_FUNCTION", "unsupported window function")
+	ErrWindowFieldEmpty                     = common.NewSystemError("ERR_QUERY_WINDOW_FIELD_EMPTY", "window function requires a field")
+	ErrWindowOffsetNegative                 = common.NewSystemError("ERR_QUERY_WINDOW_OFFSET_NEGATIVE", "window offset cannot be negative")
+	ErrInvalidWindowFrame                   = common.NewSystemError("ERR_QUERY_INVALID_WINDOW_FRAME", "invalid window frame")
+	ErrWindowWithAggregations               = common.NewSystemError("ERR_QUERY_WINDOW_WITH_AGGREGATIONS", "window expressions cannot be combined with aggregations")
 	ErrDistinctConfigNil                    = common.NewSystemError("ERR_QUERY_DISTINCT_CONFIG_NIL", "distinct configuration cannot be nil")
 	ErrDistinctConfigMissingFields          = common.NewSystemError("ERR_QUERY_DISTINCT_CONFIG_MISSING_FIELDS", "distinct configuration must specify 'is_distinct' or 'fields'")
 	ErrDistinctConfigConflict               = common.NewSystemError("ERR_QUERY_DISTINCT_CONFIG_CONFLICT", "distinct configuration cannot specify both 'is_distinct' and 'fields'")
@@ -52,9 +59,24 @@ var (
 	ErrAggregationConfigEmpty               = common.NewSystemError("ERR_QUERY_AGGREGATION_CONFIG_EMPTY", "aggregation configuration cannot be empty if specified")
 	ErrAggregationFieldEmpty                = common.NewSystemError("ERR_QUERY_AGGREGATION_FIELD_EMPTY", "aggregation field cannot be empty for non-count aggregations")
 	ErrAggregationGroupFieldEmpty           = common.NewSystemError("ERR_QUERY_AGGREGATION_GROUP_FIELD_EMPTY", "aggregation group field cannot be empty")
+	ErrInvalidTimeBucket                    = common.NewSystemError("ERR_QUERY_INVALID_TIME_BUCKET", "time bucket requires a field and a supported unit")
+	ErrInvalidPercentile                    = common.NewSystemError("ERR_QUERY_INVALID_PERCENTILE", "percentile must be a fraction between 0 and 1")
+	ErrUpdateOperatorUnsupported            = common.NewSystemError("ERR_QUERY_UPDATE_OPERATOR_UNSUPPORTED", "unsupported update operator")
+	ErrUpdateOperatorFieldEmpty             = common.NewSystemError("ERR_QUERY_UPDATE_OPERATOR_FIELD_EMPTY", "update operator requires a field")
+	ErrUpdateOperatorFieldUnknown           = common.NewSystemError("ERR_QUERY_UPDATE_OPERATOR_FIELD_UNKNOWN", "update operator targets a field that is not in the schema")
+	ErrUpdateOperatorFieldType              = common.NewSystemError("ERR_QUERY_UPDATE_OPERATOR_FIELD_TYPE", "update operator does not apply to the type of its field")
+	ErrUpdateOperatorValue                  = common.NewSystemError("ERR_QUERY_UPDATE_OPERATOR_VALUE", "invalid value for update operator")
+	ErrUpdateOperatorConflict               = common.NewSystemError("ERR_QUERY_UPDATE_OPERATOR_CONFLICT", "update operators target overlapping paths")
+	ErrUpdateOperatorRequiredField          = common.NewSystemError("ERR_QUERY_UPDATE_OPERATOR_REQUIRED_FIELD", "required fields cannot be unset")
 	ErrInvalidFilterNoConditionGroupText    = common.NewSystemError("ERR_QUERY_INVALID_FILTER_NO_CONDITION_GROUP_TEXT", "invalid filter: no condition, group, or text match specified")
 	ErrInOperatorRequiresSliceOrArray       = common.NewSystemError("ERR_QUERY_IN_OPERATOR_REQUIRES_SLICE_OR_ARRAY", "IN operator requires a slice or array value as the comparison target")
 	ErrContainsOperatorRequiresString       = common.NewSystemError("ERR_QUERY_CONTAINS_OPERATOR_REQUIRES_STRING", "CONTAINS operator requires string values for comparison target")
+	ErrInvalidOperatorValue                 = common.NewSystemError("ERR_QUERY_INVALID_OPERATOR_VALUE", "invalid value for comparison operator")
+	ErrInvalidRegexPattern                  = common.NewSystemError("ERR_QUERY_INVALID_REGEX_PATTERN", "invalid regular expression pattern")
+	ErrElementFilterTypeInvalid             = common.NewSystemError("ERR_QUERY_ELEMENT_FILTER_TYPE_INVALID", "element filter type must be 'filter'")
+	ErrParameterTypeInvalid                 = common.NewSystemError("ERR_QUERY_PARAMETER_TYPE_INVALID", "parameter type must be 'param'")
+	ErrUnboundParameter                     = common.NewSystemError("ERR_QUERY_UNBOUND_PARAMETER", "query parameter has no bound value")
+	ErrInvalidTemplate                      = common.NewSystemError("ERR_QUERY_INVALID_TEMPLATE", "invalid query template")
 	ErrFunctionNotImplementedOrRegistered   = common.NewSystemError("ERR_QUERY_FUNCTION_NOT_IMPLEMENTED_OR_REGISTERED", "function not implemented or registered")
 	ErrJoinConfigNil                        = common.NewSystemError("ERR_QUERY_JOIN_CONFIG_NIL", "join configuration cannot be nil")
 	ErrUnsupportedJoinType                  = common.NewSystemError("ERR_QUERY_UNSUPPORTED_JOIN_TYPE", "unsupported join type")
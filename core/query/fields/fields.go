@@ -0,0 +1,270 @@
+// Package fields provides typed field descriptors for building queries and
+// updates against a known document shape. Generated models declare one
+// descriptor per schema field, so filters, sorts, projections and update
+// setters are checked by the compiler: renaming a field in the schema and
+// regenerating breaks every stale reference at build time.
+//
+//	q := query.NewQueryBuilder().
+//		ApplyFilter(fields.And(ProductFields.Price.Gt(10), ProductFields.Status.Eq(ProductStatusActive))).
+//		Build()
+//	q.Sort = []query.SortConfiguration{ProductFields.Price.Desc()}
+package fields
+
+import (
+	"reflect"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+)
+
+// Object describes a field whose value is not compared directly: nested
+// objects, arrays, records, unions and opaque values. It supports existence
+// checks, projection and clearing.
+type Object struct {
+	path string
+}
+
+// NewObject returns a descriptor for the field at path.
+func NewObject(path string) Object {
+	return Object{path: path}
+}
+
+// Path returns the dotted field path.
+func (f Object) Path() string {
+	return f.path
+}
+
+// Exists matches documents where the field is present and not null.
+func (f Object) Exists() query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorExists, query.FilterValue{})
+}
+
+// NotExists matches documents where the field is absent or null.
+func (f Object) NotExists() query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorNotExists, query.FilterValue{})
+}
+
+// Size matches documents where the array field holds exactly n elements.
+func (f Object) Size(n int) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorSize, query.NewFilterValue(n))
+}
+
+// ElemMatch matches documents where at least one element of the array field
+// satisfies filter. Paths in filter are relative to the element; use
+// NewString("") and friends to compare scalar elements themselves.
+func (f Object) ElemMatch(filter query.QueryFilter) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorElemMatch, query.NewFilterValue(filter))
+}
+
+// All matches documents where the array field holds every one of vs.
+func (f Object) All(vs ...any) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorAll, listValue(vs))
+}
+
+// Include returns a projection entry selecting the field.
+func (f Object) Include() query.ProjectionField {
+	return query.ProjectionField{Name: f.path}
+}
+
+// Unset clears the field.
+func (f Object) Unset() Setter {
+	return func(cu *base.CollectionUpdate) {
+		cu.SetField(f.path, nil)
+	}
+}
+
+// Field describes a field holding values of type T.
+type Field[T any] struct {
+	Object
+}
+
+// New returns a descriptor for the field at path.
+func New[T any](path string) Field[T] {
+	return Field[T]{Object: NewObject(path)}
+}
+
+// Eq matches documents where the field equals v.
+func (f Field[T]) Eq(v T) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorEq, filterValue(v))
+}
+
+// Neq matches documents where the field does not equal v.
+func (f Field[T]) Neq(v T) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorNeq, filterValue(v))
+}
+
+// In matches documents where the field equals any of vs.
+func (f Field[T]) In(vs ...T) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorIn, listValue(vs))
+}
+
+// Nin matches documents where the field equals none of vs.
+func (f Field[T]) Nin(vs ...T) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorNin, listValue(vs))
+}
+
+// Asc returns an ascending sort on the field.
+func (f Field[T]) Asc() query.SortConfiguration {
+	return query.SortConfiguration{Field: f.path, Direction: query.SortDirectionAsc}
+}
+
+// Desc returns a descending sort on the field.
+func (f Field[T]) Desc() query.SortConfiguration {
+	return query.SortConfiguration{Field: f.path, Direction: query.SortDirectionDesc}
+}
+
+// Set assigns v to the field.
+func (f Field[T]) Set(v T) Setter {
+	return func(cu *base.CollectionUpdate) {
+		cu.SetField(f.path, plain(v))
+	}
+}
+
+// Ordered describes a field whose values have a natural order: numbers,
+// decimals and strings.
+type Ordered[T any] struct {
+	Field[T]
+}
+
+// NewOrdered returns a descriptor for the ordered field at path.
+func NewOrdered[T any](path string) Ordered[T] {
+	return Ordered[T]{Field: New[T](path)}
+}
+
+// Gt matches documents where the field is greater than v.
+func (f Ordered[T]) Gt(v T) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorGt, filterValue(v))
+}
+
+// Gte matches documents where the field is greater than or equal to v.
+func (f Ordered[T]) Gte(v T) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorGte, filterValue(v))
+}
+
+// Lt matches documents where the field is less than v.
+func (f Ordered[T]) Lt(v T) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorLt, filterValue(v))
+}
+
+// Lte matches documents where the field is less than or equal to v.
+func (f Ordered[T]) Lte(v T) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorLte, filterValue(v))
+}
+
+// Between matches documents where the field lies between low and high,
+// inclusive.
+func (f Ordered[T]) Between(low, high T) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorBetween, listValue([]T{low, high}))
+}
+
+// String describes a string field.
+type String struct {
+	Ordered[string]
+}
+
+// NewString returns a descriptor for the string field at path.
+func NewString(path string) String {
+	return String{Ordered: NewOrdered[string](path)}
+}
+
+// Contains matches documents where the field contains s.
+func (f String) Contains(s string) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorContains, query.NewFilterValue(s))
+}
+
+// NotContains matches documents where the field does not contain s.
+func (f String) NotContains(s string) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorNotContains, query.NewFilterValue(s))
+}
+
+// Matches matches documents where the field matches the regular expression
+// pattern, in Go RE2 syntax.
+func (f String) Matches(pattern string) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorRegex, query.NewFilterValue(pattern))
+}
+
+// EqualFold matches documents where the field equals s, ignoring case.
+func (f String) EqualFold(s string) query.QueryFilter {
+	return condition(f.path, query.ComparisonOperatorIEq, query.NewFilterValue(s))
+}
+
+// Setter assigns one field of a CollectionUpdate.
+type Setter func(cu *base.CollectionUpdate)
+
+// Update returns a CollectionUpdate with every setter applied.
+func Update(setters ...Setter) *base.CollectionUpdate {
+	cu := base.NewCollectionUpdate()
+	for _, set := range setters {
+		set(cu)
+	}
+	return cu
+}
+
+// And matches documents matching every filter.
+func And(filters ...query.QueryFilter) query.QueryFilter {
+	return group(common.LogicalAnd, filters)
+}
+
+// Or matches documents matching at least one filter.
+func Or(filters ...query.QueryFilter) query.QueryFilter {
+	return group(common.LogicalOr, filters)
+}
+
+// Not matches documents that do not match filter.
+func Not(filter query.QueryFilter) query.QueryFilter {
+	return group(common.LogicalNot, []query.QueryFilter{filter})
+}
+
+func condition(path string, op query.ComparisonOperator, v query.FilterValue) query.QueryFilter {
+	return query.QueryFilter{Condition: &query.FilterCondition{Field: path, Operator: op, Value: v}}
+}
+
+func group(op common.LogicalOperator, filters []query.QueryFilter) query.QueryFilter {
+	if len(filters) == 1 && op != common.LogicalNot {
+		return filters[0]
+	}
+	return query.QueryFilter{Group: &query.FilterGroup{Operator: op, Conditions: filters}}
+}
+
+func listValue[T any](vs []T) query.FilterValue {
+	items := make([]query.FilterValue, len(vs))
+	for i, v := range vs {
+		items[i] = filterValue(v)
+	}
+	return query.FilterValue{ArrayVal: items}
+}
+
+// filterValue converts v to a FilterValue. Integers become numbers, which
+// query.NewFilterValue would otherwise render as strings.
+func filterValue(v any) query.FilterValue {
+	switch p := plain(v).(type) {
+	case int64:
+		return query.NewFilterValue(float64(p))
+	case uint64:
+		return query.NewFilterValue(float64(p))
+	default:
+		return query.NewFilterValue(p)
+	}
+}
+
+// plain strips named types such as generated enums down to their underlying
+// kind, so documents and filters see the values the schema declares. Other
+// values are returned unchanged.
+func plain(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	default:
+		return v
+	}
+}
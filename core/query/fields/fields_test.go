@@ -0,0 +1,131 @@
+package fields_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/query/fields"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	testutils.ConfigureDocumentFactory()
+	os.Exit(m.Run())
+}
+
+type status string
+
+const statusActive status = "active"
+
+type priority int
+
+var product = struct {
+	Name     fields.String
+	Price    fields.Ordered[float64]
+	Stock    fields.Ordered[int64]
+	Status   fields.Field[status]
+	Priority fields.Field[priority]
+	Tags     fields.Object
+}{
+	Name:     fields.NewString("name"),
+	Price:    fields.NewOrdered[float64]("price"),
+	Stock:    fields.NewOrdered[int64]("inventory.stock"),
+	Status:   fields.New[status]("status"),
+	Priority: fields.New[priority]("priority"),
+	Tags:     fields.NewObject("tags"),
+}
+
+func TestConditions(t *testing.T) {
+	f := product.Price.Gt(10)
+	require.NotNil(t, f.Condition)
+	assert.Equal(t, "price", f.Condition.Field)
+	assert.Equal(t, query.ComparisonOperatorGt, f.Condition.Operator)
+	assert.Equal(t, 10.0, *f.Condition.Value.NumberVal)
+
+	f = product.Stock.Lte(3)
+	assert.Equal(t, "inventory.stock", f.Condition.Field)
+	require.NotNil(t, f.Condition.Value.NumberVal, "integers compare as numbers")
+	assert.Equal(t, 3.0, *f.Condition.Value.NumberVal)
+
+	f = product.Status.Eq(statusActive)
+	assert.Equal(t, "active", *f.Condition.Value.StringVal)
+
+	f = product.Priority.In(1, 2)
+	require.Len(t, f.Condition.Value.ArrayVal, 2)
+	assert.Equal(t, 2.0, *f.Condition.Value.ArrayVal[1].NumberVal)
+
+	f = product.Name.Contains("lamp")
+	assert.Equal(t, query.ComparisonOperatorContains, f.Condition.Operator)
+
+	f = product.Tags.NotExists()
+	assert.Equal(t, query.ComparisonOperatorNotExists, f.Condition.Operator)
+}
+
+func TestArrayAndPatternConditions(t *testing.T) {
+	f := product.Stock.Between(1, 5)
+	assert.Equal(t, query.ComparisonOperatorBetween, f.Condition.Operator)
+	require.Len(t, f.Condition.Value.ArrayVal, 2)
+	assert.Equal(t, 5.0, *f.Condition.Value.ArrayVal[1].NumberVal)
+
+	f = product.Name.Matches("^lamp")
+	assert.Equal(t, query.ComparisonOperatorRegex, f.Condition.Operator)
+	f = product.Name.EqualFold("Lamp")
+	assert.Equal(t, query.ComparisonOperatorIEq, f.Condition.Operator)
+
+	f = product.Tags.Size(2)
+	assert.Equal(t, query.ComparisonOperatorSize, f.Condition.Operator)
+	assert.Equal(t, 2.0, *f.Condition.Value.NumberVal)
+
+	f = product.Tags.All("sale", "new")
+	assert.Equal(t, query.ComparisonOperatorAll, f.Condition.Operator)
+	assert.Len(t, f.Condition.Value.ArrayVal, 2)
+
+	f = product.Tags.ElemMatch(fields.NewString("").Eq("sale"))
+	require.NotNil(t, f.Condition.Value.ElementFilterVal)
+	assert.Equal(t, "filter", f.Condition.Value.ElementFilterVal.Type)
+	assert.Equal(t, "", f.Condition.Value.ElementFilterVal.Filter.Condition.Field)
+}
+
+func TestGroups(t *testing.T) {
+	single := fields.And(product.Price.Gt(1))
+	assert.NotNil(t, single.Condition, "a single filter is not wrapped")
+
+	g := fields.Or(product.Price.Gt(1), product.Name.Eq("x"))
+	require.NotNil(t, g.Group)
+	assert.Equal(t, common.LogicalOr, g.Group.Operator)
+	assert.Len(t, g.Group.Conditions, 2)
+
+	n := fields.Not(product.Price.Gt(1))
+	require.NotNil(t, n.Group)
+	assert.Equal(t, common.LogicalNot, n.Group.Operator)
+}
+
+func TestSortAndProjection(t *testing.T) {
+	assert.Equal(t, query.SortConfiguration{Field: "price", Direction: query.SortDirectionDesc}, product.Price.Desc())
+	assert.Equal(t, query.SortConfiguration{Field: "name", Direction: query.SortDirectionAsc}, product.Name.Asc())
+	assert.Equal(t, "inventory.stock", product.Stock.Include().Name)
+}
+
+func TestUpdate(t *testing.T) {
+	cu := fields.Update(
+		product.Status.Set(statusActive),
+		product.Priority.Set(2),
+		product.Stock.Set(7),
+		product.Tags.Unset(),
+	)
+	require.NotNil(t, cu.Set)
+
+	v, err := cu.Set.Get("status")
+	require.NoError(t, err)
+	assert.Equal(t, "active", v)
+	v, err = cu.Set.Get("priority")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, v)
+	v, err = cu.Set.Get("inventory.stock")
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, v)
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"maps"
+	"math"
 	"reflect"
+	"regexp"
 	"slices"
 	"sort"
 	"strings"
@@ -33,6 +35,7 @@ type QueryHelper struct {
 	functions         *FunctionMap             // Custom functions map
 	computeFunctions  map[string]ComputeFunction
 	goFilterFunctions map[ComparisonOperator]PredicateFunction
+	patterns          sync.Map // compiled regex operands, keyed by pattern
 	mu                sync.RWMutex
 	logger            *zap.Logger
 }
@@ -152,6 +155,10 @@ func (h *QueryHelper) validateQuery() error {
 		}
 	}
 
+	if len(h.query.Aggregations) > 0 && len(h.query.Projection.Windows()) > 0 {
+		return ErrWindowWithAggregations.WithOperation("validateQuery")
+	}
+
 	if h.query.Distinct != nil {
 		if err := h.validateDistinctConfiguration(h.query.Distinct); err != nil {
 			return common.NewSystemError("ERR_QUERY_INVALID_DISTINCT_CONFIGURATION", "invalid distinct configuration").WithOperation("validateQuery").WithCause(err)
@@ -190,23 +197,8 @@ func (h *QueryHelper) validateQueryFilter(filter *QueryFilter) error {
 		if filter.Condition.Field == "" {
 			return common.NewSystemError("ERR_QUERY_FILTER_CONDITION_FIELD_EMPTY", "condition field cannot be empty")
 		}
-
-		// *** MODIFIED LOGIC HERE: Check if operator is custom or standard ***
-		isCustom := false
-		if h.operators != nil {
-			if _, ok := (*h.operators)[string(filter.Condition.Operator)]; ok {
-				isCustom = true
-			}
-		}
-
-		if !isCustom && !filter.Condition.Operator.IsStandard() {
-			return common.NewSystemError(ErrUnknownComparisonOperator.Code, fmt.Sprintf("unsupported comparison operator: %s", filter.Condition.Operator)).WithOperation("validateQueryFilter").WithCause(ErrUnknownComparisonOperator)
-		}
-		// End of MODIFIED LOGIC
-
-		// Basic validation for FilterValue - can be extended if needed
-		if err := h.validateFilterValue(&filter.Condition.Value); err != nil {
-			return common.NewSystemError("ERR_QUERY_INVALID_CONDITION_VALUE", "invalid condition value").WithOperation("validateQueryFilter").WithCause(err)
+		if err := h.validateCondition(filter.Condition); err != nil {
+			return err
 		}
 	}
 
@@ -231,6 +223,87 @@ func (h *QueryHelper) validateQueryFilter(filter *QueryFilter) error {
 	return nil
 }
 
+// validateCondition validates the operator and operand of a single condition.
+func (h *QueryHelper) validateCondition(condition *FilterCondition) error {
+	// *** MODIFIED LOGIC HERE: Check if operator is custom or standard ***
+	isCustom := false
+	if h.operators != nil {
+		if _, ok := (*h.operators)[string(condition.Operator)]; ok {
+			isCustom = true
+		}
+	}
+
+	if !isCustom && !condition.Operator.IsStandard() {
+		return common.NewSystemError(ErrUnknownComparisonOperator.Code, fmt.Sprintf("unsupported comparison operator: %s", condition.Operator)).WithOperation("validateQueryFilter").WithCause(ErrUnknownComparisonOperator)
+	}
+	// End of MODIFIED LOGIC
+
+	// Basic validation for FilterValue - can be extended if needed
+	if err := h.validateFilterValue(&condition.Value); err != nil {
+		return common.NewSystemError("ERR_QUERY_INVALID_CONDITION_VALUE", "invalid condition value").WithOperation("validateQueryFilter").WithCause(err)
+	}
+	if isCustom {
+		return nil
+	}
+	return validateOperatorValue(condition)
+}
+
+// validateOperatorValue checks the operand shape of the operators whose value
+// is more than a single comparison target.
+func validateOperatorValue(condition *FilterCondition) error {
+	value := &condition.Value
+	switch condition.Operator {
+	case ComparisonOperatorRegex:
+		if value.StringVal == nil {
+			return ErrInvalidOperatorValue.WithMessagef("operator %q requires a string pattern", condition.Operator).WithPath(condition.Field)
+		}
+		if _, err := regexp.Compile(*value.StringVal); err != nil {
+			return ErrInvalidRegexPattern.WithCause(err).WithPath(condition.Field)
+		}
+	case ComparisonOperatorBetween:
+		if len(value.ArrayVal) != 2 {
+			return ErrInvalidOperatorValue.WithMessagef("operator %q requires a [low, high] array", condition.Operator).WithPath(condition.Field)
+		}
+	case ComparisonOperatorSize:
+		if value.NumberVal == nil || *value.NumberVal < 0 || *value.NumberVal != math.Trunc(*value.NumberVal) {
+			return ErrInvalidOperatorValue.WithMessagef("operator %q requires a non-negative integer", condition.Operator).WithPath(condition.Field)
+		}
+	case ComparisonOperatorAll:
+		if len(value.ArrayVal) == 0 {
+			return ErrInvalidOperatorValue.WithMessagef("operator %q requires at least one value", condition.Operator).WithPath(condition.Field)
+		}
+	case ComparisonOperatorElemMatch:
+		if value.ElementFilterVal == nil {
+			return ErrInvalidOperatorValue.WithMessagef("operator %q requires an element filter", condition.Operator).WithPath(condition.Field)
+		}
+	}
+	return nil
+}
+
+// validateElementFilter validates the filter of an elem_match condition.
+// Unlike a top-level filter, its conditions may leave the field empty to
+// refer to the array element itself.
+func (h *QueryHelper) validateElementFilter(filter *QueryFilter) error {
+	if filter.TextSearchQuery != nil {
+		return ErrInvalidOperatorValue.WithMessage("element filters cannot use text search").WithOperation("validateElementFilter")
+	}
+	if filter.Condition != nil {
+		return h.validateCondition(filter.Condition)
+	}
+	if filter.Group != nil {
+		if len(filter.Group.Conditions) == 0 {
+			return ErrFilterGroupEmpty.WithOperation("validateElementFilter")
+		}
+		for i, condition := range filter.Group.Conditions {
+			if err := h.validateElementFilter(&condition); err != nil {
+				return common.NewSystemError("ERR_QUERY_INVALID_CONDITION_AT_INDEX", fmt.Sprintf("invalid condition at index %d", i)).WithOperation("validateElementFilter").WithCause(err)
+			}
+		}
+		return nil
+	}
+	return ErrInvalidFilterNoConditionGroupText.WithOperation("validateElementFilter")
+}
+
 // validateFilterValue validates the structure of a FilterValue.
 func (h *QueryHelper) validateFilterValue(fv *FilterValue) error {
 	setFields := 0
@@ -285,6 +358,22 @@ func (h *QueryHelper) validateFilterValue(fv *FilterValue) error {
 		}
 	}
 
+	if fv.ParamVal != nil {
+		// Templates are bound before the in-memory engine sees a query, so a
+		// parameter here was never given a value.
+		return ErrUnboundParameter.WithMessagef("query parameter %q has no bound value", fv.ParamVal.Name).WithOperation("validateFilterValue")
+	}
+
+	if fv.ElementFilterVal != nil {
+		setFields++
+		if fv.ElementFilterVal.Type != "filter" {
+			return ErrElementFilterTypeInvalid.WithOperation("validateFilterValue")
+		}
+		if err := h.validateElementFilter(&fv.ElementFilterVal.Filter); err != nil {
+			return err
+		}
+	}
+
 	if setFields > 1 {
 		return common.NewSystemError("ERR_QUERY_FILTER_VALUE_MULTIPLE_TYPES", "FilterValue can only have one type of value set").WithOperation("validateFilterValue")
 	}
@@ -365,8 +454,14 @@ func (h *QueryHelper) validateProjectionConfiguration(proj *ProjectionConfigurat
 				return common.NewSystemError("ERR_QUERY_INVALID_CASE_ELSE_VALUE", "invalid 'else' value in case expression").WithOperation("validateProjectionConfiguration").WithCause(err)
 			}
 		}
+		if computed.WindowExpression != nil {
+			setFields++
+			if err := h.validateWindowExpression(computed.WindowExpression); err != nil {
+				return err
+			}
+		}
 		if setFields != 1 {
-			return common.NewSystemError("ERR_QUERY_PROJECTION_COMPUTED_ITEM_CONFLICT", "ProjectionComputedItem must have exactly one of ComputedFieldExpression or CaseExpression set")
+			return ErrProjectionComputedItemConflict.WithOperation("validateProjectionConfiguration")
 		}
 	}
 	return nil
@@ -424,10 +519,12 @@ func (h *QueryHelper) validateAggregationConfiguration(aggregations []Aggregatio
 		}
 
 		// Check if the aggregation type is supported
-		if h.aggregates != nil {
-			if _, ok := (*h.aggregates)[agg.Type]; !ok {
-				return common.NewSystemError(ErrUnsupportedAggregationType.Code, fmt.Sprintf("unsupported aggregation type: %s", agg.Type)).WithOperation("validateAggregationConfiguration").WithCause(ErrUnsupportedAggregationType)
-			}
+		if _, ok := h.aggregateFunction(agg); !ok {
+			return common.NewSystemError(ErrUnsupportedAggregationType.Code, fmt.Sprintf("unsupported aggregation type: %s", agg.Type)).WithOperation("validateAggregationConfiguration").WithCause(ErrUnsupportedAggregationType)
+		}
+
+		if err := validateAggregationOptions(agg); err != nil {
+			return err
 		}
 
 		if agg.Filter != nil {
@@ -558,24 +655,31 @@ func (h *QueryHelper) Sort(records []map[string]any) ([]map[string]any, error) {
 	copy(sorted, records)
 
 	sort.Slice(sorted, func(i, j int) bool {
-		for _, sortConfig := range h.query.Sort {
-			valueI, _ := utils.GetValueByPath(sorted[i], sortConfig.Field)
-			valueJ, _ := utils.GetValueByPath(sorted[j], sortConfig.Field)
+		return h.Compare(sorted[i], sorted[j]) < 0
+	})
 
-			comparison := h.compareValues(valueI, valueJ)
-			if comparison == 0 {
-				continue // Values are equal, check next sort field
-			}
+	return sorted, nil
+}
 
-			if sortConfig.Direction == SortDirectionAsc {
-				return comparison < 0
-			}
-			return comparison > 0
+// Compare orders two records by the query's sort configuration, returning a
+// negative number when a sorts before b, a positive number when it sorts
+// after, and 0 when the sort fields are equal or the query has none.
+func (h *QueryHelper) Compare(a, b map[string]any) int {
+	for _, sortConfig := range h.query.Sort {
+		valueA, _ := utils.GetValueByPath(a, sortConfig.Field)
+		valueB, _ := utils.GetValueByPath(b, sortConfig.Field)
+
+		comparison := h.compareValues(valueA, valueB)
+		if comparison == 0 {
+			continue // Values are equal, check next sort field
 		}
-		return false // All sort fields are equal
-	})
 
-	return sorted, nil
+		if sortConfig.Direction == SortDirectionAsc {
+			return comparison
+		}
+		return -comparison
+	}
+	return 0 // All sort fields are equal
 }
 
 // Paginate applies pagination to a collection of records.
@@ -761,101 +865,128 @@ func (h *QueryHelper) projectRecord(record map[string]any, projectionConfig *Pro
 			}
 			result[computed.CaseExpression.Alias] = value
 		}
+		if computed.WindowExpression != nil {
+			// Window values depend on the other rows and are attached to the
+			// record by ApplyWindows before projection.
+			result[computed.WindowExpression.Alias] = record[computed.WindowExpression.Alias]
+		}
 	}
 
 	return result, nil
 }
 
 // ApplyAggregations applies the aggregation configurations to a collection of records.
-// Returns a map where keys are aggregation aliases and values are the aggregated results.
-func (h *QueryHelper) ApplyAggregations(records []map[string]any) (map[string]any, error) {
+// Like a database GROUP BY, it returns one row per group of the fields and time
+// buckets the aggregations group by, holding the group values and every
+// aggregation under its alias. Without groups it returns a single row.
+func (h *QueryHelper) ApplyAggregations(records []map[string]any) ([]map[string]any, error) {
 	if h.query.Aggregations == nil {
 		return nil, nil // No aggregations defined
 	}
 
-	results := make(map[string]any)
-
-	for _, aggConfig := range h.query.Aggregations {
-		// Step 1: Apply filtering for this specific aggregation
-		currentRecords := records
-		if aggConfig.Filter != nil {
-			var err error
-			// Use the modified Filter method to apply the specific aggregation filter
-			filteredRecords, err := h.Filter(currentRecords, aggConfig.Filter)
-			if err != nil {
-				return nil, common.NewSystemError("ERR_QUERY_AGGREGATION_FILTER_FAILED", fmt.Sprintf("error applying filter for aggregation '%s'", aggConfig.AliasOrDefault())).WithOperation("ApplyAggregations").WithCause(err)
-			}
-			currentRecords = filteredRecords
-		}
-
-		// Resolve the aggregation function
-		aggFunc, ok := (*h.aggregates)[aggConfig.Type]
+	aggFuncs := make([]AggregateFunction, len(h.query.Aggregations))
+	for i, aggConfig := range h.query.Aggregations {
+		aggFunc, ok := h.aggregateFunction(aggConfig)
 		if !ok {
 			return nil, common.NewSystemError(ErrUnsupportedAggregationType.Code, fmt.Sprintf("unsupported aggregation type: %s", aggConfig.Type)).WithOperation("ApplyAggregations").WithCause(ErrUnsupportedAggregationType)
 		}
+		aggFuncs[i] = aggFunc
+	}
 
-		// Step 2: Handle Grouping
-		if len(aggConfig.Groups) > 0 {
-			groupedResults, err := h.processGroupedAggregation(currentRecords, aggConfig, aggFunc)
-			if err != nil {
-				return nil, err
+	groups := h.groupAggregationRecords(records)
+	results := make([]map[string]any, 0, len(groups))
+	for _, group := range groups {
+		result := maps.Clone(group.values)
+		for i, aggConfig := range h.query.Aggregations {
+			// Step 1: Apply filtering for this specific aggregation
+			currentRecords := group.records
+			if aggConfig.Filter != nil {
+				filteredRecords, err := h.Filter(currentRecords, aggConfig.Filter)
+				if err != nil {
+					return nil, common.NewSystemError("ERR_QUERY_AGGREGATION_FILTER_FAILED", fmt.Sprintf("error applying filter for aggregation '%s'", aggConfig.AliasOrDefault())).WithOperation("ApplyAggregations").WithCause(err)
+				}
+				currentRecords = filteredRecords
 			}
-			results[aggConfig.AliasOrDefault()] = groupedResults
-		} else {
-			// Step 3: Perform simple (non-grouped) aggregation
-			aggregatedValue, err := aggFunc(currentRecords, aggConfig.Field)
+
+			// Order-sensitive aggregations such as first and last see the
+			// records of the group in the requested order.
+			currentRecords = h.orderAggregationRecords(currentRecords, aggConfig.OrderBy)
+
+			// Step 2: Aggregate the group
+			aggregatedValue, err := aggFuncs[i](currentRecords, aggConfig.Field)
 			if err != nil {
 				return nil, common.NewSystemError("ERR_QUERY_AGGREGATION_PERFORMANCE_FAILED", fmt.Sprintf("error performing aggregation for field '%s'", aggConfig.Field)).WithOperation("ApplyAggregations").WithCause(err)
 			}
-			results[aggConfig.AliasOrDefault()] = aggregatedValue
+			result[aggConfig.AliasOrDefault()] = aggregatedValue
 		}
+		results = append(results, result)
 	}
 
 	return results, nil
 }
 
-// processGroupedAggregation handles aggregations with a 'groups' clause.
-func (h *QueryHelper) processGroupedAggregation(records []map[string]any, aggConfig AggregationConfiguration, aggFunc AggregateFunction) ([]map[string]any, error) {
-	groupedData := make(map[string][]map[string]any)
-	groupKeyMap := make(map[string]map[string]any) // To store the actual group key values for output
-
-	for _, record := range records {
-		// Create a composite key for grouping
-		groupKeyParts := make([]string, len(aggConfig.Groups))
-		currentGroupKeyValues := make(map[string]any)
+// aggregationGroup is a group of records sharing the values of every group
+// field and time bucket.
+type aggregationGroup struct {
+	values  map[string]any
+	records []map[string]any
+}
 
-		for i, groupField := range aggConfig.Groups {
-			doc := map[string]any(record)
-			val, _ := utils.GetValueByPath(doc, groupField)
-			groupKeyParts[i] = fmt.Sprintf("%v", val) // Convert to string for map key
-			currentGroupKeyValues[groupField] = val   // Store actual values for later
+// groupAggregationRecords groups records by the fields and time buckets of
+// every aggregation, in order of first appearance. Ungrouped aggregations
+// yield one group holding every record, even when there are none.
+func (h *QueryHelper) groupAggregationRecords(records []map[string]any) []*aggregationGroup {
+	var fields []string
+	var buckets []TimeBucket
+	seenFields := make(map[string]struct{})
+	seenBuckets := make(map[string]struct{})
+	for _, aggConfig := range h.query.Aggregations {
+		for _, field := range aggConfig.Groups {
+			if _, ok := seenFields[field]; !ok {
+				seenFields[field] = struct{}{}
+				fields = append(fields, field)
+			}
 		}
-		groupKey := strings.Join(groupKeyParts, "|") // Delimiter for composite key
-
-		groupedData[groupKey] = append(groupedData[groupKey], record)
-		if _, ok := groupKeyMap[groupKey]; !ok {
-			groupKeyMap[groupKey] = currentGroupKeyValues // Store group field values once per group
+		for _, bucket := range aggConfig.TimeGroups {
+			if _, ok := seenBuckets[bucket.AliasOrDefault()]; !ok {
+				seenBuckets[bucket.AliasOrDefault()] = struct{}{}
+				buckets = append(buckets, bucket)
+			}
 		}
 	}
 
-	var finalGroupedResults []map[string]any
+	if len(fields) == 0 && len(buckets) == 0 {
+		return []*aggregationGroup{{values: map[string]any{}, records: records}}
+	}
 
-	for groupKey, groupRecords := range groupedData {
-		aggregatedValue, err := aggFunc(groupRecords, aggConfig.Field)
-		if err != nil {
-			return nil, common.NewSystemError("ERR_QUERY_GROUPED_AGGREGATION_FAILED", fmt.Sprintf("error performing grouped aggregation for key '%s', field '%s'", groupKey, aggConfig.Field)).WithOperation("processGroupedAggregation").WithCause(err)
+	var groups []*aggregationGroup
+	byKey := make(map[string]*aggregationGroup)
+	for _, record := range records {
+		// Create a composite key for grouping
+		keyParts := make([]string, 0, len(fields)+len(buckets))
+		values := make(map[string]any, len(fields)+len(buckets))
+		for _, field := range fields {
+			val, _ := utils.GetValueByPath(record, field)
+			keyParts = append(keyParts, fmt.Sprintf("%v", val))
+			values[field] = val
+		}
+		for _, bucket := range buckets {
+			val, _ := utils.GetValueByPath(record, bucket.Field)
+			bucketValue := timeBucketValue(val, bucket.Unit)
+			keyParts = append(keyParts, fmt.Sprintf("%v", bucketValue))
+			values[bucket.AliasOrDefault()] = bucketValue
+		}
+		key := strings.Join(keyParts, "|") // Delimiter for composite key
+
+		group, ok := byKey[key]
+		if !ok {
+			group = &aggregationGroup{values: values}
+			byKey[key] = group
+			groups = append(groups, group)
 		}
-
-		// Construct the result object for this group
-		groupResult := make(map[string]any)
-		// Add the group by fields
-		maps.Copy(groupResult, groupKeyMap[groupKey])
-		// Add the aggregated value with its alias
-		groupResult[aggConfig.AliasOrDefault()] = aggregatedValue
-		finalGroupedResults = append(finalGroupedResults, groupResult)
+		group.records = append(group.records, record)
 	}
-
-	return finalGroupedResults, nil
+	return groups
 }
 
 // AliasOrDefault returns the alias if set, otherwise generates a default alias.
@@ -897,6 +1028,17 @@ func (h *QueryHelper) evaluateCondition(record map[string]any, condition *Filter
 
 	doc := map[string]any(record)
 	fieldValue, _ := utils.GetValueByPath(doc, condition.Field)
+	return h.compareCondition(record, fieldValue, condition)
+}
+
+// compareCondition applies the operator of condition to fieldValue. Values
+// in the condition are resolved against record, which is the enclosing
+// document even when fieldValue is an array element.
+func (h *QueryHelper) compareCondition(record map[string]any, fieldValue any, condition *FilterCondition) (bool, error) {
+	if condition.Operator == ComparisonOperatorElemMatch {
+		return h.evaluateElemMatch(record, fieldValue, condition.Value.ElementFilterVal)
+	}
+
 	conditionVal, err := h.resolveFilterValue(record, &condition.Value)
 	if err != nil {
 		return false, err
@@ -937,11 +1079,166 @@ func (h *QueryHelper) evaluateCondition(record map[string]any, condition *Filter
 		return fieldValue != nil, nil
 	case ComparisonOperatorNotExists:
 		return fieldValue == nil, nil
+	case ComparisonOperatorRegex:
+		return h.evaluateRegex(fieldValue, conditionVal)
+	case ComparisonOperatorIEq:
+		fieldStr, fieldIsStr := fieldValue.(string)
+		conditionStr, conditionIsStr := conditionVal.(string)
+		if fieldIsStr && conditionIsStr {
+			return strings.EqualFold(fieldStr, conditionStr), nil
+		}
+		return h.compareValues(fieldValue, conditionVal) == 0, nil
+	case ComparisonOperatorBetween:
+		return h.evaluateBetween(fieldValue, conditionVal)
+	case ComparisonOperatorSize:
+		return h.evaluateSize(fieldValue, conditionVal)
+	case ComparisonOperatorAll:
+		return h.evaluateAll(fieldValue, conditionVal)
 	default:
 		return false, common.NewSystemError(ErrUnknownComparisonOperator.Code, fmt.Sprintf("unsupported operator: %s", condition.Operator)).WithOperation("evaluateCondition").WithCause(ErrUnknownComparisonOperator)
 	}
 }
 
+// evaluateRegex evaluates the REGEX operator. Only string values can match.
+func (h *QueryHelper) evaluateRegex(fieldValue, conditionValue any) (bool, error) {
+	pattern, ok := conditionValue.(string)
+	if !ok {
+		return false, ErrInvalidOperatorValue.WithMessage("regex operator requires a string pattern").WithOperation("evaluateRegex")
+	}
+	fieldStr, ok := fieldValue.(string)
+	if !ok {
+		return false, nil
+	}
+	re, err := h.compilePattern(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(fieldStr), nil
+}
+
+// compilePattern returns the compiled form of pattern, compiling it once per
+// helper.
+func (h *QueryHelper) compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := h.patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, ErrInvalidRegexPattern.WithCause(err).WithOperation("compilePattern")
+	}
+	h.patterns.Store(pattern, re)
+	return re, nil
+}
+
+// evaluateBetween evaluates the BETWEEN operator; both bounds are inclusive.
+func (h *QueryHelper) evaluateBetween(fieldValue, conditionValue any) (bool, error) {
+	bounds, ok := conditionValue.([]any)
+	if !ok || len(bounds) != 2 {
+		return false, ErrInvalidOperatorValue.WithMessage("between operator requires a [low, high] array").WithOperation("evaluateBetween")
+	}
+	if fieldValue == nil {
+		return false, nil
+	}
+	return h.compareValues(fieldValue, bounds[0]) >= 0 && h.compareValues(bounds[1], fieldValue) >= 0, nil
+}
+
+// evaluateSize evaluates the SIZE operator. Values that are not arrays never
+// match.
+func (h *QueryHelper) evaluateSize(fieldValue, conditionValue any) (bool, error) {
+	size, ok := getFloat(conditionValue)
+	if !ok {
+		return false, ErrInvalidOperatorValue.WithMessage("size operator requires a number").WithOperation("evaluateSize")
+	}
+	elements, ok := arrayElements(fieldValue)
+	return ok && float64(len(elements)) == size, nil
+}
+
+// evaluateAll evaluates the ALL operator: the array must hold every value in
+// the condition's set.
+func (h *QueryHelper) evaluateAll(fieldValue, conditionValue any) (bool, error) {
+	wanted, ok := conditionValue.([]any)
+	if !ok || len(wanted) == 0 {
+		return false, ErrInvalidOperatorValue.WithMessage("all operator requires a non-empty array").WithOperation("evaluateAll")
+	}
+	elements, ok := arrayElements(fieldValue)
+	if !ok {
+		return false, nil
+	}
+	for _, w := range wanted {
+		if !slices.ContainsFunc(elements, func(e any) bool { return h.compareValues(e, w) == 0 }) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// evaluateElemMatch evaluates the ELEM_MATCH operator: at least one element
+// of the array must satisfy the element filter.
+func (h *QueryHelper) evaluateElemMatch(record map[string]any, fieldValue any, elementFilter *ElementFilter) (bool, error) {
+	if elementFilter == nil {
+		return false, ErrInvalidOperatorValue.WithMessage("elem_match operator requires an element filter").WithOperation("evaluateElemMatch")
+	}
+	elements, ok := arrayElements(fieldValue)
+	if !ok {
+		return false, nil
+	}
+	for _, element := range elements {
+		matched, err := h.evaluateElementFilter(record, element, &elementFilter.Filter)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// evaluateElementFilter evaluates an element filter against one array
+// element. Condition fields are paths inside the element; an empty field is
+// the element itself.
+func (h *QueryHelper) evaluateElementFilter(record map[string]any, element any, filter *QueryFilter) (bool, error) {
+	if filter.Condition != nil {
+		if fn, ok := h.goFilterFunctions[filter.Condition.Operator]; ok {
+			if doc, isDoc := element.(map[string]any); isDoc {
+				return fn(doc, filter.Condition.Field, filter.Condition.Value)
+			}
+		}
+		fieldValue, _ := utils.GetValueByPath(element, filter.Condition.Field)
+		return h.compareCondition(record, fieldValue, filter.Condition)
+	}
+
+	if filter.Group != nil {
+		results := make([]bool, len(filter.Group.Conditions))
+		for i, condition := range filter.Group.Conditions {
+			result, err := h.evaluateElementFilter(record, element, &condition)
+			if err != nil {
+				return false, common.NewSystemError("ERR_QUERY_EVALUATING_GROUP_CONDITION", fmt.Sprintf("evaluating condition %d", i)).WithOperation("evaluateElementFilter").WithCause(err)
+			}
+			results[i] = result
+		}
+		return filter.Group.Operator.Evaluate(results)
+	}
+
+	return false, ErrInvalidOperatorValue.WithMessage("element filters support conditions and groups only").WithOperation("evaluateElementFilter")
+}
+
+// arrayElements returns the elements of a slice or array value.
+func arrayElements(value any) ([]any, bool) {
+	if elements, ok := value.([]any); ok {
+		return elements, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	elements := make([]any, rv.Len())
+	for i := range elements {
+		elements[i] = rv.Index(i).Interface()
+	}
+	return elements, true
+}
+
 // resolveFilterValue extracts the actual value from a FilterValue union type.
 // It also handles FieldReference and FunctionCall within a FilterValue.
 func (h *QueryHelper) resolveFilterValue(record map[string]any, fv *FilterValue) (any, error) {
@@ -6,6 +6,7 @@ import (
 	"github.com/asaidimu/go-anansi/v8/core/data"
 	"github.com/asaidimu/go-anansi/v8/core/document"
 	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
 )
 
 // InteractorOptions provides a set of configurations for the DatabaseInteractor.
@@ -106,6 +107,26 @@ type DocumentPoolRegistrar interface {
 	RegisterDocumentPool(sc *definition.Schema, pool *document.DocumentPool)
 }
 
+// TelemetrySink receives named telemetry snapshots from a database backend,
+// such as prepared statement cache counters.
+type TelemetrySink func(name string, data map[string]any)
+
+// TelemetrySource is implemented by database interactors, and the executors
+// behind them, that publish telemetry. Persistence installs a sink that turns
+// each snapshot into a telemetry event. It is an optional capability.
+type TelemetrySource interface {
+	// SetTelemetrySink replaces the sink snapshots are published to.
+	SetTelemetrySink(sink TelemetrySink)
+}
+
+// Instrumented is implemented by database interactors, and the executors
+// behind them, that report spans and measurements. Persistence passes on the
+// telemetry it was configured with. It is an optional capability.
+type Instrumented interface {
+	// SetTelemetry replaces the telemetry operations are reported through.
+	SetTelemetry(t telemetry.Telemetry)
+}
+
 // DatabaseInteractor defines the contract for low-level database operations.
 // It abstracts the specific SQL dialect and database-dependent logic, providing a
 // consistent interface for the persistence layer to interact with the database.
@@ -121,7 +142,8 @@ type DatabaseInteractor interface {
 	SelectStream(ctx context.Context, sc *definition.Schema, dsl *Query) (<-chan map[string]any, <-chan error, error)
 
 	// UpdateDocuments modifies documents in the database that match the provided filters.
-	UpdateDocuments(ctx context.Context, schema *definition.Schema, updates data.Documenter, computedUpdates map[string]Query, filters *QueryFilter, returning bool) ([]*document.Document, int64, error)
+	// Operators are applied in the same statement as the literal and computed updates.
+	UpdateDocuments(ctx context.Context, schema *definition.Schema, updates data.Documenter, computedUpdates map[string]Query, operators []UpdateOperator, filters *QueryFilter, returning bool) ([]*document.Document, int64, error)
 
 	// InsertDocuments adds new documents to the database.
 	InsertDocuments(ctx context.Context, schema *definition.Schema, records []data.Documenter) ([]*document.Document, error)
@@ -89,7 +89,17 @@ type QueryBuilderInterface interface {
 	Avg(field string, alias string) *QueryBuilder
 	Min(field string, alias string) *QueryBuilder
 	Max(field string, alias string) *QueryBuilder
+	CountDistinct(field string, alias string) *QueryBuilder
+	GroupConcat(field string, separator string, alias string) *QueryBuilder
+	ArrayAgg(field string, alias string) *QueryBuilder
+	Stddev(field string, alias string) *QueryBuilder
+	Variance(field string, alias string) *QueryBuilder
+	Percentile(field string, fraction float64, alias string) *QueryBuilder
+	Median(field string, alias string) *QueryBuilder
+	First(field string, alias string, orderBy ...SortConfiguration) *QueryBuilder
+	Last(field string, alias string, orderBy ...SortConfiguration) *QueryBuilder
 	GroupBy(groupField string) AggregationBuilderInterface
+	GroupByTime(field string, unit TimeBucketUnit, alias string) AggregationBuilderInterface
 
 	// Distinct
 	Distinct() *QueryBuilder
@@ -123,6 +133,12 @@ type FilterConditionBuilderInterface interface {
 	NotContains(value any) *QueryBuilder
 	Exists() *QueryBuilder
 	NotExists() *QueryBuilder
+	Matches(pattern string) *QueryBuilder
+	EqualFold(value string) *QueryBuilder
+	Between(low, high any) *QueryBuilder
+	Size(n int) *QueryBuilder
+	ElemMatch(filter QueryFilter) *QueryBuilder
+	All(values ...any) *QueryBuilder
 	Custom(operator ComparisonOperator, value any) *QueryBuilder
 }
 
@@ -149,6 +165,12 @@ type FilterConditionBuilderInGroupInterface interface {
 	NotContains(value any) *FilterGroupBuilder
 	Exists() *FilterGroupBuilder
 	NotExists() *FilterGroupBuilder
+	Matches(pattern string) *FilterGroupBuilder
+	EqualFold(value string) *FilterGroupBuilder
+	Between(low, high any) *FilterGroupBuilder
+	Size(n int) *FilterGroupBuilder
+	ElemMatch(filter QueryFilter) *FilterGroupBuilder
+	All(values ...any) *FilterGroupBuilder
 	Custom(operator ComparisonOperator, value any) *FilterGroupBuilder
 }
 
@@ -173,6 +195,7 @@ type ProjectionBuilderInterface interface {
 	Exclude(fields ...string) *ProjectionBuilder
 	AddComputed(alias string, functionName string, args ...any) *ProjectionBuilder
 	AddCase(alias string) CaseExpressionBuilderInterface
+	AddWindow(alias string, fn WindowFunction, field string) WindowExpressionBuilderInterface
 	End() *QueryBuilder
 }
 
@@ -183,6 +206,17 @@ type CaseExpressionBuilderInterface interface {
 	End() *ProjectionBuilder
 }
 
+// WindowExpressionBuilderInterface defines the interface for building a window expression for a computed field.
+type WindowExpressionBuilderInterface interface {
+	PartitionBy(fields ...string) *WindowExpressionBuilder
+	OrderBy(field string, direction SortDirection) *WindowExpressionBuilder
+	Offset(offset int) *WindowExpressionBuilder
+	Default(value any) *WindowExpressionBuilder
+	Rows(start, end WindowFrameBound) *WindowExpressionBuilder
+	Range(start, end WindowFrameBound) *WindowExpressionBuilder
+	End() *ProjectionBuilder
+}
+
 // JoinBuilderInterface defines the interface for building a join configuration.
 type JoinBuilderInterface interface {
 	On(filter QueryFilter) *JoinBuilder
@@ -198,6 +232,10 @@ type AggregationBuilderInterface interface {
 	Field(field string) *AggregationBuilder
 	Alias(alias string) *AggregationBuilder
 	AddGroup(groupField string) *AggregationBuilder
+	AddTimeGroup(field string, unit TimeBucketUnit, alias string) *AggregationBuilder
+	Separator(separator string) *AggregationBuilder
+	Percentile(fraction float64) *AggregationBuilder
+	OrderBy(field string, direction SortDirection) *AggregationBuilder
 	WithFilter(filter QueryFilter) *AggregationBuilder
 	End() *QueryBuilder
 }
@@ -281,6 +319,10 @@ type Capabilities struct {
 	SupportsGroupBy bool
 	// SupportsDistinct indicates whether the database can perform DISTINCT operations.
 	SupportsDistinct bool
+	// SupportsWindowFunctions indicates whether the database can evaluate window expressions (ROW_NUMBER, LAG, SUM OVER, ...).
+	SupportsWindowFunctions bool
+	// SupportsTimeBuckets indicates whether the database can group by timestamps truncated to a TimeBucketUnit.
+	SupportsTimeBuckets bool
 	// SupportsNestedFields indicates whether the database can query nested document structures.
 	SupportsNestedFields bool
 	// MaxWhereConditions specifies the maximum number of WHERE conditions allowed in a single query. 0 means no limit.
@@ -289,6 +331,10 @@ type Capabilities struct {
 	MaxJoinClauses int
 	// ReturnOnUpdate indicates if the interactor can return updated documents directly on update operations.
 	ReturnOnUpdate bool
+	// QueryParameters indicates whether the interactor resolves template parameters from Query.Bindings
+	// when it executes a select, so one compiled statement serves every binding. Otherwise the query
+	// engine substitutes the bound values before handing the query over.
+	QueryParameters bool
 }
 
 // QueryPartitionerInterface defines the interface for splitting a query.
@@ -202,6 +202,18 @@ func (fv *FilterValue) UnmarshalJSON(data []byte) error {
 						fv.SubqueryVal = &subqueryVal
 						return nil
 					}
+				case "filter":
+					var elementFilter ElementFilter
+					if err := json.Unmarshal(data, &elementFilter); err == nil {
+						fv.ElementFilterVal = &elementFilter
+						return nil
+					}
+				case "param":
+					var param Parameter
+					if err := json.Unmarshal(data, &param); err == nil {
+						fv.ParamVal = &param
+						return nil
+					}
 				}
 			}
 		}
@@ -255,6 +267,12 @@ func (fv FilterValue) MarshalJSON() ([]byte, error) {
 	if fv.FunctionCallVal != nil {
 		return json.Marshal(fv.FunctionCallVal)
 	}
+	if fv.ElementFilterVal != nil {
+		return json.Marshal(fv.ElementFilterVal)
+	}
+	if fv.ParamVal != nil {
+		return json.Marshal(fv.ParamVal)
+	}
 	return []byte("null"), nil
 }
 
@@ -266,7 +284,10 @@ func (p ProjectionComputedItem) MarshalJSON() ([]byte, error) {
 	if p.CaseExpression != nil {
 		return json.Marshal(p.CaseExpression)
 	}
-	return []byte("{}"), nil // Return an empty object if neither is set
+	if p.WindowExpression != nil {
+		return json.Marshal(p.WindowExpression)
+	}
+	return []byte("{}"), nil // Return an empty object if none is set
 }
 
 // UnmarshalJSON customizes the JSON unmarshalling for ProjectionComputedItem.
@@ -300,6 +321,12 @@ func (p *ProjectionComputedItem) UnmarshalJSON(b []byte) error {
 			return common.NewSystemError("ERR_QUERY_PROJECTION_COMPUTED_ITEM_UNMARSHAL_CASE", "failed to unmarshal as CaseExpression").WithOperation("UnmarshalJSON").WithCause(err)
 		}
 		p.CaseExpression = &ce
+	case "window":
+		var we WindowExpression
+		if err := json.Unmarshal(b, &we); err != nil {
+			return common.NewSystemError("ERR_QUERY_PROJECTION_COMPUTED_ITEM_UNMARSHAL_WINDOW", "failed to unmarshal as WindowExpression").WithOperation("UnmarshalJSON").WithCause(err)
+		}
+		p.WindowExpression = &we
 	default:
 		return common.NewSystemError("ERR_QUERY_PROJECTION_COMPUTED_ITEM_UNKNOWN_TYPE", fmt.Sprintf("unknown 'type' field for ProjectionComputedItem: %s", itemType)).WithOperation("UnmarshalJSON").WithCause(errors.New("unknown 'type' field"))
 	}
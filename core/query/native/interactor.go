@@ -12,6 +12,8 @@ import (
 	"github.com/asaidimu/go-anansi/v8/core/document"
 	"github.com/asaidimu/go-anansi/v8/core/query"
 	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"go.uber.org/zap"
 )
 
@@ -46,12 +48,31 @@ type NativeInteractor[T any] struct {
 	// yields a new pointer and thus a new pool. A pointer is shared by
 	// transaction sub-interactors (copied by reference, never by value).
 	pools *sync.Map
+
+	// templates caches the select statements compiled for queries bound from
+	// a query.Template, so each template is compiled once per schema and
+	// document pool. Shared by transaction sub-interactors.
+	templates *lru.Cache[templateKey, Query[T]]
+
+	// queryParameters records Capabilities().QueryParameters of the factory.
+	queryParameters bool
+}
+
+// templateCacheSize bounds the compiled template cache.
+const templateCacheSize = 256
+
+type templateKey struct {
+	template uint64
+	schema   *definition.Schema
+	pool     *document.DocumentPool
 }
 
 // ensure NativeInteractor conforms to the required interfaces
 var _ query.DatabaseInteractor = (*NativeInteractor[any])(nil)
 var _ query.SchemaManager = (*NativeInteractor[any])(nil)
 var _ query.DocumentPoolRegistrar = (*NativeInteractor[any])(nil)
+var _ query.TelemetrySource = (*NativeInteractor[any])(nil)
+var _ query.Instrumented = (*NativeInteractor[any])(nil)
 
 // NewNativeInteractor is the single constructor for creating a new database interactor.
 // It initializes a base-level interactor that is not yet in a transaction.
@@ -63,13 +84,19 @@ func NewNativeInteractor[T any](ix QueryExecutor[T], qf QueryFactory[T], logger
 		return nil, common.NewSystemError("ERR_NATIVE_QUERY_FACTORY_NIL", "query factory cannot be nil")
 	}
 
+	// New only fails for a non-positive size.
+	templates, _ := lru.New[templateKey, Query[T]](templateCacheSize)
+
 	return &NativeInteractor[T]{
-		ix:     ix,
-		b:      NewNativeQueryBuilder(qf),
-		qf:     qf,
-		isTx:   false,
-		logger: logger,
-		pools:  &sync.Map{},
+		ix:        ix,
+		b:         NewNativeQueryBuilder(qf),
+		qf:        qf,
+		isTx:      false,
+		logger:    logger,
+		pools:     &sync.Map{},
+		templates: templates,
+
+		queryParameters: qf.Capabilities().QueryParameters,
 	}, nil
 }
 
@@ -84,7 +111,7 @@ func (i *NativeInteractor[T]) Close() error {
 
 // SelectDocuments retrieves multiple documents matching the query.
 func (i *NativeInteractor[T]) SelectDocuments(ctx context.Context, schema *definition.Schema, dsl *query.Query) ([]*document.Document, int64, error) {
-	compiled, err := i.b.Build(dsl, StmtSelect, nil)
+	compiled, err := i.buildSelect(schema, dsl)
 	if err != nil {
 		return nil, 0, common.SystemErrorFrom(err, ErrCouldNotBuildQuery.Code, ErrCouldNotBuildQuery.Message).WithOperation("native.NativeInteractor.SelectDocuments")
 	}
@@ -94,12 +121,12 @@ func (i *NativeInteractor[T]) SelectDocuments(ctx context.Context, schema *defin
 		return nil, 0, common.SystemErrorFrom(err, ErrCouldNotGetResultSchema.Code, ErrCouldNotGetResultSchema.Message).WithOperation("native.NativeInteractor.SelectDocuments")
 	}
 
-	return i.ix.Query(ctx, NativeQuery[T]{Query: compiled, Schema: resultSchema})
+	return i.ix.Query(ctx, NativeQuery[T]{Query: compiled, Schema: resultSchema, Bindings: dsl.Bindings})
 }
 
 // SelectStream retrieves a stream of documents matching the query.
 func (i *NativeInteractor[T]) SelectStream(ctx context.Context, sc *definition.Schema, dsl *query.Query) (<-chan map[string]any, <-chan error, error) {
-	compiled, err := i.b.Build(dsl, StmtSelect, nil)
+	compiled, err := i.buildSelect(sc, dsl)
 	if err != nil {
 		return nil, nil, common.SystemErrorFrom(err, ErrCouldNotBuildQuery.Code, ErrCouldNotBuildQuery.Message).WithOperation("native.NativeInteractor.SelectStream")
 	}
@@ -109,7 +136,26 @@ func (i *NativeInteractor[T]) SelectStream(ctx context.Context, sc *definition.S
 		return nil, nil, common.SystemErrorFrom(err, ErrCouldNotGetResultSchema.Code, ErrCouldNotGetResultSchema.Message).WithOperation("native.NativeInteractor.SelectStream")
 	}
 
-	return i.ix.QueryStream(ctx, NativeQuery[T]{Query: compiled, Schema: resultSchema})
+	return i.ix.QueryStream(ctx, NativeQuery[T]{Query: compiled, Schema: resultSchema, Bindings: dsl.Bindings})
+}
+
+// buildSelect compiles dsl as a select. A query bound from a template reuses
+// the statement compiled for an earlier binding when the dialect resolves
+// parameters at execution.
+func (i *NativeInteractor[T]) buildSelect(sc *definition.Schema, dsl *query.Query) (Query[T], error) {
+	if dsl.Bindings == nil || !i.queryParameters {
+		return i.b.Build(dsl, StmtSelect, nil)
+	}
+	key := templateKey{template: dsl.Bindings.Key(), schema: sc, pool: dsl.DocumentPool}
+	if compiled, ok := i.templates.Get(key); ok {
+		return compiled, nil
+	}
+	compiled, err := i.b.Build(dsl, StmtSelect, nil)
+	if err != nil {
+		return nil, err
+	}
+	i.templates.Add(key, compiled)
+	return compiled, nil
 }
 
 // documentPoolForSchema returns the schema-bound document pool for sc. When a
@@ -142,8 +188,24 @@ func (i *NativeInteractor[T]) RegisterDocumentPool(sc *definition.Schema, pool *
 	i.pools.Store(sc, pool)
 }
 
+// SetTelemetrySink forwards sink to the query executor when it publishes
+// telemetry, and does nothing otherwise.
+func (i *NativeInteractor[T]) SetTelemetrySink(sink query.TelemetrySink) {
+	if source, ok := i.ix.(query.TelemetrySource); ok {
+		source.SetTelemetrySink(sink)
+	}
+}
+
+// SetTelemetry forwards t to the query executor when it is instrumented, and
+// does nothing otherwise.
+func (i *NativeInteractor[T]) SetTelemetry(t telemetry.Telemetry) {
+	if instrumented, ok := i.ix.(query.Instrumented); ok {
+		instrumented.SetTelemetry(t)
+	}
+}
+
 // UpdateDocuments updates documents matching the filter.
-func (i *NativeInteractor[T]) UpdateDocuments(ctx context.Context, schema *definition.Schema, updates data.Documenter, computedUpdates map[string]query.Query, filters *query.QueryFilter, returnDocs bool) ([]*document.Document, int64, error) {
+func (i *NativeInteractor[T]) UpdateDocuments(ctx context.Context, schema *definition.Schema, updates data.Documenter, computedUpdates map[string]query.Query, operators []query.UpdateOperator, filters *query.QueryFilter, returnDocs bool) ([]*document.Document, int64, error) {
 	dsl := &query.Query{
 		Target:       &query.QueryTarget{Name: schema.Name, Schema: schema},
 		Filters:      filters,
@@ -157,6 +219,7 @@ func (i *NativeInteractor[T]) UpdateDocuments(ctx context.Context, schema *defin
 	updatePayload := map[string]any{
 		"set":       updates,
 		"compute":   computedUpdates,
+		"operators": operators,
 		"returning": useNativeReturning,
 	}
 
@@ -266,6 +329,9 @@ func (i *NativeInteractor[T]) StartTransaction(ctx context.Context) (query.Datab
 		isTx:   true,
 		logger: i.logger,
 		pools:  i.pools,
+
+		templates:       i.templates,
+		queryParameters: i.queryParameters,
 	}
 
 	// Watch for ctx cancellation: rollback if still active.
@@ -138,6 +138,11 @@ type NativeQuery[T any] struct {
 	// Schema defines the structure and constraints for the data being queried.
 	// This is used for result mapping, validation, and type conversion.
 	Schema *definition.Schema
+
+	// Bindings carries the parameter values of a query bound from a
+	// query.Template. Executors resolve query.Parameter arguments in the
+	// compiled query from it.
+	Bindings *query.Bindings
 }
 
 // QueryFactory is implemented by each database dialect (SQL, MongoDB, etc.).
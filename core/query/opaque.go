@@ -0,0 +1,113 @@
+package query
+
+import (
+	"slices"
+
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// EncryptionMetadataKey is the field Metadata key that marks a field as
+// encrypted at rest. Its value is EncryptionDeterministic,
+// EncryptionRandomized, or true (randomized).
+const EncryptionMetadataKey = "encryption"
+
+// Field encryption modes.
+const (
+	// EncryptionDeterministic encrypts equal plaintexts to equal ciphertexts
+	// under the same key, so equality filters and unique indexes keep working.
+	EncryptionDeterministic = "deterministic"
+	// EncryptionRandomized encrypts every value with a fresh nonce. Nothing
+	// about the plaintext can be compared by the database.
+	EncryptionRandomized = "randomized"
+)
+
+// FieldEncryptionMode returns the encryption mode declared in a field's
+// Metadata, or "" when the field is stored in plaintext.
+func FieldEncryptionMode(field definition.Field) string {
+	switch v := field.Metadata[EncryptionMetadataKey].(type) {
+	case bool:
+		if v {
+			return EncryptionRandomized
+		}
+	case string:
+		if v == EncryptionDeterministic || v == EncryptionRandomized {
+			return v
+		}
+	case map[string]any:
+		if mode, ok := v["mode"].(string); ok && (mode == EncryptionDeterministic || mode == EncryptionRandomized) {
+			return mode
+		}
+	}
+	return ""
+}
+
+// OpaqueFields describes fields whose stored representation the database
+// cannot compare the way it would compare their plaintext. A field mapped to
+// true still supports equality pushdown (deterministic encryption); every
+// other predicate, and any predicate on a field mapped to false, is evaluated
+// in memory after the values have been decoded.
+type OpaqueFields map[string]bool
+
+// OpaqueFieldsOf returns the encrypted fields declared by sc, or nil when it
+// declares none.
+func OpaqueFieldsOf(sc *definition.Schema) OpaqueFields {
+	if sc == nil {
+		return nil
+	}
+	var opaque OpaqueFields
+	for _, field := range sc.Fields {
+		mode := FieldEncryptionMode(field)
+		if mode == "" {
+			continue
+		}
+		if opaque == nil {
+			opaque = make(OpaqueFields)
+		}
+		opaque[string(field.Name)] = mode == EncryptionDeterministic
+	}
+	return opaque
+}
+
+// opaqueEqualityOperators are the operators a database can evaluate on
+// deterministically encrypted values, once the operand has been encrypted the
+// same way.
+var opaqueEqualityOperators = []ComparisonOperator{
+	ComparisonOperatorEq,
+	ComparisonOperatorNeq,
+	ComparisonOperatorIn,
+	ComparisonOperatorNin,
+	ComparisonOperatorExists,
+	ComparisonOperatorNotExists,
+}
+
+// pushable reports whether cond may be evaluated by the database given the
+// opaque fields. Conditions comparing against another field are kept in
+// memory whenever either side is opaque.
+func (o OpaqueFields) pushable(cond *FilterCondition) bool {
+	if len(o) == 0 {
+		return true
+	}
+	if cond.Value.FieldRefVal != nil {
+		if _, opaque := o[cond.Value.FieldRefVal.Field]; opaque {
+			return false
+		}
+	}
+	equality, opaque := o[cond.Field]
+	if !opaque {
+		return true
+	}
+	if cond.Operator == ComparisonOperatorExists || cond.Operator == ComparisonOperatorNotExists {
+		return true
+	}
+	return equality && cond.Value.FieldRefVal == nil && slices.Contains(opaqueEqualityOperators, cond.Operator)
+}
+
+// sortable reports whether every sort key can be ordered by the database.
+func (o OpaqueFields) sortable(sorts []SortConfiguration) bool {
+	for _, sort := range sorts {
+		if _, opaque := o[sort.Field]; opaque {
+			return false
+		}
+	}
+	return true
+}
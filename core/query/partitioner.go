@@ -2,6 +2,7 @@ package query
 
 import (
 	"errors"
+	"slices"
 
 	"github.com/asaidimu/go-anansi/v8/core/common"
 )
@@ -10,6 +11,7 @@ import (
 // and a post-processing query based on the capabilities of the database.
 type QueryPartitioner struct {
 	capabilities Capabilities
+	opaque       OpaqueFields
 }
 
 // NewQueryPartitioner creates a new QueryPartitioner.
@@ -17,6 +19,13 @@ func NewQueryPartitioner(capabilities Capabilities) *QueryPartitioner {
 	return &QueryPartitioner{capabilities: capabilities}
 }
 
+// WithOpaqueFields returns a partitioner that additionally keeps predicates,
+// sorts and aggregations the database cannot evaluate on the given opaque
+// (encrypted) fields in the post-processing query.
+func (p *QueryPartitioner) WithOpaqueFields(opaque OpaqueFields) *QueryPartitioner {
+	return &QueryPartitioner{capabilities: p.capabilities, opaque: opaque}
+}
+
 // Partition splits the given QueryDSL query into two parts: one for the database and one for post-processing.
 func (p *QueryPartitioner) Partition(dsl *Query) (*Query, *Query, error) {
 	if dsl.Raw != nil {
@@ -63,8 +72,32 @@ func (p *QueryPartitioner) Partition(dsl *Query) (*Query, *Query, error) {
 	dbQuery.Sort = dbSort
 	postProcessingQuery.Sort = postSort
 
-	// Handle pagination
-	if p.supportsPagination(dsl.Pagination) {
+	// Window expressions need every row of their partition. The database
+	// evaluates them only when it also evaluates every filter; otherwise they
+	// run in memory, after the residual filters and before any page is cut.
+	residualWindows := false
+	if windows := dsl.Projection.Windows(); len(windows) > 0 {
+		window := &ProjectionConfiguration{}
+		for _, w := range windows {
+			window.Computed = append(window.Computed, ProjectionComputedItem{WindowExpression: w})
+		}
+		if postProcessingQuery.Filters == nil && p.windowsPushable(dsl.Projection, windows) {
+			dbQuery.Projection = window
+		} else {
+			postProcessingQuery.Projection = window
+			residualWindows = true
+		}
+	}
+
+	// Handle pagination. Pages can only be cut by the database when it also
+	// evaluates every filter, window, aggregation and the full sort order;
+	// rows removed, reordered or aggregated in memory would otherwise shift
+	// page boundaries.
+	if postProcessingQuery.Filters != nil || len(postSort) > 0 || residualWindows || len(postAggregations) > 0 {
+		postProcessingQuery.Sort = dsl.Sort
+		dbQuery.Sort = nil
+		postProcessingQuery.Pagination = dsl.Pagination
+	} else if p.supportsPagination(dsl.Pagination) {
 		dbQuery.Pagination = dsl.Pagination
 	} else {
 		postProcessingQuery.Pagination = dsl.Pagination
@@ -183,6 +216,14 @@ func (p *QueryPartitioner) isConditionSupported(cond *FilterCondition) bool {
 		return false
 	}
 
+	if !p.opaque.pushable(cond) {
+		return false
+	}
+
+	if cond.Value.ElementFilterVal != nil && !p.isElementFilterSupported(&cond.Value.ElementFilterVal.Filter) {
+		return false
+	}
+
 	// Check if the value contains unsupported function calls
 	if cond.Value.FunctionCallVal != nil {
 		// You could add more sophisticated function support checking here
@@ -227,6 +268,9 @@ func (p *QueryPartitioner) partitionJoins(joins []JoinConfiguration) ([]JoinConf
 	return dbJoins, postJoins, nil
 }
 
+// partitionAggregations pushes the aggregations to the database only when it
+// can evaluate every one of them: results computed in memory are shaped
+// differently from result rows, so the two cannot be mixed in one query.
 func (p *QueryPartitioner) partitionAggregations(aggregations []AggregationConfiguration) ([]AggregationConfiguration, []AggregationConfiguration, error) {
 	var dbAggregations, postAggregations []AggregationConfiguration
 
@@ -237,6 +281,18 @@ func (p *QueryPartitioner) partitionAggregations(aggregations []AggregationConfi
 			continue
 		}
 
+		if len(agg.TimeGroups) > 0 && !p.capabilities.SupportsTimeBuckets {
+			postAggregations = append(postAggregations, agg)
+			continue
+		}
+
+		// Aggregating ciphertext is meaningless except for counting; grouping
+		// is only sound on deterministically encrypted values.
+		if !p.aggregatesOpaqueSafely(agg) {
+			postAggregations = append(postAggregations, agg)
+			continue
+		}
+
 		// Check if the aggregation filter contains subqueries
 		if agg.Filter != nil {
 			dbFilter, postFilter, err := p.partitionFilters(agg.Filter)
@@ -259,13 +315,36 @@ func (p *QueryPartitioner) partitionAggregations(aggregations []AggregationConfi
 		}
 	}
 
+	if len(postAggregations) > 0 {
+		return nil, aggregations, nil
+	}
 	return dbAggregations, postAggregations, nil
 }
 
+func (p *QueryPartitioner) aggregatesOpaqueSafely(agg AggregationConfiguration) bool {
+	if equality, opaque := p.opaque[agg.Field]; opaque && agg.Type != AggregationTypeCount {
+		// Deterministic ciphertexts are equal exactly when their plaintexts are.
+		if agg.Type != AggregationTypeCountDistinct || !equality {
+			return false
+		}
+	}
+	for _, group := range agg.Groups {
+		if equality, opaque := p.opaque[group]; opaque && !equality {
+			return false
+		}
+	}
+	for _, bucket := range agg.TimeGroups {
+		if _, opaque := p.opaque[bucket.Field]; opaque {
+			return false
+		}
+	}
+	return p.opaque.sortable(agg.OrderBy)
+}
+
 func (p *QueryPartitioner) partitionSort(sorts []SortConfiguration) ([]SortConfiguration, []SortConfiguration) {
 	// For simplicity, we assume if the database supports sorting, it supports all of it.
 	// A more granular check would be needed for expression-based sorting.
-	if p.capabilities.Sorting.SupportsExpression {
+	if p.capabilities.Sorting.SupportsExpression && p.opaque.sortable(sorts) {
 		return sorts, nil
 	}
 	return nil, sorts
@@ -308,7 +387,38 @@ func (p *QueryPartitioner) partitionUnion(union *QueryUnion) (*QueryUnion, *Quer
 	}, nil, nil
 }
 
+// windowsPushable reports whether the database can evaluate the window
+// expressions of projection. Pushed windows are selected next to the include
+// list, so a projection without one keeps its windows in memory where every
+// field of the row is still returned.
+func (p *QueryPartitioner) windowsPushable(projection *ProjectionConfiguration, windows []*WindowExpression) bool {
+	if !p.capabilities.SupportsWindowFunctions || len(projection.Include) == 0 {
+		return false
+	}
+	for _, w := range windows {
+		fields := slices.Clone(w.PartitionBy)
+		if w.Field != "" {
+			fields = append(fields, w.Field)
+		}
+		for _, field := range fields {
+			if _, opaque := p.opaque[field]; opaque {
+				return false
+			}
+		}
+		if !p.opaque.sortable(w.OrderBy) {
+			return false
+		}
+	}
+	return true
+}
+
 func (p *QueryPartitioner) augmentProjection(originalQuery, dbQuery, postQuery *Query) error {
+	// Without an include list the database returns every field, which already
+	// covers whatever the post-processing query depends on.
+	if originalQuery.Projection == nil || len(originalQuery.Projection.Include) == 0 {
+		return nil
+	}
+
 	dependencies := make(map[string]struct{})
 
 	// Collect dependencies from post-processing query
@@ -350,10 +460,18 @@ func collectDependencies(q *Query, dependencies map[string]struct{}) {
 		collectDependenciesFromFilter(join.On, dependencies)
 	}
 	for _, agg := range q.Aggregations {
-		dependencies[agg.Field] = struct{}{}
+		if agg.Field != "" && agg.Field != "*" {
+			dependencies[agg.Field] = struct{}{}
+		}
 		for _, group := range agg.Groups {
 			dependencies[group] = struct{}{}
 		}
+		for _, bucket := range agg.TimeGroups {
+			dependencies[bucket.Field] = struct{}{}
+		}
+		for _, order := range agg.OrderBy {
+			dependencies[order.Field] = struct{}{}
+		}
 		if agg.Filter != nil {
 			collectDependenciesFromFilter(agg.Filter, dependencies)
 		}
@@ -363,6 +481,33 @@ func collectDependencies(q *Query, dependencies map[string]struct{}) {
 	}
 }
 
+// isElementFilterSupported reports whether every operator inside an
+// elem_match filter can be evaluated by the database. Element fields are
+// relative to the array element, so the opaque-field checks do not apply.
+func (p *QueryPartitioner) isElementFilterSupported(filter *QueryFilter) bool {
+	if filter.Condition != nil {
+		if _, supported := p.capabilities.SupportedComparisonOperators[filter.Condition.Operator]; !supported {
+			return false
+		}
+		if filter.Condition.Value.ElementFilterVal != nil {
+			return p.isElementFilterSupported(&filter.Condition.Value.ElementFilterVal.Filter)
+		}
+		return filter.Condition.Value.SubqueryVal == nil
+	}
+	if filter.Group != nil {
+		if _, supported := p.capabilities.SupportedLogicalOperators[filter.Group.Operator]; !supported {
+			return false
+		}
+		for _, condition := range filter.Group.Conditions {
+			if !p.isElementFilterSupported(&condition) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 func collectDependenciesFromFilter(filter *QueryFilter, dependencies map[string]struct{}) {
 	if filter == nil {
 		return
@@ -409,4 +554,18 @@ func collectDependenciesFromComputedField(computed ProjectionComputedItem, depen
 		}
 		collectDependenciesFromFilterValue(&computed.CaseExpression.Else, dependencies)
 	}
+	if computed.WindowExpression != nil {
+		if computed.WindowExpression.Field != "" {
+			dependencies[computed.WindowExpression.Field] = struct{}{}
+		}
+		for _, field := range computed.WindowExpression.PartitionBy {
+			dependencies[field] = struct{}{}
+		}
+		for _, sort := range computed.WindowExpression.OrderBy {
+			dependencies[sort.Field] = struct{}{}
+		}
+		if computed.WindowExpression.Default != nil {
+			collectDependenciesFromFilterValue(computed.WindowExpression.Default, dependencies)
+		}
+	}
 }
@@ -31,6 +31,16 @@ func DefaultSchemaFromQueryOptions() *SchemaFromQueryOptions {
 			AggregationTypeAvg:   definition.FieldTypeNumber,
 			AggregationTypeMin:   definition.FieldTypeUnknown,
 			AggregationTypeMax:   definition.FieldTypeUnknown,
+
+			AggregationTypeCountDistinct: definition.FieldTypeInteger,
+			AggregationTypeGroupConcat:   definition.FieldTypeString,
+			AggregationTypeArrayAgg:      definition.FieldTypeArray,
+			AggregationTypeStddev:        definition.FieldTypeNumber,
+			AggregationTypeVariance:      definition.FieldTypeNumber,
+			AggregationTypePercentile:    definition.FieldTypeNumber,
+			AggregationTypeMedian:        definition.FieldTypeNumber,
+			AggregationTypeFirst:         definition.FieldTypeUnknown,
+			AggregationTypeLast:          definition.FieldTypeUnknown,
 		},
 	}
 }
@@ -56,7 +66,7 @@ func SchemaFromQuery(q *Query, options *SchemaFromQueryOptions) (*definition.Sch
 	// target directly avoids a full deep copy on every read/write. Safe because
 	// nothing downstream mutates the returned schema for this shape; the
 	// projection/join branches below always copy before transforming.
-	if len(q.Joins) == 0 && (q.Projection == nil || (len(q.Projection.Include) == 0 && len(q.Projection.Exclude) == 0)) {
+	if len(q.Joins) == 0 && (q.Projection == nil || (len(q.Projection.Include) == 0 && len(q.Projection.Exclude) == 0 && len(q.Projection.Windows()) == 0)) {
 		return q.Target.Schema, nil
 	}
 
@@ -97,7 +107,7 @@ func generateAggregationResultSchema(q *Query, options *SchemaFromQueryOptions)
 	hasGrouping := false
 	// Check if any aggregation has groups
 	for _, agg := range q.Aggregations {
-		if len(agg.Groups) > 0 {
+		if len(agg.Groups) > 0 || len(agg.TimeGroups) > 0 {
 			hasGrouping = true
 			break
 		}
@@ -364,6 +374,8 @@ func applyProjectionToSchema(resultSchema *definition.Schema, projection *Projec
 				fieldDef = createComputedFieldDefinition(computed.ComputedFieldExpression, options)
 			} else if computed.CaseExpression != nil {
 				fieldDef = createCaseFieldDefinition(computed.CaseExpression)
+			} else if computed.WindowExpression != nil {
+				fieldDef = createWindowFieldDefinition(computed.WindowExpression, originalSchema)
 			}
 
 			if fieldDef != nil {
@@ -405,6 +417,34 @@ func createCaseFieldDefinition(expr *CaseExpression) *definition.Field {
 	}
 }
 
+// createWindowFieldDefinition creates a field definition for a window
+// expression. Ranks and counts are integers, averages are numbers, and every
+// other function returns values of the field it reads.
+func createWindowFieldDefinition(expr *WindowExpression, originalSchema *definition.Schema) *definition.Field {
+	fieldType := definition.FieldTypeUnknown
+	switch expr.Function {
+	case WindowFunctionRowNumber, WindowFunctionRank, WindowFunctionDenseRank, WindowFunctionCount:
+		fieldType = definition.FieldTypeInteger
+	case WindowFunctionAvg:
+		fieldType = definition.FieldTypeNumber
+	default:
+		if _, field := originalSchema.FindField(expr.Field); field != nil {
+			fieldType = field.Type
+		}
+		if expr.Function == WindowFunctionSum && fieldType != definition.FieldTypeInteger {
+			fieldType = definition.FieldTypeNumber
+		}
+	}
+
+	return &definition.Field{
+		Name:        definition.FieldName(expr.Alias),
+		Description: fmt.Sprintf("Window field using function %s", expr.Function),
+		FieldProperties: definition.FieldProperties{
+			Type: fieldType,
+		},
+	}
+}
+
 // inferAggregationFieldType determines the appropriate field type for an aggregation result
 func inferAggregationFieldType(agg AggregationConfiguration, targetSchema *definition.Schema, options *SchemaFromQueryOptions) definition.FieldType {
 	// For MIN and MAX, try to use the original field type
@@ -420,6 +460,13 @@ func inferAggregationFieldType(agg AggregationConfiguration, targetSchema *defin
 		}
 	}
 
+	// First and last return values of the field itself
+	if agg.Type == AggregationTypeFirst || agg.Type == AggregationTypeLast {
+		if field, exists := targetSchema.Fields[definition.FieldId(agg.Field)]; exists {
+			return field.Type
+		}
+	}
+
 	// Use default aggregation type mapping
 	if fieldType, exists := options.DefaultAggregationTypes[agg.Type]; exists {
 		return fieldType
@@ -716,6 +763,8 @@ func generateProjectedSchemaID(originalID string, projection *ProjectionConfigur
 				parts = append(parts, comp.ComputedFieldExpression.Alias)
 			} else if comp.CaseExpression != nil {
 				parts = append(parts, comp.CaseExpression.Alias)
+			} else if comp.WindowExpression != nil {
+				parts = append(parts, comp.WindowExpression.Alias)
 			}
 		}
 	}
@@ -0,0 +1,295 @@
+package query
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"maps"
+)
+
+// Params maps template parameter names to the values bound to them.
+type Params map[string]any
+
+// Param returns a placeholder for the template parameter name, used as a
+// filter value and given a value by Template.Bind:
+//
+//	q := query.NewQueryBuilder().Where("status").Eq(query.Param("status")).Build()
+//	tmpl, err := query.NewTemplate(&q)
+//	...
+//	active, err := tmpl.Bind(query.Params{"status": "active"})
+func Param(name string) Parameter {
+	return Parameter{Type: "param", Name: name}
+}
+
+// Template is a query whose filter values may be parameters. Every query
+// bound from a template has the same structure, so the query engine reuses
+// the template's partitioning, and interactors that declare
+// Capabilities.QueryParameters also reuse its compiled statement, passing the
+// bound values as statement arguments.
+//
+// Parameters are accepted as the value of eq, neq, lt, lte, gt, gte, ieq,
+// contains and not_contains conditions anywhere in the filter tree.
+type Template struct {
+	query *Query
+	names map[string]struct{}
+	key   uint64
+}
+
+// templateOperators are the operators whose value may be a parameter. Each
+// compares against a single scalar, so a bound value never changes the shape
+// of the generated statement.
+var templateOperators = map[ComparisonOperator]struct{}{
+	ComparisonOperatorEq:          {},
+	ComparisonOperatorNeq:         {},
+	ComparisonOperatorLt:          {},
+	ComparisonOperatorLte:         {},
+	ComparisonOperatorGt:          {},
+	ComparisonOperatorGte:         {},
+	ComparisonOperatorIEq:         {},
+	ComparisonOperatorContains:    {},
+	ComparisonOperatorNotContains: {},
+}
+
+// NewTemplate validates q and collects its parameters. q is cloned, so later
+// changes to it do not affect the template.
+func NewTemplate(q *Query) (*Template, error) {
+	if q == nil {
+		return nil, ErrQueryCannotBeNil.WithOperation("NewTemplate")
+	}
+	if q.Raw != nil {
+		return nil, ErrInvalidTemplate.WithMessage("raw queries cannot be templates; use RawQuery parameters instead").WithOperation("NewTemplate")
+	}
+
+	clone, err := q.Clone()
+	if err != nil {
+		return nil, err
+	}
+	clone.Bindings = nil
+	clone.DocumentPool = q.DocumentPool
+
+	t := &Template{query: clone, names: make(map[string]struct{})}
+	if clone.Filters != nil {
+		if err := t.collect(clone.Filters); err != nil {
+			return nil, err
+		}
+	}
+	for _, join := range clone.Joins {
+		if join.On != nil && filterHasParam(join.On) {
+			return nil, ErrInvalidTemplate.WithMessage("parameters are not supported in join conditions").WithOperation("NewTemplate")
+		}
+	}
+
+	bytes, err := json.Marshal(clone)
+	if err != nil {
+		return nil, ErrInvalidTemplate.WithCause(err).WithOperation("NewTemplate")
+	}
+	hasher := fnv.New64a()
+	hasher.Write(bytes)
+	t.key = hasher.Sum64()
+	return t, nil
+}
+
+// Names returns the parameter names of the template.
+func (t *Template) Names() []string {
+	names := make([]string, 0, len(t.names))
+	for name := range t.names {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Bind returns the template's query with params bound. Every parameter must
+// be given a string, number or boolean value, and params may not name
+// parameters the template does not have. The returned query shares the
+// template's filters and must not be modified.
+func (t *Template) Bind(params Params) (*Query, error) {
+	values := make(map[string]any, len(t.names))
+	for name := range t.names {
+		v, ok := params[name]
+		if !ok {
+			return nil, ErrUnboundParameter.WithMessagef("query parameter %q has no bound value", name).WithOperation("Template.Bind")
+		}
+		scalar, ok := scalarValue(v)
+		if !ok {
+			return nil, ErrInvalidTemplate.WithMessagef("query parameter %q must be a string, number or boolean, got %T", name, v).WithOperation("Template.Bind")
+		}
+		values[name] = scalar
+	}
+	for name := range params {
+		if _, ok := t.names[name]; !ok {
+			return nil, ErrInvalidTemplate.WithMessagef("template has no parameter %q", name).WithOperation("Template.Bind")
+		}
+	}
+
+	bound := *t.query
+	bound.Bindings = &Bindings{key: t.key, values: values}
+	return &bound, nil
+}
+
+// scalarValue normalizes v to the string, float64 or bool a filter value
+// holds.
+func scalarValue(v any) (any, bool) {
+	switch v := v.(type) {
+	case string, bool, float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return nil, false
+}
+
+func (t *Template) collect(filter *QueryFilter) error {
+	if filter.Condition != nil {
+		cond := filter.Condition
+		if cond.Value.ParamVal != nil {
+			if cond.Value.ParamVal.Type != "param" {
+				return ErrParameterTypeInvalid.WithOperation("NewTemplate")
+			}
+			if cond.Value.ParamVal.Name == "" {
+				return ErrInvalidTemplate.WithMessage("parameter name cannot be empty").WithOperation("NewTemplate")
+			}
+			if _, ok := templateOperators[cond.Operator]; !ok {
+				return ErrInvalidTemplate.WithMessagef("operator %q does not accept parameters", cond.Operator).WithOperation("NewTemplate")
+			}
+			t.names[cond.Value.ParamVal.Name] = struct{}{}
+			return nil
+		}
+		if valueHasParam(&cond.Value) {
+			return ErrInvalidTemplate.WithMessagef("parameters must be the whole value of a condition on %q", cond.Field).WithOperation("NewTemplate")
+		}
+	}
+	if filter.Group != nil {
+		for i := range filter.Group.Conditions {
+			if err := t.collect(&filter.Group.Conditions[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func filterHasParam(filter *QueryFilter) bool {
+	if filter.Condition != nil && valueHasParam(&filter.Condition.Value) {
+		return true
+	}
+	if filter.Group != nil {
+		for i := range filter.Group.Conditions {
+			if filterHasParam(&filter.Group.Conditions[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func valueHasParam(fv *FilterValue) bool {
+	switch {
+	case fv.ParamVal != nil:
+		return true
+	case fv.FunctionCallVal != nil:
+		for i := range fv.FunctionCallVal.Arguments {
+			if valueHasParam(&fv.FunctionCallVal.Arguments[i]) {
+				return true
+			}
+		}
+	case fv.ElementFilterVal != nil:
+		return filterHasParam(&fv.ElementFilterVal.Filter)
+	case fv.SubqueryVal != nil:
+		return fv.SubqueryVal.Query.Filters != nil && filterHasParam(fv.SubqueryVal.Query.Filters)
+	}
+	for i := range fv.ArrayVal {
+		if valueHasParam(&fv.ArrayVal[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bindings holds the parameter values of a query bound from a Template.
+type Bindings struct {
+	key    uint64
+	values map[string]any
+}
+
+// Key identifies the template the values were bound to. Queries with the same
+// key differ only in their bound values, so a statement compiled for one
+// serves them all.
+func (b *Bindings) Key() uint64 {
+	return b.key
+}
+
+// Value returns the string, float64 or bool bound to the parameter name.
+func (b *Bindings) Value(name string) (any, bool) {
+	v, ok := b.values[name]
+	return v, ok
+}
+
+// Values returns a copy of every bound value by parameter name.
+func (b *Bindings) Values() Params {
+	return maps.Clone(b.values)
+}
+
+// Apply returns a copy of q whose parameters are replaced by their bound
+// values, for evaluators that cannot pass parameters through. q itself is not
+// modified.
+func (b *Bindings) Apply(q *Query) (*Query, error) {
+	if q == nil {
+		return nil, nil
+	}
+	bound := *q
+	bound.Bindings = nil
+	if q.Filters != nil {
+		filters, err := b.bindFilter(q.Filters)
+		if err != nil {
+			return nil, err
+		}
+		bound.Filters = filters
+	}
+	return &bound, nil
+}
+
+func (b *Bindings) bindFilter(filter *QueryFilter) (*QueryFilter, error) {
+	bound := *filter
+	if filter.Condition != nil && filter.Condition.Value.ParamVal != nil {
+		name := filter.Condition.Value.ParamVal.Name
+		v, ok := b.values[name]
+		if !ok {
+			return nil, ErrUnboundParameter.WithMessagef("query parameter %q has no bound value", name).WithOperation("Bindings.Apply")
+		}
+		cond := *filter.Condition
+		cond.Value = NewFilterValue(v)
+		bound.Condition = &cond
+	}
+	if filter.Group != nil {
+		group := *filter.Group
+		group.Conditions = make([]QueryFilter, len(filter.Group.Conditions))
+		for i := range filter.Group.Conditions {
+			sub, err := b.bindFilter(&filter.Group.Conditions[i])
+			if err != nil {
+				return nil, err
+			}
+			group.Conditions[i] = *sub
+		}
+		bound.Group = &group
+	}
+	return &bound, nil
+}
@@ -0,0 +1,423 @@
+package query
+
+import (
+	"encoding/json"
+	"maps"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/utils"
+)
+
+// UpdateOperatorType names an in-place change an update applies to a field.
+type UpdateOperatorType string
+
+const (
+	UpdateOperatorIncrement UpdateOperatorType = "inc"        // Adds Value to a number; a missing number counts as 0.
+	UpdateOperatorDecrement UpdateOperatorType = "dec"        // Subtracts Value from a number; a missing number counts as 0.
+	UpdateOperatorMultiply  UpdateOperatorType = "mul"        // Multiplies a number by Value; a missing number counts as 0.
+	UpdateOperatorMin       UpdateOperatorType = "min"        // Replaces the value when Value is smaller, or when it is missing.
+	UpdateOperatorMax       UpdateOperatorType = "max"        // Replaces the value when Value is larger, or when it is missing.
+	UpdateOperatorUnset     UpdateOperatorType = "unset"      // Clears a field or removes a nested path.
+	UpdateOperatorSet       UpdateOperatorType = "set"        // Sets a nested path, keeping the rest of its object.
+	UpdateOperatorPush      UpdateOperatorType = "push"       // Appends Value to an array; a missing array starts empty.
+	UpdateOperatorAddToSet  UpdateOperatorType = "add_to_set" // Appends Value unless the array already holds it.
+	UpdateOperatorPull      UpdateOperatorType = "pull"       // Removes every element equal to Value.
+	UpdateOperatorPop       UpdateOperatorType = "pop"        // Removes the last element, or the first when Value is -1.
+)
+
+// UpdateOperator is a declarative update applied where the document is
+// stored, without reading it first. Field is a dotted path whose first
+// segment names a schema field; deeper segments address the inside of an
+// object field.
+type UpdateOperator struct {
+	Type  UpdateOperatorType `json:"type"`
+	Field string             `json:"field"`
+	Value any                `json:"value,omitempty"`
+}
+
+// numericUpdateOperators change a number in place.
+var numericUpdateOperators = map[UpdateOperatorType]struct{}{
+	UpdateOperatorIncrement: {},
+	UpdateOperatorDecrement: {},
+	UpdateOperatorMultiply:  {},
+}
+
+// arrayUpdateOperators change an array in place.
+var arrayUpdateOperators = map[UpdateOperatorType]struct{}{
+	UpdateOperatorPush:     {},
+	UpdateOperatorAddToSet: {},
+	UpdateOperatorPull:     {},
+	UpdateOperatorPop:      {},
+}
+
+// IsNumeric reports whether the operator does arithmetic on its field.
+func (op UpdateOperator) IsNumeric() bool {
+	_, ok := numericUpdateOperators[op.Type]
+	return ok
+}
+
+// IsArray reports whether the operator changes an array.
+func (op UpdateOperator) IsArray() bool {
+	_, ok := arrayUpdateOperators[op.Type]
+	return ok
+}
+
+// PopFirst reports whether a pop removes the first element rather than the
+// last.
+func (op UpdateOperator) PopFirst() bool {
+	f, ok := getFloat(op.Value)
+	return ok && f == -1
+}
+
+// ValidateUpdateOperators checks operators against each other and, when
+// schema is not nil, against the fields they target. Paths may not repeat
+// or contain one another, since the result would depend on the order the
+// backend applies them in.
+func ValidateUpdateOperators(schema *definition.Schema, operators []UpdateOperator) error {
+	for i, op := range operators {
+		if op.Field == "" {
+			return ErrUpdateOperatorFieldEmpty.WithOperation("ValidateUpdateOperators")
+		}
+		if err := validateUpdateOperatorValue(op); err != nil {
+			return err
+		}
+		for _, other := range operators[:i] {
+			if pathsOverlap(op.Field, other.Field) {
+				return ErrUpdateOperatorConflict.WithOperation("ValidateUpdateOperators").WithPath(op.Field).
+					WithMessagef("update operators on '%s' and '%s' overlap", other.Field, op.Field)
+			}
+		}
+		if schema != nil {
+			if err := validateUpdateOperatorField(schema, op); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateUpdateOperatorValue checks that an operator carries the value its
+// type needs.
+func validateUpdateOperatorValue(op UpdateOperator) error {
+	invalid := ErrUpdateOperatorValue.WithOperation("ValidateUpdateOperators").WithPath(op.Field)
+	switch {
+	case op.IsNumeric():
+		if f, ok := getFloat(op.Value); !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+			return invalid.WithMessagef("%s on '%s' requires a number", op.Type, op.Field)
+		}
+	case op.Type == UpdateOperatorMin, op.Type == UpdateOperatorMax:
+		if _, ok := getFloat(op.Value); !ok {
+			if _, ok := op.Value.(string); !ok {
+				return invalid.WithMessagef("%s on '%s' requires a number or a string", op.Type, op.Field)
+			}
+		}
+	case op.Type == UpdateOperatorPop:
+		if f, ok := getFloat(op.Value); op.Value != nil && (!ok || (f != 1 && f != -1)) {
+			return invalid.WithMessagef("pop on '%s' takes 1 for the last element or -1 for the first", op.Field)
+		}
+	case op.Type == UpdateOperatorPush, op.Type == UpdateOperatorAddToSet, op.Type == UpdateOperatorPull, op.Type == UpdateOperatorSet:
+		if _, err := json.Marshal(op.Value); err != nil {
+			return invalid.WithCause(err)
+		}
+	case op.Type == UpdateOperatorUnset:
+	default:
+		return ErrUpdateOperatorUnsupported.WithOperation("ValidateUpdateOperators").WithPath(op.Field).
+			WithMessagef("unsupported update operator '%s'", op.Type)
+	}
+	return nil
+}
+
+// validateUpdateOperatorField checks that the field an operator targets
+// exists and has a type the operator applies to. Paths inside an object
+// field are checked only as far as the object field itself.
+func validateUpdateOperatorField(schema *definition.Schema, op UpdateOperator) error {
+	parts := strings.Split(op.Field, ".")
+	if data.ReservedSystemField(parts[0]) {
+		return ErrUpdateOperatorFieldUnknown.WithOperation("ValidateUpdateOperators").WithPath(op.Field).
+			WithMessagef("'%s' is managed by the system", parts[0])
+	}
+	_, field := schema.FindField(parts[0])
+	if field == nil {
+		return ErrUpdateOperatorFieldUnknown.WithOperation("ValidateUpdateOperators").WithPath(op.Field).
+			WithMessagef("field '%s' does not exist in schema '%s'", parts[0], schema.Name)
+	}
+
+	wrongType := ErrUpdateOperatorFieldType.WithOperation("ValidateUpdateOperators").WithPath(op.Field)
+	if len(parts) > 1 {
+		if !field.Type.IsComplex() {
+			return wrongType.WithMessagef("'%s' is a %s field and has no nested paths", parts[0], field.Type)
+		}
+		return nil
+	}
+
+	switch {
+	case op.Type == UpdateOperatorSet:
+		return wrongType.WithMessagef("set needs a nested path; replace '%s' with Set instead", op.Field)
+	case op.Type == UpdateOperatorUnset:
+		if field.Required {
+			return ErrUpdateOperatorRequiredField.WithOperation("ValidateUpdateOperators").WithPath(op.Field)
+		}
+	case op.IsNumeric():
+		if field.Type != definition.FieldTypeInteger && field.Type != definition.FieldTypeNumber {
+			return wrongType.WithMessagef("%s needs a numeric field, '%s' is %s", op.Type, op.Field, field.Type)
+		}
+		if f, _ := getFloat(op.Value); field.Type == definition.FieldTypeInteger && f != math.Trunc(f) {
+			return ErrUpdateOperatorValue.WithOperation("ValidateUpdateOperators").WithPath(op.Field).
+				WithMessagef("%s on integer field '%s' requires an integer", op.Type, op.Field)
+		}
+	case op.Type == UpdateOperatorMin, op.Type == UpdateOperatorMax:
+		_, numeric := getFloat(op.Value)
+		switch field.Type {
+		case definition.FieldTypeInteger, definition.FieldTypeNumber:
+			if !numeric {
+				return ErrUpdateOperatorValue.WithOperation("ValidateUpdateOperators").WithPath(op.Field).
+					WithMessagef("%s on numeric field '%s' requires a number", op.Type, op.Field)
+			}
+		case definition.FieldTypeString:
+			if numeric {
+				return ErrUpdateOperatorValue.WithOperation("ValidateUpdateOperators").WithPath(op.Field).
+					WithMessagef("%s on string field '%s' requires a string", op.Type, op.Field)
+			}
+		default:
+			return wrongType.WithMessagef("%s needs a number or string field, '%s' is %s", op.Type, op.Field, field.Type)
+		}
+	case op.IsArray():
+		if field.Type != definition.FieldTypeArray {
+			return wrongType.WithMessagef("%s needs an array field, '%s' is %s", op.Type, op.Field, field.Type)
+		}
+	}
+	return nil
+}
+
+// pathsOverlap reports whether two dotted paths are equal or one contains
+// the other.
+func pathsOverlap(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
+
+// ApplyUpdateOperators applies operators to a document in memory, the way a
+// database applies them in an UPDATE. Objects along each path are copied
+// before they change, so values shared with the caller's original document
+// are left alone.
+func ApplyUpdateOperators(doc map[string]any, operators []UpdateOperator) error {
+	for _, op := range operators {
+		parts := strings.Split(op.Field, ".")
+		current, exists := utils.GetValueByPath(doc, op.Field)
+		if current == nil {
+			exists = false
+		}
+
+		var (
+			next   any
+			remove bool
+			err    error
+		)
+		switch {
+		case op.Type == UpdateOperatorUnset:
+			remove = true
+		case op.Type == UpdateOperatorSet:
+			next = op.Value
+		case op.IsNumeric():
+			next, err = applyNumericOperator(op, current, exists)
+		case op.Type == UpdateOperatorMin, op.Type == UpdateOperatorMax:
+			next, err = applyExtremumOperator(op, current, exists)
+		case op.IsArray():
+			if !exists && op.Type != UpdateOperatorPush && op.Type != UpdateOperatorAddToSet {
+				continue
+			}
+			next, err = applyArrayOperator(op, current)
+		default:
+			err = ErrUpdateOperatorUnsupported.WithPath(op.Field).WithMessagef("unsupported update operator '%s'", op.Type)
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeUpdatePath(doc, parts, next, remove); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyNumericOperator returns the result of inc, dec or mul. Integers stay
+// integers unless either side is a float.
+func applyNumericOperator(op UpdateOperator, current any, exists bool) (any, error) {
+	if !exists {
+		current = 0
+	}
+	a, ok := getFloat(current)
+	if !ok {
+		return nil, ErrUpdateOperatorFieldType.WithOperation("ApplyUpdateOperators").WithPath(op.Field).
+			WithMessagef("%s needs a number, found %T", op.Type, current)
+	}
+	b, _ := getFloat(op.Value)
+	integers := isIntegerKind(current) && isIntegerKind(op.Value)
+
+	switch op.Type {
+	case UpdateOperatorIncrement:
+		if integers {
+			return int64(a) + int64(b), nil
+		}
+		return a + b, nil
+	case UpdateOperatorDecrement:
+		if integers {
+			return int64(a) - int64(b), nil
+		}
+		return a - b, nil
+	default:
+		if integers {
+			return int64(a) * int64(b), nil
+		}
+		return a * b, nil
+	}
+}
+
+// applyExtremumOperator returns the result of min or max.
+func applyExtremumOperator(op UpdateOperator, current any, exists bool) (any, error) {
+	if !exists {
+		return op.Value, nil
+	}
+	var c int
+	if a, ok := getFloat(current); ok {
+		b, ok := getFloat(op.Value)
+		if !ok {
+			return nil, ErrUpdateOperatorValue.WithOperation("ApplyUpdateOperators").WithPath(op.Field).
+				WithMessagef("cannot compare %T with %T", op.Value, current)
+		}
+		c = compareFloats(b, a)
+	} else {
+		a, okA := current.(string)
+		b, okB := op.Value.(string)
+		if !okA || !okB {
+			return nil, ErrUpdateOperatorValue.WithOperation("ApplyUpdateOperators").WithPath(op.Field).
+				WithMessagef("cannot compare %T with %T", op.Value, current)
+		}
+		c = strings.Compare(b, a)
+	}
+	if (op.Type == UpdateOperatorMin && c < 0) || (op.Type == UpdateOperatorMax && c > 0) {
+		return op.Value, nil
+	}
+	return current, nil
+}
+
+// applyArrayOperator returns a new array with push, add_to_set, pull or pop
+// applied. A nil current value is an empty array.
+func applyArrayOperator(op UpdateOperator, current any) (any, error) {
+	var elements []any
+	if current != nil {
+		value := reflect.ValueOf(current)
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			return nil, ErrUpdateOperatorFieldType.WithOperation("ApplyUpdateOperators").WithPath(op.Field).
+				WithMessagef("%s needs an array, found %T", op.Type, current)
+		}
+		elements = make([]any, value.Len(), value.Len()+1)
+		for i := range elements {
+			elements[i] = value.Index(i).Interface()
+		}
+	}
+
+	switch op.Type {
+	case UpdateOperatorPush:
+		return append(elements, op.Value), nil
+	case UpdateOperatorAddToSet:
+		for _, element := range elements {
+			if equal, err := updateValuesEqual(element, op.Value); err != nil || equal {
+				return elements, err
+			}
+		}
+		return append(elements, op.Value), nil
+	case UpdateOperatorPull:
+		kept := make([]any, 0, len(elements))
+		for _, element := range elements {
+			equal, err := updateValuesEqual(element, op.Value)
+			if err != nil {
+				return nil, err
+			}
+			if !equal {
+				kept = append(kept, element)
+			}
+		}
+		return kept, nil
+	default:
+		if len(elements) == 0 {
+			return elements, nil
+		}
+		if op.PopFirst() {
+			return elements[1:], nil
+		}
+		return elements[:len(elements)-1], nil
+	}
+}
+
+// updateValuesEqual compares array elements by value, so that 1 and 1.0 are
+// equal and objects compare field by field.
+func updateValuesEqual(a, b any) (bool, error) {
+	keyA, err := distinctValueKey(a)
+	if err != nil {
+		return false, err
+	}
+	keyB, err := distinctValueKey(b)
+	if err != nil {
+		return false, err
+	}
+	return keyA == keyB, nil
+}
+
+// writeUpdatePath sets or removes the value at a path, copying every object
+// on the way down and creating the ones that are missing.
+func writeUpdatePath(doc map[string]any, parts []string, value any, remove bool) error {
+	current := doc
+	for i, part := range parts[:len(parts)-1] {
+		var child map[string]any
+		switch existing := current[part].(type) {
+		case map[string]any:
+			child = maps.Clone(existing)
+		case nil:
+			if remove {
+				return nil
+			}
+			child = make(map[string]any)
+		default:
+			return ErrUpdateOperatorFieldType.WithOperation("ApplyUpdateOperators").
+				WithPath(strings.Join(parts[:i+1], ".")).
+				WithMessagef("'%s' is %T, not an object", strings.Join(parts[:i+1], "."), existing)
+		}
+		current[part] = child
+		current = child
+	}
+
+	last := parts[len(parts)-1]
+	if remove {
+		delete(current, last)
+	} else {
+		current[last] = value
+	}
+	return nil
+}
+
+// isIntegerKind reports whether v holds a Go integer.
+func isIntegerKind(v any) bool {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// compareFloats returns -1, 0 or 1.
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -134,6 +134,8 @@ func (q *Query) Clone() (*Query, error) {
 	if err := utils.Clone(*q, &newQuery); err != nil {
 		return nil, common.NewSystemError("ERR_QUERY_CLONE_FAILED", "failed to clone query").WithOperation("Clone").WithCause(err)
 	}
+	// Bindings are immutable and do not survive JSON, so the clone shares them.
+	newQuery.Bindings = q.Bindings
 	return &newQuery, nil
 }
 
@@ -219,6 +221,9 @@ func (p *ProjectionConfiguration) HasField(name string) bool {
 		if c.CaseExpression != nil && c.CaseExpression.Alias == name {
 			return true
 		}
+		if c.WindowExpression != nil && c.WindowExpression.Alias == name {
+			return true
+		}
 	}
 	return false
 }
@@ -308,4 +313,32 @@ func (p *ProjectionConfiguration) AddCaseExpression(alias string, conditions []C
 			Alias:      alias,
 		},
 	})
-}
\ No newline at end of file
+}
+// AddWindowExpression adds a window expression if not already present (by alias).
+func (p *ProjectionConfiguration) AddWindowExpression(expr WindowExpression) {
+	if p == nil {
+		return
+	}
+	for _, c := range p.Computed {
+		if c.WindowExpression != nil && c.WindowExpression.Alias == expr.Alias {
+			return
+		}
+	}
+	expr.Type = "window"
+	p.Computed = append(p.Computed, ProjectionComputedItem{WindowExpression: &expr})
+}
+
+// Windows returns the window expressions of the projection in declaration
+// order.
+func (p *ProjectionConfiguration) Windows() []*WindowExpression {
+	if p == nil {
+		return nil
+	}
+	var windows []*WindowExpression
+	for _, c := range p.Computed {
+		if c.WindowExpression != nil {
+			windows = append(windows, c.WindowExpression)
+		}
+	}
+	return windows
+}
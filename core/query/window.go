@@ -0,0 +1,379 @@
+package query
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/utils"
+)
+
+// windowFunctionFields records, for every supported window function, whether
+// it reads Field from the rows of its window.
+var windowFunctionFields = map[WindowFunction]bool{
+	WindowFunctionRowNumber:  false,
+	WindowFunctionRank:       false,
+	WindowFunctionDenseRank:  false,
+	WindowFunctionCount:      false,
+	WindowFunctionLag:        true,
+	WindowFunctionLead:       true,
+	WindowFunctionFirstValue: true,
+	WindowFunctionLastValue:  true,
+	WindowFunctionSum:        true,
+	WindowFunctionAvg:        true,
+	WindowFunctionMin:        true,
+	WindowFunctionMax:        true,
+}
+
+// windowBoundOrder orders frame bound types from the start of a partition to
+// its end. A frame may not start at a later kind of bound than it ends.
+var windowBoundOrder = map[WindowFrameBoundType]int{
+	WindowBoundUnboundedPreceding: 0,
+	WindowBoundPreceding:          1,
+	WindowBoundCurrentRow:         2,
+	WindowBoundFollowing:          3,
+	WindowBoundUnboundedFollowing: 4,
+}
+
+// validateWindowExpression validates a WindowExpression structure.
+func (h *QueryHelper) validateWindowExpression(w *WindowExpression) error {
+	if w.Type != "window" {
+		return ErrWindowExpressionTypeInvalid.WithOperation("validateWindowExpression")
+	}
+	if w.Alias == "" {
+		return ErrWindowExpressionAliasEmpty.WithOperation("validateWindowExpression")
+	}
+	needsField, ok := windowFunctionFields[w.Function]
+	if !ok {
+		return common.NewSystemError(ErrUnsupportedWindowFunction.Code, fmt.Sprintf("unsupported window function: %s", w.Function)).WithOperation("validateWindowExpression").WithCause(ErrUnsupportedWindowFunction)
+	}
+	if needsField && w.Field == "" {
+		return common.NewSystemError(ErrWindowFieldEmpty.Code, fmt.Sprintf("window function '%s' in '%s' requires a field", w.Function, w.Alias)).WithOperation("validateWindowExpression").WithCause(ErrWindowFieldEmpty)
+	}
+	if w.Offset != nil && *w.Offset < 0 {
+		return ErrWindowOffsetNegative.WithOperation("validateWindowExpression")
+	}
+	if w.Default != nil {
+		if err := h.validateFilterValue(w.Default); err != nil {
+			return common.NewSystemError("ERR_QUERY_INVALID_WINDOW_DEFAULT", fmt.Sprintf("invalid default value in window expression '%s'", w.Alias)).WithOperation("validateWindowExpression").WithCause(err)
+		}
+	}
+	for _, field := range w.PartitionBy {
+		if field == "" {
+			return common.NewSystemError("ERR_QUERY_WINDOW_PARTITION_FIELD_EMPTY", "window partition field cannot be empty").WithOperation("validateWindowExpression")
+		}
+	}
+	for _, order := range w.OrderBy {
+		if order.Field == "" {
+			return ErrSortFieldEmpty
+		}
+		if order.Direction != SortDirectionAsc && order.Direction != SortDirectionDesc {
+			return common.NewSystemError(ErrInvalidSortDirection.Code, fmt.Sprintf("invalid sort direction: %s", order.Direction)).WithOperation("validateWindowExpression").WithCause(ErrInvalidSortDirection)
+		}
+	}
+	if w.Frame != nil {
+		if err := validateWindowFrame(w.Frame); err != nil {
+			return common.NewSystemError(ErrInvalidWindowFrame.Code, fmt.Sprintf("invalid frame in window expression '%s'", w.Alias)).WithOperation("validateWindowExpression").WithCause(err)
+		}
+	}
+	return nil
+}
+
+// validateWindowFrame checks that a frame is one both SQL backends and the
+// in-memory evaluator understand.
+func validateWindowFrame(frame *WindowFrame) error {
+	if frame.Unit != WindowFrameRows && frame.Unit != WindowFrameRange {
+		return fmt.Errorf("unknown frame unit %q", frame.Unit)
+	}
+	for _, bound := range []WindowFrameBound{frame.Start, frame.End} {
+		if _, ok := windowBoundOrder[bound.Type]; !ok {
+			return fmt.Errorf("unknown frame bound %q", bound.Type)
+		}
+		if bound.Offset < 0 {
+			return fmt.Errorf("frame offset cannot be negative")
+		}
+		if frame.Unit == WindowFrameRange && (bound.Type == WindowBoundPreceding || bound.Type == WindowBoundFollowing) {
+			return fmt.Errorf("range frames only support unbounded and current row bounds")
+		}
+	}
+	if frame.Start.Type == WindowBoundUnboundedFollowing || frame.End.Type == WindowBoundUnboundedPreceding {
+		return fmt.Errorf("frame cannot start at unbounded following or end at unbounded preceding")
+	}
+	if windowBoundOrder[frame.Start.Type] > windowBoundOrder[frame.End.Type] {
+		return fmt.Errorf("frame starts after it ends")
+	}
+	return nil
+}
+
+// ApplyWindows evaluates the window expressions of the query projection over
+// records. It returns copies of the records, in their original order, that
+// carry every window value under its alias so Project can pick them up.
+func (h *QueryHelper) ApplyWindows(records []map[string]any) ([]map[string]any, error) {
+	windows := h.query.Projection.Windows()
+	if len(windows) == 0 {
+		return records, nil
+	}
+
+	result := make([]map[string]any, len(records))
+	for i, record := range records {
+		result[i] = maps.Clone(record)
+		if result[i] == nil {
+			result[i] = make(map[string]any)
+		}
+	}
+
+	for _, w := range windows {
+		for _, partition := range windowPartitions(result, w.PartitionBy) {
+			if err := h.evaluateWindow(result, partition, w); err != nil {
+				return nil, common.NewSystemError("ERR_QUERY_WINDOW_EVALUATION_FAILED", fmt.Sprintf("error evaluating window expression '%s'", w.Alias)).WithOperation("ApplyWindows").WithCause(err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// windowPartitions groups the indexes of records by their partition values,
+// keeping partitions and their rows in input order.
+func windowPartitions(records []map[string]any, partitionBy []string) [][]int {
+	if len(partitionBy) == 0 {
+		all := make([]int, len(records))
+		for i := range records {
+			all[i] = i
+		}
+		return [][]int{all}
+	}
+
+	var partitions [][]int
+	index := make(map[string]int)
+	for i, record := range records {
+		keyParts := make([]string, len(partitionBy))
+		for j, field := range partitionBy {
+			val, _ := utils.GetValueByPath(record, field)
+			keyParts[j] = fmt.Sprintf("%v", val)
+		}
+		key := strings.Join(keyParts, "|")
+		p, ok := index[key]
+		if !ok {
+			p = len(partitions)
+			index[key] = p
+			partitions = append(partitions, nil)
+		}
+		partitions[p] = append(partitions[p], i)
+	}
+	return partitions
+}
+
+// evaluateWindow orders one partition and stores the value of w for each of
+// its rows.
+func (h *QueryHelper) evaluateWindow(records []map[string]any, rows []int, w *WindowExpression) error {
+	orderCompare := func(a, b int) int {
+		for _, order := range w.OrderBy {
+			valueA, _ := utils.GetValueByPath(records[a], order.Field)
+			valueB, _ := utils.GetValueByPath(records[b], order.Field)
+			c := h.compareWindowValues(valueA, valueB)
+			if c == 0 {
+				continue
+			}
+			if order.Direction == SortDirectionDesc {
+				return -c
+			}
+			return c
+		}
+		return 0
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return orderCompare(rows[i], rows[j]) < 0 })
+
+	// Rows with equal ORDER BY values are peers; peerStart and peerEnd hold
+	// the first and last position of each row's peer group.
+	n := len(rows)
+	peerStart, peerEnd := make([]int, n), make([]int, n)
+	denseRank := make([]int64, n)
+	for k := range rows {
+		if k > 0 && orderCompare(rows[k-1], rows[k]) == 0 {
+			peerStart[k] = peerStart[k-1]
+			denseRank[k] = denseRank[k-1]
+		} else {
+			peerStart[k] = k
+			denseRank[k] = 1
+			if k > 0 {
+				denseRank[k] = denseRank[k-1] + 1
+			}
+		}
+	}
+	for k := n - 1; k >= 0; k-- {
+		if k < n-1 && peerStart[k+1] == peerStart[k] {
+			peerEnd[k] = peerEnd[k+1]
+		} else {
+			peerEnd[k] = k
+		}
+	}
+
+	offset := 1
+	if w.Offset != nil {
+		offset = *w.Offset
+	}
+	fieldValue := func(pos int) any {
+		val, _ := utils.GetValueByPath(records[rows[pos]], w.Field)
+		return val
+	}
+
+	for k, row := range rows {
+		var value any
+		switch w.Function {
+		case WindowFunctionRowNumber:
+			value = int64(k + 1)
+		case WindowFunctionRank:
+			value = int64(peerStart[k] + 1)
+		case WindowFunctionDenseRank:
+			value = denseRank[k]
+		case WindowFunctionLag, WindowFunctionLead:
+			target := k - offset
+			if w.Function == WindowFunctionLead {
+				target = k + offset
+			}
+			if target >= 0 && target < n {
+				value = fieldValue(target)
+			} else if w.Default != nil {
+				resolved, err := h.resolveFilterValue(records[row], w.Default)
+				if err != nil {
+					return err
+				}
+				value = resolved
+			}
+		default:
+			lo, hi := windowFrameBounds(w, k, n, peerStart, peerEnd)
+			value = h.windowFrameValue(w, lo, hi, fieldValue)
+		}
+		records[row][w.Alias] = value
+	}
+	return nil
+}
+
+// windowFrameBounds returns the first and last partition position inside the
+// frame of the row at position k. The frame is empty when lo > hi.
+func windowFrameBounds(w *WindowExpression, k, n int, peerStart, peerEnd []int) (int, int) {
+	if w.Frame == nil {
+		if len(w.OrderBy) > 0 {
+			return 0, peerEnd[k]
+		}
+		return 0, n - 1
+	}
+	bound := func(b WindowFrameBound, isEnd bool) int {
+		switch b.Type {
+		case WindowBoundUnboundedPreceding:
+			return 0
+		case WindowBoundPreceding:
+			return k - b.Offset
+		case WindowBoundFollowing:
+			return k + b.Offset
+		case WindowBoundUnboundedFollowing:
+			return n - 1
+		default:
+			if w.Frame.Unit == WindowFrameRange {
+				if isEnd {
+					return peerEnd[k]
+				}
+				return peerStart[k]
+			}
+			return k
+		}
+	}
+	return max(bound(w.Frame.Start, false), 0), min(bound(w.Frame.End, true), n-1)
+}
+
+// windowFrameValue computes an aggregate or value window function over the
+// partition positions lo through hi.
+func (h *QueryHelper) windowFrameValue(w *WindowExpression, lo, hi int, fieldValue func(int) any) any {
+	if w.Function == WindowFunctionCount && w.Field == "" {
+		return int64(max(hi-lo+1, 0))
+	}
+	if lo > hi {
+		if w.Function == WindowFunctionCount {
+			return int64(0)
+		}
+		return nil
+	}
+	switch w.Function {
+	case WindowFunctionFirstValue:
+		return fieldValue(lo)
+	case WindowFunctionLastValue:
+		return fieldValue(hi)
+	}
+
+	var (
+		count    int64
+		numeric  int64
+		sum      float64
+		intSum   int64
+		integers = true
+		extreme  any
+	)
+	for pos := lo; pos <= hi; pos++ {
+		val := fieldValue(pos)
+		if val == nil {
+			continue
+		}
+		count++
+		switch w.Function {
+		case WindowFunctionSum, WindowFunctionAvg:
+			f, ok := getFloat(val)
+			if !ok {
+				continue
+			}
+			numeric++
+			sum += f
+			switch reflect.ValueOf(val).Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				intSum += int64(f)
+			default:
+				integers = false
+			}
+		case WindowFunctionMin:
+			if extreme == nil || h.compareWindowValues(val, extreme) < 0 {
+				extreme = val
+			}
+		case WindowFunctionMax:
+			if extreme == nil || h.compareWindowValues(val, extreme) > 0 {
+				extreme = val
+			}
+		}
+	}
+
+	switch w.Function {
+	case WindowFunctionCount:
+		return count
+	case WindowFunctionSum:
+		if numeric == 0 {
+			return nil
+		}
+		if integers {
+			return intSum
+		}
+		return sum
+	case WindowFunctionAvg:
+		if numeric == 0 {
+			return nil
+		}
+		return sum / float64(numeric)
+	default:
+		return extreme
+	}
+}
+
+// compareWindowValues orders values the way SQL orders window rows: nulls
+// sort before every other value.
+func (h *QueryHelper) compareWindowValues(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return h.compareValues(a, b)
+}
@@ -3,6 +3,8 @@ package definition
 import (
 	"fmt"
 	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/data/container"
 )
 
 // =============================================================================
@@ -10,13 +12,14 @@ import (
 // =============================================================================
 //
 // Every terminal field reachable via any valid path in the schema graph is
-// assigned a unique 27-bit integer address. Non-terminal fields (object/array/
+// assigned a unique 27-bit integer address (32-bit for extended-layout
+// schemas). Non-terminal fields (object/array/
 // complex containers) are structural — they own sub-blocks but do not receive
 // addresses themselves. Only leaf values are addressable.
 //
 // Single-step paths (root-level fields) occupy [1, 2^14); address 0 is
 // reserved as the "not addressable" sentinel (empty path or non-terminal end).
-// Multi-step paths occupy [2^14, 2^27).
+// Multi-step paths occupy [2^14, 2^27), or [2^14, 2^32) when extended.
 //
 // The address space uses a footprint-based allocation:
 //
@@ -109,7 +112,7 @@ func (cs *CompiledSchema) computeAddress(path ResolvedPath) uint32 {
 			return 0
 		}
 		// +1 keeps 0 reserved for "not addressable": a single-step path always
-		// lives in the root slot (FieldStart 0, FieldIdx < 256), so addresses
+		// lives in the root slot (FieldStart 0, FieldIdx < 8192), so addresses
 		// stay in [1, 2^14) and can never alias a terminal whose computed
 		// address is 0 (the very first root field).
 		return uint32(abs) + 1
@@ -150,7 +153,7 @@ func (cs *CompiledSchema) ResolvePath(path string) (ResolvedPath, error) {
 	}
 	segments := strings.Split(path, ".")
 	rp := make(ResolvedPath, 0, len(segments))
-	schemaIdx := uint8(0) // root schema slot
+	schemaIdx := uint16(0) // root schema slot
 	for i, segment := range segments {
 		step, fd, err := cs.ResolveFieldStep(schemaIdx, segment)
 		if err != nil {
@@ -180,7 +183,7 @@ func (cs *CompiledSchema) ResolvePath(path string) (ResolvedPath, error) {
 // downstream consumers that resolve view-relative paths (e.g. the document
 // layer, whose nested views are anchored at a non-root slot) can share the
 // single field-lookup implementation instead of re-walking the schema.
-func (cs *CompiledSchema) ResolveFieldStep(schemaIdx uint8, name string) (ResolvedStep, FieldDescriptor, error) {
+func (cs *CompiledSchema) ResolveFieldStep(schemaIdx uint16, name string) (ResolvedStep, FieldDescriptor, error) {
 	if int(schemaIdx) >= len(cs.Schemas) {
 		return 0, 0, ErrInvalidSchema.WithMessage(
 			fmt.Sprintf("schema slot %d is out of range (compiled schema has %d)", schemaIdx, len(cs.Schemas)),
@@ -190,7 +193,7 @@ func (cs *CompiledSchema) ResolveFieldStep(schemaIdx uint8, name string) (Resolv
 	for j := uint16(0); j < slot.FieldCount; j++ {
 		abs := int(slot.FieldStart) + int(j)
 		if abs < len(cs.FieldsMeta) && cs.FieldsMeta[abs].Name == name {
-			return NewResolvedStep(schemaIdx, uint8(j)), cs.Descriptors[abs], nil
+			return NewResolvedStep(schemaIdx, j), cs.Descriptors[abs], nil
 		}
 	}
 	return 0, 0, ErrFieldNotFound.WithMessage(
@@ -256,3 +259,79 @@ func (cs *CompiledSchema) joinPath(path ResolvedPath) string {
 func (cs *CompiledSchema) FieldPath(fd FieldDescriptor) string {
 	return cs.PathString(ResolvedPath{NewResolvedStep(fd.SchemaIdx(), fd.FieldIdx())})
 }
+
+// =============================================================================
+// DATA CONTAINER KEYS
+// =============================================================================
+//
+// A DataContainerKey pairs a DataPoint (low half) with 32 descriptor bits
+// (high half). A compact descriptor is its own high half. An extended one
+// does not fit, so its keys carry the field's SchemaIdx and FieldIdx (27 bits)
+// and, below them, the address bits above the DataPoint's 27-bit identifier.
+// Either way a key identifies its field and address without the schema; only
+// reading them back needs to know which layout the schema uses.
+
+const (
+	keyIDBits       = 27
+	keyIDMask       = 1<<keyIDBits - 1
+	keyAddrHighMask = 1<<(ExtendedAddrBits-keyIDBits) - 1
+)
+
+// AddressKey builds the key for a value of f stored at the flat address addr.
+func (f FieldDescriptor) AddressKey(addr uint32) (container.DataContainerKey, error) {
+	if !f.Extended() {
+		dp, err := container.NewDataPoint(f.DataType(), int32(addr))
+		if err != nil {
+			return 0, err
+		}
+		return container.NewDataContainerKey(dp, uint32(f)), nil
+	}
+	dp, err := container.NewDataPoint(f.DataType(), int32(addr&keyIDMask))
+	if err != nil {
+		return 0, err
+	}
+	return container.NewDataContainerKey(dp, f.extendedKeyBits()|addr>>keyIDBits), nil
+}
+
+// InternalKey builds the structural key for a value of f that has no flat
+// address (records, array-of-object fields, unions): its DataPoint derives
+// from the descriptor instead.
+func (f FieldDescriptor) InternalKey() container.DataContainerKey {
+	if !f.Extended() {
+		return container.NewDataContainerKey(container.DataPoint(f.DataPoint()), uint32(f))
+	}
+	return container.NewDataContainerKey(container.DataPoint(f.DataPoint()), f.extendedKeyBits())
+}
+
+// extendedKeyBits is the location half of an extended descriptor's keys.
+func (f FieldDescriptor) extendedKeyBits() uint32 {
+	return (uint32(f.SchemaIdx())<<13 | uint32(f.FieldIdx())) << (ExtendedAddrBits - keyIDBits)
+}
+
+// Extended reports whether cs uses the extended descriptor layout.
+func (cs *CompiledSchema) Extended() bool {
+	return len(cs.Descriptors) > 0 && cs.Descriptors[0].Extended()
+}
+
+// KeyAddress returns the flat address key was built with by AddressKey.
+func (cs *CompiledSchema) KeyAddress(key container.DataContainerKey) uint32 {
+	addr := uint32(key.DataPoint().ID())
+	if cs.Extended() {
+		addr |= (key.Descriptor() & keyAddrHighMask) << keyIDBits
+	}
+	return addr
+}
+
+// KeyDescriptor returns the descriptor of the field key was built for, or 0
+// when key names no field of cs.
+func (cs *CompiledSchema) KeyDescriptor(key container.DataContainerKey) FieldDescriptor {
+	if !cs.Extended() {
+		return FieldDescriptor(key.Descriptor())
+	}
+	loc := key.Descriptor() >> (ExtendedAddrBits - keyIDBits)
+	schemaIdx, fieldIdx := loc>>13, loc&0x1FFF
+	if int(schemaIdx) >= len(cs.Schemas) || fieldIdx >= uint32(cs.Schemas[schemaIdx].FieldCount) {
+		return 0
+	}
+	return cs.Descriptors[int(cs.Schemas[schemaIdx].FieldStart)+int(fieldIdx)]
+}
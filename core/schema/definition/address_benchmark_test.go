@@ -0,0 +1,72 @@
+package definition_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// benchLayoutSchema links a schema of rootFields root strings and a three-level
+// chain of nested objects. 100 root fields fit the compact descriptor layout;
+// 200 force the extended one, with the nested paths otherwise identical.
+func benchLayoutSchema(b *testing.B, rootFields int) *definition.CompiledSchema {
+	b.Helper()
+	var root []string
+	for i := 0; i < rootFields; i++ {
+		root = append(root, fmt.Sprintf(`"r_%d": {"name": "r_%d", "type": "string"}`, i, i))
+	}
+	root = append(root, `"a": {"name": "a", "type": "object", "schema": {"id": "a"}}`)
+	s, err := definition.FromJSON([]byte(fmt.Sprintf(`{
+		"version": "1.0.0", "name": "bench", "fields": {%s},
+		"schemas": {
+			"a": {"name": "a", "fields": {"x": {"name": "x", "type": "integer"}, "b": {"name": "b", "type": "object", "schema": {"id": "b"}}}},
+			"b": {"name": "b", "fields": {"y": {"name": "y", "type": "integer"}, "c": {"name": "c", "type": "object", "schema": {"id": "c"}}}},
+			"c": {"name": "c", "fields": {"z": {"name": "z", "type": "string"}}}
+		}
+	}`, strings.Join(root, ","))))
+	if err != nil {
+		b.Fatal(err)
+	}
+	rs, err := definition.Compile(s)
+	if err != nil {
+		b.Fatal(err)
+	}
+	cs, err := definition.Link(rs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return cs
+}
+
+// BenchmarkAddressKey measures the per-value hot path — memoised Address()
+// plus key construction — for a root field and a three-level path, in each
+// descriptor layout.
+func BenchmarkAddressKey(b *testing.B) {
+	for _, layout := range []struct {
+		name       string
+		rootFields int
+	}{{"compact", 100}, {"extended", 200}} {
+		cs := benchLayoutSchema(b, layout.rootFields)
+		if cs.Extended() != (layout.name == "extended") {
+			b.Fatalf("%s schema linked with the wrong layout", layout.name)
+		}
+		for _, path := range []string{"r_7", "a.b.c.z"} {
+			rp, err := cs.ResolvePath(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			last := rp[len(rp)-1]
+			fd := cs.Descriptors[int(cs.Schemas[last.SchemaIdx()].FieldStart)+int(last.FieldIdx())]
+			b.Run(layout.name+"/"+path, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := fd.AddressKey(cs.Address(rp)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -139,7 +139,7 @@ func buildChain(n, nFields int, terminals uint8, recursiveDepth int8) *definitio
 	for i := 0; i < n; i++ {
 		for j := 0; j < nFields; j++ {
 			terminal := (terminals>>j)&1 != 0
-			childSchemaIdx := uint8(definition.FdNoChild)
+			childSchemaIdx := definition.FdNoChild
 			kind := definition.KindSimple
 
 			// Last field in the chain: if we hit the end or this is a recursive anchor.
@@ -149,7 +149,7 @@ func buildChain(n, nFields int, terminals uint8, recursiveDepth int8) *definitio
 				kind = definition.KindObject
 				terminal = true
 				fd := definition.MakeFieldDescriptor(
-					container.TypeUnknown, kind, uint8(i), uint8(j),
+					container.TypeUnknown, kind, uint16(i), uint16(j),
 					false, false, false, false, terminal, false, true,
 					childSchemaIdx,
 				)
@@ -158,7 +158,7 @@ func buildChain(n, nFields int, terminals uint8, recursiveDepth int8) *definitio
 			}
 
 			if !terminal {
-				childSchemaIdx = uint8(i + 1)
+				childSchemaIdx = uint16(i + 1)
 				if i == n-1 {
 					// Last schema: no further children; make this field terminal.
 					terminal = true
@@ -169,7 +169,7 @@ func buildChain(n, nFields int, terminals uint8, recursiveDepth int8) *definitio
 			}
 
 			fd := definition.MakeFieldDescriptor(
-				container.TypeUnknown, kind, uint8(i), uint8(j),
+				container.TypeUnknown, kind, uint16(i), uint16(j),
 				false, false, false, false, terminal, false, false,
 				childSchemaIdx,
 			)
@@ -222,8 +222,8 @@ const pathCap = 5000
 
 func enumeratePaths(cs *definition.CompiledSchema) []definition.ResolvedPath {
 	result := make([]definition.ResolvedPath, 0, 256)
-	var walk func(schemaIdx uint8, prefix definition.ResolvedPath)
-	walk = func(schemaIdx uint8, prefix definition.ResolvedPath) {
+	var walk func(schemaIdx uint16, prefix definition.ResolvedPath)
+	walk = func(schemaIdx uint16, prefix definition.ResolvedPath) {
 		if len(result) >= pathCap {
 			return
 		}
@@ -231,7 +231,7 @@ func enumeratePaths(cs *definition.CompiledSchema) []definition.ResolvedPath {
 			return
 		}
 		slot := cs.Schemas[schemaIdx]
-		for j := uint8(0); j < uint8(slot.FieldCount); j++ {
+		for j := uint16(0); j < slot.FieldCount; j++ {
 			if len(result) >= pathCap {
 				return
 			}
@@ -1,6 +1,7 @@
 package definition
 
 import (
+	"encoding/binary"
 	"sync"
 
 	"github.com/asaidimu/go-anansi/v8/core/common"
@@ -14,7 +15,7 @@ import (
 type FieldKind uint8
 
 const (
-	KindSimple      FieldKind = iota
+	KindSimple FieldKind = iota
 	KindObject
 	KindArrayField
 	KindComplex
@@ -65,14 +66,18 @@ const (
 //        - The USER-DATA DataPoint answers: "at which path was this value stored?"
 //
 
-// FieldDescriptor is a packed uint32 that identifies a field in the flat
+// FieldDescriptor is a packed identifier for a field in the flat
 // CompiledSchema field table. Every unique (schema instance, field) pair
 // gets its own descriptor.
 //
-// Layout:
+// Descriptors come in two layouts. Link picks the compact layout whenever the
+// schema fits it, and switches the whole schema to the extended layout only
+// when it does not; the two never mix within one CompiledSchema.
+//
+// Compact layout (bits 63-32 zero):
 //
 //	bits 31-28: DataType (4 bits)
-//	bits 27-22: SchemaIdx (6 bits) — index into CompiledSchema.Schemas (max 63)
+//	bits 27-22: SchemaIdx (6 bits) — index into CompiledSchema.Schemas (max 62)
 //	bits 21-15: FieldIdx (7 bits) — position within the parent schema's fields (max 127)
 //	bits 14-9:  ChildSchemaIdx (6 bits) — 0x3F if no child
 //	bits 8-7:   Kind (2 bits)
@@ -83,31 +88,83 @@ const (
 //	bit  2:     Terminal
 //	bit  1:     Nullable
 //	bit  0:     Recursive
-type FieldDescriptor uint32
+//
+// Extended layout, for schemas with more than 63 schema slots, more than 128
+// fields in one schema, or more address slots than the compact address space
+// holds:
+//
+//	bit  63:    Extended
+//	bits 62-59: DataType (4 bits)
+//	bits 58-45: SchemaIdx (14 bits) — max 16382
+//	bits 44-32: FieldIdx (13 bits) — max 8191
+//	bits 31-23: reserved
+//	bits 22-9:  ChildSchemaIdx (14 bits) — 0x3FFF if no child
+//	bits 8-0:   Kind and flags, as in the compact layout
+//
+// The compact layout is the low 32 bits of the original uint32 descriptor,
+// so compact DataPoints, DataContainerKeys and binary layout versions are
+// unchanged by the extended layout's existence.
+type FieldDescriptor uint64
 
 const (
-	fdTypeMask           = uint32(0xF) << 28
-	fdSchemaIdxMask      = uint32(0x3F) << 22
-	fdFieldIdxMask       = uint32(0x7F) << 15
-	fdChildSchemaIdxMask = uint32(0x3F) << 9
-	fdKindMask           = uint32(0x3) << 7
-	fdRequired           = uint32(1) << 6
-	fdHasDefault         = uint32(1) << 5
-	fdDeprecated         = uint32(1) << 4
-	fdUnique             = uint32(1) << 3
-	fdTerminal           = uint32(1) << 2
-	fdNullable           = uint32(1) << 1
-	fdRecursive          = uint32(1) << 0
-	FdNoChild     uint8  = 0x3F // terminal/no-child sentinel
+	fdTypeMask           = uint64(0xF) << 28
+	fdSchemaIdxMask      = uint64(0x3F) << 22
+	fdFieldIdxMask       = uint64(0x7F) << 15
+	fdChildSchemaIdxMask = uint64(0x3F) << 9
+	fdKindMask           = uint64(0x3) << 7
+	fdRequired           = uint64(1) << 6
+	fdHasDefault         = uint64(1) << 5
+	fdDeprecated         = uint64(1) << 4
+	fdUnique             = uint64(1) << 3
+	fdTerminal           = uint64(1) << 2
+	fdNullable           = uint64(1) << 1
+	fdRecursive          = uint64(1) << 0
+
+	fdExtended              = uint64(1) << 63
+	fdExtTypeMask           = uint64(0xF) << 59
+	fdExtSchemaIdxMask      = uint64(0x3FFF) << 45
+	fdExtFieldIdxMask       = uint64(0x1FFF) << 32
+	fdExtChildSchemaIdxMask = uint64(0x3FFF) << 9
+
+	fdCompactNoChild  = 0x3F
+	fdExtendedNoChild = 0x3FFF
+
+	// FdNoChild is what ChildSchemaIdx returns for a field without a child
+	// schema (terminal or scalar), in either layout.
+	FdNoChild uint16 = 0xFFFF
 )
 
-func MakeFieldDescriptor(dt container.DataType, kind FieldKind, schemaIdx, fieldIdx uint8, required, hasDefault, deprecated, unique, terminal, nullable, recursive bool, childSchemaIdx uint8) FieldDescriptor {
-	var fd uint32
-	fd |= uint32(dt) << 28
-	fd |= uint32(schemaIdx&0x3F) << 22
-	fd |= uint32(fieldIdx&0x7F) << 15
-	fd |= uint32(childSchemaIdx&0x3F) << 9
-	fd |= uint32(kind) << 7
+// MakeFieldDescriptor packs a compact-layout descriptor. Indices beyond the
+// compact widths are masked; Link switches to MakeExtendedFieldDescriptor
+// before that can happen.
+func MakeFieldDescriptor(dt container.DataType, kind FieldKind, schemaIdx, fieldIdx uint16, required, hasDefault, deprecated, unique, terminal, nullable, recursive bool, childSchemaIdx uint16) FieldDescriptor {
+	if childSchemaIdx == FdNoChild {
+		childSchemaIdx = fdCompactNoChild
+	}
+	var fd uint64
+	fd |= uint64(dt) << 28
+	fd |= uint64(schemaIdx&0x3F) << 22
+	fd |= uint64(fieldIdx&0x7F) << 15
+	fd |= uint64(childSchemaIdx&0x3F) << 9
+	return FieldDescriptor(fd | descriptorFlags(kind, required, hasDefault, deprecated, unique, terminal, nullable, recursive))
+}
+
+// MakeExtendedFieldDescriptor packs an extended-layout descriptor.
+func MakeExtendedFieldDescriptor(dt container.DataType, kind FieldKind, schemaIdx, fieldIdx uint16, required, hasDefault, deprecated, unique, terminal, nullable, recursive bool, childSchemaIdx uint16) FieldDescriptor {
+	if childSchemaIdx == FdNoChild {
+		childSchemaIdx = fdExtendedNoChild
+	}
+	fd := fdExtended
+	fd |= uint64(dt) << 59
+	fd |= uint64(schemaIdx&0x3FFF) << 45
+	fd |= uint64(fieldIdx&0x1FFF) << 32
+	fd |= uint64(childSchemaIdx&0x3FFF) << 9
+	return FieldDescriptor(fd | descriptorFlags(kind, required, hasDefault, deprecated, unique, terminal, nullable, recursive))
+}
+
+// descriptorFlags packs the kind and flag bits, which both layouts share.
+func descriptorFlags(kind FieldKind, required, hasDefault, deprecated, unique, terminal, nullable, recursive bool) uint64 {
+	fd := uint64(kind) << 7
 	if required {
 		fd |= fdRequired
 	}
@@ -129,65 +186,96 @@ func MakeFieldDescriptor(dt container.DataType, kind FieldKind, schemaIdx, field
 	if recursive {
 		fd |= fdRecursive
 	}
-	return FieldDescriptor(fd)
+	return fd
+}
+
+// Extended reports whether f uses the extended layout.
+func (f FieldDescriptor) Extended() bool {
+	return uint64(f)&fdExtended != 0
 }
 
 func (f FieldDescriptor) DataType() container.DataType {
-	return container.DataType((uint32(f) & fdTypeMask) >> 28)
+	if f.Extended() {
+		return container.DataType((uint64(f) & fdExtTypeMask) >> 59)
+	}
+	return container.DataType((uint64(f) & fdTypeMask) >> 28)
 }
 
-func (f FieldDescriptor) SchemaIdx() uint8 {
-	return uint8((uint32(f) & fdSchemaIdxMask) >> 22)
+func (f FieldDescriptor) SchemaIdx() uint16 {
+	if f.Extended() {
+		return uint16((uint64(f) & fdExtSchemaIdxMask) >> 45)
+	}
+	return uint16((uint64(f) & fdSchemaIdxMask) >> 22)
 }
 
-func (f FieldDescriptor) FieldIdx() uint8 {
-	return uint8((uint32(f) & fdFieldIdxMask) >> 15)
+func (f FieldDescriptor) FieldIdx() uint16 {
+	if f.Extended() {
+		return uint16((uint64(f) & fdExtFieldIdxMask) >> 32)
+	}
+	return uint16((uint64(f) & fdFieldIdxMask) >> 15)
 }
 
 // ChildSchemaIdx returns the child schema slot index for non-terminal fields.
-// Returns 0x3F if the field has no child schema (terminal or scalar).
-func (f FieldDescriptor) ChildSchemaIdx() uint8 {
-	return uint8((uint32(f) & fdChildSchemaIdxMask) >> 9)
+// Returns FdNoChild if the field has no child schema (terminal or scalar).
+func (f FieldDescriptor) ChildSchemaIdx() uint16 {
+	if f.Extended() {
+		if c := uint16((uint64(f) & fdExtChildSchemaIdxMask) >> 9); c != fdExtendedNoChild {
+			return c
+		}
+		return FdNoChild
+	}
+	if c := uint16((uint64(f) & fdChildSchemaIdxMask) >> 9); c != fdCompactNoChild {
+		return c
+	}
+	return FdNoChild
 }
 
 func (f FieldDescriptor) Kind() FieldKind {
-	return FieldKind((uint32(f) & fdKindMask) >> 7)
+	return FieldKind((uint64(f) & fdKindMask) >> 7)
 }
 
 func (f FieldDescriptor) Required() bool {
-	return uint32(f)&fdRequired != 0
+	return uint64(f)&fdRequired != 0
 }
 
 func (f FieldDescriptor) HasDefault() bool {
-	return uint32(f)&fdHasDefault != 0
+	return uint64(f)&fdHasDefault != 0
 }
 
 func (f FieldDescriptor) Deprecated() bool {
-	return uint32(f)&fdDeprecated != 0
+	return uint64(f)&fdDeprecated != 0
 }
 
 func (f FieldDescriptor) Unique() bool {
-	return uint32(f)&fdUnique != 0
+	return uint64(f)&fdUnique != 0
 }
 
 func (f FieldDescriptor) Terminal() bool {
-	return uint32(f)&fdTerminal != 0
+	return uint64(f)&fdTerminal != 0
 }
 
 func (f FieldDescriptor) Nullable() bool {
-	return uint32(f)&fdNullable != 0
+	return uint64(f)&fdNullable != 0
 }
 
 func (f FieldDescriptor) Recursive() bool {
-	return uint32(f)&fdRecursive != 0
+	return uint64(f)&fdRecursive != 0
 }
 
-// DataPoint returns the 32-bit DataPoint for this field descriptor.
+// DataPoint returns the 32-bit DataPoint for this field descriptor. A compact
+// descriptor keeps its structural bits as the 27-bit identifier; an extended
+// one uses its SchemaIdx and FieldIdx, which together are exactly 27 bits.
 func (f FieldDescriptor) DataPoint() uint32 {
-	return (uint32(f) & 0xFFFFFFE0) | ((uint32(f) >> 28) & 0xF) << 1
+	if f.Extended() {
+		id := uint32(f.SchemaIdx())<<13 | uint32(f.FieldIdx())
+		return id<<5 | uint32(f.DataType())<<1
+	}
+	return (uint32(f) & 0xFFFFFFE0) | ((uint32(f)>>28)&0xF)<<1
 }
 
-// FieldDescriptorFromDataPoint recovers a FieldDescriptor from a DataPoint.
+// FieldDescriptorFromDataPoint recovers a compact FieldDescriptor from a
+// DataPoint. Extended DataPoints carry only the field's location; use
+// CompiledSchema.KeyDescriptor to recover an extended descriptor.
 func FieldDescriptorFromDataPoint(dp uint32) FieldDescriptor {
 	return FieldDescriptor((dp & 0xFFFFFFE0) | ((dp>>1)&0xF)<<28)
 }
@@ -238,9 +326,14 @@ type SchemaMeta struct {
 
 const (
 	AddrBits         = 27
-	SingleStepRegion = 1 << 14                        // [1, 2^14) — single-step paths (0 reserved)
-	MultiStepBase    = SingleStepRegion               // 2^14
+	SingleStepRegion = 1 << 14                         // [1, 2^14) — single-step paths (0 reserved)
+	MultiStepBase    = SingleStepRegion                // 2^14
 	MultiStepSize    = (1 << AddrBits) - MultiStepBase // 2^27 - 2^14
+
+	// ExtendedAddrBits is the address width of extended-layout schemas, whose
+	// keys carry the address bits above AddrBits in their descriptor half.
+	ExtendedAddrBits      = 32
+	ExtendedMultiStepSize = (1 << ExtendedAddrBits) - MultiStepBase // 2^32 - 2^14
 )
 
 // =============================================================================
@@ -254,7 +347,7 @@ type CompiledSchema struct {
 	SchemasMeta []SchemaMeta
 	Defaults    *container.DataContainer
 	Enums       *container.DataContainer // keyed by DataPoint; value is []string (string enum), []int64 (int enum), or []any (complex enum)
-	Variants    map[uint32][]uint8 // keyed by DataPoint; variant schema slot indices for union/composite fields
+	Variants    map[uint32][]uint16      // keyed by DataPoint; variant schema slot indices for union/composite fields
 	Constraints []ResolvedConstraint
 	Indexes     map[IndexID]Index
 
@@ -302,20 +395,28 @@ type CompiledSchema struct {
 	// so downstream readers can render a value's path with a single map lookup
 	// instead of re-joining the steps every time.
 	nameByAddr map[uint32]string
+
+	// data is the v1.2 payload a byte-backed view was built from by
+	// NewCompiledSchemaFromBytes, and nil for schemas built by Link. The hot
+	// sections (Schemas, Descriptors, LocalOffsets, FieldTypes) alias it; the
+	// side tables stay encoded in its cold trailer until LoadColdTrailer.
+	data     []byte
+	coldOnce sync.Once
+	coldErr  error
 }
 
 // =============================================================================
 // RESOLVED PATH
 // =============================================================================
 
-type ResolvedStep uint16
+type ResolvedStep uint32
 
-func NewResolvedStep(schemaIdx, fieldIdx uint8) ResolvedStep {
-	return ResolvedStep(uint16(schemaIdx)<<8 | uint16(fieldIdx))
+func NewResolvedStep(schemaIdx, fieldIdx uint16) ResolvedStep {
+	return ResolvedStep(uint32(schemaIdx)<<16 | uint32(fieldIdx))
 }
 
-func (r ResolvedStep) SchemaIdx() uint8 { return uint8(r >> 8) }
-func (r ResolvedStep) FieldIdx() uint8  { return uint8(r & 0xFF) }
+func (r ResolvedStep) SchemaIdx() uint16 { return uint16(r >> 16) }
+func (r ResolvedStep) FieldIdx() uint16  { return uint16(r) }
 
 type ResolvedPath []ResolvedStep
 
@@ -326,12 +427,12 @@ func (p ResolvedPath) PathKey() string {
 	if len(p) == 0 {
 		return ""
 	}
-	var buf [128]byte
-	for i, step := range p {
-		buf[i*2] = step.SchemaIdx()
-		buf[i*2+1] = step.FieldIdx()
+	var stack [128]byte
+	buf := stack[:0]
+	for _, step := range p {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(step))
 	}
-	return string(buf[:len(p)*2])
+	return string(buf)
 }
 
 // addrCacheEntry pairs an owned ResolvedPath with its memoized address. The
@@ -167,3 +167,40 @@ func FuzzSchemaLink(f *testing.F) {
 		}
 	})
 }
+
+// FuzzCompiledSchemaFromBytes feeds arbitrary payloads to the v1.2 decoder,
+// which must reject them with an error rather than panic. Seeds have their
+// checksum cleared so mutations reach the section parsing.
+func FuzzCompiledSchemaFromBytes(f *testing.F) {
+	for _, src := range []string{
+		`{"name": "test", "version": "1.0.0", "fields": {"f1": {"name": "count", "type": "integer", "default": 3}}}`,
+		`{"name": "root", "version": "1.0.0", "fields": {"f1": {"name": "user", "type": "object", "schema": {"id": "u"}}}, "schemas": {"u": {"name": "u", "fields": {"f2": {"name": "first", "type": "string"}}}}}`,
+	} {
+		var s definition.Schema
+		if err := json.Unmarshal([]byte(src), &s); err != nil {
+			f.Fatal(err)
+		}
+		rs, err := definition.Compile(&s)
+		if err != nil {
+			f.Fatal(err)
+		}
+		cs, err := definition.Link(rs)
+		if err != nil {
+			f.Fatal(err)
+		}
+		data, err := definition.EncodeCompiledSchema(cs, definition.SchemaKey{ID: 1})
+		if err != nil {
+			f.Fatal(err)
+		}
+		data[9], data[10], data[11] = 0, 0, 0
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cs, err := definition.NewCompiledSchemaFromBytes(data)
+		if err != nil {
+			return
+		}
+		_ = cs.LoadColdTrailer()
+	})
+}
@@ -1,7 +1,9 @@
 package definition
 
 import (
+	"errors"
 	"fmt"
+	"math"
 
 	"github.com/asaidimu/go-anansi/v8/core/data/container"
 )
@@ -10,38 +12,74 @@ import (
 // LINK PHASE
 // =============================================================================
 
-const (
-	// maxSchemaSlots bounds the total number of schema slots (including the
-	// root) that Link can produce. SchemaIdx/ChildSchemaIdx are 6-bit fields
-	// in FieldDescriptor, and 0x3F (FdNoChild) is reserved as the "no child"
-	// sentinel, so valid slot indices are [0, 62] — 63 slots total. This is
-	// derived from FdNoChild (compiled.go) so the two can never drift apart.
-	maxSchemaSlots = int(FdNoChild) // 63
-
-	// maxFieldsPerSchema bounds the number of fields any single schema slot
-	// may declare. FieldIdx is a 7-bit field in FieldDescriptor, giving 128
-	// distinct values (0-127). Exceeding this would silently alias field
-	// indices via the &0x7F mask in MakeFieldDescriptor.
-	maxFieldsPerSchema = 128
+// linkLimits are the bounds a descriptor layout places on a linked schema.
+type linkLimits struct {
+	// schemaSlots bounds the total number of schema slots (including the
+	// root). The all-ones ChildSchemaIdx is reserved as the "no child"
+	// sentinel, so valid slot indices stop one short of it.
+	schemaSlots int
+
+	// fieldsPerSchema bounds the number of fields any single schema slot may
+	// declare: every FieldIdx value.
+	fieldsPerSchema int
+
+	// multiStepSize is the size of the multi-step address region.
+	multiStepSize uint64
+}
+
+var (
+	// compactLimits: 6-bit slot indices, 7-bit field indices and 27-bit
+	// addresses.
+	compactLimits = linkLimits{schemaSlots: fdCompactNoChild, fieldsPerSchema: 128, multiStepSize: MultiStepSize}
+
+	// extendedLimits: 14-bit slot indices, 13-bit field indices and 32-bit
+	// addresses.
+	extendedLimits = linkLimits{schemaSlots: fdExtendedNoChild, fieldsPerSchema: 1 << 13, multiStepSize: ExtendedMultiStepSize}
 )
 
+// maxDescriptors bounds the descriptors in one compiled schema in either
+// layout: SchemaSlot.FieldStart is 16 bits wide.
+const maxDescriptors = math.MaxUint16
+
+// errCompactLimit reports that a schema exceeds a compactLimits bound. Link
+// never returns it: it relinks the schema with the extended layout instead.
+var errCompactLimit = errors.New("schema exceeds the compact descriptor layout")
+
+// Link flattens rs into a CompiledSchema. Schemas that fit the compact
+// descriptor layout get it; larger ones are linked again with the extended
+// layout, which lifts the slot, field and address limits at the cost of wider
+// keys in the descriptor half.
 func Link(rs *ResolvedSchema) (*CompiledSchema, error) {
 	if rs == nil {
 		return nil, fmt.Errorf("cannot link a nil ResolvedSchema")
 	}
+	cs, err := link(rs, false)
+	if errors.Is(err, errCompactLimit) {
+		return link(rs, true)
+	}
+	return cs, err
+}
 
+func link(rs *ResolvedSchema, extended bool) (*CompiledSchema, error) {
 	defaults := container.NewDataContainer()
 
 	lc := &linkContext{
 		schemas:             make([]SchemaSlot, 0, 16),
 		schemasMeta:         make([]SchemaMeta, 0, 16),
-		slots:               make(map[*ResolvedNestedSchema][]uint8),
+		slots:               make(map[*ResolvedNestedSchema][]uint16),
 		defaults:            defaults,
 		enums:               container.NewDataContainer(),
-		variants:            make(map[uint32][]uint8),
+		variants:            make(map[uint32][]uint16),
 		schemaConstraints:   make([]SchemaConstraint, 0, 16),
 		fieldRefConstraints: make(map[uint32]SchemaConstraint),
 		rs:                  rs,
+		extended:            extended,
+		limits:              compactLimits,
+		makeDescriptor:      MakeFieldDescriptor,
+	}
+	if extended {
+		lc.limits = extendedLimits
+		lc.makeDescriptor = MakeExtendedFieldDescriptor
 	}
 
 	// Root schema slot 0. No SchemaId: the root is identified by the
@@ -62,39 +100,44 @@ func Link(rs *ResolvedSchema) (*CompiledSchema, error) {
 	}
 
 	// Defensive invariant: assignSlot() already rejects any request that
-	// would push the slot count past maxSchemaSlots, so this should be
+	// would push the slot count past the layout's limit, so this should be
 	// unreachable. Kept as a guard against internal bugs (e.g. a future
 	// code path that appends to lc.schemas without going through assignSlot).
-	if len(lc.schemas) > maxSchemaSlots {
-		return nil, fmt.Errorf("compiled schema exceeds maximum of %d nested schemas (got %d): reduce schema nesting or inline complexity", maxSchemaSlots, len(lc.schemas))
+	if len(lc.schemas) > lc.limits.schemaSlots {
+		return nil, lc.limitErr("compiled schema exceeds maximum of %d nested schemas (got %d): reduce schema nesting or inline complexity", lc.limits.schemaSlots, len(lc.schemas))
 	}
 
-	// Compute footprints bottom-up (schemas are indexed DFS: parent before child).
+	// Compute footprints bottom-up (schemas are indexed DFS: parent before
+	// child). Sums are taken in 64 bits so an oversized tree is reported
+	// rather than wrapped.
 	for i := len(lc.schemas) - 1; i >= 0; i-- {
 		slot := &lc.schemas[i]
-		var fp uint32
+		var fp uint64
 		for j := uint16(0); j < slot.FieldCount; j++ {
 			fd := lc.descriptors[slot.FieldStart+j]
 			if fd.Terminal() {
 				fp++
 			} else if fd.ChildSchemaIdx() != FdNoChild {
-				fp += lc.schemas[fd.ChildSchemaIdx()].Footprint
+				fp += uint64(lc.schemas[fd.ChildSchemaIdx()].Footprint)
 			}
 		}
-		slot.Footprint = fp
+		if fp > lc.limits.multiStepSize {
+			return nil, lc.limitErr("schema tree too large: schema slot %d needs %d address slots but multi-step region has %d", i, fp, lc.limits.multiStepSize)
+		}
+		slot.Footprint = uint32(fp)
 	}
 
 	// Validate root's non-terminal children fit in the multi-step region.
-	var rootFP uint32
+	var rootFP uint64
 	rootSlot := &lc.schemas[0]
 	for j := uint16(0); j < rootSlot.FieldCount; j++ {
 		fd := lc.descriptors[rootSlot.FieldStart+j]
 		if !fd.Terminal() && fd.ChildSchemaIdx() != FdNoChild {
-			rootFP += lc.schemas[fd.ChildSchemaIdx()].Footprint
+			rootFP += uint64(lc.schemas[fd.ChildSchemaIdx()].Footprint)
 		}
 	}
-	if rootFP > MultiStepSize {
-		return nil, fmt.Errorf("schema tree too large: need %d address slots but multi-step region has %d", rootFP, MultiStepSize)
+	if rootFP > lc.limits.multiStepSize {
+		return nil, lc.limitErr("schema tree too large: need %d address slots but multi-step region has %d", rootFP, lc.limits.multiStepSize)
 	}
 
 	// Compute LocalOffsets: the prefix-sum offset of each descriptor within its
@@ -144,25 +187,38 @@ type linkContext struct {
 	fieldTypes  []FieldType
 	schemas     []SchemaSlot
 	schemasMeta []SchemaMeta
-	slots       map[*ResolvedNestedSchema][]uint8
+	slots       map[*ResolvedNestedSchema][]uint16
 	defaults    *container.DataContainer
 	enums       *container.DataContainer
-	variants    map[uint32][]uint8
+	variants    map[uint32][]uint16
 
 	schemaConstraints   []SchemaConstraint          // per slot
 	fieldRefConstraints map[uint32]SchemaConstraint // keyed by DataPoint
 	rs                  *ResolvedSchema
+
+	extended       bool
+	limits         linkLimits
+	makeDescriptor func(dt container.DataType, kind FieldKind, schemaIdx, fieldIdx uint16, required, hasDefault, deprecated, unique, terminal, nullable, recursive bool, childSchemaIdx uint16) FieldDescriptor
+}
+
+// limitErr reports a schema that exceeds lc.limits: errCompactLimit while
+// linking compactly, so Link retries with the extended layout, and the
+// formatted error once the extended limits are exceeded too.
+func (lc *linkContext) limitErr(format string, args ...any) error {
+	if !lc.extended {
+		return errCompactLimit
+	}
+	return fmt.Errorf(format, args...)
 }
 
 // assignSlot allocates a new schema slot for rns. It rejects the request
-// once the slot count reaches maxSchemaSlots, before the next index would
-// collide with the FdNoChild sentinel (0x3F) or, in extreme cases, before
-// the uint8 conversion below could silently wrap around.
-func (lc *linkContext) assignSlot(rns *ResolvedNestedSchema) (uint8, error) {
-	if len(lc.schemas) >= maxSchemaSlots {
-		return 0, fmt.Errorf("schema definition exceeds maximum of %d nested schemas while assigning a slot for %q: reduce schema nesting or inline complexity", maxSchemaSlots, rns.Name)
-	}
-	idx := uint8(len(lc.schemas))
+// once the slot count reaches the layout's limit, before the next index would
+// collide with the no-child sentinel.
+func (lc *linkContext) assignSlot(rns *ResolvedNestedSchema) (uint16, error) {
+	if len(lc.schemas) >= lc.limits.schemaSlots {
+		return 0, lc.limitErr("schema definition exceeds maximum of %d nested schemas while assigning a slot for %q: reduce schema nesting or inline complexity", lc.limits.schemaSlots, rns.Name)
+	}
+	idx := uint16(len(lc.schemas))
 	lc.schemas = append(lc.schemas, SchemaSlot{})
 	lc.schemasMeta = append(lc.schemasMeta, SchemaMeta{
 		ID:          rns.ID,
@@ -179,8 +235,9 @@ func (lc *linkContext) assignSlot(rns *ResolvedNestedSchema) (uint8, error) {
 // that has child fields flattened into the CompiledSchema.
 // For recursive fields the field's own schema slot is stored as the child,
 // allowing the graph builder to identify the recursive target.
-// Returns the child slot index, or 0x7F if the field has no flattenable child.
-func (lc *linkContext) childSlotForField(rf *ResolvedField, schemaIdx uint8) (uint8, error) {
+// Returns the child slot index, or FdNoChild if the field has no flattenable
+// child.
+func (lc *linkContext) childSlotForField(rf *ResolvedField, schemaIdx uint16) (uint16, error) {
 	switch {
 	case rf.Recursive != nil:
 		return schemaIdx, nil
@@ -192,12 +249,15 @@ func (lc *linkContext) childSlotForField(rf *ResolvedField, schemaIdx uint8) (ui
 	case rf.Container != nil && rf.Container.ItemSchema != nil:
 		return lc.assignSlot(rf.Container.ItemSchema)
 	}
-	return 0x7F, nil
+	return FdNoChild, nil
 }
 
-func (lc *linkContext) linkFields(fields []ResolvedField, schemaIdx uint8) (uint16, error) {
-	if len(fields) > maxFieldsPerSchema {
-		return 0, fmt.Errorf("schema slot %d declares %d fields, exceeds maximum of %d fields per schema (FieldIdx is a 7-bit descriptor field)", schemaIdx, len(fields), maxFieldsPerSchema)
+func (lc *linkContext) linkFields(fields []ResolvedField, schemaIdx uint16) (uint16, error) {
+	if len(fields) > lc.limits.fieldsPerSchema {
+		return 0, lc.limitErr("schema slot %d declares %d fields, exceeds maximum of %d fields per schema", schemaIdx, len(fields), lc.limits.fieldsPerSchema)
+	}
+	if len(lc.descriptors)+len(fields) > maxDescriptors {
+		return 0, fmt.Errorf("compiled schema exceeds maximum of %d fields across all nested schemas: reduce schema nesting or inline complexity", maxDescriptors)
 	}
 
 	// Descriptors must be laid out grouped per schema: a schema's own fields are
@@ -212,7 +272,7 @@ func (lc *linkContext) linkFields(fields []ResolvedField, schemaIdx uint8) (uint
 	type childWork struct {
 		rf       *ResolvedField
 		fd       FieldDescriptor
-		childIdx uint8
+		childIdx uint16
 	}
 	var children []childWork
 
@@ -226,8 +286,8 @@ func (lc *linkContext) linkFields(fields []ResolvedField, schemaIdx uint8) (uint
 		}
 		hasDefault := !rf.Default.IsZero()
 
-		fd := MakeFieldDescriptor(
-			dt, kind, schemaIdx, uint8(i),
+		fd := lc.makeDescriptor(
+			dt, kind, schemaIdx, uint16(i),
 			rf.Required, hasDefault, rf.Deprecated, rf.Unique, terminal, rf.Nullable, rf.Recursive != nil,
 			childSchemaIdx,
 		)
@@ -280,7 +340,7 @@ func (lc *linkContext) linkFields(fields []ResolvedField, schemaIdx uint8) (uint
 	return uint16(len(fields)), nil
 }
 
-func (lc *linkContext) linkChildFields(rf *ResolvedField, fd FieldDescriptor, childIdx uint8) error {
+func (lc *linkContext) linkChildFields(rf *ResolvedField, fd FieldDescriptor, childIdx uint16) error {
 	switch {
 	case rf.Object != nil && rf.Recursive == nil:
 		childStart := uint16(len(lc.descriptors))
@@ -305,7 +365,7 @@ func (lc *linkContext) linkChildFields(rf *ResolvedField, fd FieldDescriptor, ch
 		}
 
 	case rf.Union != nil:
-		var variantSlots []uint8
+		var variantSlots []uint16
 		for _, variant := range rf.Union.Variants {
 			childIdx, err := lc.assignSlot(variant)
 			if err != nil {
@@ -340,7 +400,7 @@ func (lc *linkContext) linkChildFields(rf *ResolvedField, fd FieldDescriptor, ch
 			FieldStart: childStart,
 			FieldCount: childCount,
 		}
-		lc.variants[fd.DataPoint()] = []uint8{childIdx}
+		lc.variants[fd.DataPoint()] = []uint16{childIdx}
 	}
 
 	return nil
@@ -525,7 +585,7 @@ func containerDataType(itemSchema *ResolvedNestedSchema, itemType FieldType) con
 func setEnumValues(doc *container.DataContainer, fd FieldDescriptor, re *ResolvedEnum) error {
 	// Extract the 27-bit field ID from the field descriptor's DataPoint.
 	dp := fd.DataPoint()
-	id := int32(dp) >> 5 // bits 5-31
+	id := int32(dp >> 5) // bits 5-31
 
 	// Store based on enum type. ExpectNumeric=true means the values are int64;
 	// otherwise they're strings (or complex/mixed).
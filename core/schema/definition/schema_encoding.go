@@ -0,0 +1,636 @@
+package definition
+
+// Anansi Schema Binary Encoding v1.2 (todo/schema_encoding.md).
+//
+// Unlike SerializeCompiledSchema, which is a sequential cache format that
+// must be decoded in full, the v1.2 encoding is laid out for random access:
+// a 144-byte header (two big-endian words and a little-endian navigation
+// directory) locates every section, so a view built by
+// NewCompiledSchemaFromBytes can alias the fixed-width hot sections straight
+// out of the payload and leave the cold trailer encoded until it is needed.
+//
+// Where the specification leaves room, this implementation takes these
+// positions:
+//
+//   - Payloads are written little-endian with 16-bit string lengths; 32-bit
+//     lengths (the Offset Width flag) are used only when a string needs them.
+//   - The payload length is padded to a multiple of 8 so payloads stored back
+//     to back (as in a bits.Registry) keep every section naturally aligned.
+//   - The checksum is CRC-24 (RFC 4880) over the bytes after the header.
+//   - Section 5 records are 8 bytes, the width of FieldDescriptor, so the
+//     section stays directly castable now that extended-layout descriptors
+//     exist. Compact descriptors leave the upper four bytes zero.
+//   - A metadata record whose ID is not a canonical UUID sets bit 48 of its
+//     Offsets word and stores the ID in the string table, its offset in the
+//     first four bytes of the ID.
+//   - Field and schema Metadata maps, which the metadata records have no
+//     room for, live in an extension section whose offset takes the first
+//     reserved navigation slot (byte 72); it is absent when no map is set.
+//   - The cold trailer is the side-table layout SerializeCompiledSchema
+//     already uses.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unsafe"
+
+	"github.com/google/uuid"
+
+	"github.com/asaidimu/go-anansi/v8/core/bits"
+	"github.com/asaidimu/go-anansi/v8/core/common"
+)
+
+// ErrInvalidCompiledSchema is returned when a payload is not a well-formed
+// v1.2 compiled schema encoding.
+var ErrInvalidCompiledSchema = common.NewSystemError("ERR_SCHEMA_INVALID_COMPILED_ENCODING", "invalid compiled schema encoding")
+
+const (
+	// CompiledSchemaHeaderSize is the fixed size of the v1.2 header: Word 0,
+	// Word 1 and the 128-byte navigation directory.
+	CompiledSchemaHeaderSize = 144
+
+	// MaxCompiledSchemaSize is the largest payload Word 0's 24-bit length can
+	// describe.
+	MaxCompiledSchemaSize = 1<<24 - 1
+
+	// compiledSchemaHandleBits is the length width of a v1.2 Word 0, which is
+	// laid out exactly as a bits.Handle with this spec.
+	compiledSchemaHandleBits = 24
+
+	schemaHeaderExtension = 1 // Words 0 + 1
+
+	schemaFlagBigEndian   = 1 << 0
+	schemaFlagInterned    = 1 << 1
+	schemaFlagWideOffsets = 1 << 2
+	schemaFlagColdTrailer = 1 << 3
+
+	navStringTable  = 16
+	navSchemas      = 24
+	navDescriptors  = 32
+	navLocalOffsets = 40
+	navFieldTypes   = 48
+	navMetadata     = 56
+	navColdTrailer  = 64
+	navMetadataMaps = 72
+
+	schemaSlotSize      = 8
+	descriptorSize      = 8
+	metaRecordSize      = 24
+	metaFlagInternedID  = uint64(1) << 48
+	metaStringOffsetMax = 1<<24 - 1
+)
+
+// SchemaKey identifies one compiled schema version: a schema family (ID),
+// a version within it and an epoch that extends the version space to 1024.
+// It is the ID block of a v1.2 payload's Word 0.
+type SchemaKey struct {
+	ID      uint8
+	Version uint8
+	Epoch   uint8 // 0–3
+}
+
+// Hash64 packs the key as Word 0's ID block stores it.
+func (k SchemaKey) Hash64() uint64 {
+	return uint64(k.ID) | uint64(k.Version)<<8 | uint64(k.Epoch&0x3)<<16
+}
+
+// String renders the key as id.version.epoch.
+func (k SchemaKey) String() string {
+	return fmt.Sprintf("%d.%d.%d", k.ID, k.Version, k.Epoch)
+}
+
+// CompiledSchemaRegistry stores v1.2 payloads back to back and serves them as
+// zero-copy CompiledSchema views.
+type CompiledSchemaRegistry = bits.Registry[SchemaKey, *CompiledSchema]
+
+// NewCompiledSchemaRegistry returns an empty registry for v1.2 payloads. Its
+// handles use Word 0's layout, and each stored payload's Word 0 is stamped
+// with the handle the registry assigns it. A payload that fails to decode is
+// served as a nil view.
+func NewCompiledSchemaRegistry() *CompiledSchemaRegistry {
+	spec, _ := bits.NewHandleSpec(compiledSchemaHandleBits)
+	return bits.NewRegistry(bits.Config[SchemaKey, *CompiledSchema]{
+		HandleSpec: spec,
+		HashKey:    SchemaKey.Hash64,
+		NewView: func(_ bits.Handle, data []byte) *CompiledSchema {
+			cs, err := NewCompiledSchemaFromBytes(data)
+			if err != nil {
+				return nil
+			}
+			return cs
+		},
+		OnWrite: stampSchemaHandle,
+	})
+}
+
+// stampSchemaHandle writes the registry's handle into Word 0, keeping the
+// compression and header-extension bits the encoder set. Payloads whose
+// Word 0 already matches are passed through uncopied.
+func stampSchemaHandle(h bits.Handle, _ uint, data []byte) (bits.Handle, []byte) {
+	if len(data) < 8 {
+		return h, data
+	}
+	const handleMask = uint64(1)<<58 - 1
+	word0 := binary.BigEndian.Uint64(data)
+	stamped := uint64(h)&handleMask | word0&^handleMask
+	if stamped == word0 {
+		return h, data
+	}
+	out := append([]byte(nil), data...)
+	binary.BigEndian.PutUint64(out, stamped)
+	return h, out
+}
+
+// =============================================================================
+// ENCODER
+// =============================================================================
+
+// EncodeCompiledSchema encodes cs in the v1.2 layout under key. Path, Parts
+// and Default of the field metadata are not stored: the first two are
+// derived from the field name on decode, and defaults travel in the cold
+// trailer's Defaults container.
+func EncodeCompiledSchema(cs *CompiledSchema, key SchemaKey) ([]byte, error) {
+	if cs == nil {
+		return nil, fmt.Errorf("compiled schema encode: nil CompiledSchema")
+	}
+	if err := cs.LoadColdTrailer(); err != nil {
+		return nil, err
+	}
+	if len(cs.LocalOffsets) != len(cs.Descriptors) || len(cs.FieldTypes) != len(cs.Descriptors) ||
+		len(cs.FieldsMeta) != len(cs.Descriptors) || len(cs.SchemasMeta) != len(cs.Schemas) {
+		return nil, fmt.Errorf("compiled schema encode: field and schema tables are not parallel")
+	}
+
+	strs := newStringTable(cs)
+	flags := uint8(schemaFlagInterned)
+	if strs.wide {
+		flags |= schemaFlagWideOffsets
+	}
+
+	buf := make([]byte, CompiledSchemaHeaderSize, CompiledSchemaHeaderSize+strs.size()+len(cs.Descriptors)*(descriptorSize+4+1+metaRecordSize)+len(cs.Schemas)*(schemaSlotSize+metaRecordSize))
+	le := binary.LittleEndian
+	nav := func(at int) { le.PutUint64(buf[at:], uint64(len(buf))) }
+	align := func(n int) {
+		for len(buf)%n != 0 {
+			buf = append(buf, 0)
+		}
+	}
+
+	nav(navStringTable)
+	buf = strs.appendTo(buf)
+
+	align(8)
+	nav(navSchemas)
+	for _, s := range cs.Schemas {
+		buf = le.AppendUint16(buf, s.FieldStart)
+		buf = le.AppendUint16(buf, s.FieldCount)
+		buf = le.AppendUint32(buf, s.Footprint)
+	}
+
+	nav(navDescriptors)
+	for _, fd := range cs.Descriptors {
+		buf = le.AppendUint64(buf, uint64(fd))
+	}
+
+	nav(navLocalOffsets)
+	for _, off := range cs.LocalOffsets {
+		buf = le.AppendUint32(buf, off)
+	}
+
+	nav(navFieldTypes)
+	for _, ft := range cs.FieldTypes {
+		buf = append(buf, byte(ft))
+	}
+
+	align(8)
+	nav(navMetadata)
+	for _, fm := range cs.FieldsMeta {
+		buf = appendMetaRecord(buf, strs, fm.ID, fm.Name, fm.Description)
+	}
+	for _, sm := range cs.SchemasMeta {
+		buf = appendMetaRecord(buf, strs, string(sm.ID), sm.Name, sm.Description)
+	}
+
+	if hasMetadataMaps(cs) {
+		nav(navMetadataMaps)
+		w := newWriter()
+		for _, fm := range cs.FieldsMeta {
+			if err := encodeAnyMap(w, fm.Metadata); err != nil {
+				return nil, fmt.Errorf("compiled schema encode: field meta metadata: %w", err)
+			}
+		}
+		for _, sm := range cs.SchemasMeta {
+			if err := encodeAnyMap(w, sm.Metadata); err != nil {
+				return nil, fmt.Errorf("compiled schema encode: schema meta metadata: %w", err)
+			}
+		}
+		buf = append(buf, w.bytes()...)
+	}
+
+	flags |= schemaFlagColdTrailer
+	nav(navColdTrailer)
+	w := newWriter()
+	if err := encodeColdTrailer(w, cs); err != nil {
+		return nil, err
+	}
+	buf = append(buf, w.bytes()...)
+	align(8)
+
+	if len(buf) > MaxCompiledSchemaSize {
+		return nil, fmt.Errorf("compiled schema encode: payload of %d bytes exceeds the %d-byte limit", len(buf), MaxCompiledSchemaSize)
+	}
+
+	idBlock := key.Hash64() | schemaHeaderExtension<<19
+	binary.BigEndian.PutUint64(buf[0:], idBlock<<40|uint64(len(buf))<<16)
+	binary.BigEndian.PutUint64(buf[8:], uint64(crc24(buf[CompiledSchemaHeaderSize:]))<<32|uint64(flags))
+	return buf, nil
+}
+
+func hasMetadataMaps(cs *CompiledSchema) bool {
+	for _, fm := range cs.FieldsMeta {
+		if len(fm.Metadata) > 0 {
+			return true
+		}
+	}
+	for _, sm := range cs.SchemasMeta {
+		if len(sm.Metadata) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// appendMetaRecord appends one 24-byte FieldMeta/SchemaMeta record.
+func appendMetaRecord(buf []byte, strs *stringTable, id, name, desc string) []byte {
+	var raw [16]byte
+	offsets := uint64(strs.offset(name)) | uint64(strs.offset(desc))<<24
+	if id != "" {
+		if u, err := uuid.Parse(id); err == nil && u != uuid.Nil && u.String() == id {
+			raw = u
+		} else {
+			binary.LittleEndian.PutUint32(raw[:], strs.offset(id))
+			offsets |= metaFlagInternedID
+		}
+	}
+	buf = append(buf, raw[:]...)
+	return binary.LittleEndian.AppendUint64(buf, offsets)
+}
+
+// stringTable interns the names, descriptions and non-UUID IDs the metadata
+// records reference. Entry 0 is the empty string.
+type stringTable struct {
+	offsets map[string]uint32
+	order   []string
+	wide    bool
+	end     uint32
+}
+
+func newStringTable(cs *CompiledSchema) *stringTable {
+	t := &stringTable{offsets: map[string]uint32{}}
+	var all []string
+	for _, fm := range cs.FieldsMeta {
+		all = append(all, fm.ID, fm.Name, fm.Description)
+	}
+	for _, sm := range cs.SchemasMeta {
+		all = append(all, string(sm.ID), sm.Name, sm.Description)
+	}
+	for _, s := range all {
+		if len(s) > 0xFFFF {
+			t.wide = true
+		}
+	}
+	t.add("")
+	for _, s := range all {
+		t.add(s)
+	}
+	return t
+}
+
+func (t *stringTable) prefix() uint32 {
+	if t.wide {
+		return 4
+	}
+	return 2
+}
+
+func (t *stringTable) add(s string) {
+	if _, ok := t.offsets[s]; ok {
+		return
+	}
+	t.offsets[s] = t.end
+	t.order = append(t.order, s)
+	t.end += t.prefix() + uint32(len(s))
+}
+
+// offset returns the string's table offset. Offsets past the 24-bit record
+// fields cannot occur: the encoder rejects payloads over 16 MiB.
+func (t *stringTable) offset(s string) uint32 { return t.offsets[s] & metaStringOffsetMax }
+
+func (t *stringTable) size() int { return int(t.end) }
+
+func (t *stringTable) appendTo(buf []byte) []byte {
+	for _, s := range t.order {
+		if t.wide {
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(len(s)))
+		} else {
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(len(s)))
+		}
+		buf = append(buf, s...)
+	}
+	return buf
+}
+
+// =============================================================================
+// DECODER
+// =============================================================================
+
+// schemaLayout is the header of a v1.2 payload, validated against its length.
+type schemaLayout struct {
+	key   SchemaKey
+	flags uint8
+	order binary.ByteOrder
+
+	stringTable, schemas, descriptors, localOffsets, fieldTypes, metadata, metadataMaps, coldTrailer int
+	end                                                                                              int
+
+	slotCount, fieldCount int
+}
+
+func invalidCompiledSchema(format string, args ...any) error {
+	return ErrInvalidCompiledSchema.WithMessagef("compiled schema decode: "+format, args...)
+}
+
+func parseSchemaLayout(data []byte) (*schemaLayout, error) {
+	if len(data) < CompiledSchemaHeaderSize {
+		return nil, invalidCompiledSchema("payload of %d bytes is shorter than the %d-byte header", len(data), CompiledSchemaHeaderSize)
+	}
+	word0 := binary.BigEndian.Uint64(data)
+	word1 := binary.BigEndian.Uint64(data[8:])
+
+	idBlock := word0 >> 40
+	if ext := idBlock >> 19 & 0x3; ext != schemaHeaderExtension {
+		return nil, invalidCompiledSchema("unsupported header extension %d", ext)
+	}
+	if idBlock>>18&1 != 0 {
+		return nil, invalidCompiledSchema("compressed payloads are not supported")
+	}
+	length := int(word0 >> 16 & MaxCompiledSchemaSize)
+	if length < CompiledSchemaHeaderSize || length > len(data) {
+		return nil, invalidCompiledSchema("declared length %d does not fit the %d-byte payload", length, len(data))
+	}
+
+	l := &schemaLayout{
+		key:   SchemaKey{ID: uint8(idBlock), Version: uint8(idBlock >> 8), Epoch: uint8(idBlock >> 16 & 0x3)},
+		flags: uint8(word1),
+		order: binary.LittleEndian,
+		end:   length,
+	}
+	if l.flags&schemaFlagInterned == 0 {
+		return nil, invalidCompiledSchema("pre-1.2 payload with inline strings")
+	}
+	if l.flags&schemaFlagBigEndian != 0 {
+		l.order = binary.BigEndian
+	}
+	if sum := uint32(word1 >> 32 & 0xFFFFFF); sum != 0 && sum != crc24(data[CompiledSchemaHeaderSize:length]) {
+		return nil, invalidCompiledSchema("checksum mismatch")
+	}
+
+	navAt := func(at int) int {
+		v := binary.LittleEndian.Uint64(data[at:])
+		if v > uint64(length) {
+			return -1
+		}
+		return int(v)
+	}
+	l.stringTable = navAt(navStringTable)
+	l.schemas = navAt(navSchemas)
+	l.descriptors = navAt(navDescriptors)
+	l.localOffsets = navAt(navLocalOffsets)
+	l.fieldTypes = navAt(navFieldTypes)
+	l.metadata = navAt(navMetadata)
+	l.metadataMaps = navAt(navMetadataMaps)
+	l.coldTrailer = navAt(navColdTrailer)
+	for _, off := range []int{l.stringTable, l.schemas, l.descriptors, l.localOffsets, l.fieldTypes, l.metadata, l.metadataMaps, l.coldTrailer} {
+		if off < 0 || (off > 0 && off < CompiledSchemaHeaderSize) {
+			return nil, invalidCompiledSchema("section offset out of range")
+		}
+	}
+	if l.stringTable == 0 || l.schemas < l.stringTable || l.descriptors < l.schemas || l.localOffsets < l.descriptors {
+		return nil, invalidCompiledSchema("sections out of order")
+	}
+
+	l.slotCount = (l.descriptors - l.schemas) / schemaSlotSize
+	l.fieldCount = (l.localOffsets - l.descriptors) / descriptorSize
+	if l.fieldTypes < l.localOffsets+4*l.fieldCount ||
+		l.metadata < l.fieldTypes+l.fieldCount ||
+		l.metadata+metaRecordSize*(l.fieldCount+l.slotCount) > length {
+		return nil, invalidCompiledSchema("section sizes do not match %d fields and %d schema slots", l.fieldCount, l.slotCount)
+	}
+	return l, nil
+}
+
+// NewCompiledSchemaFromBytes returns a view of a payload produced by
+// EncodeCompiledSchema. Schemas, Descriptors, LocalOffsets and FieldTypes
+// alias data whenever its byte order and alignment allow, so data must not be
+// modified while the view is in use, and the view's hot sections must be
+// treated as read-only. FieldsMeta and SchemasMeta are decoded from the
+// string table up front; the remaining side tables stay encoded until
+// LoadColdTrailer.
+func NewCompiledSchemaFromBytes(data []byte) (*CompiledSchema, error) {
+	l, err := parseSchemaLayout(data)
+	if err != nil {
+		return nil, err
+	}
+	data = data[:l.end]
+
+	cs := &CompiledSchema{data: data}
+	cs.Schemas = viewSection[SchemaSlot](data, l, l.schemas, l.slotCount, func(b []byte) SchemaSlot {
+		return SchemaSlot{FieldStart: l.order.Uint16(b), FieldCount: l.order.Uint16(b[2:]), Footprint: l.order.Uint32(b[4:])}
+	})
+	cs.Descriptors = viewSection[FieldDescriptor](data, l, l.descriptors, l.fieldCount, func(b []byte) FieldDescriptor {
+		return FieldDescriptor(l.order.Uint64(b))
+	})
+	cs.LocalOffsets = viewSection[uint32](data, l, l.localOffsets, l.fieldCount, l.order.Uint32)
+	cs.FieldTypes = viewSection[FieldType](data, l, l.fieldTypes, l.fieldCount, func(b []byte) FieldType { return FieldType(b[0]) })
+	for _, s := range cs.Schemas {
+		if int(s.FieldStart)+int(s.FieldCount) > l.fieldCount {
+			return nil, invalidCompiledSchema("schema slot fields out of range")
+		}
+	}
+
+	if err := cs.decodeMetadata(l); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// hostByteOrder is binary.LittleEndian or binary.BigEndian, whichever this
+// host uses, so payloads in host order can be aliased in place.
+var hostByteOrder binary.ByteOrder = func() binary.ByteOrder {
+	if binary.NativeEndian.Uint16([]byte{1, 0}) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+// viewSection returns n fixed-width records of T starting at off. Little-endian
+// payloads on little-endian hosts are aliased in place when off is suitably
+// aligned; any other payload is decoded record by record.
+func viewSection[T any](data []byte, l *schemaLayout, off, n int, decode func([]byte) T) []T {
+	if n == 0 {
+		return []T{}
+	}
+	var zero T
+	size := int(unsafe.Sizeof(zero))
+	if l.order == hostByteOrder && uintptr(unsafe.Pointer(&data[off]))%unsafe.Alignof(zero) == 0 {
+		return unsafe.Slice((*T)(unsafe.Pointer(&data[off])), n)
+	}
+	out := make([]T, n)
+	for i := range out {
+		out[i] = decode(data[off+i*size:])
+	}
+	return out
+}
+
+// decodeMetadata materializes FieldsMeta and SchemasMeta from the metadata
+// records, the string table and, when present, the metadata map extension.
+func (cs *CompiledSchema) decodeMetadata(l *schemaLayout) error {
+	strs := map[uint32]string{}
+	str := func(off uint32) (string, error) {
+		if s, ok := strs[off]; ok {
+			return s, nil
+		}
+		pos := l.stringTable + int(off)
+		prefix := 2
+		if l.flags&schemaFlagWideOffsets != 0 {
+			prefix = 4
+		}
+		if pos+prefix > l.schemas {
+			return "", invalidCompiledSchema("string offset %d out of range", off)
+		}
+		var n int
+		if prefix == 4 {
+			n = int(l.order.Uint32(cs.data[pos:]))
+		} else {
+			n = int(l.order.Uint16(cs.data[pos:]))
+		}
+		if n < 0 || pos+prefix+n > l.schemas {
+			return "", invalidCompiledSchema("string at offset %d overruns the string table", off)
+		}
+		s := string(cs.data[pos+prefix : pos+prefix+n])
+		strs[off] = s
+		return s, nil
+	}
+	record := func(i int) (id, name, desc string, err error) {
+		rec := cs.data[l.metadata+i*metaRecordSize:]
+		offsets := l.order.Uint64(rec[16:])
+		if name, err = str(uint32(offsets & metaStringOffsetMax)); err != nil {
+			return
+		}
+		if desc, err = str(uint32(offsets >> 24 & metaStringOffsetMax)); err != nil {
+			return
+		}
+		switch {
+		case offsets&metaFlagInternedID != 0:
+			id, err = str(binary.LittleEndian.Uint32(rec))
+		case uuid.UUID(rec[:16]) != uuid.Nil:
+			id = uuid.UUID(rec[:16]).String()
+		}
+		return
+	}
+
+	cs.FieldsMeta = make([]FieldMeta, l.fieldCount)
+	for i := range cs.FieldsMeta {
+		id, name, desc, err := record(i)
+		if err != nil {
+			return err
+		}
+		cs.FieldsMeta[i] = FieldMeta{ID: id, Name: name, Path: name, Parts: []string{name}, Description: desc}
+	}
+	cs.SchemasMeta = make([]SchemaMeta, l.slotCount)
+	for i := range cs.SchemasMeta {
+		id, name, desc, err := record(l.fieldCount + i)
+		if err != nil {
+			return err
+		}
+		cs.SchemasMeta[i] = SchemaMeta{ID: SchemaId(id), Name: name, Description: desc}
+	}
+
+	if l.metadataMaps == 0 {
+		return nil
+	}
+	r := newReader(cs.data[l.metadataMaps:l.end])
+	for i := range cs.FieldsMeta {
+		m, err := decodeAnyMap(r)
+		if err != nil {
+			return invalidCompiledSchema("field metadata: %v", err)
+		}
+		cs.FieldsMeta[i].Metadata = m
+	}
+	for i := range cs.SchemasMeta {
+		m, err := decodeAnyMap(r)
+		if err != nil {
+			return invalidCompiledSchema("schema metadata: %v", err)
+		}
+		cs.SchemasMeta[i].Metadata = m
+	}
+	return nil
+}
+
+// LoadColdTrailer decodes the side tables of a byte-backed view — Defaults,
+// Enums, Variants, Constraints, Indexes, SchemaConstraints and
+// FieldRefConstraints — on first call, and must be called before reading
+// them from a view. Views are otherwise read-only, so it is safe to call
+// concurrently. For schemas built by Link it is a no-op.
+func (cs *CompiledSchema) LoadColdTrailer() error {
+	if cs == nil || cs.data == nil {
+		return nil
+	}
+	cs.coldOnce.Do(func() {
+		l, err := parseSchemaLayout(cs.data)
+		if err != nil {
+			cs.coldErr = err
+			return
+		}
+		if l.flags&schemaFlagColdTrailer == 0 || l.coldTrailer == 0 {
+			return
+		}
+		if err := decodeColdTrailer(newReader(cs.data[l.coldTrailer:l.end]), cs); err != nil {
+			cs.coldErr = invalidCompiledSchema("cold trailer: %v", err)
+		}
+	})
+	return cs.coldErr
+}
+
+// Bytes returns the v1.2 payload a view was built from, or nil for schemas
+// built by Link. The bytes are shared and must not be modified.
+func (cs *CompiledSchema) Bytes() []byte { return cs.data }
+
+// Key returns the SchemaKey stored in a view's Word 0, and false for schemas
+// built by Link.
+func (cs *CompiledSchema) Key() (SchemaKey, bool) {
+	if cs.data == nil {
+		return SchemaKey{}, false
+	}
+	idBlock := binary.BigEndian.Uint64(cs.data) >> 40
+	return SchemaKey{ID: uint8(idBlock), Version: uint8(idBlock >> 8), Epoch: uint8(idBlock >> 16 & 0x3)}, true
+}
+
+// crc24 is the OpenPGP CRC-24 (RFC 4880, section 6.1).
+func crc24(data []byte) uint32 {
+	const (
+		crc24Init = 0xB704CE
+		crc24Poly = 0x1864CFB
+	)
+	crc := uint32(crc24Init)
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for range 8 {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= crc24Poly
+			}
+		}
+	}
+	return crc & 0xFFFFFF
+}
@@ -59,7 +59,7 @@ import (
 
 const (
 	compiledSchemaMagic               = "ANSC"
-	compiledSchemaFormatVersion uint8 = 4
+	compiledSchemaFormatVersion uint8 = 5
 )
 
 // =============================================================================
@@ -188,6 +188,20 @@ func (r *reader) u32() (uint32, error) {
 	return v, nil
 }
 
+// count reads a u32 element count and rejects counts the remaining bytes
+// cannot hold — every encoded element takes at least one byte — so a corrupt
+// count fails here instead of sizing a huge allocation.
+func (r *reader) count() (uint32, error) {
+	n, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n) > uint64(len(r.data)-r.pos) {
+		return 0, errUnexpectedEOF
+	}
+	return n, nil
+}
+
 func (r *reader) u64() (uint64, error) {
 	if err := r.need(8); err != nil {
 		return 0, err
@@ -222,7 +236,7 @@ func (r *reader) boolean() (bool, error) {
 }
 
 func (r *reader) bytesLP() ([]byte, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -244,7 +258,7 @@ func (r *reader) str() (string, error) {
 }
 
 func (r *reader) strSlice() ([]string, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -260,7 +274,7 @@ func (r *reader) strSlice() ([]string, error) {
 }
 
 func (r *reader) strSliceSlice() ([][]string, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -287,7 +301,7 @@ func encodeInt64Slice(w *writer, v []int64) {
 }
 
 func decodeInt64Slice(r *reader) ([]int64, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -310,7 +324,7 @@ func encodeFloat64Slice(w *writer, v []float64) {
 }
 
 func decodeFloat64Slice(r *reader) ([]float64, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -333,7 +347,7 @@ func encodeBoolSlice(w *writer, v []bool) {
 }
 
 func decodeBoolSlice(r *reader) ([]bool, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -356,7 +370,7 @@ func encodeBytesSlice(w *writer, v [][]byte) {
 }
 
 func decodeBytesSlice(r *reader) ([][]byte, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -381,7 +395,7 @@ func encodeFloat2D(w *writer, v [][]float64) {
 }
 
 func decodeFloat2D(r *reader) ([][]float64, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -406,7 +420,7 @@ func encodeFloat3D(w *writer, v [][][]float64) {
 }
 
 func decodeFloat3D(r *reader) ([][][]float64, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -432,7 +446,7 @@ func encodeDataContainerSlice(w *writer, v []*container.DataContainer) error {
 }
 
 func decodeDataContainerSlice(r *reader) ([]*container.DataContainer, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -603,7 +617,7 @@ func encodeAnySlice(w *writer, v []any) error {
 }
 
 func decodeAnySlice(r *reader) ([]any, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -635,7 +649,7 @@ func encodeAnyMap(w *writer, v map[string]any) error {
 }
 
 func decodeAnyMap(r *reader) (map[string]any, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -1057,7 +1071,7 @@ func decodeResolvedConstraintRule(r *reader) (ResolvedConstraintRule, error) {
 	}
 	rr.Predicate = PredicateName(pred)
 
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return rr, err
 	}
@@ -1102,7 +1116,7 @@ func decodeResolvedConstraintGroup(r *reader) (ResolvedConstraintGroup, error) {
 	}
 	rg.Operator = common.LogicalOperator(opB)
 
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return rg, err
 	}
@@ -1199,7 +1213,7 @@ func encodeConstraintRule(w *writer, cr ConstraintRule) error {
 func decodeConstraintRule(r *reader) (ConstraintRule, error) {
 	var cr ConstraintRule
 
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return cr, err
 	}
@@ -1248,7 +1262,7 @@ func decodeConstraintGroup(r *reader) (ConstraintGroup, error) {
 	}
 	cg.Operator = common.LogicalOperator(opB)
 
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return cg, err
 	}
@@ -1311,7 +1325,7 @@ func encodeSchemaConstraint(w *writer, sc SchemaConstraint) error {
 }
 
 func decodeSchemaConstraint(r *reader) (SchemaConstraint, error) {
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -1393,7 +1407,7 @@ func decodeIndex(r *reader) (Index, error) {
 		idx.Condition = cond
 	}
 
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return idx, err
 	}
@@ -1524,7 +1538,7 @@ func decodeIndexConditionGroup(r *reader) (IndexConditionGroup, error) {
 	}
 	g.Operator = common.LogicalOperator(opB)
 
-	n, err := r.u32()
+	n, err := r.count()
 	if err != nil {
 		return g, err
 	}
@@ -1838,7 +1852,7 @@ func SerializeCompiledSchema(cs *CompiledSchema) ([]byte, error) {
 	// Descriptors
 	w.u32(uint32(len(cs.Descriptors)))
 	for _, fd := range cs.Descriptors {
-		w.u32(uint32(fd))
+		w.u64(uint64(fd))
 	}
 
 	// FieldTypes
@@ -1888,74 +1902,8 @@ func SerializeCompiledSchema(cs *CompiledSchema) ([]byte, error) {
 		}
 	}
 
-	// Defaults / Enums documents
-	if err := encodeDataContainer(w, cs.Defaults); err != nil {
-		return nil, fmt.Errorf("compiled schema serialize: defaults: %w", err)
-	}
-	if err := encodeDataContainer(w, cs.Enums); err != nil {
-		return nil, fmt.Errorf("compiled schema serialize: enums: %w", err)
-	}
-
-	// Variants
-	w.u32(uint32(len(cs.Variants)))
-	variantKeys := make([]uint32, 0, len(cs.Variants))
-	for k := range cs.Variants {
-		variantKeys = append(variantKeys, k)
-	}
-	sort.Slice(variantKeys, func(i, j int) bool { return variantKeys[i] < variantKeys[j] })
-	for _, k := range variantKeys {
-		v := cs.Variants[k]
-		w.u32(k)
-		w.u32(uint32(len(v)))
-		for _, b := range v {
-			w.u8(b)
-		}
-	}
-
-	// Constraints
-	w.u32(uint32(len(cs.Constraints)))
-	for _, rc := range cs.Constraints {
-		if err := encodeResolvedConstraint(w, rc); err != nil {
-			return nil, fmt.Errorf("compiled schema serialize: constraints: %w", err)
-		}
-	}
-
-	// Indexes
-	w.u32(uint32(len(cs.Indexes)))
-	indexKeys := make([]string, 0, len(cs.Indexes))
-	for id := range cs.Indexes {
-		indexKeys = append(indexKeys, string(id))
-	}
-	sort.Strings(indexKeys)
-	for _, id := range indexKeys {
-		idx := cs.Indexes[IndexID(id)]
-		w.str(id)
-		if err := encodeIndex(w, idx); err != nil {
-			return nil, fmt.Errorf("compiled schema serialize: indexes: %w", err)
-		}
-	}
-
-	// SchemaConstraints
-	w.u32(uint32(len(cs.SchemaConstraints)))
-	for _, sc := range cs.SchemaConstraints {
-		if err := encodeSchemaConstraint(w, sc); err != nil {
-			return nil, fmt.Errorf("compiled schema serialize: schema constraints: %w", err)
-		}
-	}
-
-	// FieldRefConstraints
-	w.u32(uint32(len(cs.FieldRefConstraints)))
-	refKeys := make([]uint32, 0, len(cs.FieldRefConstraints))
-	for k := range cs.FieldRefConstraints {
-		refKeys = append(refKeys, k)
-	}
-	sort.Slice(refKeys, func(i, j int) bool { return refKeys[i] < refKeys[j] })
-	for _, k := range refKeys {
-		sc := cs.FieldRefConstraints[k]
-		w.u32(k)
-		if err := encodeSchemaConstraint(w, sc); err != nil {
-			return nil, fmt.Errorf("compiled schema serialize: field ref constraints: %w", err)
-		}
+	if err := encodeColdTrailer(w, cs); err != nil {
+		return nil, err
 	}
 
 	return w.bytes(), nil
@@ -1991,13 +1939,13 @@ func DeserializeCompiledSchema(data []byte) (*CompiledSchema, error) {
 	cs := &CompiledSchema{}
 
 	// Descriptors
-	descCount, err := r.u32()
+	descCount, err := r.count()
 	if err != nil {
 		return nil, err
 	}
 	cs.Descriptors = make([]FieldDescriptor, descCount)
 	for i := range cs.Descriptors {
-		v, err := r.u32()
+		v, err := r.u64()
 		if err != nil {
 			return nil, err
 		}
@@ -2005,7 +1953,7 @@ func DeserializeCompiledSchema(data []byte) (*CompiledSchema, error) {
 	}
 
 	// FieldTypes
-	ftCount, err := r.u32()
+	ftCount, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -2019,7 +1967,7 @@ func DeserializeCompiledSchema(data []byte) (*CompiledSchema, error) {
 	}
 
 	// LocalOffsets
-	loCount, err := r.u32()
+	loCount, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -2033,7 +1981,7 @@ func DeserializeCompiledSchema(data []byte) (*CompiledSchema, error) {
 	}
 
 	// FieldsMeta
-	fmCount, err := r.u32()
+	fmCount, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -2079,7 +2027,7 @@ func DeserializeCompiledSchema(data []byte) (*CompiledSchema, error) {
 	}
 
 	// Schemas
-	slotCount, err := r.u32()
+	slotCount, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -2101,7 +2049,7 @@ func DeserializeCompiledSchema(data []byte) (*CompiledSchema, error) {
 	}
 
 	// SchemasMeta
-	smCount, err := r.u32()
+	smCount, err := r.count()
 	if err != nil {
 		return nil, err
 	}
@@ -2126,40 +2074,127 @@ func DeserializeCompiledSchema(data []byte) (*CompiledSchema, error) {
 		cs.SchemasMeta[i] = SchemaMeta{ID: SchemaId(id), Name: name, Description: desc, Metadata: metadata}
 	}
 
+	if err := decodeColdTrailer(r, cs); err != nil {
+		return nil, err
+	}
+
+	return cs, nil
+}
+
+// encodeColdTrailer writes the side tables of cs — defaults, enums,
+// variants, constraints, indexes and the per-slot and per-field constraint
+// overrides — in the order decodeColdTrailer reads them. Both the cache format
+// and the v1.2 encoding (as its cold trailer) share this layout.
+func encodeColdTrailer(w *writer, cs *CompiledSchema) error {
+	// Defaults / Enums documents
+	if err := encodeDataContainer(w, cs.Defaults); err != nil {
+		return fmt.Errorf("compiled schema serialize: defaults: %w", err)
+	}
+	if err := encodeDataContainer(w, cs.Enums); err != nil {
+		return fmt.Errorf("compiled schema serialize: enums: %w", err)
+	}
+
+	// Variants
+	w.u32(uint32(len(cs.Variants)))
+	variantKeys := make([]uint32, 0, len(cs.Variants))
+	for k := range cs.Variants {
+		variantKeys = append(variantKeys, k)
+	}
+	sort.Slice(variantKeys, func(i, j int) bool { return variantKeys[i] < variantKeys[j] })
+	for _, k := range variantKeys {
+		v := cs.Variants[k]
+		w.u32(k)
+		w.u32(uint32(len(v)))
+		for _, b := range v {
+			w.u16(b)
+		}
+	}
+
+	// Constraints
+	w.u32(uint32(len(cs.Constraints)))
+	for _, rc := range cs.Constraints {
+		if err := encodeResolvedConstraint(w, rc); err != nil {
+			return fmt.Errorf("compiled schema serialize: constraints: %w", err)
+		}
+	}
+
+	// Indexes
+	w.u32(uint32(len(cs.Indexes)))
+	indexKeys := make([]string, 0, len(cs.Indexes))
+	for id := range cs.Indexes {
+		indexKeys = append(indexKeys, string(id))
+	}
+	sort.Strings(indexKeys)
+	for _, id := range indexKeys {
+		idx := cs.Indexes[IndexID(id)]
+		w.str(id)
+		if err := encodeIndex(w, idx); err != nil {
+			return fmt.Errorf("compiled schema serialize: indexes: %w", err)
+		}
+	}
+
+	// SchemaConstraints
+	w.u32(uint32(len(cs.SchemaConstraints)))
+	for _, sc := range cs.SchemaConstraints {
+		if err := encodeSchemaConstraint(w, sc); err != nil {
+			return fmt.Errorf("compiled schema serialize: schema constraints: %w", err)
+		}
+	}
+
+	// FieldRefConstraints
+	w.u32(uint32(len(cs.FieldRefConstraints)))
+	refKeys := make([]uint32, 0, len(cs.FieldRefConstraints))
+	for k := range cs.FieldRefConstraints {
+		refKeys = append(refKeys, k)
+	}
+	sort.Slice(refKeys, func(i, j int) bool { return refKeys[i] < refKeys[j] })
+	for _, k := range refKeys {
+		sc := cs.FieldRefConstraints[k]
+		w.u32(k)
+		if err := encodeSchemaConstraint(w, sc); err != nil {
+			return fmt.Errorf("compiled schema serialize: field ref constraints: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// decodeColdTrailer reads the side tables written by encodeColdTrailer into cs.
+func decodeColdTrailer(r *reader, cs *CompiledSchema) error {
 	// Defaults / Enums documents
 	defaults, err := decodeDataContainer(r)
 	if err != nil {
-		return nil, fmt.Errorf("compiled schema deserialize: defaults: %w", err)
+		return fmt.Errorf("compiled schema deserialize: defaults: %w", err)
 	}
 	cs.Defaults = defaults
 
 	enums, err := decodeDataContainer(r)
 	if err != nil {
-		return nil, fmt.Errorf("compiled schema deserialize: enums: %w", err)
+		return fmt.Errorf("compiled schema deserialize: enums: %w", err)
 	}
 	cs.Enums = enums
 
 	// Variants
-	varCount, err := r.u32()
+	varCount, err := r.count()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if varCount > 0 {
-		cs.Variants = make(map[uint32][]uint8, varCount)
+		cs.Variants = make(map[uint32][]uint16, varCount)
 		for i := uint32(0); i < varCount; i++ {
 			k, err := r.u32()
 			if err != nil {
-				return nil, err
+				return err
 			}
-			n, err := r.u32()
+			n, err := r.count()
 			if err != nil {
-				return nil, err
+				return err
 			}
-			vs := make([]uint8, n)
+			vs := make([]uint16, n)
 			for j := range vs {
-				b, err := r.u8()
+				b, err := r.u16()
 				if err != nil {
-					return nil, err
+					return err
 				}
 				vs[j] = b
 			}
@@ -2168,72 +2203,72 @@ func DeserializeCompiledSchema(data []byte) (*CompiledSchema, error) {
 	}
 
 	// Constraints
-	constrCount, err := r.u32()
+	constrCount, err := r.count()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	cs.Constraints = make([]ResolvedConstraint, constrCount)
 	for i := range cs.Constraints {
 		rc, err := decodeResolvedConstraint(r)
 		if err != nil {
-			return nil, fmt.Errorf("compiled schema deserialize: constraints: %w", err)
+			return fmt.Errorf("compiled schema deserialize: constraints: %w", err)
 		}
 		cs.Constraints[i] = rc
 	}
 
 	// Indexes
-	idxCount, err := r.u32()
+	idxCount, err := r.count()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if idxCount > 0 {
 		cs.Indexes = make(map[IndexID]Index, idxCount)
 		for i := uint32(0); i < idxCount; i++ {
 			idStr, err := r.str()
 			if err != nil {
-				return nil, err
+				return err
 			}
 			idx, err := decodeIndex(r)
 			if err != nil {
-				return nil, fmt.Errorf("compiled schema deserialize: indexes: %w", err)
+				return fmt.Errorf("compiled schema deserialize: indexes: %w", err)
 			}
 			cs.Indexes[IndexID(idStr)] = idx
 		}
 	}
 
 	// SchemaConstraints
-	scCount, err := r.u32()
+	scCount, err := r.count()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	cs.SchemaConstraints = make([]SchemaConstraint, scCount)
 	for i := range cs.SchemaConstraints {
 		sc, err := decodeSchemaConstraint(r)
 		if err != nil {
-			return nil, fmt.Errorf("compiled schema deserialize: schema constraints: %w", err)
+			return fmt.Errorf("compiled schema deserialize: schema constraints: %w", err)
 		}
 		cs.SchemaConstraints[i] = sc
 	}
 
 	// FieldRefConstraints
-	frcCount, err := r.u32()
+	frcCount, err := r.count()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if frcCount > 0 {
 		cs.FieldRefConstraints = make(map[uint32]SchemaConstraint, frcCount)
 		for i := uint32(0); i < frcCount; i++ {
 			k, err := r.u32()
 			if err != nil {
-				return nil, err
+				return err
 			}
 			sc, err := decodeSchemaConstraint(r)
 			if err != nil {
-				return nil, fmt.Errorf("compiled schema deserialize: field ref constraints: %w", err)
+				return fmt.Errorf("compiled schema deserialize: field ref constraints: %w", err)
 			}
 			cs.FieldRefConstraints[k] = sc
 		}
 	}
 
-	return cs, nil
+	return nil
 }
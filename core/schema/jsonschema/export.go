@@ -0,0 +1,397 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+type exporter struct {
+	schema *definition.Schema
+	defs   map[definition.SchemaId]string // $defs key of each nested schema
+}
+
+// Export converts s to a JSON Schema 2020-12 document. The root object
+// describes a document of the collection; nested schemas are emitted under
+// $defs, keyed by name (by id when the name is empty or shared).
+func Export(s *definition.Schema) (map[string]any, error) {
+	if s == nil {
+		return nil, ErrExportFailed.WithMessage("schema is nil").WithOperation("Export")
+	}
+	e := &exporter{schema: s, defs: make(map[definition.SchemaId]string, len(s.Schemas))}
+	e.nameDefs()
+
+	doc := map[string]any{"$schema": Dialect}
+	if s.Name != "" {
+		doc["title"] = s.Name
+	}
+	if s.Version != nil {
+		doc[KeywordVersion] = s.Version.String()
+	}
+	if err := e.object(doc, s.BaseSchema); err != nil {
+		return nil, err
+	}
+
+	if len(s.Schemas) > 0 {
+		defs := make(map[string]any, len(s.Schemas))
+		for id, ns := range s.Schemas {
+			def, err := e.nested(ns)
+			if err != nil {
+				return nil, err
+			}
+			defs[e.defs[id]] = def
+		}
+		doc["$defs"] = defs
+	}
+	return doc, nil
+}
+
+// ExportJSON is Export followed by indented JSON encoding.
+func ExportJSON(s *definition.Schema) ([]byte, error) {
+	doc, err := Export(s)
+	if err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, ErrExportFailed.WithCause(err).WithOperation("ExportJSON")
+	}
+	return append(out, '\n'), nil
+}
+
+// nameDefs assigns $defs keys in name order so collisions resolve the same
+// way on every export.
+func (e *exporter) nameDefs() {
+	ids := make([]definition.SchemaId, 0, len(e.schema.Schemas))
+	for id := range e.schema.Schemas {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ni, nj := e.schema.Schemas[ids[i]].Name, e.schema.Schemas[ids[j]].Name
+		if ni != nj {
+			return ni < nj
+		}
+		return ids[i] < ids[j]
+	})
+	taken := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		name := e.schema.Schemas[id].Name
+		if name == "" || taken[name] {
+			name = string(id)
+		}
+		taken[name] = true
+		e.defs[id] = name
+	}
+}
+
+// object fills node with the object shape of bs: properties, required,
+// expressible constraints as keywords, and annotations for the rest.
+func (e *exporter) object(node map[string]any, bs definition.BaseSchema) error {
+	node["type"] = "object"
+	if bs.Description != "" {
+		node["description"] = bs.Description
+	}
+
+	properties := make(map[string]any, len(bs.Fields))
+	var required []string
+	for _, f := range bs.Fields {
+		prop, err := e.field(f)
+		if err != nil {
+			return err
+		}
+		properties[string(f.Name)] = prop
+		if f.Required {
+			required = append(required, string(f.Name))
+		}
+	}
+	node["properties"] = properties
+	if len(required) > 0 {
+		sort.Strings(required)
+		node["required"] = required
+	}
+
+	for _, c := range bs.Constraints {
+		rule, err := definition.ConstraintAs[*definition.ConstraintRule](c.ConstraintUnion)
+		if err != nil || len(rule.Fields) != 1 {
+			continue
+		}
+		if prop, ok := properties[string(rule.Fields[0])].(map[string]any); ok {
+			applyRule(prop, rule)
+		}
+	}
+	return annotate(node, bs.Constraints, bs.Indexes, bs.Metadata)
+}
+
+// field converts a field, folding nullability and field-level annotations
+// into its type node.
+func (e *exporter) field(f definition.Field) (map[string]any, error) {
+	node, err := e.props(f.FieldProperties)
+	if err != nil {
+		return nil, ErrExportFailed.WithMessagef("field %q", f.Name).WithCause(err)
+	}
+	if f.Nullable {
+		node = nullable(node)
+	}
+	if f.Description != "" {
+		node["description"] = f.Description
+	}
+	if !f.Default.IsZero() {
+		node["default"] = f.Default.Value()
+	}
+	if f.Deprecated {
+		node["deprecated"] = true
+	}
+	if f.Unique {
+		node[KeywordUnique] = true
+	}
+	if len(f.Metadata) > 0 {
+		node[KeywordMetadata] = f.Metadata
+	}
+	return node, nil
+}
+
+// nested converts a nested schema in whichever mode it is declared.
+func (e *exporter) nested(ns definition.NestedSchema) (map[string]any, error) {
+	var node map[string]any
+	switch {
+	case len(ns.Fields) > 0:
+		node = map[string]any{}
+		if err := e.object(node, ns.BaseSchema); err != nil {
+			return nil, err
+		}
+	case len(ns.Values) > 0:
+		node = map[string]any{}
+		if ns.Type != definition.FieldTypeEnum {
+			var err error
+			if node, err = e.props(definition.FieldProperties{Type: ns.Type}); err != nil {
+				return nil, err
+			}
+		}
+		node["enum"] = literals(ns.Values)
+	default:
+		var err error
+		if node, err = e.props(ns.FieldProperties); err != nil {
+			return nil, ErrExportFailed.WithMessagef("nested schema %q", ns.Name).WithCause(err)
+		}
+		if ns.Description != "" {
+			node["description"] = ns.Description
+		}
+		if !ns.Default.IsZero() {
+			node["default"] = ns.Default.Value()
+		}
+	}
+	if ns.Name != "" {
+		node["title"] = ns.Name
+	}
+	return node, nil
+}
+
+// props converts a type and its schema reference.
+func (e *exporter) props(fp definition.FieldProperties) (map[string]any, error) {
+	switch fp.Type {
+	case definition.FieldTypeString:
+		return map[string]any{"type": "string"}, nil
+	case definition.FieldTypeNumber:
+		return map[string]any{"type": "number"}, nil
+	case definition.FieldTypeInteger:
+		return map[string]any{"type": "integer"}, nil
+	case definition.FieldTypeBoolean:
+		return map[string]any{"type": "boolean"}, nil
+	case definition.FieldTypeDecimal:
+		return map[string]any{"type": "string", "pattern": decimalPattern, KeywordType: "decimal"}, nil
+	case definition.FieldTypeBytes:
+		return map[string]any{"type": "string", "contentEncoding": "base64", KeywordType: "bytes"}, nil
+	case definition.FieldTypeGeometry:
+		return map[string]any{
+			"type":      "array",
+			"items":     map[string]any{"type": "array", "items": map[string]any{"type": "number"}},
+			KeywordType: "geometry",
+		}, nil
+	case definition.FieldTypeUnknown, 0:
+		return map[string]any{}, nil
+	case definition.FieldTypeArray:
+		node := map[string]any{"type": "array"}
+		if !fp.Schema.IsZero() {
+			items, err := e.single(fp.Schema)
+			if err != nil {
+				return nil, err
+			}
+			node["items"] = items
+		}
+		return node, nil
+	case definition.FieldTypeRecord:
+		node := map[string]any{"type": "object"}
+		if !fp.Schema.IsZero() {
+			values, err := e.single(fp.Schema)
+			if err != nil {
+				return nil, err
+			}
+			node["additionalProperties"] = values
+		}
+		return node, nil
+	case definition.FieldTypeObject:
+		return e.single(fp.Schema)
+	case definition.FieldTypeEnum:
+		if fp.Schema.IsMultiple() {
+			return e.variants("anyOf", fp.Schema)
+		}
+		return e.single(fp.Schema)
+	case definition.FieldTypeUnion:
+		return e.variants("anyOf", fp.Schema)
+	case definition.FieldTypeComposite:
+		return e.variants("allOf", fp.Schema)
+	}
+	return nil, ErrUnsupported.WithMessagef("field type %q has no JSON Schema equivalent", fp.Type.String())
+}
+
+// single converts a one-reference schema.
+func (e *exporter) single(fr definition.FieldSchemaReference) (map[string]any, error) {
+	ref, err := definition.FieldSchemaAs[definition.SchemaReference](fr)
+	if err != nil {
+		return nil, err
+	}
+	return e.ref(ref)
+}
+
+// variants converts a multi-reference schema under the given combinator.
+func (e *exporter) variants(keyword string, fr definition.FieldSchemaReference) (map[string]any, error) {
+	refs, err := definition.FieldSchemaAs[[]definition.SchemaReference](fr)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, 0, len(refs))
+	for _, ref := range refs {
+		node, err := e.ref(ref)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, node)
+	}
+	return map[string]any{keyword: out}, nil
+}
+
+// ref converts a schema reference: named references point into $defs, inline
+// descriptors expand in place. Constraint and index augmentations travel with
+// the reference.
+func (e *exporter) ref(ref definition.SchemaReference) (map[string]any, error) {
+	var node map[string]any
+	if ref.ID != "" {
+		name, ok := e.defs[ref.ID]
+		if !ok {
+			return nil, ErrExportFailed.WithMessagef("reference to unknown nested schema %q", ref.ID)
+		}
+		node = map[string]any{"$ref": "#/$defs/" + pointerEscaper.Replace(name)}
+	} else {
+		var err error
+		if node, err = e.props(definition.FieldProperties{Type: ref.Type}); err != nil {
+			return nil, err
+		}
+		if len(ref.Values) > 0 {
+			node["enum"] = literals(ref.Values)
+		}
+	}
+
+	for _, c := range ref.Constraints {
+		rule, err := definition.ConstraintAs[*definition.ConstraintRule](c.ConstraintUnion)
+		if err == nil && len(rule.Fields) == 0 {
+			applyRule(node, rule)
+		}
+	}
+	if err := annotate(node, ref.Constraints, ref.Indexes, nil); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// applyRule writes the validation keyword of a constraint rule onto node
+// when the predicate has one for the node's type.
+func applyRule(node map[string]any, rule *definition.ConstraintRule) {
+	typ := jsonType(node)
+	for _, kr := range keywordRules {
+		if kr.predicate != rule.Predicate {
+			continue
+		}
+		keyword, ok := kr.keywords[typ]
+		if !ok {
+			return
+		}
+		if _, exists := node[keyword]; exists {
+			return
+		}
+		if kr.param == "" {
+			node[keyword] = kr.value
+			return
+		}
+		params, ok := rule.Parameters.Value().(map[string]any)
+		if !ok {
+			return
+		}
+		if v, ok := params[kr.param]; ok {
+			node[keyword] = v
+		}
+		return
+	}
+}
+
+// annotate records constraints, indexes and metadata under their annotation
+// keywords, in the same JSON form the schema file uses.
+func annotate(node map[string]any, constraints map[definition.ConstraintId]definition.Constraint, indexes map[definition.IndexID]definition.Index, metadata map[string]any) error {
+	if len(constraints) > 0 {
+		v, err := toAny(constraints)
+		if err != nil {
+			return err
+		}
+		node[KeywordConstraints] = v
+	}
+	if len(indexes) > 0 {
+		v, err := toAny(indexes)
+		if err != nil {
+			return err
+		}
+		node[KeywordIndexes] = v
+	}
+	if len(metadata) > 0 {
+		node[KeywordMetadata] = metadata
+	}
+	return nil
+}
+
+// nullable widens node to also accept null.
+func nullable(node map[string]any) map[string]any {
+	if len(node) == 0 {
+		return node
+	}
+	typ, ok := node["type"].(string)
+	if !ok {
+		return map[string]any{"anyOf": []any{node, map[string]any{"type": "null"}}}
+	}
+	node["type"] = []any{typ, "null"}
+	if values, ok := node["enum"].([]any); ok {
+		node["enum"] = append(values, nil)
+	}
+	return node
+}
+
+func literals(values definition.LiteralValues) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v.Value()
+	}
+	return out
+}
+
+func toAny(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, ErrExportFailed.WithCause(err)
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, ErrExportFailed.WithCause(err)
+	}
+	return out, nil
+}
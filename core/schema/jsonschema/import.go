@@ -0,0 +1,702 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/schema/meta"
+)
+
+// ImportOptions adjusts how a JSON Schema document is imported.
+type ImportOptions struct {
+	// Name overrides the document title as the schema name.
+	Name string
+	// Version overrides the x-anansi-version annotation; "1.0.0" when both
+	// are empty.
+	Version string
+}
+
+// defKind classifies what a $defs entry becomes in the imported schema.
+type defKind byte
+
+const (
+	defObject defKind = iota + 1 // schema mode: a nested schema with fields
+	defEnum                      // enum mode: a value type with values
+	defType                      // type mode: a described type
+)
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+type importer struct {
+	root    map[string]any
+	schemas map[string]any     // nested schemas, keyed by name until normalization
+	refs    map[string]string  // $ref pointer -> nested schema key
+	kinds   map[string]defKind // nested schema key -> kind
+}
+
+// Import converts a JSON Schema document into a normalized Anansi schema.
+// The document root must describe an object; its properties become the root
+// fields. Only references local to the document are followed.
+func Import(data []byte, opts ImportOptions) (*definition.Schema, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, ErrImportFailed.WithMessage("document is not a JSON object").WithCause(err)
+	}
+	return ImportMap(doc, opts)
+}
+
+// ImportMap is Import for an already decoded document.
+func ImportMap(doc map[string]any, opts ImportOptions) (*definition.Schema, error) {
+	name := opts.Name
+	if name == "" {
+		name, _ = doc["title"].(string)
+	}
+	if name == "" {
+		return nil, ErrImportFailed.WithMessage("schema name required: the document has no title")
+	}
+	version := opts.Version
+	if version == "" {
+		version, _ = doc[KeywordVersion].(string)
+	}
+	if version == "" {
+		version = "1.0.0"
+	}
+	if _, err := common.NewVersion(version); err != nil {
+		return nil, ErrImportFailed.WithMessagef("invalid schema version %q", version).WithCause(err)
+	}
+
+	im := &importer{
+		root:    doc,
+		schemas: make(map[string]any),
+		refs:    make(map[string]string),
+		kinds:   make(map[string]defKind),
+	}
+	if t := jsonType(doc); (t != "" && t != "object") || doc["$ref"] != nil {
+		return nil, ErrUnsupported.WithMessage("the document root must describe an object")
+	}
+	out, err := im.object(doc)
+	if err != nil {
+		return nil, err
+	}
+	out["name"] = name
+	out["version"] = version
+
+	// Definitions nothing references are part of the contract too.
+	for _, key := range []string{"$defs", "definitions"} {
+		defs, _ := doc[key].(map[string]any)
+		for _, def := range sortedKeys(defs) {
+			if _, _, err := im.def("#/" + key + "/" + pointerEscaper.Replace(def)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(im.schemas) > 0 {
+		out["schemas"] = im.schemas
+	}
+
+	raw, err := json.Marshal(out)
+	if err != nil {
+		return nil, ErrImportFailed.WithCause(err)
+	}
+	s, err := definition.FromJSON(raw)
+	if err != nil {
+		return nil, ErrImportFailed.WithCause(err)
+	}
+	meta.NormalizeSchema(s)
+	return s, nil
+}
+
+// object converts an object node into the base schema form: fields,
+// constraints, indexes, description and metadata.
+func (im *importer) object(node map[string]any) (map[string]any, error) {
+	properties, _ := node["properties"].(map[string]any)
+	required := map[string]bool{}
+	if list, ok := node["required"].([]any); ok {
+		for _, r := range list {
+			if s, ok := r.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	fields := make(map[string]any, len(properties))
+	for _, name := range sortedKeys(properties) {
+		prop, ok := properties[name].(map[string]any)
+		if !ok {
+			// `true` admits any value.
+			prop = map[string]any{}
+		}
+		f, err := im.field(name, prop, required[name])
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = f
+	}
+
+	out := map[string]any{"fields": fields}
+	if desc, ok := node["description"].(string); ok && desc != "" {
+		out["description"] = desc
+	}
+	if constraints, ok := node[KeywordConstraints].(map[string]any); ok {
+		out["constraints"] = constraints
+	} else if constraints := keywordConstraints(properties); len(constraints) > 0 {
+		out["constraints"] = constraints
+	}
+	if indexes, ok := node[KeywordIndexes].(map[string]any); ok {
+		out["indexes"] = indexes
+	}
+	if md, ok := node[KeywordMetadata].(map[string]any); ok {
+		out["metadata"] = md
+	}
+	return out, nil
+}
+
+// field converts a property into a field.
+func (im *importer) field(name string, node map[string]any, required bool) (map[string]any, error) {
+	props, nullable, err := im.props(node, name)
+	if err != nil {
+		return nil, ErrImportFailed.WithMessagef("property %q", name).WithCause(err)
+	}
+	f := map[string]any{"name": name}
+	for k, v := range props {
+		f[k] = v
+	}
+	if required {
+		f["required"] = true
+	}
+	if nullable {
+		f["nullable"] = true
+	}
+	if desc, ok := node["description"].(string); ok && desc != "" {
+		f["description"] = desc
+	}
+	if def, ok := node["default"]; ok {
+		f["default"] = def
+	}
+	if deprecated, _ := node["deprecated"].(bool); deprecated {
+		f["deprecated"] = true
+	}
+	if unique, _ := node[KeywordUnique].(bool); unique {
+		f["unique"] = true
+	}
+	if md, ok := node[KeywordMetadata].(map[string]any); ok {
+		f["metadata"] = md
+	}
+	return f, nil
+}
+
+// props converts a schema node into a type and schema reference, reporting
+// whether the node also admits null. hint names any nested schema the node
+// has to be lifted into.
+func (im *importer) props(node map[string]any, hint string) (map[string]any, bool, error) {
+	if ref, ok := node["$ref"].(string); ok {
+		key, kind, err := im.def(ref)
+		if err != nil {
+			return nil, false, err
+		}
+		switch kind {
+		case defObject:
+			return map[string]any{"type": "object", "schema": map[string]any{"id": key}}, false, nil
+		case defEnum:
+			return map[string]any{"type": "enum", "schema": map[string]any{"id": key}}, false, nil
+		}
+		target, err := im.resolve(ref)
+		if err != nil {
+			return nil, false, err
+		}
+		return im.props(target, hint)
+	}
+
+	if t, ok := node[KeywordType].(string); ok {
+		switch t {
+		case "decimal", "bytes", "geometry":
+			return map[string]any{"type": t}, admitsNull(node), nil
+		}
+	}
+
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		if list, ok := node[keyword].([]any); ok {
+			return im.union(list, hint)
+		}
+	}
+	if list, ok := node["allOf"].([]any); ok {
+		return im.composite(list, hint)
+	}
+
+	if values, ok := enumValues(node); ok {
+		nullable := admitsNull(node)
+		kept := values[:0:0]
+		for _, v := range values {
+			if v == nil {
+				nullable = true
+				continue
+			}
+			kept = append(kept, v)
+		}
+		elem := enumType(node, kept)
+		if elem == "" || len(kept) == 0 {
+			return map[string]any{"type": "unknown"}, nullable, nil
+		}
+		return map[string]any{"type": "enum", "schema": map[string]any{"type": elem, "values": kept}}, nullable, nil
+	}
+
+	types, nullable := nodeTypes(node)
+	if len(types) > 1 {
+		variants := make([]any, len(types))
+		for i, t := range types {
+			variants[i] = map[string]any{"type": t}
+		}
+		props, _, err := im.union(variants, hint)
+		return props, nullable, err
+	}
+
+	typ := ""
+	if len(types) == 1 {
+		typ = types[0]
+	} else {
+		switch {
+		case node["properties"] != nil || node["additionalProperties"] != nil:
+			typ = "object"
+		case node["items"] != nil:
+			typ = "array"
+		}
+	}
+
+	switch typ {
+	case "string":
+		if node["contentEncoding"] == "base64" || node["format"] == "byte" {
+			return map[string]any{"type": "bytes"}, nullable, nil
+		}
+		return map[string]any{"type": "string"}, nullable, nil
+	case "integer", "number", "boolean":
+		return map[string]any{"type": typ}, nullable, nil
+	case "array":
+		items, ok := node["items"].(map[string]any)
+		if !ok {
+			return map[string]any{"type": "array", "schema": map[string]any{"type": "unknown"}}, nullable, nil
+		}
+		ref, err := im.ref(items, hint+"_item")
+		if err != nil {
+			return nil, false, err
+		}
+		return map[string]any{"type": "array", "schema": ref}, nullable, nil
+	case "object":
+		if isObjectNode(node) {
+			key, err := im.lift(node, hint, defObject)
+			if err != nil {
+				return nil, false, err
+			}
+			return map[string]any{"type": "object", "schema": map[string]any{"id": key}}, nullable, nil
+		}
+		values, ok := node["additionalProperties"].(map[string]any)
+		if !ok {
+			return map[string]any{"type": "record"}, nullable, nil
+		}
+		ref, err := im.ref(values, hint+"_value")
+		if err != nil {
+			return nil, false, err
+		}
+		return map[string]any{"type": "record", "schema": ref}, nullable, nil
+	}
+	return map[string]any{"type": "unknown"}, nullable, nil
+}
+
+// union converts anyOf/oneOf. Null variants make the field nullable; a
+// single remaining variant is the field type itself.
+func (im *importer) union(list []any, hint string) (map[string]any, bool, error) {
+	nullable := false
+	var variants []map[string]any
+	for _, v := range list {
+		node, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		if t, _ := node["type"].(string); t == "null" && len(node) == 1 {
+			nullable = true
+			continue
+		}
+		variants = append(variants, node)
+	}
+	switch len(variants) {
+	case 0:
+		return map[string]any{"type": "unknown"}, nullable, nil
+	case 1:
+		props, n, err := im.props(variants[0], hint)
+		return props, nullable || n, err
+	}
+	refs, err := im.variants(variants, hint)
+	if err != nil {
+		return nil, false, err
+	}
+	return map[string]any{"type": "union", "schema": refs}, nullable, nil
+}
+
+// composite converts allOf. Members that only add validation keywords to
+// their siblings carry no shape and are dropped.
+func (im *importer) composite(list []any, hint string) (map[string]any, bool, error) {
+	var parts []map[string]any
+	for _, v := range list {
+		if node, ok := v.(map[string]any); ok && hasShape(node) {
+			parts = append(parts, node)
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return map[string]any{"type": "unknown"}, false, nil
+	case 1:
+		return im.props(parts[0], hint)
+	}
+	refs, err := im.variants(parts, hint)
+	if err != nil {
+		return nil, false, err
+	}
+	return map[string]any{"type": "composite", "schema": refs}, false, nil
+}
+
+// variants converts the members of a union or composite. Anansi variants are
+// always named, so inline members are lifted into nested schemas.
+func (im *importer) variants(nodes []map[string]any, hint string) ([]any, error) {
+	refs := make([]any, len(nodes))
+	for i, node := range nodes {
+		if ref, ok := node["$ref"].(string); ok {
+			key, _, err := im.def(ref)
+			if err != nil {
+				return nil, err
+			}
+			refs[i] = map[string]any{"id": key}
+			continue
+		}
+		key, err := im.lift(node, fmt.Sprintf("%s_%d", hint, i+1), classify(node))
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = map[string]any{"id": key}
+	}
+	return refs, nil
+}
+
+// ref converts the element type of an array or the value type of a record:
+// primitives and enums stay inline, everything else is named.
+func (im *importer) ref(node map[string]any, hint string) (map[string]any, error) {
+	if ref, ok := node["$ref"].(string); ok {
+		key, _, err := im.def(ref)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"id": key}, nil
+	}
+	props, _, err := im.props(node, hint)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	switch t, _ := props["type"].(string); t {
+	case "string", "number", "integer", "decimal", "boolean", "bytes", "unknown":
+		out = map[string]any{"type": t}
+	case "record":
+		if _, ok := props["schema"]; !ok {
+			out = map[string]any{"type": t}
+		}
+	case "object":
+		out = props["schema"].(map[string]any)
+	case "enum":
+		if inline, ok := props["schema"].(map[string]any); ok && inline["values"] != nil {
+			out = inline
+		}
+	}
+	if out == nil {
+		key, err := im.lift(node, hint, defType)
+		if err != nil {
+			return nil, err
+		}
+		out = map[string]any{"id": key}
+	}
+	if constraints, ok := node[KeywordConstraints].(map[string]any); ok {
+		out["constraints"] = constraints
+	}
+	if indexes, ok := node[KeywordIndexes].(map[string]any); ok {
+		out["indexes"] = indexes
+	}
+	return out, nil
+}
+
+// def converts the definition a $ref points at, once, returning its nested
+// schema key and kind.
+func (im *importer) def(ref string) (string, defKind, error) {
+	if key, ok := im.refs[ref]; ok {
+		return key, im.kinds[key], nil
+	}
+	node, err := im.resolve(ref)
+	if err != nil {
+		return "", 0, err
+	}
+	// A definition that is only an alias of another shares its schema.
+	if target, ok := node["$ref"].(string); ok && !hasShape(withoutRef(node)) {
+		key, kind, err := im.def(target)
+		if err == nil {
+			im.refs[ref] = key
+		}
+		return key, kind, err
+	}
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	name = pointerUnescaper.Replace(name)
+	key, err := im.liftAs(node, name, classify(node), ref)
+	return key, im.kinds[key], err
+}
+
+// lift turns an inline node into a nested schema named after title or hint.
+func (im *importer) lift(node map[string]any, hint string, kind defKind) (string, error) {
+	if title, ok := node["title"].(string); ok && title != "" {
+		hint = title
+	}
+	return im.liftAs(node, hint, kind, "")
+}
+
+func (im *importer) liftAs(node map[string]any, name string, kind defKind, ref string) (string, error) {
+	key := name
+	for i := 2; im.schemas[key] != nil; i++ {
+		key = fmt.Sprintf("%s_%d", name, i)
+	}
+	// Reserve the key before converting so recursive references resolve.
+	im.schemas[key] = map[string]any{}
+	im.kinds[key] = kind
+	if ref != "" {
+		im.refs[ref] = key
+	}
+
+	var ns map[string]any
+	switch kind {
+	case defObject:
+		var err error
+		if ns, err = im.object(node); err != nil {
+			return "", err
+		}
+	case defEnum:
+		props, _, err := im.props(node, key)
+		if err != nil {
+			return "", err
+		}
+		ns = map[string]any{"type": "unknown"}
+		if inline, ok := props["schema"].(map[string]any); ok && props["type"] == "enum" {
+			ns = map[string]any{"type": inline["type"], "values": inline["values"]}
+		}
+	default:
+		props, _, err := im.props(node, key)
+		if err != nil {
+			return "", err
+		}
+		if props["type"] == "object" {
+			// A type-mode schema cannot be an object; alias the object.
+			id := props["schema"].(map[string]any)["id"].(string)
+			delete(im.schemas, key)
+			delete(im.kinds, key)
+			if ref != "" {
+				im.refs[ref] = id
+			}
+			return id, nil
+		}
+		ns = props
+		if desc, ok := node["description"].(string); ok && desc != "" {
+			ns["description"] = desc
+		}
+	}
+	ns["name"] = key
+	im.schemas[key] = ns
+	return key, nil
+}
+
+// resolve follows a local JSON pointer reference.
+func (im *importer) resolve(ref string) (map[string]any, error) {
+	if ref == "#" {
+		return nil, ErrUnsupported.WithMessage("references to the document root are not supported")
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, ErrUnsupported.WithMessagef("external reference %q is not supported", ref)
+	}
+	var current any = im.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, ErrImportFailed.WithMessagef("unresolvable reference %q", ref)
+		}
+		if current, ok = m[pointerUnescaper.Replace(part)]; !ok {
+			return nil, ErrImportFailed.WithMessagef("unresolvable reference %q", ref)
+		}
+	}
+	node, ok := current.(map[string]any)
+	if !ok {
+		return nil, ErrImportFailed.WithMessagef("reference %q does not point at a schema", ref)
+	}
+	return node, nil
+}
+
+// keywordConstraints derives constraint rules from the validation keywords
+// of each property.
+func keywordConstraints(properties map[string]any) map[string]any {
+	out := map[string]any{}
+	for _, name := range sortedKeys(properties) {
+		node, ok := properties[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		typ := jsonType(node)
+		for _, kr := range keywordRules {
+			keyword, ok := kr.keywords[typ]
+			if !ok {
+				continue
+			}
+			v, ok := node[keyword]
+			if !ok {
+				continue
+			}
+			rule := map[string]any{
+				"name":      name + "_" + string(kr.predicate),
+				"predicate": string(kr.predicate),
+				"fields":    []any{name},
+			}
+			if kr.param == "" {
+				if v != kr.value {
+					continue
+				}
+			} else {
+				rule["parameters"] = map[string]any{kr.param: v}
+			}
+			out[name+"_"+string(kr.predicate)] = rule
+		}
+	}
+	return out
+}
+
+// classify decides which nested schema mode a node needs.
+func classify(node map[string]any) defKind {
+	if isObjectNode(node) {
+		return defObject
+	}
+	if _, ok := enumValues(node); ok {
+		return defEnum
+	}
+	return defType
+}
+
+// isObjectNode reports whether node describes an object with named
+// properties, as opposed to a map.
+func isObjectNode(node map[string]any) bool {
+	props, ok := node["properties"].(map[string]any)
+	if !ok || len(props) == 0 {
+		return false
+	}
+	t := jsonType(node)
+	return t == "" || t == "object"
+}
+
+// hasShape reports whether node says anything about the type of a value.
+func hasShape(node map[string]any) bool {
+	for _, k := range []string{"$ref", "type", "properties", "additionalProperties", "items", "enum", "const", "anyOf", "oneOf", "allOf", KeywordType} {
+		if _, ok := node[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutRef(node map[string]any) map[string]any {
+	out := make(map[string]any, len(node))
+	for k, v := range node {
+		if k != "$ref" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// enumValues returns the values of an enum or const node.
+func enumValues(node map[string]any) ([]any, bool) {
+	if values, ok := node["enum"].([]any); ok {
+		return values, true
+	}
+	if v, ok := node["const"]; ok {
+		return []any{v}, true
+	}
+	return nil, false
+}
+
+// enumType is the Anansi element type of enum values: the declared type when
+// there is one, otherwise the narrowest type all values share. Only string and
+// numeric enums exist in Anansi; anything else yields "".
+func enumType(node map[string]any, values []any) string {
+	declared := jsonType(node)
+	switch declared {
+	case "string", "integer", "number":
+		return declared
+	case "":
+	default:
+		return ""
+	}
+	typ := ""
+	for _, v := range values {
+		var t string
+		switch n := v.(type) {
+		case string:
+			t = "string"
+		case float64:
+			t = "number"
+			if n == math.Trunc(n) {
+				t = "integer"
+			}
+		default:
+			return ""
+		}
+		switch {
+		case typ == "" || typ == t:
+			typ = t
+		case (typ == "integer" && t == "number") || (typ == "number" && t == "integer"):
+			typ = "number"
+		default:
+			return ""
+		}
+	}
+	return typ
+}
+
+// nodeTypes returns the non-null JSON types of node and whether null is one.
+func nodeTypes(node map[string]any) ([]string, bool) {
+	switch t := node["type"].(type) {
+	case string:
+		if t == "null" {
+			return nil, true
+		}
+		return []string{t}, false
+	case []any:
+		var types []string
+		nullable := false
+		for _, v := range t {
+			s, _ := v.(string)
+			if s == "null" {
+				nullable = true
+			} else if s != "" {
+				types = append(types, s)
+			}
+		}
+		return types, nullable
+	}
+	return nil, false
+}
+
+func admitsNull(node map[string]any) bool {
+	_, nullable := nodeTypes(node)
+	return nullable
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+// Package jsonschema converts Anansi schemas to and from JSON Schema 2020-12.
+//
+// Export maps every field type onto the nearest JSON Schema construct: nested
+// schemas become $defs entries referenced by name, unions become anyOf,
+// composites allOf, enums enum, and records objects constrained through
+// additionalProperties. Information JSON Schema cannot carry is kept in
+// x-anansi-* annotation keywords, which validators ignore and Import reads
+// back, so an exported schema re-imports without loss of types, uniqueness,
+// indexes or constraints.
+//
+// Import accepts any self-contained JSON Schema document whose root describes
+// an object. Inline object shapes, tuple-free arrays, maps and variant lists are
+// lifted into named nested schemas, and every generated id is normalized to a
+// UUIDv7 exactly as `anansi schema normalize` would.
+package jsonschema
+
+import (
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+)
+
+// Dialect is the $schema URI written by Export.
+const Dialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Annotation keywords carrying Anansi-specific information. Validators treat
+// unknown keywords as annotations, so exported documents stay portable.
+const (
+	// KeywordVersion holds the schema version on the document root.
+	KeywordVersion = "x-anansi-version"
+	// KeywordType names the Anansi field type when the JSON Schema shape alone
+	// is ambiguous: decimal, bytes and geometry.
+	KeywordType = "x-anansi-type"
+	// KeywordUnique marks a property declared unique.
+	KeywordUnique = "x-anansi-unique"
+	// KeywordConstraints holds an object's constraints, keyed by id, in their
+	// Anansi JSON form.
+	KeywordConstraints = "x-anansi-constraints"
+	// KeywordIndexes holds an object's indexes, keyed by id, in their Anansi
+	// JSON form.
+	KeywordIndexes = "x-anansi-indexes"
+	// KeywordMetadata holds schema or field metadata.
+	KeywordMetadata = "x-anansi-metadata"
+)
+
+// decimalPattern matches the string form of a decimal value.
+const decimalPattern = `^-?[0-9]+(\.[0-9]+)?$`
+
+var (
+	ErrExportFailed = common.NewSystemError("ERR_JSONSCHEMA_EXPORT_FAILED", "failed to export schema as JSON Schema")
+	ErrImportFailed = common.NewSystemError("ERR_JSONSCHEMA_IMPORT_FAILED", "failed to import JSON Schema document")
+	ErrUnsupported  = common.NewSystemError("ERR_JSONSCHEMA_UNSUPPORTED", "JSON Schema construct not supported")
+)
+
+// keywordRule ties a single-field constraint predicate to the validation
+// keyword expressing it for each JSON type. Export writes the keyword next to
+// the constraint annotation; Import turns the keyword back into a constraint
+// when a document carries no constraint annotation of its own.
+type keywordRule struct {
+	predicate definition.PredicateName
+	param     string            // parameter holding the keyword value; "" for fixed-value keywords
+	keywords  map[string]string // JSON type -> keyword
+	value     any               // keyword value of parameterless predicates
+}
+
+var keywordRules = []keywordRule{
+	{predicate: "min_length", param: "min", keywords: map[string]string{"string": "minLength", "array": "minItems"}},
+	{predicate: "max_length", param: "max", keywords: map[string]string{"string": "maxLength", "array": "maxItems"}},
+	{predicate: "greater_than", param: "min", keywords: map[string]string{"number": "exclusiveMinimum", "integer": "exclusiveMinimum"}},
+	{predicate: "greater_than_or_equal", param: "min", keywords: map[string]string{"number": "minimum", "integer": "minimum"}},
+	{predicate: "less_than", param: "max", keywords: map[string]string{"number": "exclusiveMaximum", "integer": "exclusiveMaximum"}},
+	{predicate: "less_than_or_equal", param: "max", keywords: map[string]string{"number": "maximum", "integer": "maximum"}},
+	{predicate: "pattern", param: "pattern", keywords: map[string]string{"string": "pattern"}},
+	{predicate: "email_format", keywords: map[string]string{"string": "format"}, value: "email"},
+}
+
+// jsonType returns the single non-null JSON type a schema node declares, or ""
+// when it declares none or several.
+func jsonType(node map[string]any) string {
+	switch t := node["type"].(type) {
+	case string:
+		return t
+	case []any:
+		found := ""
+		for _, v := range t {
+			s, _ := v.(string)
+			if s == "null" {
+				continue
+			}
+			if found != "" {
+				return ""
+			}
+			found = s
+		}
+		return found
+	}
+	return ""
+}
@@ -0,0 +1,432 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	corejson "github.com/asaidimu/go-anansi/v8/core/json"
+	"github.com/asaidimu/go-anansi/v8/core/schema"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/schema/jsonschema"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ordersSchema = `{
+  "version": "2.1.0",
+  "name": "orders",
+  "description": "Customer orders",
+  "metadata": { "owner": "sales" },
+  "fields": {
+    "number":   { "name": "number",   "type": "string", "required": true, "unique": true },
+    "email":    { "name": "email",    "type": "string", "nullable": true, "description": "Contact address" },
+    "total":    { "name": "total",    "type": "decimal", "required": true },
+    "quantity": { "name": "quantity", "type": "integer", "default": 1 },
+    "paid":     { "name": "paid",     "type": "boolean", "deprecated": true },
+    "receipt":  { "name": "receipt",  "type": "bytes" },
+    "area":     { "name": "area",     "type": "geometry" },
+    "extra":    { "name": "extra",    "type": "unknown" },
+    "status":   { "name": "status",   "type": "enum", "schema": { "type": "string", "values": ["open", "shipped"] } },
+    "priority": { "name": "priority", "type": "enum", "schema": { "id": "priority" } },
+    "tags":     { "name": "tags",     "type": "array", "schema": { "type": "string", "constraints": {
+      "tag_min": { "name": "tag_min", "predicate": "min_length", "fields": [], "parameters": { "min": 2 } }
+    } } },
+    "lines":    { "name": "lines",    "type": "array", "schema": { "id": "line" } },
+    "shipping": { "name": "shipping", "type": "object", "schema": { "id": "address" }, "nullable": true },
+    "labels":   { "name": "labels",   "type": "record", "schema": { "type": "string" } },
+    "attrs":    { "name": "attrs",    "type": "record" },
+    "payment":  { "name": "payment",  "type": "union", "schema": [ { "id": "card" }, { "id": "transfer" } ] },
+    "party":    { "name": "party",    "type": "composite", "schema": [ { "id": "address" }, { "id": "contact" } ] }
+  },
+  "constraints": {
+    "number_min":  { "name": "number_min",  "predicate": "min_length",   "fields": ["number"],   "parameters": { "min": 3 } },
+    "email_fmt":   { "name": "email_fmt",   "predicate": "email_format", "fields": ["email"] },
+    "qty_pos":     { "name": "qty_pos",     "predicate": "greater_than", "fields": ["quantity"], "parameters": { "min": 0 } },
+    "custom_rule": { "name": "custom_rule", "predicate": "order_is_consistent", "fields": ["number", "total"] }
+  },
+  "indexes": {
+    "by_number": { "name": "by_number", "type": "normal", "fields": ["number"], "unique": true }
+  },
+  "schemas": {
+    "priority": { "name": "priority", "type": "integer", "values": [1, 2, 3] },
+    "line": {
+      "name": "line",
+      "fields": {
+        "sku":   { "name": "sku",   "type": "string", "required": true },
+        "price": { "name": "price", "type": "number" }
+      }
+    },
+    "address": {
+      "name": "address",
+      "fields": { "city": { "name": "city", "type": "string", "required": true } }
+    },
+    "contact": {
+      "name": "contact",
+      "fields": { "phone": { "name": "phone", "type": "string" } }
+    },
+    "card": {
+      "name": "card",
+      "fields": { "card_number": { "name": "card_number", "type": "string", "required": true } }
+    },
+    "transfer": {
+      "name": "transfer",
+      "fields": { "iban": { "name": "iban", "type": "string", "required": true } }
+    }
+  }
+}`
+
+func parse(t *testing.T, raw string) *definition.Schema {
+	t.Helper()
+	s, err := definition.FromJSON([]byte(raw))
+	require.NoError(t, err)
+	return s
+}
+
+func node(t *testing.T, v any, path ...string) map[string]any {
+	t.Helper()
+	for _, p := range path {
+		m, ok := v.(map[string]any)
+		require.True(t, ok, "%v is not an object", path)
+		v = m[p]
+	}
+	m, ok := v.(map[string]any)
+	require.True(t, ok, "%v is not an object", path)
+	return m
+}
+
+func exportDoc(t *testing.T, s *definition.Schema) map[string]any {
+	t.Helper()
+	raw, err := jsonschema.ExportJSON(s)
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	return doc
+}
+
+func fieldByName(t *testing.T, bs definition.BaseSchema, name string) definition.Field {
+	t.Helper()
+	_, f := bs.FindField(name)
+	require.NotNil(t, f, "field %q", name)
+	return *f
+}
+
+func TestExport(t *testing.T) {
+	doc := exportDoc(t, parse(t, ordersSchema))
+	props := node(t, doc, "properties")
+
+	assert.Equal(t, jsonschema.Dialect, doc["$schema"])
+	assert.Equal(t, "orders", doc["title"])
+	assert.Equal(t, "2.1.0", doc[jsonschema.KeywordVersion])
+	assert.Equal(t, []any{"number", "total"}, doc["required"])
+
+	for name, want := range map[string]map[string]any{
+		"number":   {"type": "string", "minLength": float64(3), jsonschema.KeywordUnique: true},
+		"email":    {"type": []any{"string", "null"}, "format": "email", "description": "Contact address"},
+		"quantity": {"type": "integer", "default": float64(1), "exclusiveMinimum": float64(0)},
+		"paid":     {"type": "boolean", "deprecated": true},
+		"extra":    {},
+		"status":   {"type": "string", "enum": []any{"open", "shipped"}},
+		"priority": {"$ref": "#/$defs/priority"},
+		"labels":   {"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+		"attrs":    {"type": "object"},
+		"payment":  {"anyOf": []any{map[string]any{"$ref": "#/$defs/card"}, map[string]any{"$ref": "#/$defs/transfer"}}},
+		"party":    {"allOf": []any{map[string]any{"$ref": "#/$defs/address"}, map[string]any{"$ref": "#/$defs/contact"}}},
+	} {
+		assert.Equal(t, want, node(t, props, name), name)
+	}
+
+	assert.Equal(t, "decimal", node(t, props, "total")[jsonschema.KeywordType])
+	assert.Equal(t, "base64", node(t, props, "receipt")["contentEncoding"])
+	assert.Equal(t, "geometry", node(t, props, "area")[jsonschema.KeywordType])
+	assert.Equal(t, float64(2), node(t, props, "tags", "items")["minLength"])
+	assert.Equal(t, map[string]any{"$ref": "#/$defs/line"}, node(t, props, "lines", "items"))
+	assert.Equal(t, []any{map[string]any{"$ref": "#/$defs/address"}, map[string]any{"type": "null"}}, node(t, props, "shipping")["anyOf"])
+
+	defs := node(t, doc, "$defs")
+	assert.Equal(t, map[string]any{"type": "integer", "enum": []any{float64(1), float64(2), float64(3)}, "title": "priority"}, node(t, defs, "priority"))
+	assert.Equal(t, []any{"sku"}, node(t, defs, "line")["required"])
+	assert.Len(t, node(t, doc, jsonschema.KeywordConstraints), 4)
+	assert.Contains(t, node(t, doc, jsonschema.KeywordIndexes), "by_number")
+	assert.Equal(t, map[string]any{"owner": "sales"}, doc[jsonschema.KeywordMetadata])
+}
+
+func TestExport_ValidatesDocuments(t *testing.T) {
+	raw, err := jsonschema.ExportJSON(parse(t, ordersSchema))
+	require.NoError(t, err)
+	compiler, err := corejson.NewCompiler(raw)
+	require.NoError(t, err)
+
+	valid := map[string]any{
+		"number":   "A-100",
+		"email":    nil,
+		"total":    "12.50",
+		"quantity": 2,
+		"status":   "open",
+		"priority": 2,
+		"tags":     []any{"gift", "eu"},
+		"lines":    []any{map[string]any{"sku": "X1", "price": 3.5}},
+		"shipping": map[string]any{"city": "Nairobi"},
+		"labels":   map[string]any{"channel": "web"},
+		"payment":  map[string]any{"iban": "KE00"},
+		"party":    map[string]any{"city": "Mombasa", "phone": "+254"},
+		"area":     []any{[]any{36.8, -1.3}},
+	}
+	require.NoError(t, compiler.ValidateValue(roundTrip(t, valid)))
+
+	for name, mutate := range map[string]func(map[string]any){
+		"missing required": func(d map[string]any) { delete(d, "total") },
+		"bad decimal":      func(d map[string]any) { d["total"] = "12,50" },
+		"short number":     func(d map[string]any) { d["number"] = "A1" },
+		"unknown status":   func(d map[string]any) { d["status"] = "lost" },
+		"bad line":         func(d map[string]any) { d["lines"] = []any{map[string]any{"price": 1}} },
+		"bad label value":  func(d map[string]any) { d["labels"] = map[string]any{"channel": 1} },
+		"no payment shape": func(d map[string]any) { d["payment"] = "cash" },
+		"null shipping ok": nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			doc := roundTrip(t, valid)
+			if mutate == nil {
+				doc["shipping"] = nil
+				assert.NoError(t, compiler.ValidateValue(doc))
+				return
+			}
+			mutate(doc)
+			assert.Error(t, compiler.ValidateValue(doc))
+		})
+	}
+}
+
+func roundTrip(t *testing.T, v map[string]any) map[string]any {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(raw, &out))
+	return out
+}
+
+func TestImport_RoundTrip(t *testing.T) {
+	original := parse(t, ordersSchema)
+	raw, err := jsonschema.ExportJSON(original)
+	require.NoError(t, err)
+
+	s, err := jsonschema.Import(raw, jsonschema.ImportOptions{})
+	require.NoError(t, err)
+	issues, ok := schema.SchemaValidator().Validate(s.AsMap())
+	require.True(t, ok, "%v", issues)
+
+	assert.Equal(t, "orders", s.Name)
+	assert.Equal(t, "2.1.0", s.Version.String())
+	assert.Equal(t, original.Description, s.Description)
+	assert.Equal(t, original.Metadata, s.Metadata)
+	require.Len(t, s.Fields, len(original.Fields))
+	for _, want := range original.Fields {
+		got := fieldByName(t, s.BaseSchema, string(want.Name))
+		assert.Equal(t, want.Type, got.Type, want.Name)
+		assert.Equal(t, want.Required, got.Required, want.Name)
+		assert.Equal(t, want.Nullable, got.Nullable, want.Name)
+		assert.Equal(t, want.Unique, got.Unique, want.Name)
+		assert.Equal(t, want.Deprecated, got.Deprecated, want.Name)
+		assert.Equal(t, want.Description, got.Description, want.Name)
+		assert.Equal(t, want.Default.Value(), got.Default.Value(), want.Name)
+	}
+
+	names := map[string]definition.SchemaId{}
+	for id, ns := range s.Schemas {
+		names[ns.Name] = id
+	}
+	assert.Len(t, s.Schemas, len(original.Schemas))
+	for _, ns := range original.Schemas {
+		assert.Contains(t, names, ns.Name)
+	}
+
+	// References land on the re-created nested schemas.
+	lines := fieldByName(t, s.BaseSchema, "lines")
+	ref, err := definition.FieldSchemaAs[definition.SchemaReference](lines.Schema)
+	require.NoError(t, err)
+	assert.Equal(t, names["line"], ref.ID)
+	payment := fieldByName(t, s.BaseSchema, "payment")
+	variants, err := definition.FieldSchemaAs[[]definition.SchemaReference](payment.Schema)
+	require.NoError(t, err)
+	assert.Equal(t, []definition.SchemaId{names["card"], names["transfer"]}, []definition.SchemaId{variants[0].ID, variants[1].ID})
+	assert.Equal(t, definition.LiteralValues{
+		definition.MustNewLiteralValue[int64](1), definition.MustNewLiteralValue[int64](2), definition.MustNewLiteralValue[int64](3),
+	}, s.Schemas[names["priority"]].Values)
+	tags := fieldByName(t, s.BaseSchema, "tags")
+	tagRef, err := definition.FieldSchemaAs[definition.SchemaReference](tags.Schema)
+	require.NoError(t, err)
+	assert.Len(t, tagRef.Constraints, 1)
+
+	// Constraints come back verbatim rather than re-derived from keywords.
+	var constraintNames []string
+	for _, c := range s.Constraints {
+		constraintNames = append(constraintNames, c.Name)
+	}
+	assert.ElementsMatch(t, []string{"number_min", "email_fmt", "qty_pos", "custom_rule"}, constraintNames)
+	assert.Len(t, s.Indexes, 1)
+
+	// Once ids are UUIDv7 they survive import, so further round trips are
+	// stable.
+	first, err := jsonschema.ExportJSON(s)
+	require.NoError(t, err)
+	s2, err := jsonschema.Import(first, jsonschema.ImportOptions{})
+	require.NoError(t, err)
+	second, err := jsonschema.ExportJSON(s2)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(first), string(second))
+}
+
+const externalSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "customer",
+  "type": "object",
+  "required": ["id", "name"],
+  "properties": {
+    "id":       { "type": "string", "format": "uuid" },
+    "name":     { "type": "string", "minLength": 1, "maxLength": 80 },
+    "email":    { "type": ["string", "null"], "format": "email" },
+    "age":      { "type": "integer", "minimum": 0 },
+    "tier":     { "enum": ["free", "pro", null] },
+    "plan":     { "const": "annual" },
+    "score":    { "type": ["integer", "string"] },
+    "address":  { "$ref": "#/$defs/Address" },
+    "billing":  { "oneOf": [ { "$ref": "#/$defs/Address" }, { "type": "null" } ] },
+    "previous": { "type": "array", "items": { "$ref": "#/$defs/Address" }, "minItems": 1 },
+    "phones":   { "type": "array", "items": { "type": "object", "properties": { "number": { "type": "string" } } } },
+    "matrix":   { "type": "array", "items": { "type": "array", "items": { "type": "number" } } },
+    "prefs":    { "type": "object", "additionalProperties": { "type": "boolean" } },
+    "raw":      { "type": "object" },
+    "avatar":   { "type": "string", "contentEncoding": "base64" },
+    "contact":  { "anyOf": [ { "$ref": "#/$defs/Email" }, { "$ref": "#/$defs/Phone" } ] },
+    "profile":  { "allOf": [ { "$ref": "#/$defs/Address" }, { "required": ["city"] } ] },
+    "manager":  { "$ref": "#/$defs/Customer" },
+    "anything": true
+  },
+  "$defs": {
+    "Address":  { "type": "object", "required": ["city"], "properties": { "city": { "type": "string" }, "zip": { "type": "string", "pattern": "^[0-9]{5}$" } } },
+    "Email":    { "type": "object", "properties": { "email": { "type": "string" } } },
+    "Phone":    { "type": "object", "properties": { "phone": { "type": "string" } } },
+    "Customer": { "type": "object", "properties": { "name": { "type": "string" }, "manager": { "$ref": "#/$defs/Customer" } } },
+    "Unused":   { "type": "string", "enum": ["x", "y"] }
+  }
+}`
+
+func TestImport_External(t *testing.T) {
+	s, err := jsonschema.Import([]byte(externalSchema), jsonschema.ImportOptions{Version: "0.3.0"})
+	require.NoError(t, err)
+	issues, ok := schema.SchemaValidator().Validate(s.AsMap())
+	require.True(t, ok, "%v", issues)
+	assert.Equal(t, "customer", s.Name)
+	assert.Equal(t, "0.3.0", s.Version.String())
+
+	for id := range s.Fields {
+		parsed, err := uuid.Parse(string(id))
+		require.NoError(t, err)
+		assert.Equal(t, uuid.Version(7), parsed.Version())
+	}
+
+	names := map[definition.SchemaId]string{}
+	for id, ns := range s.Schemas {
+		names[id] = ns.Name
+	}
+	refName := func(f definition.Field) string {
+		ref, err := definition.FieldSchemaAs[definition.SchemaReference](f.Schema)
+		require.NoError(t, err)
+		return names[ref.ID]
+	}
+
+	for name, want := range map[string]definition.FieldType{
+		"id": definition.FieldTypeString, "name": definition.FieldTypeString,
+		"email": definition.FieldTypeString, "age": definition.FieldTypeInteger,
+		"tier": definition.FieldTypeEnum, "plan": definition.FieldTypeEnum,
+		"score": definition.FieldTypeUnion, "address": definition.FieldTypeObject,
+		"billing": definition.FieldTypeObject, "previous": definition.FieldTypeArray,
+		"phones": definition.FieldTypeArray, "matrix": definition.FieldTypeArray,
+		"prefs": definition.FieldTypeRecord, "raw": definition.FieldTypeRecord,
+		"avatar": definition.FieldTypeBytes, "contact": definition.FieldTypeUnion,
+		"profile": definition.FieldTypeObject, "manager": definition.FieldTypeObject,
+		"anything": definition.FieldTypeUnknown,
+	} {
+		assert.Equal(t, want, fieldByName(t, s.BaseSchema, name).Type, name)
+	}
+
+	assert.True(t, fieldByName(t, s.BaseSchema, "id").Required)
+	assert.False(t, fieldByName(t, s.BaseSchema, "age").Required)
+	assert.True(t, fieldByName(t, s.BaseSchema, "email").Nullable)
+	assert.True(t, fieldByName(t, s.BaseSchema, "tier").Nullable)
+	assert.True(t, fieldByName(t, s.BaseSchema, "billing").Nullable)
+	assert.Equal(t, "Address", refName(fieldByName(t, s.BaseSchema, "billing")))
+	assert.Equal(t, "Address", refName(fieldByName(t, s.BaseSchema, "profile")))
+	assert.Equal(t, "Address", refName(fieldByName(t, s.BaseSchema, "previous")))
+	assert.Equal(t, "phones_item", refName(fieldByName(t, s.BaseSchema, "phones")))
+	assert.Equal(t, "matrix_item", refName(fieldByName(t, s.BaseSchema, "matrix")))
+
+	tier, err := definition.FieldSchemaAs[definition.SchemaReference](fieldByName(t, s.BaseSchema, "tier").Schema)
+	require.NoError(t, err)
+	assert.Equal(t, definition.FieldTypeString, tier.Type)
+	assert.Len(t, tier.Values, 2)
+
+	// Recursive definitions resolve to themselves.
+	var customer definition.NestedSchema
+	for _, ns := range s.Schemas {
+		if ns.Name == "Customer" {
+			customer = ns
+		}
+	}
+	assert.Equal(t, "Customer", refName(fieldByName(t, customer.BaseSchema, "manager")))
+	assert.Equal(t, "Customer", refName(fieldByName(t, s.BaseSchema, "manager")))
+	assert.Contains(t, names, mustSchemaID(t, s, "Unused"))
+
+	// Validation keywords become constraints.
+	byName := map[string]definition.PredicateName{}
+	for _, c := range s.Constraints {
+		rule, err := definition.ConstraintAs[*definition.ConstraintRule](c.ConstraintUnion)
+		require.NoError(t, err)
+		byName[c.Name] = rule.Predicate
+	}
+	assert.Equal(t, map[string]definition.PredicateName{
+		"name_min_length":           "min_length",
+		"name_max_length":           "max_length",
+		"email_email_format":        "email_format",
+		"age_greater_than_or_equal": "greater_than_or_equal",
+		"previous_min_length":       "min_length",
+	}, byName)
+	address := s.Schemas[mustSchemaID(t, s, "Address")]
+	require.Len(t, address.Constraints, 1)
+	for _, c := range address.Constraints {
+		assert.Equal(t, "zip_pattern", c.Name)
+	}
+}
+
+func mustSchemaID(t *testing.T, s *definition.Schema, name string) definition.SchemaId {
+	t.Helper()
+	for id, ns := range s.Schemas {
+		if ns.Name == name {
+			return id
+		}
+	}
+	t.Fatalf("nested schema %q not found", name)
+	return ""
+}
+
+func TestImport_Errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		doc  string
+		code string
+	}{
+		"no title":        {`{"type": "object", "properties": {}}`, jsonschema.ErrImportFailed.Code},
+		"not json":        {`[1, 2]`, jsonschema.ErrImportFailed.Code},
+		"array root":      {`{"title": "x", "type": "array"}`, jsonschema.ErrUnsupported.Code},
+		"external ref":    {`{"title": "x", "properties": {"a": {"$ref": "other.json#/a"}}}`, jsonschema.ErrUnsupported.Code},
+		"root ref":        {`{"title": "x", "properties": {"a": {"$ref": "#"}}}`, jsonschema.ErrUnsupported.Code},
+		"missing def":     {`{"title": "x", "properties": {"a": {"$ref": "#/$defs/nope"}}}`, jsonschema.ErrImportFailed.Code},
+		"invalid version": {`{"title": "x", "x-anansi-version": "one", "properties": {}}`, jsonschema.ErrImportFailed.Code},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonschema.Import([]byte(tc.doc), jsonschema.ImportOptions{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.code)
+		})
+	}
+}
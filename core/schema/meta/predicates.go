@@ -909,7 +909,17 @@ var MetaSchemaPredicates = definition.PredicateMap{
 			parts := strings.Split(path, ".")
 			current := contextFields
 			for i, part := range parts {
-				if field, exists := current[part]; exists {
+				field, exists := current[part]
+				if !exists {
+					// Normalized schemas key fields by id; paths name them.
+					for _, candidate := range current {
+						if fm, ok := candidate.(map[string]any); ok && fm["name"] == part {
+							field, exists = candidate, true
+							break
+						}
+					}
+				}
+				if exists {
 					if i == len(parts)-1 {
 						return true
 					}
@@ -86,3 +86,29 @@ func TestMetaSchema_EnforcesEnumSchema(t *testing.T) {
 	require.True(t, found, "Should have found a constraint violation for the missing enum schema")
 }
 
+
+func TestMetaSchema_ConstraintFieldsResolveByName(t *testing.T) {
+	// Normalized schemas key fields by UUIDv7 while constraints keep naming
+	// them, so the field check must resolve paths by name as well as by key.
+	s := map[string]any{
+		"name":    "Orders",
+		"version": "1.0.0",
+		"fields": map[string]any{
+			"019fc802-2195-7e1a-bfc3-7aa5fb626251": map[string]any{"name": "number", "type": "string"},
+		},
+		"constraints": map[string]any{
+			"019fc802-2195-7e1b-a757-a2d39a5524cb": map[string]any{
+				"name":       "number_min",
+				"predicate":  "min_length",
+				"fields":     []any{"number"},
+				"parameters": map[string]any{"min": 3},
+			},
+		},
+	}
+
+	vd, err := definition.NewDocumentValidator(&meta.MetaSchema, meta.MetaSchemaPredicates)
+	require.NoError(t, err)
+
+	issues, ok := vd.Validate(s)
+	require.True(t, ok, "%v", issues)
+}
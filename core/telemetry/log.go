@@ -0,0 +1,82 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Log returns a Telemetry writing finished spans and every measurement to
+// logger at debug level.
+func Log(logger *zap.Logger) Telemetry {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return Telemetry{Tracer: logTracer{logger}, Meter: logMeter{logger}}
+}
+
+type logTracer struct {
+	logger *zap.Logger
+}
+
+func (t logTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	return ctx, &logSpan{logger: t.logger, name: name, attrs: attrs, start: time.Now()}
+}
+
+type logSpan struct {
+	logger *zap.Logger
+	name   string
+	attrs  []Attribute
+	err    error
+	start  time.Time
+}
+
+func (s *logSpan) SetAttributes(attrs ...Attribute) {
+	s.attrs = append(s.attrs, attrs...)
+}
+
+func (s *logSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *logSpan) End() {
+	fields := append(fieldsOf(s.attrs), zap.String("span", s.name), zap.Duration("duration", time.Since(s.start)))
+	if s.err != nil {
+		fields = append(fields, zap.Error(s.err))
+	}
+	s.logger.Debug("span finished", fields...)
+}
+
+type logMeter struct {
+	logger *zap.Logger
+}
+
+func (m logMeter) Counter(name string) Counter {
+	return logInstrument{logger: m.logger, name: name}
+}
+
+func (m logMeter) Histogram(name string) Histogram {
+	return logInstrument{logger: m.logger, name: name}
+}
+
+type logInstrument struct {
+	logger *zap.Logger
+	name   string
+}
+
+func (i logInstrument) Add(_ context.Context, value int64, attrs ...Attribute) {
+	i.logger.Debug("counter", append(fieldsOf(attrs), zap.String("metric", i.name), zap.Int64("value", value))...)
+}
+
+func (i logInstrument) Record(_ context.Context, value float64, attrs ...Attribute) {
+	i.logger.Debug("histogram", append(fieldsOf(attrs), zap.String("metric", i.name), zap.Float64("value", value))...)
+}
+
+func fieldsOf(attrs []Attribute) []zap.Field {
+	fields := make([]zap.Field, 0, len(attrs)+3)
+	for _, attr := range attrs {
+		fields = append(fields, zap.Any(attr.Key, attr.Value))
+	}
+	return fields
+}
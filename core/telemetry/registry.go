@@ -0,0 +1,222 @@
+package telemetry
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry is an in-memory Tracer and Meter. It keeps every finished span
+// and aggregates measurements by series, which makes it suited to tests and
+// to exposing metrics through expvar.
+type Registry struct {
+	mu         sync.Mutex
+	spans      []SpanRecord
+	nextSpan   uint64
+	counters   map[string]int64
+	histograms map[string]HistogramSnapshot
+}
+
+var (
+	_ Tracer = (*Registry)(nil)
+	_ Meter  = (*Registry)(nil)
+)
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		counters:   make(map[string]int64),
+		histograms: make(map[string]HistogramSnapshot),
+	}
+}
+
+// Telemetry returns a Telemetry recording into r.
+func (r *Registry) Telemetry() Telemetry {
+	return Telemetry{Tracer: r, Meter: r}
+}
+
+// SpanRecord is a span finished in a Registry.
+type SpanRecord struct {
+	ID uint64
+	// ParentID is the ID of the span the span was started under, or zero.
+	ParentID   uint64
+	Name       string
+	Attributes map[string]any
+	Err        error
+	Start      time.Time
+	Duration   time.Duration
+}
+
+// HistogramSnapshot summarises the values recorded in a histogram series.
+type HistogramSnapshot struct {
+	Count int64   `json:"count"`
+	Sum   float64 `json:"sum"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+}
+
+// Snapshot holds the measurements of a Registry, keyed by Series.
+type Snapshot struct {
+	Counters   map[string]int64             `json:"counters"`
+	Histograms map[string]HistogramSnapshot `json:"histograms"`
+}
+
+// Series names the series of an instrument with the given attributes:
+// name{key=value,...}, with keys sorted. Attributes are sorted in place.
+func Series(name string, attrs ...Attribute) string {
+	if len(attrs) == 0 {
+		return name
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, attr := range attrs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%v", attr.Key, attr.Value)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Spans returns the finished spans, in the order they ended.
+func (r *Registry) Spans() []SpanRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]SpanRecord(nil), r.spans...)
+}
+
+// Snapshot returns a copy of the measurements recorded so far.
+func (r *Registry) Snapshot() Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snapshot := Snapshot{
+		Counters:   make(map[string]int64, len(r.counters)),
+		Histograms: make(map[string]HistogramSnapshot, len(r.histograms)),
+	}
+	for series, value := range r.counters {
+		snapshot.Counters[series] = value
+	}
+	for series, value := range r.histograms {
+		snapshot.Histograms[series] = value
+	}
+	return snapshot
+}
+
+// Reset discards every span and measurement.
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+	r.counters = make(map[string]int64)
+	r.histograms = make(map[string]HistogramSnapshot)
+}
+
+// Publish exposes the snapshot of r as the expvar variable name, served at
+// /debug/vars by the expvar handler. Like expvar.Publish, it panics when name
+// is already published.
+func (r *Registry) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return r.Snapshot() }))
+}
+
+type spanKey struct{}
+
+func (r *Registry) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	r.mu.Lock()
+	r.nextSpan++
+	id := r.nextSpan
+	r.mu.Unlock()
+
+	span := &registrySpan{
+		registry: r,
+		record: SpanRecord{
+			ID:         id,
+			Name:       name,
+			Attributes: make(map[string]any, len(attrs)),
+			Start:      time.Now(),
+		},
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*registrySpan); ok && parent.registry == r {
+		span.record.ParentID = parent.record.ID
+	}
+	span.SetAttributes(attrs...)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+type registrySpan struct {
+	registry *Registry
+	mu       sync.Mutex
+	record   SpanRecord
+}
+
+func (s *registrySpan) SetAttributes(attrs ...Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, attr := range attrs {
+		s.record.Attributes[attr.Key] = attr.Value
+	}
+}
+
+func (s *registrySpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record.Err = err
+}
+
+func (s *registrySpan) End() {
+	s.mu.Lock()
+	s.record.Duration = time.Since(s.record.Start)
+	record := s.record
+	s.mu.Unlock()
+
+	s.registry.mu.Lock()
+	defer s.registry.mu.Unlock()
+	s.registry.spans = append(s.registry.spans, record)
+}
+
+func (r *Registry) Counter(name string) Counter {
+	return registryCounter{registry: r, name: name}
+}
+
+func (r *Registry) Histogram(name string) Histogram {
+	return registryHistogram{registry: r, name: name}
+}
+
+type registryCounter struct {
+	registry *Registry
+	name     string
+}
+
+func (c registryCounter) Add(_ context.Context, value int64, attrs ...Attribute) {
+	series := Series(c.name, append([]Attribute(nil), attrs...)...)
+	c.registry.mu.Lock()
+	defer c.registry.mu.Unlock()
+	c.registry.counters[series] += value
+}
+
+type registryHistogram struct {
+	registry *Registry
+	name     string
+}
+
+func (h registryHistogram) Record(_ context.Context, value float64, attrs ...Attribute) {
+	series := Series(h.name, append([]Attribute(nil), attrs...)...)
+	h.registry.mu.Lock()
+	defer h.registry.mu.Unlock()
+	snapshot, ok := h.registry.histograms[series]
+	if !ok || value < snapshot.Min {
+		snapshot.Min = value
+	}
+	if !ok || value > snapshot.Max {
+		snapshot.Max = value
+	}
+	snapshot.Count++
+	snapshot.Sum += value
+	h.registry.histograms[series] = snapshot
+}
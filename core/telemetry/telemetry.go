@@ -0,0 +1,221 @@
+// Package telemetry defines the tracing and metrics interfaces Anansi reports
+// through, so that an observability stack can be wired in directly instead of
+// scraping persistence events.
+//
+// A Telemetry pairs a Tracer with a Meter. Either may be nil, and the zero
+// Telemetry records nothing. Instrumented components start an Operation for
+// each unit of work; the Operation is a span and, when it ends, three
+// measurements named after its family:
+//
+//	<family>.count     counter, 1 per operation, with an outcome attribute
+//	<family>.duration  histogram, in milliseconds
+//	<family>.rows      counter, the rows the operation returned or affected
+//
+// The families reported by Anansi are:
+//
+//	anansi.collection  collection writes and reads (create, read, update, delete)
+//	anansi.query       query engine stages (partition, execute, residual)
+//	anansi.sqlite      SQLite statements, by statement type (select, insert, ...)
+//	anansi.migration   data migrations between schema versions (migrate)
+//
+// Spans carry every attribute: collection, operation, statement, rows and
+// transaction ID where known. Measurements carry only the low-cardinality
+// ones (collection, operation, statement and outcome), so that metric
+// backends are not flooded with a series per transaction.
+//
+// Log writes spans and measurements to a zap logger. Registry keeps them in
+// memory, for tests, and can publish its measurements through expvar.
+// Adapters for other systems implement Tracer and Meter.
+package telemetry
+
+import (
+	"context"
+	"time"
+)
+
+// Attribute keys set by Anansi.
+const (
+	KeyCollection  = "anansi.collection"
+	KeyOperation   = "anansi.operation"
+	KeyStatement   = "anansi.statement"
+	KeyRows        = "anansi.rows"
+	KeyTransaction = "anansi.transaction_id"
+	KeyOutcome     = "anansi.outcome"
+	KeyError       = "anansi.error"
+)
+
+// Outcomes recorded under KeyOutcome.
+const (
+	OutcomeOK    = "ok"
+	OutcomeError = "error"
+)
+
+// Operation families reported by Anansi.
+const (
+	FamilyCollection = "anansi.collection"
+	FamilyQuery      = "anansi.query"
+	FamilySQLite     = "anansi.sqlite"
+	FamilyMigration  = "anansi.migration"
+)
+
+// metricKeys are the attribute keys measurements keep.
+var metricKeys = map[string]bool{
+	KeyCollection: true,
+	KeyOperation:  true,
+	KeyStatement:  true,
+	KeyOutcome:    true,
+}
+
+// Attribute is a key-value pair describing a span or a measurement. Values
+// are strings, int64s, float64s or bools.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Float returns a floating point attribute.
+func Float(key string, value float64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer starts spans. The returned context carries the span, so spans
+// started from it are its children.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a timed unit of work.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// RecordError marks the span as failed.
+	RecordError(err error)
+	// End finishes the span. It is called exactly once.
+	End()
+}
+
+// Meter provides named instruments. Instruments with the same name are the
+// same instrument, so implementations may create them on demand.
+type Meter interface {
+	Counter(name string) Counter
+	Histogram(name string) Histogram
+}
+
+// Counter is a monotonically increasing sum.
+type Counter interface {
+	Add(ctx context.Context, value int64, attrs ...Attribute)
+}
+
+// Histogram records a distribution of values.
+type Histogram interface {
+	Record(ctx context.Context, value float64, attrs ...Attribute)
+}
+
+// Telemetry is the tracer and meter a component reports through.
+type Telemetry struct {
+	Tracer Tracer
+	Meter  Meter
+}
+
+// Enabled reports whether t records anything.
+func (t Telemetry) Enabled() bool {
+	return t.Tracer != nil || t.Meter != nil
+}
+
+// Start begins an operation of family. The span is named
+// "<family>.<operation>" and carries attrs and KeyOperation. Start returns a
+// nil Operation, whose methods do nothing, when t is not enabled.
+func (t Telemetry) Start(ctx context.Context, family, operation string, attrs ...Attribute) (context.Context, *Operation) {
+	if !t.Enabled() {
+		return ctx, nil
+	}
+	op := &Operation{
+		meter:  t.Meter,
+		family: family,
+		attrs:  append([]Attribute{String(KeyOperation, operation)}, attrs...),
+		start:  time.Now(),
+	}
+	if t.Tracer != nil {
+		ctx, op.span = t.Tracer.Start(ctx, family+"."+operation, op.attrs...)
+	}
+	return ctx, op
+}
+
+// Operation is a unit of work being reported. See Telemetry.Start.
+type Operation struct {
+	span   Span
+	meter  Meter
+	family string
+	attrs  []Attribute
+	rows   *int64
+	start  time.Time
+}
+
+// SetAttributes adds attributes to the operation.
+func (o *Operation) SetAttributes(attrs ...Attribute) {
+	if o == nil {
+		return
+	}
+	o.attrs = append(o.attrs, attrs...)
+	if o.span != nil {
+		o.span.SetAttributes(attrs...)
+	}
+}
+
+// SetRows records the rows the operation returned or affected.
+func (o *Operation) SetRows(rows int64) {
+	if o == nil {
+		return
+	}
+	o.rows = &rows
+	if o.span != nil {
+		o.span.SetAttributes(Int(KeyRows, rows))
+	}
+}
+
+// End finishes the operation, recording err when it failed.
+func (o *Operation) End(ctx context.Context, err error) {
+	if o == nil {
+		return
+	}
+	outcome := OutcomeOK
+	if err != nil {
+		outcome = OutcomeError
+	}
+	if o.span != nil {
+		if err != nil {
+			o.span.RecordError(err)
+		}
+		o.span.End()
+	}
+	if o.meter == nil {
+		return
+	}
+
+	attrs := make([]Attribute, 0, len(o.attrs)+1)
+	for _, attr := range o.attrs {
+		if metricKeys[attr.Key] {
+			attrs = append(attrs, attr)
+		}
+	}
+	attrs = append(attrs, String(KeyOutcome, outcome))
+	o.meter.Counter(o.family+".count").Add(ctx, 1, attrs...)
+	o.meter.Histogram(o.family+".duration").Record(ctx, float64(time.Since(o.start).Microseconds())/1000, attrs...)
+	if o.rows != nil {
+		o.meter.Counter(o.family+".rows").Add(ctx, *o.rows, attrs...)
+	}
+}
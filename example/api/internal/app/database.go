@@ -1,52 +1,35 @@
 package app
 
 import (
-	"database/sql"
 	"fmt"
 
 	"github.com/asaidimu/go-anansi/v8/core/query"
-	"github.com/asaidimu/go-anansi/v8/core/query/native"
-	sqliteExecutor "github.com/asaidimu/go-anansi/v8/sqlite/executor"
-	sqliteQuery "github.com/asaidimu/go-anansi/v8/sqlite/query"
-	_ "github.com/mattn/go-sqlite3" // SQLite driver
+	"github.com/asaidimu/go-anansi/v8/sqlite"
 	"go.uber.org/zap"
 )
 
-// Database holds the database connection and Anansi interactor.
+// Database holds the Anansi interactor and the function that closes its
+// connection pools.
 type Database struct {
-	DB         *sql.DB
 	Interactor query.DatabaseInteractor
+	close      func() error
 }
 
-// NewDatabase initializes a new SQLite database connection and Anansi interactor.
+// NewDatabase opens the SQLite database in WAL mode with a serialized writer
+// and a pool of readers, and wraps it in an Anansi interactor.
 func NewDatabase(cfg *Config, logger *zap.Logger) (*Database, error) {
-	db, err := sql.Open("sqlite3", cfg.DBPath)
+	interactor, closeDB, err := sqlite.NewInteractor(sqlite.Options{Path: cfg.DBPath}, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	_, err = db.Exec("PRAGMA journal_mode=WAL;")
-	if err != nil {
-		return nil, err
-	}
-
-	executor, err := sqliteExecutor.NewSQLiteExecutor(db, logger)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create SQLite interactor: %w", err)
-	}
-	queryFactory := sqliteQuery.NewSQLiteFactory(logger)
-	interactor, err := native.NewNativeInteractor(executor, queryFactory, logger)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create native interactor: %w", err)
-	}
-
 	return &Database{
-		DB:         db,
 		Interactor: interactor,
+		close:      closeDB,
 	}, nil
 }
 
-// Close closes the database connection.
+// Close closes the database connections.
 func (d *Database) Close() error {
-	return d.DB.Close()
+	return d.close()
 }
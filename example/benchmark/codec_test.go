@@ -0,0 +1,158 @@
+// BenchmarkModelCodec compares the two ways a model crosses the document
+// boundary, without persistence in the way:
+//
+//	generated:  schemas.Order implements document.DocumentEncoder and
+//	            DocumentDecoder, so FromStruct and BindTo write and read the
+//	            container slots directly.
+//	reflective: reflectOrder has the same fields and tags but no codec
+//	            methods, so the same calls walk its struct tags.
+//
+//	go test ./example/benchmark/ -run xxx -bench ModelCodec -benchmem
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/document"
+	"github.com/asaidimu/go-anansi/v8/example/benchmark/schemas"
+)
+
+// reflectOrder is schemas.Order without its generated methods.
+type reflectOrder schemas.Order
+
+// encodeDecode builds a document from model and binds it into out.
+func (env *bindEnv) encodeDecode(tb testing.TB, model, out any) {
+	tb.Helper()
+	doc, err := env.orders.FromStruct(model)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	err = doc.BindTo(out)
+	env.orders.Release(doc)
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// BenchmarkModelCodec measures FromStruct followed by BindTo per iteration.
+func BenchmarkModelCodec(b *testing.B) {
+	for _, name := range benchSizes {
+		n := erpLineCounts[name]
+		env := newBindEnv(b, n, 0)
+		doc, err := env.orders.FromJSON(buildOrderPayload(n))
+		if err != nil {
+			b.Fatal(err)
+		}
+		var order schemas.Order
+		if err := doc.BindTo(&order); err != nil {
+			b.Fatal(err)
+		}
+		env.orders.Release(doc)
+		reflected := reflectOrder(order)
+
+		b.Run("generated/"+name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var out schemas.Order
+				env.encodeDecode(b, &order, &out)
+			}
+		})
+		b.Run("reflective/"+name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var out reflectOrder
+				env.encodeDecode(b, &reflected, &out)
+			}
+		})
+	}
+}
+
+// TestModelCodecMatchesReflection checks that the generated codecs build the
+// same documents and structs as the reflective binder.
+func TestModelCodecMatchesReflection(t *testing.T) {
+	if _, ok := any(&schemas.Order{}).(document.DocumentEncoder); !ok {
+		t.Fatal("generated model has no document encoder")
+	}
+	if _, ok := any(&reflectOrder{}).(document.DocumentDecoder); ok {
+		t.Fatal("reflectOrder must not have a document decoder")
+	}
+	env := newBindEnv(t, 4, 0)
+	doc, err := env.orders.FromJSON(buildOrderPayload(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var generated schemas.Order
+	if err := doc.BindTo(&generated); err != nil {
+		t.Fatal(err)
+	}
+	var reflected reflectOrder
+	if err := doc.BindTo(&reflected); err != nil {
+		t.Fatal(err)
+	}
+	env.orders.Release(doc)
+	sameJSON(t, "bound struct", generated, reflected)
+
+	full := func(model any) []byte {
+		d, err := env.orders.FromStruct(model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer env.orders.Release(d)
+		out, err := d.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	if g, r := full(&generated), full(&reflected); !bytes.Equal(g, r) {
+		t.Fatalf("FromStruct differs:\ngenerated  %s\nreflective %s", g, r)
+	}
+
+	patch := schemas.Order{Status: generated.Status, Lines: generated.Lines[:1]}
+	reflectedPatch := reflectOrder(patch)
+	partial := func(model any) []byte {
+		d, err := env.orders.FromPartialStruct(model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer env.orders.Release(d)
+		out, err := d.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	if g, r := partial(&patch), partial(&reflectedPatch); !bytes.Equal(g, r) {
+		t.Fatalf("FromPartialStruct differs:\ngenerated  %s\nreflective %s", g, r)
+	}
+
+	g, err := env.orders.MarshalStruct(&generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := env.orders.MarshalStruct(&reflected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(g, r) {
+		t.Fatalf("MarshalStruct differs:\ngenerated  %s\nreflective %s", g, r)
+	}
+}
+
+func sameJSON(t *testing.T, what string, a, b any) {
+	t.Helper()
+	ja, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jb, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ja, jb) {
+		t.Fatalf("%s differs:\ngenerated  %s\nreflective %s", what, ja, jb)
+	}
+}
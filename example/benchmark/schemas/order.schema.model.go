@@ -18,59 +18,60 @@ import (
 	"github.com/asaidimu/go-anansi/v8/core/document"
 	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
 	"github.com/asaidimu/go-anansi/v8/core/persistence/collection"
+	"github.com/asaidimu/go-anansi/v8/core/query/fields"
 	"go.uber.org/zap"
 	"sync"
 )
 
 type Address struct {
-	City    *string `anansi:"city,required=false" json:"city,omitempty"`
-	Country *string `anansi:"country,required=false" json:"country,omitempty"`
-	Postal  *string `anansi:"postal,required=false" json:"postal,omitempty"`
-	Region  *string `anansi:"region,required=false" json:"region,omitempty"`
-	Street  *string `anansi:"street,required=false" json:"street,omitempty"`
+	City    *string `anansi:"city,required=false,omitempty" json:"city,omitempty"`
+	Country *string `anansi:"country,required=false,omitempty" json:"country,omitempty"`
+	Postal  *string `anansi:"postal,required=false,omitempty" json:"postal,omitempty"`
+	Region  *string `anansi:"region,required=false,omitempty" json:"region,omitempty"`
+	Street  *string `anansi:"street,required=false,omitempty" json:"street,omitempty"`
 }
 
 type Customer struct {
-	Email *string `anansi:"email,required=false" json:"email,omitempty"`
-	ID    *string `anansi:"id,required=false" json:"id,omitempty"`
-	Name  *string `anansi:"name,required=false" json:"name,omitempty"`
-	Tier  *string `anansi:"tier,required=false" json:"tier,omitempty"`
+	Email *string `anansi:"email,required=false,omitempty" json:"email,omitempty"`
+	ID    *string `anansi:"id,required=false,omitempty" json:"id,omitempty"`
+	Name  *string `anansi:"name,required=false,omitempty" json:"name,omitempty"`
+	Tier  *string `anansi:"tier,required=false,omitempty" json:"tier,omitempty"`
 }
 
 type OrderLine struct {
-	Description *string  `anansi:"description,required=false" json:"description,omitempty"`
-	Discount    *float64 `anansi:"discount,required=false" json:"discount,omitempty"`
-	LineTotal   *float64 `anansi:"line_total,required=false" json:"line_total,omitempty"`
-	Qty         *int64   `anansi:"qty,required=false" json:"qty,omitempty"`
-	Sku         *string  `anansi:"sku,required=false" json:"sku,omitempty"`
-	TaxRate     *float64 `anansi:"tax_rate,required=false" json:"tax_rate,omitempty"`
-	UnitPrice   *float64 `anansi:"unit_price,required=false" json:"unit_price,omitempty"`
+	Description *string  `anansi:"description,required=false,omitempty" json:"description,omitempty"`
+	Discount    *float64 `anansi:"discount,required=false,omitempty" json:"discount,omitempty"`
+	LineTotal   *float64 `anansi:"line_total,required=false,omitempty" json:"line_total,omitempty"`
+	Qty         *int64   `anansi:"qty,required=false,omitempty" json:"qty,omitempty"`
+	Sku         *string  `anansi:"sku,required=false,omitempty" json:"sku,omitempty"`
+	TaxRate     *float64 `anansi:"tax_rate,required=false,omitempty" json:"tax_rate,omitempty"`
+	UnitPrice   *float64 `anansi:"unit_price,required=false,omitempty" json:"unit_price,omitempty"`
 }
 
 type OrderMetadata struct {
 	Checksum  string  `anansi:"checksum,required=true" json:"checksum"`
 	Created   string  `anansi:"created,required=true" json:"created"`
 	Updated   string  `anansi:"updated,required=true" json:"updated"`
-	Signature *string `anansi:"signature,required=false" json:"signature,omitempty"`
-	SpanID    *string `anansi:"span_id,required=false" json:"span_id,omitempty"`
-	TraceID   *string `anansi:"trace_id,required=false" json:"trace_id,omitempty"`
+	Signature *string `anansi:"signature,required=false,omitempty" json:"signature,omitempty"`
+	SpanID    *string `anansi:"span_id,required=false,omitempty" json:"span_id,omitempty"`
+	TraceID   *string `anansi:"trace_id,required=false,omitempty" json:"trace_id,omitempty"`
 	Version   float64 `anansi:"version,required=true" json:"version"`
 }
 
 type Order struct {
 	document.DocumentModel
-	Lines           []OrderLine    `anansi:"lines,required=false" json:"lines,omitempty"`
-	Notes           []string       `anansi:"notes,required=false" json:"notes,omitempty"`
+	Lines           []OrderLine    `anansi:"lines,required=false,omitempty" json:"lines,omitempty"`
+	Notes           []string       `anansi:"notes,required=false,omitempty" json:"notes,omitempty"`
 	ID              string         `anansi:"_id_,required=true" json:"_id_"`
-	Metadata        *OrderMetadata `anansi:"_metadata_,required=false" json:"_metadata_,omitempty"`
-	BillingAddress  *Address       `anansi:"billing_address,required=false" json:"billing_address,omitempty"`
-	Currency        *string        `anansi:"currency,required=false,default=EUR" json:"currency,omitempty"`
-	Customer        *Customer      `anansi:"customer,required=false" json:"customer,omitempty"`
-	ShippingAddress *Address       `anansi:"shipping_address,required=false" json:"shipping_address,omitempty"`
-	Status          *string        `anansi:"status,required=false,default=processing" json:"status,omitempty"`
-	Subtotal        *float64       `anansi:"subtotal,required=false" json:"subtotal,omitempty"`
-	Tax             *float64       `anansi:"tax,required=false" json:"tax,omitempty"`
-	Total           *float64       `anansi:"total,required=false" json:"total,omitempty"`
+	Metadata        *OrderMetadata `anansi:"_metadata_,required=false,omitempty" json:"_metadata_,omitempty"`
+	BillingAddress  *Address       `anansi:"billing_address,required=false,omitempty" json:"billing_address,omitempty"`
+	Currency        *string        `anansi:"currency,required=false,default=EUR,omitempty" json:"currency,omitempty"`
+	Customer        *Customer      `anansi:"customer,required=false,omitempty" json:"customer,omitempty"`
+	ShippingAddress *Address       `anansi:"shipping_address,required=false,omitempty" json:"shipping_address,omitempty"`
+	Status          *string        `anansi:"status,required=false,default=processing,omitempty" json:"status,omitempty"`
+	Subtotal        *float64       `anansi:"subtotal,required=false,omitempty" json:"subtotal,omitempty"`
+	Tax             *float64       `anansi:"tax,required=false,omitempty" json:"tax,omitempty"`
+	Total           *float64       `anansi:"total,required=false,omitempty" json:"total,omitempty"`
 }
 
 // GetID returns the document identifier for Order.
@@ -146,3 +147,250 @@ func DangerouslyResetOrdersModel() {
 	_ = ordersModel.Close()
 	ordersModel = nil
 }
+
+// OrderFieldSet holds a typed descriptor for each Order field.
+type OrderFieldSet struct {
+	BillingAddress  AddressFieldSet
+	Currency        fields.String
+	Customer        CustomerFieldSet
+	ID              fields.String
+	Lines           fields.Object
+	Metadata        OrderMetadataFieldSet
+	Notes           fields.Object
+	ShippingAddress AddressFieldSet
+	Status          fields.String
+	Subtotal        fields.Ordered[float64]
+	Tax             fields.Ordered[float64]
+	Total           fields.Ordered[float64]
+}
+
+// OrderFields describes the Order fields for building typed filters, sorts,
+// projections and updates.
+var OrderFields = OrderFieldSet{
+	BillingAddress:  newAddressFieldSet("billing_address"),
+	Currency:        fields.NewString("currency"),
+	Customer:        newCustomerFieldSet("customer"),
+	ID:              fields.NewString("_id_"),
+	Lines:           fields.NewObject("lines"),
+	Metadata:        newOrderMetadataFieldSet("_metadata_"),
+	Notes:           fields.NewObject("notes"),
+	ShippingAddress: newAddressFieldSet("shipping_address"),
+	Status:          fields.NewString("status"),
+	Subtotal:        fields.NewOrdered[float64]("subtotal"),
+	Tax:             fields.NewOrdered[float64]("tax"),
+	Total:           fields.NewOrdered[float64]("total"),
+}
+
+// AddressFieldSet holds a typed descriptor for each Address field.
+type AddressFieldSet struct {
+	fields.Object
+	City    fields.String
+	Country fields.String
+	Postal  fields.String
+	Region  fields.String
+	Street  fields.String
+}
+
+func newAddressFieldSet(path string) AddressFieldSet {
+	return AddressFieldSet{
+		Object:  fields.NewObject(path),
+		City:    fields.NewString(path + ".city"),
+		Country: fields.NewString(path + ".country"),
+		Postal:  fields.NewString(path + ".postal"),
+		Region:  fields.NewString(path + ".region"),
+		Street:  fields.NewString(path + ".street"),
+	}
+}
+
+// CustomerFieldSet holds a typed descriptor for each Customer field.
+type CustomerFieldSet struct {
+	fields.Object
+	Email fields.String
+	ID    fields.String
+	Name  fields.String
+	Tier  fields.String
+}
+
+func newCustomerFieldSet(path string) CustomerFieldSet {
+	return CustomerFieldSet{
+		Object: fields.NewObject(path),
+		Email:  fields.NewString(path + ".email"),
+		ID:     fields.NewString(path + ".id"),
+		Name:   fields.NewString(path + ".name"),
+		Tier:   fields.NewString(path + ".tier"),
+	}
+}
+
+// OrderMetadataFieldSet holds a typed descriptor for each OrderMetadata field.
+type OrderMetadataFieldSet struct {
+	fields.Object
+	Checksum  fields.String
+	Created   fields.String
+	Signature fields.String
+	SpanID    fields.String
+	TraceID   fields.String
+	Updated   fields.String
+	Version   fields.Ordered[float64]
+}
+
+func newOrderMetadataFieldSet(path string) OrderMetadataFieldSet {
+	return OrderMetadataFieldSet{
+		Object:    fields.NewObject(path),
+		Checksum:  fields.NewString(path + ".checksum"),
+		Created:   fields.NewString(path + ".created"),
+		Signature: fields.NewString(path + ".signature"),
+		SpanID:    fields.NewString(path + ".span_id"),
+		TraceID:   fields.NewString(path + ".trace_id"),
+		Updated:   fields.NewString(path + ".updated"),
+		Version:   fields.NewOrdered[float64](path + ".version"),
+	}
+}
+
+// EncodeDocument writes the Order fields into a document without reflection.
+func (m *Order) EncodeDocument(e *document.Encoder) error {
+	if !e.Partial() {
+		e.ID(m.DocumentModel.ID)
+		e.MetadataMap(m.DocumentModel.Metadata)
+	}
+	e.ID(m.ID)
+	if m.Metadata != nil {
+		e.Metadata(m.Metadata)
+	}
+	if m.BillingAddress != nil {
+		e.Object("billing_address", m.BillingAddress)
+	}
+	e.OptString("currency", m.Currency, true)
+	if m.Customer != nil {
+		e.Object("customer", m.Customer)
+	}
+	document.EncodeObjects(e, "lines", m.Lines, true)
+	e.Strings("notes", m.Notes, true)
+	if m.ShippingAddress != nil {
+		e.Object("shipping_address", m.ShippingAddress)
+	}
+	e.OptString("status", m.Status, true)
+	e.OptFloat("subtotal", m.Subtotal, true)
+	e.OptFloat("tax", m.Tax, true)
+	e.OptFloat("total", m.Total, true)
+	return e.Err()
+}
+
+// DecodeDocument reads the Order fields from a document without reflection.
+func (m *Order) DecodeDocument(d *document.Decoder) error {
+	d.Model(&m.DocumentModel, m)
+	m.ID = d.ID()
+	m.Metadata = nil
+	if d.Has("_metadata_") {
+		m.Metadata = &OrderMetadata{}
+		d.Object("_metadata_", m.Metadata)
+	}
+	m.BillingAddress = nil
+	if d.Has("billing_address") {
+		m.BillingAddress = &Address{}
+		d.Object("billing_address", m.BillingAddress)
+	}
+	m.Currency = d.OptString("currency")
+	m.Customer = nil
+	if d.Has("customer") {
+		m.Customer = &Customer{}
+		d.Object("customer", m.Customer)
+	}
+	m.Lines = document.DecodeObjects[OrderLine](d, "lines")
+	m.Notes = d.Strings("notes")
+	m.ShippingAddress = nil
+	if d.Has("shipping_address") {
+		m.ShippingAddress = &Address{}
+		d.Object("shipping_address", m.ShippingAddress)
+	}
+	m.Status = d.OptString("status")
+	m.Subtotal = d.OptFloat("subtotal")
+	m.Tax = d.OptFloat("tax")
+	m.Total = d.OptFloat("total")
+	return d.Err()
+}
+
+// EncodeDocument writes the Address fields into a document without reflection.
+func (m *Address) EncodeDocument(e *document.Encoder) error {
+	e.OptString("city", m.City, true)
+	e.OptString("country", m.Country, true)
+	e.OptString("postal", m.Postal, true)
+	e.OptString("region", m.Region, true)
+	e.OptString("street", m.Street, true)
+	return e.Err()
+}
+
+// DecodeDocument reads the Address fields from a document without reflection.
+func (m *Address) DecodeDocument(d *document.Decoder) error {
+	m.City = d.OptString("city")
+	m.Country = d.OptString("country")
+	m.Postal = d.OptString("postal")
+	m.Region = d.OptString("region")
+	m.Street = d.OptString("street")
+	return d.Err()
+}
+
+// EncodeDocument writes the Customer fields into a document without reflection.
+func (m *Customer) EncodeDocument(e *document.Encoder) error {
+	e.OptString("email", m.Email, true)
+	e.OptString("id", m.ID, true)
+	e.OptString("name", m.Name, true)
+	e.OptString("tier", m.Tier, true)
+	return e.Err()
+}
+
+// DecodeDocument reads the Customer fields from a document without reflection.
+func (m *Customer) DecodeDocument(d *document.Decoder) error {
+	m.Email = d.OptString("email")
+	m.ID = d.OptString("id")
+	m.Name = d.OptString("name")
+	m.Tier = d.OptString("tier")
+	return d.Err()
+}
+
+// EncodeDocument writes the OrderLine fields into a document without reflection.
+func (m *OrderLine) EncodeDocument(e *document.Encoder) error {
+	e.OptString("description", m.Description, true)
+	e.OptFloat("discount", m.Discount, true)
+	e.OptFloat("line_total", m.LineTotal, true)
+	e.OptInt("qty", m.Qty, true)
+	e.OptString("sku", m.Sku, true)
+	e.OptFloat("tax_rate", m.TaxRate, true)
+	e.OptFloat("unit_price", m.UnitPrice, true)
+	return e.Err()
+}
+
+// DecodeDocument reads the OrderLine fields from a document without reflection.
+func (m *OrderLine) DecodeDocument(d *document.Decoder) error {
+	m.Description = d.OptString("description")
+	m.Discount = d.OptFloat("discount")
+	m.LineTotal = d.OptFloat("line_total")
+	m.Qty = d.OptInt("qty")
+	m.Sku = d.OptString("sku")
+	m.TaxRate = d.OptFloat("tax_rate")
+	m.UnitPrice = d.OptFloat("unit_price")
+	return d.Err()
+}
+
+// EncodeDocument writes the OrderMetadata fields into a document without reflection.
+func (m *OrderMetadata) EncodeDocument(e *document.Encoder) error {
+	e.String("checksum", m.Checksum, false)
+	e.String("created", m.Created, false)
+	e.OptString("signature", m.Signature, true)
+	e.OptString("span_id", m.SpanID, true)
+	e.OptString("trace_id", m.TraceID, true)
+	e.String("updated", m.Updated, false)
+	e.Float("version", m.Version, false)
+	return e.Err()
+}
+
+// DecodeDocument reads the OrderMetadata fields from a document without reflection.
+func (m *OrderMetadata) DecodeDocument(d *document.Decoder) error {
+	m.Checksum = d.String("checksum")
+	m.Created = d.String("created")
+	m.Signature = d.OptString("signature")
+	m.SpanID = d.OptString("span_id")
+	m.TraceID = d.OptString("trace_id")
+	m.Updated = d.String("updated")
+	m.Version = d.Float("version")
+	return d.Err()
+}
@@ -0,0 +1,14 @@
+package rest
+
+import "github.com/asaidimu/go-anansi/v8/core/common"
+
+// Pre-defined errors for the rest package.
+var (
+	ErrInvalidBody          = common.NewSystemError("ERR_REST_INVALID_BODY", "request body is not valid JSON for this endpoint")
+	ErrInvalidQuery         = common.NewSystemError("ERR_REST_INVALID_QUERY", "query parameters are not valid")
+	ErrInvalidPrecondition  = common.NewSystemError("ERR_REST_INVALID_PRECONDITION", "If-Match must be a single entity tag or *")
+	ErrDocumentNotFound     = common.NewSystemError("ERR_REST_DOCUMENT_NOT_FOUND", "document not found")
+	ErrPreconditionFailed   = common.NewSystemError("ERR_REST_PRECONDITION_FAILED", "document version does not match If-Match")
+	ErrPreconditionRequired = common.NewSystemError("ERR_REST_PRECONDITION_REQUIRED", "this request requires an If-Match header")
+	ErrBulkTooLarge         = common.NewSystemError("ERR_REST_BULK_TOO_LARGE", "bulk request exceeds the configured document limit")
+)
@@ -0,0 +1,396 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/utils"
+)
+
+// List is the body of list and query responses.
+type List struct {
+	Data       []map[string]any      `json:"data"`
+	Count      int                   `json:"count"`
+	Total      *int                  `json:"total,omitempty"`
+	Pagination *query.PaginationInfo `json:"pagination,omitempty"`
+}
+
+// BulkRequest is the body of a bulk create.
+type BulkRequest struct {
+	Documents []map[string]any `json:"documents"`
+}
+
+// BulkResponse is the body of a bulk create response: the created
+// documents, in request order.
+type BulkResponse struct {
+	Documents []map[string]any `json:"documents"`
+}
+
+// collectionRoutes serves the routes of one collection.
+type collectionRoutes struct {
+	h    *Handler
+	name string
+	coll base.Collection
+}
+
+func (cr *collectionRoutes) context(r *http.Request) context.Context {
+	return common.ContextWithCollectionName(r.Context(), cr.name)
+}
+
+func (cr *collectionRoutes) list(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := &query.Query{}
+	if raw := params.Get("query"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), q); err != nil {
+			WriteProblem(w, r, ErrInvalidQuery.WithPath("query").WithCause(err))
+			return
+		}
+	}
+	if err := applyPaging(q, params); err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	cr.read(w, r, q)
+}
+
+func (cr *collectionRoutes) query(w http.ResponseWriter, r *http.Request) {
+	q := &query.Query{}
+	if err := cr.h.decode(w, r, q); err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	cr.read(w, r, q)
+}
+
+func (cr *collectionRoutes) read(w http.ResponseWriter, r *http.Request, q *query.Query) {
+	ctx := cr.context(r)
+	result, err := cr.coll.Read(ctx, q)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	defer releaseAll(result.Data)
+
+	docs, err := result.Data.Sanitize(ctx)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, List{
+		Data:       docs.ToMaps(),
+		Count:      result.Count,
+		Total:      result.Total,
+		Pagination: result.PaginationInfo,
+	})
+}
+
+func (cr *collectionRoutes) get(w http.ResponseWriter, r *http.Request) {
+	ctx := cr.context(r)
+	doc, err := cr.find(ctx, r.PathValue("id"))
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	defer doc.Release()
+
+	etag := ETag(doc)
+	if etag != "" && r.Header.Get("If-None-Match") == etag {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	cr.writeDocument(w, r, ctx, http.StatusOK, doc)
+}
+
+func (cr *collectionRoutes) create(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if err := cr.h.decode(w, r, &body); err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	ctx := cr.context(r)
+	doc, err := data.NewDocument(numbers(body), ctx)
+	if err != nil {
+		WriteProblem(w, r, ErrInvalidBody.WithCause(err))
+		return
+	}
+
+	res, err := cr.coll.CreateOne(ctx, doc)
+	if err == nil {
+		err = createError(res)
+	}
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	w.Header().Set("Location", cr.h.prefix+"/"+cr.name+"/"+url.PathEscape(res.Data.ID()))
+	cr.writeDocument(w, r, ctx, http.StatusCreated, res.Data)
+}
+
+func (cr *collectionRoutes) bulk(w http.ResponseWriter, r *http.Request) {
+	var body BulkRequest
+	if err := cr.h.decode(w, r, &body); err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	if cr.h.maxBulk > 0 && len(body.Documents) > cr.h.maxBulk {
+		WriteProblem(w, r, ErrBulkTooLarge.WithMessagef("bulk request has %d documents, the limit is %d", len(body.Documents), cr.h.maxBulk))
+		return
+	}
+
+	ctx := cr.context(r)
+	docs := make([]data.Documenter, len(body.Documents))
+	for i, m := range body.Documents {
+		doc, err := data.NewDocument(numbers(m), ctx)
+		if err != nil {
+			WriteProblem(w, r, ErrInvalidBody.WithPath("documents["+strconv.Itoa(i)+"]").WithCause(err))
+			return
+		}
+		docs[i] = doc
+	}
+
+	// CreateMany is all or nothing: when any document fails, none is stored
+	// and the problem lists the failures by index.
+	results, err := cr.coll.CreateMany(ctx, docs)
+	if err == nil {
+		for _, res := range results {
+			if err = createError(res); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+
+	resp := BulkResponse{Documents: make([]map[string]any, len(results))}
+	for i, res := range results {
+		safe, err := res.Data.Sanitize(ctx)
+		if err != nil {
+			WriteProblem(w, r, err)
+			return
+		}
+		resp.Documents[i] = safe.ToMap()
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (cr *collectionRoutes) patch(w http.ResponseWriter, r *http.Request) {
+	version, err := cr.precondition(r)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	var body map[string]any
+	if err := cr.h.decode(w, r, &body); err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+
+	ctx := cr.context(r)
+	id := r.PathValue("id")
+	params := &base.CollectionUpdate{
+		Filter:         idFilter(id, nil),
+		Set:            data.Patch(numbers(body).(map[string]any)).Document(ctx),
+		Version:        version,
+		ReturnDocument: utils.BoolPtr(true),
+	}
+	result, err := cr.coll.Update(ctx, params)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	if result.Count == 0 {
+		WriteProblem(w, r, cr.missed(ctx, id, version))
+		return
+	}
+	defer releaseAll(result.Data)
+
+	// The update succeeded; the returned copy is only missing when the
+	// post-update fetch failed, so fetch it again.
+	var doc data.Documenter
+	if len(result.Data) > 0 {
+		doc = result.Data[0]
+	} else {
+		if doc, err = cr.find(ctx, id); err != nil {
+			WriteProblem(w, r, err)
+			return
+		}
+		defer doc.Release()
+	}
+	cr.writeDocument(w, r, ctx, http.StatusOK, doc)
+}
+
+func (cr *collectionRoutes) delete(w http.ResponseWriter, r *http.Request) {
+	version, err := cr.precondition(r)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	ctx := cr.context(r)
+	id := r.PathValue("id")
+	count, err := cr.coll.Delete(ctx, idFilter(id, version), false)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	if count == 0 {
+		WriteProblem(w, r, cr.missed(ctx, id, version))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// find reads one document by id.
+func (cr *collectionRoutes) find(ctx context.Context, id string) (data.Documenter, error) {
+	q := query.NewQueryBuilder().Where(data.DocumentIDField).Eq(id).Build()
+	result, err := cr.coll.Read(ctx, &q)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Data) == 0 {
+		return nil, ErrDocumentNotFound.WithPath(id)
+	}
+	for _, extra := range result.Data[1:] {
+		extra.Release()
+	}
+	return result.Data[0], nil
+}
+
+// missed explains why a write by id touched nothing: the document is gone, or
+// it exists at another version than If-Match named.
+func (cr *collectionRoutes) missed(ctx context.Context, id string, version *int) error {
+	doc, err := cr.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer doc.Release()
+	if version != nil {
+		return ErrPreconditionFailed.WithPath(id)
+	}
+	return ErrDocumentNotFound.WithPath(id)
+}
+
+// precondition reads If-Match. It returns nil for "*" and, unless If-Match is
+// required, for an absent header.
+func (cr *collectionRoutes) precondition(r *http.Request) (*int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch header {
+	case "":
+		if cr.h.requireIfMatch {
+			return nil, ErrPreconditionRequired
+		}
+		return nil, nil
+	case "*":
+		return nil, nil
+	}
+	version, ok := ParseETag(header)
+	if !ok {
+		return nil, ErrInvalidPrecondition.WithPath(header)
+	}
+	return &version, nil
+}
+
+func (cr *collectionRoutes) writeDocument(w http.ResponseWriter, r *http.Request, ctx context.Context, status int, doc data.Documenter) {
+	safe, err := doc.Sanitize(ctx)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	if etag := ETag(doc); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	writeJSON(w, status, safe.ToMap())
+}
+
+// ETag returns the entity tag of a document: its metadata version, quoted.
+// Documents without a version have no tag.
+func ETag(doc data.DocumentReader) string {
+	version, err := doc.Version()
+	if err != nil {
+		return ""
+	}
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ParseETag reverses ETag. Weak tags are accepted, since the version names
+// the same document state either way.
+func ParseETag(tag string) (int, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	raw, err := strconv.Unquote(tag)
+	if err != nil {
+		return 0, false
+	}
+	version, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// createError turns a non-created result into an error.
+func createError(res base.CreateResult) error {
+	switch {
+	case res.Status == base.StatusCreated:
+		return nil
+	case res.Error != nil:
+		return res.Error.WithIssues(res.Issues)
+	default:
+		return base.ErrValidationFailed.WithIssues(res.Issues)
+	}
+}
+
+// idFilter matches one document, optionally at a given metadata version.
+func idFilter(id string, version *int) *query.QueryFilter {
+	qb := query.NewQueryBuilder().Where(data.DocumentIDField).Eq(id)
+	if version != nil {
+		qb = qb.Where(data.MetadataFieldPath(data.MetadataVersion)).Eq(*version)
+	}
+	return qb.Build().Filters
+}
+
+// applyPaging applies the limit and offset parameters as offset pagination.
+func applyPaging(q *query.Query, params url.Values) error {
+	limitParam, offsetParam := params.Get("limit"), params.Get("offset")
+	if limitParam == "" && offsetParam == "" {
+		return nil
+	}
+	p := &query.PaginationOptions{Type: query.PaginationTypeOffset}
+	if q.Pagination != nil {
+		*p = *q.Pagination
+	}
+	if limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			return ErrInvalidQuery.WithPath("limit").WithMessage("limit must be a positive integer")
+		}
+		p.Limit = limit
+	}
+	if offsetParam != "" {
+		offset, err := strconv.Atoi(offsetParam)
+		if err != nil || offset < 0 {
+			return ErrInvalidQuery.WithPath("offset").WithMessage("offset must be a non-negative integer")
+		}
+		p.Offset = &offset
+	}
+	if p.Limit <= 0 {
+		return ErrInvalidQuery.WithPath("limit").WithMessage("offset requires a limit")
+	}
+	q.Pagination = p
+	return nil
+}
+
+func releaseAll(docs data.DocumentSet) {
+	for _, d := range docs {
+		d.Release()
+	}
+}
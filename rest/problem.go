@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+)
+
+// ProblemContentType is the media type of error responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details body. Code carries the SystemError
+// code and Issues its validation issues, so clients can branch on the same
+// values Go callers see.
+type Problem struct {
+	Type     string        `json:"type"`
+	Title    string        `json:"title"`
+	Status   int           `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Instance string        `json:"instance,omitempty"`
+	Code     string        `json:"code,omitempty"`
+	Issues   common.Issues `json:"issues,omitempty"`
+}
+
+// statusByCode maps error codes to HTTP statuses. The first code in the error
+// chain with an entry decides the status.
+var statusByCode = map[string]int{
+	ErrInvalidBody.Code:          http.StatusBadRequest,
+	ErrInvalidQuery.Code:         http.StatusBadRequest,
+	ErrInvalidPrecondition.Code:  http.StatusBadRequest,
+	ErrDocumentNotFound.Code:     http.StatusNotFound,
+	ErrPreconditionFailed.Code:   http.StatusPreconditionFailed,
+	ErrPreconditionRequired.Code: http.StatusPreconditionRequired,
+	ErrBulkTooLarge.Code:         http.StatusRequestEntityTooLarge,
+
+	base.ErrValidationFailed.Code:                    http.StatusUnprocessableEntity,
+	base.ErrImmutableFieldModification.Code:          http.StatusUnprocessableEntity,
+	base.ErrUniqueConstraintViolation.Code:           http.StatusConflict,
+	base.ErrCollectionNotFound.Code:                  http.StatusNotFound,
+	base.ErrEmptyUpdate.Code:                         http.StatusBadRequest,
+	base.ErrInvalidUpdateParams.Code:                 http.StatusBadRequest,
+	base.ErrDangerousUpdate.Code:                     http.StatusBadRequest,
+	base.ErrDangerousDelete.Code:                     http.StatusBadRequest,
+	base.ErrDeleteRequiresFilter.Code:                http.StatusBadRequest,
+	base.ErrExplicitMetadataProjectionForbidden.Code: http.StatusBadRequest,
+	base.ErrPersistenceClosed.Code:                   http.StatusServiceUnavailable,
+	base.ErrTransactionTimeout.Code:                  http.StatusServiceUnavailable,
+}
+
+// statusOfCode resolves a single code, falling back to the naming families
+// shared across backends.
+func statusOfCode(code string) (int, bool) {
+	if status, ok := statusByCode[code]; ok {
+		return status, true
+	}
+	switch {
+	case strings.HasSuffix(code, "_UNIQUE_CONSTRAINT_VIOLATION"):
+		return http.StatusConflict, true
+	case strings.HasPrefix(code, "ERR_QUERY_"):
+		return http.StatusBadRequest, true
+	}
+	return 0, false
+}
+
+// StatusOf returns the HTTP status for err. Errors with no known code in their
+// chain are 422 when they carry validation issues and 500 otherwise.
+func StatusOf(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	withIssues := false
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		se, ok := e.(*common.SystemError)
+		if !ok {
+			continue
+		}
+		if status, ok := statusOfCode(se.Code); ok {
+			return status
+		}
+		if se.Issues.HasErrors() {
+			withIssues = true
+		}
+	}
+	if withIssues {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// ProblemOf builds the problem body for err. Server errors keep their code but
+// not their message, which may describe internals.
+func ProblemOf(err error) Problem {
+	status := StatusOf(err)
+	p := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+	var se *common.SystemError
+	if !errors.As(err, &se) {
+		if status < http.StatusInternalServerError {
+			p.Detail = err.Error()
+		}
+		return p
+	}
+	p.Code = se.Code
+	if status < http.StatusInternalServerError {
+		p.Detail = se.Message
+		if p.Detail == "" {
+			p.Detail = se.Error()
+		}
+	}
+	for e := error(se); e != nil; e = errors.Unwrap(e) {
+		if inner, ok := e.(*common.SystemError); ok && len(inner.Issues) > 0 {
+			p.Issues = inner.Issues
+			break
+		}
+	}
+	return p
+}
+
+// WriteProblem writes err as an application/problem+json response.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	p := ProblemOf(err)
+	if r != nil {
+		p.Instance = r.URL.Path
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,153 @@
+// Package rest serves collections over HTTP with a fixed set of REST routes.
+//
+// For every registered collection, Mount adds these routes under the prefix:
+//
+//	GET    {prefix}/{collection}        list; ?query=<query JSON>&limit=&offset=
+//	POST   {prefix}/{collection}        create one document
+//	POST   {prefix}/{collection}/query  list with the query DSL in the body
+//	POST   {prefix}/{collection}/bulk   create many documents, all or none
+//	GET    {prefix}/{collection}/{id}   get by id
+//	PATCH  {prefix}/{collection}/{id}   partial update (base.CollectionUpdate)
+//	DELETE {prefix}/{collection}/{id}   delete by id
+//
+// Documents travel as their JSON maps, including _id_ and _metadata_. Single
+// document responses carry an ETag built from the metadata version; PATCH and
+// DELETE honour If-Match against it, and GET honours If-None-Match. Errors are
+// written as application/problem+json (see WriteProblem).
+//
+// The codegen/openapi package describes the same routes as an OpenAPI 3.1
+// document.
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+)
+
+const (
+	// DefaultMaxBulk is the default document limit of a bulk request.
+	DefaultMaxBulk = 1000
+	// DefaultMaxBodyBytes is the default request body limit.
+	DefaultMaxBodyBytes = 8 << 20
+)
+
+// Handler mounts REST routes for a set of collections.
+type Handler struct {
+	collections    map[string]base.Collection
+	prefix         string
+	maxBulk        int
+	maxBodyBytes   int64
+	requireIfMatch bool
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithPrefix mounts the routes under prefix (e.g. "/api/v1"). The default is
+// no prefix.
+func WithPrefix(prefix string) Option {
+	return func(h *Handler) { h.prefix = strings.TrimRight(prefix, "/") }
+}
+
+// WithMaxBulk limits the number of documents in one bulk request.
+func WithMaxBulk(n int) Option {
+	return func(h *Handler) { h.maxBulk = n }
+}
+
+// WithMaxBodyBytes limits the size of request bodies.
+func WithMaxBodyBytes(n int64) Option {
+	return func(h *Handler) { h.maxBodyBytes = n }
+}
+
+// WithRequireIfMatch makes PATCH and DELETE fail with 428 unless the request
+// carries If-Match, ruling out blind overwrites.
+func WithRequireIfMatch() Option {
+	return func(h *Handler) { h.requireIfMatch = true }
+}
+
+// New returns a Handler serving collections, keyed by the name used in their
+// routes.
+func New(collections map[string]base.Collection, opts ...Option) *Handler {
+	h := &Handler{
+		collections:  make(map[string]base.Collection, len(collections)),
+		maxBulk:      DefaultMaxBulk,
+		maxBodyBytes: DefaultMaxBodyBytes,
+	}
+	for name, c := range collections {
+		h.collections[name] = c
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Mount registers the routes of every collection on mux.
+func (h *Handler) Mount(mux *http.ServeMux) {
+	names := make([]string, 0, len(h.collections))
+	for name := range h.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cr := &collectionRoutes{h: h, name: name, coll: h.collections[name]}
+		path := h.prefix + "/" + name
+		mux.HandleFunc("GET "+path, cr.list)
+		mux.HandleFunc("POST "+path, cr.create)
+		mux.HandleFunc("POST "+path+"/query", cr.query)
+		mux.HandleFunc("POST "+path+"/bulk", cr.bulk)
+		mux.HandleFunc("GET "+path+"/{id}", cr.get)
+		mux.HandleFunc("PATCH "+path+"/{id}", cr.patch)
+		mux.HandleFunc("DELETE "+path+"/{id}", cr.delete)
+	}
+}
+
+// decode reads a JSON request body into v within the body limit. Numbers
+// decode as json.Number so integers survive; see numbers.
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v any) error {
+	body := http.MaxBytesReader(w, r.Body, h.maxBodyBytes)
+	defer body.Close()
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return ErrInvalidBody.WithCause(err)
+	}
+	return nil
+}
+
+// numbers replaces json.Number values with int64 when integral and float64
+// otherwise, the types document validation expects.
+func numbers(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]any:
+		for k, e := range val {
+			val[k] = numbers(e)
+		}
+		return val
+	case []any:
+		for i, e := range val {
+			val[i] = numbers(e)
+		}
+		return val
+	}
+	return v
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		_ = json.NewEncoder(w).Encode(v)
+	}
+}
@@ -0,0 +1,222 @@
+package rest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/schema/meta"
+	"github.com/asaidimu/go-anansi/v8/rest"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newServer(t *testing.T, opts ...rest.Option) *httptest.Server {
+	t.Helper()
+	interactor, cleanup := testutils.CreateNativeInteractor(t)
+	t.Cleanup(cleanup)
+	p, err := persistence.NewPersistence(interactor, nil, zap.NewNop(), nil)
+	require.NoError(t, err)
+	s := testutils.NewTestSchema("people")
+	meta.NormalizeSchema(s)
+	coll, err := p.CreateCollection(context.Background(), s)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	rest.New(map[string]base.Collection{"people": coll}, append([]rest.Option{rest.WithPrefix("/api/")}, opts...)...).Mount(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func do(t *testing.T, srv *httptest.Server, method, path string, body any, header ...string) (*http.Response, map[string]any) {
+	t.Helper()
+	var reader *bytes.Reader
+	if s, ok := body.(string); ok {
+		reader = bytes.NewReader([]byte(s))
+	} else {
+		raw, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, srv.URL+path, reader)
+	require.NoError(t, err)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var out map[string]any
+	if resp.ContentLength != 0 && resp.StatusCode != http.StatusNotModified {
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+	}
+	return resp, out
+}
+
+func create(t *testing.T, srv *httptest.Server, name string, age int) (string, string) {
+	t.Helper()
+	resp, doc := do(t, srv, http.MethodPost, "/api/people", map[string]any{"name": name, "age": age})
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "%v", doc)
+	id, _ := doc[data.DocumentIDField].(string)
+	require.NotEmpty(t, id)
+	return id, resp.Header.Get("ETag")
+}
+
+func TestCreateAndGet(t *testing.T) {
+	srv := newServer(t)
+	id, etag := create(t, srv, "Ada", 36)
+	require.NotEmpty(t, etag)
+
+	resp, doc := do(t, srv, http.MethodGet, "/api/people/"+id, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "Ada", doc["name"])
+	assert.Equal(t, etag, resp.Header.Get("ETag"))
+
+	resp, _ = do(t, srv, http.MethodGet, "/api/people/"+id, nil, "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp, problem := do(t, srv, http.MethodGet, "/api/people/missing", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, rest.ProblemContentType, resp.Header.Get("Content-Type"))
+	assert.Equal(t, rest.ErrDocumentNotFound.Code, problem["code"])
+}
+
+func TestCreate_ValidationProblem(t *testing.T) {
+	srv := newServer(t)
+	resp, problem := do(t, srv, http.MethodPost, "/api/people", map[string]any{"age": 3})
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "%v", problem)
+	assert.Equal(t, rest.ProblemContentType, resp.Header.Get("Content-Type"))
+	assert.EqualValues(t, http.StatusUnprocessableEntity, problem["status"])
+	assert.NotEmpty(t, problem["issues"])
+
+	resp, problem = do(t, srv, http.MethodPost, "/api/people", "{not json")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, rest.ErrInvalidBody.Code, problem["code"])
+}
+
+func TestListAndQuery(t *testing.T) {
+	srv := newServer(t)
+	create(t, srv, "Ada", 36)
+	create(t, srv, "Grace", 45)
+	create(t, srv, "Linus", 28)
+
+	resp, list := do(t, srv, http.MethodGet, "/api/people", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, list["data"], 3)
+
+	q := `{"filters":{"condition":{"field":"age","operator":"gt","value":30}}}`
+	resp, list = do(t, srv, http.MethodGet, "/api/people?query="+url.QueryEscape(q), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "%v", list)
+	assert.Len(t, list["data"], 2)
+
+	resp, list = do(t, srv, http.MethodPost, "/api/people/query", q)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "%v", list)
+	assert.Len(t, list["data"], 2)
+
+	resp, list = do(t, srv, http.MethodGet, "/api/people?limit=2&offset=0", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "%v", list)
+	assert.Len(t, list["data"], 2)
+
+	resp, problem := do(t, srv, http.MethodGet, "/api/people?limit=nope", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, rest.ErrInvalidQuery.Code, problem["code"])
+}
+
+func TestPatch_IfMatch(t *testing.T) {
+	srv := newServer(t)
+	id, etag := create(t, srv, "Ada", 36)
+
+	resp, doc := do(t, srv, http.MethodPatch, "/api/people/"+id, map[string]any{"age": 37}, "If-Match", etag)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "%v", doc)
+	assert.EqualValues(t, 37, doc["age"])
+	next := resp.Header.Get("ETag")
+	require.NotEmpty(t, next)
+	assert.NotEqual(t, etag, next)
+
+	// The old tag no longer matches.
+	resp, problem := do(t, srv, http.MethodPatch, "/api/people/"+id, map[string]any{"age": 38}, "If-Match", etag)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	assert.Equal(t, rest.ErrPreconditionFailed.Code, problem["code"])
+
+	resp, _ = do(t, srv, http.MethodPatch, "/api/people/missing", map[string]any{"age": 1})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = do(t, srv, http.MethodPatch, "/api/people/"+id, map[string]any{"age": 1}, "If-Match", "not-a-tag")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	srv := newServer(t, rest.WithRequireIfMatch())
+	id, etag := create(t, srv, "Ada", 36)
+
+	resp, problem := do(t, srv, http.MethodDelete, "/api/people/"+id, nil)
+	assert.Equal(t, http.StatusPreconditionRequired, resp.StatusCode)
+	assert.Equal(t, rest.ErrPreconditionRequired.Code, problem["code"])
+
+	resp, _ = do(t, srv, http.MethodDelete, "/api/people/"+id, nil, "If-Match", etag)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestDelete(t *testing.T) {
+	srv := newServer(t)
+	id, etag := create(t, srv, "Ada", 36)
+
+	resp, _ := do(t, srv, http.MethodDelete, "/api/people/"+id, nil, "If-Match", `"999"`)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	resp, _ = do(t, srv, http.MethodDelete, "/api/people/"+id, nil, "If-Match", etag)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, _ = do(t, srv, http.MethodDelete, "/api/people/"+id, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestBulk(t *testing.T) {
+	srv := newServer(t, rest.WithMaxBulk(3))
+
+	resp, body := do(t, srv, http.MethodPost, "/api/people/bulk", rest.BulkRequest{Documents: []map[string]any{
+		{"name": "Ada"}, {"name": "Grace"},
+	}})
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "%v", body)
+	assert.Len(t, body["documents"], 2)
+
+	// One invalid document fails the whole request.
+	resp, body = do(t, srv, http.MethodPost, "/api/people/bulk", rest.BulkRequest{Documents: []map[string]any{
+		{"name": "Linus"}, {"age": 4},
+	}})
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "%v", body)
+	issues := body["issues"].([]any)
+	require.Len(t, issues, 1)
+	assert.EqualValues(t, 1, issues[0].(map[string]any)["index"])
+	resp, list := do(t, srv, http.MethodGet, "/api/people", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, list["data"], 2)
+
+	resp, body = do(t, srv, http.MethodPost, "/api/people/bulk", rest.BulkRequest{Documents: make([]map[string]any, 4)})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.Equal(t, rest.ErrBulkTooLarge.Code, body["code"])
+}
+
+func TestStatusOf(t *testing.T) {
+	assert.Equal(t, http.StatusUnprocessableEntity, rest.StatusOf(base.ErrValidationFailed))
+	assert.Equal(t, http.StatusConflict, rest.StatusOf(common.NewSystemError("ERR_NATIVE_UNIQUE_CONSTRAINT_VIOLATION")))
+	assert.Equal(t, http.StatusBadRequest, rest.StatusOf(common.NewSystemError("ERR_QUERY_INVALID_SORT_DIRECTION")))
+	assert.Equal(t, http.StatusNotFound, rest.StatusOf(common.NewSystemError("ERR_WRAPPER").WithCause(rest.ErrDocumentNotFound)))
+	assert.Equal(t, http.StatusInternalServerError, rest.StatusOf(common.NewSystemError("ERR_SOMETHING")))
+
+	p := rest.ProblemOf(common.NewSystemError("ERR_SOMETHING", "secret internals"))
+	assert.Equal(t, "ERR_SOMETHING", p.Code)
+	assert.Empty(t, p.Detail)
+}
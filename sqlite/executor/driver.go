@@ -0,0 +1,118 @@
+package executor
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// DriverName is the database/sql driver under which the SQLite driver is
+// registered with Anansi's SQL functions attached to every connection. Open
+// databases with it, rather than the plain "sqlite3" driver, to evaluate
+// regex filters in SQL:
+//
+//	db, err := sql.Open(executor.DriverName, "file:app.db?_fk=1")
+const DriverName = "sqlite3_anansi"
+
+func init() {
+	sql.Register(DriverName, &sqlite3.SQLiteDriver{ConnectHook: RegisterFunctions})
+}
+
+// RegisterFunctions attaches Anansi's SQL functions to conn. It is the
+// ConnectHook of DriverName, and can be called from the hook of a custom
+// driver registration that needs other extensions as well.
+//
+// regexp(pattern, value) backs the REGEXP operator, so "value REGEXP pattern"
+// is true when value is text matching the Go RE2 pattern.
+func RegisterFunctions(conn *sqlite3.SQLiteConn) error {
+	return conn.RegisterFunc("regexp", regexpMatch, true)
+}
+
+// NewConnector returns a connector that opens dsn with Anansi's SQL functions
+// registered and runs pragmas, in order, on every new connection. Use it with
+// sql.OpenDB when connections need settings the DSN cannot carry, such as
+// mmap_size or query_only:
+//
+//	db := sql.OpenDB(executor.NewConnector("app.db", "PRAGMA mmap_size = 268435456"))
+func NewConnector(dsn string, pragmas ...string) driver.Connector {
+	return &connector{
+		dsn: dsn,
+		driver: &sqlite3.SQLiteDriver{ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := RegisterFunctions(conn); err != nil {
+				return err
+			}
+			for _, pragma := range pragmas {
+				if _, err := conn.Exec(pragma, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+	}
+}
+
+type connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// maxCachedPatterns bounds the compiled pattern cache; it is cleared when
+// full rather than evicting entries one by one.
+const maxCachedPatterns = 256
+
+var (
+	patternsMu sync.Mutex
+	patterns   = make(map[string]*regexp.Regexp)
+)
+
+// regexpMatch implements regexp(pattern, value). NULL and non-text values
+// never match.
+func regexpMatch(pattern string, value any) (bool, error) {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case []byte:
+		if v == nil {
+			return false, nil
+		}
+		text = string(v)
+	default:
+		return false, nil
+	}
+
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(text), nil
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patternsMu.Lock()
+	defer patternsMu.Unlock()
+	if re, ok := patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) >= maxCachedPatterns {
+		clear(patterns)
+	}
+	patterns[pattern] = re
+	return re, nil
+}
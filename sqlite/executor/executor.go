@@ -4,12 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/asaidimu/go-anansi/v8/core/common"
 	"github.com/asaidimu/go-anansi/v8/core/document"
 	"github.com/asaidimu/go-anansi/v8/core/query"
 	"github.com/asaidimu/go-anansi/v8/core/query/native"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
 	"github.com/asaidimu/go-anansi/v8/sqlite/types"
 	"go.uber.org/zap"
 )
@@ -29,31 +32,114 @@ type sqliteExecutor struct {
 	db     *sql.DB
 	tx     *sql.Tx
 	logger *zap.Logger
+
+	// reader, when set, is a read-only pool that serves SELECTs issued
+	// outside a transaction, leaving db to writes. See NewPooledSQLiteExecutor.
+	reader *sql.DB
+
+	// cache holds prepared statements when WithStatementCache is set; a
+	// transaction executor shares its parent's cache and rebinds statements
+	// into txStmts.
+	cache   *statementCache
+	txStmts *txStatements
+
+	// telemetry reports every statement; a transaction executor inherits
+	// its parent's.
+	telemetry telemetry.Telemetry
 }
 
 var _ (native.QueryExecutor[types.SQLitePayload]) = (*sqliteExecutor)(nil)
+var _ query.TelemetrySource = (*sqliteExecutor)(nil)
+var _ query.Instrumented = (*sqliteExecutor)(nil)
 
 // NewSQLiteExecutor creates a new instance of the SQLiteInteractor. It can be
 // configured to operate in transactional mode by providing a non-nil *sql.Tx.
-func NewSQLiteExecutor(db *sql.DB, logger *zap.Logger) (native.QueryExecutor[types.SQLitePayload], error) {
-	return newSQLiteExecutor(db, logger, nil)
+func NewSQLiteExecutor(db *sql.DB, logger *zap.Logger, opts ...ExecutorOption) (native.QueryExecutor[types.SQLitePayload], error) {
+	s := &sqliteExecutor{
+		db:     db,
+		logger: logger,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s, nil
 }
 
-func newSQLiteExecutor(db *sql.DB, logger *zap.Logger, tx *sql.Tx) (native.QueryExecutor[types.SQLitePayload], error) {
-	return &sqliteExecutor{
-		db:     db,
+// NewPooledSQLiteExecutor creates an executor that writes through writer and
+// reads through reader. SELECTs outside a transaction run on reader; every
+// other statement, and everything inside a transaction, runs on writer. Limit
+// writer to a single open connection to serialize writes in the pool rather
+// than with a process-wide transaction mutex.
+func NewPooledSQLiteExecutor(writer, reader *sql.DB, logger *zap.Logger, opts ...ExecutorOption) (native.QueryExecutor[types.SQLitePayload], error) {
+	s := &sqliteExecutor{
+		db:     writer,
+		reader: reader,
 		logger: logger,
-		tx:     tx,
-	}, nil
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s, nil
+}
+
+func newSQLiteExecutor(db *sql.DB, logger *zap.Logger, tx *sql.Tx, cache *statementCache, t telemetry.Telemetry) (native.QueryExecutor[types.SQLitePayload], error) {
+	s := &sqliteExecutor{
+		db:        db,
+		logger:    logger,
+		tx:        tx,
+		cache:     cache,
+		telemetry: t,
+	}
+	if cache != nil {
+		s.txStmts = &txStatements{stmts: make(map[string]*sql.Stmt)}
+	}
+	return s, nil
+}
+
+// SetTelemetrySink publishes statement cache snapshots to sink under
+// StatementCacheTelemetry. It does nothing when the cache is disabled.
+func (s *sqliteExecutor) SetTelemetrySink(sink query.TelemetrySink) {
+	if s.cache != nil {
+		s.cache.setSink(sink)
+	}
+}
+
+// SetTelemetry reports every statement the executor runs, and those of the
+// transactions it begins afterwards, through t.
+func (s *sqliteExecutor) SetTelemetry(t telemetry.Telemetry) {
+	s.telemetry = t
+}
+
+// instrument starts the operation reporting a statement of stmtType against
+// sc, which may be nil.
+func (s *sqliteExecutor) instrument(ctx context.Context, stmtType native.StatementType, sc *definition.Schema) (context.Context, *telemetry.Operation) {
+	if !s.telemetry.Enabled() {
+		return ctx, nil
+	}
+	attrs := []telemetry.Attribute{telemetry.String(telemetry.KeyStatement, string(stmtType))}
+	if sc != nil {
+		attrs = append(attrs, telemetry.String(telemetry.KeyCollection, sc.Name))
+	}
+	return s.telemetry.Start(ctx, telemetry.FamilySQLite, strings.ToLower(string(stmtType)), attrs...)
 }
 
-func (s *sqliteExecutor) Query(ctx context.Context, nq native.NativeQuery[types.SQLitePayload]) ([]*document.Document, int64, error) {
+func (s *sqliteExecutor) Query(ctx context.Context, nq native.NativeQuery[types.SQLitePayload]) (docs []*document.Document, total int64, err error) {
 	q := nq.Query
+	ctx, op := s.instrument(ctx, q.StatementType(), nq.Schema)
+	defer func() {
+		op.SetRows(int64(len(docs)))
+		op.End(ctx, err)
+	}()
 	payload := q.Raw()
-	rows, err := s.runner().QueryContext(ctx, payload.SQL, payload.Params...)
+	args, bindErr := bindArgs(payload.Params, nq.Bindings)
+	if bindErr != nil {
+		return nil, 0, bindErr.WithOperation("Query")
+	}
+	rows, release, err := s.queryContext(ctx, q.StatementType(), payload.SQL, args)
 	if err != nil {
 		return nil, 0, translateError(err).WithOperation("Query")
 	}
+	defer release()
 	defer rows.Close()
 
 	var results []*document.Document = nil
@@ -76,10 +162,17 @@ func (s *sqliteExecutor) Query(ctx context.Context, nq native.NativeQuery[types.
 
 func (s *sqliteExecutor) QueryStream(ctx context.Context, compiled native.NativeQuery[types.SQLitePayload]) (<-chan map[string]any, <-chan error, error) {
 	q := compiled.Query
+	ctx, op := s.instrument(ctx, q.StatementType(), compiled.Schema)
 	payload := q.Raw()
-	rows, err := s.runner().QueryContext(ctx, payload.SQL, payload.Params...)
+	args, bindErr := bindArgs(payload.Params, compiled.Bindings)
+	if bindErr != nil {
+		op.End(ctx, bindErr)
+		return nil, nil, bindErr.WithOperation("QueryStream")
+	}
+	rows, release, err := s.queryContext(ctx, q.StatementType(), payload.SQL, args)
 
 	if err != nil {
+		op.End(ctx, err)
 		return nil, nil, translateError(err).WithOperation("QueryStream")
 	}
 
@@ -87,8 +180,16 @@ func (s *sqliteExecutor) QueryStream(ctx context.Context, compiled native.Native
 	errChan := make(chan error, 1)
 
 	go func() {
+		// The stream is reported once it is drained or abandoned.
+		var streamed int64
+		var streamErr error
+		defer func() {
+			op.SetRows(streamed)
+			op.End(ctx, streamErr)
+		}()
 		defer close(docChan)
 		defer close(errChan)
+		defer release()
 		defer rows.Close()
 
 		utilDocChan, utilErrChan := readRowsToDocs(rows)
@@ -102,11 +203,13 @@ func (s *sqliteExecutor) QueryStream(ctx context.Context, compiled native.Native
 				}
 				select {
 				case docChan <- row:
+					streamed++
 				case <-ctx.Done():
 					return
 				}
 			case err, ok := <-utilErrChan:
 				if ok {
+					streamErr = err
 					errChan <- err
 				}
 				close(docChan)
@@ -119,23 +222,42 @@ func (s *sqliteExecutor) QueryStream(ctx context.Context, compiled native.Native
 	return docChan, errChan, nil
 }
 
-func (s *sqliteExecutor) Exec(ctx context.Context, compiled native.NativeQuery[types.SQLitePayload]) (int64, error) {
+func (s *sqliteExecutor) Exec(ctx context.Context, compiled native.NativeQuery[types.SQLitePayload]) (affected int64, err error) {
 	q := compiled.Query
+	ctx, op := s.instrument(ctx, q.StatementType(), compiled.Schema)
+	defer func() {
+		op.SetRows(affected)
+		op.End(ctx, err)
+	}()
 	payload := q.Raw()
-	result, err := s.runner().ExecContext(ctx, payload.SQL, payload.Params...)
+	args, bindErr := bindArgs(payload.Params, compiled.Bindings)
+	if bindErr != nil {
+		return 0, bindErr.WithOperation("Exec")
+	}
+	result, err := s.execContext(ctx, q.StatementType(), payload.SQL, args)
 	if err != nil {
 		return 0, translateError(err).WithOperation("Exec")
 	}
+	if s.cache != nil && isDDL(q.StatementType()) {
+		s.invalidateStatements()
+	}
 	return result.RowsAffected()
 }
 
-func (s *sqliteExecutor) ExecuteQuery(ctx context.Context, compiled native.NativeQuery[types.SQLitePayload]) (*query.RawQueryResult, error) {
+func (s *sqliteExecutor) ExecuteQuery(ctx context.Context, compiled native.NativeQuery[types.SQLitePayload]) (result *query.RawQueryResult, err error) {
 	q := compiled.Query
+	ctx, op := s.instrument(ctx, native.StmtRaw, compiled.Schema)
+	defer func() {
+		if result != nil {
+			op.SetRows(int64(result.Count) + result.AffectedRows)
+		}
+		op.End(ctx, err)
+	}()
 	payload := q.Raw()
 	template := strings.TrimSpace(strings.ToUpper(payload.SQL))
 
 	if strings.HasPrefix(template, "SELECT") {
-		rows, err := s.runner().QueryContext(ctx, payload.SQL, payload.Params...)
+		rows, err := s.runnerFor(native.StmtSelect).QueryContext(ctx, payload.SQL, payload.Params...)
 		if err != nil {
 			return nil, translateError(err).WithOperation("ExecuteQuery")
 		}
@@ -176,7 +298,7 @@ func (s *sqliteExecutor) BeginTransaction(ctx context.Context) (native.QueryExec
 		return nil, native.ErrTransactionFailed.WithCause(err).WithOperation("BeginTransaction")
 	}
 
-	ti, err := newSQLiteExecutor(s.db, s.logger, tx)
+	ti, err := newSQLiteExecutor(s.db, s.logger, tx, s.cache, s.telemetry)
 	if err != nil {
 		// If creating the executor fails, we must roll back the transaction
 		if rbErr := tx.Rollback(); rbErr != nil {
@@ -197,6 +319,7 @@ func (i *sqliteExecutor) Commit(ctx context.Context) error {
 	}
 	err := i.tx.Commit()
 	i.tx = nil // Clear the transaction reference
+	i.releaseTxStatements()
 	if err != nil {
 		return native.ErrTransactionFailed.WithCause(err).WithOperation("Commit")
 	}
@@ -209,6 +332,7 @@ func (i *sqliteExecutor) Rollback(ctx context.Context) error {
 	}
 	err := i.tx.Rollback()
 	i.tx = nil // Clear the transaction reference
+	i.releaseTxStatements()
 	if err != nil {
 		return native.ErrTransactionFailed.WithCause(err).WithOperation("Rollback")
 	}
@@ -217,6 +341,18 @@ func (i *sqliteExecutor) Rollback(ctx context.Context) error {
 
 func (i *sqliteExecutor) Close() error {
 	if i.tx == nil {
+		if i.cache != nil {
+			i.cache.writer.purge()
+			if i.cache.reader != nil {
+				i.cache.reader.purge()
+			}
+		}
+		if i.reader != nil {
+			if err := i.reader.Close(); err != nil {
+				i.db.Close()
+				return err
+			}
+		}
 		return i.db.Close()
 	}
 
@@ -233,3 +369,138 @@ func (i *sqliteExecutor) runner() runner {
 	}
 	return i.db
 }
+
+// runnerFor returns the runner for a statement of the given type: the read
+// pool for a SELECT outside a transaction when one is configured, otherwise
+// runner().
+func (i *sqliteExecutor) runnerFor(stmt native.StatementType) runner {
+	if i.tx == nil && i.reader != nil && stmt == native.StmtSelect {
+		return i.reader
+	}
+	return i.runner()
+}
+
+// statement returns the cached prepared statement for sqlText and the
+// function that releases it, or a nil statement when statements of stmtType
+// are not cached.
+func (i *sqliteExecutor) statement(ctx context.Context, stmtType native.StatementType, sqlText string) (*sql.Stmt, func(), error) {
+	if i.cache == nil || !cacheable(stmtType) {
+		return nil, func() {}, nil
+	}
+
+	if i.tx != nil {
+		t := i.txStmts
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if stmt, ok := t.stmts[sqlText]; ok {
+			i.cache.lookup(true)
+			return stmt, func() {}, nil
+		}
+		// The transaction may hold the pool's only connection, so a statement
+		// missing from the shared cache is prepared on the transaction and
+		// added to the cache once the transaction ends.
+		e, ok := i.cache.writer.get(sqlText)
+		i.cache.lookup(ok)
+		if ok {
+			stmt := i.tx.StmtContext(ctx, e.stmt)
+			t.stmts[sqlText] = stmt
+			t.held = append(t.held, e)
+			return stmt, func() {}, nil
+		}
+		stmt, err := i.tx.PrepareContext(ctx, sqlText)
+		if err != nil {
+			return nil, nil, err
+		}
+		t.stmts[sqlText] = stmt
+		t.pending = append(t.pending, sqlText)
+		return stmt, func() {}, nil
+	}
+
+	lru := i.cache.writer
+	if i.reader != nil && stmtType == native.StmtSelect {
+		lru = i.cache.reader
+	}
+	e, err := lru.acquire(ctx, sqlText)
+	if err != nil {
+		return nil, nil, err
+	}
+	return e.stmt, func() { lru.release(e) }, nil
+}
+
+// queryContext runs a row-returning statement, through the statement cache
+// when it applies. The returned function must be called once rows is closed.
+func (i *sqliteExecutor) queryContext(ctx context.Context, stmtType native.StatementType, sqlText string, args []any) (*sql.Rows, func(), error) {
+	stmt, release, err := i.statement(ctx, stmtType, sqlText)
+	if err != nil {
+		return nil, nil, err
+	}
+	var rows *sql.Rows
+	if stmt == nil {
+		rows, err = i.runnerFor(stmtType).QueryContext(ctx, sqlText, args...)
+	} else {
+		rows, err = stmt.QueryContext(ctx, args...)
+	}
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return rows, release, nil
+}
+
+// execContext runs a statement that returns no rows, through the statement
+// cache when it applies.
+func (i *sqliteExecutor) execContext(ctx context.Context, stmtType native.StatementType, sqlText string, args []any) (sql.Result, error) {
+	stmt, release, err := i.statement(ctx, stmtType, sqlText)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if stmt == nil {
+		return i.runner().ExecContext(ctx, sqlText, args...)
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+// invalidateStatements drops every cached statement after a schema change,
+// including those a running transaction has rebound.
+func (i *sqliteExecutor) invalidateStatements() {
+	if i.txStmts != nil {
+		i.txStmts.reset(i.cache.writer)
+	}
+	i.cache.invalidate()
+}
+
+func (i *sqliteExecutor) releaseTxStatements() {
+	if i.txStmts != nil {
+		i.txStmts.releaseAll(i.cache.writer)
+	}
+}
+
+// bindArgs resolves the template parameters among params from bindings. The
+// compiled params are shared by every binding of a template, so they are
+// copied rather than resolved in place.
+func bindArgs(params []any, bindings *query.Bindings) ([]any, *common.SystemError) {
+	var args []any
+	for i, p := range params {
+		param, ok := p.(query.Parameter)
+		if !ok {
+			continue
+		}
+		if args == nil {
+			args = slices.Clone(params)
+		}
+		var v any
+		bound := false
+		if bindings != nil {
+			v, bound = bindings.Value(param.Name)
+		}
+		if !bound {
+			return nil, query.ErrUnboundParameter.WithMessagef("query parameter %q has no bound value", param.Name)
+		}
+		args[i] = v
+	}
+	if args == nil {
+		return params, nil
+	}
+	return args, nil
+}
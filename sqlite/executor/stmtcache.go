@@ -0,0 +1,281 @@
+package executor
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/query/native"
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+)
+
+// statementCacheReportEvery is how many cache lookups pass between two
+// statement cache telemetry snapshots. Invalidations report immediately.
+const statementCacheReportEvery = 1000
+
+// StatementCacheTelemetry is the name under which the executor publishes
+// statement cache snapshots to its telemetry sink. The data carries hits,
+// misses, evictions, invalidations, size and capacity.
+const StatementCacheTelemetry = "sqlite.statement_cache"
+
+// ExecutorOption configures an executor created by NewSQLiteExecutor or
+// NewPooledSQLiteExecutor.
+type ExecutorOption func(*sqliteExecutor)
+
+// WithStatementCache keeps up to capacity prepared statements per connection
+// pool, keyed by the generated SQL, so repeated queries skip parsing. Inside a
+// transaction a cached statement is rebound with tx.StmtContext, and one not
+// cached yet is prepared on the transaction and cached when it ends. Raw
+// queries are never cached, and DDL issued through the SchemaManager clears
+// the cache. A capacity of zero or less disables it.
+func WithStatementCache(capacity int) ExecutorOption {
+	return func(s *sqliteExecutor) {
+		if capacity <= 0 {
+			return
+		}
+		s.cache = &statementCache{capacity: capacity}
+		s.cache.writer = newStmtLRU(s.db, capacity, s.cache)
+		if s.reader != nil {
+			s.cache.reader = newStmtLRU(s.reader, capacity, s.cache)
+		}
+	}
+}
+
+// statementCache holds the prepared statement LRUs of an executor and its
+// counters. Transaction executors share their parent's cache.
+type statementCache struct {
+	capacity int
+	writer   *stmtLRU
+	reader   *stmtLRU
+
+	hits, misses, evictions, invalidations atomic.Uint64
+	lookups                                atomic.Uint64
+
+	sinkMu sync.RWMutex
+	sink   query.TelemetrySink
+}
+
+// cacheable reports whether statements of type stmt are cached. Raw SQL is
+// caller-supplied and DDL runs once, so neither is worth a slot.
+func cacheable(stmt native.StatementType) bool {
+	switch stmt {
+	case native.StmtSelect, native.StmtInsert, native.StmtUpdate, native.StmtDelete, native.StmtCheckCollection:
+		return true
+	}
+	return false
+}
+
+// isDDL reports whether statements of type stmt change the database schema.
+func isDDL(stmt native.StatementType) bool {
+	switch stmt {
+	case native.StmtCreateCollection, native.StmtDropCollection,
+		native.StmtCreateIndex, native.StmtDropIndex,
+		native.StmtAddColumn, native.StmtDropColumn, native.StmtRenameColumn:
+		return true
+	}
+	return false
+}
+
+func (c *statementCache) setSink(sink query.TelemetrySink) {
+	c.sinkMu.Lock()
+	c.sink = sink
+	c.sinkMu.Unlock()
+}
+
+// lookup counts a cache lookup and reports every statementCacheReportEvery.
+func (c *statementCache) lookup(hit bool) {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	if c.lookups.Add(1)%statementCacheReportEvery == 0 {
+		c.report()
+	}
+}
+
+// invalidate closes every idle cached statement and forgets the rest, which
+// close once their last user releases them.
+func (c *statementCache) invalidate() {
+	c.writer.purge()
+	if c.reader != nil {
+		c.reader.purge()
+	}
+	c.invalidations.Add(1)
+	c.report()
+}
+
+func (c *statementCache) report() {
+	c.sinkMu.RLock()
+	sink := c.sink
+	c.sinkMu.RUnlock()
+	if sink == nil {
+		return
+	}
+	size := c.writer.len()
+	if c.reader != nil {
+		size += c.reader.len()
+	}
+	sink(StatementCacheTelemetry, map[string]any{
+		"hits":          c.hits.Load(),
+		"misses":        c.misses.Load(),
+		"evictions":     c.evictions.Load(),
+		"invalidations": c.invalidations.Load(),
+		"size":          size,
+		"capacity":      c.capacity,
+	})
+}
+
+// stmtLRU is the prepared statement LRU of one *sql.DB. Entries are reference
+// counted so eviction and invalidation never close a statement that another
+// goroutine is still executing; such a statement closes on its last release.
+type stmtLRU struct {
+	db      *sql.DB
+	parent  *statementCache
+	mu      sync.Mutex
+	lru     *simplelru.LRU[string, *cachedStmt]
+	purging bool
+}
+
+type cachedStmt struct {
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+func newStmtLRU(db *sql.DB, capacity int, parent *statementCache) *stmtLRU {
+	c := &stmtLRU{db: db, parent: parent}
+	// NewLRU only fails for a non-positive size, which WithStatementCache
+	// rules out.
+	c.lru, _ = simplelru.NewLRU(capacity, c.onEvict)
+	return c
+}
+
+// onEvict runs under c.mu, from Add when the LRU is full or from Purge.
+func (c *stmtLRU) onEvict(_ string, e *cachedStmt) {
+	if !c.purging {
+		c.parent.evictions.Add(1)
+	}
+	e.evicted = true
+	if e.refs == 0 {
+		e.stmt.Close()
+	}
+}
+
+// get returns the cached statement for sqlText without preparing it on a
+// miss. A found statement must be released like an acquired one. The caller
+// counts the lookup.
+func (c *stmtLRU) get(sqlText string) (*cachedStmt, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.lru.Get(sqlText)
+	if ok {
+		e.refs++
+	}
+	return e, ok
+}
+
+// acquire returns the cached statement for sqlText, preparing it on a miss.
+// Every acquire must be paired with a release.
+func (c *stmtLRU) acquire(ctx context.Context, sqlText string) (*cachedStmt, error) {
+	e, ok := c.get(sqlText)
+	c.parent.lookup(ok)
+	if ok {
+		return e, nil
+	}
+	return c.prepare(ctx, sqlText)
+}
+
+// prepare adds sqlText to the cache and returns its entry acquired, without
+// counting a lookup.
+func (c *stmtLRU) prepare(ctx context.Context, sqlText string) (*cachedStmt, error) {
+	stmt, err := c.db.PrepareContext(ctx, sqlText)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Another goroutine may have prepared the same statement meanwhile.
+	if e, ok := c.lru.Get(sqlText); ok {
+		e.refs++
+		stmt.Close()
+		return e, nil
+	}
+	e := &cachedStmt{stmt: stmt, refs: 1}
+	c.lru.Add(sqlText, e)
+	return e, nil
+}
+
+func (c *stmtLRU) release(e *cachedStmt) {
+	c.mu.Lock()
+	e.refs--
+	if e.evicted && e.refs == 0 {
+		e.stmt.Close()
+	}
+	c.mu.Unlock()
+}
+
+func (c *stmtLRU) purge() {
+	c.mu.Lock()
+	c.purging = true
+	c.lru.Purge()
+	c.purging = false
+	c.mu.Unlock()
+}
+
+func (c *stmtLRU) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// txStatements holds the statements of a transaction: those rebound from the
+// executor's cache, whose cache entries stay acquired until the transaction
+// ends, and those prepared on the transaction after a cache miss, whose SQL
+// is pending addition to the cache.
+type txStatements struct {
+	mu      sync.Mutex
+	stmts   map[string]*sql.Stmt
+	held    []*cachedStmt
+	pending []string
+}
+
+// releaseAll runs once the transaction has ended and its connection is back
+// in the pool. It returns the cache entries held by the transaction and
+// prepares its missed statements into the cache; those misses were counted
+// when the transaction looked them up. The transaction's own statements close
+// with it.
+func (t *txStatements) releaseAll(cache *stmtLRU) {
+	t.mu.Lock()
+	held, pending := t.held, t.pending
+	t.held, t.pending, t.stmts = nil, nil, nil
+	t.mu.Unlock()
+	for _, e := range held {
+		cache.release(e)
+	}
+	for _, sqlText := range pending {
+		if e, err := cache.prepare(context.Background(), sqlText); err == nil {
+			cache.release(e)
+		}
+	}
+}
+
+// reset drops every statement of a transaction whose schema has changed. The
+// statements prepared on the transaction are closed, the cache entries it
+// holds are returned, and its missed statements are no longer added to the
+// cache, since they were prepared against the old schema.
+func (t *txStatements) reset(cache *stmtLRU) {
+	t.mu.Lock()
+	stmts, held := t.stmts, t.held
+	t.stmts, t.held, t.pending = make(map[string]*sql.Stmt), nil, nil
+	t.mu.Unlock()
+	for _, stmt := range stmts {
+		stmt.Close()
+	}
+	for _, e := range held {
+		cache.release(e)
+	}
+}
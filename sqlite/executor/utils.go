@@ -8,6 +8,7 @@ import (
 
 	"github.com/asaidimu/go-anansi/v8/core/common"
 	"github.com/asaidimu/go-anansi/v8/core/document"
+	"github.com/asaidimu/go-anansi/v8/core/encoding/binary"
 	"github.com/asaidimu/go-anansi/v8/core/query"
 	"github.com/asaidimu/go-anansi/v8/core/query/native"
 	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
@@ -107,6 +108,14 @@ func ReadRows(ctx context.Context, logger *zap.Logger, sc *definition.Schema, ro
 				}
 
 				_, fieldDef := sc.FindField(fieldName)
+				if packet, ok := binaryFieldPacket(fieldDef, value); ok && tableName == sc.Name {
+					v, err := document.DecodeBinaryField(sc, fieldName, packet)
+					if err == nil {
+						tableObj[fieldName] = v
+						continue
+					}
+					logger.Warn("failed to decode binary value", zap.String("field", fieldName), zap.Error(err))
+				}
 				cv, err := fromSQLiteValue(fieldDef, value)
 				if err != nil {
 					logger.Warn("failed to convert value", zap.String("field", fieldName), zap.Error(err))
@@ -217,6 +226,17 @@ func unmarshalJSON(value any) (any, error) {
 	return data, nil
 }
 
+// binaryFieldPacket reports whether value is a container column stored as a
+// binary field packet (see query.WithBinaryDocuments). JSON is always bound
+// as TEXT, so only BLOBs are considered.
+func binaryFieldPacket(fieldDef *definition.Field, value any) ([]byte, bool) {
+	b, ok := value.([]byte)
+	if !ok || fieldDef == nil || !fieldDef.Type.IsContainer() {
+		return nil, false
+	}
+	return b, binary.IsFieldPacket(b)
+}
+
 // convertBooleanFromSQLite converts integer representations back to booleans
 func convertBooleanFromSQLite(value any) (any, error) {
 	if i, ok := value.(int64); ok {
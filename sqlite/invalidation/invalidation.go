@@ -0,0 +1,419 @@
+// Package invalidation keeps LiveCollection caches coherent across processes
+// that share one SQLite database file.
+//
+// Source implements collection.InvalidationSource. Watching a collection
+// installs AFTER INSERT/UPDATE/DELETE triggers on its table that bump a
+// per-collection change sequence in the ChangesTable table. Because the
+// triggers live in the database file, every writer fires them, whether or
+// not it uses this package. A single goroutine polls PRAGMA data_version on a
+// dedicated connection — a cheap check that changes only when another
+// connection commits — and reads the change sequences only when it does.
+// Watchers whose sequence moved receive a full invalidation.
+//
+//	source := invalidation.NewSource(db, invalidation.Options{PollInterval: 100 * time.Millisecond})
+//	defer source.Close()
+//	repo, err := collection.NewLiveRepository(ctx, collection.LiveRepositoryOptions[Rule]{
+//		Collection:   rules,
+//		Processor:    compiler,
+//		QueryKey:     "name",
+//		Invalidation: source,
+//		MaxStaleness: time.Minute,
+//	})
+//
+// Writes made by this process also move the sequence, so they invalidate the
+// cache of every LiveCollection watching the collection, including the one
+// that made them. PollInterval bounds how long a change goes unnoticed.
+//
+// The triggers outlive the Source. A migration that rebuilds a watched table
+// drops them; the poller reinstalls them when it next sees a commit and
+// invalidates the table's watchers, since changes made in between went
+// untracked. Untrack removes the triggers of a table no longer watched.
+package invalidation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/collection"
+	"go.uber.org/zap"
+)
+
+// ChangesTable holds one change sequence per watched table.
+const ChangesTable = "_anansi_changes"
+
+// DefaultPollInterval is used when Options.PollInterval is not set.
+const DefaultPollInterval = 250 * time.Millisecond
+
+// ErrWatchFailed is returned when a collection's change tracking cannot be
+// installed.
+var ErrWatchFailed = common.NewSystemError("ERR_INVALIDATION_WATCH_FAILED", "failed to watch collection for changes")
+
+// ErrUntrackFailed is returned when a collection's change tracking cannot be
+// removed.
+var ErrUntrackFailed = common.NewSystemError("ERR_INVALIDATION_UNTRACK_FAILED", "failed to remove change tracking from collection")
+
+// ErrStillWatched is returned by Untrack for a collection the Source still
+// watches.
+var ErrStillWatched = common.NewSystemError("ERR_INVALIDATION_STILL_WATCHED", "collection is still watched")
+
+// Options configures a Source.
+type Options struct {
+	// PollInterval is how often PRAGMA data_version is checked. It bounds
+	// how long a change made elsewhere can go unnoticed.
+	PollInterval time.Duration
+	// Logger receives poll failures. Defaults to a no-op logger.
+	Logger *zap.Logger
+}
+
+// Source is a collection.InvalidationSource backed by a SQLite database. It
+// is safe for concurrent use; one Source can serve any number of
+// LiveCollections.
+type Source struct {
+	db     *sql.DB
+	opts   Options
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	watchers map[*watcher]struct{}
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+type watcher struct {
+	table   string
+	seq     int64
+	handler func(collection.Invalidation)
+}
+
+var _ collection.InvalidationSource = (*Source)(nil)
+
+// NewSource returns a Source polling db. Close stops it.
+func NewSource(db *sql.DB, opts Options) *Source {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Source{
+		db:       db,
+		opts:     opts,
+		logger:   logger,
+		watchers: make(map[*watcher]struct{}),
+	}
+}
+
+// Watch installs change tracking on target's table and reports changes to it
+// until the returned function is called. The poller starts with the first
+// watch.
+func (s *Source) Watch(ctx context.Context, target collection.InvalidationTarget, handler func(collection.Invalidation)) (func(), error) {
+	table := tableOf(target)
+	if err := s.track(ctx, s.db, table); err != nil {
+		return nil, ErrWatchFailed.WithPath(table).WithCause(err)
+	}
+	seq, err := s.sequence(ctx, s.db, table)
+	if err != nil {
+		return nil, ErrWatchFailed.WithPath(table).WithCause(err)
+	}
+
+	w := &watcher{table: table, seq: seq, handler: handler}
+	s.mu.Lock()
+	s.watchers[w] = struct{}{}
+	if s.cancel == nil {
+		pollCtx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		s.done = make(chan struct{})
+		go s.poll(pollCtx, s.done)
+	}
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.watchers, w)
+			s.mu.Unlock()
+		})
+	}, nil
+}
+
+// Close stops the poller. Watches stop receiving invalidations. Idempotent.
+func (s *Source) Close() error {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	return nil
+}
+
+// Untrack removes the change tracking Watch installed on target's table: its
+// triggers and its change sequence. It fails with ErrStillWatched while this
+// Source watches the table; watches held by other processes lose their
+// invalidations.
+func (s *Source) Untrack(ctx context.Context, target collection.InvalidationTarget) error {
+	table := tableOf(target)
+	for _, w := range s.snapshot() {
+		if w.table == table {
+			return ErrStillWatched.WithPath(table)
+		}
+	}
+
+	statements := make([]string, 0, len(trackedEvents)+1)
+	for _, event := range trackedEvents {
+		statements = append(statements, fmt.Sprintf("DROP TRIGGER IF EXISTS %s", quoteIdentifier(triggerName(table, event))))
+	}
+	var changes int
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", ChangesTable,
+	).Scan(&changes); err != nil {
+		return ErrUntrackFailed.WithPath(table).WithCause(err)
+	}
+	if changes > 0 {
+		statements = append(statements, fmt.Sprintf(
+			"DELETE FROM %s WHERE collection = %s", quoteIdentifier(ChangesTable), quoteLiteral(table),
+		))
+	}
+	for _, stmt := range statements {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return ErrUntrackFailed.WithPath(table).WithCause(err)
+		}
+	}
+	return nil
+}
+
+// trackedEvents are the writes whose triggers move a table's sequence.
+var trackedEvents = []string{"INSERT", "UPDATE", "DELETE"}
+
+func tableOf(target collection.InvalidationTarget) string {
+	if target.Table != "" {
+		return target.Table
+	}
+	return target.Name
+}
+
+func triggerName(table, event string) string {
+	return fmt.Sprintf("%s_%s_%s", ChangesTable, table, strings.ToLower(event))
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// track creates the changes table and the triggers that maintain table's
+// sequence.
+func (s *Source) track(ctx context.Context, db execer, table string) error {
+	statements := []string{fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (collection TEXT PRIMARY KEY, seq INTEGER NOT NULL)",
+		quoteIdentifier(ChangesTable),
+	)}
+	for _, event := range trackedEvents {
+		statements = append(statements, fmt.Sprintf(
+			"CREATE TRIGGER IF NOT EXISTS %s AFTER %s ON %s BEGIN "+
+				"INSERT INTO %s (collection, seq) VALUES (%s, 1) "+
+				"ON CONFLICT (collection) DO UPDATE SET seq = seq + 1; END",
+			quoteIdentifier(triggerName(table, event)),
+			event,
+			quoteIdentifier(table),
+			quoteIdentifier(ChangesTable),
+			quoteLiteral(table),
+		))
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verify reinstalls the triggers of watched tables that lost them, which a
+// migration rebuilding a table does. It returns the tables whose changes may
+// have gone untracked. Tables that no longer exist are reported without
+// reinstalling anything.
+func (s *Source) verify(ctx context.Context, conn *sql.Conn, watchers []*watcher) (map[string]bool, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT type, name FROM sqlite_master WHERE type IN ('table', 'trigger')")
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool)
+	for rows.Next() {
+		var kind, name string
+		if err := rows.Scan(&kind, &name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		present[kind+":"+name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	untracked := make(map[string]bool)
+	for _, w := range watchers {
+		if _, seen := untracked[w.table]; seen {
+			continue
+		}
+		complete := true
+		for _, event := range trackedEvents {
+			complete = complete && present["trigger:"+triggerName(w.table, event)]
+		}
+		if complete {
+			continue
+		}
+		untracked[w.table] = true
+		if !present["table:"+w.table] {
+			continue
+		}
+		if err := s.track(ctx, conn, w.table); err != nil {
+			return nil, err
+		}
+		s.logger.Info("sqlite invalidation triggers reinstalled", zap.String("table", w.table))
+	}
+	return untracked, nil
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sequence returns the current change sequence of table.
+func (s *Source) sequence(ctx context.Context, q queryRower, table string) (int64, error) {
+	var seq int64
+	err := q.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT seq FROM %s WHERE collection = ?", quoteIdentifier(ChangesTable)), table,
+	).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// poll checks data_version every PollInterval until ctx is cancelled.
+// data_version is per connection, so the poller holds its own.
+func (s *Source) poll(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	var conn *sql.Conn
+	var version int64 = -1
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if conn == nil {
+			c, err := s.db.Conn(ctx)
+			if err != nil {
+				s.fail(err)
+				continue
+			}
+			conn, version = c, -1
+		}
+
+		var current int64
+		if err := conn.QueryRowContext(ctx, "PRAGMA data_version").Scan(&current); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			_ = conn.Close()
+			conn = nil
+			s.fail(err)
+			continue
+		}
+		if current == version {
+			continue
+		}
+		version = current
+
+		if err := s.dispatch(ctx, conn); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.fail(err)
+		}
+	}
+}
+
+// dispatch reads the change sequences and invalidates watchers whose table
+// changed since they last looked or lost its triggers.
+func (s *Source) dispatch(ctx context.Context, conn *sql.Conn) error {
+	watchers := s.snapshot()
+	untracked, err := s.verify(ctx, conn, watchers)
+	if err != nil {
+		return err
+	}
+
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT collection, seq FROM %s", quoteIdentifier(ChangesTable)))
+	if err != nil {
+		return err
+	}
+	sequences := make(map[string]int64)
+	for rows.Next() {
+		var table string
+		var seq int64
+		if err := rows.Scan(&table, &seq); err != nil {
+			rows.Close()
+			return err
+		}
+		sequences[table] = seq
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, w := range watchers {
+		if seq := sequences[w.table]; seq != w.seq || untracked[w.table] {
+			w.seq = seq
+			w.handler(collection.Invalidation{All: true})
+		}
+	}
+	return nil
+}
+
+// fail reports a poll failure to every watcher as a full invalidation: what
+// changed in the meantime is unknown.
+func (s *Source) fail(err error) {
+	s.logger.Warn("sqlite invalidation poll failed", zap.Error(err))
+	for _, w := range s.snapshot() {
+		w.handler(collection.Invalidation{All: true})
+	}
+}
+
+func (s *Source) snapshot() []*watcher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*watcher, 0, len(s.watchers))
+	for w := range s.watchers {
+		out = append(out, w)
+	}
+	return out
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteLiteral(value string) string {
+	return `'` + strings.ReplaceAll(value, `'`, `''`) + `'`
+}
@@ -26,8 +26,8 @@ func (q *sqliteQuery) StatementType() native.StatementType {
 
 
 // NewSQLiteFactory creates a new factory for building SQLite queries.
-func NewSQLiteFactory(logger *zap.Logger) native.QueryFactory[types.SQLitePayload] {
-	return newSQLiteFactory(logger)
+func NewSQLiteFactory(logger *zap.Logger, opts ...FactoryOption) native.QueryFactory[types.SQLitePayload] {
+	return newSQLiteFactory(logger, opts...)
 }
 
 func (x *sqliteFactory) Build(
@@ -36,7 +36,7 @@ func (x *sqliteFactory) Build(
 	extra any,
 ) (native.Query[types.SQLitePayload], error) {
 
-	f := newSQLiteFactory(x.logger)
+	f := x.newRootScope()
 
 	// Check for raw query first - raw takes precedence
 	if q.Raw != nil {
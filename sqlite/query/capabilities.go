@@ -7,7 +7,7 @@ import (
 
 // Capabilities returns the capabilities of the SQLite interactor.
 func (i *sqliteFactory) Capabilities() query.Capabilities {
-	return query.Capabilities{
+	capabilities := query.Capabilities{
 		SchemaEvolution: query.SchemaEvolution{
 			AddColumn:       true,  // ALTER TABLE ... ADD COLUMN
 			DropColumn:      true,  // ALTER TABLE ... DROP COLUMN (SQLite 3.35.0+)
@@ -16,7 +16,7 @@ func (i *sqliteFactory) Capabilities() query.Capabilities {
 			AddConstraint:   false, // very limited after creation
 			DropConstraint:  false, // not supported
 		},
-		RequiresTransactionSerialization: true,
+		RequiresTransactionSerialization: !i.serializedWriter,
 		SupportedLogicalOperators: map[common.LogicalOperator]struct{}{
 			common.LogicalAnd: {},
 			common.LogicalOr:  {},
@@ -34,6 +34,11 @@ func (i *sqliteFactory) Capabilities() query.Capabilities {
 			query.ComparisonOperatorNotContains: {},
 			query.ComparisonOperatorExists:      {},
 			query.ComparisonOperatorNotExists:   {},
+			query.ComparisonOperatorIEq:         {},
+			query.ComparisonOperatorBetween:     {},
+			query.ComparisonOperatorSize:        {},
+			query.ComparisonOperatorElemMatch:   {},
+			query.ComparisonOperatorAll:         {},
 		},
 		SupportedExpressionOperators: map[string]struct{}{
 			// SQLite supports basic arithmetic operators
@@ -109,7 +114,11 @@ func (i *sqliteFactory) Capabilities() query.Capabilities {
 			query.AggregationTypeAvg:   {},
 			query.AggregationTypeMin:   {},
 			query.AggregationTypeMax:   {},
-			// SQLite also supports GROUP_CONCAT which could be mapped to a custom type
+			query.AggregationTypeCountDistinct: {},
+			query.AggregationTypeGroupConcat:   {},
+			// array_agg would come back as JSON text, and stddev, variance,
+			// percentiles and first/last have no native SQLite aggregate;
+			// those run in memory.
 		},
 		SupportedPaginationTypes: map[query.PaginationType]struct{}{
 			query.PaginationTypeOffset: {},
@@ -125,9 +134,18 @@ func (i *sqliteFactory) Capabilities() query.Capabilities {
 		},
 		SupportsGroupBy:      true,  // SQLite supports GROUP BY
 		SupportsDistinct:     true,  // SQLite supports DISTINCT
+		SupportsWindowFunctions: true, // Native since SQLite 3.25
+		SupportsTimeBuckets: true, // Via strftime
 		SupportsNestedFields: true,  // Via JSON functions like json_extract
 		MaxWhereConditions:   0,     // SQLite has no practical limit
 		MaxJoinClauses:      63,     // SQLite has a default limit of 64 tables in a join
 		ReturnOnUpdate: true,
+		QueryParameters: true, // Bound by the executor as statement arguments
 	}
+	if i.regexFunction {
+		// REGEXP calls the regexp function, which plain "sqlite3"
+		// connections lack.
+		capabilities.SupportedComparisonOperators[query.ComparisonOperatorRegex] = struct{}{}
+	}
+	return capabilities
 }
@@ -3,6 +3,7 @@ package query_test
 import (
 	"testing"
 
+	corequery "github.com/asaidimu/go-anansi/v8/core/query"
 	"github.com/asaidimu/go-anansi/v8/sqlite/query"
 	"github.com/stretchr/testify/assert"
 )
@@ -18,3 +19,11 @@ func TestSQLiteCapabilities_SchemaEvolution(t *testing.T) {
 	assert.False(t, caps.SchemaEvolution.AddConstraint)
 	assert.False(t, caps.SchemaEvolution.DropConstraint)
 }
+
+func TestSQLiteCapabilities_RegexNeedsTheFunction(t *testing.T) {
+	_, plain := query.NewSQLiteFactory(nil).Capabilities().SupportedComparisonOperators[corequery.ComparisonOperatorRegex]
+	assert.False(t, plain, "plain sqlite3 connections have no regexp function")
+
+	_, registered := query.NewSQLiteFactory(nil, query.WithRegexFunction()).Capabilities().SupportedComparisonOperators[corequery.ComparisonOperatorRegex]
+	assert.True(t, registered)
+}
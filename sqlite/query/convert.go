@@ -1,6 +1,7 @@
 package query
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 
@@ -76,3 +77,37 @@ func toSQLiteValue(fieldDef *definition.Field, value any, doc data.Documenter) (
 		return value, nil
 	}
 }
+
+// toBinaryValue converts a container field to a binary field packet for a
+// factory configured WithBinaryDocuments. Container-backed documents are
+// encoded straight from their container; other values, including record
+// views, are first bound to the schema. An absent field converts to NULL.
+func (f *sqliteFactory) toBinaryValue(sc *definition.Schema, fieldDef *definition.Field, value any, doc data.Documenter) (any, error) {
+	name := string(fieldDef.Name)
+	if d, ok := doc.(*document.Document); ok {
+		packet, present, err := d.SerializeFieldBinary(name)
+		if err == nil {
+			if !present {
+				return nil, nil
+			}
+			return packet, nil
+		}
+		if !errors.Is(err, document.ErrBinaryView) {
+			return nil, ErrConvertMarshalFieldFailed.WithCause(fmt.Errorf("failed to encode field '%s': %w", name, err))
+		}
+	}
+	if value == nil && doc != nil {
+		value, _ = doc.Get(name)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	packet, present, err := document.EncodeBinaryField(sc, name, value)
+	if err != nil {
+		return nil, ErrConvertMarshalFieldFailed.WithCause(fmt.Errorf("failed to encode field '%s': %w", name, err))
+	}
+	if !present {
+		return nil, nil
+	}
+	return packet, nil
+}
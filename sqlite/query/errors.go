@@ -22,6 +22,10 @@ var (
 	ErrSelectUnsupportedJoinType        = common.NewSystemError("ERR_QUERY_SELECT_UNSUPPORTED_JOIN_TYPE", "unsupported join type")
 	ErrSelectLimitInvalid               = common.NewSystemError("ERR_QUERY_SELECT_LIMIT_INVALID", "limit must be greater than zero for pagination")
 	ErrSelectBuildError                 = common.NewSystemError("ERR_QUERY_SELECT_BUILD_ERROR", "error building query part")
+	ErrSelectUnsupportedWindowFunction  = common.NewSystemError("ERR_QUERY_SELECT_UNSUPPORTED_WINDOW_FUNCTION", "unsupported window function")
+	ErrSelectInvalidWindowFrame         = common.NewSystemError("ERR_QUERY_SELECT_INVALID_WINDOW_FRAME", "invalid window frame")
+	ErrSelectUnsupportedTimeBucket      = common.NewSystemError("ERR_QUERY_SELECT_UNSUPPORTED_TIME_BUCKET", "unsupported time bucket unit")
+	ErrSelectInvalidOperatorValue       = common.NewSystemError("ERR_QUERY_SELECT_INVALID_OPERATOR_VALUE", "invalid value for comparison operator")
 
 	// Convert errors
 	ErrConvertMarshalValueFailed = common.NewSystemError("ERR_QUERY_CONVERT_MARSHAL_VALUE_FAILED", "failed to marshal value to JSON")
@@ -38,10 +42,13 @@ var (
 	ErrUpdateStatementNoAssignments    = common.NewSystemError("ERR_QUERY_UPDATE_STATEMENT_NO_ASSIGNMENTS", "update statement must have assignments")
 	ErrUpdateNoTargetSpecified         = common.NewSystemError("ERR_QUERY_UPDATE_NO_TARGET_SPECIFIED", "no target specified for update")
 	ErrUpdateQueryNoTarget             = common.NewSystemError("ERR_QUERY_UPDATE_QUERY_NO_TARGET", "update query must have a target")
-	ErrUpdateQueryNoDataPayload        = common.NewSystemError("ERR_QUERY_UPDATE_QUERY_NO_DATA_PAYLOAD", "update query must have data payload for set or compute")
+	ErrUpdateQueryNoDataPayload        = common.NewSystemError("ERR_QUERY_UPDATE_QUERY_NO_DATA_PAYLOAD", "update query must have data payload for set, compute or operators")
 	ErrBuilderInvalidUpdatePayload     = common.NewSystemError("ERR_QUERY_BUILDER_INVALID_UPDATE_PAYLOAD", "invalid data type for update payload: expected map[string]any")
 	ErrUpdateInvalidSetType            = common.NewSystemError("ERR_QUERY_UPDATE_INVALID_SET_TYPE", "invalid data type for 'set' in update")
 	ErrUpdateInvalidComputeType        = common.NewSystemError("ERR_QUERY_UPDATE_INVALID_COMPUTE_TYPE", "invalid data type for 'compute' in update")
+	ErrUpdateInvalidOperatorsType      = common.NewSystemError("ERR_QUERY_UPDATE_INVALID_OPERATORS_TYPE", "invalid data type for 'operators' in update")
+	ErrUpdateInvalidOperatorValue      = common.NewSystemError("ERR_QUERY_UPDATE_INVALID_OPERATOR_VALUE", "update operator value cannot be encoded as JSON")
+	ErrUpdateUnsupportedOperator       = common.NewSystemError("ERR_QUERY_UPDATE_UNSUPPORTED_OPERATOR", "unsupported update operator")
 
 	// Builder errors
 	ErrBuilderUnsupportedStatementType = common.NewSystemError("ERR_QUERY_BUILDER_UNSUPPORTED_STATEMENT_TYPE", "unsupported statement type")
@@ -65,4 +72,7 @@ var (
 	ErrIndexExtraNotIndexDefinition = common.NewSystemError("ERR_QUERY_INDEX_EXTRA_NOT_INDEX_DEFINITION", "extra is not an IndexDefinition")
 	ErrIndexSchemaNotDefined        = common.NewSystemError("ERR_QUERY_INDEX_SCHEMA_NOT_DEFINED", "schema is not defined for create index tree")
 	ErrIndexIndexNotDefined         = common.NewSystemError("ERR_QUERY_INDEX_INDEX_NOT_DEFINED", "index is not defined for create index tree")
+
+	// Binary document errors
+	ErrBinaryNestedPath = common.NewSystemError("ERR_QUERY_BINARY_NESTED_PATH", "nested paths are not addressable in binary document columns")
 )
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/asaidimu/go-anansi/v8/core/data"
 	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
 	"go.uber.org/zap"
 )
@@ -23,15 +24,66 @@ type sqliteFactory struct {
 	// Depth tracking to prevent infinite recursion
 	depth int
 	logger      *zap.Logger
+
+	// binaryDocuments stores container columns as binary field packets
+	// instead of JSON text. See WithBinaryDocuments.
+	binaryDocuments bool
+
+	// serializedWriter reports that the executor funnels every write through
+	// one connection. See WithSerializedWriter.
+	serializedWriter bool
+
+	// regexFunction reports that every connection has the regexp function
+	// registered. See WithRegexFunction.
+	regexFunction bool
+}
+
+// FactoryOption configures a factory created by NewSQLiteFactory.
+type FactoryOption func(*sqliteFactory)
+
+// WithBinaryDocuments stores object, array and record columns as BLOBs in the
+// Anansi binary wire format rather than as JSON text, which is smaller and
+// decodes without parsing. The system _metadata_ column stays JSON. SQLite's
+// JSON functions cannot address inside a binary column, so filters, sorts,
+// updates and indexes on nested paths of such columns fail with
+// ErrBinaryNestedPath; whole-column reads and writes work as usual. Rows
+// written as JSON before the option was enabled remain readable.
+func WithBinaryDocuments() FactoryOption {
+	return func(f *sqliteFactory) {
+		f.binaryDocuments = true
+	}
+}
+
+// WithSerializedWriter declares that the executor runs all writes and
+// transactions on a single connection, as executor.NewPooledSQLiteExecutor does with a
+// one-connection writer pool. The connection pool then serializes
+// transactions, so Capabilities drops RequiresTransactionSerialization and
+// persistence no longer takes its process-wide transaction mutex.
+func WithSerializedWriter() FactoryOption {
+	return func(f *sqliteFactory) {
+		f.serializedWriter = true
+	}
+}
+
+// WithRegexFunction declares that every connection of the executor has
+// Anansi's regexp function registered: it was opened with executor.DriverName
+// or executor.NewConnector, or its driver's ConnectHook calls
+// executor.RegisterFunctions. Capabilities then advertises the regex operator
+// and regex filters run in SQL; without the option they are evaluated in
+// memory, which works with the plain "sqlite3" driver.
+func WithRegexFunction() FactoryOption {
+	return func(f *sqliteFactory) {
+		f.regexFunction = true
+	}
 }
 
 // newSQLiteFactory creates a new root-level factory.
-func newSQLiteFactory(logger *zap.Logger) *sqliteFactory {
+func newSQLiteFactory(logger *zap.Logger, opts ...FactoryOption) *sqliteFactory {
 	counter := 0
 	if logger == nil {
 		logger = zap.NewNop()
 	}
-	return &sqliteFactory{
+	f := &sqliteFactory{
 		aliases:            make(map[string]string),
 		schemas:            make(map[string]*definition.Schema),
 		globalParamCounter: &counter,
@@ -39,6 +91,51 @@ func newSQLiteFactory(logger *zap.Logger) *sqliteFactory {
 		depth:              0,
 		logger: logger,
 	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// newRootScope creates a fresh root-level factory with this factory's options.
+func (f *sqliteFactory) newRootScope() *sqliteFactory {
+	root := newSQLiteFactory(f.logger)
+	root.binaryDocuments = f.binaryDocuments
+	return root
+}
+
+// binaryColumn reports whether fieldDef is stored as a binary field packet.
+func (f *sqliteFactory) binaryColumn(fieldDef *definition.Field) bool {
+	return f.binaryDocuments && fieldDef != nil && fieldDef.Type.IsContainer() &&
+		string(fieldDef.Name) != data.MetadataField
+}
+
+// jsonExtract renders access to path inside the JSON column. Binary columns
+// cannot be addressed by SQLite's JSON functions.
+func (f *sqliteFactory) jsonExtract(column string, fieldDef *definition.Field, path []string) (string, error) {
+	jsonPath := "$." + strings.Join(path, ".")
+	if f.binaryColumn(fieldDef) {
+		return "", ErrBinaryNestedPath.WithMessagef("cannot address %s in binary column %s", jsonPath, fieldDef.Name)
+	}
+	return fmt.Sprintf("json_extract(%s, '%s')", column, jsonPath), nil
+}
+
+// checkJSONField fails with ErrBinaryNestedPath when fieldRef names a binary
+// container column, whose contents SQLite's JSON functions cannot read.
+func (f *sqliteFactory) checkJSONField(fieldRef string, schemas map[string]*definition.Schema) error {
+	parts := strings.Split(fieldRef, ".")
+	if schemaDef, ok := schemas[parts[0]]; ok && len(parts) > 1 {
+		if _, fieldDef := schemaDef.FindField(parts[1]); f.binaryColumn(fieldDef) {
+			return ErrBinaryNestedPath.WithMessagef("cannot read the elements of binary column %s", fieldDef.Name)
+		}
+		return nil
+	}
+	for _, schemaDef := range schemas {
+		if _, fieldDef := schemaDef.FindField(parts[0]); f.binaryColumn(fieldDef) {
+			return ErrBinaryNestedPath.WithMessagef("cannot read the elements of binary column %s", fieldDef.Name)
+		}
+	}
+	return nil
 }
 
 // createChildScope creates a new child scope for subqueries.
@@ -50,6 +147,7 @@ func (f *sqliteFactory) createChildScope() *sqliteFactory {
 		globalParamCounter: f.globalParamCounter,
 		parent:             f,
 		depth:              f.depth + 1,
+		binaryDocuments:    f.binaryDocuments,
 	}
 }
 
@@ -107,8 +205,7 @@ func (f *sqliteFactory) resolveFieldReference(fieldRef string, schemas map[strin
 			if _, fieldDef := schemaDef.FindField(parts[1]); fieldDef != nil && fieldDef.Type.IsComplex() {
 				// This is a JSON field - use json_extract for nested access
 				if len(parts) > 2 {
-					jsonPath := "$." + strings.Join(parts[2:], ".")
-					return fmt.Sprintf("json_extract(%s, '%s')", quoteIdentifier(parts[0])+"."+quoteIdentifier(parts[1]), jsonPath), nil
+					return f.jsonExtract(quoteIdentifier(parts[0])+"."+quoteIdentifier(parts[1]), fieldDef, parts[2:])
 				}
 			}
 			// Regular table.field reference
@@ -131,8 +228,7 @@ func (f *sqliteFactory) resolveFieldReference(fieldRef string, schemas map[strin
 		for _, schemaDef := range schemas {
 			if _, fieldDef := schemaDef.FindField(parts[0]); fieldDef != nil && fieldDef.Type.IsComplex() {
 				// This is a JSON field - use json_extract for nested access
-				jsonPath := "$." + strings.Join(parts[1:], ".")
-				return fmt.Sprintf("json_extract(%s, '%s')", quoteIdentifier(parts[0]), jsonPath), nil
+				return f.jsonExtract(quoteIdentifier(parts[0]), fieldDef, parts[1:])
 			}
 		}
 
@@ -140,8 +236,7 @@ func (f *sqliteFactory) resolveFieldReference(fieldRef string, schemas map[strin
 		if f.parent != nil {
 			for _, schemaDef := range f.parent.schemas {
 				if _, fieldDef := schemaDef.FindField(parts[0]); fieldDef != nil && fieldDef.Type.IsComplex() {
-					jsonPath := "$." + strings.Join(parts[1:], ".")
-					return fmt.Sprintf("json_extract(%s, '%s')", quoteIdentifier(parts[0]), jsonPath), nil
+					return f.jsonExtract(quoteIdentifier(parts[0]), fieldDef, parts[1:])
 				}
 			}
 		}
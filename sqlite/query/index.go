@@ -18,6 +18,17 @@ func (f *sqliteFactory) buildCreateIndexTree(q *query.Query, extra any) (SQLNode
 			return nil, ErrIndexExtraNotIndexDefinition
 		}
 	}
+	if f.binaryDocuments && q.Target.Schema != nil {
+		for _, field := range index.Fields {
+			column, _, nested := strings.Cut(string(field), ".")
+			if !nested {
+				continue
+			}
+			if _, fieldDef := q.Target.Schema.FindField(column); f.binaryColumn(fieldDef) {
+				return nil, ErrBinaryNestedPath.WithMessagef("cannot index %s in binary column %s", field, column)
+			}
+		}
+	}
 	return &createIndexTree{collection: q.Target.Name, index: index}, nil
 }
 
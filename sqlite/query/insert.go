@@ -123,6 +123,15 @@ func (i *SQLiteInsertValues) processDocumentFields(
 		case data.MetadataField:
 			value = doc.Metadata()
 		default:
+			if i.factory.binaryColumn(fieldDef) {
+				packet, err := i.factory.toBinaryValue(i.schema, fieldDef, nil, doc)
+				if err != nil {
+					return nil, nil, ErrInsertFieldConversionFailed.WithCause(fmt.Errorf("failed to convert field %s: %w", fieldName, err))
+				}
+				placeholders = append(placeholders, i.factory.nextParam())
+				params = append(params, packet)
+				continue
+			}
 			// Container fields are serialized directly from the container by
 			// toSQLiteValue; materializing them via Get would be wasted.
 			if fieldDef != nil && fieldDef.Type.IsContainer() {
@@ -3,6 +3,7 @@ package query
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/asaidimu/go-anansi/v8/core/common"
@@ -45,6 +46,24 @@ func (p *SQLiteSelectProjection) Value() (string, []any, error) {
 
 	// Handle aggregations
 	if len(p.aggregations) > 0 {
+		// Time buckets are computed values, so each is selected once under
+		// its alias to make the bucket visible in the result rows.
+		selectedBuckets := make(map[string]struct{})
+		for _, agg := range p.aggregations {
+			for _, bucket := range agg.TimeGroups {
+				alias := bucket.AliasOrDefault()
+				if _, seen := selectedBuckets[alias]; seen {
+					continue
+				}
+				selectedBuckets[alias] = struct{}{}
+				bucketSQL, err := p.factory.buildTimeBucket(bucket, p.schemas)
+				if err != nil {
+					return "", nil, err
+				}
+				parts = append(parts, fmt.Sprintf("%s AS %s", bucketSQL, quoteIdentifier(alias)))
+			}
+		}
+
 		for _, agg := range p.aggregations {
 			if agg.Type == "" { // This is a grouping/having configuration, not a projection
 				continue
@@ -85,6 +104,23 @@ func (p *SQLiteSelectProjection) Value() (string, []any, error) {
 					return "", nil, err
 				}
 				aggPart = fmt.Sprintf("MAX(%s)", resolvedField)
+			case query.AggregationTypeCountDistinct:
+				resolvedField, err := p.factory.resolveFieldReference(agg.Field, p.schemas)
+				if err != nil {
+					return "", nil, err
+				}
+				aggPart = fmt.Sprintf("COUNT(DISTINCT %s)", resolvedField)
+			case query.AggregationTypeGroupConcat:
+				resolvedField, err := p.factory.resolveFieldReference(agg.Field, p.schemas)
+				if err != nil {
+					return "", nil, err
+				}
+				separator := ","
+				if agg.Separator != nil {
+					separator = *agg.Separator
+				}
+				aggPart = fmt.Sprintf("GROUP_CONCAT(%s, %s)", resolvedField, p.factory.nextParam())
+				params = append(params, separator)
 			default:
 				return "", nil, ErrSelectUnsupportedAggregationType.WithCause(fmt.Errorf("unsupported aggregation type: %s", agg.Type))
 			}
@@ -136,6 +172,16 @@ func (p *SQLiteSelectProjection) Value() (string, []any, error) {
 				casePart := fmt.Sprintf("%s AS %s", caseSQL, caseExpr.Alias)
 				parts = append(parts, casePart)
 			}
+
+			if computed.WindowExpression != nil {
+				window := computed.WindowExpression
+				windowSQL, windowParams, err := p.buildWindowExpression(window)
+				if err != nil {
+					return "", nil, err
+				}
+				params = append(params, windowParams...)
+				parts = append(parts, fmt.Sprintf("%s AS %s", windowSQL, window.Alias))
+			}
 		}
 	}
 
@@ -262,6 +308,132 @@ func (p *SQLiteSelectProjection) buildCaseExpression(ce *query.CaseExpression) (
 	return strings.Join(parts, " "), params, nil
 }
 
+var windowFunctions = map[query.WindowFunction]string{
+	query.WindowFunctionRowNumber:  "ROW_NUMBER",
+	query.WindowFunctionRank:       "RANK",
+	query.WindowFunctionDenseRank:  "DENSE_RANK",
+	query.WindowFunctionLag:        "LAG",
+	query.WindowFunctionLead:       "LEAD",
+	query.WindowFunctionFirstValue: "FIRST_VALUE",
+	query.WindowFunctionLastValue:  "LAST_VALUE",
+	query.WindowFunctionSum:        "SUM",
+	query.WindowFunctionAvg:        "AVG",
+	query.WindowFunctionCount:      "COUNT",
+	query.WindowFunctionMin:        "MIN",
+	query.WindowFunctionMax:        "MAX",
+}
+
+func (p *SQLiteSelectProjection) buildWindowExpression(we *query.WindowExpression) (string, []any, error) {
+	fn, ok := windowFunctions[we.Function]
+	if !ok {
+		return "", nil, ErrSelectUnsupportedWindowFunction.WithCause(fmt.Errorf("unsupported window function: %s", we.Function))
+	}
+
+	var args []string
+	var params []any
+	switch {
+	case we.Field != "":
+		resolvedField, err := p.factory.resolveFieldReference(we.Field, p.schemas)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, resolvedField)
+	case we.Function == query.WindowFunctionCount:
+		args = append(args, "*")
+	}
+	if we.Function == query.WindowFunctionLag || we.Function == query.WindowFunctionLead {
+		offset := 1
+		if we.Offset != nil {
+			offset = *we.Offset
+		}
+		args = append(args, strconv.Itoa(offset))
+		if we.Default != nil {
+			defaultSQL, defaultParams, err := p.buildProjectionValue(we.Default)
+			if err != nil {
+				return "", nil, err
+			}
+			args = append(args, defaultSQL)
+			params = append(params, defaultParams...)
+		}
+	}
+
+	var over []string
+	if len(we.PartitionBy) > 0 {
+		var fields []string
+		for _, field := range we.PartitionBy {
+			resolvedField, err := p.factory.resolveFieldReference(field, p.schemas)
+			if err != nil {
+				return "", nil, err
+			}
+			fields = append(fields, resolvedField)
+		}
+		over = append(over, "PARTITION BY "+strings.Join(fields, ", "))
+	}
+	if len(we.OrderBy) > 0 {
+		var fields []string
+		for _, sort := range we.OrderBy {
+			resolvedField, err := p.factory.resolveFieldReference(sort.Field, p.schemas)
+			if err != nil {
+				return "", nil, err
+			}
+			direction := "ASC"
+			if sort.Direction == query.SortDirectionDesc {
+				direction = "DESC"
+			}
+			fields = append(fields, fmt.Sprintf("%s %s", resolvedField, direction))
+		}
+		over = append(over, "ORDER BY "+strings.Join(fields, ", "))
+	}
+	if we.Frame != nil {
+		frameSQL, err := buildWindowFrame(we.Frame)
+		if err != nil {
+			return "", nil, err
+		}
+		over = append(over, frameSQL)
+	}
+
+	sql := fmt.Sprintf("%s(%s) OVER (%s)", fn, strings.Join(args, ", "), strings.Join(over, " "))
+	return sql, params, nil
+}
+
+func buildWindowFrame(frame *query.WindowFrame) (string, error) {
+	var unit string
+	switch frame.Unit {
+	case query.WindowFrameRows:
+		unit = "ROWS"
+	case query.WindowFrameRange:
+		unit = "RANGE"
+	default:
+		return "", ErrSelectInvalidWindowFrame.WithCause(fmt.Errorf("unknown frame unit: %s", frame.Unit))
+	}
+	start, err := buildWindowFrameBound(frame.Start)
+	if err != nil {
+		return "", err
+	}
+	end, err := buildWindowFrameBound(frame.End)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s BETWEEN %s AND %s", unit, start, end), nil
+}
+
+func buildWindowFrameBound(bound query.WindowFrameBound) (string, error) {
+	switch bound.Type {
+	case query.WindowBoundUnboundedPreceding:
+		return "UNBOUNDED PRECEDING", nil
+	case query.WindowBoundPreceding:
+		return fmt.Sprintf("%d PRECEDING", bound.Offset), nil
+	case query.WindowBoundCurrentRow:
+		return "CURRENT ROW", nil
+	case query.WindowBoundFollowing:
+		return fmt.Sprintf("%d FOLLOWING", bound.Offset), nil
+	case query.WindowBoundUnboundedFollowing:
+		return "UNBOUNDED FOLLOWING", nil
+	default:
+		return "", ErrSelectInvalidWindowFrame.WithCause(fmt.Errorf("unknown frame bound: %s", bound.Type))
+	}
+}
+
 func (p *SQLiteSelectProjection) buildProjectionValue(value *query.FilterValue) (string, []any, error) {
 	if value.FieldRefVal != nil {
 		resolvedField, err := p.factory.resolveFieldReference(value.FieldRefVal.Field, p.schemas)
@@ -305,6 +477,23 @@ func (p *SQLiteSelectProjection) buildQueryFilter(filter *query.QueryFilter) (st
 }
 
 func (p *SQLiteSelectProjection) buildFilterCondition(condition *query.FilterCondition) (string, []any, error) {
+	resolvedField, err := p.factory.resolveFieldReference(condition.Field, p.schemas)
+	if err != nil {
+		return "", nil, err
+	}
+	switch condition.Operator {
+	case query.ComparisonOperatorSize, query.ComparisonOperatorElemMatch, query.ComparisonOperatorAll:
+		if err := p.factory.checkJSONField(condition.Field, p.schemas); err != nil {
+			return "", nil, err
+		}
+	}
+	return p.buildComparison(resolvedField, condition, 0)
+}
+
+// buildComparison renders condition against the SQL expression field, which
+// is either a resolved column reference or an array element inside an
+// elem_match. depth counts the enclosing elem_match scopes.
+func (p *SQLiteSelectProjection) buildComparison(field string, condition *query.FilterCondition, depth int) (string, []any, error) {
 	isNull := condition.Value.StringVal == nil &&
 		condition.Value.NumberVal == nil &&
 		condition.Value.BoolVal == nil &&
@@ -312,22 +501,32 @@ func (p *SQLiteSelectProjection) buildFilterCondition(condition *query.FilterCon
 		condition.Value.ArrayVal == nil &&
 		condition.Value.FieldRefVal == nil &&
 		condition.Value.FunctionCallVal == nil &&
-		condition.Value.SubqueryVal == nil
+		condition.Value.SubqueryVal == nil &&
+		condition.Value.ElementFilterVal == nil &&
+		condition.Value.ParamVal == nil
 
 	if isNull {
-		var resolvedField string
-		resolvedField, err := p.factory.resolveFieldReference(condition.Field, p.schemas)
-		if err != nil {
-			return "", nil, err
-		}
 		switch condition.Operator {
 		case query.ComparisonOperatorEq:
-			return fmt.Sprintf("%s IS NULL", resolvedField), nil, nil
+			return fmt.Sprintf("%s IS NULL", field), nil, nil
 		case query.ComparisonOperatorNeq:
-			return fmt.Sprintf("%s IS NOT NULL", resolvedField), nil, nil
+			return fmt.Sprintf("%s IS NOT NULL", field), nil, nil
 		}
 	}
 
+	switch condition.Operator {
+	case query.ComparisonOperatorExists:
+		return fmt.Sprintf("%s IS NOT NULL", field), nil, nil
+	case query.ComparisonOperatorNotExists:
+		return fmt.Sprintf("%s IS NULL", field), nil, nil
+	case query.ComparisonOperatorBetween:
+		return p.buildBetween(field, condition)
+	case query.ComparisonOperatorElemMatch:
+		return p.buildElemMatch(field, condition, depth)
+	case query.ComparisonOperatorAll:
+		return p.buildAll(field, condition, depth)
+	}
+
 	valueSQL, params, err := p.buildFilterValue(&condition.Value)
 	if err != nil {
 		return "", nil, err
@@ -357,28 +556,133 @@ func (p *SQLiteSelectProjection) buildFilterCondition(condition *query.FilterCon
 	case query.ComparisonOperatorNotContains:
 		operator = "NOT LIKE"
 		valueSQL = "'%' || " + valueSQL + " || '%'"
-	case query.ComparisonOperatorExists:
-		resolvedField, err := p.factory.resolveFieldReference(condition.Field, p.schemas)
-		if err != nil {
-			return "", nil, err
+	case query.ComparisonOperatorRegex:
+		// REGEXP calls the regexp() function registered by the executor's driver.
+		if condition.Value.StringVal == nil {
+			return "", nil, ErrSelectInvalidOperatorValue.WithMessagef("operator %q requires a string pattern", condition.Operator)
 		}
-		return fmt.Sprintf("%s IS NOT NULL", resolvedField), nil, nil
-	case query.ComparisonOperatorNotExists:
-		resolvedField, err := p.factory.resolveFieldReference(condition.Field, p.schemas)
-		if err != nil {
-			return "", nil, err
+		operator = "REGEXP"
+	case query.ComparisonOperatorIEq:
+		field = "LOWER(" + field + ")"
+		operator = "="
+		valueSQL = "LOWER(" + valueSQL + ")"
+	case query.ComparisonOperatorSize:
+		if condition.Value.NumberVal == nil {
+			return "", nil, ErrSelectInvalidOperatorValue.WithMessagef("operator %q requires a number", condition.Operator)
 		}
-		return fmt.Sprintf("%s IS NULL", resolvedField), nil, nil
+		field = "json_array_length(" + field + ")"
+		operator = "="
 	default:
 		return "", nil, ErrSelectUnsupportedOperator.WithCause(fmt.Errorf("unsupported operator: %s", condition.Operator))
 	}
 
-	resolvedField, err := p.factory.resolveFieldReference(condition.Field, p.schemas)
+	sql := fmt.Sprintf("%s %s %s", field, operator, valueSQL)
+	return sql, params, nil
+}
+
+// buildBetween renders an inclusive range check; the value holds the two
+// bounds.
+func (p *SQLiteSelectProjection) buildBetween(field string, condition *query.FilterCondition) (string, []any, error) {
+	if len(condition.Value.ArrayVal) != 2 {
+		return "", nil, ErrSelectInvalidOperatorValue.WithMessagef("operator %q requires a [low, high] array", condition.Operator)
+	}
+	lowSQL, lowParams, err := p.buildFilterValue(&condition.Value.ArrayVal[0])
 	if err != nil {
 		return "", nil, err
 	}
-	sql := fmt.Sprintf("%s %s %s", resolvedField, operator, valueSQL)
-	return sql, params, nil
+	highSQL, highParams, err := p.buildFilterValue(&condition.Value.ArrayVal[1])
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s BETWEEN %s AND %s", field, lowSQL, highSQL), append(lowParams, highParams...), nil
+}
+
+// buildElemMatch renders an EXISTS over the array's json_each rows, with the
+// element filter applied to each row.
+func (p *SQLiteSelectProjection) buildElemMatch(field string, condition *query.FilterCondition, depth int) (string, []any, error) {
+	if condition.Value.ElementFilterVal == nil {
+		return "", nil, ErrSelectInvalidOperatorValue.WithMessagef("operator %q requires an element filter", condition.Operator)
+	}
+	alias := elementAlias(depth)
+	filterSQL, params, err := p.buildElementFilter(&condition.Value.ElementFilterVal.Filter, alias, depth+1)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) AS %s WHERE %s)", field, alias, filterSQL), params, nil
+}
+
+// buildAll requires one matching json_each row for every value in the set.
+func (p *SQLiteSelectProjection) buildAll(field string, condition *query.FilterCondition, depth int) (string, []any, error) {
+	if len(condition.Value.ArrayVal) == 0 {
+		return "", nil, ErrSelectInvalidOperatorValue.WithMessagef("operator %q requires at least one value", condition.Operator)
+	}
+	alias := elementAlias(depth)
+	var conditions []string
+	var params []any
+	for _, item := range condition.Value.ArrayVal {
+		itemSQL, itemParams, err := p.buildFilterValue(&item)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) AS %s WHERE %s.value = %s)", field, alias, alias, itemSQL))
+		params = append(params, itemParams...)
+	}
+	return fmt.Sprintf("(%s)", strings.Join(conditions, " AND ")), params, nil
+}
+
+// buildElementFilter renders the filter of an elem_match against the
+// json_each row named alias.
+func (p *SQLiteSelectProjection) buildElementFilter(filter *query.QueryFilter, alias string, depth int) (string, []any, error) {
+	if filter.Condition != nil {
+		field, err := elementField(alias, filter.Condition.Field)
+		if err != nil {
+			return "", nil, err
+		}
+		return p.buildComparison(field, filter.Condition, depth)
+	}
+	if filter.Group != nil {
+		var conditions []string
+		var params []any
+		for _, condition := range filter.Group.Conditions {
+			condSQL, condParams, err := p.buildElementFilter(&condition, alias, depth)
+			if err != nil {
+				return "", nil, err
+			}
+			conditions = append(conditions, condSQL)
+			params = append(params, condParams...)
+		}
+
+		var operator string
+		switch filter.Group.Operator {
+		case common.LogicalAnd:
+			operator = "AND"
+		case common.LogicalOr:
+			operator = "OR"
+		default:
+			return "", nil, ErrSelectUnsupportedLogicalOperator.WithCause(fmt.Errorf("unsupported logical operator: %s", filter.Group.Operator))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(conditions, fmt.Sprintf(" %s ", operator))), params, nil
+	}
+	return "", nil, ErrSelectInvalidOperatorValue.WithMessage("element filters support conditions and groups only")
+}
+
+// elementAlias names the json_each row source of an elem_match or all
+// operator nested depth levels deep.
+func elementAlias(depth int) string {
+	return quoteIdentifier(fmt.Sprintf("_elem%d", depth))
+}
+
+// elementField renders path inside the json_each row alias. The empty path is
+// the element itself; other paths address object elements, and yield NULL
+// for scalar elements rather than failing on non-JSON text.
+func elementField(alias, path string) (string, error) {
+	if path == "" {
+		return alias + ".value", nil
+	}
+	if !isValidIdentifier(path) {
+		return "", ErrSelectUnsupportedFieldReference.WithCause(fmt.Errorf("unsupported field reference: %s", path))
+	}
+	return fmt.Sprintf("CASE WHEN %s.type = 'object' THEN json_extract(%s.value, '$.%s') END", alias, alias, path), nil
 }
 
 func (p *SQLiteSelectProjection) buildFilterGroup(group *query.FilterGroup) (string, []any, error) {
@@ -520,6 +824,14 @@ func (p *SQLiteSelectProjection) buildFilterValue(value *query.FilterValue) (str
 		param := p.factory.nextParam()
 		return param, []any{value.ObjectVal}, nil
 	}
+	if value.ElementFilterVal != nil {
+		return "", nil, ErrSelectUnsupportedFilterValue.WithMessage("element filters are only valid as the value of elem_match")
+	}
+	if value.ParamVal != nil {
+		// The executor resolves the parameter from the query's bindings.
+		param := p.factory.nextParam()
+		return param, []any{*value.ParamVal}, nil
+	}
 
 	return "NULL", nil, nil
 }
@@ -707,6 +1019,13 @@ func (g *SQLiteGroupByClause) Value() (string, []any, error) {
 				groupFields = append(groupFields, resolvedField)
 			}
 		}
+		for _, bucket := range agg.TimeGroups {
+			bucketSQL, err := g.factory.buildTimeBucket(bucket, g.schemas)
+			if err != nil {
+				return "", nil, err
+			}
+			groupFields = append(groupFields, bucketSQL)
+		}
 	}
 
 	if len(groupFields) == 0 {
@@ -726,6 +1045,36 @@ func (g *SQLiteGroupByClause) Value() (string, []any, error) {
 	return fmt.Sprintf("GROUP BY %s", strings.Join(uniqueFields, ", ")), nil, nil
 }
 
+// timeBucketFormats are the strftime formats that truncate a timestamp to
+// the start of its bucket, in the layout the in-memory helper produces.
+var timeBucketFormats = map[query.TimeBucketUnit]string{
+	query.TimeBucketMinute: "%Y-%m-%dT%H:%M:00Z",
+	query.TimeBucketHour:   "%Y-%m-%dT%H:00:00Z",
+	query.TimeBucketDay:    "%Y-%m-%dT00:00:00Z",
+	query.TimeBucketWeek:   "%Y-%m-%dT00:00:00Z",
+	query.TimeBucketMonth:  "%Y-%m-01T00:00:00Z",
+}
+
+// buildTimeBucket renders the expression truncating a timestamp column to
+// its bucket. Numeric columns hold Unix seconds; text columns hold ISO 8601
+// timestamps. Weeks start on Monday: 'weekday 0' moves to the coming Sunday
+// and six days back is that week's Monday.
+func (f *sqliteFactory) buildTimeBucket(bucket query.TimeBucket, schemas map[string]*definition.Schema) (string, error) {
+	format, ok := timeBucketFormats[bucket.Unit]
+	if !ok {
+		return "", ErrSelectUnsupportedTimeBucket.WithCause(fmt.Errorf("unsupported time bucket unit: %s", bucket.Unit))
+	}
+	resolvedField, err := f.resolveFieldReference(bucket.Field, schemas)
+	if err != nil {
+		return "", err
+	}
+	timestamp := fmt.Sprintf("CASE WHEN typeof(%[1]s) IN ('integer', 'real') THEN datetime(%[1]s, 'unixepoch') ELSE %[1]s END", resolvedField)
+	if bucket.Unit == query.TimeBucketWeek {
+		return fmt.Sprintf("strftime('%s', %s, 'weekday 0', '-6 days')", format, timestamp), nil
+	}
+	return fmt.Sprintf("strftime('%s', %s)", format, timestamp), nil
+}
+
 // SQLiteHavingClause handles HAVING clause
 type SQLiteHavingClause struct {
 	factory      *sqliteFactory
@@ -834,7 +1183,7 @@ func (u *SQLiteUnionClause) Value() (string, []any, error) {
 	var params []any
 
 	for i, subQuery := range u.union.Queries {
-		subFactory := newSQLiteFactory(u.factory.logger)
+		subFactory := u.factory.newRootScope()
 		selectTree, err := subFactory.buildSelectTree(&subQuery)
 		if err != nil {
 			return "", nil, err
@@ -1,7 +1,9 @@
 package query
 
 import (
+	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
@@ -28,14 +30,15 @@ func isJSONType(field *definition.Field) bool {
 
 // sqliteUpdateAssignments handles the SET clause in an UPDATE statement.
 type sqliteUpdateAssignments struct {
-	factory *sqliteFactory
-	set     data.Documenter
-	compute map[string]query.Query
-	schema  *definition.Schema
+	factory   *sqliteFactory
+	set       data.Documenter
+	compute   map[string]query.Query
+	operators []query.UpdateOperator
+	schema    *definition.Schema
 }
 
 func (u *sqliteUpdateAssignments) Value() (string, []any, error) {
-	if u.set == nil && len(u.compute) == 0 {
+	if u.set == nil && len(u.compute) == 0 && len(u.operators) == 0 {
 		return "", nil, nil
 	}
 
@@ -63,8 +66,10 @@ func (u *sqliteUpdateAssignments) Value() (string, []any, error) {
 	// 2. Process the sorted assignments, populating intermediate maps.
 	relationalParts := make(map[string]string)
 	relationalParams := make(map[string]any)
-	jsonUpdates := make(map[string]map[string]any) // parentColumn -> {jsonPath: valueOrExpr}
-	jsonParams := make(map[string][]any)           // parentColumn -> params
+	jsonUpdates := make(map[string]map[string]any)   // parentColumn -> {jsonPath: valueOrExpr}
+	jsonParams := make(map[string]map[string][]any)  // parentColumn -> {jsonPath: params}
+	jsonRemovals := make(map[string][]string)        // parentColumn -> jsonPaths
+	jsonOperatorColumns := make(map[string]struct{}) // parentColumns an operator writes into
 
 	for _, assign := range assignments {
 		fieldParts := strings.Split(assign.fieldPath, ".")
@@ -75,12 +80,15 @@ func (u *sqliteUpdateAssignments) Value() (string, []any, error) {
 
 		// Determine if this update targets a nested field within a JSON column.
 		if len(fieldParts) > 1 && isJSONType(topLevelField) {
+			if u.factory.binaryColumn(topLevelField) {
+				return "", nil, ErrBinaryNestedPath.WithMessagef("cannot update %s in binary column %s", assign.fieldPath, topLevelField.Name)
+			}
 			parentColumn := fieldParts[0]
 			jsonPath := "$." + strings.Join(fieldParts[1:], ".")
 
 			if _, ok := jsonUpdates[parentColumn]; !ok {
 				jsonUpdates[parentColumn] = make(map[string]any)
-				jsonParams[parentColumn] = []any{}
+				jsonParams[parentColumn] = make(map[string][]any)
 			}
 
 			if assign.isComputed {
@@ -94,7 +102,7 @@ func (u *sqliteUpdateAssignments) Value() (string, []any, error) {
 					expr = expr[1 : len(expr)-1]
 				}
 				jsonUpdates[parentColumn][jsonPath] = expr
-				jsonParams[parentColumn] = append(jsonParams[parentColumn], params...)
+				jsonParams[parentColumn][jsonPath] = params
 			} else {
 				param := u.factory.nextParam()
 				jsonUpdates[parentColumn][jsonPath] = param
@@ -106,7 +114,7 @@ func (u *sqliteUpdateAssignments) Value() (string, []any, error) {
 				if err != nil {
 					return "", nil, err
 				}
-				jsonParams[parentColumn] = append(jsonParams[parentColumn], convertedValue)
+				jsonParams[parentColumn][jsonPath] = []any{convertedValue}
 			}
 		} else { // This is a standard relational column update.
 			if assign.isComputed {
@@ -122,7 +130,13 @@ func (u *sqliteUpdateAssignments) Value() (string, []any, error) {
 			} else {
 				param := u.factory.nextParam()
 				relationalParts[assign.fieldPath] = fmt.Sprintf("%s = %s", quoteIdentifier(assign.fieldPath), param)
-				convertedValue, err := toSQLiteValue(topLevelField, assign.value, u.set)
+				var convertedValue any
+				var err error
+				if u.factory.binaryColumn(topLevelField) {
+					convertedValue, err = u.factory.toBinaryValue(u.schema, topLevelField, assign.value, u.set)
+				} else {
+					convertedValue, err = toSQLiteValue(topLevelField, assign.value, u.set)
+				}
 				if err != nil {
 					return "", nil, err
 				}
@@ -131,6 +145,57 @@ func (u *sqliteUpdateAssignments) Value() (string, []any, error) {
 		}
 	}
 
+	// Operators compile to expressions over the current value, so the whole
+	// change happens inside the UPDATE statement.
+	operators := slices.Clone(u.operators)
+	sort.SliceStable(operators, func(i, j int) bool {
+		return operators[i].Field < operators[j].Field
+	})
+	for _, op := range operators {
+		fieldParts := strings.Split(op.Field, ".")
+		var topLevelField *definition.Field
+		if u.schema != nil {
+			_, topLevelField = u.schema.FindField(fieldParts[0])
+		}
+		if u.factory.binaryColumn(topLevelField) {
+			return "", nil, ErrBinaryNestedPath.WithMessagef("cannot apply %s to %s in binary column %s", op.Type, op.Field, topLevelField.Name)
+		}
+
+		if len(fieldParts) > 1 && isJSONType(topLevelField) {
+			parentColumn := fieldParts[0]
+			jsonPath := "$." + strings.Join(fieldParts[1:], ".")
+			if op.Type == query.UpdateOperatorUnset {
+				jsonRemovals[parentColumn] = append(jsonRemovals[parentColumn], jsonPath)
+				continue
+			}
+
+			current := fmt.Sprintf("json_extract(%s, '%s')", quoteIdentifier(parentColumn), jsonPath)
+			expr, params, err := u.buildOperatorExpression(op, current, nil)
+			if err != nil {
+				return "", nil, err
+			}
+			// Arrays and objects stay JSON rather than becoming strings.
+			if op.IsArray() {
+				expr = fmt.Sprintf("json(%s)", expr)
+			}
+			if _, ok := jsonUpdates[parentColumn]; !ok {
+				jsonUpdates[parentColumn] = make(map[string]any)
+				jsonParams[parentColumn] = make(map[string][]any)
+			}
+			jsonUpdates[parentColumn][jsonPath] = expr
+			jsonParams[parentColumn][jsonPath] = params
+			jsonOperatorColumns[parentColumn] = struct{}{}
+			continue
+		}
+
+		expr, params, err := u.buildOperatorExpression(op, quoteIdentifier(op.Field), topLevelField)
+		if err != nil {
+			return "", nil, err
+		}
+		relationalParts[op.Field] = fmt.Sprintf("%s = %s", quoteIdentifier(op.Field), expr)
+		relationalParams[op.Field] = params
+	}
+
 	// 3. Assemble the final SQL string and parameters from the processed parts.
 	var finalParts []string
 	var finalParams []any
@@ -151,27 +216,47 @@ func (u *sqliteUpdateAssignments) Value() (string, []any, error) {
 	}
 
 	// Process JSON parts
-	jsonKeys := make([]string, 0, len(jsonUpdates))
+	jsonKeys := make([]string, 0, len(jsonUpdates)+len(jsonRemovals))
 	for k := range jsonUpdates {
 		jsonKeys = append(jsonKeys, k)
 	}
+	for k := range jsonRemovals {
+		if _, ok := jsonUpdates[k]; !ok {
+			jsonKeys = append(jsonKeys, k)
+		}
+	}
 	sort.Strings(jsonKeys)
 	for _, parentColumn := range jsonKeys {
-		updates := jsonUpdates[parentColumn]
-		paths := make([]string, 0, len(updates))
-		for path := range updates {
-			paths = append(paths, path)
+		expr := quoteIdentifier(parentColumn)
+		// Operators build on whatever the column holds, even when it is NULL.
+		if _, ok := jsonOperatorColumns[parentColumn]; ok {
+			expr = fmt.Sprintf("COALESCE(%s, '{}')", expr)
 		}
-		sort.Strings(paths) // Sort paths within a single json_set for determinism.
 
-		var jsonSetArgs []string
-		for _, path := range paths {
-			jsonSetArgs = append(jsonSetArgs, fmt.Sprintf("'%s'", path), fmt.Sprintf("%v", updates[path]))
+		if updates := jsonUpdates[parentColumn]; len(updates) > 0 {
+			paths := make([]string, 0, len(updates))
+			for path := range updates {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths) // Sort paths within a single json_set for determinism.
+
+			var jsonSetArgs []string
+			for _, path := range paths {
+				jsonSetArgs = append(jsonSetArgs, fmt.Sprintf("'%s'", path), fmt.Sprintf("%v", updates[path]))
+				finalParams = append(finalParams, jsonParams[parentColumn][path]...)
+			}
+			expr = fmt.Sprintf("json_set(%s, %s)", expr, strings.Join(jsonSetArgs, ", "))
+		}
+
+		if removals := jsonRemovals[parentColumn]; len(removals) > 0 {
+			quoted := make([]string, len(removals))
+			for i, path := range removals {
+				quoted[i] = fmt.Sprintf("'%s'", path)
+			}
+			expr = fmt.Sprintf("json_remove(%s, %s)", expr, strings.Join(quoted, ", "))
 		}
 
-		expr := fmt.Sprintf("json_set(%s, %s)", quoteIdentifier(parentColumn), strings.Join(jsonSetArgs, ", "))
 		finalParts = append(finalParts, fmt.Sprintf("%s = %s", quoteIdentifier(parentColumn), expr))
-		finalParams = append(finalParams, jsonParams[parentColumn]...)
 	}
 
 	if len(finalParts) == 0 {
@@ -181,6 +266,72 @@ func (u *sqliteUpdateAssignments) Value() (string, []any, error) {
 	return fmt.Sprintf("SET %s", strings.Join(finalParts, ", ")), finalParams, nil
 }
 
+// jsonElementText renders the json_each element of the current row as JSON
+// text, so elements compare with json($n) regardless of their type.
+const jsonElementText = "CASE json_each.type WHEN 'object' THEN json_each.value WHEN 'array' THEN json_each.value " +
+	"WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' WHEN 'null' THEN 'null' ELSE json_quote(json_each.value) END"
+
+// buildOperatorExpression renders the new value an update operator gives a
+// field, in terms of the SQL expression for its current value. fieldDef is
+// the column's definition for top-level fields and nil inside JSON columns.
+// A parameter that appears twice in the expression is bound once.
+func (u *sqliteUpdateAssignments) buildOperatorExpression(op query.UpdateOperator, current string, fieldDef *definition.Field) (string, []any, error) {
+	switch op.Type {
+	case query.UpdateOperatorUnset:
+		// Only top-level columns get here; nested paths are removed with json_remove.
+		return "NULL", nil, nil
+	case query.UpdateOperatorPop:
+		if op.PopFirst() {
+			return fmt.Sprintf("json_remove(%s, '$[0]')", current), nil, nil
+		}
+		return fmt.Sprintf("json_remove(%s, '$[#-1]')", current), nil, nil
+	}
+
+	param := u.factory.nextParam()
+	switch op.Type {
+	case query.UpdateOperatorIncrement:
+		return fmt.Sprintf("COALESCE(%s, 0) + %s", current, param), []any{op.Value}, nil
+	case query.UpdateOperatorDecrement:
+		return fmt.Sprintf("COALESCE(%s, 0) - %s", current, param), []any{op.Value}, nil
+	case query.UpdateOperatorMultiply:
+		return fmt.Sprintf("COALESCE(%s, 0) * %s", current, param), []any{op.Value}, nil
+	case query.UpdateOperatorMin:
+		return fmt.Sprintf("min(COALESCE(%s, %s), %s)", current, param, param), []any{op.Value}, nil
+	case query.UpdateOperatorMax:
+		return fmt.Sprintf("max(COALESCE(%s, %s), %s)", current, param, param), []any{op.Value}, nil
+	case query.UpdateOperatorSet:
+		if fieldDef != nil {
+			value, err := toSQLiteValue(fieldDef, op.Value, nil)
+			if err != nil {
+				return "", nil, err
+			}
+			return param, []any{value}, nil
+		}
+	}
+
+	encoded, err := json.Marshal(op.Value)
+	if err != nil {
+		return "", nil, ErrUpdateInvalidOperatorValue.WithPath(op.Field).WithCause(err)
+	}
+	params := []any{string(encoded)}
+	value := fmt.Sprintf("json(%s)", param)
+	appended := fmt.Sprintf("json_insert(COALESCE(%s, '[]'), '$[#]', %s)", current, value)
+
+	switch op.Type {
+	case query.UpdateOperatorSet:
+		return value, params, nil
+	case query.UpdateOperatorPush:
+		return appended, params, nil
+	case query.UpdateOperatorAddToSet:
+		return fmt.Sprintf("CASE WHEN EXISTS (SELECT 1 FROM json_each(%s) WHERE %s = %s) THEN %s ELSE %s END",
+			current, jsonElementText, value, current, appended), params, nil
+	case query.UpdateOperatorPull:
+		return fmt.Sprintf("CASE WHEN %s IS NULL THEN NULL ELSE (SELECT json('[' || COALESCE(group_concat(%s, ','), '') || ']') FROM json_each(%s) WHERE %s IS NOT %s) END",
+			current, jsonElementText, current, jsonElementText, value), params, nil
+	}
+	return "", nil, ErrUpdateUnsupportedOperator.WithPath(op.Field).WithMessagef("unsupported update operator '%s'", op.Type)
+}
+
 // buildSetClauseExpression generates the SQL expression for a computed value.
 // For subqueries (when q.Target != nil), it wraps them in parentheses.
 // For inline expressions, it returns them unwrapped.
@@ -319,7 +470,14 @@ func (f *sqliteFactory) buildUpdateTree(q *query.Query, updatePayload map[string
 			return nil, ErrUpdateInvalidComputeType.WithCause(fmt.Errorf("invalid data type for 'compute' in update: %T", computeVal))
 		}
 	}
-	if (setData == nil || setData.Len() == 0) && len(computeData) == 0 {
+	var operators []query.UpdateOperator
+	if operatorsVal, ok := updatePayload["operators"]; ok && operatorsVal != nil {
+		operators, ok = operatorsVal.([]query.UpdateOperator)
+		if !ok {
+			return nil, ErrUpdateInvalidOperatorsType.WithCause(fmt.Errorf("invalid data type for 'operators' in update: %T", operatorsVal))
+		}
+	}
+	if (setData == nil || setData.Len() == 0) && len(computeData) == 0 && len(operators) == 0 {
 		return nil, ErrUpdateQueryNoDataPayload
 	}
 
@@ -328,10 +486,11 @@ func (f *sqliteFactory) buildUpdateTree(q *query.Query, updatePayload map[string
 			target: q.Target,
 		},
 		assignments: &sqliteUpdateAssignments{
-			factory: f,
-			set:     setData,
-			compute: computeData,
-			schema:  q.Target.Schema,
+			factory:   f,
+			set:       setData,
+			compute:   computeData,
+			operators: operators,
+			schema:    q.Target.Schema,
 		},
 	}
 
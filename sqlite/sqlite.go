@@ -0,0 +1,247 @@
+// Package sqlite opens SQLite databases configured for production use and
+// wires them into an Anansi interactor.
+//
+// NewInteractor keeps two connection pools over one database file: a writer
+// pool limited to a single connection, through which every write and
+// transaction is serialized, and a read-only pool that serves SELECTs issued
+// outside a transaction. In WAL mode readers never wait for the writer, and
+// since the writer pool already serializes transactions, the interactor does
+// not ask persistence for its process-wide transaction mutex.
+//
+//	interactor, closeDB, err := sqlite.NewInteractor(sqlite.Options{Path: "app.db"}, logger)
+//	if err != nil {
+//		return err
+//	}
+//	defer closeDB()
+//	p, err := anansi.Setup(anansi.SetupConfig{Interactor: interactor, Logger: logger})
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/query/native"
+	"github.com/asaidimu/go-anansi/v8/sqlite/executor"
+	sqliteQuery "github.com/asaidimu/go-anansi/v8/sqlite/query"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidOptions = common.NewSystemError("ERR_SQLITE_INVALID_OPTIONS", "invalid sqlite options")
+	ErrOpenFailed     = common.NewSystemError("ERR_SQLITE_OPEN_FAILED", "failed to open sqlite database")
+)
+
+// JournalMode is a value of PRAGMA journal_mode.
+type JournalMode string
+
+const (
+	JournalModeWAL      JournalMode = "WAL"
+	JournalModeDelete   JournalMode = "DELETE"
+	JournalModeTruncate JournalMode = "TRUNCATE"
+	JournalModePersist  JournalMode = "PERSIST"
+	JournalModeMemory   JournalMode = "MEMORY"
+	JournalModeOff      JournalMode = "OFF"
+)
+
+// Synchronous is a value of PRAGMA synchronous.
+type Synchronous string
+
+const (
+	SynchronousOff    Synchronous = "OFF"
+	SynchronousNormal Synchronous = "NORMAL"
+	SynchronousFull   Synchronous = "FULL"
+	SynchronousExtra  Synchronous = "EXTRA"
+)
+
+// MemoryPath opens a private in-memory database. It has no separate readers:
+// every statement runs on the single writer connection.
+const MemoryPath = ":memory:"
+
+// Options configures NewInteractor. The zero value of every field except Path
+// selects the production default.
+type Options struct {
+	// Path is the database file, created when missing, or MemoryPath. It may
+	// be a "file:" URI with query parameters.
+	Path string
+
+	// JournalMode defaults to WAL, which lets readers run alongside the
+	// writer. Other modes block readers while a write commits.
+	JournalMode JournalMode
+
+	// Synchronous defaults to NORMAL, which is durable across application
+	// crashes in WAL mode and only risks the last commits on power loss.
+	Synchronous Synchronous
+
+	// BusyTimeout is how long a connection waits for a lock held by another
+	// process before failing with SQLITE_BUSY. Defaults to 5s.
+	BusyTimeout time.Duration
+
+	// DisableForeignKeys turns off foreign key enforcement, which is on by
+	// default.
+	DisableForeignKeys bool
+
+	// MmapSize is PRAGMA mmap_size in bytes. Zero keeps SQLite's default.
+	MmapSize int64
+
+	// CacheSize is PRAGMA cache_size: pages when positive, KiB when negative.
+	// Zero keeps SQLite's default.
+	CacheSize int
+
+	// MaxReaders caps the read-only pool. Defaults to runtime.NumCPU().
+	MaxReaders int
+
+	// StatementCacheSize is how many prepared statements each pool keeps,
+	// see executor.WithStatementCache. Defaults to 256; negative disables
+	// the cache.
+	StatementCacheSize int
+
+	// FactoryOptions are passed to the SQLite query factory, for example
+	// sqliteQuery.WithBinaryDocuments().
+	FactoryOptions []sqliteQuery.FactoryOption
+}
+
+// withDefaults returns o with unset fields filled in, or an error when a set
+// field is invalid.
+func (o Options) withDefaults() (Options, error) {
+	if o.Path == "" {
+		return o, ErrInvalidOptions.WithMessage("path is required; use sqlite.MemoryPath for an in-memory database")
+	}
+	switch o.JournalMode {
+	case "":
+		o.JournalMode = JournalModeWAL
+	case JournalModeWAL, JournalModeDelete, JournalModeTruncate, JournalModePersist, JournalModeMemory, JournalModeOff:
+	default:
+		return o, ErrInvalidOptions.WithMessagef("unknown journal mode %q", o.JournalMode)
+	}
+	switch o.Synchronous {
+	case "":
+		o.Synchronous = SynchronousNormal
+	case SynchronousOff, SynchronousNormal, SynchronousFull, SynchronousExtra:
+	default:
+		return o, ErrInvalidOptions.WithMessagef("unknown synchronous level %q", o.Synchronous)
+	}
+	if o.BusyTimeout < 0 || o.MmapSize < 0 || o.MaxReaders < 0 {
+		return o, ErrInvalidOptions.WithMessage("busy timeout, mmap size and max readers cannot be negative")
+	}
+	if o.BusyTimeout == 0 {
+		o.BusyTimeout = 5 * time.Second
+	}
+	if o.MaxReaders == 0 {
+		o.MaxReaders = runtime.NumCPU()
+	}
+	if o.StatementCacheSize == 0 {
+		o.StatementCacheSize = 256
+	}
+	return o, nil
+}
+
+// pragmas returns the statements run on every connection. readOnly adds
+// query_only; the journal mode is a property of the database file and is set
+// once through the writer.
+func (o Options) pragmas(readOnly bool) []string {
+	foreignKeys := "ON"
+	if o.DisableForeignKeys {
+		foreignKeys = "OFF"
+	}
+	pragmas := []string{
+		fmt.Sprintf("PRAGMA busy_timeout = %d", o.BusyTimeout.Milliseconds()),
+		fmt.Sprintf("PRAGMA synchronous = %s", o.Synchronous),
+		fmt.Sprintf("PRAGMA foreign_keys = %s", foreignKeys),
+	}
+	if o.MmapSize > 0 {
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA mmap_size = %d", o.MmapSize))
+	}
+	if o.CacheSize != 0 {
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA cache_size = %d", o.CacheSize))
+	}
+	if readOnly {
+		pragmas = append(pragmas, "PRAGMA query_only = ON")
+	}
+	return pragmas
+}
+
+// NewInteractor opens the database at opts.Path and returns an interactor
+// that writes through one serialized connection and reads through a pool of
+// read-only connections, together with a function that closes both pools.
+func NewInteractor(opts Options, logger *zap.Logger) (query.DatabaseInteractor, func() error, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, nil, err
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	writer, reader, err := openPools(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ix, err := executor.NewPooledSQLiteExecutor(writer, reader, logger, executor.WithStatementCache(opts.StatementCacheSize))
+	if err != nil {
+		closePools(writer, reader)
+		return nil, nil, err
+	}
+
+	factoryOpts := append([]sqliteQuery.FactoryOption{sqliteQuery.WithSerializedWriter(), sqliteQuery.WithRegexFunction()}, opts.FactoryOptions...)
+	interactor, err := native.NewNativeInteractor(ix, sqliteQuery.NewSQLiteFactory(logger, factoryOpts...), logger)
+	if err != nil {
+		closePools(writer, reader)
+		return nil, nil, err
+	}
+	return interactor, ix.Close, nil
+}
+
+// openPools opens the writer, applies the journal mode, then opens the
+// readers. An in-memory database is private to its connection, so it gets no
+// reader pool.
+func openPools(opts Options) (*sql.DB, *sql.DB, error) {
+	// BEGIN IMMEDIATE takes the write lock up front, so a transaction never
+	// fails to upgrade from a read lock when another process is writing.
+	writer := sql.OpenDB(executor.NewConnector(withParameter(opts.Path, "_txlock=immediate"), opts.pragmas(false)...))
+	writer.SetMaxOpenConns(1)
+	writer.SetMaxIdleConns(1)
+	writer.SetConnMaxLifetime(0)
+	writer.SetConnMaxIdleTime(0)
+
+	ctx := context.Background()
+	if _, err := writer.ExecContext(ctx, fmt.Sprintf("PRAGMA journal_mode = %s", opts.JournalMode)); err != nil {
+		writer.Close()
+		return nil, nil, ErrOpenFailed.WithCause(err).WithPath(opts.Path).WithOperation("sqlite.NewInteractor")
+	}
+
+	if opts.Path == MemoryPath {
+		return writer, nil, nil
+	}
+
+	reader := sql.OpenDB(executor.NewConnector(opts.Path, opts.pragmas(true)...))
+	reader.SetMaxOpenConns(opts.MaxReaders)
+	reader.SetMaxIdleConns(opts.MaxReaders)
+	if err := reader.PingContext(ctx); err != nil {
+		closePools(writer, reader)
+		return nil, nil, ErrOpenFailed.WithCause(err).WithPath(opts.Path).WithOperation("sqlite.NewInteractor")
+	}
+	return writer, reader, nil
+}
+
+// withParameter appends a DSN query parameter to path, which may be a file:
+// URI carrying parameters of its own.
+func withParameter(path, parameter string) string {
+	if strings.Contains(path, "?") {
+		return path + "&" + parameter
+	}
+	return path + "?" + parameter
+}
+
+func closePools(writer, reader *sql.DB) {
+	writer.Close()
+	if reader != nil {
+		reader.Close()
+	}
+}
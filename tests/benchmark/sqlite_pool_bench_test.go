@@ -0,0 +1,155 @@
+package benchmark
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/query/native"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/sqlite"
+	sqliteExecutor "github.com/asaidimu/go-anansi/v8/sqlite/executor"
+	sqliteQuery "github.com/asaidimu/go-anansi/v8/sqlite/query"
+)
+
+// The throughput benchmarks compare two ways of running SQLite on a WAL file
+// under concurrent load: a single database/sql pool shared by reads and writes,
+// with persistence serializing transactions behind its mutex, and the
+// sqlite.NewInteractor split of one writer connection plus a read-only pool.
+//
+//	ANANSI_ENV=development go test ./tests/benchmark/ -bench=BenchmarkSQLiteThroughput -cpu=1,4,8
+
+const eventSchemaJSON = `{
+  "version": "1.0.0",
+  "name": "bench_event",
+  "fields": {
+    "kind":  { "name": "kind",  "type": "string" },
+    "value": { "name": "value", "type": "number" }
+  }
+}`
+
+// openSharedInteractor opens path as a single pool, as the plain
+// NewSQLiteExecutor setup does.
+func openSharedInteractor(b *testing.B, path string) query.DatabaseInteractor {
+	db, err := sql.Open(sqliteExecutor.DriverName, fmt.Sprintf("file:%s?_fk=1&_journal_mode=WAL&_busy_timeout=5000", path))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	executor, err := sqliteExecutor.NewSQLiteExecutor(db, zap.NewNop())
+	if err != nil {
+		b.Fatal(err)
+	}
+	interactor, err := native.NewNativeInteractor(executor, sqliteQuery.NewSQLiteFactory(nil), zap.NewNop())
+	if err != nil {
+		b.Fatal(err)
+	}
+	return interactor
+}
+
+func openPooledInteractor(b *testing.B, path string) query.DatabaseInteractor {
+	interactor, closeDB, err := sqlite.NewInteractor(sqlite.Options{Path: path}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = closeDB() })
+	return interactor
+}
+
+// newEventCollection creates the event collection on interactor and seeds
+// warmDocs documents, returning the collection and the seeded ids.
+func newEventCollection(b *testing.B, interactor query.DatabaseInteractor) (base.Collection, []string) {
+	b.Helper()
+	if err := data.ConfigureDocumentFactory(data.DocumentFactoryConfig{}, nil); err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+	sc, err := definition.FromJSON([]byte(eventSchemaJSON))
+	if err != nil {
+		b.Fatal(err)
+	}
+	p, err := persistence.NewPersistence(interactor, nil, zap.NewNop(), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	coll, err := p.CreateCollection(ctx, sc)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ids := make([]string, 0, warmDocs)
+	for i := 0; i < warmDocs; i++ {
+		res, err := coll.CreateMany(ctx, []data.Documenter{eventDocument(b, i)})
+		if err != nil {
+			b.Fatal(err)
+		}
+		ids = append(ids, res[0].Data.ID())
+	}
+	return coll, ids
+}
+
+func eventDocument(b *testing.B, i int) data.Documenter {
+	doc, err := data.NewDocument(map[string]any{"kind": "click", "value": float64(i)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return doc
+}
+
+// BenchmarkSQLiteThroughput runs parallel workloads where writeEvery is the
+// share of operations that insert: 0 reads only, 1 writes only, 10 inserts on
+// every tenth operation and reads by id otherwise.
+func BenchmarkSQLiteThroughput(b *testing.B) {
+	setups := []struct {
+		name string
+		open func(*testing.B, string) query.DatabaseInteractor
+	}{
+		{"shared", openSharedInteractor},
+		{"pooled", openPooledInteractor},
+	}
+	workloads := []struct {
+		name       string
+		writeEvery int
+	}{
+		{"read", 0},
+		{"write", 1},
+		{"mixed", 10},
+	}
+
+	for _, w := range workloads {
+		for _, s := range setups {
+			b.Run(w.name+"/"+s.name, func(b *testing.B) {
+				interactor := s.open(b, filepath.Join(b.TempDir(), "bench.db"))
+				coll, ids := newEventCollection(b, interactor)
+				ctx := context.Background()
+				var seq atomic.Int64
+
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						n := int(seq.Add(1))
+						if w.writeEvery > 0 && n%w.writeEvery == 0 {
+							if _, err := coll.CreateMany(ctx, []data.Documenter{eventDocument(b, n)}); err != nil {
+								b.Error(err)
+								return
+							}
+							continue
+						}
+						if _, err := coll.Read(ctx, idQuery(ids[n%len(ids)])); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			})
+		}
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"testing"
 
+	"github.com/asaidimu/go-anansi/v8/core/common"
 	"github.com/asaidimu/go-anansi/v8/core/data"
 	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
 	pevents "github.com/asaidimu/go-anansi/v8/core/persistence/events"
 	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
 	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
 	rootutils "github.com/asaidimu/go-anansi/v8/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -121,3 +123,326 @@ func TestCollection_Delete(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 0, readDeletedResult.Count)
 }
+
+func TestCollection_ReadWindows(t *testing.T) {
+	ctx := context.Background()
+	p, cleanup := setupSQLitePersistence(t)
+	defer cleanup()
+	collection, err := p.CreateCollection(ctx, newMigrationTestSchema("windows", map[definition.FieldId]definition.Field{
+		fieldID(): {Name: "name", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+		fieldID(): {Name: "age", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeInteger}},
+		fieldID(): {Name: "price", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeNumber}},
+	}))
+	require.NoError(t, err)
+
+	for _, row := range []map[string]any{
+		{"name": "ana", "age": 30, "price": 1.5},
+		{"name": "ben", "age": 40, "price": 2.5},
+		{"name": "cid", "age": 30, "price": 3.0},
+		{"name": "dee", "age": 20, "price": 4.0},
+	} {
+		_, err := collection.CreateOne(ctx, data.MustNewDocument(row))
+		require.NoError(t, err)
+	}
+
+	// With an include list the windows are evaluated by SQLite, over every
+	// row, before the page is cut.
+	ranked := query.NewQueryBuilder().Select().
+		Include("name").
+		AddWindow("position", query.WindowFunctionRank, "").OrderBy("age", query.SortDirectionDesc).End().
+		End().
+		OrderBy("age", query.SortDirectionDesc).
+		ThenSortBy("name", query.SortDirectionAsc).
+		Limit(3).
+		Build()
+	result, err := collection.Read(ctx, &ranked)
+	require.NoError(t, err)
+	require.Equal(t, 3, result.Count)
+	var names, positions []any
+	for _, doc := range result.Data {
+		names = append(names, doc.MustGet("name"))
+		positions = append(positions, doc.MustGet("position"))
+	}
+	assert.Equal(t, []any{"ben", "ana", "cid"}, names)
+	assert.Equal(t, []any{int64(1), int64(2), int64(2)}, positions)
+
+	// Without one, they run in memory after the database read.
+	running := query.NewQueryBuilder().Select().
+		AddWindow("running", query.WindowFunctionSum, "price").OrderBy("name", query.SortDirectionAsc).Rows(query.UnboundedPreceding(), query.CurrentRow()).End().
+		End().
+		OrderBy("name", query.SortDirectionDesc).
+		Limit(1).
+		Build()
+	result, err = collection.Read(ctx, &running)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Count)
+	assert.Equal(t, "dee", result.Data[0].MustGet("name"))
+	assert.Equal(t, 11.0, result.Data[0].MustGet("running"))
+}
+
+func TestCollection_ReadTimeBucketedAggregations(t *testing.T) {
+	ctx := context.Background()
+	p, cleanup := setupSQLitePersistence(t)
+	defer cleanup()
+	collection, err := p.CreateCollection(ctx, newMigrationTestSchema("page_views", map[definition.FieldId]definition.Field{
+		fieldID(): {Name: "user", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+		fieldID(): {Name: "ms", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeInteger}},
+		fieldID(): {Name: "at", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+	}))
+	require.NoError(t, err)
+
+	for _, row := range []map[string]any{
+		{"user": "ana", "ms": 120, "at": "2024-03-04T09:15:30Z"},
+		{"user": "ben", "ms": 80, "at": "2024-03-04T09:45:00Z"},
+		{"user": "ana", "ms": 200, "at": "2024-03-10T23:59:59Z"},
+		{"user": "ben", "ms": 60, "at": "2024-04-01T00:00:00+02:00"},
+	} {
+		_, err := collection.CreateOne(ctx, data.MustNewDocument(row))
+		require.NoError(t, err)
+	}
+
+	// SQLite buckets the timestamps itself, one row per week.
+	weekly := query.NewQueryBuilder().
+		GroupByTime("at", query.TimeBucketWeek, "week").Type(query.AggregationTypeCountDistinct).Field("user").Alias("visitors").End().
+		Build()
+	result, err := collection.Read(ctx, &weekly)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Count)
+	visitors := make(map[any]any)
+	for _, doc := range result.Data {
+		visitors[doc.MustGet("week")] = doc.MustGet("visitors")
+	}
+	assert.Equal(t, map[any]any{"2024-03-04T00:00:00Z": int64(2), "2024-03-25T00:00:00Z": int64(1)}, visitors)
+
+	// A median has no SQLite aggregate, so the whole aggregation runs in
+	// memory and buckets the same way.
+	median := query.NewQueryBuilder().
+		GroupByTime("at", query.TimeBucketWeek, "week").Type(query.AggregationTypeMedian).Field("ms").Alias("median_ms").End().
+		Build()
+	result, err = collection.Read(ctx, &median)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Count)
+	medians := make(map[any]any)
+	for _, doc := range result.Data {
+		medians[doc.MustGet("week")] = doc.MustGet("median_ms")
+	}
+	assert.Equal(t, map[any]any{"2024-03-04T00:00:00Z": 120.0, "2024-03-25T00:00:00Z": 60.0}, medians)
+}
+
+func TestCollection_ReadInMemoryAggregationsIgnorePagination(t *testing.T) {
+	ctx := context.Background()
+	p, cleanup := setupSQLitePersistence(t)
+	defer cleanup()
+	collection, err := p.CreateCollection(ctx, newMigrationTestSchema("prices", map[definition.FieldId]definition.Field{
+		fieldID(): {Name: "price", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeNumber}},
+	}))
+	require.NoError(t, err)
+	for _, price := range []float64{1, 1, 100, 200} {
+		_, err := collection.CreateOne(ctx, data.MustNewDocument(map[string]any{"price": price}))
+		require.NoError(t, err)
+	}
+
+	// SQLite limits the aggregate rows, not the rows aggregated.
+	pushed := query.NewQueryBuilder().Max("price", "max").Limit(2).Build()
+	result, err := collection.Read(ctx, &pushed)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Count)
+	assert.EqualValues(t, 200, result.Data[0].MustGet("max"))
+
+	// A standard deviation runs in memory, and must see every row too.
+	inMemory := query.NewQueryBuilder().Max("price", "max").Stddev("price", "sd").OrderByAsc("price").Limit(2).Build()
+	result, err = collection.Read(ctx, &inMemory)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Count)
+	assert.EqualValues(t, 200, result.Data[0].MustGet("max"))
+	assert.InDelta(t, 95.22, result.Data[0].MustGet("sd"), 0.01)
+}
+
+func TestCollection_UpdateOperators(t *testing.T) {
+	ctx := context.Background()
+	p, cleanup := setupSQLitePersistence(t)
+	defer cleanup()
+	profileID := definition.SchemaId(fieldID())
+	schema := newMigrationTestSchema("counters", map[definition.FieldId]definition.Field{
+		fieldID(): {Name: "name", Required: true, FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+		fieldID(): {Name: "views", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeInteger}},
+		fieldID(): {Name: "score", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeNumber}},
+		fieldID(): {Name: "best", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeInteger}},
+		fieldID(): {Name: "note", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+		fieldID(): {Name: "tags", FieldProperties: definition.FieldProperties{
+			Type:   definition.FieldTypeArray,
+			Schema: definition.NewSchemaReference(definition.SchemaReference{Type: definition.FieldTypeString}),
+		}},
+		fieldID(): {Name: "profile", FieldProperties: definition.FieldProperties{
+			Type:   definition.FieldTypeObject,
+			Schema: definition.NewSchemaReference(definition.SchemaReference{ID: profileID}),
+		}},
+	})
+	schema.Schemas = map[definition.SchemaId]definition.NestedSchema{
+		profileID: {BaseSchema: definition.BaseSchema{Name: "profile", Fields: map[definition.FieldId]definition.Field{
+			fieldID(): {Name: "city", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+			fieldID(): {Name: "country", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+			fieldID(): {Name: "visits", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeInteger}},
+		}}},
+	}
+	collection, err := p.CreateCollection(ctx, schema)
+	require.NoError(t, err)
+
+	_, err = collection.CreateOne(ctx, data.MustNewDocument(map[string]any{
+		"name":    "home",
+		"views":   10,
+		"score":   2.5,
+		"best":    7,
+		"note":    "draft",
+		"tags":    []any{"a", "b", "a"},
+		"profile": map[string]any{"city": "Nairobi", "visits": 1},
+	}))
+	require.NoError(t, err)
+
+	read := func() map[string]any {
+		t.Helper()
+		q := query.NewQueryBuilder().Where("name").Eq("home").Build()
+		result, err := collection.Read(ctx, &q)
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Count)
+		return result.Data[0].ToMap()
+	}
+	version := func(doc map[string]any) float64 {
+		t.Helper()
+		v, ok := doc[data.MetadataField].(map[string]any)[data.MetadataVersion].(float64)
+		require.True(t, ok, "metadata version is a number")
+		return v
+	}
+	update := func(u *base.CollectionUpdate) {
+		t.Helper()
+		filter := query.NewQueryBuilder().Where("name").Eq("home").Build().Filters
+		_, err := collection.Update(ctx, u.WithFilter(filter))
+		require.NoError(t, err)
+	}
+
+	before := version(read())
+	update(base.NewCollectionUpdate().
+		Increment("views", 5).
+		Multiply("score", 2).
+		Max("best", 3).
+		Unset("note").
+		SetPath("profile.country", "KE").
+		Increment("profile.visits", 1).
+		Pull("tags", "a"))
+
+	doc := read()
+	assert.EqualValues(t, 15, doc["views"])
+	assert.EqualValues(t, 5.0, doc["score"])
+	assert.EqualValues(t, 7, doc["best"])
+	assert.Nil(t, doc["note"])
+	assert.Equal(t, map[string]any{"city": "Nairobi", "country": "KE", "visits": int64(2)}, doc["profile"])
+	assert.Equal(t, before+1, version(doc), "one update bumps the version once")
+
+	// Pull removed both "a"s; a field takes one operator per update.
+	update(base.NewCollectionUpdate().AddToSet("tags", "b").Decrement("views", 1))
+	update(base.NewCollectionUpdate().AddToSet("tags", "d").Min("best", 3))
+	update(base.NewCollectionUpdate().Pop("tags", true))
+	update(base.NewCollectionUpdate().Push("tags", "c"))
+	doc = read()
+	assert.Equal(t, []string{"d", "c"}, doc["tags"])
+	assert.EqualValues(t, 14, doc["views"])
+	assert.EqualValues(t, 3, doc["best"])
+	assert.Equal(t, before+5, version(doc))
+
+	// Operator-only updates are not empty updates; invalid ones are rejected
+	// before they reach the database.
+	for name, u := range map[string]*base.CollectionUpdate{
+		"unknown field":      base.NewCollectionUpdate().Increment("clicks", 1),
+		"wrong type":         base.NewCollectionUpdate().Push("views", 1),
+		"required":           base.NewCollectionUpdate().Unset("name"),
+		"overlapping":        base.NewCollectionUpdate().SetPath("profile.city", "Mombasa").Unset("profile"),
+		"conflicts with set": base.NewCollectionUpdate().SetField("views", 1).Increment("views", 1),
+	} {
+		filter := query.NewQueryBuilder().Where("name").Eq("home").Build().Filters
+		_, err := collection.Update(ctx, u.WithFilter(filter))
+		assert.Error(t, err, name)
+	}
+	assert.EqualValues(t, 14, read()["views"])
+}
+
+func TestCollection_ReadArrayAndRegexPredicates(t *testing.T) {
+	ctx := context.Background()
+	p, cleanup := setupSQLitePersistence(t)
+	defer cleanup()
+	itemID := definition.SchemaId(fieldID())
+	schema := newMigrationTestSchema("orders", map[definition.FieldId]definition.Field{
+		fieldID(): {Name: "code", Required: true, FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+		fieldID(): {Name: "total", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeNumber}},
+		fieldID(): {Name: "tags", FieldProperties: definition.FieldProperties{
+			Type:   definition.FieldTypeArray,
+			Schema: definition.NewSchemaReference(definition.SchemaReference{Type: definition.FieldTypeString}),
+		}},
+		fieldID(): {Name: "items", FieldProperties: definition.FieldProperties{
+			Type:   definition.FieldTypeArray,
+			Schema: definition.NewSchemaReference(definition.SchemaReference{ID: itemID}),
+		}},
+	})
+	schema.Schemas = map[definition.SchemaId]definition.NestedSchema{
+		itemID: {BaseSchema: definition.BaseSchema{Name: "item", Fields: map[definition.FieldId]definition.Field{
+			fieldID(): {Name: "sku", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+			fieldID(): {Name: "qty", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeInteger}},
+		}}},
+	}
+	collection, err := p.CreateCollection(ctx, schema)
+	require.NoError(t, err)
+
+	for _, order := range []map[string]any{
+		{"code": "ORD-001", "total": 12.5, "tags": []any{"rush", "gift"}, "items": []any{
+			map[string]any{"sku": "pen", "qty": 10}, map[string]any{"sku": "ink", "qty": 1},
+		}},
+		{"code": "ord-002", "total": 40.0, "tags": []any{"gift"}, "items": []any{
+			map[string]any{"sku": "pen", "qty": 1},
+		}},
+		{"code": "INV-003", "total": 99.0, "tags": []any{}, "items": []any{}},
+	} {
+		_, err := collection.CreateOne(ctx, data.MustNewDocument(order))
+		require.NoError(t, err)
+	}
+
+	item := func(sku string, minQty int) query.QueryFilter {
+		return query.QueryFilter{Group: &query.FilterGroup{
+			Operator: common.LogicalAnd,
+			Conditions: []query.QueryFilter{
+				{Condition: &query.FilterCondition{Field: "sku", Operator: query.ComparisonOperatorEq, Value: query.NewFilterValue(sku)}},
+				{Condition: &query.FilterCondition{Field: "qty", Operator: query.ComparisonOperatorGte, Value: query.NewFilterValue(minQty)}},
+			},
+		}}
+	}
+
+	testCases := []struct {
+		name     string
+		query    query.Query
+		expected []string
+	}{
+		{"regex", query.NewQueryBuilder().Where("code").Matches(`^ORD-\d+$`).Build(), []string{"ORD-001"}},
+		{"case-insensitive regex", query.NewQueryBuilder().Where("code").Matches(`(?i)^ord-`).Build(), []string{"ORD-001", "ord-002"}},
+		{"ieq", query.NewQueryBuilder().Where("code").EqualFold("ORD-002").Build(), []string{"ord-002"}},
+		{"between", query.NewQueryBuilder().Where("total").Between(12.5, 40).Build(), []string{"ORD-001", "ord-002"}},
+		{"size", query.NewQueryBuilder().Where("tags").Size(0).Build(), []string{"INV-003"}},
+		{"all", query.NewQueryBuilder().Where("tags").All("gift", "rush").Build(), []string{"ORD-001"}},
+		{"elem_match on objects", query.NewQueryBuilder().Where("items").ElemMatch(item("pen", 5)).Build(), []string{"ORD-001"}},
+		{"elem_match matches within one element", query.NewQueryBuilder().Where("items").ElemMatch(item("ink", 5)).Build(), nil},
+		{"elem_match on scalars", query.NewQueryBuilder().Where("tags").ElemMatch(query.QueryFilter{
+			Condition: &query.FilterCondition{Field: "", Operator: query.ComparisonOperatorRegex, Value: query.NewFilterValue("^ru")},
+		}).Build(), []string{"ORD-001"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := tc.query
+			q.Sort = []query.SortConfiguration{{Field: "total", Direction: query.SortDirectionAsc}}
+			result, err := collection.Read(ctx, &q)
+			require.NoError(t, err)
+			var codes []string
+			for _, doc := range result.Data {
+				codes = append(codes, doc.MustGet("code").(string))
+			}
+			assert.Equal(t, tc.expected, codes)
+		})
+	}
+}
@@ -431,3 +431,50 @@ func TestSQLiteCapabilities_OtherFeatures(t *testing.T) {
 	require.NoError(t, err)
 	assert.Contains(t, nqNested.Raw().SQL, "json_extract(\"profile\", '$.address.city') = $1")
 }
+
+func TestSQLiteCapabilities_WindowFunctions(t *testing.T) {
+	builder := sqlite.NewSQLiteFactory(nil)
+	schema := &definition.Schema{
+		BaseSchema: definition.BaseSchema{
+			Name: "scores",
+			Fields: map[definition.FieldId]definition.Field{
+				"player": {Name: "player", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+				"team":   {Name: "team", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+				"score":  {Name: "score", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeInteger}},
+			},
+		},
+	}
+
+	// Test ROW_NUMBER per partition
+	qRank := query.NewQueryBuilder().From("scores").Schema(schema).Select().Include("player").
+		AddWindow("position", query.WindowFunctionRowNumber, "").PartitionBy("team").OrderBy("score", query.SortDirectionDesc).End().
+		End().Build()
+	nqRank, err := builder.Build(&qRank, native.StmtSelect, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "player", ROW_NUMBER() OVER (PARTITION BY "team" ORDER BY "score" DESC) AS position FROM "scores"`, nqRank.Raw().SQL)
+
+	// Test LAG with offset and default
+	qLag := query.NewQueryBuilder().From("scores").Schema(schema).Select().
+		AddWindow("previous", query.WindowFunctionLag, "score").OrderBy("player", query.SortDirectionAsc).Offset(2).Default(0).End().
+		End().Build()
+	nqLag, err := builder.Build(&qLag, native.StmtSelect, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT LAG("score", 2, $1) OVER (ORDER BY "player" ASC) AS previous FROM "scores"`, nqLag.Raw().SQL)
+	assert.Equal(t, []any{0.0}, nqLag.Raw().Params)
+
+	// Test moving average frame
+	qAvg := query.NewQueryBuilder().From("scores").Schema(schema).Select().
+		AddWindow("moving", query.WindowFunctionAvg, "score").OrderBy("player", query.SortDirectionAsc).Rows(query.Preceding(2), query.CurrentRow()).End().
+		End().Build()
+	nqAvg, err := builder.Build(&qAvg, native.StmtSelect, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT AVG("score") OVER (ORDER BY "player" ASC ROWS BETWEEN 2 PRECEDING AND CURRENT ROW) AS moving FROM "scores"`, nqAvg.Raw().SQL)
+
+	// Test COUNT over the whole result
+	qCount := query.NewQueryBuilder().From("scores").Schema(schema).Select().
+		AddWindow("players", query.WindowFunctionCount, "").End().
+		End().Build()
+	nqCount, err := builder.Build(&qCount, native.StmtSelect, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT COUNT(*) OVER () AS players FROM "scores"`, nqCount.Raw().SQL)
+}
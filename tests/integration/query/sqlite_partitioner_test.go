@@ -106,6 +106,71 @@ func TestQueryPartitioner_Partition_MixedFilters_OR(t *testing.T) {
 	assert.Len(t, postQuery.Filters.Group.Conditions, 2)
 }
 
+func TestQueryPartitioner_Partition_InMemoryAggregationsKeepPage(t *testing.T) {
+	partitioner := query.NewQueryPartitioner(sqlite.NewSQLiteFactory(nil).Capabilities())
+
+	// Stddev has no SQLite aggregate, so every aggregation runs in memory and
+	// the page applies to their result, not to the rows read.
+	dsl := query.NewQueryBuilder().
+		From("products").
+		Max("price", "max").
+		Stddev("price", "sd").
+		OrderByAsc("price").
+		Limit(2).
+		Build()
+
+	dbQuery, postQuery, err := partitioner.Partition(&dsl)
+	require.NoError(t, err)
+	assert.Empty(t, dbQuery.Aggregations)
+	assert.Nil(t, dbQuery.Pagination)
+	assert.Empty(t, dbQuery.Sort)
+	assert.Len(t, postQuery.Aggregations, 2)
+	assert.Equal(t, dsl.Pagination, postQuery.Pagination)
+	assert.Equal(t, dsl.Sort, postQuery.Sort)
+}
+
+func TestQueryPartitioner_Partition_ResidualFilterKeepsPage(t *testing.T) {
+	capabilities := sqlite.NewSQLiteFactory(nil).Capabilities()
+	delete(capabilities.SupportedComparisonOperators, query.ComparisonOperatorContains)
+	partitioner := query.NewQueryPartitioner(capabilities)
+
+	// The database cannot drop the rows the in-memory filter rejects, so
+	// cutting the page there would return short or shifted pages.
+	dsl := query.NewQueryBuilder().
+		From("users").
+		Where("age").Gt(30).
+		Where("name").Contains("A").
+		OrderByAsc("age").
+		Limit(10).
+		Offset(20).
+		Build()
+
+	dbQuery, postQuery, err := partitioner.Partition(&dsl)
+	require.NoError(t, err)
+	assert.NotNil(t, dbQuery.Filters)
+	assert.NotNil(t, postQuery.Filters)
+	assert.Nil(t, dbQuery.Pagination)
+	assert.Empty(t, dbQuery.Sort)
+	assert.Equal(t, dsl.Pagination, postQuery.Pagination)
+	assert.Equal(t, dsl.Sort, postQuery.Sort)
+
+	// Once every filter is pushed down, the database cuts the page again.
+	dsl = query.NewQueryBuilder().
+		From("users").
+		Where("age").Gt(30).
+		OrderByAsc("age").
+		Limit(10).
+		Offset(20).
+		Build()
+
+	dbQuery, postQuery, err = partitioner.Partition(&dsl)
+	require.NoError(t, err)
+	assert.Equal(t, dsl.Pagination, dbQuery.Pagination)
+	assert.Equal(t, dsl.Sort, dbQuery.Sort)
+	assert.Nil(t, postQuery.Pagination)
+	assert.Empty(t, postQuery.Sort)
+}
+
 func TestQueryPartitioner_Partition_UnsupportedJoin(t *testing.T) {
 	factory := sqlite.NewSQLiteFactory(nil)
 	capabilities := factory.Capabilities()
@@ -167,3 +232,33 @@ func TestQueryPartitioner_Partition_ProjectionAugmentation(t *testing.T) {
 
 	assert.NotNil(t, postQuery.Filters)
 }
+
+func TestQueryPartitioner_Partition_ResidualFilterWithoutInclude(t *testing.T) {
+	capabilities := sqlite.NewSQLiteFactory(nil).Capabilities()
+	delete(capabilities.SupportedComparisonOperators, query.ComparisonOperatorContains)
+	partitioner := query.NewQueryPartitioner(capabilities)
+
+	// Without an include list the database already returns every field;
+	// narrowing it to the residual filter's dependencies would drop the rest.
+	dsl := query.NewQueryBuilder().
+		From("users").
+		Where("name").Contains("A").
+		Build()
+
+	dbQuery, postQuery, err := partitioner.Partition(&dsl)
+	require.NoError(t, err)
+	assert.Nil(t, dbQuery.Projection)
+	assert.NotNil(t, postQuery.Filters)
+
+	dsl = query.NewQueryBuilder().
+		From("users").
+		Select().Exclude("password").End().
+		Where("name").Contains("A").
+		Build()
+
+	// An exclude-only projection likewise leaves the database reading whole
+	// rows rather than an include list of the filter's dependencies.
+	dbQuery, _, err = partitioner.Partition(&dsl)
+	require.NoError(t, err)
+	assert.Nil(t, dbQuery.Projection)
+}
@@ -0,0 +1,129 @@
+package schema_test
+
+import (
+	"encoding/binary"
+	"testing"
+	"unsafe"
+
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompiledSchemaEncoding_View verifies that a v1.2 view reproduces the
+// linked schema: hot sections and metadata up front, side tables once the
+// cold trailer is loaded.
+func TestCompiledSchemaEncoding_View(t *testing.T) {
+	cs := compileSchema(t, allTypesSchema)
+	key := definition.SchemaKey{ID: 7, Version: 3, Epoch: 1}
+
+	data, err := definition.EncodeCompiledSchema(cs, key)
+	require.NoError(t, err)
+	require.Zero(t, len(data)%8, "payloads are padded to keep registry entries aligned")
+
+	view, err := definition.NewCompiledSchemaFromBytes(data)
+	require.NoError(t, err)
+	got, ok := view.Key()
+	require.True(t, ok)
+	assert.Equal(t, key, got)
+
+	assert.Equal(t, cs.Descriptors, view.Descriptors)
+	if binary.NativeEndian.Uint16([]byte{1, 0}) == 1 {
+		start := uintptr(unsafe.Pointer(unsafe.SliceData(data)))
+		desc := uintptr(unsafe.Pointer(unsafe.SliceData(view.Descriptors)))
+		assert.True(t, desc > start && desc < start+uintptr(len(data)), "descriptors alias the payload")
+	}
+	assert.Equal(t, cs.Schemas, view.Schemas)
+	assert.Equal(t, cs.LocalOffsets, view.LocalOffsets)
+	assert.Equal(t, cs.FieldTypes, view.FieldTypes)
+	assert.Equal(t, cs.SchemasMeta, view.SchemasMeta)
+	want := append([]definition.FieldMeta(nil), cs.FieldsMeta...)
+	for i := range want {
+		want[i].Default = definition.LiteralValue{} // travels in Defaults
+	}
+	assert.Equal(t, want, view.FieldsMeta)
+
+	path, err := view.ResolvePath("f_object.nested_int")
+	require.NoError(t, err)
+	csPath, err := cs.ResolvePath("f_object.nested_int")
+	require.NoError(t, err)
+	assert.Equal(t, cs.Address(csPath), view.Address(path))
+
+	assert.Nil(t, view.Defaults, "the cold trailer is decoded on demand")
+	require.NoError(t, view.LoadColdTrailer())
+	assert.Equal(t, snapshotDoc(t, cs.Defaults), snapshotDoc(t, view.Defaults))
+	assert.Equal(t, snapshotDoc(t, cs.Enums), snapshotDoc(t, view.Enums))
+	assert.Equal(t, cs.Variants, view.Variants)
+	assert.Equal(t, cs.Indexes, view.Indexes)
+	assert.Equal(t, len(cs.Constraints), len(view.Constraints))
+
+	again, err := definition.EncodeCompiledSchema(view, key)
+	require.NoError(t, err)
+	assert.Equal(t, data, again, "a view re-encodes to the same bytes")
+}
+
+func TestCompiledSchemaEncoding_Header(t *testing.T) {
+	cs := compileSchema(t, allTypesSchema)
+	data, err := definition.EncodeCompiledSchema(cs, definition.SchemaKey{ID: 1, Version: 2})
+	require.NoError(t, err)
+
+	word0 := binary.BigEndian.Uint64(data)
+	assert.Equal(t, uint64(len(data)), word0>>16&0xFFFFFF, "length")
+	assert.Equal(t, uint64(1)|uint64(2)<<8|uint64(1)<<19, word0>>40, "id block with header extension 1")
+	word1 := binary.BigEndian.Uint64(data[8:])
+	assert.Equal(t, uint64(0b1010), word1&0xFF, "interned strings and cold trailer")
+	assert.NotZero(t, word1>>32&0xFFFFFF, "checksum")
+	assert.Equal(t, uint64(definition.CompiledSchemaHeaderSize), binary.LittleEndian.Uint64(data[16:]),
+		"the string table follows the header")
+
+	for _, tc := range []struct {
+		name   string
+		mutate func([]byte)
+	}{
+		{"truncated", nil},
+		{"corrupted body", func(b []byte) { b[len(b)-9] ^= 0xFF }},
+		{"inline strings", func(b []byte) { b[15] &^= 0b10 }},
+		{"compressed", func(b []byte) { b[0] |= 0b100 }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bad := append([]byte(nil), data...)
+			if tc.mutate == nil {
+				bad = bad[:definition.CompiledSchemaHeaderSize-1]
+			} else {
+				tc.mutate(bad)
+			}
+			_, err := definition.NewCompiledSchemaFromBytes(bad)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), definition.ErrInvalidCompiledSchema.Code)
+		})
+	}
+}
+
+func TestCompiledSchemaRegistry(t *testing.T) {
+	cs := compileSchema(t, allTypesSchema)
+	reg := definition.NewCompiledSchemaRegistry()
+	defer reg.Close()
+
+	keys := []definition.SchemaKey{{ID: 1}, {ID: 1, Version: 1}, {ID: 2, Epoch: 3}}
+	for _, k := range keys {
+		data, err := definition.EncodeCompiledSchema(cs, k)
+		require.NoError(t, err)
+		view, err := reg.Set(k, data)
+		require.NoError(t, err)
+		require.NotNil(t, view)
+	}
+
+	restored := definition.NewCompiledSchemaRegistry()
+	defer restored.Close()
+	require.NoError(t, restored.Import(reg.Export()))
+	for _, k := range keys {
+		view, err := restored.Get(k)
+		require.NoError(t, err)
+		require.NotNil(t, view)
+		got, _ := view.Key()
+		assert.Equal(t, k, got)
+		assert.Equal(t, cs.Descriptors, view.Descriptors)
+		require.NoError(t, view.LoadColdTrailer())
+		assert.Equal(t, snapshotDoc(t, cs.Defaults), snapshotDoc(t, view.Defaults))
+	}
+}
@@ -0,0 +1,191 @@
+package schema_test
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	anbinary "github.com/asaidimu/go-anansi/v8/core/encoding/binary"
+	anjson "github.com/asaidimu/go-anansi/v8/core/encoding/json"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wideSchema builds a schema with rootFields string fields ("r_0"…) at the
+// root and, under "next", a chain of depth distinct nested schemas, each
+// declaring nestedFields integer fields ("n_0"…) and the next link.
+func wideSchema(rootFields, depth, nestedFields int) string {
+	field := func(name, typ, ref string) string {
+		if ref != "" {
+			return fmt.Sprintf(`%q: {"name": %q, "type": "object", "schema": {"id": %q}}`, name, name, ref)
+		}
+		return fmt.Sprintf(`%q: {"name": %q, "type": %q}`, name, name, typ)
+	}
+	var root []string
+	for i := 0; i < rootFields; i++ {
+		root = append(root, field(fmt.Sprintf("r_%d", i), "string", ""))
+	}
+	if depth > 0 {
+		root = append(root, field("next", "", "level_0"))
+	}
+	var schemas []string
+	for d := 0; d < depth; d++ {
+		var fields []string
+		for i := 0; i < nestedFields; i++ {
+			fields = append(fields, field(fmt.Sprintf("n_%d", i), "integer", ""))
+		}
+		if d < depth-1 {
+			fields = append(fields, field("next", "", fmt.Sprintf("level_%d", d+1)))
+		}
+		schemas = append(schemas, fmt.Sprintf(`"level_%d": {"name": "level_%d", "fields": {%s}}`, d, d, strings.Join(fields, ",")))
+	}
+	return fmt.Sprintf(`{"version": "1.0.0", "name": "wide", "fields": {%s}, "schemas": {%s}}`,
+		strings.Join(root, ","), strings.Join(schemas, ","))
+}
+
+// deepPath is the path of field n_<field> at nesting level depth (1-based).
+func deepPath(depth, field int) string {
+	return strings.Repeat("next.", depth) + fmt.Sprintf("n_%d", field)
+}
+
+func TestExtendedLayout_Selection(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		schema   string
+		extended bool
+	}{
+		{"small schema", allTypesSchema, false},
+		{"128 fields in one schema", wideSchema(128, 0, 0), false},
+		{"129 fields in one schema", wideSchema(129, 0, 0), true},
+		{"62 nested schemas", wideSchema(1, 62, 1), false},
+		{"63 nested schemas", wideSchema(1, 63, 1), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := compileSchema(t, tc.schema)
+			assert.Equal(t, tc.extended, cs.Extended())
+			for _, fd := range cs.Descriptors {
+				require.Equal(t, tc.extended, fd.Extended(), "layouts never mix within a schema")
+			}
+		})
+	}
+}
+
+func TestExtendedLayout_Addresses(t *testing.T) {
+	const depth, nested = 80, 3
+	cs := compileSchema(t, wideSchema(300, depth, nested))
+	require.True(t, cs.Extended())
+	require.Len(t, cs.Schemas, depth+1)
+
+	paths := []string{"r_0", "r_150", "r_299"}
+	for d := 1; d <= depth; d++ {
+		for f := 0; f < nested; f++ {
+			paths = append(paths, deepPath(d, f))
+		}
+	}
+
+	seen := make(map[uint32]string, len(paths))
+	for _, path := range paths {
+		rp, err := cs.ResolvePath(path)
+		require.NoError(t, err, path)
+		addr := cs.Address(rp)
+		require.NotZero(t, addr, path)
+		require.NotContains(t, seen, addr, "%s aliases %s", path, seen[addr])
+		seen[addr] = path
+
+		last := rp[len(rp)-1]
+		fd := cs.Descriptors[int(cs.Schemas[last.SchemaIdx()].FieldStart)+int(last.FieldIdx())]
+		key, err := fd.AddressKey(addr)
+		require.NoError(t, err)
+		assert.Equal(t, addr, cs.KeyAddress(key), path)
+		assert.Equal(t, fd, cs.KeyDescriptor(key), path)
+		assert.Equal(t, path, cs.PathString(rp))
+	}
+
+	t.Run("keys carry addresses beyond 27 bits", func(t *testing.T) {
+		fd := cs.Descriptors[0]
+		for _, addr := range []uint32{1<<27 - 1, 1 << 27, 1<<32 - 1} {
+			key, err := fd.AddressKey(addr)
+			require.NoError(t, err)
+			assert.Equal(t, addr, cs.KeyAddress(key))
+			assert.Equal(t, fd, cs.KeyDescriptor(key))
+		}
+	})
+}
+
+func TestExtendedLayout_JSONRoundTrip(t *testing.T) {
+	// Wide enough to need the extended layout, shallow enough for the binary
+	// codec's nesting limit.
+	const depth = 60
+	cs := compileSchema(t, wideSchema(200, depth, 2))
+	require.True(t, cs.Extended())
+
+	doc := map[string]any{"r_0": "first", "r_199": "last"}
+	level := doc
+	for d := 1; d <= depth; d++ {
+		next := map[string]any{"n_0": float64(d), "n_1": float64(-d)}
+		level["next"] = next
+		level = next
+	}
+	data, err := stdjson.Marshal(doc)
+	require.NoError(t, err)
+
+	c, err := anjson.DecodeJSON(cs, data)
+	require.NoError(t, err)
+	out, err := anjson.SerializeJSON(cs, c)
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, stdjson.Unmarshal(out, &got))
+	assert.Equal(t, doc, got)
+
+	t.Run("binary codec", func(t *testing.T) {
+		codec, err := anbinary.NewCodec(cs, anbinary.Options{Version: anbinary.LayoutVersion(cs)})
+		require.NoError(t, err)
+		packet, err := codec.Encode(c)
+		require.NoError(t, err)
+		decoded, err := codec.Decode(packet)
+		require.NoError(t, err)
+		out, err := anjson.SerializeJSON(cs, decoded)
+		require.NoError(t, err)
+		var got map[string]any
+		require.NoError(t, stdjson.Unmarshal(out, &got))
+		assert.Equal(t, doc, got)
+	})
+}
+
+func TestExtendedLayout_Encodings(t *testing.T) {
+	cs := compileSchema(t, wideSchema(150, 70, 2))
+	require.True(t, cs.Extended())
+
+	data, err := definition.SerializeCompiledSchema(cs)
+	require.NoError(t, err)
+	restored, err := definition.DeserializeCompiledSchema(data)
+	require.NoError(t, err)
+	assert.Equal(t, cs.Descriptors, restored.Descriptors)
+	assert.Equal(t, cs.Schemas, restored.Schemas)
+
+	payload, err := definition.EncodeCompiledSchema(cs, definition.SchemaKey{ID: 1})
+	require.NoError(t, err)
+	view, err := definition.NewCompiledSchemaFromBytes(payload)
+	require.NoError(t, err)
+	assert.True(t, view.Extended())
+	assert.Equal(t, cs.Descriptors, view.Descriptors)
+
+	rp, err := view.ResolvePath(deepPath(70, 1))
+	require.NoError(t, err)
+	csPath, err := cs.ResolvePath(deepPath(70, 1))
+	require.NoError(t, err)
+	assert.Equal(t, cs.Address(csPath), view.Address(rp))
+}
+
+func TestExtendedLayout_Limits(t *testing.T) {
+	s, err := definition.FromJSON([]byte(wideSchema(1<<13+1, 0, 0)))
+	require.NoError(t, err)
+	rs, err := definition.Compile(s)
+	require.NoError(t, err)
+	_, err = definition.Link(rs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fields per schema")
+}
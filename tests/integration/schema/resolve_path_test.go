@@ -33,7 +33,7 @@ func TestResolvePath_SingleStep(t *testing.T) {
 	require.Len(t, rp, 1)
 
 	fd, _ := rootFieldDesc(t, cs, "f_string")
-	assert.Equal(t, uint8(0), rp[0].SchemaIdx())
+	assert.Equal(t, uint16(0), rp[0].SchemaIdx())
 	assert.Equal(t, fd.FieldIdx(), rp[0].FieldIdx())
 
 	addr := cs.Address(rp)
@@ -0,0 +1,135 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/query/native"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	sqliteExecutor "github.com/asaidimu/go-anansi/v8/sqlite/executor"
+	sqliteQuery "github.com/asaidimu/go-anansi/v8/sqlite/query"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const binaryPeopleSchemaJSON = `{
+  "version": "1.0.0",
+  "name": "people",
+  "fields": {
+    "019f6c3e-5a10-7d01-8a00-000000000001": { "name": "name", "type": "string", "required": true },
+    "019f6c3e-5a10-7d01-8a00-000000000002": { "name": "tags", "type": "array", "schema": { "type": "string" } },
+    "019f6c3e-5a10-7d01-8a00-000000000003": { "name": "address", "type": "object", "schema": { "id": "019f6c3e-5a10-7d01-8a00-0000000000a0" } },
+    "019f6c3e-5a10-7d01-8a00-000000000004": { "name": "history", "type": "array", "schema": { "id": "019f6c3e-5a10-7d01-8a00-0000000000a0" } }
+  },
+  "schemas": {
+    "019f6c3e-5a10-7d01-8a00-0000000000a0": {
+      "name": "addr",
+      "fields": {
+        "019f6c3e-5a10-7d01-8a00-0000000000a1": { "name": "street", "type": "string" },
+        "019f6c3e-5a10-7d01-8a00-0000000000a2": { "name": "zip", "type": "integer" }
+      }
+    }
+  }
+}`
+
+// TestBinaryDocuments_RoundTrip stores container columns as binary packets
+// and reads them back through every read path.
+func TestBinaryDocuments_RoundTrip(t *testing.T) {
+	testutils.ConfigureDocumentFactory()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	logger := zap.NewNop()
+	executor, err := sqliteExecutor.NewSQLiteExecutor(db, logger)
+	require.NoError(t, err)
+	interactor, err := native.NewNativeInteractor(executor, sqliteQuery.NewSQLiteFactory(nil, sqliteQuery.WithBinaryDocuments()), logger)
+	require.NoError(t, err)
+	p, err := persistence.NewPersistence(interactor, nil, logger, nil)
+	require.NoError(t, err)
+
+	sc, err := definition.FromJSON([]byte(binaryPeopleSchemaJSON))
+	require.NoError(t, err)
+	people, err := p.CreateCollection(ctx, sc)
+	require.NoError(t, err)
+
+	_, err = people.CreateOne(ctx, data.MustNewDocument(map[string]any{
+		"name":    "Ada",
+		"tags":    []any{"math", "engines"},
+		"address": map[string]any{"street": "1 Analytical Way", "zip": int64(1815)},
+		"history": []any{
+			map[string]any{"street": "2 Old Rd", "zip": int64(1)},
+			map[string]any{"street": "3 New Rd", "zip": int64(2)},
+		},
+	}))
+	require.NoError(t, err)
+	_, err = people.CreateOne(ctx, data.MustNewDocument(map[string]any{"name": "Bob"}))
+	require.NoError(t, err)
+
+	physical, err := people.Schema(ctx)
+	require.NoError(t, err)
+	table := physical.Name
+
+	var addressType, tagsType, metaType, bobAddress string
+	require.NoError(t, db.QueryRowContext(ctx,
+		`SELECT typeof(address), typeof(tags), typeof(_metadata_) FROM `+table+` WHERE name = 'Ada'`).
+		Scan(&addressType, &tagsType, &metaType))
+	assert.Equal(t, "blob", addressType)
+	assert.Equal(t, "blob", tagsType)
+	assert.Equal(t, "text", metaType, "metadata stays JSON")
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT typeof(address) FROM `+table+` WHERE name = 'Bob'`).Scan(&bobAddress))
+	assert.Equal(t, "null", bobAddress, "absent fields are stored as NULL")
+
+	read := func(name string) data.Documenter {
+		t.Helper()
+		q := query.NewQueryBuilder().Where("name").Eq(name).Build()
+		res, err := people.Read(ctx, &q)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Count)
+		return res.Data[0]
+	}
+	ada := read("Ada")
+	address, err := ada.Get("address")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"street": "1 Analytical Way", "zip": int64(1815)}, address)
+	tags, err := ada.Get("tags")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"math", "engines"}, tags)
+	history, err := ada.Get("history")
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.False(t, read("Bob").HasKey("address"))
+
+	// Whole-column updates are re-encoded.
+	filter := query.NewQueryBuilder().Where("name").Eq("Ada").Build().Filters
+	_, err = people.Update(ctx, &base.CollectionUpdate{
+		Set:    data.MustNewDocument(map[string]any{"address": map[string]any{"street": "4 Difference Ln", "zip": int64(1842)}}),
+		Filter: filter,
+	})
+	require.NoError(t, err)
+	address, err = read("Ada").Get("address")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"street": "4 Difference Ln", "zip": int64(1842)}, address)
+
+	// The map-backed read path decodes binary columns too.
+	rows, err := db.QueryContext(ctx, `SELECT name, address FROM `+table+` WHERE name = 'Ada'`)
+	require.NoError(t, err)
+	docs, _, err := sqliteExecutor.ReadRows(ctx, logger, physical, rows)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	address, err = docs[0].Get("address")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"street": "4 Difference Ln", "zip": int64(1842)}, address)
+
+	// Nested paths inside binary columns are not addressable by SQL.
+	nested := query.NewQueryBuilder().Where("address.zip").Eq(1842).Build()
+	_, err = people.Read(ctx, &nested)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), sqliteQuery.ErrBinaryNestedPath.Code)
+}
@@ -0,0 +1,124 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asaidimu/go-anansi/v8/core/persistence/collection"
+	"github.com/asaidimu/go-anansi/v8/sqlite/invalidation"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInvalidationSource_ReportsOtherConnectionsWrites opens the same file
+// twice, standing in for two processes, and checks that writes through the
+// second handle reach watchers of the first.
+func TestInvalidationSource_ReportsOtherConnectionsWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.db")
+	open := func() *sql.DB {
+		db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000", path))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	local, remote := open(), open()
+
+	ctx := context.Background()
+	for _, table := range []string{"rules", "other"} {
+		_, err := remote.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (name TEXT)", table))
+		require.NoError(t, err)
+	}
+
+	source := invalidation.NewSource(local, invalidation.Options{PollInterval: 5 * time.Millisecond})
+	defer source.Close()
+
+	var rules, others atomic.Int32
+	stopRules, err := source.Watch(ctx, collection.InvalidationTarget{Name: "rules", Table: "rules"}, func(inv collection.Invalidation) {
+		assert.True(t, inv.All)
+		rules.Add(1)
+	})
+	require.NoError(t, err)
+	_, err = source.Watch(ctx, collection.InvalidationTarget{Name: "other", Table: "other"}, func(collection.Invalidation) {
+		others.Add(1)
+	})
+	require.NoError(t, err)
+
+	_, err = remote.ExecContext(ctx, "INSERT INTO rules (name) VALUES ('a')")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return rules.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	_, err = remote.ExecContext(ctx, "UPDATE rules SET name = 'b'")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return rules.Load() == 2 }, time.Second, 5*time.Millisecond)
+	assert.Zero(t, others.Load(), "writes to rules must not invalidate other")
+
+	stopRules()
+	_, err = remote.ExecContext(ctx, "DELETE FROM rules")
+	require.NoError(t, err)
+	_, err = remote.ExecContext(ctx, "INSERT INTO other (name) VALUES ('x')")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return others.Load() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(2), rules.Load(), "stopped watches receive nothing")
+}
+
+// TestInvalidationSource_ReinstallsDroppedTriggers rebuilds a watched table
+// the way a migration does, which drops its triggers, and checks that they
+// come back and that Untrack removes them.
+func TestInvalidationSource_ReinstallsDroppedTriggers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.db")
+	open := func() *sql.DB {
+		db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000", path))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	local, remote := open(), open()
+
+	ctx := context.Background()
+	_, err := remote.ExecContext(ctx, "CREATE TABLE rules (name TEXT)")
+	require.NoError(t, err)
+	triggers := func() int {
+		var n int
+		require.NoError(t, remote.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND tbl_name = 'rules'").Scan(&n))
+		return n
+	}
+
+	source := invalidation.NewSource(local, invalidation.Options{PollInterval: 5 * time.Millisecond})
+	defer source.Close()
+	target := collection.InvalidationTarget{Name: "rules", Table: "rules"}
+	var rules atomic.Int32
+	stop, err := source.Watch(ctx, target, func(collection.Invalidation) { rules.Add(1) })
+	require.NoError(t, err)
+	require.Equal(t, 3, triggers())
+
+	for _, stmt := range []string{
+		"CREATE TABLE rules_new (name TEXT)",
+		"INSERT INTO rules_new SELECT name FROM rules",
+		"DROP TABLE rules",
+		"ALTER TABLE rules_new RENAME TO rules",
+	} {
+		_, err := remote.ExecContext(ctx, stmt)
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool { return triggers() == 3 }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return rules.Load() >= 1 }, time.Second, 5*time.Millisecond)
+
+	// The reinstalled triggers report writes again.
+	seen := rules.Load()
+	_, err = remote.ExecContext(ctx, "INSERT INTO rules (name) VALUES ('a')")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return rules.Load() > seen }, time.Second, 5*time.Millisecond)
+
+	errcode.Require(t, source.Untrack(ctx, target), invalidation.ErrStillWatched.Code)
+	stop()
+	require.NoError(t, source.Untrack(ctx, target))
+	assert.Zero(t, triggers())
+}
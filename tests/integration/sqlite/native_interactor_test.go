@@ -316,7 +316,7 @@ func TestNativeInteractor_UpdateDocuments(t *testing.T) {
 			Value:    query.FilterValue{StringVal: utils.StringPtr("t1")},
 		},
 	}
-	_, rowsAffected, err := interactor.UpdateDocuments(ctx, testSchema, documenter(updates), nil, filterT1, false)
+	_, rowsAffected, err := interactor.UpdateDocuments(ctx, testSchema, documenter(updates), nil, nil, filterT1, false)
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), rowsAffected)
 
@@ -338,7 +338,7 @@ func TestNativeInteractor_UpdateDocuments(t *testing.T) {
 			Value:    query.FilterValue{BoolVal: utils.BoolPtr(false)},
 		},
 	}
-	_, rowsAffectedAll, err := interactor.UpdateDocuments(ctx, testSchema, documenter(updatesAll), nil, filterIncomplete, false)
+	_, rowsAffectedAll, err := interactor.UpdateDocuments(ctx, testSchema, documenter(updatesAll), nil, nil, filterIncomplete, false)
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), rowsAffectedAll) // Only t2 was incomplete
 
@@ -597,7 +597,7 @@ func TestNativeInteractor_Transactions(t *testing.T) {
 			Value:    query.FilterValue{StringVal: utils.StringPtr("acc1")},
 		},
 	}
-	_, _, err = txInteractor.UpdateDocuments(ctx, testSchema, documenter(updates), nil, filterAcc1, false)
+	_, _, err = txInteractor.UpdateDocuments(ctx, testSchema, documenter(updates), nil, nil, filterAcc1, false)
 	require.NoError(t, err)
 
 	// Insert within transaction
@@ -642,7 +642,7 @@ func TestNativeInteractor_Transactions(t *testing.T) {
 			Value:    query.FilterValue{StringVal: utils.StringPtr("acc1")},
 		},
 	}
-	_, _, err = txInteractor2.UpdateDocuments(ctx, testSchema, documenter(updates2), nil, filterAcc1_2, false)
+	_, _, err = txInteractor2.UpdateDocuments(ctx, testSchema, documenter(updates2), nil, nil, filterAcc1_2, false)
 	require.NoError(t, err)
 	_, err = txInteractor2.InsertDocuments(ctx, testSchema, documenters([]map[string]any{
 		{"account_id": "acc4", "balance": 300.0},
@@ -0,0 +1,169 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/sqlite"
+	sqliteExecutor "github.com/asaidimu/go-anansi/v8/sqlite/executor"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ledgerSchema = &definition.Schema{
+	BaseSchema: definition.BaseSchema{
+		Name: "ledger",
+		Fields: map[definition.FieldId]definition.Field{
+			"entry_id": {Name: "entry_id", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+			"amount":   {Name: "amount", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeNumber}},
+		},
+	},
+}
+
+func openPooledInteractor(t *testing.T, opts sqlite.Options) query.DatabaseInteractor {
+	t.Helper()
+	testutils.ConfigureDocumentFactory()
+	interactor, closeDB, err := sqlite.NewInteractor(opts, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = closeDB() })
+	require.NoError(t, interactor.SchemaManager().CreateCollection(context.Background(), *ledgerSchema))
+	return interactor
+}
+
+func selectLedger(ctx context.Context, interactor query.DatabaseInteractor) (int, error) {
+	docs, _, err := interactor.SelectDocuments(ctx, ledgerSchema, &query.Query{
+		Target: &query.QueryTarget{Name: ledgerSchema.Name, Schema: ledgerSchema},
+	})
+	return len(docs), err
+}
+
+func TestPooledInteractor_ReadsDoNotWaitForWriter(t *testing.T) {
+	interactor := openPooledInteractor(t, sqlite.Options{Path: filepath.Join(t.TempDir(), "ledger.db")})
+	ctx := context.Background()
+
+	_, err := interactor.InsertDocuments(ctx, ledgerSchema, documenters([]map[string]any{{"entry_id": "e1", "amount": 10.0}}))
+	require.NoError(t, err)
+
+	tx, err := interactor.StartTransaction(ctx)
+	require.NoError(t, err)
+	_, err = tx.InsertDocuments(ctx, ledgerSchema, documenters([]map[string]any{{"entry_id": "e2", "amount": 20.0}}))
+	require.NoError(t, err)
+
+	// The writer connection is held by tx; a read must be served by the
+	// reader pool and see only committed rows.
+	readCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	n, err := selectLedger(readCtx, interactor)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = selectLedger(ctx, tx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "reads inside the transaction use the writer")
+
+	require.NoError(t, tx.Commit(ctx))
+	n, err = selectLedger(ctx, interactor)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestPooledInteractor_SerializesWriters(t *testing.T) {
+	interactor := openPooledInteractor(t, sqlite.Options{Path: filepath.Join(t.TempDir(), "ledger.db")})
+	assert.False(t, interactor.Capabilities().RequiresTransactionSerialization)
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx, err := interactor.StartTransaction(ctx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			_, err = tx.InsertDocuments(ctx, ledgerSchema, documenters([]map[string]any{{"entry_id": "e", "amount": float64(i)}}))
+			if err != nil {
+				_ = tx.Rollback(ctx)
+				errs <- err
+				return
+			}
+			errs <- tx.Commit(ctx)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	n, err := selectLedger(ctx, interactor)
+	require.NoError(t, err)
+	assert.Equal(t, 16, n)
+}
+
+func TestPooledInteractor_Pragmas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.db")
+	openPooledInteractor(t, sqlite.Options{Path: path, Synchronous: sqlite.SynchronousFull})
+
+	db, err := sql.Open(sqliteExecutor.DriverName, path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var mode string
+	require.NoError(t, db.QueryRow("PRAGMA journal_mode").Scan(&mode))
+	assert.Equal(t, "wal", mode)
+
+	reader := sql.OpenDB(sqliteExecutor.NewConnector(path, "PRAGMA query_only = ON"))
+	defer reader.Close()
+	_, err = reader.Exec(`DELETE FROM "ledger"`)
+	assert.Error(t, err, "query_only connections reject writes")
+}
+
+func TestPooledInteractor_Memory(t *testing.T) {
+	interactor := openPooledInteractor(t, sqlite.Options{Path: sqlite.MemoryPath})
+	ctx := context.Background()
+
+	_, err := interactor.InsertDocuments(ctx, ledgerSchema, documenters([]map[string]any{{"entry_id": "e1", "amount": 1.0}}))
+	require.NoError(t, err)
+	n, err := selectLedger(ctx, interactor)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestPooledInteractor_URIPath(t *testing.T) {
+	// A file: URI may carry parameters of its own.
+	path := "file:" + filepath.Join(t.TempDir(), "ledger.db") + "?_busy_timeout=1000"
+	interactor := openPooledInteractor(t, sqlite.Options{Path: path})
+	ctx := context.Background()
+
+	tx, err := interactor.StartTransaction(ctx)
+	require.NoError(t, err)
+	_, err = tx.InsertDocuments(ctx, ledgerSchema, documenters([]map[string]any{{"entry_id": "e1", "amount": 1.0}}))
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(ctx))
+	n, err := selectLedger(ctx, interactor)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestPooledInteractor_InvalidOptions(t *testing.T) {
+	for _, opts := range []sqlite.Options{
+		{},
+		{Path: sqlite.MemoryPath, JournalMode: "wal2"},
+		{Path: sqlite.MemoryPath, Synchronous: "sometimes"},
+		{Path: sqlite.MemoryPath, MaxReaders: -1},
+	} {
+		_, _, err := sqlite.NewInteractor(opts, nil)
+		errcode.Require(t, err, sqlite.ErrInvalidOptions.Code, "%+v", opts)
+	}
+}
@@ -0,0 +1,189 @@
+package sqlite_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/query/native"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	sqliteExecutor "github.com/asaidimu/go-anansi/v8/sqlite/executor"
+	sqliteQuery "github.com/asaidimu/go-anansi/v8/sqlite/query"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// cacheStats records the latest statement cache snapshot.
+type cacheStats struct {
+	mu   sync.Mutex
+	last map[string]any
+}
+
+func (c *cacheStats) sink(name string, data map[string]any) {
+	if name != sqliteExecutor.StatementCacheTelemetry {
+		return
+	}
+	c.mu.Lock()
+	c.last = data
+	c.mu.Unlock()
+}
+
+func (c *cacheStats) value(key string) any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last[key]
+}
+
+func (c *cacheStats) get(key string) uint64 {
+	v, _ := c.value(key).(uint64)
+	return v
+}
+
+func openCachedInteractor(t *testing.T, capacity int) (query.DatabaseInteractor, *cacheStats) {
+	t.Helper()
+	testutils.ConfigureDocumentFactory()
+	db, cleanup := testutils.SetupTestDB(t)
+	t.Cleanup(cleanup)
+
+	ix, err := sqliteExecutor.NewSQLiteExecutor(db, zap.NewNop(), sqliteExecutor.WithStatementCache(capacity))
+	require.NoError(t, err)
+	interactor, err := native.NewNativeInteractor(ix, sqliteQuery.NewSQLiteFactory(nil), zap.NewNop())
+	require.NoError(t, err)
+
+	stats := &cacheStats{}
+	source, ok := interactor.(query.TelemetrySource)
+	require.True(t, ok)
+	source.SetTelemetrySink(stats.sink)
+
+	require.NoError(t, interactor.SchemaManager().CreateCollection(context.Background(), *ledgerSchema))
+	return interactor, stats
+}
+
+// invalidate issues DDL, which clears the cache and reports a snapshot.
+func invalidate(t *testing.T, interactor query.DatabaseInteractor, name string) {
+	t.Helper()
+	sc := *ledgerSchema
+	sc.Name = name
+	require.NoError(t, interactor.SchemaManager().CreateCollection(context.Background(), sc))
+}
+
+func TestStatementCache_ReusesStatements(t *testing.T) {
+	interactor, stats := openCachedInteractor(t, 8)
+	ctx := context.Background()
+	require.NotZero(t, stats.get("invalidations"), "creating the collection is DDL")
+	invalidations := stats.get("invalidations")
+
+	_, err := interactor.InsertDocuments(ctx, ledgerSchema, documenters([]map[string]any{{"entry_id": "e1", "amount": 1.0}}))
+	require.NoError(t, err)
+	for range 3 {
+		n, err := selectLedger(ctx, interactor)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	}
+
+	// Inside transactions a statement missing from the cache is prepared on
+	// the transaction and cached when it commits.
+	for i := range 2 {
+		tx, err := interactor.StartTransaction(ctx)
+		require.NoError(t, err)
+		n, err := selectLedger(ctx, tx)
+		require.NoError(t, err)
+		assert.Equal(t, 1+i, n)
+		_, err = tx.InsertDocuments(ctx, ledgerSchema, documenters([]map[string]any{{"entry_id": "e2", "amount": 2.0}}))
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit(ctx))
+	}
+
+	invalidate(t, interactor, "ledger_archive")
+	assert.Greater(t, stats.get("invalidations"), invalidations)
+	assert.GreaterOrEqual(t, stats.get("hits"), uint64(4), "repeated selects and transaction statements hit")
+	assert.GreaterOrEqual(t, stats.get("misses"), uint64(2))
+	assert.Equal(t, 0, stats.value("size"), "DDL empties the cache")
+
+	n, err := selectLedger(ctx, interactor)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestStatementCache_Evicts(t *testing.T) {
+	interactor, stats := openCachedInteractor(t, 1)
+	ctx := context.Background()
+
+	for i := range 4 {
+		_, _, err := interactor.SelectDocuments(ctx, ledgerSchema, &query.Query{
+			Target:  &query.QueryTarget{Name: ledgerSchema.Name, Schema: ledgerSchema},
+			Filters: query.NewQueryBuilder().Where([]string{"entry_id", "amount"}[i%2]).Exists().Build().Filters,
+		})
+		require.NoError(t, err)
+	}
+
+	invalidate(t, interactor, "ledger_archive")
+	assert.GreaterOrEqual(t, stats.get("evictions"), uint64(3))
+	assert.Equal(t, 1, stats.value("capacity"))
+}
+
+func TestStatementCache_RollbackAfterDDL(t *testing.T) {
+	interactor, _ := openCachedInteractor(t, 8)
+	ctx := context.Background()
+
+	tx, err := interactor.StartTransaction(ctx)
+	require.NoError(t, err)
+	_, err = tx.InsertDocuments(ctx, ledgerSchema, documenters([]map[string]any{{"entry_id": "e1", "amount": 1.0}}))
+	require.NoError(t, err)
+	audit := &definition.Schema{BaseSchema: ledgerSchema.BaseSchema}
+	audit.Name = "ledger_audit"
+	require.NoError(t, tx.SchemaManager().CreateCollection(ctx, *audit))
+	_, err = tx.InsertDocuments(ctx, ledgerSchema, documenters([]map[string]any{{"entry_id": "e2", "amount": 2.0}}))
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback(ctx))
+
+	n, err := selectLedger(ctx, interactor)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	_, err = interactor.InsertDocuments(ctx, ledgerSchema, documenters([]map[string]any{{"entry_id": "e3", "amount": 3.0}}))
+	require.NoError(t, err)
+}
+
+func TestStatementCache_CountsTransactionLookups(t *testing.T) {
+	interactor, stats := openCachedInteractor(t, 8)
+	ctx := context.Background()
+	hits, misses := stats.get("hits"), stats.get("misses")
+	selectAmounts := func(ix query.DatabaseInteractor) {
+		_, _, err := ix.SelectDocuments(ctx, ledgerSchema, &query.Query{
+			Target:  &query.QueryTarget{Name: ledgerSchema.Name, Schema: ledgerSchema},
+			Filters: query.NewQueryBuilder().Where("amount").Exists().Build().Filters,
+		})
+		require.NoError(t, err)
+	}
+
+	// A miss prepares on the transaction, which then reuses the statement.
+	tx, err := interactor.StartTransaction(ctx)
+	require.NoError(t, err)
+	for range 2 {
+		_, err = selectLedger(ctx, tx)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tx.Commit(ctx))
+
+	// The next transaction finds that statement in the shared cache and
+	// misses another, which DDL then drops before the commit could cache it.
+	tx, err = interactor.StartTransaction(ctx)
+	require.NoError(t, err)
+	_, err = selectLedger(ctx, tx)
+	require.NoError(t, err)
+	selectAmounts(tx)
+	audit := &definition.Schema{BaseSchema: ledgerSchema.BaseSchema}
+	audit.Name = "ledger_audit"
+	require.NoError(t, tx.SchemaManager().CreateCollection(ctx, *audit))
+	assert.Equal(t, uint64(2), stats.get("hits")-hits)
+	assert.Equal(t, uint64(2), stats.get("misses")-misses)
+	require.NoError(t, tx.Commit(ctx))
+
+	selectAmounts(interactor)
+	invalidate(t, interactor, "ledger_archive")
+	assert.Equal(t, uint64(2), stats.get("hits")-hits)
+	assert.Equal(t, uint64(3), stats.get("misses")-misses, "statements prepared before DDL are not cached")
+}
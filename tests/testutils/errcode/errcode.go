@@ -0,0 +1,22 @@
+// Package errcode asserts the codes of the SystemErrors returned under test.
+// It is kept apart from testutils, and imports nothing but core/common, so
+// that the in-package tests of any core package can use it without an import
+// cycle.
+package errcode
+
+import (
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Require fails the test unless err is, or wraps, a *common.SystemError with
+// code. It returns that error so the caller can inspect its issues.
+func Require(t testing.TB, err error, code string, msgAndArgs ...any) *common.SystemError {
+	t.Helper()
+	var sysErr *common.SystemError
+	require.ErrorAs(t, err, &sysErr, msgAndArgs...)
+	require.Equal(t, code, sysErr.Code, msgAndArgs...)
+	return sysErr
+}
@@ -148,7 +148,7 @@ func TestEphemeralDatabaseInteractor_UpdateDocuments(t *testing.T) {
 	filters := query.NewQueryBuilder().Where("name").Eq("Bob").Build().Filters
 	updates := map[string]any{"status": "inactive"}
 
-	_, updatedCount, err := interactor.UpdateDocuments(context.Background(), &schemaDef, documenter(updates), nil, filters, false)
+	_, updatedCount, err := interactor.UpdateDocuments(context.Background(), &schemaDef, documenter(updates), nil, nil, filters, false)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), updatedCount)
 
@@ -159,6 +159,47 @@ func TestEphemeralDatabaseInteractor_UpdateDocuments(t *testing.T) {
 	assert.Equal(t, "Bob", selected[0].GetOr("name", nil))
 }
 
+func TestEphemeralDatabaseInteractor_UpdateDocuments_WithOperators(t *testing.T) {
+	interactor := ephemeral.NewEphemeral()
+	manager := interactor.SchemaManager()
+	schemaDef := getUserSchema(t)
+	err := manager.CreateCollection(context.Background(), schemaDef)
+	assert.NoError(t, err)
+
+	docsToInsert := []map[string]any{
+		{"name": "Alice", "age": 30, "address": map[string]any{"city": "Nairobi", "zip": 100}},
+		{"name": "Bob", "age": 25, "address": map[string]any{"city": "Kisumu", "zip": 400}},
+	}
+	_, err = interactor.InsertDocuments(context.Background(), &schemaDef, documenters(docsToInsert))
+	assert.NoError(t, err)
+
+	filters := query.NewQueryBuilder().Where("name").Eq("Bob").Build().Filters
+	operators := []query.UpdateOperator{
+		{Type: query.UpdateOperatorIncrement, Field: "age", Value: 1},
+		{Type: query.UpdateOperatorSet, Field: "address.city", Value: "Mombasa"},
+		{Type: query.UpdateOperatorIncrement, Field: data.MetadataFieldPath(data.MetadataVersion), Value: 1},
+	}
+	updated, updatedCount, err := interactor.UpdateDocuments(context.Background(), &schemaDef, nil, nil, operators, filters, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), updatedCount)
+	if !assert.Len(t, updated, 1) {
+		return
+	}
+
+	bob := updated[0]
+	assert.EqualValues(t, 26, bob.GetOr("age", nil))
+	assert.Equal(t, "Mombasa", bob.GetOr("address.city", nil))
+	assert.EqualValues(t, 400, bob.GetOr("address.zip", nil), "sibling paths survive a nested set")
+	assert.EqualValues(t, 2, bob.GetOr(data.MetadataFieldPath(data.MetadataVersion), nil), "inserts start at version 1")
+
+	dsl := query.NewQueryBuilder().Where("name").Eq("Alice").Build()
+	selected, _, err := interactor.SelectDocuments(context.Background(), &schemaDef, &dsl)
+	assert.NoError(t, err)
+	if assert.Len(t, selected, 1) {
+		assert.EqualValues(t, 30, selected[0].GetOr("age", nil))
+	}
+}
+
 func TestEphemeralDatabaseInteractor_DeleteDocuments(t *testing.T) {
 	interactor := ephemeral.NewEphemeral()
 	manager := interactor.SchemaManager()
@@ -253,7 +294,7 @@ func TestEphemeralDatabaseInteractor_UpdateDocuments_NoMatch(t *testing.T) {
 	filters := query.NewQueryBuilder().Where("name").Eq("non-existent").Build().Filters
 	updates := map[string]any{"status": "inactive"}
 
-	_, updatedCount, err := interactor.UpdateDocuments(context.Background(), &schemaDef, documenter(updates), nil, filters, false)
+	_, updatedCount, err := interactor.UpdateDocuments(context.Background(), &schemaDef, documenter(updates), nil, nil, filters, false)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(0), updatedCount)
 }
@@ -339,3 +380,82 @@ func TestEphemeralDatabaseInteractor_SelectDocuments_WithJoin(t *testing.T) {
 		assert.Equal(t, user["id"], order["user_id"], "User ID and Order User ID should match")
 	}
 }
+
+func TestEphemeralDatabaseInteractor_SelectDocuments_WithWindow(t *testing.T) {
+	interactor := ephemeral.NewEphemeral()
+	manager := interactor.SchemaManager()
+	schemaDef := getUserSchema(t)
+	err := manager.CreateCollection(context.Background(), schemaDef)
+	assert.NoError(t, err)
+
+	docsToInsert := []map[string]any{
+		{"name": "Alice", "age": 30, "status": "active"},
+		{"name": "Bob", "age": 25, "status": "active"},
+		{"name": "Charlie", "age": 35, "status": "inactive"},
+		{"name": "David", "age": 40, "status": "active"},
+	}
+	_, err = interactor.InsertDocuments(context.Background(), &schemaDef, documenters(docsToInsert))
+	assert.NoError(t, err)
+
+	// The oldest member of each status group, ranked before the page is cut.
+	dsl := query.NewQueryBuilder().Select().
+		Include("name").
+		AddWindow("position", query.WindowFunctionRowNumber, "").PartitionBy("status").OrderBy("age", query.SortDirectionDesc).End().
+		End().
+		OrderByAsc("name").
+		Limit(2).
+		Build()
+	selected, _, err := interactor.SelectDocuments(context.Background(), &schemaDef, &dsl)
+	assert.NoError(t, err)
+	assert.Len(t, selected, 2)
+	assert.Equal(t, map[string]any{"name": "Alice", "position": int64(2)}, selected[0].ToMap())
+	assert.Equal(t, map[string]any{"name": "Bob", "position": int64(3)}, selected[1].ToMap())
+}
+
+func TestEphemeralDatabaseInteractor_SelectDocuments_WithStatisticalAggregations(t *testing.T) {
+	interactor := ephemeral.NewEphemeral()
+	manager := interactor.SchemaManager()
+	schemaDef := getUserSchema(t)
+	err := manager.CreateCollection(context.Background(), schemaDef)
+	assert.NoError(t, err)
+
+	docsToInsert := []map[string]any{
+		{"name": "Alice", "age": 30, "status": "active"},
+		{"name": "Bob", "age": 25, "status": "active"},
+		{"name": "Charlie", "age": 35, "status": "inactive"},
+		{"name": "David", "age": 40, "status": "active"},
+	}
+	_, err = interactor.InsertDocuments(context.Background(), &schemaDef, documenters(docsToInsert))
+	assert.NoError(t, err)
+
+	dsl := query.NewQueryBuilder().
+		CountDistinct("status", "statuses").
+		Variance("age", "age_variance").
+		Percentile("age", 0.25, "age_p25").
+		First("name", "oldest", query.SortConfiguration{Field: "age", Direction: query.SortDirectionDesc}).
+		Build()
+	selected, _, err := interactor.SelectDocuments(context.Background(), &schemaDef, &dsl)
+	assert.NoError(t, err)
+	assert.Len(t, selected, 1)
+
+	result := selected[0].ToMap()
+	assert.Equal(t, 2, result["statuses"])
+	assert.InDelta(t, 125.0/3, result["age_variance"], 1e-9)
+	assert.InDelta(t, 28.75, result["age_p25"], 1e-9)
+	assert.Equal(t, "David", result["oldest"])
+
+	// Grouped aggregations return one document per group.
+	grouped := query.NewQueryBuilder().
+		GroupBy("status").Type(query.AggregationTypeMedian).Field("age").Alias("median_age").End().
+		Build()
+	selected, _, err = interactor.SelectDocuments(context.Background(), &schemaDef, &grouped)
+	assert.NoError(t, err)
+	var groups []map[string]any
+	for _, doc := range selected {
+		groups = append(groups, doc.ToMap())
+	}
+	assert.ElementsMatch(t, []map[string]any{
+		{"status": "active", "median_age": 30.0},
+		{"status": "inactive", "median_age": 35.0},
+	}, groups)
+}
@@ -0,0 +1,143 @@
+package persistence_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/ephemeral"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/audit"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// setupAuditTest returns the audited persistence and the undecorated one
+// underneath it, which can reach the audit collection without restrictions.
+func setupAuditTest(t *testing.T) (base.Persistence, base.Persistence) {
+	raw, err := persistence.NewPersistence(ephemeral.NewEphemeral(), nil, zap.NewNop(), nil)
+	require.NoError(t, err)
+	audited := audit.Decorator(audit.Config{Collections: []string{"orders"}})(raw)
+
+	_, err = audited.CreateCollection(context.Background(), newTenantTestSchema("orders", false))
+	require.NoError(t, err)
+	_, err = audited.CreateCollection(context.Background(), newTenantTestSchema("countries", false))
+	require.NoError(t, err)
+	return audited, raw
+}
+
+func readAuditEntries(t *testing.T, p base.BasePersistence) []audit.Entry {
+	t.Helper()
+	log, err := p.Collection(context.Background(), audit.DefaultCollection)
+	require.NoError(t, err)
+	q := query.NewQueryBuilder().OrderByAsc("sequence").Build()
+	result, err := log.Read(context.Background(), &q)
+	require.NoError(t, err)
+	entries := make([]audit.Entry, 0, len(result.Data))
+	for _, doc := range result.Data {
+		encoded, err := json.Marshal(doc.Data())
+		require.NoError(t, err)
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(encoded, &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAudit_RecordsChainedEntries(t *testing.T) {
+	p, raw := setupAuditTest(t)
+	ctx := common.ContextWithPrincipal(context.Background(), common.Principal{ID: "alice"})
+
+	orders, err := p.Collection(ctx, "orders")
+	require.NoError(t, err)
+	created, err := orders.CreateOne(ctx, data.MustNewDocument(map[string]any{"name": "a1"}))
+	require.NoError(t, err)
+	_, err = orders.Update(ctx, base.NewCollectionUpdate().SetField("name", "a2").
+		WithFilter(query.NewQueryBuilder().Where("name").Eq("a1").Build().Filters))
+	require.NoError(t, err)
+	_, err = orders.Delete(ctx, query.NewQueryBuilder().Where("name").Eq("a2").Build().Filters, false)
+	require.NoError(t, err)
+
+	// Collections outside Config.Collections are not audited.
+	countries, err := p.Collection(ctx, "countries")
+	require.NoError(t, err)
+	_, err = countries.CreateOne(ctx, data.MustNewDocument(map[string]any{"name": "kenya"}))
+	require.NoError(t, err)
+
+	entries := readAuditEntries(t, raw)
+	require.Len(t, entries, 3)
+	for i, entry := range entries {
+		assert.Equal(t, "orders", entry.Collection)
+		assert.Equal(t, int64(i+1), entry.Sequence)
+		assert.Equal(t, "alice", entry.Actor)
+		assert.Equal(t, created.Data.ID(), entry.DocumentID)
+		assert.Equal(t, entry.ComputeHash(), entry.Hash)
+		if i > 0 {
+			assert.Equal(t, entries[i-1].Hash, entry.PreviousHash)
+		}
+	}
+	assert.Equal(t, []audit.Operation{audit.OperationCreate, audit.OperationUpdate, audit.OperationDelete},
+		[]audit.Operation{entries[0].Operation, entries[1].Operation, entries[2].Operation})
+	assert.JSONEq(t, `[{"op":"replace","path":"/name","value":"a2"}]`, entries[1].Patch)
+
+	report, err := audit.NewVerifier(raw, "").Verify(ctx, "orders")
+	require.NoError(t, err)
+	assert.True(t, report.Valid(), "unexpected issues: %v", report.Issues)
+	assert.Equal(t, 3, report.Entries)
+	assert.Equal(t, entries[2].Hash, report.Head)
+
+	names, err := audit.NewVerifier(raw, "").Collections(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders"}, names)
+}
+
+func TestAudit_AuditCollectionIsAppendOnly(t *testing.T) {
+	p, _ := setupAuditTest(t)
+	ctx := context.Background()
+
+	orders, err := p.Collection(ctx, "orders")
+	require.NoError(t, err)
+	_, err = orders.CreateOne(ctx, data.MustNewDocument(map[string]any{"name": "a1"}))
+	require.NoError(t, err)
+
+	log, err := p.Collection(ctx, audit.DefaultCollection)
+	require.NoError(t, err)
+	_, err = log.Delete(ctx, nil, true)
+	errcode.Require(t, err, "ERR_AUDIT_APPEND_ONLY")
+	_, err = log.Update(ctx, base.NewCollectionUpdate().SetField("actor", "mallory"))
+	errcode.Require(t, err, "ERR_AUDIT_APPEND_ONLY")
+}
+
+func TestAudit_VerifyDetectsTampering(t *testing.T) {
+	p, raw := setupAuditTest(t)
+	ctx := context.Background()
+
+	orders, err := p.Collection(ctx, "orders")
+	require.NoError(t, err)
+	for _, name := range []string{"a1", "a2", "a3"} {
+		_, err = orders.CreateOne(ctx, data.MustNewDocument(map[string]any{"name": name}))
+		require.NoError(t, err)
+	}
+
+	// Tampering happens below the decorator, straight against the store.
+	log, err := raw.Collection(ctx, audit.DefaultCollection)
+	require.NoError(t, err)
+	_, err = log.Update(ctx, base.NewCollectionUpdate().SetField("actor", "mallory").
+		WithFilter(query.NewQueryBuilder().Where("sequence").Eq(1).Build().Filters))
+	require.NoError(t, err)
+	_, err = log.Delete(ctx, query.NewQueryBuilder().Where("sequence").Eq(2).Build().Filters, false)
+	require.NoError(t, err)
+
+	report, err := audit.NewVerifier(raw, "").Verify(ctx, "orders")
+	require.NoError(t, err)
+	assert.False(t, report.Valid())
+	assert.True(t, report.Issues.ContainsCode(audit.IssueEntryAltered))
+	assert.True(t, report.Issues.ContainsCode(audit.IssueSequenceGap))
+	assert.True(t, report.Issues.ContainsCode(audit.IssueChainBroken))
+}
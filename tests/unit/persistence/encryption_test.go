@@ -0,0 +1,168 @@
+package persistence_test
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/encryption"
+	"github.com/asaidimu/go-anansi/v8/core/ephemeral"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupEncryptionTest(t *testing.T, keys encryption.KeyProvider) (base.Collection, query.DatabaseInteractor) {
+	store := ephemeral.NewEphemeral()
+	p, err := persistence.NewPersistence(encryption.NewInteractor(store, keys), nil, zap.NewNop(), nil)
+	require.NoError(t, err)
+
+	field := func(name string, mode string) definition.Field {
+		f := definition.Field{Name: definition.FieldName(name), FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}}
+		if mode != "" {
+			f.Metadata = map[string]any{query.EncryptionMetadataKey: mode}
+		}
+		return f
+	}
+	collection, err := p.CreateCollection(context.Background(), &definition.Schema{
+		Version: common.MustNewVersion("1.0.0"),
+		BaseSchema: definition.BaseSchema{
+			Name: "patients",
+			Fields: map[definition.FieldId]definition.Field{
+				"name":  field("name", ""),
+				"email": field("email", query.EncryptionDeterministic),
+				"notes": field("notes", query.EncryptionRandomized),
+			},
+		},
+	})
+	require.NoError(t, err)
+	return collection, store
+}
+
+func newTestKey(t *testing.T) []byte {
+	key := make([]byte, encryption.KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func TestEncryption_StoresCiphertextAndFiltersTransparently(t *testing.T) {
+	ctx := context.Background()
+	keys, err := encryption.NewStaticKeyring("k1", map[string][]byte{"k1": newTestKey(t)})
+	require.NoError(t, err)
+	collection, store := setupEncryptionTest(t, keys)
+
+	_, err = collection.CreateMany(ctx, []data.Documenter{
+		data.MustNewDocument(map[string]any{"name": "ada", "email": "ada@example.com", "notes": "allergic to penicillin"}),
+		data.MustNewDocument(map[string]any{"name": "bob", "email": "bob@example.com", "notes": "none"}),
+	})
+	require.NoError(t, err)
+
+	// The database only ever sees ciphertext.
+	sc, err := collection.Schema(ctx)
+	require.NoError(t, err)
+	raw, _, err := store.SelectDocuments(ctx, sc, &query.Query{})
+	require.NoError(t, err)
+	require.Len(t, raw, 2)
+	for _, doc := range raw {
+		email, _ := doc.Get("email")
+		notes, _ := doc.Get("notes")
+		assert.True(t, encryption.IsCiphertext(email.(string)))
+		assert.True(t, encryption.IsCiphertext(notes.(string)))
+	}
+
+	// Equality on a deterministic field is pushed down as ciphertext.
+	q := query.NewQueryBuilder().Where("email").Eq("ada@example.com").Build()
+	result, err := collection.Read(ctx, &q)
+	require.NoError(t, err)
+	require.Len(t, result.Data, 1)
+	notes, err := result.Data[0].GetString("notes")
+	require.NoError(t, err)
+	assert.Equal(t, "allergic to penicillin", notes)
+
+	// Predicates the database cannot evaluate on ciphertext run in memory.
+	q = query.NewQueryBuilder().Where("notes").Contains("penicillin").Build()
+	result, err = collection.Read(ctx, &q)
+	require.NoError(t, err)
+	require.Len(t, result.Data, 1)
+	name, _ := result.Data[0].GetString("name")
+	assert.Equal(t, "ada", name)
+
+	q = query.NewQueryBuilder().OrderByDesc("email").Build()
+	result, err = collection.Read(ctx, &q)
+	require.NoError(t, err)
+	require.Len(t, result.Data, 2)
+	name, _ = result.Data[0].GetString("name")
+	assert.Equal(t, "bob", name)
+
+	_, err = collection.Update(ctx, base.NewCollectionUpdate().SetField("notes", "discharged").
+		WithFilter(query.NewQueryBuilder().Where("email").Eq("bob@example.com").Build().Filters))
+	require.NoError(t, err)
+	q = query.NewQueryBuilder().Where("name").Eq("bob").Build()
+	result, err = collection.Read(ctx, &q)
+	require.NoError(t, err)
+	require.Len(t, result.Data, 1)
+	notes, _ = result.Data[0].GetString("notes")
+	assert.Equal(t, "discharged", notes)
+}
+
+func TestEncryption_EnvelopeRotationKeepsOldValuesReadable(t *testing.T) {
+	ctx := context.Background()
+	wrapper, err := encryption.NewAESKeyWrapper(newTestKey(t))
+	require.NoError(t, err)
+	keys, err := encryption.NewEnvelopeKeyProvider(ctx, wrapper, nil)
+	require.NoError(t, err)
+	collection, _ := setupEncryptionTest(t, keys)
+
+	_, err = collection.CreateOne(ctx, data.MustNewDocument(map[string]any{"name": "ada", "email": "ada@example.com"}))
+	require.NoError(t, err)
+	_, err = keys.Rotate(ctx)
+	require.NoError(t, err)
+	_, err = collection.CreateOne(ctx, data.MustNewDocument(map[string]any{"name": "ada2", "email": "ada@example.com"}))
+	require.NoError(t, err)
+
+	// A provider restored from the stored wrapped keys finds rows written
+	// under either key.
+	restored, err := encryption.NewEnvelopeKeyProvider(ctx, wrapper, keys.WrappedKeys())
+	require.NoError(t, err)
+	active, err := restored.ActiveKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, keys.WrappedKeys()[1].ID, active.ID)
+
+	q := query.NewQueryBuilder().Where("email").Eq("ada@example.com").Build()
+	result, err := collection.Read(ctx, &q)
+	require.NoError(t, err)
+	assert.Len(t, result.Data, 2)
+}
+
+func TestEncryption_RejectsUnsupportedFields(t *testing.T) {
+	keys, err := encryption.NewStaticKeyring("k1", map[string][]byte{"k1": newTestKey(t)})
+	require.NoError(t, err)
+	_, err = encryption.NewStaticKeyring("k1", map[string][]byte{"k1": []byte("short")})
+	errcode.Require(t, err, "ERR_ENCRYPTION_INVALID_KEY")
+
+	p, err := persistence.NewPersistence(encryption.NewInteractor(ephemeral.NewEphemeral(), keys), nil, zap.NewNop(), nil)
+	require.NoError(t, err)
+	_, err = p.CreateCollection(context.Background(), &definition.Schema{
+		Version: common.MustNewVersion("1.0.0"),
+		BaseSchema: definition.BaseSchema{
+			Name: "scores",
+			Fields: map[definition.FieldId]definition.Field{
+				"score": {
+					Name:            "score",
+					FieldProperties: definition.FieldProperties{Type: definition.FieldTypeNumber},
+					Metadata:        map[string]any{query.EncryptionMetadataKey: true},
+				},
+			},
+		},
+	})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "only string fields can be encrypted")
+}
@@ -0,0 +1,287 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/ephemeral"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/hooks"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/utils"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupHooksTest(t *testing.T, registry *hooks.Registry) base.Persistence {
+	decorators := &utils.Decorators{
+		CollectionDecorators: []utils.DecoratorFunc[base.Collection]{
+			(utils.DecoratorFunc[base.Collection])(hooks.Decorator(hooks.Config{Registry: registry})),
+		},
+	}
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), nil, zap.NewNop(), decorators)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = p.CreateCollection(ctx, orderSchema())
+	require.NoError(t, err)
+	notes := orderSchema()
+	notes.Name = "notes"
+	_, err = p.CreateCollection(ctx, notes)
+	require.NoError(t, err)
+	return p
+}
+
+func countOrders(t *testing.T, orders base.Collection, status string) int {
+	t.Helper()
+	q := query.NewQueryBuilder().Where("status").Eq(status).Build()
+	result, err := orders.Read(context.Background(), &q)
+	require.NoError(t, err)
+	return result.Count
+}
+
+func TestHooks_BeforeCreate(t *testing.T) {
+	registry := hooks.NewRegistry()
+	require.NoError(t, registry.Register("orders",
+		hooks.Hook{Name: "default-status", Stage: hooks.BeforeCreate, Func: func(ctx context.Context, change *hooks.Change) ([]common.Issue, error) {
+			if !change.Document.HasKey("status") {
+				return nil, change.Document.Set("status", "open")
+			}
+			return nil, nil
+		}},
+		hooks.Hook{Name: "positive-amount", Stage: hooks.BeforeCreate, Func: func(ctx context.Context, change *hooks.Change) ([]common.Issue, error) {
+			if amount, _ := change.Document.GetFloat64("amount"); amount <= 0 {
+				return []common.Issue{{Path: "amount", Message: "amount must be positive"}}, nil
+			}
+			return nil, nil
+		}},
+	))
+	p := setupHooksTest(t, registry)
+	ctx := context.Background()
+	orders, err := p.Collection(ctx, "orders")
+	require.NoError(t, err)
+
+	result, err := orders.CreateOne(ctx, data.MustNewDocument(map[string]any{"amount": 10.0}))
+	require.NoError(t, err)
+	assert.Equal(t, base.StatusCreated, result.Status)
+	assert.Equal(t, 1, countOrders(t, orders, "open"), "the hook's change is stored")
+
+	results, err := orders.CreateMany(ctx, []data.Documenter{
+		data.MustNewDocument(map[string]any{"amount": 5.0}),
+		data.MustNewDocument(map[string]any{"amount": -1.0}),
+	})
+	assert.Contains(t, errcode.Require(t, err, base.ErrValidationFailed.Code).Issues.String(), "amount")
+	require.Len(t, results, 2)
+	assert.Equal(t, base.StatusCreated, results[0].Status)
+	assert.Equal(t, base.StatusFailedValidation, results[1].Status)
+	assert.Equal(t, hooks.IssueRejected, results[1].Issues[0].Code)
+	assert.Equal(t, 1, countOrders(t, orders, "open"), "a rejected batch writes nothing")
+}
+
+func TestHooks_BeforeUpdate(t *testing.T) {
+	registry := hooks.NewRegistry()
+	require.NoError(t, registry.Register("orders", hooks.Hook{Name: "closing", Stage: hooks.BeforeUpdate, Func: func(ctx context.Context, change *hooks.Change) ([]common.Issue, error) {
+		previous, _ := change.Previous.GetString("status")
+		next, _ := change.Document.GetString("status")
+		if previous == "closed" && next != "closed" {
+			return []common.Issue{{Path: "status", Message: "closed orders cannot be reopened"}}, nil
+		}
+		if next == "closed" {
+			// Closing an order zeroes the amount still due.
+			return nil, change.Document.Set("amount", 0.0)
+		}
+		return nil, nil
+	}}))
+	p := setupHooksTest(t, registry)
+	ctx := context.Background()
+	orders, err := p.Collection(ctx, "orders")
+	require.NoError(t, err)
+
+	for _, amount := range []float64{10, 20} {
+		_, err := orders.CreateOne(ctx, data.MustNewDocument(map[string]any{"status": "open", "amount": amount}))
+		require.NoError(t, err)
+	}
+
+	open := query.NewQueryBuilder().Where("status").Eq("open").Build()
+	result, err := orders.Update(ctx, base.NewCollectionUpdate().SetField("status", "closed").WithFilter(open.Filters).WithReturnDocument(true))
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Count)
+	for _, doc := range result.Data {
+		amount, _ := doc.GetFloat64("amount")
+		assert.Zero(t, amount)
+	}
+
+	closed := query.NewQueryBuilder().Where("status").Eq("closed").Build()
+	_, err = orders.Update(ctx, base.NewCollectionUpdate().SetField("status", "open").WithFilter(closed.Filters))
+	assert.Contains(t, errcode.Require(t, err, base.ErrValidationFailed.Code).Issues.String(), "status")
+	assert.Equal(t, 2, countOrders(t, orders, "closed"))
+}
+
+func TestHooks_BeforeUpdateOperators(t *testing.T) {
+	registry := hooks.NewRegistry()
+	require.NoError(t, registry.Register("orders",
+		hooks.Hook{Name: "non-negative", Stage: hooks.BeforeUpdate, Func: func(ctx context.Context, change *hooks.Change) ([]common.Issue, error) {
+			if amount, _ := change.Document.GetFloat64("amount"); amount < 0 {
+				return []common.Issue{{Path: "amount", Message: "amount cannot be negative"}}, nil
+			}
+			return nil, nil
+		}},
+		hooks.Hook{Name: "capped", Stage: hooks.BeforeUpdate, Func: func(ctx context.Context, change *hooks.Change) ([]common.Issue, error) {
+			if amount, _ := change.Document.GetFloat64("amount"); amount > 100 {
+				return nil, change.Document.Set("amount", 100.0)
+			}
+			return nil, nil
+		}},
+	))
+	p := setupHooksTest(t, registry)
+	ctx := context.Background()
+	orders, err := p.Collection(ctx, "orders")
+	require.NoError(t, err)
+	_, err = orders.CreateOne(ctx, data.MustNewDocument(map[string]any{"status": "open", "amount": 10.0}))
+	require.NoError(t, err)
+	open := query.NewQueryBuilder().Where("status").Eq("open").Build()
+	amount := func() float64 {
+		t.Helper()
+		result, err := orders.Read(ctx, &open)
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Count)
+		value, _ := result.Data[0].GetFloat64("amount")
+		return value
+	}
+
+	// Hooks see the document with the operators applied.
+	_, err = orders.Update(ctx, base.NewCollectionUpdate().Increment("amount", -5).WithFilter(open.Filters))
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, amount())
+	_, err = orders.Update(ctx, base.NewCollectionUpdate().Increment("amount", -10).WithFilter(open.Filters))
+	assert.Contains(t, errcode.Require(t, err, base.ErrValidationFailed.Code).Issues.String(), "amount")
+	assert.Equal(t, 5.0, amount())
+
+	// A value a hook sets replaces the operator's result rather than
+	// feeding it.
+	_, err = orders.Update(ctx, base.NewCollectionUpdate().Increment("amount", 200).WithFilter(open.Filters))
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, amount())
+}
+
+func TestHooks_BeforeDelete(t *testing.T) {
+	registry := hooks.NewRegistry()
+	require.NoError(t, registry.Register("orders", hooks.Hook{Name: "keep-open", Stage: hooks.BeforeDelete, Func: func(ctx context.Context, change *hooks.Change) ([]common.Issue, error) {
+		if status, _ := change.Previous.GetString("status"); status == "open" {
+			return []common.Issue{{Path: "status", Message: "open orders cannot be deleted"}}, nil
+		}
+		return nil, nil
+	}}))
+	p := setupHooksTest(t, registry)
+	ctx := context.Background()
+	orders, err := p.Collection(ctx, "orders")
+	require.NoError(t, err)
+
+	for _, status := range []string{"open", "closed"} {
+		_, err := orders.CreateOne(ctx, data.MustNewDocument(map[string]any{"status": status, "amount": 1.0}))
+		require.NoError(t, err)
+	}
+
+	_, err = orders.Delete(ctx, nil, true)
+	assert.Contains(t, errcode.Require(t, err, base.ErrValidationFailed.Code).Issues.String(), "status")
+	assert.Equal(t, 1, countOrders(t, orders, "closed"))
+
+	closed := query.NewQueryBuilder().Where("status").Eq("closed").Build()
+	n, err := orders.Delete(ctx, closed.Filters, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestHooks_Transaction(t *testing.T) {
+	var mu sync.Mutex
+	var committed []hooks.Operation
+	registry := hooks.NewRegistry()
+	p := setupHooksTest(t, registry)
+	require.NoError(t, registry.Register("orders",
+		hooks.Hook{Name: "note", Stage: hooks.BeforeCreate, Func: func(ctx context.Context, change *hooks.Change) ([]common.Issue, error) {
+			notes, err := p.Collection(ctx, "notes")
+			if err != nil {
+				return nil, err
+			}
+			_, err = notes.CreateOne(ctx, data.MustNewDocument(map[string]any{"status": "noted", "amount": 0.0}))
+			return nil, err
+		}},
+		hooks.Hook{Name: "record", Stage: hooks.AfterCommit, Func: func(ctx context.Context, change *hooks.Change) ([]common.Issue, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			committed = append(committed, change.Operation)
+			return nil, nil
+		}},
+	))
+	ctx := context.Background()
+	orders, err := p.Collection(ctx, "orders")
+	require.NoError(t, err)
+	notes, err := p.Collection(ctx, "notes")
+	require.NoError(t, err)
+
+	// A rolled back transaction discards the hook's write and never commits.
+	rollback := errors.New("rollback")
+	_, err = p.Transact(ctx, func(ctx context.Context, tx base.BasePersistence) (any, error) {
+		orders, err := tx.Collection(ctx, "orders")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := orders.CreateOne(ctx, data.MustNewDocument(map[string]any{"status": "open", "amount": 1.0})); err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Empty(t, committed, "after-commit hooks wait for the outer transaction")
+		return nil, rollback
+	})
+	require.ErrorIs(t, err, rollback)
+	assert.Zero(t, countOrders(t, notes, "noted"))
+	assert.Empty(t, committed)
+
+	_, err = orders.CreateOne(ctx, data.MustNewDocument(map[string]any{"status": "open", "amount": 1.0}))
+	require.NoError(t, err)
+	_, err = orders.Delete(ctx, query.NewQueryBuilder().Where("status").Eq("open").Build().Filters, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, countOrders(t, notes, "noted"))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []hooks.Operation{hooks.OperationCreate, hooks.OperationDelete}, committed)
+}
+
+func TestHooks_Registry(t *testing.T) {
+	registry := hooks.NewRegistry()
+	noop := func(context.Context, *hooks.Change) ([]common.Issue, error) { return nil, nil }
+	require.NoError(t, registry.Register("orders",
+		hooks.Hook{Name: "a", Stage: hooks.BeforeCreate, Func: noop},
+		hooks.Hook{Name: "b", Stage: hooks.AfterCommit, Func: noop},
+	))
+	require.NoError(t, registry.Register("orders", hooks.Hook{Name: "c", Stage: hooks.BeforeCreate, Func: noop}))
+
+	names := func() []string {
+		var names []string
+		for _, hook := range registry.Hooks("orders") {
+			names = append(names, hook.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, names())
+
+	err := registry.Register("orders", hooks.Hook{Name: "a", Stage: hooks.BeforeDelete, Func: noop})
+	errcode.Require(t, err, hooks.ErrDuplicateHook.Code)
+	err = registry.Register("orders", hooks.Hook{Name: "d", Stage: "sometime", Func: noop})
+	errcode.Require(t, err, hooks.ErrInvalidHook.Code)
+	err = registry.Register("orders", hooks.Hook{Name: "e", Stage: hooks.BeforeCreate})
+	errcode.Require(t, err, hooks.ErrInvalidHook.Code)
+
+	assert.True(t, registry.Unregister("orders", "a"))
+	assert.False(t, registry.Unregister("orders", "a"))
+	assert.Equal(t, []string{"b", "c"}, names())
+	assert.Empty(t, registry.Hooks("notes"))
+}
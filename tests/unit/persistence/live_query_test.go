@@ -0,0 +1,236 @@
+package persistence_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/ephemeral"
+	"github.com/asaidimu/go-anansi/v8/core/events"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/collection"
+	pevents "github.com/asaidimu/go-anansi/v8/core/persistence/events"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	rootutils "github.com/asaidimu/go-anansi/v8/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// syncBus delivers events synchronously and without serialising them, so
+// event outputs keep their Go types.
+type syncBus struct {
+	mu   sync.Mutex
+	subs map[int]events.SubscriptionRequest[base.PersistenceEvent]
+	next int
+}
+
+func (b *syncBus) Emit(ctx context.Context, eventType string, event base.PersistenceEvent) {
+	b.mu.Lock()
+	var matching []events.SubscriptionRequest[base.PersistenceEvent]
+	for _, req := range b.subs {
+		if req.EventType == eventType {
+			matching = append(matching, req)
+		}
+	}
+	b.mu.Unlock()
+
+next:
+	for _, req := range matching {
+		for _, filter := range req.Filters {
+			if !filter(ctx, event) {
+				continue next
+			}
+		}
+		_ = req.Handler(ctx, event)
+	}
+}
+
+func (b *syncBus) Subscribe(req events.SubscriptionRequest[base.PersistenceEvent]) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[int]events.SubscriptionRequest[base.PersistenceEvent])
+	}
+	id := b.next
+	b.next++
+	b.subs[id] = req
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
+
+// liveUpdates records the updates of a live query.
+type liveUpdates struct {
+	mu      sync.Mutex
+	updates []base.QueryUpdate
+}
+
+func (l *liveUpdates) callback(_ context.Context, update base.QueryUpdate) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.updates = append(l.updates, update)
+}
+
+func (l *liveUpdates) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.updates)
+}
+
+func (l *liveUpdates) last() base.QueryUpdate {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.updates[len(l.updates)-1]
+}
+
+func amounts(result *base.ReadResult) []float64 {
+	values := make([]float64, len(result.Data))
+	for i, doc := range result.Data {
+		values[i], _ = doc.GetFloat64("amount")
+	}
+	return values
+}
+
+func createOrder(t *testing.T, orders base.Collection, status string, amount float64) string {
+	t.Helper()
+	result, err := orders.CreateOne(context.Background(), data.MustNewDocument(map[string]any{"status": status, "amount": amount}))
+	require.NoError(t, err)
+	return result.Data.ID()
+}
+
+func updateOrder(t *testing.T, orders base.Collection, id string, field string, value any) {
+	t.Helper()
+	filter := query.NewQueryBuilder().Where(data.DocumentIDField).Eq(id).Build().Filters
+	_, err := orders.Update(context.Background(), base.NewCollectionUpdate().SetField(field, value).WithFilter(filter).WithReturnDocument(true))
+	require.NoError(t, err)
+}
+
+func TestLiveQuery_AppliesWrites(t *testing.T) {
+	ctx := context.Background()
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), &syncBus{}, zap.NewNop(), nil)
+	require.NoError(t, err)
+	orders, err := p.CreateCollection(ctx, orderSchema())
+	require.NoError(t, err)
+
+	a := createOrder(t, orders, "open", 10)
+	c := createOrder(t, orders, "open", 30)
+	createOrder(t, orders, "closed", 20)
+
+	q := query.NewQueryBuilder().Where("status").Eq("open").OrderByAsc("amount").Build()
+	live := &liveUpdates{}
+	_, err = orders.SubscribeQuery(ctx, &q, live.callback)
+	require.NoError(t, err)
+	require.Equal(t, 1, live.len())
+	assert.True(t, live.last().Refresh)
+	assert.Equal(t, []float64{10, 30}, amounts(live.last().Result))
+
+	b := createOrder(t, orders, "open", 20)
+	require.Equal(t, 2, live.len())
+	update := live.last()
+	assert.False(t, update.Refresh)
+	assert.Equal(t, []float64{10, 20, 30}, amounts(update.Result))
+	require.Len(t, update.Changes, 1)
+	assert.Equal(t, base.QueryDocumentAdded, update.Changes[0].Type)
+	assert.Equal(t, b, update.Changes[0].Document.ID())
+	assert.Equal(t, 1, update.Changes[0].Index)
+	assert.Equal(t, -1, update.Changes[0].PreviousIndex)
+
+	createOrder(t, orders, "closed", 5)
+	assert.Equal(t, 2, live.len(), "writes outside the result deliver nothing")
+
+	// Moving a to the end reports it alone; b and c keep their order.
+	updateOrder(t, orders, a, "amount", 40.0)
+	require.Equal(t, 3, live.len())
+	update = live.last()
+	assert.Equal(t, []float64{20, 30, 40}, amounts(update.Result))
+	require.Len(t, update.Changes, 1)
+	assert.Equal(t, base.QueryChange{Type: base.QueryDocumentChanged, Document: update.Changes[0].Document, Index: 2, PreviousIndex: 0}, update.Changes[0])
+	assert.Equal(t, a, update.Changes[0].Document.ID())
+
+	updateOrder(t, orders, c, "status", "closed")
+	require.Equal(t, 4, live.len())
+	update = live.last()
+	assert.Equal(t, []float64{20, 40}, amounts(update.Result))
+	require.Len(t, update.Changes, 1)
+	assert.Equal(t, base.QueryDocumentRemoved, update.Changes[0].Type)
+	assert.Equal(t, c, update.Changes[0].Document.ID())
+	assert.Equal(t, -1, update.Changes[0].Index)
+	assert.Equal(t, 1, update.Changes[0].PreviousIndex)
+
+	// Deletes do not report their documents, so the query is re-executed.
+	_, err = orders.Delete(ctx, query.NewQueryBuilder().Where(data.DocumentIDField).Eq(b).Build().Filters, false)
+	require.NoError(t, err)
+	require.Equal(t, 5, live.len())
+	update = live.last()
+	require.Len(t, update.Changes, 1)
+	assert.Equal(t, base.QueryDocumentRemoved, update.Changes[0].Type)
+	assert.Equal(t, b, update.Changes[0].Document.ID())
+
+	current, err := orders.Read(ctx, &q)
+	require.NoError(t, err)
+	assert.Equal(t, amounts(current), amounts(update.Result))
+}
+
+func TestLiveQuery_ReexecutesQueries(t *testing.T) {
+	goBus, err := rootutils.NewInMemoryGoEventsBus("test")
+	require.NoError(t, err)
+	defer goBus.Close()
+	bus := pevents.NewGoEventsBusAdapter[base.PersistenceEvent](goBus)
+
+	ctx := context.Background()
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), bus, zap.NewNop(), nil)
+	require.NoError(t, err)
+	orders, err := p.CreateCollection(ctx, orderSchema())
+	require.NoError(t, err)
+	for _, amount := range []float64{10, 20, 30} {
+		createOrder(t, orders, "open", amount)
+	}
+
+	// Limited queries depend on rows outside their result, so every write
+	// re-executes them.
+	top := query.NewQueryBuilder().OrderByDesc("amount").Limit(2).Build()
+	live := &liveUpdates{}
+	id, err := orders.SubscribeQuery(ctx, &top, live.callback)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{30, 20}, amounts(live.last().Result))
+
+	subscriptions, err := orders.Subscriptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, "live query", *subscriptions[0].Label)
+
+	added := createOrder(t, orders, "open", 40)
+	require.Eventually(t, func() bool { return live.len() == 2 }, time.Second, 5*time.Millisecond)
+	update := live.last()
+	require.NoError(t, update.Error)
+	assert.Equal(t, []float64{40, 30}, amounts(update.Result))
+	require.Len(t, update.Changes, 2)
+	assert.Equal(t, base.QueryDocumentRemoved, update.Changes[0].Type)
+	assert.Equal(t, 1, update.Changes[0].PreviousIndex)
+	assert.Equal(t, base.QueryDocumentAdded, update.Changes[1].Type)
+	assert.Equal(t, added, update.Changes[1].Document.ID())
+	assert.Equal(t, 0, update.Changes[1].Index)
+
+	orders.Unsubscribe(ctx, id)
+	createOrder(t, orders, "open", 50)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, live.len(), "cancelled live queries receive nothing")
+}
+
+func TestLiveQuery_RequiresCallback(t *testing.T) {
+	ctx := context.Background()
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), nil, zap.NewNop(), nil)
+	require.NoError(t, err)
+	orders, err := p.CreateCollection(ctx, orderSchema())
+	require.NoError(t, err)
+
+	_, err = orders.SubscribeQuery(ctx, &query.Query{}, nil)
+	errcode.Require(t, err, collection.ErrInvalidLiveQuery.Code)
+}
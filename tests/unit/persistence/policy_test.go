@@ -0,0 +1,289 @@
+package persistence_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/ephemeral"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	pcollection "github.com/asaidimu/go-anansi/v8/core/persistence/collection"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/policy"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/utils"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupPolicyTest(t *testing.T) base.Collection {
+	documents := policy.Policy{
+		Collection: "documents",
+		Rules: []policy.Rule{
+			{
+				Name:     "owners",
+				Filter:   query.NewQueryBuilder().Where("owner_id").Eq("{{principal.id}}").Build().Filters,
+				Hidden:   []string{"reviewer_notes"},
+				ReadOnly: []string{"approved"},
+			},
+			{
+				Name:    "department",
+				Actions: []policy.Action{policy.ActionRead},
+				Roles:   []string{"manager"},
+				Filter:  query.NewQueryBuilder().Where("department").Eq("{{principal.department}}").Build().Filters,
+			},
+		},
+	}
+	return newPolicedDocuments(t, documents)
+}
+
+func newPolicedDocuments(t *testing.T, documents policy.Policy) base.Collection {
+	t.Helper()
+	decorators := &utils.Decorators{
+		CollectionDecorators: []utils.DecoratorFunc[base.Collection]{
+			(utils.DecoratorFunc[base.Collection])(policy.Decorator(policy.Config{Policies: []policy.Policy{documents}})),
+		},
+	}
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), &syncBus{}, zap.NewNop(), decorators)
+	require.NoError(t, err)
+
+	field := func(name string, fieldType definition.FieldType) definition.Field {
+		return definition.Field{Name: definition.FieldName(name), FieldProperties: definition.FieldProperties{Type: fieldType}}
+	}
+	collection, err := p.CreateCollection(context.Background(), &definition.Schema{
+		Version: common.MustNewVersion("1.0.0"),
+		BaseSchema: definition.BaseSchema{
+			Name: "documents",
+			Fields: map[definition.FieldId]definition.Field{
+				"name":           field("name", definition.FieldTypeString),
+				"owner_id":       field("owner_id", definition.FieldTypeString),
+				"department":     field("department", definition.FieldTypeString),
+				"reviewer_notes": field("reviewer_notes", definition.FieldTypeString),
+				"approved":       field("approved", definition.FieldTypeBoolean),
+			},
+		},
+	})
+	require.NoError(t, err)
+	return collection
+}
+
+func principalContext(id string, department string, roles ...string) context.Context {
+	return common.ContextWithPrincipal(context.Background(), common.Principal{
+		ID:         id,
+		Roles:      roles,
+		Attributes: map[string]any{"department": department},
+	})
+}
+
+func TestPolicy_ScopesRowsAndFields(t *testing.T) {
+	collection := setupPolicyTest(t)
+	alice := principalContext("alice", "legal")
+	bob := principalContext("bob", "sales")
+	carol := principalContext("carol", "legal", "manager")
+
+	_, err := collection.CreateOne(alice, data.MustNewDocument(map[string]any{
+		"name": "contract", "owner_id": "alice", "department": "legal", "reviewer_notes": "",
+	}))
+	assert.True(t, errcode.Require(t, err, policy.ErrAccessDenied.Code).Issues.ContainsCode(policy.IssueHiddenField))
+
+	_, err = collection.CreateOne(alice, data.MustNewDocument(map[string]any{"name": "contract", "owner_id": "alice", "department": "legal"}))
+	require.NoError(t, err)
+	_, err = collection.CreateOne(bob, data.MustNewDocument(map[string]any{"name": "quote", "owner_id": "bob", "department": "sales"}))
+	require.NoError(t, err)
+
+	_, err = collection.CreateOne(bob, data.MustNewDocument(map[string]any{"name": "forged", "owner_id": "alice", "department": "legal"}))
+	assert.True(t, errcode.Require(t, err, policy.ErrAccessDenied.Code).Issues.ContainsCode(policy.IssueRowNotPermitted))
+
+	assert.Equal(t, []string{"contract"}, readNames(t, alice, collection))
+	assert.Equal(t, []string{"quote"}, readNames(t, bob, collection))
+	// Managers read their department through the second rule.
+	assert.Equal(t, []string{"contract"}, readNames(t, carol, collection))
+
+	probe := query.NewQueryBuilder().Where("reviewer_notes").Eq("x").Build()
+	_, err = collection.Read(alice, &probe)
+	assert.True(t, errcode.Require(t, err, policy.ErrAccessDenied.Code).Issues.ContainsCode(policy.IssueHiddenField))
+
+	_, err = collection.Update(alice, base.NewCollectionUpdate().SetField("approved", true).
+		WithFilter(query.NewQueryBuilder().Where("name").Eq("contract").Build().Filters))
+	assert.True(t, errcode.Require(t, err, policy.ErrAccessDenied.Code).Issues.ContainsCode(policy.IssueReadOnlyField))
+
+	_, err = collection.Update(alice, base.NewCollectionUpdate().SetField("name", "renamed"))
+	errcode.Require(t, err, base.ErrInvalidUpdateParams.Code)
+	_, err = collection.Delete(alice, nil, false)
+	errcode.Require(t, err, base.ErrDeleteRequiresFilter.Code)
+
+	deleted, err := collection.Delete(bob, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Equal(t, []string{"contract"}, readNames(t, alice, collection))
+}
+
+func TestPolicy_OverlappingRulesKeepFieldsHidden(t *testing.T) {
+	collection := newPolicedDocuments(t, policy.Policy{
+		Collection: "documents",
+		Rules: []policy.Rule{
+			{
+				Name:    "reviewers",
+				Actions: []policy.Action{policy.ActionRead},
+				Roles:   []string{"reviewer"},
+				Hidden:  []string{"reviewer_notes"},
+			},
+			{
+				Name:   "owners",
+				Filter: query.NewQueryBuilder().Where("owner_id").Eq("{{principal.id}}").Build().Filters,
+			},
+		},
+	})
+	alice := principalContext("alice", "legal")
+	for _, owner := range []string{"alice", "bob"} {
+		ctx := principalContext(owner, "legal")
+		_, err := collection.CreateOne(ctx, data.MustNewDocument(map[string]any{"name": owner, "owner_id": owner, "reviewer_notes": "notes"}))
+		require.NoError(t, err)
+	}
+
+	result, err := collection.Read(alice, &query.Query{})
+	require.NoError(t, err)
+	require.Len(t, result.Data, 1)
+	assert.True(t, result.Data[0].HasKey("reviewer_notes"), "the owners rule alone hides nothing")
+
+	// As a reviewer, alice reads every row, and the notes stay hidden on her
+	// own row too.
+	reviewer := principalContext("alice", "legal", "reviewer")
+	result, err = collection.Read(reviewer, &query.Query{})
+	require.NoError(t, err)
+	require.Len(t, result.Data, 2)
+	for _, doc := range result.Data {
+		assert.False(t, doc.HasKey("reviewer_notes"))
+	}
+	probe := query.NewQueryBuilder().Where("reviewer_notes").Exists().Build()
+	_, err = collection.Read(reviewer, &probe)
+	assert.True(t, errcode.Require(t, err, policy.ErrAccessDenied.Code).Issues.ContainsCode(policy.IssueHiddenField))
+}
+
+func TestPolicy_ScopesEventSubscriptions(t *testing.T) {
+	collection := newPolicedDocuments(t, policy.Policy{
+		Collection: "documents",
+		Rules: []policy.Rule{
+			{
+				Name:    "reviewers",
+				Actions: []policy.Action{policy.ActionRead},
+				Roles:   []string{"reviewer"},
+				Hidden:  []string{"reviewer_notes"},
+			},
+			{
+				Name:   "owners",
+				Filter: query.NewQueryBuilder().Where("owner_id").Eq("{{principal.id}}").Build().Filters,
+			},
+		},
+	})
+	assert.Empty(t, collection.Subscribe(context.Background(), base.SubscriptionOptions{Event: base.DocumentCreateSuccess}))
+
+	seen := map[string][]data.Documenter{}
+	subscribe := func(name string, ctx context.Context) {
+		id := collection.Subscribe(ctx, base.SubscriptionOptions{
+			Event: base.DocumentCreateSuccess,
+			Callback: func(_ context.Context, event base.PersistenceEvent) error {
+				assert.Nil(t, event.Input, "the writer's request is withheld")
+				written, known := pcollection.WrittenDocuments(event)
+				require.True(t, known)
+				seen[name] = append(seen[name], written...)
+				return nil
+			},
+		})
+		require.NotEmpty(t, id)
+	}
+	subscribe("owner", principalContext("alice", "legal"))
+	subscribe("reviewer", principalContext("carol", "legal", "reviewer"))
+
+	for _, owner := range []string{"alice", "bob"} {
+		_, err := collection.CreateOne(principalContext(owner, "legal"), data.MustNewDocument(map[string]any{"name": owner, "owner_id": owner, "reviewer_notes": "notes"}))
+		require.NoError(t, err)
+	}
+
+	require.Len(t, seen["owner"], 1, "rows outside the grant are not delivered")
+	name, _ := seen["owner"][0].GetString("name")
+	assert.Equal(t, "alice", name)
+	assert.True(t, seen["owner"][0].HasKey("reviewer_notes"))
+	require.Len(t, seen["reviewer"], 2)
+	for _, doc := range seen["reviewer"] {
+		assert.False(t, doc.HasKey("reviewer_notes"), "hidden fields are redacted")
+	}
+}
+
+func TestPolicy_DeniesWithoutMatchingRule(t *testing.T) {
+	collection := setupPolicyTest(t)
+
+	_, err := collection.Read(context.Background(), &query.Query{})
+	errcode.Require(t, err, policy.ErrPrincipalRequired.Code)
+
+	// A manager without the department attribute cannot resolve the template.
+	noDepartment := common.ContextWithPrincipal(context.Background(), common.Principal{ID: "dave", Roles: []string{"manager"}})
+	_, err = collection.Read(noDepartment, &query.Query{})
+	assert.True(t, errcode.Require(t, err, policy.ErrAccessDenied.Code).Issues.ContainsCode(policy.IssueUnresolvedTemplate))
+}
+
+func TestPolicy_ScopesLiveQueries(t *testing.T) {
+	collection := setupPolicyTest(t)
+	alice := principalContext("alice", "legal")
+	for _, owner := range []string{"alice", "bob"} {
+		_, err := collection.CreateOne(principalContext(owner, "legal"), data.MustNewDocument(map[string]any{"name": owner, "owner_id": owner, "department": "legal"}))
+		require.NoError(t, err)
+	}
+
+	var initial base.QueryUpdate
+	_, err := collection.SubscribeQuery(alice, &query.Query{}, func(_ context.Context, update base.QueryUpdate) {
+		initial = update
+	})
+	require.NoError(t, err)
+	require.NotNil(t, initial.Result)
+	require.Len(t, initial.Result.Data, 1)
+	name, _ := initial.Result.Data[0].GetString("name")
+	assert.Equal(t, "alice", name)
+
+	probe := query.NewQueryBuilder().Where("reviewer_notes").Exists().Build()
+	_, err = collection.SubscribeQuery(alice, &probe, func(context.Context, base.QueryUpdate) {})
+	assert.True(t, errcode.Require(t, err, policy.ErrAccessDenied.Code).Issues.ContainsCode(policy.IssueHiddenField))
+
+	_, err = collection.SubscribeQuery(context.Background(), &query.Query{}, func(context.Context, base.QueryUpdate) {})
+	errcode.Require(t, err, policy.ErrPrincipalRequired.Code)
+}
+
+func TestPolicy_RejectsEveryHiddenFieldReference(t *testing.T) {
+	collection := setupPolicyTest(t)
+	alice := principalContext("alice", "legal")
+	_, err := collection.CreateOne(alice, data.MustNewDocument(map[string]any{"name": "contract", "owner_id": "alice", "department": "legal"}))
+	require.NoError(t, err)
+
+	window := query.ProjectionComputedItem{WindowExpression: &query.WindowExpression{
+		Function: query.WindowFunctionRank, PartitionBy: []string{"reviewer_notes"}, Alias: "rank",
+	}}
+	computed := query.ProjectionComputedItem{ComputedFieldExpression: &query.ComputedFieldExpression{
+		Expression: &query.FunctionCall{Function: "upper", Arguments: []query.FilterValue{{FieldRefVal: &query.FieldReference{Field: "reviewer_notes"}}}},
+		Alias:      "notes",
+	}}
+	probes := map[string]query.Query{
+		"nested filter": query.NewQueryBuilder().Where("reviewer_notes.text").Eq("x").Build(),
+		"projection":    query.NewQueryBuilder().Select().Include("reviewer_notes").End().Build(),
+		"aggregation":   query.NewQueryBuilder().Max("reviewer_notes", "latest").Build(),
+		"group":         query.NewQueryBuilder().GroupBy("reviewer_notes").Type(query.AggregationTypeCount).Field("name").Alias("n").End().Build(),
+		"window":        {Projection: &query.ProjectionConfiguration{Computed: []query.ProjectionComputedItem{window}}},
+		"computed":      {Projection: &query.ProjectionConfiguration{Computed: []query.ProjectionComputedItem{computed}}},
+		"text search":   {Filters: &query.QueryFilter{TextSearchQuery: &query.TextSearchQuery{Query: "secret"}}},
+	}
+	for name, probe := range probes {
+		t.Run(name, func(t *testing.T) {
+			_, err := collection.Read(alice, &probe)
+			assert.True(t, errcode.Require(t, err, policy.ErrAccessDenied.Code).Issues.ContainsCode(policy.IssueHiddenField))
+		})
+	}
+
+	// An update cannot compute a visible field from a hidden one either.
+	copyNotes := base.NewCollectionUpdate().WithComputedField("name", probes["computed"]).
+		WithFilter(query.NewQueryBuilder().Where("name").Eq("contract").Build().Filters)
+	_, err = collection.Update(alice, copyNotes)
+	assert.True(t, errcode.Require(t, err, policy.ErrAccessDenied.Code).Issues.ContainsCode(policy.IssueHiddenField))
+}
@@ -610,3 +610,74 @@ func TestList(t *testing.T) {
 		assert.Equal(t, "ERR_REGISTRY_VERSION_NOT_FOUND_FOR_COLLECTION", sysErr.Code)
 	})
 }
+
+func TestCompiledSchema(t *testing.T) {
+	ctx := context.Background()
+	sampleSchema := newTestSchema("test_coll_compiled")
+
+	cr, _, _ := setupTestEnv(t)
+	_, err := cr.CreateCollection(ctx, sampleSchema)
+	require.NoError(t, err)
+
+	newSchema := newTestSchema("test_coll_compiled")
+	newSchema.Version = common.MustNewVersion("1.1.0")
+	newSchema.BaseSchema.Fields["new_field"] = definition.Field{
+		Name:            "new_field",
+		FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString},
+	}
+	_, err = cr.AddSchemaVersion(ctx, sampleSchema.Name, "1.1.0", newSchema)
+	require.NoError(t, err)
+
+	active, err := cr.CompiledSchema(ctx, sampleSchema.Name)
+	require.NoError(t, err)
+	_, err = active.ResolvePath("new_field")
+	assert.Error(t, err, "the active version is still 1.0.0")
+
+	next, err := cr.CompiledSchema(ctx, sampleSchema.Name, "1.1.0")
+	require.NoError(t, err)
+	_, err = next.ResolvePath("new_field")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, next.Bytes(), "stored schemas are byte-backed views")
+
+	t.Run("Export and import restore every version", func(t *testing.T) {
+		restored, _, _ := setupTestEnv(t)
+		require.NoError(t, restored.ImportCompiledSchemas(cr.ExportCompiledSchemas()))
+
+		// The restoring registry has no collections of its own: the view can
+		// only come from the imported store.
+		view, err := restored.CompiledSchema(ctx, sampleSchema.Name, "1.1.0")
+		require.NoError(t, err)
+		assert.Equal(t, next.Bytes(), view.Bytes())
+		assert.Equal(t, next.Descriptors, view.Descriptors)
+	})
+
+	t.Run("Rejects a malformed export", func(t *testing.T) {
+		restored, _, _ := setupTestEnv(t)
+		err := restored.ImportCompiledSchemas([]byte("nope"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), registry.ErrInvalidCompiledSchemaExport.Code)
+	})
+
+	t.Run("A failed import leaves lookups working", func(t *testing.T) {
+		export := cr.ExportCompiledSchemas()
+		err := cr.ImportCompiledSchemas(export[:len(export)-1])
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), registry.ErrInvalidCompiledSchemaExport.Code)
+
+		view, err := cr.CompiledSchema(ctx, sampleSchema.Name, "1.1.0")
+		require.NoError(t, err)
+		_, err = view.ResolvePath("new_field")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Pruning and dropping remove compiled versions", func(t *testing.T) {
+		_, err := cr.PruneVersion(ctx, sampleSchema.Name, "1.1.0")
+		require.NoError(t, err)
+		_, err = cr.CompiledSchema(ctx, sampleSchema.Name, "1.1.0")
+		assert.Error(t, err)
+
+		require.NoError(t, cr.DropCollection(ctx, sampleSchema.Name, base.DropCollectionOptions{}))
+		_, err = cr.CompiledSchema(ctx, sampleSchema.Name)
+		assert.Error(t, err)
+	})
+}
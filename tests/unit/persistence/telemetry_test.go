@@ -0,0 +1,155 @@
+package persistence_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/ephemeral"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// spansNamed returns the spans of reg named name.
+func spansNamed(reg *telemetry.Registry, name string) []telemetry.SpanRecord {
+	var spans []telemetry.SpanRecord
+	for _, span := range reg.Spans() {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestTelemetry_ReportsOperations(t *testing.T) {
+	ctx := context.Background()
+	reg := telemetry.NewRegistry()
+	p, err := persistence.NewPersistence(sqliteInteractor(t), nil, zap.NewNop(), nil, persistence.WithTelemetry(reg.Telemetry()))
+	require.NoError(t, err)
+	orders, err := p.CreateCollection(ctx, orderSchema())
+	require.NoError(t, err)
+
+	reg.Reset()
+	createOrder(t, orders, "open", 10)
+	createOrder(t, orders, "open", 20)
+	createOrder(t, orders, "closed", 30)
+	result, err := orders.Read(ctx, &query.Query{Filters: query.NewQueryBuilder().Where("status").Eq("open").Build().Filters})
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Count)
+
+	reads := spansNamed(reg, "anansi.collection.read")
+	require.Len(t, reads, 1)
+	assert.Equal(t, "orders", reads[0].Attributes[telemetry.KeyCollection])
+	assert.Equal(t, int64(2), reads[0].Attributes[telemetry.KeyRows])
+	assert.Nil(t, reads[0].Err)
+
+	// The query engine and the SQLite statement report under the read.
+	executes := spansNamed(reg, "anansi.query.execute")
+	require.Len(t, executes, 1)
+	assert.Equal(t, reads[0].ID, executes[0].ParentID)
+	selects := spansNamed(reg, "anansi.sqlite.select")
+	require.Len(t, selects, 1)
+	assert.Equal(t, executes[0].ID, selects[0].ParentID)
+	assert.Equal(t, "SELECT", selects[0].Attributes[telemetry.KeyStatement])
+	assert.Equal(t, int64(2), selects[0].Attributes[telemetry.KeyRows])
+
+	snapshot := reg.Snapshot()
+	created := telemetry.Series("anansi.collection.count",
+		telemetry.String(telemetry.KeyCollection, "orders"),
+		telemetry.String(telemetry.KeyOperation, "create"),
+		telemetry.String(telemetry.KeyOutcome, telemetry.OutcomeOK),
+	)
+	assert.Equal(t, int64(3), snapshot.Counters[created])
+	read := telemetry.Series("anansi.collection.rows",
+		telemetry.String(telemetry.KeyCollection, "orders"),
+		telemetry.String(telemetry.KeyOperation, "read"),
+		telemetry.String(telemetry.KeyOutcome, telemetry.OutcomeOK),
+	)
+	assert.Equal(t, int64(2), snapshot.Counters[read])
+	duration := telemetry.Series("anansi.collection.duration",
+		telemetry.String(telemetry.KeyCollection, "orders"),
+		telemetry.String(telemetry.KeyOperation, "read"),
+		telemetry.String(telemetry.KeyOutcome, telemetry.OutcomeOK),
+	)
+	assert.Equal(t, int64(1), snapshot.Histograms[duration].Count)
+}
+
+func TestTelemetry_ReportsTransactionsAndFailures(t *testing.T) {
+	ctx := context.Background()
+	reg := telemetry.NewRegistry()
+	p, err := persistence.NewPersistence(sqliteInteractor(t), nil, zap.NewNop(), nil, persistence.WithTelemetry(reg.Telemetry()))
+	require.NoError(t, err)
+	orders, err := p.CreateCollection(ctx, orderSchema())
+	require.NoError(t, err)
+
+	reg.Reset()
+	_, err = orders.Transact(ctx, func(ctx context.Context) (any, error) {
+		return orders.CreateOne(ctx, data.MustNewDocument(map[string]any{"status": "open", "amount": 10.0}))
+	})
+	require.NoError(t, err)
+	creates := spansNamed(reg, "anansi.collection.create")
+	require.Len(t, creates, 1)
+	assert.NotEmpty(t, creates[0].Attributes[telemetry.KeyTransaction])
+
+	// Transaction IDs stay out of metric series.
+	for series := range reg.Snapshot().Counters {
+		assert.NotContains(t, series, telemetry.KeyTransaction)
+	}
+
+	_, err = orders.Update(ctx, nil)
+	require.Error(t, err)
+	updates := spansNamed(reg, "anansi.collection.update")
+	require.Len(t, updates, 1)
+	assert.Error(t, updates[0].Err)
+	failed := telemetry.Series("anansi.collection.count",
+		telemetry.String(telemetry.KeyCollection, "orders"),
+		telemetry.String(telemetry.KeyOperation, "update"),
+		telemetry.String(telemetry.KeyOutcome, telemetry.OutcomeError),
+	)
+	assert.Equal(t, int64(1), reg.Snapshot().Counters[failed])
+}
+
+func TestTelemetry_ReportsMigrations(t *testing.T) {
+	ctx := context.Background()
+	reg := telemetry.NewRegistry()
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), nil, zap.NewNop(), nil, persistence.WithTelemetry(reg.Telemetry()))
+	require.NoError(t, err)
+	coll, err := p.CreateCollection(ctx, newMigrationTestSchema("migrate_telemetry"))
+	require.NoError(t, err)
+	_, err = coll.CreateMany(ctx, []data.Documenter{
+		data.MustNewDocument(map[string]any{"name": "Alice"}),
+		data.MustNewDocument(map[string]any{"name": "Bob"}),
+	})
+	require.NoError(t, err)
+
+	target := &definition.Schema{
+		BaseSchema: definition.BaseSchema{
+			Name: "migrate_telemetry",
+			Fields: map[definition.FieldId]definition.Field{
+				"f1": {Name: "full_name", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+			},
+		},
+	}
+	_, err = p.Migrate(ctx, "migrate_telemetry", &base.MigrationPlan{
+		Description: "rename name",
+		Target:      target,
+		Transformer: func(_ context.Context, doc data.Document) (data.Document, error) {
+			d := doc.ToMap()
+			d["full_name"] = d["name"]
+			delete(d, "name")
+			return *data.MustNewDocument(d), nil
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	migrations := spansNamed(reg, "anansi.migration.migrate")
+	require.Len(t, migrations, 1)
+	assert.Equal(t, "migrate_telemetry", migrations[0].Attributes[telemetry.KeyCollection])
+	assert.Equal(t, int64(2), migrations[0].Attributes[telemetry.KeyRows])
+}
@@ -0,0 +1,144 @@
+package persistence_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/ephemeral"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	pevents "github.com/asaidimu/go-anansi/v8/core/persistence/events"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/sqlite"
+	sqliteExecutor "github.com/asaidimu/go-anansi/v8/sqlite/executor"
+	rootutils "github.com/asaidimu/go-anansi/v8/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func orderSchema() *definition.Schema {
+	return &definition.Schema{
+		Version: common.MustNewVersion("1.0.0"),
+		BaseSchema: definition.BaseSchema{
+			Name: "orders",
+			Fields: map[definition.FieldId]definition.Field{
+				"status": {Name: "status", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+				"amount": {Name: "amount", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeNumber}},
+			},
+		},
+	}
+}
+
+func sqliteInteractor(t *testing.T) query.DatabaseInteractor {
+	interactor, closeDB, err := sqlite.NewInteractor(sqlite.Options{Path: sqlite.MemoryPath}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = closeDB() })
+	return interactor
+}
+
+func TestQueryTemplate_Read(t *testing.T) {
+	backends := map[string]func(*testing.T) query.DatabaseInteractor{
+		"sqlite":    sqliteInteractor,
+		"ephemeral": func(*testing.T) query.DatabaseInteractor { return ephemeral.NewEphemeral() },
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			p, err := persistence.NewPersistence(open(t), nil, zap.NewNop(), nil)
+			require.NoError(t, err)
+			orders, err := p.CreateCollection(ctx, orderSchema())
+			require.NoError(t, err)
+
+			for _, row := range []map[string]any{
+				{"status": "open", "amount": 10.0},
+				{"status": "open", "amount": 20.0},
+				{"status": "open", "amount": 30.0},
+				{"status": "closed", "amount": 40.0},
+			} {
+				_, err := orders.CreateOne(ctx, data.MustNewDocument(row))
+				require.NoError(t, err)
+			}
+
+			q := query.NewQueryBuilder().
+				WhereGroup(common.LogicalAnd).
+				Where("status").Eq(query.Param("status")).
+				Where("amount").Gte(query.Param("min")).
+				End().
+				Build()
+			tmpl, err := query.NewTemplate(&q)
+			require.NoError(t, err)
+
+			for _, tc := range []struct {
+				params query.Params
+				count  int
+			}{
+				{query.Params{"status": "open", "min": 15}, 2},
+				{query.Params{"status": "open", "min": 25}, 1},
+				{query.Params{"status": "closed", "min": 0}, 1},
+				{query.Params{"status": "open", "min": 100}, 0},
+			} {
+				bound, err := tmpl.Bind(tc.params)
+				require.NoError(t, err)
+				result, err := orders.Read(ctx, bound)
+				require.NoError(t, err)
+				assert.Equal(t, tc.count, result.Count, "%v", tc.params)
+			}
+		})
+	}
+}
+
+func TestQueryTemplate_UnboundParameter(t *testing.T) {
+	ctx := context.Background()
+	p, err := persistence.NewPersistence(sqliteInteractor(t), nil, zap.NewNop(), nil)
+	require.NoError(t, err)
+	orders, err := p.CreateCollection(ctx, orderSchema())
+	require.NoError(t, err)
+
+	// A parameter read without binding a template has no value to run with.
+	q := query.NewQueryBuilder().Where("status").Eq(query.Param("status")).Build()
+	_, err = orders.Read(ctx, &q)
+	assert.Error(t, err)
+}
+
+func TestPersistence_StatementCacheTelemetry(t *testing.T) {
+	goBus, err := rootutils.NewInMemoryGoEventsBus("test")
+	require.NoError(t, err)
+	defer goBus.Close()
+	bus := pevents.NewGoEventsBusAdapter[base.PersistenceEvent](goBus)
+
+	ctx := context.Background()
+	p, err := persistence.NewPersistence(sqliteInteractor(t), bus, zap.NewNop(), nil)
+	require.NoError(t, err)
+
+	received := make(chan base.PersistenceEvent, 16)
+	p.Subscribe(ctx, base.SubscriptionOptions{
+		Event: base.Telemetry,
+		Callback: func(ctx context.Context, event base.PersistenceEvent) error {
+			received <- event
+			return nil
+		},
+	})
+
+	// Creating a collection is DDL, which invalidates the statement cache and
+	// reports its counters.
+	_, err = p.CreateCollection(ctx, orderSchema())
+	require.NoError(t, err)
+
+	select {
+	case event := <-received:
+		assert.Equal(t, base.Telemetry, event.Type)
+		assert.Equal(t, sqliteExecutor.StatementCacheTelemetry, event.Operation)
+		stats, ok := event.Output.(map[string]any)
+		require.True(t, ok)
+		assert.Contains(t, stats, "hits")
+		assert.Contains(t, stats, "misses")
+		assert.NotZero(t, stats["invalidations"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("no statement cache telemetry event")
+	}
+}
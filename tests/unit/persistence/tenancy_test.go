@@ -0,0 +1,297 @@
+package persistence_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/ephemeral"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/tenancy"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/utils"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTenantTestSchema(name string, tenantField bool) *definition.Schema {
+	fields := map[definition.FieldId]definition.Field{
+		"name": {Name: "name", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+	}
+	if tenantField {
+		fields["tenant_id"] = definition.Field{Name: "tenant_id", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}}
+	}
+	return &definition.Schema{
+		Version:    common.MustNewVersion("1.0.0"),
+		BaseSchema: definition.BaseSchema{Name: name, Fields: fields},
+	}
+}
+
+func setupRowLevelTenancy(t *testing.T) base.Persistence {
+	decorators := &utils.Decorators{
+		CollectionDecorators: []utils.DecoratorFunc[base.Collection]{
+			(utils.DecoratorFunc[base.Collection])(tenancy.RowLevel(tenancy.RowLevelConfig{})),
+		},
+	}
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), nil, zap.NewNop(), decorators)
+	require.NoError(t, err)
+
+	_, err = p.CreateCollection(context.Background(), newTenantTestSchema("orders", true))
+	require.NoError(t, err)
+	_, err = p.CreateCollection(context.Background(), newTenantTestSchema("countries", false))
+	require.NoError(t, err)
+	return p
+}
+
+func readNames(t *testing.T, ctx context.Context, c base.Collection) []string {
+	t.Helper()
+	result, err := c.Read(ctx, &query.Query{})
+	require.NoError(t, err)
+	var names []string
+	for _, doc := range result.Data {
+		name, err := doc.GetString("name")
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestRowLevelTenancy_IsolatesTenants(t *testing.T) {
+	p := setupRowLevelTenancy(t)
+	acme := common.ContextWithTenant(context.Background(), "acme")
+	globex := common.ContextWithTenant(context.Background(), "globex")
+
+	orders, err := p.Collection(acme, "orders")
+	require.NoError(t, err)
+
+	res, err := orders.CreateOne(acme, data.MustNewDocument(map[string]any{"name": "a1"}))
+	require.NoError(t, err)
+	require.Equal(t, base.StatusCreated, res.Status)
+	_, err = orders.CreateOne(globex, data.MustNewDocument(map[string]any{"name": "g1"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a1"}, readNames(t, acme, orders))
+	assert.Equal(t, []string{"g1"}, readNames(t, globex, orders))
+
+	_, err = orders.Read(context.Background(), &query.Query{})
+	errcode.Require(t, err, "ERR_TENANCY_TENANT_REQUIRED")
+
+	_, err = orders.CreateOne(acme, data.MustNewDocument(map[string]any{"name": "x", "tenant_id": "globex"}))
+	errcode.Require(t, err, "ERR_TENANCY_CROSS_TENANT_ACCESS")
+
+	_, err = orders.Update(acme, base.NewCollectionUpdate().
+		SetField("tenant_id", "globex").
+		WithFilter(query.NewQueryBuilder().Where("name").Eq("a1").Build().Filters))
+	errcode.Require(t, err, "ERR_TENANCY_CROSS_TENANT_ACCESS")
+
+	_, err = orders.Update(acme, base.NewCollectionUpdate().SetField("name", "renamed"))
+	errcode.Require(t, err, base.ErrInvalidUpdateParams.Code)
+	_, err = orders.Delete(acme, nil, false)
+	errcode.Require(t, err, base.ErrDeleteRequiresFilter.Code)
+
+	_, err = orders.Read(acme, &query.Query{Raw: &query.RawQuery{Template: "SELECT 1"}})
+	errcode.Require(t, err, "ERR_TENANCY_UNSCOPED_QUERY")
+
+	deleted, err := orders.Delete(acme, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Empty(t, readNames(t, acme, orders))
+	assert.Equal(t, []string{"g1"}, readNames(t, globex, orders))
+
+	metadata := orders.Metadata(globex, nil, true)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "globex", metadata.Tenant)
+}
+
+func TestRowLevelTenancy_PassesThroughUnscopedCollections(t *testing.T) {
+	p := setupRowLevelTenancy(t)
+
+	countries, err := p.Collection(context.Background(), "countries")
+	require.NoError(t, err)
+
+	_, err = countries.CreateOne(context.Background(), data.MustNewDocument(map[string]any{"name": "Kenya"}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Kenya"}, readNames(t, common.ContextWithTenant(context.Background(), "acme"), countries))
+}
+
+func TestTenancyRouter_SeparatesPhysicalCollections(t *testing.T) {
+	shared, err := persistence.NewPersistence(ephemeral.NewEphemeral(), nil, zap.NewNop(), nil)
+	require.NoError(t, err)
+	router, err := tenancy.NewRouter(tenancy.RouterConfig{Shared: shared})
+	require.NoError(t, err)
+
+	acme := common.ContextWithTenant(context.Background(), "acme")
+	globex := common.ContextWithTenant(context.Background(), "globex")
+
+	for _, ctx := range []context.Context{acme, globex} {
+		_, err := router.CreateCollection(ctx, newTenantTestSchema("orders", false))
+		require.NoError(t, err)
+	}
+
+	acmeOrders, err := router.Collection(acme, "orders")
+	require.NoError(t, err)
+	globexOrders, err := router.Collection(globex, "orders")
+	require.NoError(t, err)
+
+	_, err = acmeOrders.CreateOne(acme, data.MustNewDocument(map[string]any{"name": "a1"}))
+	require.NoError(t, err)
+	_, err = globexOrders.CreateOne(globex, data.MustNewDocument(map[string]any{"name": "g1"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a1"}, readNames(t, acme, acmeOrders))
+	assert.Equal(t, []string{"g1"}, readNames(t, globex, globexOrders))
+
+	_, err = acmeOrders.Read(globex, &query.Query{})
+	errcode.Require(t, err, "ERR_TENANCY_CROSS_TENANT_ACCESS")
+
+	names, err := router.ListCollections(acme)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders"}, names)
+
+	sc, err := acmeOrders.Schema(acme)
+	require.NoError(t, err)
+	assert.Equal(t, "orders", sc.Name)
+
+	metadata, err := router.Metadata(acme, nil)
+	require.NoError(t, err)
+	require.Len(t, metadata.Collections, 1)
+	assert.Equal(t, "orders", metadata.Collections[0].Name)
+	assert.Equal(t, "acme", metadata.Collections[0].Tenant)
+
+	_, err = router.Collection(context.Background(), "orders")
+	errcode.Require(t, err, "ERR_TENANCY_TENANT_REQUIRED")
+
+	_, err = router.Collection(common.ContextWithTenant(context.Background(), "Acme__x"), "orders")
+	errcode.Require(t, err, "ERR_TENANCY_INVALID_TENANT")
+}
+
+func TestRowLevelTenancy_ListedCollectionRequiresTenantField(t *testing.T) {
+	decorators := &utils.Decorators{
+		CollectionDecorators: []utils.DecoratorFunc[base.Collection]{
+			(utils.DecoratorFunc[base.Collection])(tenancy.RowLevel(tenancy.RowLevelConfig{Collections: []string{"countries"}})),
+		},
+	}
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), nil, zap.NewNop(), decorators)
+	require.NoError(t, err)
+	countries, err := p.CreateCollection(context.Background(), newTenantTestSchema("countries", false))
+	require.NoError(t, err)
+
+	_, err = countries.Read(common.ContextWithTenant(context.Background(), "acme"), &query.Query{})
+	errcode.Require(t, err, "ERR_TENANCY_FIELD_MISSING")
+}
+
+func TestTenancyRouter_ScopesSubscriptions(t *testing.T) {
+	shared, err := persistence.NewPersistence(ephemeral.NewEphemeral(), &syncBus{}, zap.NewNop(), nil)
+	require.NoError(t, err)
+	router, err := tenancy.NewRouter(tenancy.RouterConfig{Shared: shared})
+	require.NoError(t, err)
+
+	acme := common.ContextWithTenant(context.Background(), "acme")
+	globex := common.ContextWithTenant(context.Background(), "globex")
+	collections := map[context.Context]base.Collection{}
+	for _, ctx := range []context.Context{acme, globex} {
+		c, err := router.CreateCollection(ctx, newTenantTestSchema("orders", false))
+		require.NoError(t, err)
+		collections[ctx] = c
+	}
+
+	var seen, collectionSeen []string
+	id := router.Subscribe(acme, base.SubscriptionOptions{
+		Event: base.DocumentCreateSuccess,
+		Callback: func(_ context.Context, event base.PersistenceEvent) error {
+			seen = append(seen, *event.Collection)
+			return nil
+		},
+	})
+	require.NotEmpty(t, id)
+	assert.Empty(t, collections[acme].Subscribe(globex, base.SubscriptionOptions{Event: base.DocumentCreateSuccess}))
+	collections[acme].Subscribe(acme, base.SubscriptionOptions{
+		Event: base.DocumentCreateSuccess,
+		Callback: func(_ context.Context, event base.PersistenceEvent) error {
+			collectionSeen = append(collectionSeen, *event.Collection)
+			return nil
+		},
+	})
+
+	_, err = collections[globex].CreateOne(globex, data.MustNewDocument(map[string]any{"name": "g1"}))
+	require.NoError(t, err)
+	_, err = collections[acme].CreateOne(acme, data.MustNewDocument(map[string]any{"name": "a1"}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders"}, seen)
+	assert.Equal(t, []string{"orders"}, collectionSeen)
+
+	subscriptions, err := router.Subscriptions(globex)
+	require.NoError(t, err)
+	assert.Empty(t, subscriptions)
+	router.Unsubscribe(globex, id)
+	subscriptions, err = router.Subscriptions(acme)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	router.Unsubscribe(acme, id)
+	subscriptions, err = router.Subscriptions(acme)
+	require.NoError(t, err)
+	assert.Empty(t, subscriptions)
+}
+
+func TestRowLevelTenancy_ScopesSubscriptions(t *testing.T) {
+	decorators := &utils.Decorators{
+		CollectionDecorators: []utils.DecoratorFunc[base.Collection]{
+			(utils.DecoratorFunc[base.Collection])(tenancy.RowLevel(tenancy.RowLevelConfig{})),
+		},
+	}
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), &syncBus{}, zap.NewNop(), decorators)
+	require.NoError(t, err)
+	_, err = p.CreateCollection(context.Background(), newTenantTestSchema("orders", true))
+	require.NoError(t, err)
+
+	acme := common.ContextWithTenant(context.Background(), "acme")
+	globex := common.ContextWithTenant(context.Background(), "globex")
+	orders, err := p.Collection(acme, "orders")
+	require.NoError(t, err)
+
+	assert.Empty(t, orders.Subscribe(context.Background(), base.SubscriptionOptions{Event: base.DocumentCreateSuccess}))
+
+	seen := map[context.Context][]base.PersistenceEventType{}
+	ids := map[context.Context][]string{}
+	for _, ctx := range []context.Context{acme, globex} {
+		for _, event := range []base.PersistenceEventType{base.DocumentCreateSuccess, base.DocumentUpdateSuccess, base.DocumentDeleteSuccess} {
+			id := orders.Subscribe(ctx, base.SubscriptionOptions{
+				Event: event,
+				Callback: func(_ context.Context, event base.PersistenceEvent) error {
+					seen[ctx] = append(seen[ctx], event.Type)
+					return nil
+				},
+			})
+			require.NotEmpty(t, id)
+			ids[ctx] = append(ids[ctx], id)
+		}
+	}
+
+	_, err = orders.CreateOne(acme, data.MustNewDocument(map[string]any{"name": "a1"}))
+	require.NoError(t, err)
+	_, err = orders.CreateMany(globex, []data.Documenter{data.MustNewDocument(map[string]any{"name": "g1"})})
+	require.NoError(t, err)
+	_, err = orders.Update(globex, base.NewCollectionUpdate().SetField("name", "g2").
+		WithFilter(query.NewQueryBuilder().Where("name").Eq("g1").Build().Filters))
+	require.NoError(t, err)
+	_, err = orders.Delete(globex, nil, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, []base.PersistenceEventType{base.DocumentCreateSuccess}, seen[acme])
+	assert.Equal(t, []base.PersistenceEventType{base.DocumentCreateSuccess, base.DocumentUpdateSuccess, base.DocumentDeleteSuccess}, seen[globex])
+
+	subscriptions, err := orders.Subscriptions(globex)
+	require.NoError(t, err)
+	assert.Len(t, subscriptions, 3)
+	for _, id := range ids[acme] {
+		orders.Unsubscribe(globex, id)
+	}
+	subscriptions, err = orders.Subscriptions(acme)
+	require.NoError(t, err)
+	assert.Len(t, subscriptions, 3, "a tenant cannot remove another tenant's subscriptions")
+}
@@ -0,0 +1,208 @@
+package persistence_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/ephemeral"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/base"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/persistence"
+	"github.com/asaidimu/go-anansi/v8/core/persistence/views"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// viewRows reads a view, keyed by document ID.
+func viewRows(t *testing.T, view base.Collection) map[string]map[string]any {
+	t.Helper()
+	result, err := view.Read(context.Background(), &query.Query{})
+	require.NoError(t, err)
+	rows := make(map[string]map[string]any, len(result.Data))
+	for _, doc := range result.Data {
+		rows[doc.ID()] = doc.Data()
+	}
+	return rows
+}
+
+// groupTotals reads an aggregate view grouped by status, keyed by status.
+func groupTotals(t *testing.T, view base.Collection) map[string][2]float64 {
+	t.Helper()
+	totals := make(map[string][2]float64)
+	for _, row := range viewRows(t, view) {
+		status, _ := row["status"].(string)
+		totals[status] = [2]float64{number(row["total"]), number(row["orders"])}
+	}
+	return totals
+}
+
+func number(value any) float64 {
+	switch n := value.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	case int:
+		return float64(n)
+	}
+	return 0
+}
+
+func TestViews_MaintainsRowViews(t *testing.T) {
+	ctx := context.Background()
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), &syncBus{}, zap.NewNop(), nil)
+	require.NoError(t, err)
+	orders, err := p.CreateCollection(ctx, orderSchema())
+	require.NoError(t, err)
+
+	a := createOrder(t, orders, "open", 10)
+	createOrder(t, orders, "closed", 20)
+
+	manager := views.New(p, views.Config{})
+	defer manager.Close(ctx)
+	open, err := manager.Create(ctx, views.View{
+		Name:  "open_orders",
+		Query: query.NewQueryBuilder().From("orders").Where("status").Eq("open").Select().Include("amount").End().Build(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]any{a: {"amount": 10.0}}, viewRows(t, open))
+
+	b := createOrder(t, orders, "open", 30)
+	assert.Equal(t, map[string]map[string]any{a: {"amount": 10.0}, b: {"amount": 30.0}}, viewRows(t, open))
+
+	updateOrder(t, orders, a, "amount", 15.0)
+	assert.Equal(t, map[string]map[string]any{a: {"amount": 15.0}, b: {"amount": 30.0}}, viewRows(t, open))
+
+	updateOrder(t, orders, a, "status", "closed")
+	assert.Equal(t, map[string]map[string]any{b: {"amount": 30.0}}, viewRows(t, open))
+
+	_, err = orders.Delete(ctx, query.NewQueryBuilder().Where(data.DocumentIDField).Eq(b).Build().Filters, false)
+	require.NoError(t, err)
+	assert.Empty(t, viewRows(t, open))
+}
+
+func TestViews_MaintainsAggregateViews(t *testing.T) {
+	backends := map[string]func(*testing.T) query.DatabaseInteractor{
+		"sqlite":    sqliteInteractor,
+		"ephemeral": func(*testing.T) query.DatabaseInteractor { return ephemeral.NewEphemeral() },
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			p, err := persistence.NewPersistence(open(t), &syncBus{}, zap.NewNop(), nil)
+			require.NoError(t, err)
+			orders, err := p.CreateCollection(ctx, orderSchema())
+			require.NoError(t, err)
+
+			a := createOrder(t, orders, "open", 10)
+			createOrder(t, orders, "closed", 20)
+
+			manager := views.New(p, views.Config{})
+			defer manager.Close(ctx)
+			totals, err := manager.Create(ctx, views.View{
+				Name: "order_totals",
+				Query: query.NewQueryBuilder().From("orders").
+					GroupBy("status").Type(query.AggregationTypeSum).Field("amount").Alias("total").End().
+					GroupBy("status").Type(query.AggregationTypeCount).Field("amount").Alias("orders").End().
+					Build(),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, map[string][2]float64{"open": {10, 1}, "closed": {20, 1}}, groupTotals(t, totals))
+
+			b := createOrder(t, orders, "open", 5)
+			assert.Equal(t, map[string][2]float64{"open": {15, 2}, "closed": {20, 1}}, groupTotals(t, totals))
+
+			updateOrder(t, orders, a, "amount", 40.0)
+			assert.Equal(t, map[string][2]float64{"open": {45, 2}, "closed": {20, 1}}, groupTotals(t, totals))
+
+			// Changing a group field moves the order between groups.
+			updateOrder(t, orders, b, "status", "shipped")
+			assert.Equal(t, map[string][2]float64{"open": {40, 1}, "closed": {20, 1}, "shipped": {5, 1}}, groupTotals(t, totals))
+
+			_, err = orders.Delete(ctx, query.NewQueryBuilder().Where(data.DocumentIDField).Eq(a).Build().Filters, false)
+			require.NoError(t, err)
+			assert.Equal(t, map[string][2]float64{"closed": {20, 1}, "shipped": {5, 1}}, groupTotals(t, totals))
+		})
+	}
+}
+
+func TestViews_RefreshAndDrop(t *testing.T) {
+	ctx := context.Background()
+	// Without an event bus, views change only when refreshed.
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), nil, zap.NewNop(), nil)
+	require.NoError(t, err)
+	orders, err := p.CreateCollection(ctx, orderSchema())
+	require.NoError(t, err)
+	createOrder(t, orders, "open", 10)
+
+	definition := views.View{
+		Name:  "all_orders",
+		Query: query.NewQueryBuilder().From("orders").OrderByDesc("amount").Limit(2).Build(),
+	}
+	manager := views.New(p, views.Config{})
+	top, err := manager.Create(ctx, definition)
+	require.NoError(t, err)
+	assert.Len(t, viewRows(t, top), 1)
+
+	createOrder(t, orders, "open", 20)
+	createOrder(t, orders, "open", 30)
+	assert.Len(t, viewRows(t, top), 1)
+	require.NoError(t, manager.Refresh(ctx, "all_orders"))
+	var amounts []float64
+	for _, row := range viewRows(t, top) {
+		amounts = append(amounts, row["amount"].(float64))
+	}
+	sort.Float64s(amounts)
+	assert.Equal(t, []float64{20, 30}, amounts)
+
+	_, err = manager.Create(ctx, definition)
+	errcode.Require(t, err, views.ErrViewExists.Code)
+	assert.Equal(t, []views.View{definition}, manager.Views())
+
+	// A later run reuses the collection of an unchanged view.
+	manager.Close(ctx)
+	manager = views.New(p, views.Config{})
+	_, err = manager.Create(ctx, definition)
+	require.NoError(t, err)
+
+	require.NoError(t, manager.Drop(ctx, "all_orders"))
+	exists, err := p.HasCollection(ctx, "all_orders")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	errcode.Require(t, manager.Refresh(ctx, "all_orders"), views.ErrViewNotFound.Code)
+}
+
+func TestViews_RejectsInvalidViews(t *testing.T) {
+	ctx := context.Background()
+	p, err := persistence.NewPersistence(ephemeral.NewEphemeral(), nil, zap.NewNop(), nil)
+	require.NoError(t, err)
+	_, err = p.CreateCollection(ctx, orderSchema())
+	require.NoError(t, err)
+	manager := views.New(p, views.Config{})
+
+	_, err = manager.Create(ctx, views.View{
+		Name:  "totals",
+		Query: query.NewQueryBuilder().From("orders").GroupBy("status").Type(query.AggregationTypeSum).Field("amount").End().Build(),
+	})
+	errcode.Require(t, err, views.ErrInvalidView.Code)
+
+	_, err = manager.Create(ctx, views.View{
+		Name:  "totals",
+		Query: query.NewQueryBuilder().From("orders").Sum("amount", "total").Limit(1).Build(),
+	})
+	errcode.Require(t, err, views.ErrUnsupportedView.Code)
+
+	// Collections that are not views are never overwritten.
+	_, err = manager.Create(ctx, views.View{Name: "orders", Query: query.NewQueryBuilder().From("orders").Build()})
+	errcode.Require(t, err, views.ErrInvalidView.Code)
+	other := orderSchema()
+	other.Name = "archive"
+	_, err = p.CreateCollection(ctx, other)
+	require.NoError(t, err)
+	_, err = manager.Create(ctx, views.View{Name: "archive", Query: query.NewQueryBuilder().From("orders").Build()})
+	errcode.Require(t, err, views.ErrViewExists.Code)
+}
@@ -0,0 +1,228 @@
+package query_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pageViews = []map[string]any{
+	{"user": "ana", "page": "home", "ms": 120, "at": "2024-03-04T09:15:30Z"},
+	{"user": "ben", "page": "docs", "ms": 80, "at": "2024-03-04T09:45:00Z"},
+	{"user": "ana", "page": "docs", "ms": 200, "at": "2024-03-06T18:00:00Z"},
+	{"user": "cid", "page": "home", "ms": 100, "at": int64(1710054000)}, // Sunday 2024-03-10 07:00 UTC
+	{"user": "ben", "page": "home", "ms": 60, "at": "2024-04-01T00:00:00+02:00"},
+}
+
+func applyAggregations(t *testing.T, qb *query.QueryBuilder) []map[string]any {
+	t.Helper()
+	q := qb.Build()
+	helper, err := query.NewQueryHelper(&q, nil, nil, nil)
+	require.NoError(t, err)
+	result, err := helper.ApplyAggregations(pageViews)
+	require.NoError(t, err)
+	return result
+}
+
+func TestApplyAggregations_Builtins(t *testing.T) {
+	result := applyAggregations(t, query.NewQueryBuilder().From("views").
+		Count("", "views").
+		Sum("ms", "total_ms").
+		Avg("ms", "avg_ms").
+		CountDistinct("user", "visitors").
+		GroupConcat("page", "/", "pages").
+		ArrayAgg("ms", "durations").
+		Variance("ms", "variance_ms").
+		Stddev("ms", "stddev_ms").
+		Median("ms", "median_ms").
+		Percentile("ms", 0.9, "p90_ms").
+		First("page", "slowest_page", query.SortConfiguration{Field: "ms", Direction: query.SortDirectionDesc}).
+		Last("user", "slowest_user", query.SortConfiguration{Field: "ms", Direction: query.SortDirectionAsc}))[0]
+
+	assert.Equal(t, int64(5), result["views"])
+	assert.Equal(t, int64(560), result["total_ms"])
+	assert.Equal(t, 112.0, result["avg_ms"])
+	assert.Equal(t, int64(3), result["visitors"])
+	assert.Equal(t, "home/docs/docs/home/home", result["pages"])
+	assert.Equal(t, []any{120, 80, 200, 100, 60}, result["durations"])
+	assert.InDelta(t, 2920.0, result["variance_ms"], 1e-9)
+	assert.InDelta(t, math.Sqrt(2920), result["stddev_ms"], 1e-9)
+	assert.Equal(t, 100.0, result["median_ms"])
+	assert.InDelta(t, 168.0, result["p90_ms"], 1e-9)
+	assert.Equal(t, "docs", result["slowest_page"])
+	assert.Equal(t, "ana", result["slowest_user"])
+}
+
+func TestApplyAggregations_TimeBuckets(t *testing.T) {
+	weekly := applyAggregations(t, query.NewQueryBuilder().From("views").
+		GroupByTime("at", query.TimeBucketWeek, "week").Type(query.AggregationTypeCountDistinct).Field("user").Alias("visitors").End())
+
+	// Buckets are UTC, weeks start on Monday and numbers are Unix seconds.
+	assert.Equal(t, []map[string]any{
+		{"week": "2024-03-04T00:00:00Z", "visitors": int64(3)},
+		{"week": "2024-03-25T00:00:00Z", "visitors": int64(1)},
+	}, weekly)
+
+	monthly := applyAggregations(t, query.NewQueryBuilder().From("views").
+		GroupBy("page").AddTimeGroup("at", query.TimeBucketMonth, "").Type(query.AggregationTypeSum).Field("ms").Alias("ms").End())
+	assert.Equal(t, []map[string]any{
+		{"page": "home", "at_month": "2024-03-01T00:00:00Z", "ms": int64(280)},
+		{"page": "docs", "at_month": "2024-03-01T00:00:00Z", "ms": int64(280)},
+	}, monthly)
+}
+
+func TestApplyAggregations_OneRowPerGroup(t *testing.T) {
+	// Like a GROUP BY, every aggregation is computed for each group of the
+	// fields all of them group by.
+	result := applyAggregations(t, query.NewQueryBuilder().From("views").
+		GroupBy("page").Type(query.AggregationTypeCount).Alias("views").End().
+		Median("ms", "median_ms"))
+	assert.Equal(t, []map[string]any{
+		{"page": "home", "views": int64(3), "median_ms": 100.0},
+		{"page": "docs", "views": int64(2), "median_ms": 140.0},
+	}, result)
+
+	q := query.NewQueryBuilder().From("views").GroupBy("page").Type(query.AggregationTypeCount).Alias("views").End().Build()
+	helper, err := query.NewQueryHelper(&q, nil, nil, nil)
+	require.NoError(t, err)
+	empty, err := helper.ApplyAggregations(nil)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	// Ungrouped aggregations always have their one row.
+	total := applyAggregations(t, query.NewQueryBuilder().From("views").Count("", "views"))
+	assert.Equal(t, []map[string]any{{"views": int64(5)}}, total)
+}
+
+func TestApplyAggregations_TimeBucketUnits(t *testing.T) {
+	expected := map[query.TimeBucketUnit]string{
+		query.TimeBucketMinute: "2024-03-06T18:42:00Z",
+		query.TimeBucketHour:   "2024-03-06T18:00:00Z",
+		query.TimeBucketDay:    "2024-03-06T00:00:00Z",
+		query.TimeBucketWeek:   "2024-03-04T00:00:00Z",
+		query.TimeBucketMonth:  "2024-03-01T00:00:00Z",
+	}
+	for unit, bucket := range expected {
+		t.Run(string(unit), func(t *testing.T) {
+			q := query.NewQueryBuilder().From("views").
+				GroupByTime("at", unit, "bucket").Type(query.AggregationTypeCount).Alias("n").End().
+				Build()
+			helper, err := query.NewQueryHelper(&q, nil, nil, nil)
+			require.NoError(t, err)
+
+			result, err := helper.ApplyAggregations([]map[string]any{{"at": "2024-03-06T18:42:31Z"}})
+			require.NoError(t, err)
+			assert.Equal(t, []map[string]any{{"bucket": bucket, "n": int64(1)}}, result)
+		})
+	}
+}
+
+func TestApplyAggregations_RegisteredFunctionsTakePrecedence(t *testing.T) {
+	q := query.NewQueryBuilder().From("views").Median("ms", "median_ms").Build()
+	custom := query.AggregationFunctionsMap{
+		query.AggregationTypeMedian: func(records []map[string]any, field string) (any, error) {
+			return "custom", nil
+		},
+	}
+	helper, err := query.NewQueryHelper(&q, nil, &custom, nil)
+	require.NoError(t, err)
+
+	result, err := helper.ApplyAggregations(pageViews)
+	require.NoError(t, err)
+	assert.Equal(t, "custom", result[0]["median_ms"])
+}
+
+func TestAggregationConfiguration_Validation(t *testing.T) {
+	testCases := []struct {
+		name string
+		agg  query.AggregationConfiguration
+		code string
+	}{
+		{"unknown type", query.AggregationConfiguration{Type: "mode", Field: "ms"}, query.ErrUnsupportedAggregationType.Code},
+		{"missing percentile", query.AggregationConfiguration{Type: query.AggregationTypePercentile, Field: "ms"}, query.ErrInvalidPercentile.Code},
+		{"percentile out of range", query.AggregationConfiguration{Type: query.AggregationTypePercentile, Field: "ms", Percentile: float64p(1.5)}, query.ErrInvalidPercentile.Code},
+		{"unknown bucket unit", query.AggregationConfiguration{
+			Type: query.AggregationTypeCount, TimeGroups: []query.TimeBucket{{Field: "at", Unit: "year"}},
+		}, query.ErrInvalidTimeBucket.Code},
+		{"bucket without field", query.AggregationConfiguration{
+			Type: query.AggregationTypeCount, TimeGroups: []query.TimeBucket{{Unit: query.TimeBucketDay}},
+		}, query.ErrInvalidTimeBucket.Code},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := &query.Query{Aggregations: []query.AggregationConfiguration{tc.agg}}
+			_, err := query.NewQueryHelper(q, nil, nil, nil)
+			require.Error(t, err)
+			var sysErr *common.SystemError
+			for e := error(err); e != nil; e = errors.Unwrap(e) {
+				if errors.As(e, &sysErr) && sysErr.Code == tc.code {
+					return
+				}
+			}
+			t.Errorf("expected error code %s, got %v", tc.code, err)
+		})
+	}
+}
+
+func TestAggregationConfiguration_JSON(t *testing.T) {
+	q := query.NewQueryBuilder().From("views").
+		GroupConcat("page", ";", "pages").
+		GroupByTime("at", query.TimeBucketDay, "day").Type(query.AggregationTypePercentile).Field("ms").Percentile(0.5).End().
+		GroupBy("page").Type(query.AggregationTypeFirst).Field("user").OrderBy("at", query.SortDirectionAsc).End().
+		Build()
+
+	data, err := json.Marshal(q)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"time_groups":[{"field":"at","unit":"day","alias":"day"}]`)
+
+	var decoded query.Query
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, q.Aggregations, decoded.Aggregations)
+}
+
+func TestSchemaFromQuery_Aggregations(t *testing.T) {
+	views := &definition.Schema{
+		Version: common.MustNewVersion("1.0.0"),
+		BaseSchema: definition.BaseSchema{
+			Name: "views",
+			Fields: map[definition.FieldId]definition.Field{
+				"page": {Name: "page", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+				"ms":   {Name: "ms", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeInteger}},
+			},
+		},
+	}
+
+	q := query.NewQueryBuilder().From("views").Schema(views).
+		CountDistinct("page", "pages").
+		GroupConcat("page", "", "page_list").
+		ArrayAgg("ms", "durations").
+		Stddev("ms", "stddev_ms").
+		Percentile("ms", 0.95, "p95_ms").
+		First("page", "first_page").
+		Build()
+
+	resultSchema, err := query.SchemaFromQuery(&q, nil)
+	require.NoError(t, err)
+
+	expected := map[string]definition.FieldType{
+		"pages":      definition.FieldTypeInteger,
+		"page_list":  definition.FieldTypeString,
+		"durations":  definition.FieldTypeArray,
+		"stddev_ms":  definition.FieldTypeNumber,
+		"p95_ms":     definition.FieldTypeNumber,
+		"first_page": definition.FieldTypeString,
+	}
+	for name, fieldType := range expected {
+		field, ok := resultSchema.Fields[definition.FieldId(name)]
+		require.True(t, ok, name)
+		assert.Equal(t, fieldType, field.Type, name)
+	}
+}
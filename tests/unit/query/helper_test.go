@@ -156,6 +156,77 @@ func TestFilter(t *testing.T) {
 	}
 }
 
+func TestFilter_ArrayAndRegexOperators(t *testing.T) {
+	orders := []map[string]any{
+		{"id": 1, "code": "ORD-001", "total": 12.5, "tags": []string{"rush", "gift"}, "items": []any{
+			map[string]any{"sku": "pen", "qty": 10}, map[string]any{"sku": "ink", "qty": 1},
+		}},
+		{"id": 2, "code": "ord-002", "total": 40, "tags": []any{"gift"}, "items": []map[string]any{{"sku": "pen", "qty": 1}}},
+		{"id": 3, "code": "INV-003", "total": 99, "tags": []any{}},
+	}
+	condition := func(field string, op query.ComparisonOperator, value any) *query.QueryFilter {
+		return &query.QueryFilter{Condition: &query.FilterCondition{Field: field, Operator: op, Value: query.NewFilterValue(value)}}
+	}
+	item := func(sku string, minQty int) query.QueryFilter {
+		return query.QueryFilter{Group: &query.FilterGroup{
+			Operator:   common.LogicalAnd,
+			Conditions: []query.QueryFilter{*condition("sku", query.ComparisonOperatorEq, sku), *condition("qty", query.ComparisonOperatorGte, minQty)},
+		}}
+	}
+
+	testCases := []struct {
+		name        string
+		filter      *query.QueryFilter
+		expectedIDs []int
+	}{
+		{"regex", condition("code", query.ComparisonOperatorRegex, `^ORD-\d+$`), []int{1}},
+		{"case-insensitive regex", condition("code", query.ComparisonOperatorRegex, `(?i)^ord-`), []int{1, 2}},
+		{"ieq", condition("code", query.ComparisonOperatorIEq, "ORD-002"), []int{2}},
+		{"between is inclusive", condition("total", query.ComparisonOperatorBetween, []any{12.5, 40}), []int{1, 2}},
+		{"size", condition("tags", query.ComparisonOperatorSize, 1), []int{2}},
+		{"size of a missing array", condition("items", query.ComparisonOperatorSize, 0), nil},
+		{"all", condition("tags", query.ComparisonOperatorAll, []any{"gift", "rush"}), []int{1}},
+		{"elem_match on objects", condition("items", query.ComparisonOperatorElemMatch, item("pen", 5)), []int{1}},
+		{"elem_match matches within one element", condition("items", query.ComparisonOperatorElemMatch, item("ink", 5)), nil},
+		{"elem_match on scalars", condition("tags", query.ComparisonOperatorElemMatch, *condition("", query.ComparisonOperatorRegex, "^ru")), []int{1}},
+		{"elem_match with a field reference", condition("items", query.ComparisonOperatorElemMatch, query.QueryFilter{
+			Condition: &query.FilterCondition{Field: "qty", Operator: query.ComparisonOperatorGt, Value: query.FilterValue{FieldRefVal: &query.FieldReference{Field: "total", Type: "field"}}},
+		}), nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			helper, err := query.NewQueryHelper(&query.Query{Filters: tc.filter}, nil, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to create query helper: %v", err)
+			}
+			filtered, err := helper.Filter(orders)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var resultIDs []int
+			for _, r := range filtered {
+				resultIDs = append(resultIDs, r["id"].(int))
+			}
+			if !reflect.DeepEqual(resultIDs, tc.expectedIDs) {
+				t.Errorf("expected IDs %v, but got %v", tc.expectedIDs, resultIDs)
+			}
+		})
+	}
+
+	for name, filter := range map[string]*query.QueryFilter{
+		"bad pattern":            condition("code", query.ComparisonOperatorRegex, "("),
+		"one bound":              condition("total", query.ComparisonOperatorBetween, []any{1}),
+		"fractional size":        condition("tags", query.ComparisonOperatorSize, 1.5),
+		"empty set":              condition("tags", query.ComparisonOperatorAll, []any{}),
+		"missing element filter": condition("items", query.ComparisonOperatorElemMatch, "pen"),
+	} {
+		if _, err := query.NewQueryHelper(&query.Query{Filters: filter}, nil, nil, nil); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
 func TestSort(t *testing.T) {
 	testCases := []struct {
 		name        string
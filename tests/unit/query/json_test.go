@@ -897,3 +897,32 @@ func TestQuery_MarshalUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+// TestFilterValue_ElementFilterJSON tests that elem_match operands survive a JSON round trip.
+func TestFilterValue_ElementFilterJSON(t *testing.T) {
+	jsonStr := `{"field":"items","operator":"elem_match","value":{"type":"filter","filter":{"operator":"and","conditions":[{"field":"sku","operator":"eq","value":"pen"},{"field":"","operator":"exists","value":null}]}}}`
+
+	var filter query.QueryFilter
+	if err := json.Unmarshal([]byte(jsonStr), &filter); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	elementFilter := filter.Condition.Value.ElementFilterVal
+	if elementFilter == nil || elementFilter.Type != "filter" || elementFilter.Filter.Group == nil {
+		t.Fatalf("Unmarshal() element filter = %+v", elementFilter)
+	}
+	if got := elementFilter.Filter.Group.Conditions[0].Condition; got.Field != "sku" || *got.Value.StringVal != "pen" {
+		t.Errorf("Unmarshal() first element condition = %+v", got)
+	}
+
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var roundTrip query.QueryFilter
+	if err := json.Unmarshal(encoded, &roundTrip); err != nil {
+		t.Fatalf("Unmarshal() of marshalled filter error = %v", err)
+	}
+	if !reflect.DeepEqual(filter, roundTrip) {
+		t.Errorf("round trip = %s, want %s", encoded, jsonStr)
+	}
+}
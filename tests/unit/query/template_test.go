@@ -0,0 +1,100 @@
+package query_test
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/tests/testutils/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func templateQuery() query.Query {
+	return query.NewQueryBuilder().
+		WhereGroup(common.LogicalAnd).
+		Where("status").Eq(query.Param("status")).
+		Where("amount").Gte(query.Param("min")).
+		End().
+		Build()
+}
+
+func TestTemplate_Bind(t *testing.T) {
+	q := templateQuery()
+	tmpl, err := query.NewTemplate(&q)
+	require.NoError(t, err)
+
+	names := tmpl.Names()
+	sort.Strings(names)
+	assert.Equal(t, []string{"min", "status"}, names)
+
+	a, err := tmpl.Bind(query.Params{"status": "open", "min": 10})
+	require.NoError(t, err)
+	b, err := tmpl.Bind(query.Params{"status": "closed", "min": 2.5})
+	require.NoError(t, err)
+
+	require.NotNil(t, a.Bindings)
+	assert.Equal(t, a.Bindings.Key(), b.Bindings.Key(), "bindings of one template share its key")
+	v, ok := a.Bindings.Value("min")
+	require.True(t, ok)
+	assert.Equal(t, 10.0, v, "numbers are bound as float64")
+
+	// The bound query keeps its placeholders until the bindings are applied.
+	assert.NotNil(t, a.Filters.Group.Conditions[0].Condition.Value.ParamVal)
+	applied, err := a.Bindings.Apply(a)
+	require.NoError(t, err)
+	assert.Nil(t, applied.Bindings)
+	assert.Equal(t, "open", *applied.Filters.Group.Conditions[0].Condition.Value.StringVal)
+	assert.Equal(t, 10.0, *applied.Filters.Group.Conditions[1].Condition.Value.NumberVal)
+	assert.NotNil(t, a.Filters.Group.Conditions[0].Condition.Value.ParamVal, "Apply does not modify its input")
+
+	clone, err := a.Clone()
+	require.NoError(t, err)
+	assert.Same(t, a.Bindings, clone.Bindings)
+}
+
+func TestTemplate_BindErrors(t *testing.T) {
+	q := templateQuery()
+	tmpl, err := query.NewTemplate(&q)
+	require.NoError(t, err)
+
+	for name, params := range map[string]query.Params{
+		"missing":    {"status": "open"},
+		"unknown":    {"status": "open", "min": 1, "max": 2},
+		"non-scalar": {"status": []any{"open"}, "min": 1},
+		"struct":     {"status": struct{}{}, "min": 1},
+		"nil":        {"status": nil, "min": 1},
+	} {
+		_, err := tmpl.Bind(params)
+		var sysErr *common.SystemError
+		assert.True(t, errors.As(err, &sysErr), name)
+	}
+}
+
+func TestTemplate_Invalid(t *testing.T) {
+	for name, q := range map[string]query.Query{
+		"operator": query.NewQueryBuilder().Where("tags").In(query.Param("tags")).Build(),
+		"array":    query.NewQueryBuilder().Where("status").In([]any{query.Param("a"), "b"}).Build(),
+	} {
+		_, err := query.NewTemplate(&q)
+		errcode.Require(t, err, query.ErrInvalidTemplate.Code, name)
+	}
+
+	_, err := query.NewTemplate(nil)
+	assert.Error(t, err)
+}
+
+func TestParameter_JSON(t *testing.T) {
+	q := query.NewQueryBuilder().Where("status").Eq(query.Param("status")).Build()
+	bytes, err := json.Marshal(q.Filters)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"field":"status","operator":"eq","value":{"type":"param","name":"status"}}`, string(bytes))
+
+	var decoded query.QueryFilter
+	require.NoError(t, json.Unmarshal(bytes, &decoded))
+	require.NotNil(t, decoded.Condition.Value.ParamVal)
+	assert.Equal(t, "status", decoded.Condition.Value.ParamVal.Name)
+}
@@ -0,0 +1,147 @@
+package query_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var postSchema = &definition.Schema{
+	Version: common.MustNewVersion("1.0.0"),
+	BaseSchema: definition.BaseSchema{
+		Name: "posts",
+		Fields: map[definition.FieldId]definition.Field{
+			"title": {Name: "title", Required: true, FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+			"views": {Name: "views", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeInteger}},
+			"score": {Name: "score", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeNumber}},
+			"tags":  {Name: "tags", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeArray}},
+			"stats": {Name: "stats", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeObject}},
+		},
+	},
+}
+
+func TestApplyUpdateOperators(t *testing.T) {
+	stats := map[string]any{"likes": 3, "best": 7}
+	doc := map[string]any{
+		"title": "hello",
+		"views": 10,
+		"score": 1.5,
+		"tags":  []string{"go", "db", "go"},
+		"stats": stats,
+	}
+
+	err := query.ApplyUpdateOperators(doc, []query.UpdateOperator{
+		{Type: query.UpdateOperatorIncrement, Field: "views", Value: 5},
+		{Type: query.UpdateOperatorMultiply, Field: "score", Value: 2},
+		{Type: query.UpdateOperatorDecrement, Field: "clicks", Value: 1},
+		{Type: query.UpdateOperatorMin, Field: "stats.best", Value: 4},
+		{Type: query.UpdateOperatorMax, Field: "stats.likes", Value: 1},
+		{Type: query.UpdateOperatorSet, Field: "stats.owner.name", Value: "ana"},
+		{Type: query.UpdateOperatorPull, Field: "tags", Value: "go"},
+		{Type: query.UpdateOperatorPush, Field: "links", Value: map[string]any{"rel": "self"}},
+		{Type: query.UpdateOperatorUnset, Field: "title"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"views":  int64(15),
+		"score":  3.0,
+		"clicks": int64(-1),
+		"tags":   []any{"db"},
+		"links":  []any{map[string]any{"rel": "self"}},
+		"stats":  map[string]any{"likes": 3, "best": 4, "owner": map[string]any{"name": "ana"}},
+	}, doc)
+	assert.Equal(t, map[string]any{"likes": 3, "best": 7}, stats, "nested objects are copied before they change")
+}
+
+func TestApplyUpdateOperators_Arrays(t *testing.T) {
+	testCases := []struct {
+		name     string
+		op       query.UpdateOperator
+		expected any
+	}{
+		{"add_to_set appends new values", query.UpdateOperator{Type: query.UpdateOperatorAddToSet, Field: "tags", Value: 3}, []any{1, 2.0, 3}},
+		{"add_to_set compares numbers by value", query.UpdateOperator{Type: query.UpdateOperatorAddToSet, Field: "tags", Value: 2}, []any{1, 2.0}},
+		{"pop removes the last element", query.UpdateOperator{Type: query.UpdateOperatorPop, Field: "tags", Value: 1}, []any{1}},
+		{"pop -1 removes the first element", query.UpdateOperator{Type: query.UpdateOperatorPop, Field: "tags", Value: -1}, []any{2.0}},
+		{"pull on a missing array is a no-op", query.UpdateOperator{Type: query.UpdateOperatorPull, Field: "other", Value: 1}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := map[string]any{"tags": []any{1, 2.0}}
+			require.NoError(t, query.ApplyUpdateOperators(doc, []query.UpdateOperator{tc.op}))
+			assert.Equal(t, tc.expected, doc[tc.op.Field])
+		})
+	}
+
+	err := query.ApplyUpdateOperators(map[string]any{"tags": "go"}, []query.UpdateOperator{{Type: query.UpdateOperatorPush, Field: "tags", Value: 1}})
+	assert.Error(t, err, "push needs an array")
+}
+
+func TestValidateUpdateOperators(t *testing.T) {
+	testCases := []struct {
+		name string
+		ops  []query.UpdateOperator
+		code string
+	}{
+		{"unknown type", []query.UpdateOperator{{Type: "rename", Field: "views"}}, query.ErrUpdateOperatorUnsupported.Code},
+		{"missing field", []query.UpdateOperator{{Type: query.UpdateOperatorUnset}}, query.ErrUpdateOperatorFieldEmpty.Code},
+		{"unknown field", []query.UpdateOperator{{Type: query.UpdateOperatorIncrement, Field: "clicks", Value: 1}}, query.ErrUpdateOperatorFieldUnknown.Code},
+		{"system field", []query.UpdateOperator{{Type: query.UpdateOperatorIncrement, Field: "_metadata_.version", Value: 1}}, query.ErrUpdateOperatorFieldUnknown.Code},
+		{"non-numeric value", []query.UpdateOperator{{Type: query.UpdateOperatorIncrement, Field: "views", Value: "1"}}, query.ErrUpdateOperatorValue.Code},
+		{"fraction on integer", []query.UpdateOperator{{Type: query.UpdateOperatorMultiply, Field: "views", Value: 1.5}}, query.ErrUpdateOperatorValue.Code},
+		{"arithmetic on string", []query.UpdateOperator{{Type: query.UpdateOperatorIncrement, Field: "title", Value: 1}}, query.ErrUpdateOperatorFieldType.Code},
+		{"push to number", []query.UpdateOperator{{Type: query.UpdateOperatorPush, Field: "views", Value: 1}}, query.ErrUpdateOperatorFieldType.Code},
+		{"nested path in scalar", []query.UpdateOperator{{Type: query.UpdateOperatorSet, Field: "title.en", Value: "hi"}}, query.ErrUpdateOperatorFieldType.Code},
+		{"set without nested path", []query.UpdateOperator{{Type: query.UpdateOperatorSet, Field: "stats", Value: map[string]any{}}}, query.ErrUpdateOperatorFieldType.Code},
+		{"unset required", []query.UpdateOperator{{Type: query.UpdateOperatorUnset, Field: "title"}}, query.ErrUpdateOperatorRequiredField.Code},
+		{"bad pop end", []query.UpdateOperator{{Type: query.UpdateOperatorPop, Field: "tags", Value: 2}}, query.ErrUpdateOperatorValue.Code},
+		{"overlapping paths", []query.UpdateOperator{
+			{Type: query.UpdateOperatorSet, Field: "stats.likes", Value: 1},
+			{Type: query.UpdateOperatorUnset, Field: "stats"},
+		}, query.ErrUpdateOperatorConflict.Code},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := query.ValidateUpdateOperators(postSchema, tc.ops)
+			require.Error(t, err)
+			var sysErr *common.SystemError
+			for e := error(err); e != nil; e = errors.Unwrap(e) {
+				if errors.As(e, &sysErr) && sysErr.Code == tc.code {
+					return
+				}
+			}
+			t.Errorf("expected error code %s, got %v", tc.code, err)
+		})
+	}
+
+	assert.NoError(t, query.ValidateUpdateOperators(postSchema, []query.UpdateOperator{
+		{Type: query.UpdateOperatorIncrement, Field: "views", Value: 1},
+		{Type: query.UpdateOperatorMax, Field: "score", Value: 9.5},
+		{Type: query.UpdateOperatorAddToSet, Field: "tags", Value: "go"},
+		{Type: query.UpdateOperatorSet, Field: "stats.likes", Value: 1},
+		{Type: query.UpdateOperatorIncrement, Field: "stats.shares", Value: 1},
+	}))
+}
+
+func TestUpdateOperator_JSON(t *testing.T) {
+	ops := []query.UpdateOperator{
+		{Type: query.UpdateOperatorIncrement, Field: "views", Value: 1.0},
+		{Type: query.UpdateOperatorUnset, Field: "stats.draft"},
+	}
+	encoded, err := json.Marshal(ops)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"type":"inc","field":"views","value":1},{"type":"unset","field":"stats.draft"}]`, string(encoded))
+
+	var decoded []query.UpdateOperator
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, ops, decoded)
+}
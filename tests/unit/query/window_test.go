@@ -0,0 +1,219 @@
+package query_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/common"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var leaderboard = []map[string]any{
+	{"player": "ana", "team": "red", "score": 30},
+	{"player": "ben", "team": "blue", "score": 20},
+	{"player": "cid", "team": "red", "score": 50},
+	{"player": "dee", "team": "blue", "score": 20},
+	{"player": "eli", "team": "red", "score": 30},
+	{"player": "fay", "team": "blue", "score": 10},
+}
+
+func applyWindows(t *testing.T, pb *query.ProjectionBuilder) map[string]map[string]any {
+	t.Helper()
+	q := pb.End().Build()
+	helper, err := query.NewQueryHelper(&q, nil, nil, nil)
+	require.NoError(t, err)
+	rows, err := helper.ApplyWindows(leaderboard)
+	require.NoError(t, err)
+	require.Len(t, rows, len(leaderboard))
+
+	byPlayer := make(map[string]map[string]any, len(rows))
+	for i, row := range rows {
+		assert.Equal(t, leaderboard[i]["player"], row["player"], "rows keep their input order")
+		byPlayer[row["player"].(string)] = row
+	}
+	return byPlayer
+}
+
+func windowColumn(rows map[string]map[string]any, alias string) map[string]any {
+	column := make(map[string]any, len(rows))
+	for player, row := range rows {
+		column[player] = row[alias]
+	}
+	return column
+}
+
+func TestApplyWindows_Ranking(t *testing.T) {
+	rows := applyWindows(t, query.NewQueryBuilder().From("scores").Select().
+		AddWindow("position", query.WindowFunctionRowNumber, "").PartitionBy("team").OrderBy("score", query.SortDirectionDesc).OrderBy("player", query.SortDirectionAsc).End().
+		AddWindow("rank", query.WindowFunctionRank, "").PartitionBy("team").OrderBy("score", query.SortDirectionDesc).End().
+		AddWindow("dense", query.WindowFunctionDenseRank, "").PartitionBy("team").OrderBy("score", query.SortDirectionDesc).End())
+
+	assert.Equal(t, map[string]any{"cid": int64(1), "ana": int64(2), "eli": int64(3), "ben": int64(1), "dee": int64(2), "fay": int64(3)}, windowColumn(rows, "position"))
+	assert.Equal(t, map[string]any{"cid": int64(1), "ana": int64(2), "eli": int64(2), "ben": int64(1), "dee": int64(1), "fay": int64(3)}, windowColumn(rows, "rank"))
+	assert.Equal(t, map[string]any{"cid": int64(1), "ana": int64(2), "eli": int64(2), "ben": int64(1), "dee": int64(1), "fay": int64(2)}, windowColumn(rows, "dense"))
+	assert.NotContains(t, leaderboard[0], "position", "input records are not modified")
+}
+
+func TestApplyWindows_LagLead(t *testing.T) {
+	rows := applyWindows(t, query.NewQueryBuilder().From("scores").Select().
+		AddWindow("previous", query.WindowFunctionLag, "score").PartitionBy("team").OrderBy("player", query.SortDirectionAsc).Default(0).End().
+		AddWindow("after_next", query.WindowFunctionLead, "player").OrderBy("player", query.SortDirectionAsc).Offset(2).End())
+
+	assert.Equal(t, map[string]any{"ana": float64(0), "cid": 30, "eli": 50, "ben": float64(0), "dee": 20, "fay": 20}, windowColumn(rows, "previous"))
+	assert.Equal(t, map[string]any{"ana": "cid", "ben": "dee", "cid": "eli", "dee": "fay", "eli": nil, "fay": nil}, windowColumn(rows, "after_next"))
+}
+
+func TestApplyWindows_Frames(t *testing.T) {
+	rows := applyWindows(t, query.NewQueryBuilder().From("scores").Select().
+		AddWindow("running", query.WindowFunctionSum, "score").OrderBy("player", query.SortDirectionAsc).Rows(query.UnboundedPreceding(), query.CurrentRow()).End().
+		AddWindow("moving", query.WindowFunctionAvg, "score").OrderBy("player", query.SortDirectionAsc).Rows(query.Preceding(1), query.Following(1)).End().
+		AddWindow("team_total", query.WindowFunctionSum, "score").PartitionBy("team").End().
+		AddWindow("peers", query.WindowFunctionCount, "").OrderBy("score", query.SortDirectionAsc).End().
+		AddWindow("best", query.WindowFunctionMax, "score").PartitionBy("team").End().
+		AddWindow("last", query.WindowFunctionLastValue, "player").OrderBy("score", query.SortDirectionAsc).OrderBy("player", query.SortDirectionAsc).Range(query.CurrentRow(), query.UnboundedFollowing()).End())
+
+	assert.Equal(t, map[string]any{"ana": int64(30), "ben": int64(50), "cid": int64(100), "dee": int64(120), "eli": int64(150), "fay": int64(160)}, windowColumn(rows, "running"))
+	assert.Equal(t, map[string]any{"ana": 25.0, "ben": 100.0 / 3, "cid": 30.0, "dee": 100.0 / 3, "eli": 20.0, "fay": 20.0}, windowColumn(rows, "moving"))
+	assert.Equal(t, map[string]any{"ana": int64(110), "cid": int64(110), "eli": int64(110), "ben": int64(50), "dee": int64(50), "fay": int64(50)}, windowColumn(rows, "team_total"))
+	// Without a frame, an ordered window runs up to the last peer of the row.
+	assert.Equal(t, map[string]any{"fay": int64(1), "ben": int64(3), "dee": int64(3), "ana": int64(5), "eli": int64(5), "cid": int64(6)}, windowColumn(rows, "peers"))
+	assert.Equal(t, map[string]any{"ana": 50, "cid": 50, "eli": 50, "ben": 20, "dee": 20, "fay": 20}, windowColumn(rows, "best"))
+	assert.Equal(t, "cid", rows["fay"]["last"])
+}
+
+func TestApplyWindows_Project(t *testing.T) {
+	q := query.NewQueryBuilder().From("scores").Select().
+		Include("player").
+		AddWindow("position", query.WindowFunctionRowNumber, "").OrderBy("score", query.SortDirectionDesc).OrderBy("player", query.SortDirectionAsc).End().
+		End().
+		OrderBy("score", query.SortDirectionDesc).
+		Limit(2).
+		Build()
+	helper, err := query.NewQueryHelper(&q, nil, nil, nil)
+	require.NoError(t, err)
+
+	rows, err := helper.ApplyWindows(leaderboard)
+	require.NoError(t, err)
+	rows, err = helper.Sort(rows)
+	require.NoError(t, err)
+	rows, _, err = helper.Paginate(rows)
+	require.NoError(t, err)
+	rows, err = helper.Project(rows)
+	require.NoError(t, err)
+
+	assert.Equal(t, []map[string]any{
+		{"player": "cid", "position": int64(1)},
+		{"player": "ana", "position": int64(2)},
+	}, rows)
+}
+
+func TestWindowExpression_Validation(t *testing.T) {
+	window := func(w query.WindowExpression) *query.Query {
+		p := &query.ProjectionConfiguration{}
+		p.AddWindowExpression(w)
+		return &query.Query{Projection: p}
+	}
+	testCases := []struct {
+		name  string
+		query *query.Query
+		code  string
+	}{
+		{"unknown function", window(query.WindowExpression{Function: "ntile", Alias: "n"}), query.ErrUnsupportedWindowFunction.Code},
+		{"missing field", window(query.WindowExpression{Function: query.WindowFunctionSum, Alias: "s"}), query.ErrWindowFieldEmpty.Code},
+		{"negative offset", window(query.WindowExpression{Function: query.WindowFunctionLag, Field: "score", Offset: intPtr(-1), Alias: "l"}), query.ErrWindowOffsetNegative.Code},
+		{"range offset", window(query.WindowExpression{
+			Function: query.WindowFunctionSum, Field: "score", Alias: "s",
+			Frame: &query.WindowFrame{Unit: query.WindowFrameRange, Start: query.Preceding(1), End: query.CurrentRow()},
+		}), query.ErrInvalidWindowFrame.Code},
+		{"frame ends before it starts", window(query.WindowExpression{
+			Function: query.WindowFunctionSum, Field: "score", Alias: "s",
+			Frame: &query.WindowFrame{Unit: query.WindowFrameRows, Start: query.CurrentRow(), End: query.Preceding(1)},
+		}), query.ErrInvalidWindowFrame.Code},
+		{"with aggregations", func() *query.Query {
+			q := window(query.WindowExpression{Function: query.WindowFunctionRowNumber, Alias: "n"})
+			q.Aggregations = []query.AggregationConfiguration{{Type: query.AggregationTypeCount}}
+			return q
+		}(), query.ErrWindowWithAggregations.Code},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := query.NewQueryHelper(tc.query, nil, nil, nil)
+			require.Error(t, err)
+			var sysErr *common.SystemError
+			for e := error(err); e != nil; e = errors.Unwrap(e) {
+				if errors.As(e, &sysErr) && sysErr.Code == tc.code {
+					return
+				}
+			}
+			t.Errorf("expected error code %s, got %v", tc.code, err)
+		})
+	}
+}
+
+func TestWindowExpression_JSON(t *testing.T) {
+	q := query.NewQueryBuilder().From("scores").Select().
+		AddWindow("previous", query.WindowFunctionLag, "score").PartitionBy("team").OrderBy("player", query.SortDirectionAsc).Offset(2).Default(0).End().
+		AddWindow("moving", query.WindowFunctionAvg, "score").OrderBy("player", query.SortDirectionAsc).Rows(query.Preceding(2), query.CurrentRow()).End().
+		End().Build()
+
+	data, err := json.Marshal(q.Projection)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"window"`)
+
+	var decoded query.ProjectionConfiguration
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded.Windows(), 2)
+	assert.Equal(t, q.Projection.Computed[1].WindowExpression, decoded.Windows()[1])
+
+	lag := decoded.Windows()[0]
+	assert.Equal(t, query.WindowFunctionLag, lag.Function)
+	assert.Equal(t, []string{"team"}, lag.PartitionBy)
+	assert.Equal(t, 2, *lag.Offset)
+	require.NotNil(t, lag.Default)
+	assert.Equal(t, 0.0, *lag.Default.NumberVal)
+}
+
+func TestSchemaFromQuery_Windows(t *testing.T) {
+	scores := &definition.Schema{
+		Version: common.MustNewVersion("1.0.0"),
+		BaseSchema: definition.BaseSchema{
+			Name: "scores",
+			Fields: map[definition.FieldId]definition.Field{
+				"player": {Name: "player", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+				"score":  {Name: "score", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeInteger}},
+				"time":   {Name: "time", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeNumber}},
+			},
+		},
+	}
+
+	q := query.NewQueryBuilder().From("scores").Schema(scores).Select().
+		AddWindow("position", query.WindowFunctionRowNumber, "").OrderBy("score", query.SortDirectionDesc).End().
+		AddWindow("total", query.WindowFunctionSum, "score").End().
+		AddWindow("total_time", query.WindowFunctionSum, "time").End().
+		AddWindow("average", query.WindowFunctionAvg, "score").End().
+		AddWindow("previous", query.WindowFunctionLag, "player").End().
+		End().Build()
+
+	resultSchema, err := query.SchemaFromQuery(&q, nil)
+	require.NoError(t, err)
+	assert.NotSame(t, scores, resultSchema, "the target schema is not modified")
+	assert.Len(t, resultSchema.Fields, 8)
+
+	expected := map[string]definition.FieldType{
+		"position":   definition.FieldTypeInteger,
+		"total":      definition.FieldTypeInteger,
+		"total_time": definition.FieldTypeNumber,
+		"average":    definition.FieldTypeNumber,
+		"previous":   definition.FieldTypeString,
+	}
+	for name, fieldType := range expected {
+		_, field := resultSchema.FindField(name)
+		require.NotNil(t, field, name)
+		assert.Equal(t, fieldType, field.Type, name)
+	}
+}
@@ -0,0 +1,97 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/data"
+	"github.com/asaidimu/go-anansi/v8/core/document"
+	"github.com/asaidimu/go-anansi/v8/core/encoding/binary"
+	"github.com/asaidimu/go-anansi/v8/core/query"
+	"github.com/asaidimu/go-anansi/v8/core/query/native"
+	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
+	sqlite "github.com/asaidimu/go-anansi/v8/sqlite/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const binaryUsersSchemaJSON = `{
+  "version": "1.0.0",
+  "name": "users",
+  "fields": {
+    "id":      { "name": "id",      "type": "string" },
+    "profile": { "name": "profile", "type": "object", "schema": { "id": "profile" } }
+  },
+  "schemas": {
+    "profile": {
+      "name": "profile",
+      "fields": {
+        "city":   { "name": "city",   "type": "string" },
+        "visits": { "name": "visits", "type": "integer" }
+      }
+    }
+  }
+}`
+
+func TestBinaryDocuments_Builder(t *testing.T) {
+	schemaDef, err := definition.FromJSON([]byte(binaryUsersSchemaJSON))
+	require.NoError(t, err)
+	builder := sqlite.NewSQLiteFactory(nil, sqlite.WithBinaryDocuments())
+	profile := map[string]any{"city": "Lagos", "visits": int64(3)}
+
+	requireBinaryParam := func(t *testing.T, param any) {
+		t.Helper()
+		packet, ok := param.([]byte)
+		require.True(t, ok, "expected a binary packet, got %T", param)
+		assert.True(t, binary.IsFieldPacket(packet))
+		got, err := document.DecodeBinaryField(schemaDef, "profile", packet)
+		require.NoError(t, err)
+		assert.Equal(t, profile, got)
+	}
+
+	t.Run("insert", func(t *testing.T) {
+		q := query.NewQueryBuilder().From("users").Schema(schemaDef).Build()
+		nq, err := builder.Build(&q, native.StmtInsert, map[string]any{"id": "u1", "profile": profile})
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "users" ("id", "profile") VALUES ($1, $2) RETURNING *;`, nq.Raw().SQL)
+		assert.Equal(t, "u1", nq.Raw().Params[0])
+		requireBinaryParam(t, nq.Raw().Params[1])
+	})
+
+	t.Run("whole-column update", func(t *testing.T) {
+		q := query.NewQueryBuilder().From("users").Schema(schemaDef).Where("id").Eq("u1").Build()
+		set, err := data.NewDocument(map[string]any{"profile": profile})
+		require.NoError(t, err)
+		nq, err := builder.Build(&q, native.StmtUpdate, map[string]any{"set": set})
+		require.NoError(t, err)
+		assert.Equal(t, `UPDATE "users" SET "profile" = $1 WHERE "id" = $2`, nq.Raw().SQL)
+		requireBinaryParam(t, nq.Raw().Params[0])
+	})
+
+	t.Run("nested paths", func(t *testing.T) {
+		q := query.NewQueryBuilder().From("users").Schema(schemaDef).Where("profile.city").Eq("Lagos").Build()
+		_, err := builder.Build(&q, native.StmtSelect, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), sqlite.ErrBinaryNestedPath.Code)
+
+		q = query.NewQueryBuilder().From("users").Schema(schemaDef).Where("id").Eq("u1").Build()
+		visits := query.NewQueryBuilder().Select().AddComputed("visits", "ADD",
+			&query.FieldReference{Field: "visits"}, 1).End().Build()
+		_, err = builder.Build(&q, native.StmtUpdate, map[string]any{
+			"compute": map[string]query.Query{"profile.visits": visits},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), sqlite.ErrBinaryNestedPath.Code)
+
+		q = query.NewQueryBuilder().From("users").Schema(schemaDef).Build()
+		_, err = builder.Build(&q, native.StmtCreateIndex, definition.Index{Fields: []definition.FieldName{"profile.city"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), sqlite.ErrBinaryNestedPath.Code)
+	})
+
+	t.Run("json storage is unchanged", func(t *testing.T) {
+		q := query.NewQueryBuilder().From("users").Schema(schemaDef).Where("profile.city").Eq("Lagos").Build()
+		nq, err := sqlite.NewSQLiteFactory(nil).Build(&q, native.StmtSelect, nil)
+		require.NoError(t, err)
+		assert.Contains(t, nq.Raw().SQL, `json_extract("profile", '$.city')`)
+	})
+}
@@ -2,6 +2,7 @@ package sqlite_test
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/asaidimu/go-anansi/v8/core/common"
@@ -242,6 +243,68 @@ func TestSelectWithInAndNinOperators(t *testing.T) {
 	assert.ElementsMatch(t, []any{"inactive", "pending"}, nqNotIn.Raw().Params)
 }
 
+func TestSelectWithArrayAndRegexOperators(t *testing.T) {
+	builder := sqlite.NewSQLiteFactory(nil)
+
+	orderSchema := &definition.Schema{
+		BaseSchema: definition.BaseSchema{
+			Fields: map[definition.FieldId]definition.Field{
+				"f1": {Name: "code", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+				"f2": {Name: "tags", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeArray}},
+				"f3": {Name: "meta", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeObject}},
+			},
+		},
+	}
+	lineItem := query.QueryFilter{Group: &query.FilterGroup{
+		Operator: common.LogicalAnd,
+		Conditions: []query.QueryFilter{
+			{Condition: &query.FilterCondition{Field: "sku", Operator: query.ComparisonOperatorEq, Value: query.NewFilterValue("pen")}},
+			{Condition: &query.FilterCondition{Field: "tags", Operator: query.ComparisonOperatorElemMatch, Value: query.NewFilterValue(query.QueryFilter{
+				Condition: &query.FilterCondition{Field: "", Operator: query.ComparisonOperatorIEq, Value: query.NewFilterValue("Blue")},
+			})}},
+		},
+	}}
+
+	testCases := []struct {
+		name          string
+		build         func(qb *query.QueryBuilder) *query.QueryBuilder
+		expectedWhere string
+		expectedArgs  []any
+	}{
+		{"regex", func(qb *query.QueryBuilder) *query.QueryBuilder { return qb.Where("code").Matches("^A") },
+			`"code" REGEXP $1`, []any{"^A"}},
+		{"ieq", func(qb *query.QueryBuilder) *query.QueryBuilder { return qb.Where("code").EqualFold("abc") },
+			`LOWER("code") = LOWER($1)`, []any{"abc"}},
+		{"between", func(qb *query.QueryBuilder) *query.QueryBuilder { return qb.Where("meta.total").Between(1, 5) },
+			`json_extract("meta", '$.total') BETWEEN $1 AND $2`, []any{1.0, 5.0}},
+		{"size", func(qb *query.QueryBuilder) *query.QueryBuilder { return qb.Where("tags").Size(2) },
+			`json_array_length("tags") = $1`, []any{2.0}},
+		{"all", func(qb *query.QueryBuilder) *query.QueryBuilder { return qb.Where("tags").All("a", "b") },
+			`(EXISTS (SELECT 1 FROM json_each("tags") AS "_elem0" WHERE "_elem0".value = $1) AND EXISTS (SELECT 1 FROM json_each("tags") AS "_elem0" WHERE "_elem0".value = $2))`,
+			[]any{"a", "b"}},
+		{"nested elem_match", func(qb *query.QueryBuilder) *query.QueryBuilder { return qb.Where("meta.items").ElemMatch(lineItem) },
+			`EXISTS (SELECT 1 FROM json_each(json_extract("meta", '$.items')) AS "_elem0" WHERE (CASE WHEN "_elem0".type = 'object' THEN json_extract("_elem0".value, '$.sku') END = $1 AND ` +
+				`EXISTS (SELECT 1 FROM json_each(CASE WHEN "_elem0".type = 'object' THEN json_extract("_elem0".value, '$.tags') END) AS "_elem1" WHERE LOWER("_elem1".value) = LOWER($2))))`,
+			[]any{"pen", "Blue"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := tc.build(query.NewQueryBuilder().From("orders").Schema(orderSchema)).Build()
+			nq, err := builder.Build(&q, native.StmtSelect, nil)
+			assert.NoError(t, err)
+			_, where, found := strings.Cut(nq.Raw().SQL, " WHERE ")
+			assert.True(t, found)
+			assert.Equal(t, tc.expectedWhere, where)
+			assert.Equal(t, tc.expectedArgs, nq.Raw().Params)
+		})
+	}
+
+	q := query.NewQueryBuilder().From("orders").Schema(orderSchema).Where("tags").Custom(query.ComparisonOperatorBetween, 1).Build()
+	_, err := builder.Build(&q, native.StmtSelect, nil)
+	assert.Error(t, err, "between needs two bounds")
+}
+
 func TestSelectWithMultipleJoins(t *testing.T) {
 	builder := sqlite.NewSQLiteFactory(nil)
 
@@ -476,6 +539,31 @@ func TestSelectWithAggregations(t *testing.T) {
 	assert.Equal(t, []any{1000.0}, nq.Raw().Params)
 }
 
+func TestSQLiteFactory_SelectTimeBucketedAggregations(t *testing.T) {
+	builder := sqlite.NewSQLiteFactory(nil)
+
+	q := query.NewQueryBuilder().
+		From("events").Schema(&definition.Schema{}).
+		CountDistinct("user_id", "visitors").
+		GroupConcat("page", " | ", "pages").
+		GroupByTime("created_at", query.TimeBucketWeek, "week").
+		Type(query.AggregationTypeCount).
+		Field("*").
+		Alias("events").
+		AddGroup("country").
+		End().
+		Build()
+
+	nq, err := builder.Build(&q, native.StmtSelect, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, nq)
+
+	week := `strftime('%Y-%m-%dT00:00:00Z', CASE WHEN typeof("created_at") IN ('integer', 'real') THEN datetime("created_at", 'unixepoch') ELSE "created_at" END, 'weekday 0', '-6 days')`
+	expectedSQL := `SELECT ` + week + ` AS "week", COUNT(DISTINCT "user_id") AS visitors, GROUP_CONCAT("page", $1) AS pages, COUNT(*) AS events FROM "events" GROUP BY "country", ` + week
+	assert.Equal(t, expectedSQL, nq.Raw().SQL)
+	assert.Equal(t, []any{" | "}, nq.Raw().Params)
+}
+
 func TestSQLiteFactory_SelectImplicitFields(t *testing.T) {
 	builder := sqlite.NewSQLiteFactory(nil)
 
@@ -3,6 +3,7 @@ package sqlite_test
 import (
 	"testing"
 
+	"github.com/asaidimu/go-anansi/v8/core/data"
 	"github.com/asaidimu/go-anansi/v8/core/query"
 	"github.com/asaidimu/go-anansi/v8/core/query/native"
 	"github.com/asaidimu/go-anansi/v8/core/schema/definition"
@@ -82,3 +83,78 @@ func TestUpdateQueryTranslation(t *testing.T) {
 		assert.Equal(t, []any{1.0, "123"}, params)
 	})
 }
+
+func TestUpdateOperatorTranslation(t *testing.T) {
+	factory := sqlite_query.NewSQLiteFactory(nil)
+
+	schemaDef := &definition.Schema{
+		BaseSchema: definition.BaseSchema{
+			Name: "posts",
+			Fields: map[definition.FieldId]definition.Field{
+				"f1": {Name: "id", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+				"f2": {Name: "views", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeInteger}},
+				"f3": {Name: "tags", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeArray}},
+				"f4": {Name: "stats", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeObject}},
+				"f5": {Name: "note", FieldProperties: definition.FieldProperties{Type: definition.FieldTypeString}},
+			},
+		},
+	}
+
+	build := func(t *testing.T, operators ...query.UpdateOperator) (string, []any) {
+		t.Helper()
+		q := query.NewQueryBuilder().From("posts").Schema(schemaDef).Where("id").Eq("p1").Build()
+		node, err := factory.Build(&q, native.StmtUpdate, map[string]any{"operators": operators})
+		require.NoError(t, err)
+		raw := node.Raw()
+		return raw.SQL, raw.Params
+	}
+
+	t.Run("arithmetic and extremes on columns", func(t *testing.T) {
+		sql, params := build(t,
+			query.UpdateOperator{Type: query.UpdateOperatorIncrement, Field: "views", Value: 2},
+			query.UpdateOperator{Type: query.UpdateOperatorUnset, Field: "note"},
+		)
+		assert.Equal(t, `UPDATE "posts" SET "note" = NULL, "views" = COALESCE("views", 0) + $1 WHERE "id" = $2`, sql)
+		assert.Equal(t, []any{2, "p1"}, params)
+	})
+
+	t.Run("nested paths share one json_set", func(t *testing.T) {
+		sql, params := build(t,
+			query.UpdateOperator{Type: query.UpdateOperatorMax, Field: "stats.best", Value: 10},
+			query.UpdateOperator{Type: query.UpdateOperatorSet, Field: "stats.label", Value: "hot"},
+			query.UpdateOperator{Type: query.UpdateOperatorUnset, Field: "stats.draft"},
+		)
+		assert.Equal(t, `UPDATE "posts" SET "stats" = json_remove(json_set(COALESCE("stats", '{}'), `+
+			`'$.best', max(COALESCE(json_extract("stats", '$.best'), $1), $1), '$.label', json($2)), '$.draft') WHERE "id" = $3`, sql)
+		assert.Equal(t, []any{10, `"hot"`, "p1"}, params)
+	})
+
+	t.Run("array operators", func(t *testing.T) {
+		sql, params := build(t, query.UpdateOperator{Type: query.UpdateOperatorPush, Field: "tags", Value: map[string]any{"k": 1}})
+		assert.Equal(t, `UPDATE "posts" SET "tags" = json_insert(COALESCE("tags", '[]'), '$[#]', json($1)) WHERE "id" = $2`, sql)
+		assert.Equal(t, []any{`{"k":1}`, "p1"}, params)
+
+		sql, _ = build(t, query.UpdateOperator{Type: query.UpdateOperatorPop, Field: "tags", Value: -1})
+		assert.Equal(t, `UPDATE "posts" SET "tags" = json_remove("tags", '$[0]') WHERE "id" = $1`, sql)
+
+		sql, params = build(t, query.UpdateOperator{Type: query.UpdateOperatorAddToSet, Field: "tags", Value: "go"})
+		assert.Contains(t, sql, `CASE WHEN EXISTS (SELECT 1 FROM json_each("tags") WHERE `)
+		assert.Contains(t, sql, `THEN "tags" ELSE json_insert(COALESCE("tags", '[]'), '$[#]', json($1)) END`)
+		assert.Equal(t, []any{`"go"`, "p1"}, params)
+
+		sql, _ = build(t, query.UpdateOperator{Type: query.UpdateOperatorPull, Field: "stats.tags", Value: "go"})
+		assert.Contains(t, sql, `json_set(COALESCE("stats", '{}'), '$.tags', json(CASE WHEN json_extract("stats", '$.tags') IS NULL THEN NULL ELSE (SELECT json('[' || COALESCE(group_concat(`)
+	})
+
+	t.Run("operators alongside set", func(t *testing.T) {
+		q := query.NewQueryBuilder().From("posts").Schema(schemaDef).Where("id").Eq("p1").Build()
+		set := data.MustNewDocument(map[string]any{"note": "edited"})
+		node, err := factory.Build(&q, native.StmtUpdate, map[string]any{
+			"set":       set,
+			"operators": []query.UpdateOperator{{Type: query.UpdateOperatorDecrement, Field: "views", Value: 1}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `UPDATE "posts" SET "note" = $1, "views" = COALESCE("views", 0) - $2 WHERE "id" = $3`, node.Raw().SQL)
+		assert.Equal(t, []any{"edited", 1, "p1"}, node.Raw().Params)
+	})
+}
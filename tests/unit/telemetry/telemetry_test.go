@@ -0,0 +1,93 @@
+package telemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/asaidimu/go-anansi/v8/core/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// published numbers the expvar names the tests publish, which stay taken for
+// the life of the process, so repeated runs (-count) each get a fresh one.
+var published atomic.Int64
+
+func TestOperation_DisabledTelemetryRecordsNothing(t *testing.T) {
+	ctx := context.Background()
+	next, op := telemetry.Telemetry{}.Start(ctx, telemetry.FamilyQuery, "execute")
+	assert.Nil(t, op)
+	assert.Equal(t, ctx, next)
+	// A nil operation is safe to use.
+	op.SetAttributes(telemetry.Bool("cached", true))
+	op.SetRows(3)
+	op.End(ctx, nil)
+}
+
+func TestRegistry_RecordsSpansAndMeasurements(t *testing.T) {
+	reg := telemetry.NewRegistry()
+	tel := reg.Telemetry()
+
+	ctx, parent := tel.Start(context.Background(), telemetry.FamilyCollection, "read",
+		telemetry.String(telemetry.KeyCollection, "orders"),
+		telemetry.String(telemetry.KeyTransaction, "tx-1"),
+	)
+	_, child := tel.Start(ctx, telemetry.FamilySQLite, "select")
+	child.SetRows(4)
+	child.End(ctx, nil)
+	parent.SetRows(2)
+	parent.End(ctx, errors.New("boom"))
+
+	spans := reg.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "anansi.sqlite.select", spans[0].Name)
+	assert.Equal(t, spans[1].ID, spans[0].ParentID)
+	assert.Equal(t, "anansi.collection.read", spans[1].Name)
+	assert.Equal(t, "tx-1", spans[1].Attributes[telemetry.KeyTransaction])
+	assert.EqualError(t, spans[1].Err, "boom")
+
+	failed := telemetry.Series("anansi.collection.count",
+		telemetry.String(telemetry.KeyOutcome, telemetry.OutcomeError),
+		telemetry.String(telemetry.KeyOperation, "read"),
+		telemetry.String(telemetry.KeyCollection, "orders"),
+	)
+	assert.Equal(t, "anansi.collection.count{anansi.collection=orders,anansi.operation=read,anansi.outcome=error}", failed)
+	snapshot := reg.Snapshot()
+	assert.Equal(t, int64(1), snapshot.Counters[failed])
+	assert.Equal(t, int64(4), snapshot.Counters["anansi.sqlite.rows{anansi.operation=select,anansi.outcome=ok}"])
+	assert.Equal(t, int64(1), snapshot.Histograms["anansi.sqlite.duration{anansi.operation=select,anansi.outcome=ok}"].Count)
+
+	name := fmt.Sprintf("anansi_test_registry_%d", published.Add(1))
+	reg.Publish(name)
+	var exposed telemetry.Snapshot
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &exposed))
+	assert.Equal(t, snapshot, exposed)
+
+	reg.Reset()
+	assert.Empty(t, reg.Spans())
+	assert.Empty(t, reg.Snapshot().Counters)
+}
+
+func TestLog_WritesSpansAndMeasurements(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	tel := telemetry.Log(zap.New(core))
+
+	ctx, op := tel.Start(context.Background(), telemetry.FamilyMigration, "migrate", telemetry.String(telemetry.KeyCollection, "orders"))
+	op.SetRows(7)
+	op.End(ctx, nil)
+
+	entries := logs.FilterMessage("span finished").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "anansi.migration.migrate", entries[0].ContextMap()["span"])
+	assert.Equal(t, int64(7), entries[0].ContextMap()[telemetry.KeyRows])
+	assert.Equal(t, 2, logs.FilterMessage("counter").Len())
+	assert.Equal(t, 1, logs.FilterMessage("histogram").Len())
+}